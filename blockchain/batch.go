@@ -0,0 +1,43 @@
+package blockchain
+
+import (
+	"github.com/0xPolygon/minimal/blockchain/storage"
+)
+
+// blockBatch buffers the writes of a block to the DB together with the
+// updates of the caches and the head, which are applied once the writes are committed
+type blockBatch struct {
+	storage.BatchWriter
+
+	onCommit []func()
+}
+
+// newBatch creates a batch of writes to the DB
+func (b *Blockchain) newBatch() *blockBatch {
+	return &blockBatch{
+		BatchWriter: b.db.NewBatch(),
+		onCommit:    []func(){},
+	}
+}
+
+// afterCommit registers an update to apply once the writes of the batch are committed
+func (w *blockBatch) afterCommit(fn func()) {
+	w.onCommit = append(w.onCommit, fn)
+}
+
+// commitBatch writes the batch to the DB. The canonical lock is held until the
+// caches are updated so that the reads of the canonical chain do not cache stale hashes
+func (b *Blockchain) commitBatch(w *blockBatch) error {
+	b.canonicalLock.Lock()
+	defer b.canonicalLock.Unlock()
+
+	b.metrics.batchSize.Observe(float64(w.Size()))
+	if err := w.Write(); err != nil {
+		return err
+	}
+	for _, fn := range w.onCommit {
+		fn()
+	}
+
+	return nil
+}
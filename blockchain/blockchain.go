@@ -1,11 +1,13 @@
 package blockchain
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"path/filepath"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/0xPolygon/minimal/blockchain/storage"
 	"github.com/0xPolygon/minimal/blockchain/storage/leveldb"
@@ -32,6 +34,11 @@ type Blockchain struct {
 
 	headersCache    *lru.Cache // LRU cache for the headers
 	difficultyCache *lru.Cache // LRU cache for the difficulty
+	canonicalCache  *lru.Cache // LRU cache for the canonical hashes by number
+	bodiesCache     *lru.Cache // LRU cache for the bodies
+	receiptsCache   *lru.Cache // LRU cache for the receipts
+
+	canonicalLock sync.RWMutex // Keeps the canonical cache in sync with the DB
 
 	currentHeader     atomic.Value // The current header
 	currentDifficulty atomic.Value // The current difficulty
@@ -43,6 +50,19 @@ type Blockchain struct {
 	averageGasPriceCount *big.Int // Param used in the avg. gas price calculation
 
 	agpMux sync.Mutex // Mutex for the averageGasPrice calculation
+
+	freezeThreshold uint64 // Number of recent blocks that are not moved to the ancient store
+
+	bloomSections uint64     // Number of sections indexed in the bloom bits (atomic)
+	bloomLock     sync.Mutex // Mutex for the updates of the indexed sections
+
+	pendingBlock *types.Block // Block being built on top of the head by the proposer
+	pendingLock  sync.Mutex   // Mutex for the pending block
+
+	metrics *Metrics // Prometheus metrics
+
+	stopCh chan struct{}  // Stops the background tasks
+	wg     sync.WaitGroup // Waits for the background tasks to stop
 }
 
 type Verifier interface {
@@ -53,6 +73,10 @@ type Executor interface {
 	ProcessBlock(parentRoot types.Hash, block *types.Block) (*state.BlockResult, error)
 }
 
+// ErrInvalidBlock is matched with errors.Is by the errors of WriteBlocks caused by an
+// invalid block, as opposed to the local failures (i.e. of the storage) writing it
+var ErrInvalidBlock = state.ErrInvalidBlock
+
 // UpdateGasPriceAvg Updates the rolling average value of the gas price
 func (b *Blockchain) UpdateGasPriceAvg(newValue *big.Int) {
 	b.agpMux.Lock()
@@ -72,7 +96,8 @@ func (b *Blockchain) GetAvgGasPrice() *big.Int {
 	return b.averageGasPrice
 }
 
-// NewBlockchain creates a new blockchain object
+// NewBlockchain creates a new blockchain object. The chain is stored
+// in memory if the data dir is empty and in leveldb otherwise
 func NewBlockchain(
 	logger hclog.Logger,
 	dataDir string,
@@ -81,14 +106,6 @@ func NewBlockchain(
 	executor Executor,
 ) (*Blockchain, error) {
 
-	b := &Blockchain{
-		logger:    logger.Named("blockchain"),
-		config:    config,
-		consensus: consensus,
-		executor:  executor,
-		stream:    &eventStream{},
-	}
-
 	var storage storage.Storage
 	var err error
 	if dataDir == "" {
@@ -104,13 +121,34 @@ func NewBlockchain(
 		}
 	}
 
-	b.db = storage
+	return NewBlockchainWithStorage(logger, storage, config, consensus, executor)
+}
 
-	b.headersCache, _ = lru.New(100)
-	b.difficultyCache, _ = lru.New(100)
+// NewBlockchainWithStorage creates a new blockchain object on top of the given storage
+func NewBlockchainWithStorage(
+	logger hclog.Logger,
+	db storage.Storage,
+	config *chain.Chain,
+	consensus Verifier,
+	executor Executor,
+) (*Blockchain, error) {
 
-	// Push the initial event to the stream
-	b.stream.push(&Event{})
+	b := &Blockchain{
+		logger:    logger.Named("blockchain"),
+		config:    config,
+		consensus: consensus,
+		executor:  executor,
+		stream:    newEventStream(),
+		db:        db,
+		stopCh:    make(chan struct{}),
+	}
+	b.metrics = newMetrics(b)
+
+	if err := b.SetCacheConfig(DefaultCacheConfig()); err != nil {
+		return nil, err
+	}
+
+	b.bloomSections, _ = db.ReadBloomSections()
 
 	if _, ok := consensus.(*MockVerifier); ok {
 		// if we are using mock consensus we can compute right away the genesis since
@@ -162,12 +200,19 @@ func (b *Blockchain) ComputeGenesis() error {
 			header.Number,
 		)
 
+		if err := b.checkForkSchedule(header.Number); err != nil {
+			return err
+		}
+
 		b.setCurrentHeader(header, diff)
 	} else {
 		// empty storage, write the genesis
 		if err := b.writeGenesis(b.config.Genesis); err != nil {
 			return err
 		}
+		if err := b.checkForkSchedule(0); err != nil {
+			return err
+		}
 	}
 
 	b.logger.Info("genesis", "hash", b.config.Genesis.Hash())
@@ -175,11 +220,78 @@ func (b *Blockchain) ComputeGenesis() error {
 	return nil
 }
 
+// chainForks returns the forks of the chain config
+func (b *Blockchain) chainForks() *chain.Forks {
+	if b.config.Params == nil || b.config.Params.Forks == nil {
+		return &chain.Forks{}
+	}
+	return b.config.Params.Forks
+}
+
+// checkForkSchedule checks that the forks of the chain config do not change the rules of
+// the blocks up to the head, which were processed with the forks stored in the DB, and then
+// stores them. New forks can be scheduled in a running chain after the head
+func (b *Blockchain) checkForkSchedule(head uint64) error {
+	forks := b.chainForks()
+
+	if data, ok := b.db.ReadForkSchedule(); ok {
+		stored := &chain.Forks{}
+		if err := json.Unmarshal(data, stored); err != nil {
+			return fmt.Errorf("failed to decode the stored fork schedule: %v", err)
+		}
+		if err := stored.CheckCompatible(forks, head); err != nil {
+			return fmt.Errorf("the fork schedule is not compatible with the chain: %v", err)
+		}
+	}
+
+	data, err := json.Marshal(forks)
+	if err != nil {
+		return err
+	}
+	if err := b.db.WriteForkSchedule(data); err != nil {
+		return err
+	}
+
+	for _, fork := range forks.Pending(head) {
+		b.logger.Info("Fork scheduled", "name", fork.Name, "block", fork.Block)
+	}
+	return nil
+}
+
 // SetConsensus sets the consensus
 func (b *Blockchain) SetConsensus(c Verifier) {
 	b.consensus = c
 }
 
+// SetFreezeThreshold sets the number of recent blocks kept in the database. The older
+// canonical blocks are moved to the ancient store if the storage has one. Disabled if 0
+func (b *Blockchain) SetFreezeThreshold(threshold uint64) {
+	b.freezeThreshold = threshold
+}
+
+// freeze moves the canonical blocks older than the freeze threshold to the ancient store
+func (b *Blockchain) freeze() {
+	db, ok := b.db.(storage.AncientStorage)
+	if !ok || b.freezeThreshold == 0 {
+		return
+	}
+
+	head := b.Header().Number
+	if head <= b.freezeThreshold {
+		return
+	}
+	limit := head - b.freezeThreshold
+	if limit <= db.Ancients() {
+		return
+	}
+
+	if err := db.Freeze(limit); err != nil {
+		b.logger.Error("failed to freeze blocks", "limit", limit, "err", err)
+		return
+	}
+	b.logger.Debug("freeze blocks", "ancients", db.Ancients())
+}
+
 // setCurrentHeader sets the current header
 func (b *Blockchain) setCurrentHeader(h *types.Header, diff *big.Int) {
 	// Update the header (atomic)
@@ -244,12 +356,17 @@ func (b *Blockchain) writeGenesisImpl(header *types.Header) error {
 	b.genesis = header.Hash
 
 	// Update the DB
-	if err := b.db.WriteHeader(header); err != nil {
+	batch := b.newBatch()
+	if err := batch.WriteHeader(header); err != nil {
 		return err
 	}
 
 	// Advance the head
-	if _, err := b.advanceHead(header); err != nil {
+	if _, err := b.advanceHead(batch, header); err != nil {
+		return err
+	}
+
+	if err := b.commitBatch(batch); err != nil {
 		return err
 	}
 
@@ -281,14 +398,17 @@ func (b *Blockchain) GetTD(hash types.Hash) (*big.Int, bool) {
 }
 
 // writeCanonicalHeader writes the new header
-func (b *Blockchain) writeCanonicalHeader(event *Event, h *types.Header) error {
+func (b *Blockchain) writeCanonicalHeader(batch *blockBatch, event *Event, h *types.Header, body *types.Body) error {
 	td, ok := b.readDiff(h.ParentHash)
 	if !ok {
 		return fmt.Errorf("parent difficulty not found")
 	}
 
 	diff := big.NewInt(1).Add(td, new(big.Int).SetUint64(h.Difficulty))
-	if err := b.db.WriteCanonicalHeader(h, diff); err != nil {
+	if err := b.writeCanonicalTxLookups(batch, h, body); err != nil {
+		return err
+	}
+	if err := batch.WriteCanonicalHeader(h, diff); err != nil {
 		return err
 	}
 
@@ -296,25 +416,28 @@ func (b *Blockchain) writeCanonicalHeader(event *Event, h *types.Header) error {
 	event.AddNewHeader(h)
 	event.SetDifficulty(diff)
 
-	b.setCurrentHeader(h, diff)
+	batch.afterCommit(func() {
+		b.canonicalCache.Add(h.Number, h.Hash)
+		b.setCurrentHeader(h, diff)
+	})
 
 	return nil
 }
 
 // advanceHead Sets the passed in header as the new head of the chain
-func (b *Blockchain) advanceHead(newHeader *types.Header) (*big.Int, error) {
+func (b *Blockchain) advanceHead(batch *blockBatch, newHeader *types.Header) (*big.Int, error) {
 	// Write the current head hash into storage
-	if err := b.db.WriteHeadHash(newHeader.Hash); err != nil {
+	if err := batch.WriteHeadHash(newHeader.Hash); err != nil {
 		return nil, err
 	}
 
 	// Write the current head number into storage
-	if err := b.db.WriteHeadNumber(newHeader.Number); err != nil {
+	if err := batch.WriteHeadNumber(newHeader.Number); err != nil {
 		return nil, err
 	}
 
 	// Matches the current head number with the current hash
-	if err := b.db.WriteCanonicalHash(newHeader.Number, newHeader.Hash); err != nil {
+	if err := b.writeCanonicalHash(batch, newHeader.Number, newHeader.Hash); err != nil {
 		return nil, err
 	}
 
@@ -330,19 +453,31 @@ func (b *Blockchain) advanceHead(newHeader *types.Header) (*big.Int, error) {
 
 	// Calculate the new difficulty
 	diff := big.NewInt(1).Add(currentDiff, new(big.Int).SetUint64(newHeader.Difficulty))
-	if err := b.db.WriteDiff(newHeader.Hash, diff); err != nil {
+	if err := batch.WriteDiff(newHeader.Hash, diff); err != nil {
 		return nil, err
 	}
 
-	// Update the blockchain reference
-	b.setCurrentHeader(newHeader, diff)
+	// Update the blockchain reference once the head is written
+	batch.afterCommit(func() {
+		b.setCurrentHeader(newHeader, diff)
+	})
 
 	return diff, nil
 }
 
 // GetReceiptsByHash returns the receipts by their hash
 func (b *Blockchain) GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error) {
-	return b.db.ReadReceipts(hash)
+	if receipts, ok := b.receiptsCache.Get(hash); ok {
+		return receipts.([]*types.Receipt), nil
+	}
+
+	receipts, err := b.db.ReadReceipts(hash)
+	if err != nil {
+		return nil, err
+	}
+	b.receiptsCache.Add(hash, receipts)
+
+	return receipts, nil
 }
 
 // GetBodyByHash returns the body by their hash
@@ -379,12 +514,17 @@ func (b *Blockchain) readHeader(hash types.Hash) (*types.Header, bool) {
 
 // readBody reads the block's body, using the block hash
 func (b *Blockchain) readBody(hash types.Hash) (*types.Body, bool) {
+	if body, ok := b.bodiesCache.Get(hash); ok {
+		return body.(*types.Body), true
+	}
+
 	bb, err := b.db.ReadBody(hash)
 	if err != nil {
 		b.logger.Error("failed to read body", "err", err)
 
 		return nil, false
 	}
+	b.bodiesCache.Add(hash, bb)
 
 	return bb, true
 }
@@ -410,9 +550,47 @@ func (b *Blockchain) readDiff(headerHash types.Hash) (*big.Int, bool) {
 	return dbDifficulty, true
 }
 
+// readCanonicalHash returns the hash of the canonical block with the given number.
+// The numbers above the head might still point to the blocks of a longer chain
+// that was reorged out by a heavier one
+func (b *Blockchain) readCanonicalHash(n uint64) (types.Hash, bool) {
+	if head, ok := b.currentHeader.Load().(*types.Header); ok && n > head.Number {
+		return types.Hash{}, false
+	}
+
+	if hash, ok := b.canonicalCache.Get(n); ok {
+		return hash.(types.Hash), true
+	}
+
+	// the lock prevents a reorg from writing the hash between the read and the
+	// update of the cache, which would leave the cache with the old hash
+	b.canonicalLock.RLock()
+	defer b.canonicalLock.RUnlock()
+
+	hash, ok := b.db.ReadCanonicalHash(n)
+	if !ok {
+		return types.Hash{}, false
+	}
+	b.canonicalCache.Add(n, hash)
+
+	return hash, true
+}
+
+// writeCanonicalHash writes the hash of the canonical block with the given number
+func (b *Blockchain) writeCanonicalHash(batch *blockBatch, n uint64, hash types.Hash) error {
+	if err := batch.WriteCanonicalHash(n, hash); err != nil {
+		return err
+	}
+	batch.afterCommit(func() {
+		b.canonicalCache.Add(n, hash)
+	})
+
+	return nil
+}
+
 // GetHeaderByNumber returns the header using the block number
 func (b *Blockchain) GetHeaderByNumber(n uint64) (*types.Header, bool) {
-	hash, ok := b.db.ReadCanonicalHash(n)
+	hash, ok := b.readCanonicalHash(n)
 	if !ok {
 		return nil, false
 	}
@@ -459,7 +637,11 @@ func (b *Blockchain) WriteHeadersWithBodies(headers []*types.Header) error {
 	// Write the actual headers
 	for _, h := range headers {
 		event := &Event{}
-		if err := b.writeHeaderImpl(event, h); err != nil {
+		batch := b.newBatch()
+		if err := b.writeHeaderImpl(batch, event, h, nil); err != nil {
+			return err
+		}
+		if err := b.commitBatch(batch); err != nil {
 			return err
 		}
 
@@ -520,75 +702,86 @@ func (b *Blockchain) WriteBlocks(blocks []*types.Block) error {
 
 		// Check the parent numbers
 		if block.Number()-1 != parent.Number {
-			return fmt.Errorf(
+			return state.InvalidBlock(fmt.Errorf(
 				"number sequence not correct at %d, %d and %d",
 				i,
 				block.Number(),
 				parent.Number,
-			)
+			))
 		}
 
 		// Check the parent hash
 		if block.ParentHash() != parent.Hash {
-			return fmt.Errorf("parent hash not correct")
+			return state.InvalidBlock(fmt.Errorf("parent hash not correct"))
 		}
 
 		// Verify the header
 		if err := b.consensus.VerifyHeader(parent, block.Header); err != nil {
-			return fmt.Errorf("failed to verify the header: %v", err)
+			return state.InvalidBlock(fmt.Errorf("failed to verify the header: %v", err))
 		}
 
 		// Verify body data
 		if hash := buildroot.CalculateUncleRoot(block.Uncles);
 			hash != block.Header.Sha3Uncles {
 
-			return fmt.Errorf(
+			return state.InvalidBlock(fmt.Errorf(
 				"uncle root hash mismatch: have %s, want %s",
 				hash,
 				block.Header.Sha3Uncles,
-			)
+			))
 		}
 
 		// TODO, the wrapper around transactions
 		if hash := buildroot.CalculateTransactionsRoot(block.Transactions);
 			hash != block.Header.TxRoot {
 
-			return fmt.Errorf(
+			return state.InvalidBlock(fmt.Errorf(
 				"transaction root hash mismatch: have %s, want %s",
 				hash,
 				block.Header.TxRoot,
-			)
+			))
 		}
 
 		parent = block.Header
 	}
 
-	// Checks are passed, write the chain
+	// Checks are passed, write the chain. The body, the receipts, the header and
+	// the indexes of every block are committed in a single batch, so that a crash
+	// never leaves a canonical block without its data
 	for indx, block := range blocks {
 		header := block.Header
 
-		if err := b.writeBody(block); err != nil {
-			return err
-		}
 		// Process and validate the block
 		res, err := b.processBlock(blocks[indx])
 		if err != nil {
 			return err
 		}
 
+		batch := b.newBatch()
+
+		// the senders of the txns are recovered by the executor and stored with the
+		// body, so the new blocks served from the cache are complete
+		body := block.Body()
+		if err := batch.WriteBody(header.Hash, body); err != nil {
+			return err
+		}
+		batch.afterCommit(func() {
+			b.bodiesCache.Add(header.Hash, body)
+		})
+		if err := batch.WriteReceipts(header.Hash, res.Receipts); err != nil {
+			return err
+		}
+
 		// Write the header to the chain
 		evnt := &Event{}
-		if err := b.writeHeaderImpl(evnt, header); err != nil {
+		if err := b.writeHeaderImpl(batch, evnt, header, body); err != nil {
 			return err
 		}
-		b.dispatchEvent(evnt)
-
-		// write the receipts, do it only after the header has been written.
-		// Otherwise, a client might ask for a header once the receipt is valid
-		// but before it is written into the storage
-		if err := b.db.WriteReceipts(block.Hash(), res.Receipts); err != nil {
+		if err := b.commitBatch(batch); err != nil {
 			return err
 		}
+		b.dispatchEvent(evnt)
+		b.metrics.blocksImported.Inc()
 
 		// Update the average gas price
 		b.UpdateGasPriceAvg(new(big.Int).SetUint64(header.GasUsed))
@@ -596,34 +789,18 @@ func (b *Blockchain) WriteBlocks(blocks []*types.Block) error {
 
 	b.logger.Info("new head", "hash", b.Header().Hash, "number", b.Header().Number)
 
+	b.freeze()
+
 	return nil
 }
 
-// writeBody writes the block body to the DB.
-// Additionally, it also updates the txn lookup, for txnHash -> block lookups
+// writeBody writes the block body to the DB. The txn lookups are
+// written once the block is part of the canonical chain
 func (b *Blockchain) writeBody(block *types.Block) error {
 	body := block.Body()
 
 	// Write the full body (txns + receipts)
-	if err := b.db.WriteBody(block.Header.Hash, body); err != nil {
-		return err
-	}
-
-	// Write txn lookups (txHash -> block)
-	for _, txn := range block.Transactions {
-		if err := b.db.WriteTxLookup(txn.Hash, block.Hash()); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-// ReadTxLookup returns the block hash using the transaction hash
-func (b *Blockchain) ReadTxLookup(hash types.Hash) (types.Hash, bool) {
-	v, ok := b.db.ReadTxLookup(hash)
-
-	return v, ok
+	return b.db.WriteBody(block.Header.Hash, body)
 }
 
 // processBlock Processes the block, and does validation
@@ -636,28 +813,30 @@ func (b *Blockchain) processBlock(block *types.Block) (*state.BlockResult, error
 		return nil, fmt.Errorf("unknown ancestor")
 	}
 
+	start := time.Now()
 	result, err := b.executor.ProcessBlock(parent.StateRoot, block)
 	if err != nil {
 		return nil, err
 	}
+	b.metrics.blockExecution.Observe(time.Since(start).Seconds())
 
 	receipts := result.Receipts
 	if len(receipts) != len(block.Transactions) {
-		return nil, fmt.Errorf("bad size of receipts and transactions")
+		return nil, state.InvalidBlock(fmt.Errorf("bad size of receipts and transactions"))
 	}
 
 	// Validate the fields
 	if result.Root != header.StateRoot {
-		return nil, fmt.Errorf("invalid merkle root")
+		return nil, state.InvalidBlock(fmt.Errorf("invalid merkle root"))
 	}
 
 	if result.TotalGas != header.GasUsed {
-		return nil, fmt.Errorf("gas used is different")
+		return nil, state.InvalidBlock(fmt.Errorf("gas used is different"))
 	}
 
 	receiptSha := buildroot.CalculateReceiptsRoot(result.Receipts)
 	if receiptSha != header.ReceiptsRoot {
-		return nil, fmt.Errorf("invalid receipts root")
+		return nil, state.InvalidBlock(fmt.Errorf("invalid receipts root"))
 	}
 
 	return result, nil
@@ -702,11 +881,16 @@ func (b *Blockchain) GetHashByNumber(blockNumber uint64) types.Hash {
 // WriteBlock writes a block of data
 func (b *Blockchain) WriteBlock(block *types.Block) error {
 	evnt := &Event{}
-	if err := b.writeHeaderImpl(evnt, block.Header); err != nil {
+	batch := b.newBatch()
+	if err := b.writeHeaderImpl(batch, evnt, block.Header, nil); err != nil {
+		return err
+	}
+	if err := b.commitBatch(batch); err != nil {
 		return err
 	}
 
 	b.dispatchEvent(evnt)
+	b.freeze()
 
 	return nil
 }
@@ -716,17 +900,18 @@ func (b *Blockchain) dispatchEvent(evnt *Event) {
 	b.stream.push(evnt)
 }
 
-// writeHeaderImpl writes a block and the data, assumes the genesis is already set
-func (b *Blockchain) writeHeaderImpl(evnt *Event, header *types.Header) error {
+// writeHeaderImpl writes a block and the data, assumes the genesis is already set.
+// The body is nil if it is not part of the batch and it is read from the DB
+func (b *Blockchain) writeHeaderImpl(batch *blockBatch, evnt *Event, header *types.Header, body *types.Body) error {
 	head := b.Header()
 
 	// Write the data
 	if header.ParentHash == head.Hash {
 		// Fast path to save the new canonical header
-		return b.writeCanonicalHeader(evnt, header)
+		return b.writeCanonicalHeader(batch, evnt, header, body)
 	}
 
-	if err := b.db.WriteHeader(header); err != nil {
+	if err := batch.WriteHeader(header); err != nil {
 		return err
 	}
 
@@ -745,23 +930,19 @@ func (b *Blockchain) writeHeaderImpl(evnt *Event, header *types.Header) error {
 	}
 
 	// Write the difficulty
-	if err := b.db.WriteDiff(
-		header.Hash,
-		big.NewInt(1).Add(
-			parentDiff,
-			new(big.Int).SetUint64(header.Difficulty),
-		),
-	); err != nil {
+	incomingDiff := big.NewInt(1).Add(parentDiff, new(big.Int).SetUint64(header.Difficulty))
+	if err := batch.WriteDiff(header.Hash, incomingDiff); err != nil {
 		return err
 	}
 
 	// Update the headers cache
-	b.headersCache.Add(header.Hash, header)
+	batch.afterCommit(func() {
+		b.headersCache.Add(header.Hash, header)
+	})
 
-	incomingDiff := big.NewInt(1).Add(parentDiff, new(big.Int).SetUint64(header.Difficulty))
 	if incomingDiff.Cmp(headerDiff) > 0 {
 		// new block has higher difficulty, reorg the chain
-		if err := b.handleReorg(evnt, head, header); err != nil {
+		if err := b.handleReorg(batch, evnt, head, header, body); err != nil {
 			return err
 		}
 	} else {
@@ -769,7 +950,7 @@ func (b *Blockchain) writeHeaderImpl(evnt *Event, header *types.Header) error {
 		evnt.AddOldHeader(header)
 		evnt.Type = EventFork
 
-		if err := b.writeFork(header); err != nil {
+		if err := b.writeForks(batch, header.Hash, header.ParentHash); err != nil {
 			return err
 		}
 	}
@@ -777,8 +958,9 @@ func (b *Blockchain) writeHeaderImpl(evnt *Event, header *types.Header) error {
 	return nil
 }
 
-// writeFork writes the new header forks to the DB
-func (b *Blockchain) writeFork(header *types.Header) error {
+// writeForks adds the head of a side chain to the forks and removes the given
+// hashes, which are either the previous head of the side chain or canonical blocks
+func (b *Blockchain) writeForks(batch *blockBatch, head types.Hash, remove ...types.Hash) error {
 	forks, err := b.db.ReadForks()
 	if err != nil {
 		if err == storage.ErrNotFound {
@@ -788,15 +970,24 @@ func (b *Blockchain) writeFork(header *types.Header) error {
 		}
 	}
 
+	isRemoved := func(fork types.Hash) bool {
+		for _, hash := range remove {
+			if fork == hash {
+				return true
+			}
+		}
+		return false
+	}
+
 	newForks := []types.Hash{}
 	for _, fork := range forks {
-		if fork != header.ParentHash {
+		if !isRemoved(fork) {
 			newForks = append(newForks, fork)
 		}
 	}
 
-	newForks = append(newForks, header.Hash)
-	if err := b.db.WriteForks(newForks); err != nil {
+	newForks = append(newForks, head)
+	if err := batch.WriteForks(newForks); err != nil {
 		return err
 	}
 
@@ -805,79 +996,114 @@ func (b *Blockchain) writeFork(header *types.Header) error {
 
 // handleReorg handles a reorganization event
 func (b *Blockchain) handleReorg(
+	batch *blockBatch,
 	evnt *Event,
 	oldHeader *types.Header,
 	newHeader *types.Header,
+	body *types.Body,
 ) error {
 	newChainHead := newHeader
 	oldChainHead := oldHeader
 
+	// the blocks of both chains after the common ancestor, from the newest to the oldest
 	oldChain := []*types.Header{}
 	newChain := []*types.Header{}
 
-	var ok bool
-
-	// Fill up the old headers array
-	for oldHeader.Number > newHeader.Number {
-		oldHeader, ok = b.readHeader(oldHeader.ParentHash)
+	parent := func(h *types.Header) (*types.Header, error) {
+		p, ok := b.readHeader(h.ParentHash)
 		if !ok {
-			return fmt.Errorf("header '%s' not found", oldHeader.ParentHash.String())
+			return nil, fmt.Errorf("header '%s' not found", h.ParentHash.String())
 		}
+		return p, nil
+	}
+
+	var err error
 
+	// Fill up the old headers array
+	for oldHeader.Number > newHeader.Number {
 		oldChain = append(oldChain, oldHeader)
+		if oldHeader, err = parent(oldHeader); err != nil {
+			return err
+		}
 	}
 
 	// Fill up the new headers array
 	for newHeader.Number > oldHeader.Number {
-		newHeader, ok = b.readHeader(newHeader.ParentHash)
-		if !ok {
-			return fmt.Errorf("header '%s' not found", newHeader.ParentHash.String())
-		}
-
 		newChain = append(newChain, newHeader)
+		if newHeader, err = parent(newHeader); err != nil {
+			return err
+		}
 	}
 
+	// Walk back both chains until the common ancestor
 	for oldHeader.Hash != newHeader.Hash {
-		oldHeader, ok = b.readHeader(oldHeader.ParentHash)
-		if !ok {
-			return fmt.Errorf("header '%s' not found", oldHeader.ParentHash.String())
-		}
+		oldChain = append(oldChain, oldHeader)
+		newChain = append(newChain, newHeader)
 
-		newHeader, ok = b.readHeader(newHeader.ParentHash)
-		if !ok {
-			return fmt.Errorf("header '%s' not found", newHeader.ParentHash.String())
+		if oldHeader, err = parent(oldHeader); err != nil {
+			return err
+		}
+		if newHeader, err = parent(newHeader); err != nil {
+			return err
 		}
-
-		oldChain = append(oldChain, oldHeader)
 	}
+	ancestor := oldHeader
 
-	for _, b := range oldChain[:len(oldChain)-1] {
-		evnt.AddOldHeader(b)
+	// the blocks in the ancient store are final
+	if db, ok := b.db.(storage.AncientStorage); ok && ancestor.Number+1 < db.Ancients() {
+		return fmt.Errorf("reorg from block %d below the ancient blocks (%d)", ancestor.Number+1, db.Ancients())
 	}
 
-	evnt.AddOldHeader(oldChainHead)
-	evnt.AddNewHeader(newChainHead)
+	b.metrics.reorgs.Inc()
+	b.metrics.reorgDepth.Observe(float64(len(oldChain)))
 
-	for _, b := range newChain {
-		evnt.AddNewHeader(b)
-	}
+	b.logger.Info(
+		"reorg",
+		"ancestor", ancestor.Number,
+		"old", oldChainHead.Number,
+		"new", newChainHead.Number,
+		"dropped", len(oldChain),
+		"added", len(newChain),
+	)
 
-	if err := b.writeFork(oldChainHead); err != nil {
+	// the old chain is now a side chain and the new one is no longer a fork
+	if err := b.writeForks(batch, oldChainHead.Hash, oldChainHead.ParentHash, newChainHead.ParentHash); err != nil {
 		return fmt.Errorf("failed to write the old header as fork: %v", err)
 	}
 
-	// Update canonical chain numbers
-	for _, h := range newChain {
-		if err := b.db.WriteCanonicalHash(h.Number, h.Hash); err != nil {
+	// Update the canonical chain from the ancestor up
+	for i := len(newChain) - 1; i > 0; i-- {
+		h := newChain[i]
+		if err := b.writeCanonicalHash(batch, h.Number, h.Hash); err != nil {
 			return err
 		}
+		if err := b.writeCanonicalTxLookups(batch, h, nil); err != nil {
+			return err
+		}
+	}
+	if err := b.writeCanonicalTxLookups(batch, newChainHead, body); err != nil {
+		return err
+	}
+
+	// the sections are rolled back before the batch is committed, a failed
+	// reorg only makes the indexer process them again
+	if err := b.rollbackBloomSections(ancestor.Number); err != nil {
+		return err
 	}
 
-	diff, err := b.advanceHead(newChainHead)
+	diff, err := b.advanceHead(batch, newChainHead)
 	if err != nil {
 		return err
 	}
 
+	// the segments of the event are sorted from the oldest to the newest block
+	for i := len(oldChain) - 1; i >= 0; i-- {
+		evnt.AddOldHeader(oldChain[i])
+	}
+	for i := len(newChain) - 1; i >= 0; i-- {
+		evnt.AddNewHeader(newChain[i])
+	}
+
 	// Set the event type and difficulty
 	evnt.Type = EventReorg
 	evnt.SetDifficulty(diff)
@@ -920,7 +1146,7 @@ func (b *Blockchain) GetBlockByHash(hash types.Hash, full bool) (*types.Block, b
 
 // GetBlockByNumber returns the block using the block number
 func (b *Blockchain) GetBlockByNumber(blockNumber uint64, full bool) (*types.Block, bool) {
-	blockHash, ok := b.db.ReadCanonicalHash(blockNumber)
+	blockHash, ok := b.readCanonicalHash(blockNumber)
 	if !ok {
 		return nil, false
 	}
@@ -930,5 +1156,9 @@ func (b *Blockchain) GetBlockByNumber(blockNumber uint64, full bool) (*types.Blo
 
 // Close closes the DB connection
 func (b *Blockchain) Close() error {
+	if b.stopCh != nil {
+		close(b.stopCh)
+		b.wg.Wait()
+	}
 	return b.db.Close()
 }
@@ -1,15 +1,24 @@
 package blockchain
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"math/big"
+	"os"
 	"reflect"
 	"testing"
 
+	"github.com/hashicorp/go-hclog"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/blockchain/storage/badger"
+	"github.com/0xPolygon/minimal/blockchain/storage/freezer"
+	"github.com/0xPolygon/minimal/blockchain/storage/leveldb"
 	"github.com/0xPolygon/minimal/blockchain/storage/memory"
+	"github.com/0xPolygon/minimal/blockchain/storage/pebble"
+	"github.com/0xPolygon/minimal/chain"
 	"github.com/0xPolygon/minimal/types"
 )
 
@@ -20,8 +29,7 @@ func TestGenesis(t *testing.T) {
 	genesis := &types.Header{Difficulty: 1, Number: 0}
 	genesis.ComputeHash()
 
-	_, err := b.advanceHead(genesis)
-	assert.NoError(t, err)
+	assert.NoError(t, b.writeGenesisImpl(genesis))
 
 	header := b.Header()
 	assert.Equal(t, header.Hash, genesis.Hash)
@@ -393,9 +401,9 @@ func TestInsertHeaders(t *testing.T) {
 					header: mock(0x4).Parent(0x2).Diff(10),
 					event: &evnt{
 						NewChain: []*header{
-							mock(0x4).Parent(0x2).Diff(10),
-							mock(0x2),
 							mock(0x1),
+							mock(0x2),
+							mock(0x4).Parent(0x2).Diff(10),
 						},
 						OldChain: []*header{
 							mock(0x3).Parent(0x0).Diff(5),
@@ -406,7 +414,6 @@ func TestInsertHeaders(t *testing.T) {
 			},
 			Head: mock(0x4).Parent(0x2).Diff(10),
 			Forks: []*header{
-				mock(0x2),
 				mock(0x3).Parent(0x0).Diff(5),
 			},
 			Chain: []*header{
@@ -524,8 +531,7 @@ func TestForkUnkwonParents(t *testing.T) {
 	h1 := NewTestHeaderFromChain(h0[:5], 10)
 
 	// Write genesis
-	_, err := b.advanceHead(h0[0])
-	assert.NoError(t, err)
+	assert.NoError(t, b.writeGenesisImpl(h0[0]))
 
 	// Write 10 headers
 	assert.NoError(t, b.WriteHeaders(h0[1:]))
@@ -541,6 +547,7 @@ func TestBlockchainWriteBody(t *testing.T) {
 	b := &Blockchain{
 		db: storage,
 	}
+	assert.NoError(t, b.SetCacheConfig(DefaultCacheConfig()))
 
 	block := &types.Block{
 		Header: &types.Header{},
@@ -561,3 +568,270 @@ func TestBlockchainWriteBody(t *testing.T) {
 	fmt.Println(body)
 	fmt.Println(ok)
 }
+
+func TestBlockchainFreeze(t *testing.T) {
+	path, err := ioutil.TempDir("/tmp", "minimal_freezer")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	f, err := freezer.Open(path)
+	assert.NoError(t, err)
+
+	db := storage.NewFreezerStorage(hclog.NewNullLogger(), memory.NewBackend(), f)
+	defer db.Close()
+
+	config := &chain.Chain{
+		Genesis: &chain.Genesis{},
+	}
+	b, err := NewBlockchainWithStorage(hclog.NewNullLogger(), db, config, &MockVerifier{}, &mockExecutor{})
+	assert.NoError(t, err)
+	b.SetFreezeThreshold(3)
+
+	h0 := NewTestHeaderChain(10)
+	assert.NoError(t, b.writeGenesisImpl(h0[0]))
+	assert.NoError(t, b.WriteBlocks(HeadersToBlocks(h0[1:])))
+
+	// the blocks older than the threshold are in the freezer
+	assert.Equal(t, uint64(6), db.Ancients())
+
+	for _, h := range h0 {
+		header, ok := b.GetHeaderByNumber(h.Number)
+		assert.True(t, ok)
+		assert.Equal(t, h.Hash, header.Hash)
+	}
+
+	// read a full block from the freezer, the genesis has no body
+	block, ok := b.GetBlockByNumber(2, true)
+	assert.True(t, ok)
+	assert.Equal(t, h0[2].Hash, block.Hash())
+
+	_, err = b.GetReceiptsByHash(h0[2].Hash)
+	assert.NoError(t, err)
+
+	// the chain cannot be reorganized below the frozen blocks
+	h1 := NewTestHeaderFromChainWithSeed(h0[:3], 10, 1)
+	assert.Error(t, b.WriteHeaders(h1[3:]))
+	assert.Equal(t, h0[9].Hash, b.Header().Hash)
+}
+
+func TestBlockchainReorg(t *testing.T) {
+	b := TestBlockchain(t, nil)
+
+	genesis := &types.Header{}
+	genesis.ComputeHash()
+	assert.NoError(t, b.writeGenesisImpl(genesis))
+
+	newTxn := func(nonce uint64) *types.Transaction {
+		txn := &types.Transaction{
+			Nonce:    nonce,
+			Value:    big.NewInt(0),
+			GasPrice: big.NewInt(0),
+			V:        0x27,
+		}
+		txn.ComputeHash()
+		return txn
+	}
+	newBlock := func(parent *types.Header, difficulty uint64, txns ...*types.Transaction) *types.Block {
+		header := &types.Header{
+			ParentHash: parent.Hash,
+			Number:     parent.Number + 1,
+			Difficulty: difficulty,
+			ExtraData:  []byte{},
+			Sha3Uncles: types.EmptyUncleHash,
+		}
+		header.ComputeHash()
+		return &types.Block{
+			Header:       header,
+			Transactions: txns,
+		}
+	}
+	writeBlock := func(block *types.Block) *Event {
+		sub := b.SubscribeEvents()
+		defer sub.Close()
+
+		assert.NoError(t, b.writeBody(block))
+		assert.NoError(t, b.WriteBlock(block))
+		return sub.GetEvent()
+	}
+
+	txn1, txn2, txn3 := newTxn(1), newTxn(2), newTxn(3)
+
+	// canonical chain with the txns 1 and 2
+	a1 := newBlock(genesis, 1, txn1)
+	a2 := newBlock(a1.Header, 1, txn2)
+	writeBlock(a1)
+	writeBlock(a2)
+
+	// heavier fork with the txns 1 and 3
+	b1 := newBlock(genesis, 2, txn3, txn1)
+	evnt := writeBlock(b1)
+	assert.Equal(t, EventFork, evnt.Type)
+	assert.Equal(t, a2.Hash(), b.Header().Hash)
+
+	// the txns of the side chain are not indexed
+	lookup, ok := b.ReadTxLookup(txn1.Hash)
+	assert.True(t, ok)
+	assert.Equal(t, a1.Hash(), lookup.BlockHash)
+
+	_, ok = b.ReadTxLookup(txn3.Hash)
+	assert.False(t, ok)
+
+	b2 := newBlock(b1.Header, 2)
+	evnt = writeBlock(b2)
+	assert.Equal(t, EventReorg, evnt.Type)
+	assert.Equal(t, b2.Hash(), b.Header().Hash)
+
+	// the segments of the chains after the common ancestor
+	hashes := func(headers []*types.Header) []types.Hash {
+		res := []types.Hash{}
+		for _, h := range headers {
+			res = append(res, h.Hash)
+		}
+		return res
+	}
+	assert.Equal(t, []types.Hash{a1.Hash(), a2.Hash()}, hashes(evnt.OldChain))
+	assert.Equal(t, []types.Hash{b1.Hash(), b2.Hash()}, hashes(evnt.NewChain))
+
+	for i, block := range []*types.Block{b1, b2} {
+		header, ok := b.GetHeaderByNumber(uint64(i + 1))
+		assert.True(t, ok)
+		assert.Equal(t, block.Hash(), header.Hash)
+	}
+
+	lookup, ok = b.ReadTxLookup(txn1.Hash)
+	assert.True(t, ok)
+	assert.Equal(t, b1.Hash(), lookup.BlockHash)
+	assert.Equal(t, uint64(1), lookup.Index)
+
+	lookup, ok = b.ReadTxLookup(txn3.Hash)
+	assert.True(t, ok)
+	assert.Equal(t, b1.Hash(), lookup.BlockHash)
+
+	// the txn 2 is not in the new chain
+	_, ok = b.ReadTxLookup(txn2.Hash)
+	assert.False(t, ok)
+
+	forks, err := b.GetForks()
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Hash{a2.Hash()}, forks)
+
+	// a shorter but heavier fork
+	c1 := newBlock(genesis, 10)
+	evnt = writeBlock(c1)
+	assert.Equal(t, EventReorg, evnt.Type)
+	assert.Equal(t, c1.Hash(), b.Header().Hash)
+
+	_, ok = b.GetHeaderByNumber(2)
+	assert.False(t, ok)
+
+	_, ok = b.ReadTxLookup(txn1.Hash)
+	assert.False(t, ok)
+}
+
+// failingBackend is a backend whose batches fail to be written once fail is set
+type failingBackend struct {
+	storage.Backend
+	fail bool
+}
+
+func (b *failingBackend) NewBatch() storage.Batch {
+	return &failingBatch{Batch: b.Backend.NewBatch(), backend: b}
+}
+
+type failingBatch struct {
+	storage.Batch
+	backend *failingBackend
+}
+
+func (b *failingBatch) Write() error {
+	if b.backend.fail {
+		return fmt.Errorf("failed to write the batch")
+	}
+	return b.Batch.Write()
+}
+
+func TestBlockchainWriteBlocksBatch(t *testing.T) {
+	backends := map[string]func(path string) (storage.Backend, error){
+		"memory": func(string) (storage.Backend, error) {
+			return memory.NewBackend(), nil
+		},
+		"leveldb": leveldb.NewBackend,
+		"pebble":  pebble.NewBackend,
+		"badger":  badger.NewBackend,
+	}
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			path, err := ioutil.TempDir("/tmp", "minimal_blockchain")
+			assert.NoError(t, err)
+			defer os.RemoveAll(path)
+
+			backend, err := open(path)
+			assert.NoError(t, err)
+			defer backend.Close()
+
+			testWriteBlocksBatch(t, backend)
+		})
+	}
+}
+
+func testWriteBlocksBatch(t *testing.T, backend storage.Backend) {
+	kv := &failingBackend{Backend: backend}
+	db := storage.NewKeyValueStorage(hclog.NewNullLogger(), kv)
+
+	config := &chain.Chain{
+		Genesis: &chain.Genesis{},
+	}
+	b, err := NewBlockchainWithStorage(hclog.NewNullLogger(), db, config, &MockVerifier{}, &mockExecutor{})
+	assert.NoError(t, err)
+
+	h0 := NewTestHeaderChain(3)
+	assert.NoError(t, b.writeGenesisImpl(h0[0]))
+	assert.NoError(t, b.WriteBlocks(HeadersToBlocks(h0[1:2])))
+
+	// none of the data of the block is written if the batch fails,
+	// which is a local failure and not an invalid block
+	kv.fail = true
+	err = b.WriteBlocks(HeadersToBlocks(h0[2:]))
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrInvalidBlock))
+	assert.Equal(t, h0[1].Hash, b.Header().Hash)
+
+	_, err = db.ReadHeader(h0[2].Hash)
+	assert.Error(t, err)
+	_, err = db.ReadBody(h0[2].Hash)
+	assert.Error(t, err)
+	_, err = db.ReadReceipts(h0[2].Hash)
+	assert.Error(t, err)
+	_, ok := db.ReadCanonicalHash(2)
+	assert.False(t, ok)
+	_, ok = db.ReadDiff(h0[2].Hash)
+	assert.False(t, ok)
+
+	head, ok := db.ReadHeadHash()
+	assert.True(t, ok)
+	assert.Equal(t, h0[1].Hash, head)
+
+	// a block that fails the validation is an invalid block
+	invalid := h0[2].Copy()
+	invalid.TxRoot = types.StringToHash("1")
+	err = b.WriteBlocks(HeadersToBlocks([]*types.Header{invalid}))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, ErrInvalidBlock))
+
+	// but not a block whose parent is unknown
+	invalid = h0[2].Copy()
+	invalid.ParentHash = types.StringToHash("1")
+	err = b.WriteBlocks(HeadersToBlocks([]*types.Header{invalid}))
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrInvalidBlock))
+
+	// the block is written once the batch succeeds
+	kv.fail = false
+	assert.NoError(t, b.WriteBlocks(HeadersToBlocks(h0[2:])))
+	assert.Equal(t, h0[2].Hash, b.Header().Hash)
+
+	_, err = db.ReadReceipts(h0[2].Hash)
+	assert.NoError(t, err)
+	head, _ = db.ReadHeadHash()
+	assert.Equal(t, h0[2].Hash, head)
+}
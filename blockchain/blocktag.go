@@ -0,0 +1,102 @@
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// BlockTag is a block referred to by its position in the chain instead of its number
+type BlockTag string
+
+const (
+	// BlockTagEarliest is the genesis block
+	BlockTagEarliest BlockTag = "earliest"
+
+	// BlockTagLatest is the head of the chain
+	BlockTagLatest BlockTag = "latest"
+
+	// BlockTagPending is the block being built on top of the head by the proposer,
+	// or the head if the node is not building one
+	BlockTagPending BlockTag = "pending"
+
+	// BlockTagSafe is the newest block that is not expected to be reorged
+	BlockTagSafe BlockTag = "safe"
+
+	// BlockTagFinalized is the newest block that cannot be reorged
+	BlockTagFinalized BlockTag = "finalized"
+)
+
+// Finalizer is implemented by the consensus engines whose blocks are final some time after
+// they are written. The blocks of the engines without it are final once they are written
+type Finalizer interface {
+	// FinalizedNumber returns the number of the newest final block with the given head
+	FinalizedNumber(head *types.Header) uint64
+}
+
+// ResolveBlockTag returns the header of the block referred to by the tag
+func (b *Blockchain) ResolveBlockTag(tag BlockTag) (*types.Header, error) {
+	switch tag {
+	case BlockTagEarliest:
+		header, ok := b.GetHeaderByNumber(0)
+		if !ok {
+			return nil, fmt.Errorf("genesis header not found")
+		}
+		return header, nil
+
+	case BlockTagLatest:
+		return b.Header(), nil
+
+	case BlockTagPending:
+		if block, ok := b.PendingBlock(); ok {
+			return block.Header, nil
+		}
+		return b.Header(), nil
+
+	case BlockTagSafe, BlockTagFinalized:
+		// without a separate notion of safety, the final blocks are the safe ones
+		return b.FinalizedHeader()
+
+	default:
+		return nil, fmt.Errorf("unknown block tag '%s'", tag)
+	}
+}
+
+// FinalizedHeader returns the header of the newest block that cannot be reorged
+func (b *Blockchain) FinalizedHeader() (*types.Header, error) {
+	head := b.Header()
+
+	finalizer, ok := b.consensus.(Finalizer)
+	if !ok {
+		return head, nil
+	}
+	number := finalizer.FinalizedNumber(head)
+	if number >= head.Number {
+		return head, nil
+	}
+	header, ok := b.GetHeaderByNumber(number)
+	if !ok {
+		return nil, fmt.Errorf("finalized header %d not found", number)
+	}
+	return header, nil
+}
+
+// SetPendingBlock sets the block being built on top of the head by the proposer,
+// its state must be written. It is discarded once the head changes
+func (b *Blockchain) SetPendingBlock(block *types.Block) {
+	b.pendingLock.Lock()
+	defer b.pendingLock.Unlock()
+
+	b.pendingBlock = block
+}
+
+// PendingBlock returns the block being built on top of the head, if any
+func (b *Blockchain) PendingBlock() (*types.Block, bool) {
+	b.pendingLock.Lock()
+	defer b.pendingLock.Unlock()
+
+	if b.pendingBlock == nil || b.pendingBlock.ParentHash() != b.Header().Hash {
+		return nil, false
+	}
+	return b.pendingBlock, true
+}
@@ -0,0 +1,58 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockFinalizer finalizes the blocks some blocks behind the head
+type mockFinalizer struct {
+	MockVerifier
+	depth uint64
+}
+
+func (m *mockFinalizer) FinalizedNumber(head *types.Header) uint64 {
+	if head.Number < m.depth {
+		return 0
+	}
+	return head.Number - m.depth
+}
+
+func TestResolveBlockTag(t *testing.T) {
+	headers := NewTestHeaderChain(10)
+	b := NewTestBlockchain(t, headers)
+
+	resolve := func(tag BlockTag) *types.Header {
+		header, err := b.ResolveBlockTag(tag)
+		assert.NoError(t, err)
+		return header
+	}
+
+	assert.Equal(t, headers[0].Hash, resolve(BlockTagEarliest).Hash)
+	assert.Equal(t, headers[9].Hash, resolve(BlockTagLatest).Hash)
+
+	// the blocks are final once they are written without a finalizer
+	assert.Equal(t, headers[9].Hash, resolve(BlockTagSafe).Hash)
+	assert.Equal(t, headers[9].Hash, resolve(BlockTagFinalized).Hash)
+
+	b.SetConsensus(&mockFinalizer{depth: 3})
+	assert.Equal(t, headers[6].Hash, resolve(BlockTagFinalized).Hash)
+	assert.Equal(t, headers[6].Hash, resolve(BlockTagSafe).Hash)
+
+	// the head is pending without a block being built
+	assert.Equal(t, headers[9].Hash, resolve(BlockTagPending).Hash)
+
+	pending := NewTestHeaderFromChain(headers, 1)[10]
+	b.SetPendingBlock(&types.Block{Header: pending})
+	assert.Equal(t, pending.Hash, resolve(BlockTagPending).Hash)
+
+	// the pending block is discarded once the head changes
+	assert.NoError(t, b.WriteHeaders(NewTestHeaderFromChainWithSeed(headers, 1, 1)[10:]))
+	assert.Equal(t, b.Header().Hash, resolve(BlockTagPending).Hash)
+	assert.NotEqual(t, pending.Hash, b.Header().Hash)
+
+	_, err := b.ResolveBlockTag("unknown")
+	assert.Error(t, err)
+}
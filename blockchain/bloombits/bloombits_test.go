@@ -0,0 +1,113 @@
+package bloombits
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func logsBloom(addr types.Address, topics ...types.Hash) types.Bloom {
+	return types.CreateBloom([]*types.Receipt{
+		{
+			Logs: []*types.Log{
+				{Address: addr, Topics: topics},
+			},
+		},
+	})
+}
+
+func TestGenerator_Order(t *testing.T) {
+	g := NewGenerator()
+
+	assert.Error(t, g.AddBloom(1, types.Bloom{}))
+	assert.NoError(t, g.AddBloom(0, types.Bloom{}))
+
+	// the section is not complete
+	_, err := g.Bitset(0)
+	assert.Error(t, err)
+}
+
+func TestMatcher(t *testing.T) {
+	addr1 := types.StringToAddress("1")
+	addr2 := types.StringToAddress("2")
+	topic1 := types.StringToHash("topic1")
+
+	// the blooms of the section by number
+	blooms := map[uint64]types.Bloom{
+		3:    logsBloom(addr1),
+		100:  logsBloom(addr1, topic1),
+		2000: logsBloom(addr2, topic1),
+		4095: logsBloom(addr2),
+	}
+
+	g := NewGenerator()
+	for i := uint64(0); i < SectionSize; i++ {
+		assert.NoError(t, g.AddBloom(i, blooms[i]))
+	}
+
+	section := uint64(2)
+	getBits := func(bit uint) ([]byte, error) {
+		return g.Bitset(bit)
+	}
+
+	cases := []struct {
+		groups  [][][]byte
+		numbers []uint64
+	}{
+		{
+			[][][]byte{{addr1.Bytes()}},
+			[]uint64{3, 100},
+		},
+		{
+			[][][]byte{{addr1.Bytes(), addr2.Bytes()}},
+			[]uint64{3, 100, 2000, 4095},
+		},
+		{
+			[][][]byte{{addr2.Bytes()}, {topic1.Bytes()}},
+			[]uint64{2000},
+		},
+		{
+			[][][]byte{{}, {topic1.Bytes()}},
+			[]uint64{100, 2000},
+		},
+		{
+			[][][]byte{{types.StringToAddress("3").Bytes()}},
+			[]uint64{},
+		},
+	}
+
+	for indx, c := range cases {
+		t.Run(fmt.Sprintf("%d", indx), func(t *testing.T) {
+			m := NewMatcher(c.groups)
+
+			found, err := m.Match(section, getBits)
+			assert.NoError(t, err)
+
+			expected := []uint64{}
+			for _, num := range c.numbers {
+				expected = append(expected, section*SectionSize+num)
+			}
+			assert.Equal(t, expected, found)
+
+			// the matches of the section are the ones of the blooms
+			for num, bloom := range blooms {
+				bloom := bloom
+				found := false
+				for _, n := range c.numbers {
+					if n == num {
+						found = true
+					}
+				}
+				assert.Equal(t, found, m.MatchBloom(&bloom))
+			}
+		})
+	}
+}
+
+func TestMatcher_Empty(t *testing.T) {
+	m := NewMatcher([][][]byte{{}, {}})
+	assert.True(t, m.Empty())
+	assert.True(t, m.MatchBloom(&types.Bloom{}))
+}
@@ -0,0 +1,83 @@
+package bloombits
+
+import (
+	"fmt"
+
+	"github.com/0xPolygon/minimal/helper/keccak"
+	"github.com/0xPolygon/minimal/types"
+)
+
+const (
+	// SectionSize is the number of blocks indexed in each section
+	SectionSize = 4096
+
+	// BloomBitLength is the number of bits of a logs bloom
+	BloomBitLength = 8 * types.BloomByteLength
+)
+
+// Generator rotates the logs blooms of a section of blocks into one bit
+// vector per bloom bit. The bit n of the vector of the bloom bit i is set
+// if the bit i is set in the logs bloom of the n-th block of the section
+type Generator struct {
+	blooms [BloomBitLength][]byte
+	next   uint64
+}
+
+// NewGenerator creates a generator for a section of blocks
+func NewGenerator() *Generator {
+	g := &Generator{}
+	for i := range g.blooms {
+		g.blooms[i] = make([]byte, SectionSize/8)
+	}
+	return g
+}
+
+// AddBloom adds the logs bloom of the block with the given position in
+// the section. The blooms have to be added in order
+func (g *Generator) AddBloom(index uint64, bloom types.Bloom) error {
+	if index != g.next {
+		return fmt.Errorf("bloom %d added out of order, expected %d", index, g.next)
+	}
+	if index >= SectionSize {
+		return fmt.Errorf("bloom %d out of the section", index)
+	}
+
+	byteIndex := index / 8
+	bitMask := byte(1) << byte(7-index%8)
+
+	for i := 0; i < BloomBitLength; i++ {
+		if bloom[types.BloomByteLength-1-i/8]&(byte(1)<<byte(i%8)) != 0 {
+			g.blooms[i][byteIndex] |= bitMask
+		}
+	}
+
+	g.next++
+	return nil
+}
+
+// Bitset returns the bit vector of a bloom bit once the section is complete
+func (g *Generator) Bitset(bit uint) ([]byte, error) {
+	if g.next != SectionSize {
+		return nil, fmt.Errorf("section not complete, %d blooms out of %d", g.next, SectionSize)
+	}
+	if bit >= BloomBitLength {
+		return nil, fmt.Errorf("bloom bit %d out of range", bit)
+	}
+	return g.blooms[bit], nil
+}
+
+// BloomIndexes returns the bloom bits set by the given data in a logs bloom
+func BloomIndexes(data []byte) [3]uint {
+	hasher := keccak.DefaultKeccakPool.Get()
+	defer keccak.DefaultKeccakPool.Put(hasher)
+
+	hasher.Reset()
+	hasher.Write(data)
+	buf := hasher.Read()
+
+	var indexes [3]uint
+	for i := 0; i < 3; i++ {
+		indexes[i] = (uint(buf[2*i])<<8 + uint(buf[2*i+1])) & 2047
+	}
+	return indexes
+}
@@ -0,0 +1,129 @@
+package bloombits
+
+import (
+	"fmt"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// Matcher finds the blocks of a section whose logs blooms might include
+// the logs of a filter. The filter is a list of groups of values: a block
+// matches if, for every group, the bloom includes any of the values of the group
+type Matcher struct {
+	groups [][][3]uint
+}
+
+// NewMatcher creates a matcher for the groups of values. The empty groups
+// match any block
+func NewMatcher(groups [][][]byte) *Matcher {
+	m := &Matcher{
+		groups: [][][3]uint{},
+	}
+	for _, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		indexes := [][3]uint{}
+		for _, data := range group {
+			indexes = append(indexes, BloomIndexes(data))
+		}
+		m.groups = append(m.groups, indexes)
+	}
+	return m
+}
+
+// Empty returns true if the matcher matches any block
+func (m *Matcher) Empty() bool {
+	return len(m.groups) == 0
+}
+
+// MatchBloom returns true if the logs bloom might include the values of the filter
+func (m *Matcher) MatchBloom(bloom *types.Bloom) bool {
+	for _, group := range m.groups {
+		match := false
+		for _, indexes := range group {
+			if bloomContains(bloom, indexes) {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
+
+// Match returns the numbers of the blocks of the section whose logs bloom
+// might include the values of the filter. The bit vectors of the section
+// are read with the given function
+func (m *Matcher) Match(section uint64, getBits func(bit uint) ([]byte, error)) ([]uint64, error) {
+	// the vectors are read once even if several values share a bit
+	vectors := map[uint][]byte{}
+	getVector := func(bit uint) ([]byte, error) {
+		if v, ok := vectors[bit]; ok {
+			return v, nil
+		}
+		v, err := getBits(bit)
+		if err != nil {
+			return nil, err
+		}
+		if len(v) != SectionSize/8 {
+			return nil, fmt.Errorf("bad size of bit vector %d in section %d: %d", bit, section, len(v))
+		}
+		vectors[bit] = v
+		return v, nil
+	}
+
+	result := make([]byte, SectionSize/8)
+	for i := range result {
+		result[i] = 0xff
+	}
+
+	for _, group := range m.groups {
+		groupRes := make([]byte, SectionSize/8)
+		for _, indexes := range group {
+			valueRes := make([]byte, SectionSize/8)
+			for i := range valueRes {
+				valueRes[i] = 0xff
+			}
+			for _, bit := range indexes {
+				v, err := getVector(bit)
+				if err != nil {
+					return nil, err
+				}
+				for i := range valueRes {
+					valueRes[i] &= v[i]
+				}
+			}
+			for i := range groupRes {
+				groupRes[i] |= valueRes[i]
+			}
+		}
+		for i := range result {
+			result[i] &= groupRes[i]
+		}
+	}
+
+	numbers := []uint64{}
+	for i, b := range result {
+		if b == 0 {
+			continue
+		}
+		for j := 0; j < 8; j++ {
+			if b&(byte(1)<<byte(7-j)) != 0 {
+				numbers = append(numbers, section*SectionSize+uint64(i*8+j))
+			}
+		}
+	}
+	return numbers, nil
+}
+
+func bloomContains(bloom *types.Bloom, indexes [3]uint) bool {
+	for _, bit := range indexes {
+		if bloom[types.BloomByteLength-1-bit/8]&(byte(1)<<byte(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,154 @@
+package blockchain
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/0xPolygon/minimal/blockchain/bloombits"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// bloomConfirms is the number of blocks on top of a section before it is indexed,
+// so that the indexed sections are not affected by the common reorgs
+const bloomConfirms = 256
+
+// BloomSections returns the number of sections of blocks indexed in the bloom bits
+func (b *Blockchain) BloomSections() uint64 {
+	return atomic.LoadUint64(&b.bloomSections)
+}
+
+// ReadBloomBits returns the bit vector of a bloom bit for an indexed section
+func (b *Blockchain) ReadBloomBits(bit uint, section uint64) ([]byte, bool) {
+	if section >= b.BloomSections() {
+		return nil, false
+	}
+	return b.db.ReadBloomBits(bit, section)
+}
+
+// StartBloomIndexer indexes in the background the logs blooms of the headers
+// in sections of bloom bits as the chain grows
+func (b *Blockchain) StartBloomIndexer() {
+	sub := b.stream.subscribe()
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+
+		go func() {
+			<-b.stopCh
+			sub.Close()
+		}()
+
+		for {
+			select {
+			case <-b.stopCh:
+				return
+			default:
+			}
+
+			if err := b.indexBloomSections(); err != nil {
+				b.logger.Error("failed to index bloom bits", "err", err)
+			}
+
+			// wait for a new head
+			if sub.GetEvent() == nil {
+				return
+			}
+		}
+	}()
+}
+
+// indexBloomSections indexes the sections that are complete and confirmed
+func (b *Blockchain) indexBloomSections() error {
+	for {
+		section := b.BloomSections()
+		if b.Header().Number+1 < (section+1)*bloombits.SectionSize+bloomConfirms {
+			return nil
+		}
+
+		select {
+		case <-b.stopCh:
+			return nil
+		default:
+		}
+
+		if err := b.indexBloomSection(section); err != nil {
+			return err
+		}
+	}
+}
+
+func (b *Blockchain) indexBloomSection(section uint64) error {
+	gen := bloombits.NewGenerator()
+
+	first := section * bloombits.SectionSize
+	last, _ := b.db.ReadCanonicalHash(first + bloombits.SectionSize - 1)
+
+	for i := uint64(0); i < bloombits.SectionSize; i++ {
+		header, ok := b.GetHeaderByNumber(first + i)
+		if !ok {
+			return fmt.Errorf("header %d not found", first+i)
+		}
+		if err := gen.AddBloom(i, b.logsBloom(header)); err != nil {
+			return err
+		}
+	}
+
+	for bit := uint(0); bit < bloombits.BloomBitLength; bit++ {
+		bits, err := gen.Bitset(bit)
+		if err != nil {
+			return err
+		}
+		if err := b.db.WriteBloomBits(bit, section, bits); err != nil {
+			return err
+		}
+	}
+
+	b.bloomLock.Lock()
+	defer b.bloomLock.Unlock()
+
+	// the section is indexed again if there was a reorg in the meantime
+	if hash, ok := b.db.ReadCanonicalHash(first + bloombits.SectionSize - 1); !ok || hash != last {
+		return nil
+	}
+	if b.BloomSections() != section {
+		return nil
+	}
+	if err := b.db.WriteBloomSections(section + 1); err != nil {
+		return err
+	}
+	atomic.StoreUint64(&b.bloomSections, section+1)
+
+	b.logger.Debug("indexed bloom bits", "section", section)
+	return nil
+}
+
+// logsBloom returns the logs bloom of the block. The blocks sealed before the
+// logs bloom was set in the header have an empty bloom, which is computed from the receipts
+func (b *Blockchain) logsBloom(header *types.Header) types.Bloom {
+	if header.LogsBloom != (types.Bloom{}) || header.ReceiptsRoot == types.EmptyRootHash {
+		return header.LogsBloom
+	}
+	receipts, err := b.db.ReadReceipts(header.Hash)
+	if err != nil {
+		return header.LogsBloom
+	}
+	return types.CreateBloom(receipts)
+}
+
+// rollbackBloomSections removes from the index the sections with blocks
+// after the given number, which are no longer canonical
+func (b *Blockchain) rollbackBloomSections(number uint64) error {
+	b.bloomLock.Lock()
+	defer b.bloomLock.Unlock()
+
+	sections := (number + 1) / bloombits.SectionSize
+	if sections >= b.BloomSections() {
+		return nil
+	}
+	if err := b.db.WriteBloomSections(sections); err != nil {
+		return err
+	}
+	atomic.StoreUint64(&b.bloomSections, sections)
+	return nil
+}
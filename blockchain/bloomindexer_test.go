@@ -0,0 +1,90 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/bloombits"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBloomIndexer(t *testing.T) {
+	addr := types.StringToAddress("1")
+	bloom := types.CreateBloom([]*types.Receipt{
+		{
+			Logs: []*types.Log{
+				{Address: addr},
+			},
+		},
+	})
+
+	// the blocks with logs of the address
+	logs := map[uint64]bool{
+		10:   true,
+		4000: true,
+	}
+
+	n := bloombits.SectionSize + bloomConfirms
+	headers := []*types.Header{}
+	for i := 0; i < n; i++ {
+		header := &types.Header{
+			Number:       uint64(i),
+			TxRoot:       types.EmptyRootHash,
+			Sha3Uncles:   types.EmptyUncleHash,
+			ReceiptsRoot: types.EmptyRootHash,
+			Difficulty:   uint64(i),
+		}
+		if i > 0 {
+			header.ParentHash = headers[i-1].Hash
+		}
+		if logs[uint64(i)] {
+			header.LogsBloom = bloom
+		}
+		header.ComputeHash()
+		headers = append(headers, header)
+	}
+
+	b := NewTestBlockchain(t, nil)
+	assert.NoError(t, b.writeGenesisImpl(headers[0]))
+	assert.NoError(t, b.WriteHeaders(headers[1:n-1]))
+
+	// the section is not confirmed yet
+	assert.NoError(t, b.indexBloomSections())
+	assert.Equal(t, uint64(0), b.BloomSections())
+
+	assert.NoError(t, b.WriteHeaders(headers[n-1:]))
+	assert.NoError(t, b.indexBloomSections())
+	assert.Equal(t, uint64(1), b.BloomSections())
+
+	sections, ok := b.db.ReadBloomSections()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), sections)
+
+	m := bloombits.NewMatcher([][][]byte{{addr.Bytes()}})
+	numbers, err := m.Match(0, func(bit uint) ([]byte, error) {
+		bits, _ := b.ReadBloomBits(bit, 0)
+		return bits, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{10, 4000}, numbers)
+
+	_, ok = b.ReadBloomBits(0, 1)
+	assert.False(t, ok)
+
+	// a reorg of the indexed section removes it from the index
+	fork := NewTestHeaderFromChainWithSeed(headers[:100], n, 1)
+	assert.NoError(t, b.WriteHeaders(fork[100:]))
+	assert.Equal(t, fork[len(fork)-1].Hash, b.Header().Hash)
+	assert.Equal(t, uint64(0), b.BloomSections())
+
+	// and it is indexed again with the new chain
+	assert.NoError(t, b.indexBloomSections())
+	assert.Equal(t, uint64(1), b.BloomSections())
+
+	numbers, err = m.Match(0, func(bit uint) ([]byte, error) {
+		bits, _ := b.ReadBloomBits(bit, 0)
+		return bits, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{10}, numbers)
+}
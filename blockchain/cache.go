@@ -0,0 +1,49 @@
+package blockchain
+
+import (
+	"fmt"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// CacheConfig is the number of recent items kept in memory by the blockchain
+type CacheConfig struct {
+	Headers   int // Headers (and their total difficulty) by hash
+	Canonical int // Hashes of the canonical blocks by number
+	Bodies    int // Block bodies by hash
+	Receipts  int // Block receipts by hash
+}
+
+// DefaultCacheConfig returns the default sizes of the blockchain caches
+func DefaultCacheConfig() *CacheConfig {
+	return &CacheConfig{
+		Headers:   512,
+		Canonical: 2048,
+		Bodies:    256,
+		Receipts:  256,
+	}
+}
+
+// SetCacheConfig sets the sizes of the caches of the blockchain. The caches
+// are cleared, it has to be called before the blockchain is used
+func (b *Blockchain) SetCacheConfig(config *CacheConfig) error {
+	sizes := []struct {
+		name  string
+		size  int
+		cache **lru.Cache
+	}{
+		{"headers", config.Headers, &b.headersCache},
+		{"difficulty", config.Headers, &b.difficultyCache},
+		{"canonical", config.Canonical, &b.canonicalCache},
+		{"bodies", config.Bodies, &b.bodiesCache},
+		{"receipts", config.Receipts, &b.receiptsCache},
+	}
+	for _, c := range sizes {
+		cache, err := lru.New(c.size)
+		if err != nil {
+			return fmt.Errorf("invalid size of the %s cache: %d", c.name, c.size)
+		}
+		*c.cache = cache
+	}
+	return nil
+}
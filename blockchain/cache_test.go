@@ -0,0 +1,89 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/storage/memory"
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheConfig(t *testing.T) {
+	b := NewTestBlockchain(t, nil)
+
+	config := DefaultCacheConfig()
+	config.Bodies = 0
+	assert.Error(t, b.SetCacheConfig(config))
+
+	config.Bodies = 10
+	assert.NoError(t, b.SetCacheConfig(config))
+}
+
+func TestCacheCanonicalReorg(t *testing.T) {
+	b := NewTestBlockchain(t, nil)
+
+	headers := NewTestHeaderChain(3)
+	assert.NoError(t, b.writeGenesisImpl(headers[0]))
+	assert.NoError(t, b.WriteHeaders(headers[1:]))
+
+	// cache the canonical hashes
+	for _, h := range headers {
+		found, ok := b.GetHeaderByNumber(h.Number)
+		assert.True(t, ok)
+		assert.Equal(t, h.Hash, found.Hash)
+		assert.True(t, b.canonicalCache.Contains(h.Number))
+	}
+
+	// heavier fork from the genesis
+	fork := NewTestHeaderFromChainWithSeed(headers[:1], 3, 1)
+	assert.NoError(t, b.WriteHeaders(fork[1:]))
+	assert.Equal(t, fork[3].Hash, b.Header().Hash)
+
+	for _, h := range fork {
+		found, ok := b.GetHeaderByNumber(h.Number)
+		assert.True(t, ok)
+		assert.Equal(t, h.Hash, found.Hash)
+	}
+}
+
+func TestCacheReceipts(t *testing.T) {
+	b := NewTestBlockchain(t, nil)
+
+	hash := types.StringToHash("1")
+	receipts := []*types.Receipt{
+		{GasUsed: 10},
+	}
+	assert.NoError(t, b.db.WriteReceipts(hash, receipts))
+
+	found, err := b.GetReceiptsByHash(hash)
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+	assert.True(t, b.receiptsCache.Contains(hash))
+
+	// the receipts not found are not cached
+	_, err = b.GetReceiptsByHash(types.StringToHash("2"))
+	assert.Error(t, err)
+	assert.False(t, b.receiptsCache.Contains(types.StringToHash("2")))
+}
+
+func TestCacheBodiesOnWrite(t *testing.T) {
+	db, err := memory.NewMemoryStorage(nil)
+	assert.NoError(t, err)
+
+	genesis := &chain.Genesis{}
+	header := genesis.GenesisHeader()
+	header.ComputeHash()
+
+	b, err := NewBlockchainWithStorage(hclog.NewNullLogger(), db, &chain.Chain{Genesis: genesis}, &MockVerifier{}, &mockExecutor{})
+	assert.NoError(t, err)
+
+	headers := NewTestHeaderChainWithSeed(header, 3, 0)
+	assert.NoError(t, b.WriteBlocks(HeadersToBlocks(headers[1:])))
+
+	// the bodies of the new blocks are served without reading the DB
+	for _, h := range headers[1:] {
+		assert.True(t, b.bodiesCache.Contains(h.Hash))
+	}
+}
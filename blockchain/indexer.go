@@ -0,0 +1,161 @@
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// ChainIndexer builds a custom index of the canonical chain, i.e. the transfers of a token.
+// The blocks are added in order as they join the canonical chain, and removed from the
+// newest to the oldest when a reorg takes them out of it, so the index always follows the
+// canonical chain. The last block processed is stored once the call returns, so the same
+// block might be added or removed again after a crash
+type ChainIndexer interface {
+	// OnBlockAdded is called with each block that joins the canonical chain
+	OnBlockAdded(block *IndexedBlock) error
+
+	// OnBlockRemoved is called with each indexed block that leaves the canonical chain
+	OnBlockRemoved(block *IndexedBlock) error
+}
+
+// IndexedBlock is a block passed to a chain indexer
+type IndexedBlock struct {
+	Block    *types.Block
+	Receipts []*types.Receipt
+
+	// State is the state after the block, nil if it is not available, i.e. it was pruned
+	State state.Snapshot
+}
+
+// StartIndexer runs the chain indexer in the background from the last block it processed,
+// or from the genesis if it is new. The name identifies the progress of the indexer in the DB.
+// The state is used to pass the state of the blocks to the indexer and it can be nil
+func (b *Blockchain) StartIndexer(name string, indexer ChainIndexer, st state.State) {
+	sub := b.stream.subscribe()
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+
+		go func() {
+			<-b.stopCh
+			sub.Close()
+		}()
+
+		for {
+			if err := b.syncIndexer(name, indexer, st); err != nil {
+				b.logger.Error("failed to run chain indexer", "name", name, "err", err)
+			}
+
+			// wait for a new head
+			if sub.GetEvent() == nil {
+				return
+			}
+		}
+	}()
+}
+
+// syncIndexer removes from the index the blocks that are no longer canonical
+// and adds the canonical blocks up to the head
+func (b *Blockchain) syncIndexer(name string, indexer ChainIndexer, st state.State) error {
+	for {
+		head, ok := b.db.ReadIndexerHead(name)
+
+		var next uint64
+		if ok {
+			header, ok := b.GetHeaderByHash(head)
+			if !ok {
+				return fmt.Errorf("indexed block %s not found", head)
+			}
+			if hash, ok := b.readCanonicalHash(header.Number); !ok || hash != head {
+				if err := b.removeIndexedBlock(name, indexer, st, header); err != nil {
+					return err
+				}
+				continue
+			}
+			next = header.Number + 1
+		}
+
+		if next > b.Header().Number {
+			return nil
+		}
+
+		select {
+		case <-b.stopCh:
+			return nil
+		default:
+		}
+
+		block, ok := b.GetBlockByNumber(next, false)
+		if !ok {
+			return fmt.Errorf("block %d not found", next)
+		}
+		if next != 0 && block.ParentHash() != head {
+			// a reorg happened after the canonical hash of the indexed block
+			// was read, the block is removed in the next iteration
+			continue
+		}
+		if err := b.addIndexedBlock(name, indexer, st, block.Header); err != nil {
+			return err
+		}
+	}
+}
+
+func (b *Blockchain) addIndexedBlock(name string, indexer ChainIndexer, st state.State, header *types.Header) error {
+	block, err := b.readIndexedBlock(st, header)
+	if err != nil {
+		return err
+	}
+	if err := indexer.OnBlockAdded(block); err != nil {
+		return fmt.Errorf("failed to add block %d: %v", header.Number, err)
+	}
+	return b.db.WriteIndexerHead(name, header.Hash)
+}
+
+func (b *Blockchain) removeIndexedBlock(name string, indexer ChainIndexer, st state.State, header *types.Header) error {
+	block, err := b.readIndexedBlock(st, header)
+	if err != nil {
+		return err
+	}
+	if err := indexer.OnBlockRemoved(block); err != nil {
+		return fmt.Errorf("failed to remove block %d: %v", header.Number, err)
+	}
+	return b.db.WriteIndexerHead(name, header.ParentHash)
+}
+
+// readIndexedBlock reads the body and the receipts of the block. The blocks
+// without transactions might not have them stored
+func (b *Blockchain) readIndexedBlock(st state.State, header *types.Header) (*IndexedBlock, error) {
+	body, err := b.db.ReadBody(header.Hash)
+	if err != nil {
+		if err != storage.ErrNotFound || header.TxRoot != types.EmptyRootHash {
+			return nil, fmt.Errorf("body of block %d not found: %v", header.Number, err)
+		}
+		body = &types.Body{}
+	}
+	receipts, err := b.GetReceiptsByHash(header.Hash)
+	if err != nil {
+		if err != storage.ErrNotFound || header.ReceiptsRoot != types.EmptyRootHash {
+			return nil, fmt.Errorf("receipts of block %d not found: %v", header.Number, err)
+		}
+		receipts = []*types.Receipt{}
+	}
+
+	block := &IndexedBlock{
+		Block: &types.Block{
+			Header:       header,
+			Transactions: body.Transactions,
+			Uncles:       body.Uncles,
+		},
+		Receipts: receipts,
+	}
+	if st != nil {
+		if snap, err := st.NewSnapshotAt(header.StateRoot); err == nil {
+			block.State = snap
+		}
+	}
+	return block, nil
+}
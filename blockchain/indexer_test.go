@@ -0,0 +1,84 @@
+package blockchain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockIndexer indexes the hashes of the canonical blocks
+type mockIndexer struct {
+	hashes  []types.Hash
+	removed []types.Hash
+	failAt  int64
+}
+
+func (m *mockIndexer) OnBlockAdded(block *IndexedBlock) error {
+	if int64(block.Block.Number()) == m.failAt {
+		return fmt.Errorf("failed")
+	}
+	m.hashes = append(m.hashes, block.Block.Hash())
+	return nil
+}
+
+func (m *mockIndexer) OnBlockRemoved(block *IndexedBlock) error {
+	last := m.hashes[len(m.hashes)-1]
+	if last != block.Block.Hash() {
+		return fmt.Errorf("block %s is not the last indexed", block.Block.Hash())
+	}
+	m.hashes = m.hashes[:len(m.hashes)-1]
+	m.removed = append(m.removed, block.Block.Hash())
+	return nil
+}
+
+func headerHashes(headers []*types.Header) []types.Hash {
+	hashes := []types.Hash{}
+	for _, h := range headers {
+		hashes = append(hashes, h.Hash)
+	}
+	return hashes
+}
+
+func TestChainIndexer(t *testing.T) {
+	headers := NewTestHeaderChain(10)
+	b := NewTestBlockchain(t, headers)
+
+	indexer := &mockIndexer{failAt: -1}
+	assert.NoError(t, b.syncIndexer("a", indexer, nil))
+	assert.Equal(t, headerHashes(headers), indexer.hashes)
+
+	head, ok := b.db.ReadIndexerHead("a")
+	assert.True(t, ok)
+	assert.Equal(t, headers[9].Hash, head)
+
+	// a reorg removes the blocks out of the canonical chain
+	fork := NewTestHeaderFromChainWithSeed(headers[:5], 7, 1)
+	assert.NoError(t, b.WriteHeaders(fork[5:]))
+	assert.Equal(t, fork[len(fork)-1].Hash, b.Header().Hash)
+
+	assert.NoError(t, b.syncIndexer("a", indexer, nil))
+	assert.Equal(t, headerHashes(fork), indexer.hashes)
+	assert.Equal(t, []types.Hash{
+		headers[9].Hash,
+		headers[8].Hash,
+		headers[7].Hash,
+		headers[6].Hash,
+		headers[5].Hash,
+	}, indexer.removed)
+
+	// the progress is kept by indexer
+	other := &mockIndexer{failAt: 3}
+	assert.Error(t, b.syncIndexer("b", other, nil))
+	assert.Equal(t, headerHashes(fork[:3]), other.hashes)
+
+	head, ok = b.db.ReadIndexerHead("b")
+	assert.True(t, ok)
+	assert.Equal(t, fork[2].Hash, head)
+
+	// the indexer resumes from the last block processed
+	other.failAt = -1
+	assert.NoError(t, b.syncIndexer("b", other, nil))
+	assert.Equal(t, headerHashes(fork), other.hashes)
+}
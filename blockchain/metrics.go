@@ -0,0 +1,105 @@
+package blockchain
+
+import (
+	"github.com/0xPolygon/minimal/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "blockchain"
+
+// Metrics are the prometheus metrics of the blockchain
+type Metrics struct {
+	// number of the head block, 0 until the genesis is written
+	head prometheus.GaugeFunc
+
+	// timestamp of the head block, a chain stall leaves it behind the clock
+	headTimestamp prometheus.GaugeFunc
+
+	// number of blocks executed and written
+	blocksImported prometheus.Counter
+
+	// time to execute a block
+	blockExecution prometheus.Histogram
+
+	// size of the batches written to the DB
+	batchSize prometheus.Histogram
+
+	// number of reorgs
+	reorgs prometheus.Counter
+
+	// number of blocks dropped from the canonical chain by a reorg
+	reorgDepth prometheus.Histogram
+}
+
+func newMetrics(b *Blockchain) *Metrics {
+	return &Metrics{
+		head: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "head_block_number",
+			Help:      "Number of the head block of the chain",
+		}, func() float64 {
+			if header, ok := b.currentHeader.Load().(*types.Header); ok {
+				return float64(header.Number)
+			}
+			return 0
+		}),
+		headTimestamp: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "head_block_timestamp_seconds",
+			Help:      "Timestamp of the head block of the chain",
+		}, func() float64 {
+			if header, ok := b.currentHeader.Load().(*types.Header); ok {
+				return float64(header.Timestamp)
+			}
+			return 0
+		}),
+		blocksImported: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "blocks_imported_total",
+			Help:      "Number of blocks executed and written to the chain",
+		}),
+		blockExecution: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "block_execution_seconds",
+			Help:      "Time to execute the transactions of a block",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+		}),
+		batchSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "write_batch_bytes",
+			Help:      "Size of the batches of writes to the database",
+			Buckets:   prometheus.ExponentialBuckets(256, 4, 10),
+		}),
+		reorgs: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "reorgs_total",
+			Help:      "Number of reorgs of the canonical chain",
+		}),
+		reorgDepth: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "reorg_depth",
+			Help:      "Number of blocks dropped from the canonical chain by a reorg",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+		}),
+	}
+}
+
+// RegisterMetrics registers the metrics of the blockchain in the prometheus registerer
+func (b *Blockchain) RegisterMetrics(reg prometheus.Registerer) error {
+	m := b.metrics
+	collectors := []prometheus.Collector{
+		m.head,
+		m.headTimestamp,
+		m.blocksImported,
+		m.blockExecution,
+		m.batchSize,
+		m.reorgs,
+		m.reorgDepth,
+	}
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
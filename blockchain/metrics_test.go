@@ -0,0 +1,53 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/storage/memory"
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/hashicorp/go-hclog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_WriteBlocks(t *testing.T) {
+	db, err := memory.NewMemoryStorage(nil)
+	assert.NoError(t, err)
+
+	config := &chain.Chain{
+		Genesis: &chain.Genesis{},
+	}
+	b, err := NewBlockchainWithStorage(hclog.NewNullLogger(), db, config, &MockVerifier{}, &mockExecutor{})
+	assert.NoError(t, err)
+
+	reg := prometheus.NewRegistry()
+	assert.NoError(t, b.RegisterMetrics(reg))
+
+	// the head is not set until the genesis is written
+	assert.Equal(t, float64(0), testutil.ToFloat64(b.metrics.head))
+
+	headers := NewTestHeaderChain(4)
+	assert.NoError(t, b.writeGenesisImpl(headers[0]))
+	assert.NoError(t, b.WriteBlocks(HeadersToBlocks(headers[1:])))
+
+	assert.Equal(t, float64(3), testutil.ToFloat64(b.metrics.head))
+	assert.Equal(t, float64(headers[3].Timestamp), testutil.ToFloat64(b.metrics.headTimestamp))
+	assert.Equal(t, float64(3), testutil.ToFloat64(b.metrics.blocksImported))
+	assert.Equal(t, 1, testutil.CollectAndCount(b.metrics.blockExecution))
+	assert.Equal(t, 1, testutil.CollectAndCount(b.metrics.batchSize))
+}
+
+func TestMetrics_Reorg(t *testing.T) {
+	headers := NewTestHeaderChain(10)
+	b := NewTestBlockchain(t, headers)
+
+	assert.Equal(t, float64(0), testutil.ToFloat64(b.metrics.reorgs))
+
+	// the fork replaces the last 5 blocks
+	fork := NewTestHeaderFromChainWithSeed(headers[:5], 7, 1)
+	assert.NoError(t, b.WriteHeaders(fork[5:]))
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(b.metrics.reorgs))
+	assert.Equal(t, float64(11), testutil.ToFloat64(b.metrics.head))
+}
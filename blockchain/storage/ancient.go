@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/0xPolygon/minimal/blockchain/storage/freezer"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+)
+
+// AncientStorage is a storage that moves the old canonical blocks to an ancient store
+type AncientStorage interface {
+	Storage
+
+	// Ancients returns the number of blocks in the ancient store
+	Ancients() uint64
+
+	// Freeze moves the canonical blocks below the limit to the ancient store
+	Freeze(limit uint64) error
+}
+
+// FreezerStorage is a key-value storage that moves the headers, bodies and receipts of the
+// old canonical blocks to a freezer. The rest of the data remains in the key-value database
+type FreezerStorage struct {
+	*KeyValueStorage
+
+	backend Backend
+	freezer *freezer.Freezer
+}
+
+// NewFreezerStorage creates a storage on top of the backend and the freezer
+func NewFreezerStorage(logger hclog.Logger, db Backend, f *freezer.Freezer) *FreezerStorage {
+	return &FreezerStorage{
+		KeyValueStorage: &KeyValueStorage{logger: logger, db: db},
+		backend:         db,
+		freezer:         f,
+	}
+}
+
+// Ancients returns the number of blocks in the freezer
+func (s *FreezerStorage) Ancients() uint64 {
+	return s.freezer.Ancients()
+}
+
+// Freeze moves the canonical blocks below the limit from the key-value database to the freezer
+func (s *FreezerStorage) Freeze(limit uint64) error {
+	batch := s.backend.NewBatch()
+
+	var err error
+	for n := s.freezer.Ancients(); n < limit; n++ {
+		if err = s.freezeBlock(n, batch); err != nil {
+			break
+		}
+	}
+
+	// the blocks are only removed from the database once they are in disk
+	if syncErr := s.freezer.Sync(); syncErr != nil {
+		return syncErr
+	}
+	if writeErr := batch.Write(); writeErr != nil {
+		return writeErr
+	}
+	return err
+}
+
+func (s *FreezerStorage) freezeBlock(n uint64, batch Batch) error {
+	hash, ok := s.ReadCanonicalHash(n)
+	if !ok {
+		return fmt.Errorf("canonical hash of block %d not found", n)
+	}
+	header, ok := s.get(HEADER, hash.Bytes())
+	if !ok {
+		return fmt.Errorf("header of block %d not found", n)
+	}
+
+	// the genesis block has neither body nor receipts
+	body, _ := s.get(BODY, hash.Bytes())
+	receipts, _ := s.get(RECEIPTS, hash.Bytes())
+
+	if err := s.freezer.Append(n, hash.Bytes(), header, body, receipts); err != nil {
+		return err
+	}
+
+	batch.Set(dbKey(ANCIENT, hash.Bytes()), s.encodeUint(n))
+	batch.Delete(dbKey(HEADER, hash.Bytes()))
+	batch.Delete(dbKey(BODY, hash.Bytes()))
+	batch.Delete(dbKey(RECEIPTS, hash.Bytes()))
+	return nil
+}
+
+// ReadHeader reads the header from the database or the freezer
+func (s *FreezerStorage) ReadHeader(hash types.Hash) (*types.Header, error) {
+	header, err := s.KeyValueStorage.ReadHeader(hash)
+	if err != ErrNotFound {
+		return header, err
+	}
+	header = &types.Header{}
+	err = s.readAncient(freezer.HeaderTable, hash, header)
+	return header, err
+}
+
+// ReadBody reads the body from the database or the freezer
+func (s *FreezerStorage) ReadBody(hash types.Hash) (*types.Body, error) {
+	body, err := s.KeyValueStorage.ReadBody(hash)
+	if err != ErrNotFound {
+		return body, err
+	}
+	body = &types.Body{}
+	err = s.readAncient(freezer.BodyTable, hash, body)
+	return body, err
+}
+
+// ReadReceipts reads the receipts from the database or the freezer
+func (s *FreezerStorage) ReadReceipts(hash types.Hash) ([]*types.Receipt, error) {
+	receipts, err := s.KeyValueStorage.ReadReceipts(hash)
+	if err != ErrNotFound {
+		return receipts, err
+	}
+	rr := &types.Receipts{}
+	err = s.readAncient(freezer.ReceiptsTable, hash, rr)
+	return *rr, err
+}
+
+func (s *FreezerStorage) readAncient(kind string, hash types.Hash, raw types.RLPUnmarshaler) error {
+	v, ok := s.get(ANCIENT, hash.Bytes())
+	if !ok {
+		return ErrNotFound
+	}
+	number := s.decodeUint(v)
+
+	frozenHash, err := s.freezer.Retrieve(freezer.HashTable, number)
+	if err != nil {
+		return err
+	}
+	if types.BytesToHash(frozenHash) != hash {
+		return fmt.Errorf("ancient block %d has hash %s, expected %s", number, types.BytesToHash(frozenHash), hash)
+	}
+
+	data, err := s.freezer.Retrieve(kind, number)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return ErrNotFound
+	}
+	return decodeRLP(data, raw)
+}
+
+// Close closes the database and the freezer
+func (s *FreezerStorage) Close() error {
+	if err := s.freezer.Close(); err != nil {
+		return err
+	}
+	return s.KeyValueStorage.Close()
+}
+
+// dbKey returns the key of the entry in the database
+func dbKey(p []byte, k []byte) []byte {
+	return append(append([]byte{}, p...), k...)
+}
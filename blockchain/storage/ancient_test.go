@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/storage/freezer"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFreezerStorage(t *testing.T) (*FreezerStorage, func()) {
+	path, err := ioutil.TempDir("/tmp", "minimal_freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := freezer.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewFreezerStorage(hclog.NewNullLogger(), newMockBackend(), f)
+	close := func() {
+		s.Close()
+		if err := os.RemoveAll(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s, close
+}
+
+func TestFreezerStorage(t *testing.T) {
+	TestStorage(t, func(t *testing.T) (Storage, func()) {
+		return newFreezerStorage(t)
+	})
+}
+
+func TestFreezerStorage_Freeze(t *testing.T) {
+	s, close := newFreezerStorage(t)
+	defer close()
+
+	headers := []*types.Header{}
+	for i := uint64(0); i < 4; i++ {
+		h := &types.Header{
+			Number:    i,
+			ExtraData: []byte{},
+		}
+		h.ComputeHash()
+		headers = append(headers, h)
+
+		assert.NoError(t, s.WriteCanonicalHeader(h, big.NewInt(1)))
+		if i != 0 {
+			assert.NoError(t, s.WriteBody(h.Hash, &types.Body{}))
+			assert.NoError(t, s.WriteReceipts(h.Hash, []*types.Receipt{
+				{CumulativeGasUsed: i, Logs: []*types.Log{}},
+			}))
+		}
+	}
+
+	assert.NoError(t, s.Freeze(3))
+	assert.Equal(t, uint64(3), s.Ancients())
+
+	// the frozen blocks are removed from the database
+	_, ok := s.get(HEADER, headers[1].Hash.Bytes())
+	assert.False(t, ok)
+
+	// the block above the limit remains in the database
+	_, ok = s.get(HEADER, headers[3].Hash.Bytes())
+	assert.True(t, ok)
+
+	for _, h := range headers {
+		header, err := s.ReadHeader(h.Hash)
+		assert.NoError(t, err)
+		assert.Equal(t, h.Hash, header.Hash)
+	}
+
+	receipts, err := s.ReadReceipts(headers[2].Hash)
+	assert.NoError(t, err)
+	assert.Len(t, receipts, 1)
+	assert.Equal(t, uint64(2), receipts[0].CumulativeGasUsed)
+
+	_, err = s.ReadBody(headers[0].Hash)
+	assert.Equal(t, ErrNotFound, err)
+
+	_, err = s.ReadHeader(types.StringToHash("1"))
+	assert.Equal(t, ErrNotFound, err)
+
+	// freezing again is a no-op
+	assert.NoError(t, s.Freeze(3))
+	assert.Equal(t, uint64(3), s.Ancients())
+}
@@ -0,0 +1,70 @@
+package storage
+
+// Batch is a set of writes to a backend that are applied atomically
+type Batch interface {
+	Set(k, v []byte)
+	Delete(k []byte)
+	Write() error
+}
+
+// Backend is a key-value database in which both the blockchain
+// and the state can be stored
+type Backend interface {
+	KV
+
+	// NewBatch creates a new batch of writes
+	NewBatch() Batch
+
+	// Iterate calls fn for each key-value pair with the given prefix in key
+	// order, until fn returns false. The slices are only valid during the call
+	Iterate(prefix []byte, fn func(k, v []byte) bool) error
+}
+
+// Compacter is implemented by the backends that can compact their files to reclaim
+// the space of the deleted and overwritten pairs, i.e. after a prune
+type Compacter interface {
+	Compact() error
+}
+
+// BackendFactory opens a backend in the given path
+type BackendFactory func(path string) (Backend, error)
+
+// defaultMigrateBatchSize is the number of pairs written in each batch during a migration
+const defaultMigrateBatchSize = 1000
+
+// Migrate copies all the key-value pairs from one backend to another and
+// returns the number of pairs copied
+func Migrate(from, to Backend) (uint64, error) {
+	var (
+		count    uint64
+		writeErr error
+	)
+
+	batch := to.NewBatch()
+	pending := 0
+
+	err := from.Iterate(nil, func(k, v []byte) bool {
+		// the slices are only valid during the call
+		batch.Set(append([]byte{}, k...), append([]byte{}, v...))
+		count++
+
+		if pending++; pending == defaultMigrateBatchSize {
+			if writeErr = batch.Write(); writeErr != nil {
+				return false
+			}
+			batch = to.NewBatch()
+			pending = 0
+		}
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+	if writeErr != nil {
+		return 0, writeErr
+	}
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,161 @@
+package badger
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/dgraph-io/badger/v2"
+	"github.com/hashicorp/go-hclog"
+)
+
+// badgerGCDiscardRatio is the ratio of stale data over which a value log file is rewritten
+const badgerGCDiscardRatio = 0.5
+
+// NewBadgerStorage creates the new storage reference with badger
+func NewBadgerStorage(path string, logger hclog.Logger) (storage.Storage, error) {
+	kv, err := NewBackend(path)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewKeyValueStorage(logger.Named("badger"), kv), nil
+}
+
+// NewBackend opens a badger backend in the given path
+func NewBackend(path string) (storage.Backend, error) {
+	db, err := badger.Open(badger.DefaultOptions(path).WithLogger(nil))
+	if err != nil {
+		return nil, err
+	}
+	return &badgerKV{db}, nil
+}
+
+// NewReadOnlyBackend opens a badger backend in the given path in read-only mode.
+// Badger only opens in read-only mode the databases that were closed, so it fails
+// while a node is running on it
+func NewReadOnlyBackend(path string) (storage.Backend, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	db, err := badger.Open(badger.DefaultOptions(path).WithLogger(nil).WithReadOnly(true))
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewReadOnlyBackend(&badgerKV{db}), nil
+}
+
+// badgerKV is the badger implementation of the kv storage
+type badgerKV struct {
+	db *badger.DB
+}
+
+// Set sets the key-value pair in badger storage
+func (b *badgerKV) Set(p []byte, v []byte) error {
+	return b.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(p, v)
+	})
+}
+
+// Get retrieves the key-value pair in badger storage
+func (b *badgerKV) Get(p []byte) ([]byte, bool, error) {
+	var data []byte
+	err := b.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(p)
+		if err != nil {
+			return err
+		}
+		data, err = item.ValueCopy(nil)
+		return err
+	})
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// NewBatch creates a new badger batch
+func (b *badgerKV) NewBatch() storage.Batch {
+	return &badgerBatch{txn: b.db.NewTransaction(true)}
+}
+
+// Iterate iterates over the key-value pairs with the prefix in badger storage
+func (b *badgerKV) Iterate(prefix []byte, fn func(k, v []byte) bool) error {
+	return b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+
+		iter := txn.NewIterator(opts)
+		defer iter.Close()
+
+		for iter.Rewind(); iter.Valid(); iter.Next() {
+			item := iter.Item()
+			v, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			if !fn(item.Key(), v) {
+				break
+			}
+		}
+		return nil
+	})
+}
+
+// Compact merges the levels of the badger storage and rewrites
+// the value log files until there is no space left to reclaim
+func (b *badgerKV) Compact() error {
+	if err := b.db.Flatten(runtime.NumCPU()); err != nil {
+		return err
+	}
+	for {
+		if err := b.db.RunValueLogGC(badgerGCDiscardRatio); err != nil {
+			if err == badger.ErrNoRewrite {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// Close closes the badger storage instance
+func (b *badgerKV) Close() error {
+	return b.db.Close()
+}
+
+// badgerBatch is a batch write for badger. The writes are buffered in a single
+// transaction so that the batch is applied atomically, a batch too big for a
+// transaction fails instead of being split
+type badgerBatch struct {
+	txn *badger.Txn
+	err error
+}
+
+func (b *badgerBatch) Set(k, v []byte) {
+	// badger keeps a reference to the slices until the transaction
+	// is committed and the caller might reuse them before
+	if b.err == nil {
+		b.err = b.txn.Set(append([]byte{}, k...), append([]byte{}, v...))
+	}
+}
+
+func (b *badgerBatch) Delete(k []byte) {
+	if b.err == nil {
+		b.err = b.txn.Delete(append([]byte{}, k...))
+	}
+}
+
+func (b *badgerBatch) Write() error {
+	defer b.txn.Discard()
+
+	if b.err != nil {
+		if b.err == badger.ErrTxnTooBig {
+			return fmt.Errorf("batch too big for a badger transaction: %v", b.err)
+		}
+		return b.err
+	}
+	return b.txn.Commit()
+}
@@ -0,0 +1,100 @@
+package badger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func newStorage(t *testing.T) (storage.Storage, func()) {
+	path, err := ioutil.TempDir("/tmp", "minimal_storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewBadgerStorage(path, hclog.NewNullLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	close := func() {
+		if err := os.RemoveAll(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s, close
+}
+
+func TestStorage(t *testing.T) {
+	storage.TestStorage(t, newStorage)
+}
+
+func TestBackend(t *testing.T) {
+	path, err := ioutil.TempDir("/tmp", "minimal_storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+
+	b, err := NewBackend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	storage.TestBackend(t, b)
+}
+
+func TestReadOnlyBackend(t *testing.T) {
+	path, err := ioutil.TempDir("/tmp", "minimal_storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+
+	b, err := NewBackend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.WriteTestBackend(t, b)
+	// badger only opens the closed databases in read-only mode
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ro, err := NewReadOnlyBackend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ro.Close()
+
+	storage.TestReadOnlyBackend(t, ro)
+}
+
+func TestBatchTooBig(t *testing.T) {
+	path, err := ioutil.TempDir("/tmp", "minimal_storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+
+	b, err := NewBackend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	// a batch over the size of a transaction fails instead of being split
+	batch := b.NewBatch()
+	for i := 0; i < 500000; i++ {
+		batch.Set([]byte(fmt.Sprintf("key-%d", i)), []byte{1})
+	}
+	assert.Error(t, batch.Write())
+
+	_, ok, err := b.Get([]byte("key-0"))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// BatchWriter buffers writes to the blockchain storage until Write is called.
+// The writes are applied atomically if the key-value database supports batches
+type BatchWriter interface {
+	WriteCanonicalHash(n uint64, hash types.Hash) error
+	DeleteCanonicalHash(n uint64) error
+	WriteHeadHash(h types.Hash) error
+	WriteHeadNumber(uint64) error
+	WriteForks(forks []types.Hash) error
+	WriteDiff(hash types.Hash, diff *big.Int) error
+	WriteHeader(h *types.Header) error
+	WriteCanonicalHeader(h *types.Header, diff *big.Int) error
+	WriteBody(hash types.Hash, body *types.Body) error
+	WriteReceipts(hash types.Hash, receipts []*types.Receipt) error
+	WriteTxLookup(hash types.Hash, lookup *TxLookup) error
+	WriteTxLookupTail(n uint64) error
+
+	// Size returns the number of bytes of the keys and values buffered in the batch
+	Size() int
+
+	// Write commits the writes of the batch
+	Write() error
+}
+
+// NewBatch creates a batch of writes to the storage
+func (s *KeyValueStorage) NewBatch() BatchWriter {
+	var batch Batch
+	if backend, ok := s.db.(Backend); ok {
+		batch = backend.NewBatch()
+	} else {
+		// the database does not support batches, the writes are
+		// applied in order once the batch is written
+		batch = &sequentialBatch{db: s.db}
+	}
+
+	kv := &batchKV{batch: batch}
+	return &kvBatchWriter{
+		KeyValueStorage: &KeyValueStorage{logger: s.logger, db: kv, compress: s.compress},
+		batch:           kv,
+	}
+}
+
+// kvBatchWriter uses the encoding of the key-value storage to write into a batch
+type kvBatchWriter struct {
+	*KeyValueStorage
+	batch *batchKV
+}
+
+// DeleteCanonicalHash removes the number from the canonical chain
+func (w *kvBatchWriter) DeleteCanonicalHash(n uint64) error {
+	w.batch.Delete(dbKey(CANONICAL, w.encodeUint(n)))
+	return nil
+}
+
+func (w *kvBatchWriter) Size() int {
+	return w.batch.size
+}
+
+func (w *kvBatchWriter) Write() error {
+	return w.batch.Write()
+}
+
+// batchKV adapts a batch to the KV interface so that the writes of
+// the key-value storage are buffered in the batch
+type batchKV struct {
+	batch Batch
+	size  int
+}
+
+func (b *batchKV) Set(p []byte, v []byte) error {
+	b.batch.Set(p, v)
+	b.size += len(p) + len(v)
+	return nil
+}
+
+func (b *batchKV) Delete(p []byte) {
+	b.batch.Delete(p)
+	b.size += len(p)
+}
+
+func (b *batchKV) Write() error {
+	return b.batch.Write()
+}
+
+func (b *batchKV) Get(p []byte) ([]byte, bool, error) {
+	return nil, false, fmt.Errorf("reads are not supported in a batch")
+}
+
+func (b *batchKV) Close() error {
+	return nil
+}
+
+// sequentialBatch is the batch of the databases without batches
+type sequentialBatch struct {
+	db     KV
+	keys   [][]byte
+	values [][]byte
+	err    error
+}
+
+func (b *sequentialBatch) Set(k, v []byte) {
+	b.keys = append(b.keys, append([]byte{}, k...))
+	b.values = append(b.values, append([]byte{}, v...))
+}
+
+func (b *sequentialBatch) Delete(k []byte) {
+	b.err = fmt.Errorf("deletions are not supported by the database")
+}
+
+func (b *sequentialBatch) Write() error {
+	if b.err != nil {
+		return b.err
+	}
+	for i, k := range b.keys {
+		if err := b.db.Set(k, b.values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// checkpointAttempts is the number of times a checkpoint is retried
+// when the database changes while it is created
+const checkpointAttempts = 5
+
+// CreateCheckpoint creates a checkpoint of a database that is open by another process
+// in a temporary dir next to it. The files reported as immutable (i.e. the tables) are
+// hard linked and the rest (i.e. the manifest and the journals) are copied. The checkpoint
+// is created again if the database moves to a new manifest or if any of its files is
+// removed by a compaction in the meantime
+func CreateCheckpoint(path string, immutable func(name string) bool) (string, error) {
+	var err error
+	for i := 0; i < checkpointAttempts; i++ {
+		var checkpoint string
+		var consistent bool
+		checkpoint, consistent, err = createCheckpoint(path, immutable)
+		if err != nil {
+			return "", err
+		}
+		if consistent {
+			return checkpoint, nil
+		}
+		if err := os.RemoveAll(checkpoint); err != nil {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("the database changed during %d attempts", checkpointAttempts)
+}
+
+func createCheckpoint(path string, immutable func(name string) bool) (string, bool, error) {
+	current, err := ioutil.ReadFile(filepath.Join(path, "CURRENT"))
+	if err != nil {
+		return "", false, err
+	}
+
+	checkpoint, err := ioutil.TempDir(filepath.Dir(path), filepath.Base(path)+".readonly")
+	if err != nil {
+		return "", false, err
+	}
+	consistent, err := copyCheckpointFiles(path, checkpoint, immutable)
+	if err == nil && consistent {
+		// the manifest copied must still be the current one
+		var now []byte
+		if now, err = ioutil.ReadFile(filepath.Join(path, "CURRENT")); err == nil {
+			consistent = bytes.Equal(current, now)
+		}
+	}
+	if err != nil {
+		os.RemoveAll(checkpoint)
+		return "", false, err
+	}
+	return checkpoint, consistent, nil
+}
+
+// copyCheckpointFiles copies the mutable files first and links the tables afterwards, so that
+// every table referenced by the copied manifest is linked. It reports false if a file was removed
+func copyCheckpointFiles(path, checkpoint string, immutable func(name string) bool) (bool, error) {
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return false, err
+	}
+	tables := map[string]struct{}{}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || name == "LOCK" || name == "LOG" || name == "LOG.old" {
+			continue
+		}
+		if immutable(name) {
+			tables[name] = struct{}{}
+			continue
+		}
+		if err := copyFile(filepath.Join(path, name), filepath.Join(checkpoint, name)); err != nil {
+			if os.IsNotExist(err) {
+				return false, nil
+			}
+			return false, err
+		}
+	}
+
+	// the tables created while the manifest was copied are linked too
+	if files, err = ioutil.ReadDir(path); err != nil {
+		return false, err
+	}
+	for _, file := range files {
+		if name := file.Name(); !file.IsDir() && immutable(name) {
+			tables[name] = struct{}{}
+		}
+	}
+	for name := range tables {
+		if err := os.Link(filepath.Join(path, name), filepath.Join(checkpoint, name)); err != nil {
+			if os.IsNotExist(err) {
+				return false, nil
+			}
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func copyFile(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/golang/snappy"
+)
+
+// compressedPrefix is the first byte of the compressed values. The values
+// encoded in rlp are lists, which never start with this byte
+const compressedPrefix = 0x00
+
+// compress returns the value compressed with snappy and tagged with the compressed prefix
+func compress(data []byte) []byte {
+	dst := make([]byte, 1+snappy.MaxEncodedLen(len(data)))
+	dst[0] = compressedPrefix
+	return dst[:1+len(snappy.Encode(dst[1:], data))]
+}
+
+// decompress returns the value as it was written, whether it was compressed or not
+func decompress(data []byte) ([]byte, error) {
+	if len(data) == 0 || data[0] != compressedPrefix {
+		return data, nil
+	}
+	raw, err := snappy.Decode(nil, data[1:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress value: %v", err)
+	}
+	return raw, nil
+}
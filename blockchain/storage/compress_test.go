@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompress(t *testing.T) {
+	data := bytes.Repeat([]byte{0xc0, 0x1}, 100)
+
+	compressed := compress(data)
+	assert.Equal(t, byte(compressedPrefix), compressed[0])
+	assert.Less(t, len(compressed), len(data))
+
+	raw, err := decompress(compressed)
+	assert.NoError(t, err)
+	assert.Equal(t, data, raw)
+
+	// the values that are not compressed are returned as they are
+	raw, err = decompress(data)
+	assert.NoError(t, err)
+	assert.Equal(t, data, raw)
+
+	_, err = decompress([]byte{compressedPrefix, 0xff})
+	assert.Error(t, err)
+}
+
+func TestFreezerStorage_Compression(t *testing.T) {
+	TestStorage(t, func(t *testing.T) (Storage, func()) {
+		s, close := newFreezerStorage(t)
+		s.SetCompression(true)
+		return s, close
+	})
+}
+
+func TestFreezerStorage_CompressionToggle(t *testing.T) {
+	s, close := newFreezerStorage(t)
+	defer close()
+
+	input := []byte{}
+	for i := 0; i < 100; i++ {
+		input = append(input, 0x1, 0x2, 0x3, 0x4)
+	}
+	body := &types.Body{
+		Transactions: []*types.Transaction{
+			{Value: big.NewInt(1), GasPrice: big.NewInt(1), Input: input},
+		},
+		Uncles: []*types.Header{},
+	}
+	receipts := []*types.Receipt{
+		{CumulativeGasUsed: 1, Logs: []*types.Log{{Data: input}}},
+	}
+
+	headers := []*types.Header{}
+	for i := uint64(0); i < 4; i++ {
+		h := &types.Header{
+			Number:    i,
+			ExtraData: []byte{},
+		}
+		h.ComputeHash()
+		headers = append(headers, h)
+
+		// the blocks are written before and after the compression is enabled
+		s.SetCompression(i >= 2)
+
+		assert.NoError(t, s.WriteCanonicalHeader(h, big.NewInt(1)))
+		if i == 3 {
+			batch := s.NewBatch()
+			assert.NoError(t, batch.WriteBody(h.Hash, body))
+			assert.NoError(t, batch.WriteReceipts(h.Hash, receipts))
+			assert.NoError(t, batch.Write())
+		} else {
+			assert.NoError(t, s.WriteBody(h.Hash, body))
+			assert.NoError(t, s.WriteReceipts(h.Hash, receipts))
+		}
+	}
+
+	raw, _ := s.get(BODY, headers[1].Hash.Bytes())
+	assert.NotEqual(t, byte(compressedPrefix), raw[0])
+
+	for _, h := range headers[2:] {
+		compressed, _ := s.get(BODY, h.Hash.Bytes())
+		assert.Equal(t, byte(compressedPrefix), compressed[0])
+		assert.Less(t, len(compressed), len(raw))
+
+		compressed, _ = s.get(RECEIPTS, h.Hash.Bytes())
+		assert.Equal(t, byte(compressedPrefix), compressed[0])
+	}
+
+	// the compressed blocks are moved to the freezer as they are
+	assert.NoError(t, s.Freeze(3))
+
+	for _, h := range headers {
+		b, err := s.ReadBody(h.Hash)
+		assert.NoError(t, err)
+		assert.Equal(t, input, b.Transactions[0].Input)
+
+		rr, err := s.ReadReceipts(h.Hash)
+		assert.NoError(t, err)
+		assert.Equal(t, input, rr[0].Logs[0].Data)
+	}
+}
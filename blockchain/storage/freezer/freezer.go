@@ -0,0 +1,164 @@
+package freezer
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Tables of the freezer
+const (
+	HashTable     = "hashes"
+	HeaderTable   = "headers"
+	BodyTable     = "bodies"
+	ReceiptsTable = "receipts"
+)
+
+var tableNames = []string{
+	HashTable,
+	HeaderTable,
+	BodyTable,
+	ReceiptsTable,
+}
+
+// Freezer is an append-only store of the ancient canonical blocks indexed by number.
+// The blocks in the freezer are immutable and are not stored in the key-value database
+type Freezer struct {
+	lock     sync.RWMutex
+	tables   map[string]*table
+	ancients uint64
+	readOnly bool
+}
+
+// Open opens the freezer in the given path
+func Open(path string) (*Freezer, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+	return open(path, false)
+}
+
+// OpenReadOnly opens the freezer in the given path in read-only mode. The blocks
+// appended after it is opened, i.e. by a running node, are not visible
+func OpenReadOnly(path string) (*Freezer, error) {
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			// the data dirs created before the freezer have no ancient blocks
+			return &Freezer{tables: map[string]*table{}, readOnly: true}, nil
+		}
+		return nil, err
+	}
+	return open(path, true)
+}
+
+func open(path string, readOnly bool) (*Freezer, error) {
+	f := &Freezer{
+		tables:   map[string]*table{},
+		readOnly: readOnly,
+	}
+	for _, name := range tableNames {
+		t, err := openTable(path, name, readOnly)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		f.tables[name] = t
+	}
+
+	// the tables might have a different number of items if an
+	// append was interrupted. Keep only the complete blocks
+	ancients := f.tables[HashTable].Items()
+	for _, t := range f.tables {
+		if items := t.Items(); items < ancients {
+			ancients = items
+		}
+	}
+	for _, t := range f.tables {
+		if err := t.truncate(ancients); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	f.ancients = ancients
+
+	return f, nil
+}
+
+// Ancients returns the number of blocks in the freezer
+func (f *Freezer) Ancients() uint64 {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.ancients
+}
+
+// Append appends a block to the freezer. The blocks must be appended in order
+func (f *Freezer) Append(number uint64, hash, header, body, receipts []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.readOnly {
+		return fmt.Errorf("the freezer is open in read-only mode")
+	}
+	if number != f.ancients {
+		return fmt.Errorf("expected block %d but found %d", f.ancients, number)
+	}
+
+	items := map[string][]byte{
+		HashTable:     hash,
+		HeaderTable:   header,
+		BodyTable:     body,
+		ReceiptsTable: receipts,
+	}
+	for name, item := range items {
+		if err := f.tables[name].Append(item); err != nil {
+			// drop the partial block
+			for _, t := range f.tables {
+				t.truncate(f.ancients)
+			}
+			return err
+		}
+	}
+
+	f.ancients++
+	return nil
+}
+
+// Retrieve returns the item of the table for the given block number
+func (f *Freezer) Retrieve(kind string, number uint64) ([]byte, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	if number >= f.ancients {
+		return nil, fmt.Errorf("block %d not in the freezer", number)
+	}
+	t, ok := f.tables[kind]
+	if !ok {
+		return nil, fmt.Errorf("table '%s' not found", kind)
+	}
+	return t.Retrieve(number)
+}
+
+// Sync flushes the freezer to disk
+func (f *Freezer) Sync() error {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	for _, t := range f.tables {
+		if err := t.Sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the freezer
+func (f *Freezer) Close() error {
+	var err error
+	for _, t := range f.tables {
+		if closeErr := t.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
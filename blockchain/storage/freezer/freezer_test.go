@@ -0,0 +1,133 @@
+package freezer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFreezer(t *testing.T) (*Freezer, string) {
+	path, err := ioutil.TempDir("/tmp", "minimal_freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, path
+}
+
+func TestFreezer_AppendRetrieve(t *testing.T) {
+	f, path := newTestFreezer(t)
+	defer os.RemoveAll(path)
+
+	for i := 0; i < 3; i++ {
+		b := byte(i)
+		assert.NoError(t, f.Append(uint64(i), []byte{b}, []byte{b, 1}, []byte{b, 2}, []byte{}))
+	}
+	assert.Equal(t, uint64(3), f.Ancients())
+
+	// blocks must be appended in order
+	assert.Error(t, f.Append(5, nil, nil, nil, nil))
+
+	data, err := f.Retrieve(HeaderTable, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 1}, data)
+
+	data, err = f.Retrieve(ReceiptsTable, 2)
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+
+	_, err = f.Retrieve(BodyTable, 3)
+	assert.Error(t, err)
+
+	// the blocks persist after reopening the freezer
+	assert.NoError(t, f.Close())
+
+	f, err = Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	assert.Equal(t, uint64(3), f.Ancients())
+
+	data, err = f.Retrieve(BodyTable, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{2, 2}, data)
+}
+
+func TestFreezer_Repair(t *testing.T) {
+	f, path := newTestFreezer(t)
+	defer os.RemoveAll(path)
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, f.Append(uint64(i), []byte{1}, []byte{2}, []byte{3}, []byte{4}))
+	}
+	assert.NoError(t, f.Close())
+
+	// simulate an interrupted append of the third block
+	data, err := os.OpenFile(filepath.Join(path, HashTable+".dat"), os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = data.Write([]byte{1, 2, 3})
+	assert.NoError(t, err)
+	data.Close()
+
+	index, err := os.OpenFile(filepath.Join(path, HeaderTable+".idx"), os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = index.Write([]byte{0, 0, 0, 0, 0, 0, 0, 100})
+	assert.NoError(t, err)
+	index.Close()
+
+	f, err = Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	assert.Equal(t, uint64(2), f.Ancients())
+	assert.NoError(t, f.Append(2, []byte{5}, []byte{6}, []byte{7}, []byte{8}))
+
+	data2, err := f.Retrieve(HashTable, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{5}, data2)
+}
+
+func TestFreezer_ReadOnly(t *testing.T) {
+	f, path := newTestFreezer(t)
+	defer os.RemoveAll(path)
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, f.Append(uint64(i), []byte{1}, []byte{2}, []byte{3}, []byte{4}))
+	}
+
+	// simulate an append in progress of the third block
+	index, err := os.OpenFile(filepath.Join(path, HeaderTable+".idx"), os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = index.Write([]byte{0, 0, 0, 0, 0, 0, 0, 100})
+	assert.NoError(t, err)
+	index.Close()
+
+	ro, err := OpenReadOnly(path)
+	assert.NoError(t, err)
+	defer ro.Close()
+
+	assert.Equal(t, uint64(2), ro.Ancients())
+	data, err := ro.Retrieve(BodyTable, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{3}, data)
+
+	assert.Error(t, ro.Append(2, []byte{5}, []byte{6}, []byte{7}, []byte{8}))
+
+	// the partial block is not truncated from the files
+	stat, err := os.Stat(filepath.Join(path, HeaderTable+".idx"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3*indexEntrySize), stat.Size())
+
+	// a missing freezer has no blocks
+	empty, err := OpenReadOnly(filepath.Join(path, "missing"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), empty.Ancients())
+	_, err = empty.Retrieve(HashTable, 0)
+	assert.Error(t, err)
+}
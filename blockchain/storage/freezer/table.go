@@ -0,0 +1,201 @@
+package freezer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// indexEntrySize is the size of an entry of the index file
+const indexEntrySize = 8
+
+// table is an append-only flat file of items. The data file holds the
+// items back to back and the index file holds the end offset of each item
+type table struct {
+	lock  sync.RWMutex
+	data  *os.File
+	index *os.File
+	items uint64 // number of items in the table
+	size  uint64 // size of the data file
+
+	// the files of a read-only table are not truncated, the
+	// items after the truncation are only dropped from the table
+	readOnly bool
+}
+
+func openTable(path string, name string, readOnly bool) (*table, error) {
+	flag := os.O_RDWR | os.O_CREATE
+	if readOnly {
+		flag = os.O_RDONLY
+	}
+	data, err := os.OpenFile(filepath.Join(path, name+".dat"), flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+	index, err := os.OpenFile(filepath.Join(path, name+".idx"), flag, 0644)
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+
+	t := &table{
+		data:     data,
+		index:    index,
+		readOnly: readOnly,
+	}
+	if err := t.repair(); err != nil {
+		t.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// repair drops the items that were not fully written, i.e. an append
+// interrupted between the write of the data and the write of the index
+func (t *table) repair() error {
+	indexStat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	dataStat, err := t.data.Stat()
+	if err != nil {
+		return err
+	}
+
+	items := uint64(indexStat.Size()) / indexEntrySize
+	dataSize := uint64(dataStat.Size())
+
+	for ; items > 0; items-- {
+		end, err := t.readOffset(items - 1)
+		if err != nil {
+			return err
+		}
+		if end <= dataSize {
+			break
+		}
+	}
+	return t.truncateLocked(items)
+}
+
+func (t *table) readOffset(item uint64) (uint64, error) {
+	buf := make([]byte, indexEntrySize)
+	if _, err := t.index.ReadAt(buf, int64(item*indexEntrySize)); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf), nil
+}
+
+// truncateLocked drops all the items from the given one onwards
+func (t *table) truncateLocked(items uint64) error {
+	size := uint64(0)
+	if items > 0 {
+		end, err := t.readOffset(items - 1)
+		if err != nil {
+			return err
+		}
+		size = end
+	}
+	if t.readOnly {
+		t.items = items
+		t.size = size
+		return nil
+	}
+	if err := t.index.Truncate(int64(items * indexEntrySize)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(size)); err != nil {
+		return err
+	}
+	t.items = items
+	t.size = size
+	return nil
+}
+
+// truncate drops all the items from the given one onwards
+func (t *table) truncate(items uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if items >= t.items {
+		return nil
+	}
+	return t.truncateLocked(items)
+}
+
+// Items returns the number of items in the table
+func (t *table) Items() uint64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.items
+}
+
+// Append appends an item at the end of the table
+func (t *table) Append(item []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, err := t.data.WriteAt(item, int64(t.size)); err != nil {
+		return err
+	}
+
+	end := t.size + uint64(len(item))
+
+	buf := make([]byte, indexEntrySize)
+	binary.BigEndian.PutUint64(buf, end)
+	if _, err := t.index.WriteAt(buf, int64(t.items*indexEntrySize)); err != nil {
+		return err
+	}
+
+	t.items++
+	t.size = end
+	return nil
+}
+
+// Retrieve returns the item at the given position
+func (t *table) Retrieve(item uint64) ([]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if item >= t.items {
+		return nil, fmt.Errorf("item %d out of bounds (%d items)", item, t.items)
+	}
+
+	start := uint64(0)
+	if item > 0 {
+		var err error
+		if start, err = t.readOffset(item - 1); err != nil {
+			return nil, err
+		}
+	}
+	end, err := t.readOffset(item)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, end-start)
+	if _, err := t.data.ReadAt(buf, int64(start)); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Sync flushes the table files to disk
+func (t *table) Sync() error {
+	if err := t.data.Sync(); err != nil {
+		return err
+	}
+	return t.index.Sync()
+}
+
+// Close closes the table files
+func (t *table) Close() error {
+	dataErr := t.data.Close()
+	indexErr := t.index.Close()
+	if dataErr != nil {
+		return dataErr
+	}
+	return indexErr
+}
@@ -38,13 +38,28 @@ var (
 
 	// TX_LOOKUP_PREFIX is the prefix for transaction lookups
 	TX_LOOKUP_PREFIX = []byte("l")
+
+	// ANCIENT is the prefix for the numbers of the blocks moved to the freezer
+	ANCIENT = []byte("a")
+
+	// BLOOM_BITS is the prefix for the bloom bits index of the logs blooms
+	BLOOM_BITS = []byte("B")
+
+	// CHAIN_CONFIG is the prefix for the chain config the blocks were processed with
+	CHAIN_CONFIG = []byte("C")
+
+	// INDEXER is the prefix for the progress of the chain indexers
+	INDEXER = []byte("I")
 )
 
 // Sub-prefixes
 var (
-	HASH   = []byte("hash")
-	NUMBER = []byte("number")
-	EMPTY  = []byte("empty")
+	HASH     = []byte("hash")
+	NUMBER   = []byte("number")
+	EMPTY    = []byte("empty")
+	TAIL     = []byte("tail")
+	SECTIONS = []byte("sections")
+	SCHEDULE = []byte("schedule")
 )
 
 // KV is a key value storage interface.
@@ -61,12 +76,21 @@ type KeyValueStorage struct {
 	logger hclog.Logger
 	db     KV
 	Db     KV
+
+	// compress enables the compression of the bodies and the receipts written
+	compress bool
 }
 
 func NewKeyValueStorage(logger hclog.Logger, db KV) Storage {
 	return &KeyValueStorage{logger: logger, db: db}
 }
 
+// SetCompression enables or disables the compression of the bodies and the receipts
+// written from now on. The values are read whether they are compressed or not
+func (s *KeyValueStorage) SetCompression(enabled bool) {
+	s.compress = enabled
+}
+
 func (s *KeyValueStorage) encodeUint(n uint64) []byte {
 	b := make([]byte, 8)
 	binary.BigEndian.PutUint64(b[:], n)
@@ -195,7 +219,7 @@ func (s *KeyValueStorage) WriteCanonicalHeader(h *types.Header, diff *big.Int) e
 
 // WriteBody writes the body
 func (s *KeyValueStorage) WriteBody(hash types.Hash, body *types.Body) error {
-	return s.writeRLP(BODY, hash.Bytes(), body)
+	return s.writeCompressedRLP(BODY, hash.Bytes(), body)
 }
 
 // ReadBody reads the body
@@ -226,7 +250,7 @@ func (s *KeyValueStorage) ReadSnapshot(hash types.Hash) ([]byte, bool) {
 // WriteReceipts writes the receipts
 func (s *KeyValueStorage) WriteReceipts(hash types.Hash, receipts []*types.Receipt) error {
 	rr := types.Receipts(receipts)
-	return s.writeRLP(RECEIPTS, hash.Bytes(), &rr)
+	return s.writeCompressedRLP(RECEIPTS, hash.Bytes(), &rr)
 }
 
 // ReadReceipts reads the receipts
@@ -238,42 +262,160 @@ func (s *KeyValueStorage) ReadReceipts(hash types.Hash) ([]*types.Receipt, error
 
 // TX LOOKUP //
 
-// WriteTxLookup maps the transaction hash to the block hash
-func (s *KeyValueStorage) WriteTxLookup(hash types.Hash, blockHash types.Hash) error {
+// TxLookup is the position of a transaction in the chain
+type TxLookup struct {
+	BlockHash   types.Hash
+	BlockNumber uint64
+	Index       uint64
+
+	// Legacy is set for the lookups written before the position was
+	// indexed. Only the block hash is set in that case
+	Legacy bool
+}
+
+// WriteTxLookup maps the transaction hash to its position in the chain
+func (s *KeyValueStorage) WriteTxLookup(hash types.Hash, lookup *TxLookup) error {
 	ar := &fastrlp.Arena{}
-	vr := ar.NewBytes(blockHash.Bytes())
+	vr := ar.NewArray()
+	vr.Set(ar.NewBytes(lookup.BlockHash.Bytes()))
+	vr.Set(ar.NewUint(lookup.BlockNumber))
+	vr.Set(ar.NewUint(lookup.Index))
 	return s.write2(TX_LOOKUP_PREFIX, hash.Bytes(), vr)
 }
 
-// ReadTxLookup reads the block hash using the transaction hash
-func (s *KeyValueStorage) ReadTxLookup(hash types.Hash) (types.Hash, bool) {
+// ReadTxLookup reads the position of the transaction using its hash
+func (s *KeyValueStorage) ReadTxLookup(hash types.Hash) (*TxLookup, bool) {
 	parser := &fastrlp.Parser{}
 	v := s.read2(TX_LOOKUP_PREFIX, hash.Bytes(), parser)
 	if v == nil {
-		return types.Hash{}, false
+		return nil, false
 	}
 
-	blockHash := []byte{}
-	blockHash, err := v.GetBytes(blockHash[:0], 32)
-	if err != nil {
-		panic(err)
+	if v.Type() == fastrlp.TypeBytes {
+		// legacy lookup with only the block hash
+		blockHash, err := v.GetBytes(nil, 32)
+		if err != nil {
+			return nil, false
+		}
+		return &TxLookup{BlockHash: types.BytesToHash(blockHash), Legacy: true}, true
+	}
+
+	elems, err := v.GetElems()
+	if err != nil || len(elems) != 3 {
+		return nil, false
+	}
+
+	lookup := &TxLookup{}
+	if err := elems[0].GetHash(lookup.BlockHash[:]); err != nil {
+		return nil, false
+	}
+	if lookup.BlockNumber, err = elems[1].GetUint64(); err != nil {
+		return nil, false
+	}
+	if lookup.Index, err = elems[2].GetUint64(); err != nil {
+		return nil, false
+	}
+	return lookup, true
+}
+
+// ReadTxLookupTail returns the number of the oldest block whose transactions are indexed
+func (s *KeyValueStorage) ReadTxLookupTail() (uint64, bool) {
+	data, ok := s.get(TX_LOOKUP_PREFIX, TAIL)
+	if !ok || len(data) != 8 {
+		return 0, false
+	}
+	return s.decodeUint(data), true
+}
+
+// WriteTxLookupTail writes the number of the oldest block whose transactions are indexed
+func (s *KeyValueStorage) WriteTxLookupTail(n uint64) error {
+	return s.set(TX_LOOKUP_PREFIX, TAIL, s.encodeUint(n))
+}
+
+// -- bloom bits --
+
+func (s *KeyValueStorage) bloomBitsKey(bit uint, section uint64) []byte {
+	k := make([]byte, 10)
+	binary.BigEndian.PutUint64(k[0:8], section)
+	binary.BigEndian.PutUint16(k[8:10], uint16(bit))
+	return k
+}
+
+// ReadBloomBits reads the bit vector of a bloom bit for a section of blocks
+func (s *KeyValueStorage) ReadBloomBits(bit uint, section uint64) ([]byte, bool) {
+	return s.get(BLOOM_BITS, s.bloomBitsKey(bit, section))
+}
+
+// WriteBloomBits writes the bit vector of a bloom bit for a section of blocks
+func (s *KeyValueStorage) WriteBloomBits(bit uint, section uint64, bits []byte) error {
+	return s.set(BLOOM_BITS, s.bloomBitsKey(bit, section), bits)
+}
+
+// ReadBloomSections returns the number of sections indexed in the bloom bits
+func (s *KeyValueStorage) ReadBloomSections() (uint64, bool) {
+	data, ok := s.get(BLOOM_BITS, SECTIONS)
+	if !ok || len(data) != 8 {
+		return 0, false
 	}
+	return s.decodeUint(data), true
+}
 
-	return types.BytesToHash(blockHash), true
+// WriteBloomSections writes the number of sections indexed in the bloom bits
+func (s *KeyValueStorage) WriteBloomSections(n uint64) error {
+	return s.set(BLOOM_BITS, SECTIONS, s.encodeUint(n))
+}
+
+// CHAIN CONFIG //
+
+// ReadForkSchedule reads the encoded fork schedule of the chain
+func (s *KeyValueStorage) ReadForkSchedule() ([]byte, bool) {
+	return s.get(CHAIN_CONFIG, SCHEDULE)
+}
+
+// WriteForkSchedule writes the encoded fork schedule of the chain
+func (s *KeyValueStorage) WriteForkSchedule(data []byte) error {
+	return s.set(CHAIN_CONFIG, SCHEDULE, data)
+}
+
+// INDEXERS //
+
+// ReadIndexerHead reads the hash of the last block processed by the chain indexer
+func (s *KeyValueStorage) ReadIndexerHead(name string) (types.Hash, bool) {
+	data, ok := s.get(INDEXER, []byte(name))
+	if !ok || len(data) != types.HashLength {
+		return types.Hash{}, false
+	}
+	return types.BytesToHash(data), true
+}
+
+// WriteIndexerHead writes the hash of the last block processed by the chain indexer
+func (s *KeyValueStorage) WriteIndexerHead(name string, hash types.Hash) error {
+	return s.set(INDEXER, []byte(name), hash.Bytes())
 }
 
 // WRITE OPERATIONS //
 
 func (s *KeyValueStorage) writeRLP(p, k []byte, raw types.RLPMarshaler) error {
-	var data []byte
-	if obj, ok := raw.(types.RLPStoreMarshaler); ok {
-		data = obj.MarshalStoreRLPTo(nil)
-	} else {
-		data = raw.MarshalRLPTo(nil)
+	return s.set(p, k, encodeRLP(raw))
+}
+
+// writeCompressedRLP writes the value compressed if the compression is enabled
+func (s *KeyValueStorage) writeCompressedRLP(p, k []byte, raw types.RLPMarshaler) error {
+	data := encodeRLP(raw)
+	if s.compress {
+		data = compress(data)
 	}
 	return s.set(p, k, data)
 }
 
+// encodeRLP encodes the value in the format in which it is stored
+func encodeRLP(raw types.RLPMarshaler) []byte {
+	if obj, ok := raw.(types.RLPStoreMarshaler); ok {
+		return obj.MarshalStoreRLPTo(nil)
+	}
+	return raw.MarshalRLPTo(nil)
+}
+
 var ErrNotFound = fmt.Errorf("not found")
 
 func (s *KeyValueStorage) readRLP(p, k []byte, raw types.RLPUnmarshaler) error {
@@ -285,6 +427,15 @@ func (s *KeyValueStorage) readRLP(p, k []byte, raw types.RLPUnmarshaler) error {
 	if !ok {
 		return ErrNotFound
 	}
+	return decodeRLP(data, raw)
+}
+
+// decodeRLP decodes the data in the format in which it is stored
+func decodeRLP(data []byte, raw types.RLPUnmarshaler) error {
+	data, err := decompress(data)
+	if err != nil {
+		return err
+	}
 	if obj, ok := raw.(types.RLPStoreUnmarshaler); ok {
 		// decode in the store format
 		if err := obj.UnmarshalStoreRLP(data); err != nil {
@@ -2,10 +2,16 @@ package leveldb
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"syscall"
 
 	"github.com/0xPolygon/minimal/blockchain/storage"
 	"github.com/hashicorp/go-hclog"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	lvlstorage "github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 // Factory creates a leveldb storage
@@ -23,18 +29,72 @@ func Factory(config map[string]interface{}, logger hclog.Logger) (storage.Storag
 
 // NewLevelDBStorage creates the new storage reference with leveldb
 func NewLevelDBStorage(path string, logger hclog.Logger) (storage.Storage, error) {
+	kv, err := NewBackend(path)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewKeyValueStorage(logger.Named("leveldb"), kv), nil
+}
+
+// NewBackend opens a leveldb backend in the given path
+func NewBackend(path string) (storage.Backend, error) {
 	db, err := leveldb.OpenFile(path, nil)
 	if err != nil {
 		return nil, err
 	}
+	return &levelDBKV{db: db}, nil
+}
 
-	kv := &levelDBKV{db}
-	return storage.NewKeyValueStorage(logger.Named("leveldb"), kv), nil
+// NewReadOnlyBackend opens a leveldb backend in the given path in read-only mode.
+// A running node holds the lock of the database, in which case a checkpoint
+// of its files is opened instead
+func NewReadOnlyBackend(path string) (storage.Backend, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	stor, err := lvlstorage.OpenFile(path, true)
+	if err == nil {
+		return openReadOnly(stor, "")
+	}
+
+	if err != syscall.EWOULDBLOCK {
+		// the database is not locked, it failed to open for another reason
+		return nil, err
+	}
+	checkpoint, err := storage.CreateCheckpoint(path, func(name string) bool {
+		return strings.HasSuffix(name, ".ldb") || strings.HasSuffix(name, ".sst")
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a checkpoint of %s: %v", path, err)
+	}
+	stor, err = lvlstorage.OpenFile(checkpoint, true)
+	if err != nil {
+		os.RemoveAll(checkpoint)
+		return nil, err
+	}
+	return openReadOnly(stor, checkpoint)
+}
+
+func openReadOnly(stor lvlstorage.Storage, checkpoint string) (storage.Backend, error) {
+	db, err := leveldb.Open(stor, &opt.Options{ReadOnly: true, ErrorIfMissing: true})
+	if err != nil {
+		stor.Close()
+		if checkpoint != "" {
+			os.RemoveAll(checkpoint)
+		}
+		return nil, err
+	}
+	return storage.NewReadOnlyBackend(&levelDBKV{db: db, stor: stor, checkpoint: checkpoint}), nil
 }
 
 // levelDBKV is the leveldb implementation of the kv storage
 type levelDBKV struct {
 	db *leveldb.DB
+
+	// the storage and the checkpoint of the read-only backends
+	stor       lvlstorage.Storage
+	checkpoint string
 }
 
 // Set sets the key-value pair in leveldb storage
@@ -56,7 +116,58 @@ func (l *levelDBKV) Get(p []byte) ([]byte, bool, error) {
 	return data, true, nil
 }
 
+// NewBatch creates a new leveldb batch
+func (l *levelDBKV) NewBatch() storage.Batch {
+	return &levelDBBatch{db: l.db, batch: &leveldb.Batch{}}
+}
+
+// Iterate iterates over the key-value pairs with the prefix in leveldb storage
+func (l *levelDBKV) Iterate(prefix []byte, fn func(k, v []byte) bool) error {
+	iter := l.db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		if !fn(iter.Key(), iter.Value()) {
+			break
+		}
+	}
+	return iter.Error()
+}
+
+// Compact compacts all the key range of the leveldb storage
+func (l *levelDBKV) Compact() error {
+	return l.db.CompactRange(util.Range{})
+}
+
 // Close closes the leveldb storage instance
 func (l *levelDBKV) Close() error {
-	return l.db.Close()
+	err := l.db.Close()
+	if l.stor != nil {
+		// the storage is not closed by the db if it was opened by the caller
+		l.stor.Close()
+	}
+	if l.checkpoint != "" {
+		if removeErr := os.RemoveAll(l.checkpoint); removeErr != nil && err == nil {
+			err = removeErr
+		}
+	}
+	return err
+}
+
+// levelDBBatch is a batch write for leveldb
+type levelDBBatch struct {
+	db    *leveldb.DB
+	batch *leveldb.Batch
+}
+
+func (b *levelDBBatch) Set(k, v []byte) {
+	b.batch.Put(k, v)
+}
+
+func (b *levelDBBatch) Delete(k []byte) {
+	b.batch.Delete(k)
+}
+
+func (b *levelDBBatch) Write() error {
+	return b.db.Write(b.batch, nil)
 }
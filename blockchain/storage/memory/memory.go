@@ -1,6 +1,10 @@
 package memory
 
 import (
+	"bytes"
+	"sort"
+	"sync"
+
 	"github.com/0xPolygon/minimal/blockchain/storage"
 	"github.com/0xPolygon/minimal/helper/hex"
 	"github.com/hashicorp/go-hclog"
@@ -8,21 +12,32 @@ import (
 
 // NewMemoryStorage creates the new storage reference with inmemory
 func NewMemoryStorage(logger hclog.Logger) (storage.Storage, error) {
-	db := &memoryKV{map[string][]byte{}}
-	return storage.NewKeyValueStorage(logger, db), nil
+	return storage.NewKeyValueStorage(logger, NewBackend()), nil
+}
+
+// NewBackend creates an in memory backend
+func NewBackend() storage.Backend {
+	return &memoryKV{db: map[string][]byte{}}
 }
 
 // memoryKV is an in memory implementation of the kv storage
 type memoryKV struct {
-	db map[string][]byte
+	lock sync.RWMutex
+	db   map[string][]byte
 }
 
 func (m *memoryKV) Set(p []byte, v []byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	m.db[hex.EncodeToHex(p)] = v
 	return nil
 }
 
 func (m *memoryKV) Get(p []byte) ([]byte, bool, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
 	v, ok := m.db[hex.EncodeToHex(p)]
 	if !ok {
 		return nil, false, nil
@@ -30,6 +45,73 @@ func (m *memoryKV) Get(p []byte) ([]byte, bool, error) {
 	return v, true, nil
 }
 
+func (m *memoryKV) NewBatch() storage.Batch {
+	return &memoryBatch{kv: m}
+}
+
+func (m *memoryKV) Iterate(prefix []byte, fn func(k, v []byte) bool) error {
+	m.lock.RLock()
+	keys := [][]byte{}
+	for k := range m.db {
+		key, err := hex.DecodeHex(k)
+		if err != nil {
+			m.lock.RUnlock()
+			return err
+		}
+		if bytes.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	m.lock.RUnlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+	for _, k := range keys {
+		v, ok, _ := m.Get(k)
+		if !ok {
+			// removed during the iteration
+			continue
+		}
+		if !fn(k, v) {
+			break
+		}
+	}
+	return nil
+}
+
 func (m *memoryKV) Close() error {
 	return nil
 }
+
+// memoryBatch buffers the writes until the batch is written
+type memoryBatch struct {
+	kv     *memoryKV
+	keys   [][]byte
+	values [][]byte // a nil value deletes the key
+}
+
+func (b *memoryBatch) Set(k, v []byte) {
+	// the caller might reuse the slices before the batch is written
+	b.keys = append(b.keys, append([]byte{}, k...))
+	b.values = append(b.values, append([]byte{}, v...))
+}
+
+func (b *memoryBatch) Delete(k []byte) {
+	b.keys = append(b.keys, append([]byte{}, k...))
+	b.values = append(b.values, nil)
+}
+
+func (b *memoryBatch) Write() error {
+	b.kv.lock.Lock()
+	defer b.kv.lock.Unlock()
+
+	for i, k := range b.keys {
+		if b.values[i] == nil {
+			delete(b.kv.db, hex.EncodeToHex(k))
+		} else {
+			b.kv.db[hex.EncodeToHex(k)] = b.values[i]
+		}
+	}
+	return nil
+}
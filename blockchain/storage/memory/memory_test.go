@@ -13,3 +13,7 @@ func TestStorage(t *testing.T) {
 	}
 	storage.TestStorage(t, f)
 }
+
+func TestBackend(t *testing.T) {
+	storage.TestBackend(t, NewBackend())
+}
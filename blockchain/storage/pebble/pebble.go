@@ -0,0 +1,172 @@
+package pebble
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/hashicorp/go-hclog"
+)
+
+// NewPebbleStorage creates the new storage reference with pebble
+func NewPebbleStorage(path string, logger hclog.Logger) (storage.Storage, error) {
+	kv, err := NewBackend(path)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewKeyValueStorage(logger.Named("pebble"), kv), nil
+}
+
+// NewBackend opens a pebble backend in the given path
+func NewBackend(path string) (storage.Backend, error) {
+	db, err := pebble.Open(path, &pebble.Options{})
+	if err != nil {
+		return nil, err
+	}
+	return &pebbleKV{db: db}, nil
+}
+
+// NewReadOnlyBackend opens a pebble backend in the given path in read-only mode.
+// A running node holds the lock of the database, in which case a checkpoint
+// of its files is opened instead
+func NewReadOnlyBackend(path string) (storage.Backend, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	db, err := openReadOnly(path)
+	if err == nil {
+		return storage.NewReadOnlyBackend(&pebbleKV{db: db}), nil
+	}
+	if lock, lockErr := vfs.Default.Lock(filepath.Join(path, "LOCK")); lockErr == nil {
+		// the database is not locked, it failed to open for another reason
+		lock.Close()
+		return nil, err
+	}
+
+	checkpoint, err := storage.CreateCheckpoint(path, func(name string) bool {
+		return strings.HasSuffix(name, ".sst")
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a checkpoint of %s: %v", path, err)
+	}
+	if db, err = openReadOnly(checkpoint); err != nil {
+		os.RemoveAll(checkpoint)
+		return nil, err
+	}
+	return storage.NewReadOnlyBackend(&pebbleKV{db: db, checkpoint: checkpoint}), nil
+}
+
+func openReadOnly(path string) (*pebble.DB, error) {
+	return pebble.Open(path, &pebble.Options{
+		ReadOnly:         true,
+		ErrorIfNotExists: true,
+	})
+}
+
+// pebbleKV is the pebble implementation of the kv storage
+type pebbleKV struct {
+	db *pebble.DB
+
+	// the checkpoint of the read-only backends
+	checkpoint string
+}
+
+// Set sets the key-value pair in pebble storage
+func (p *pebbleKV) Set(k []byte, v []byte) error {
+	return p.db.Set(k, v, pebble.Sync)
+}
+
+// Get retrieves the key-value pair in pebble storage
+func (p *pebbleKV) Get(k []byte) ([]byte, bool, error) {
+	data, closer, err := p.db.Get(k)
+	if err != nil {
+		if err == pebble.ErrNotFound {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	defer closer.Close()
+
+	return append([]byte{}, data...), true, nil
+}
+
+// NewBatch creates a new pebble batch
+func (p *pebbleKV) NewBatch() storage.Batch {
+	return &pebbleBatch{batch: p.db.NewBatch()}
+}
+
+// Iterate iterates over the key-value pairs with the prefix in pebble storage
+func (p *pebbleKV) Iterate(prefix []byte, fn func(k, v []byte) bool) error {
+	iter := p.db.NewIter(&pebble.IterOptions{
+		LowerBound: prefix,
+		UpperBound: upperBound(prefix),
+	})
+	for iter.First(); iter.Valid(); iter.Next() {
+		if !fn(iter.Key(), iter.Value()) {
+			break
+		}
+	}
+	return iter.Close()
+}
+
+// Compact compacts the key range of the pebble storage between its first and last keys
+func (p *pebbleKV) Compact() error {
+	iter := p.db.NewIter(nil)
+	if !iter.First() {
+		return iter.Close()
+	}
+	start := append([]byte{}, iter.Key()...)
+	iter.Last()
+	// the end of the range is exclusive
+	end := append(append([]byte{}, iter.Key()...), 0)
+	if err := iter.Close(); err != nil {
+		return err
+	}
+	return p.db.Compact(start, end)
+}
+
+// Close closes the pebble storage instance
+func (p *pebbleKV) Close() error {
+	err := p.db.Close()
+	if p.checkpoint != "" {
+		if removeErr := os.RemoveAll(p.checkpoint); removeErr != nil && err == nil {
+			err = removeErr
+		}
+	}
+	return err
+}
+
+// upperBound returns the smallest key greater than all the keys
+// with the given prefix, or nil if there is none
+func upperBound(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// pebbleBatch is a batch write for pebble
+type pebbleBatch struct {
+	batch *pebble.Batch
+}
+
+func (b *pebbleBatch) Set(k, v []byte) {
+	// Set on a batch only fails if the batch is already committed
+	_ = b.batch.Set(k, v, nil)
+}
+
+func (b *pebbleBatch) Delete(k []byte) {
+	_ = b.batch.Delete(k, nil)
+}
+
+func (b *pebbleBatch) Write() error {
+	return b.batch.Commit(pebble.Sync)
+}
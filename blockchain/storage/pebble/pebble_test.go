@@ -0,0 +1,83 @@
+package pebble
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func newStorage(t *testing.T) (storage.Storage, func()) {
+	path, err := ioutil.TempDir("/tmp", "minimal_storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewPebbleStorage(path, hclog.NewNullLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	close := func() {
+		if err := os.RemoveAll(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s, close
+}
+
+func TestStorage(t *testing.T) {
+	storage.TestStorage(t, newStorage)
+}
+
+func TestBackend(t *testing.T) {
+	path, err := ioutil.TempDir("/tmp", "minimal_storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+
+	b, err := NewBackend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	storage.TestBackend(t, b)
+}
+
+func TestReadOnlyBackend(t *testing.T) {
+	path, err := ioutil.TempDir("/tmp", "minimal_storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+
+	b, err := NewBackend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.WriteTestBackend(t, b)
+	defer b.Close()
+
+	// the backend is locked, a checkpoint is opened
+	ro, err := NewReadOnlyBackend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.TestReadOnlyBackend(t, ro)
+
+	// the writes after the checkpoint are not visible
+	assert.NoError(t, b.Set([]byte("c"), []byte{4}))
+	_, ok, err := ro.Get([]byte("c"))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// the checkpoint is removed on close
+	assert.NoError(t, ro.Close())
+	checkpoints, err := filepath.Glob(path + ".readonly*")
+	assert.NoError(t, err)
+	assert.Empty(t, checkpoints)
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"fmt"
+)
+
+// ErrReadOnly is returned by the writes to a backend opened in read-only mode
+var ErrReadOnly = fmt.Errorf("the database is open in read-only mode")
+
+// ReadOnlyBackendFactory opens a backend in the given path in read-only mode
+type ReadOnlyBackendFactory func(path string) (Backend, error)
+
+// NewReadOnlyBackend wraps a backend so that all the writes fail with ErrReadOnly,
+// even the ones the database itself would accept
+func NewReadOnlyBackend(b Backend) Backend {
+	return &readOnlyBackend{b}
+}
+
+type readOnlyBackend struct {
+	Backend
+}
+
+func (r *readOnlyBackend) Set(p []byte, v []byte) error {
+	return ErrReadOnly
+}
+
+func (r *readOnlyBackend) NewBatch() Batch {
+	return readOnlyBatch{}
+}
+
+// readOnlyBatch discards the writes and fails when it is written
+type readOnlyBatch struct{}
+
+func (readOnlyBatch) Set(k, v []byte) {}
+
+func (readOnlyBatch) Delete(k []byte) {}
+
+func (readOnlyBatch) Write() error {
+	return ErrReadOnly
+}
@@ -37,8 +37,26 @@ type Storage interface {
 	WriteReceipts(hash types.Hash, receipts []*types.Receipt) error
 	ReadReceipts(hash types.Hash) ([]*types.Receipt, error)
 
-	WriteTxLookup(hash types.Hash, blockHash types.Hash) error
-	ReadTxLookup(hash types.Hash) (types.Hash, bool)
+	WriteTxLookup(hash types.Hash, lookup *TxLookup) error
+	ReadTxLookup(hash types.Hash) (*TxLookup, bool)
+
+	WriteTxLookupTail(n uint64) error
+	ReadTxLookupTail() (uint64, bool)
+
+	WriteBloomBits(bit uint, section uint64, bits []byte) error
+	ReadBloomBits(bit uint, section uint64) ([]byte, bool)
+
+	WriteBloomSections(n uint64) error
+	ReadBloomSections() (uint64, bool)
+
+	WriteForkSchedule(data []byte) error
+	ReadForkSchedule() ([]byte, bool)
+
+	WriteIndexerHead(name string, hash types.Hash) error
+	ReadIndexerHead(name string) (types.Hash, bool)
+
+	// NewBatch creates a batch to write atomically to the storage
+	NewBatch() BatchWriter
 
 	Close() error
 }
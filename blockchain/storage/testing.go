@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"math/big"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/0xPolygon/minimal/helper/hex"
@@ -49,6 +51,21 @@ func TestStorage(t *testing.T, m MockStorage) {
 	t.Run("", func(t *testing.T) {
 		testReceipts(t, m)
 	})
+	t.Run("", func(t *testing.T) {
+		testTxLookup(t, m)
+	})
+	t.Run("", func(t *testing.T) {
+		testBloomBits(t, m)
+	})
+	t.Run("", func(t *testing.T) {
+		testForkSchedule(t, m)
+	})
+	t.Run("", func(t *testing.T) {
+		testIndexerHead(t, m)
+	})
+	t.Run("", func(t *testing.T) {
+		testBatch(t, m)
+	})
 }
 
 func testCanonicalChain(t *testing.T, m MockStorage) {
@@ -362,6 +379,151 @@ func testReceipts(t *testing.T, m MockStorage) {
 	assert.True(t, reflect.DeepEqual(receipts, found))
 }
 
+func testTxLookup(t *testing.T, m MockStorage) {
+	s, close := m(t)
+	defer close()
+
+	_, ok := s.ReadTxLookup(hash1)
+	assert.False(t, ok)
+
+	lookup := &TxLookup{
+		BlockHash:   hash2,
+		BlockNumber: 10,
+		Index:       3,
+	}
+	assert.NoError(t, s.WriteTxLookup(hash1, lookup))
+
+	found, ok := s.ReadTxLookup(hash1)
+	assert.True(t, ok)
+	assert.Equal(t, lookup, found)
+
+	_, ok = s.ReadTxLookupTail()
+	assert.False(t, ok)
+
+	assert.NoError(t, s.WriteTxLookupTail(5))
+
+	tail, ok := s.ReadTxLookupTail()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(5), tail)
+}
+
+func testBloomBits(t *testing.T, m MockStorage) {
+	s, close := m(t)
+	defer close()
+
+	_, ok := s.ReadBloomBits(10, 1)
+	assert.False(t, ok)
+
+	assert.NoError(t, s.WriteBloomBits(10, 1, []byte{0x1, 0x2}))
+	assert.NoError(t, s.WriteBloomBits(11, 1, []byte{0x3}))
+
+	bits, ok := s.ReadBloomBits(10, 1)
+	assert.True(t, ok)
+	assert.Equal(t, []byte{0x1, 0x2}, bits)
+
+	_, ok = s.ReadBloomBits(10, 2)
+	assert.False(t, ok)
+
+	_, ok = s.ReadBloomSections()
+	assert.False(t, ok)
+
+	assert.NoError(t, s.WriteBloomSections(2))
+
+	sections, ok := s.ReadBloomSections()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), sections)
+}
+
+func testForkSchedule(t *testing.T, m MockStorage) {
+	s, close := m(t)
+	defer close()
+
+	_, ok := s.ReadForkSchedule()
+	assert.False(t, ok)
+
+	assert.NoError(t, s.WriteForkSchedule([]byte(`{"homestead":0}`)))
+
+	data, ok := s.ReadForkSchedule()
+	assert.True(t, ok)
+	assert.Equal(t, []byte(`{"homestead":0}`), data)
+}
+
+func testIndexerHead(t *testing.T, m MockStorage) {
+	s, close := m(t)
+	defer close()
+
+	_, ok := s.ReadIndexerHead("a")
+	assert.False(t, ok)
+
+	hash := types.StringToHash("1")
+	assert.NoError(t, s.WriteIndexerHead("a", hash))
+
+	head, ok := s.ReadIndexerHead("a")
+	assert.True(t, ok)
+	assert.Equal(t, hash, head)
+
+	// the progress is kept by indexer
+	_, ok = s.ReadIndexerHead("b")
+	assert.False(t, ok)
+}
+
+func testBatch(t *testing.T, m MockStorage) {
+	s, close := m(t)
+	defer close()
+
+	h := &types.Header{
+		Number:    10,
+		ExtraData: []byte{0x1},
+	}
+	h.ComputeHash()
+
+	batch := s.NewBatch()
+	assert.Equal(t, 0, batch.Size())
+	assert.NoError(t, batch.WriteBody(h.Hash, &types.Body{}))
+	assert.NoError(t, batch.WriteReceipts(h.Hash, []*types.Receipt{}))
+	assert.NoError(t, batch.WriteCanonicalHeader(h, big.NewInt(10)))
+	assert.NoError(t, batch.WriteTxLookup(hash1, &TxLookup{BlockHash: h.Hash, BlockNumber: 10}))
+	assert.NotZero(t, batch.Size())
+
+	// the writes are not visible until the batch is written
+	_, err := s.ReadHeader(h.Hash)
+	assert.Error(t, err)
+	_, ok := s.ReadHeadHash()
+	assert.False(t, ok)
+
+	assert.NoError(t, batch.Write())
+
+	hh, err := s.ReadHeader(h.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, h.Hash, hh.Hash)
+
+	_, err = s.ReadBody(h.Hash)
+	assert.NoError(t, err)
+
+	head, ok := s.ReadHeadHash()
+	assert.True(t, ok)
+	assert.Equal(t, h.Hash, head)
+
+	canonical, ok := s.ReadCanonicalHash(10)
+	assert.True(t, ok)
+	assert.Equal(t, h.Hash, canonical)
+
+	diff, ok := s.ReadDiff(h.Hash)
+	assert.True(t, ok)
+	assert.Equal(t, big.NewInt(10), diff)
+
+	lookup, ok := s.ReadTxLookup(hash1)
+	assert.True(t, ok)
+	assert.Equal(t, h.Hash, lookup.BlockHash)
+
+	batch = s.NewBatch()
+	assert.NoError(t, batch.DeleteCanonicalHash(10))
+	assert.NoError(t, batch.Write())
+
+	_, ok = s.ReadCanonicalHash(10)
+	assert.False(t, ok)
+}
+
 func testWriteCanonicalHeader(t *testing.T, m MockStorage) {
 	s, close := m(t)
 	defer close()
@@ -415,3 +577,176 @@ func testWriteCanonicalHeader(t *testing.T, m MockStorage) {
 		t.Fatal("canonical hash not correct")
 	}
 }
+
+// TestBackend tests the batches, the iteration and the migration of a backend
+func TestBackend(t *testing.T, b Backend) {
+	t.Helper()
+
+	batch := b.NewBatch()
+	batch.Set([]byte("ab"), []byte{2})
+	batch.Set([]byte("aa"), []byte{1})
+	batch.Set([]byte("b"), []byte{3})
+
+	// the writes are not visible until the batch is written
+	_, ok, err := b.Get([]byte("aa"))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, batch.Write())
+
+	iterate := func(b Backend, prefix string) []string {
+		keys := []string{}
+		assert.NoError(t, b.Iterate([]byte(prefix), func(k, v []byte) bool {
+			keys = append(keys, string(k))
+			return true
+		}))
+		return keys
+	}
+	assert.Equal(t, []string{"aa", "ab"}, iterate(b, "a"))
+	assert.Equal(t, []string{"aa", "ab", "b"}, iterate(b, ""))
+
+	// migrate the pairs to an empty backend
+	to := newMockBackend()
+	count, err := Migrate(b, to)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), count)
+	assert.Equal(t, []string{"aa", "ab", "b"}, iterate(to, ""))
+
+	v, ok, err := to.Get([]byte("b"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte{3}, v)
+
+	// delete a key
+	batch = b.NewBatch()
+	batch.Delete([]byte("ab"))
+	assert.NoError(t, batch.Write())
+	assert.Equal(t, []string{"aa", "b"}, iterate(b, ""))
+
+	// the slices can be reused before the batch is written
+	batch = b.NewBatch()
+	buf := []byte("c")
+	batch.Set(buf, buf)
+	buf[0] = 'd'
+	batch.Set(buf, buf)
+	assert.NoError(t, batch.Write())
+	assert.Equal(t, []string{"aa", "b", "c", "d"}, iterate(b, ""))
+
+	v, _, err = b.Get([]byte("c"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("c"), v)
+}
+
+// WriteTestBackend writes the pairs checked by TestReadOnlyBackend
+func WriteTestBackend(t *testing.T, b Backend) {
+	t.Helper()
+
+	batch := b.NewBatch()
+	batch.Set([]byte("aa"), []byte{1})
+	batch.Set([]byte("ab"), []byte{2})
+	batch.Set([]byte("b"), []byte{3})
+	assert.NoError(t, batch.Write())
+}
+
+// TestReadOnlyBackend checks a backend opened in read-only mode
+// on the pairs written by WriteTestBackend
+func TestReadOnlyBackend(t *testing.T, b Backend) {
+	t.Helper()
+
+	v, ok, err := b.Get([]byte("ab"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte{2}, v)
+
+	keys := []string{}
+	assert.NoError(t, b.Iterate(nil, func(k, v []byte) bool {
+		keys = append(keys, string(k))
+		return true
+	}))
+	assert.Equal(t, []string{"aa", "ab", "b"}, keys)
+
+	// the writes fail and are not applied
+	assert.Equal(t, ErrReadOnly, b.Set([]byte("c"), []byte{4}))
+
+	batch := b.NewBatch()
+	batch.Set([]byte("c"), []byte{4})
+	batch.Delete([]byte("aa"))
+	assert.Equal(t, ErrReadOnly, batch.Write())
+
+	_, ok, err = b.Get([]byte("c"))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = b.Get([]byte("aa"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+// mockBackend is a minimal in memory backend used as the target of the migration tests
+type mockBackend struct {
+	db map[string][]byte
+}
+
+func newMockBackend() *mockBackend {
+	return &mockBackend{db: map[string][]byte{}}
+}
+
+func (m *mockBackend) Close() error {
+	return nil
+}
+
+func (m *mockBackend) Set(p []byte, v []byte) error {
+	m.db[string(p)] = v
+	return nil
+}
+
+func (m *mockBackend) Get(p []byte) ([]byte, bool, error) {
+	v, ok := m.db[string(p)]
+	return v, ok, nil
+}
+
+func (m *mockBackend) NewBatch() Batch {
+	return &mockBatch{m: m}
+}
+
+func (m *mockBackend) Iterate(prefix []byte, fn func(k, v []byte) bool) error {
+	keys := []string{}
+	for k := range m.db {
+		if strings.HasPrefix(k, string(prefix)) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !fn([]byte(k), m.db[k]) {
+			break
+		}
+	}
+	return nil
+}
+
+type mockBatch struct {
+	m      *mockBackend
+	writes []func()
+}
+
+func (b *mockBatch) Set(k, v []byte) {
+	k, v = append([]byte{}, k...), append([]byte{}, v...)
+	b.writes = append(b.writes, func() {
+		b.m.db[string(k)] = v
+	})
+}
+
+func (b *mockBatch) Delete(k []byte) {
+	key := string(k)
+	b.writes = append(b.writes, func() {
+		delete(b.m.db, key)
+	})
+}
+
+func (b *mockBatch) Write() error {
+	for _, write := range b.writes {
+		write()
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package blockchain
 
 import (
+	"fmt"
 	"math/big"
 	"sync"
 
@@ -47,29 +48,42 @@ type subscription struct {
 	updateCh chan struct{} // Channel for update information
 	closeCh  chan struct{} // Channel for close signals
 	elem     *eventElem    // Reference to the blockchain event wrapper
+	stream   *eventStream  // Reference to the stream of events
+
+	closeOnce sync.Once
 }
 
-// GetEventCh creates a new event channel, and returns it
+// GetEventCh creates a new event channel, and returns it. The channel
+// is closed once the subscription is closed
 func (s *subscription) GetEventCh() chan *Event {
 	eventCh := make(chan *Event)
 	go func() {
+		defer close(eventCh)
+
 		for {
 			evnt := s.GetEvent()
-			eventCh <- evnt
+			if evnt == nil {
+				return
+			}
+
+			select {
+			case eventCh <- evnt:
+			case <-s.closeCh:
+				return
+			}
 		}
 	}()
 
 	return eventCh
 }
 
-// GetEvent returns the event from the subscription (BLOCKING)
+// GetEvent returns the next event from the subscription (BLOCKING).
+// It returns nil if the subscription is closed
 func (s *subscription) GetEvent() *Event {
 	for {
-		if s.elem.next != nil {
-			s.elem = s.elem.next
-			evnt := s.elem.event
-
-			return evnt
+		if next := s.stream.next(s.elem); next != nil {
+			s.elem = next
+			return next.event
 		}
 
 		// Wait for an update
@@ -84,7 +98,10 @@ func (s *subscription) GetEvent() *Event {
 
 // Close closes the subscription
 func (s *subscription) Close() {
-	close(s.closeCh)
+	s.closeOnce.Do(func() {
+		s.stream.unsubscribe(s.updateCh)
+		close(s.closeCh)
+	})
 }
 
 type EventType int
@@ -95,12 +112,25 @@ const (
 	EventFork                   // Chain fork event
 )
 
+func (e EventType) String() string {
+	switch e {
+	case EventHead:
+		return "head"
+	case EventReorg:
+		return "reorg"
+	case EventFork:
+		return "fork"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(e))
+	}
+}
+
 // Event is the blockchain event that gets passed to the listeners
 type Event struct {
-	// Old chain (removed headers) if there was a reorg
+	// Old chain (removed headers) if there was a reorg, from the oldest to the newest
 	OldChain []*types.Header
 
-	// New part of the chain (or a fork)
+	// New part of the chain (or a fork), from the oldest to the newest
 	NewChain []*types.Header
 
 	// Difficulty is the new difficulty created with this event
@@ -146,7 +176,10 @@ func (e *Event) AddOldHeader(oldHeader *types.Header) {
 	e.OldChain = append(e.OldChain, header)
 }
 
-// SubscribeEvents returns a blockchain event subscription
+// SubscribeEvents returns a subscription to the blockchain events from now on.
+// The head and reorg events include the headers that left the canonical chain
+// (OldChain) and the ones that joined it (NewChain), so that the listeners can
+// revert the state they derived from the removed blocks
 func (b *Blockchain) SubscribeEvents() Subscription {
 	return b.stream.subscribe()
 }
@@ -158,7 +191,7 @@ type eventElem struct {
 }
 
 // eventStream is the structure that contains the event list,
-// as well as the update channel which it uses to notify of updates
+// as well as the update channels which it uses to notify of updates
 type eventStream struct {
 	lock sync.Mutex
 	head *eventElem
@@ -167,45 +200,60 @@ type eventStream struct {
 	updateCh []chan struct{}
 }
 
-// subscribe Creates a new blockchain event subscription
+func newEventStream() *eventStream {
+	return &eventStream{
+		head: &eventElem{},
+	}
+}
+
+// subscribe creates a new blockchain event subscription
 func (e *eventStream) subscribe() *subscription {
-	head, updateCh := e.Head()
-	s := &subscription{
-		elem:     head,
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	// the update channel is buffered so that a notification sent
+	// while the subscriber is busy is not lost
+	updateCh := make(chan struct{}, 1)
+	e.updateCh = append(e.updateCh, updateCh)
+
+	return &subscription{
+		elem:     e.head,
 		updateCh: updateCh,
 		closeCh:  make(chan struct{}),
+		stream:   e,
 	}
-
-	return s
 }
 
-// Head returns the event list head
-func (e *eventStream) Head() (*eventElem, chan struct{}) {
+// unsubscribe stops notifying the update channel of a subscription
+func (e *eventStream) unsubscribe(updateCh chan struct{}) {
 	e.lock.Lock()
-	head := e.head
+	defer e.lock.Unlock()
 
-	ch := make(chan struct{})
-	if e.updateCh == nil {
-		e.updateCh = []chan struct{}{}
+	for i, ch := range e.updateCh {
+		if ch == updateCh {
+			e.updateCh = append(e.updateCh[:i], e.updateCh[i+1:]...)
+			break
+		}
 	}
-	e.updateCh = append(e.updateCh, ch)
+}
 
-	e.lock.Unlock()
+// next returns the element that follows elem in the list, if any
+func (e *eventStream) next(elem *eventElem) *eventElem {
+	e.lock.Lock()
+	defer e.lock.Unlock()
 
-	return head, ch
+	return elem.next
 }
 
 // push adds a new Event, and notifies listeners
 func (e *eventStream) push(event *Event) {
 	e.lock.Lock()
+	defer e.lock.Unlock()
 
 	newHead := &eventElem{
 		event: event,
 	}
-
-	if e.head != nil {
-		e.head.next = newHead
-	}
+	e.head.next = newHead
 	e.head = newHead
 
 	// Notify the listeners
@@ -215,6 +263,4 @@ func (e *eventStream) push(event *Event) {
 		default:
 		}
 	}
-
-	e.lock.Unlock()
 }
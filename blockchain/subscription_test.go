@@ -5,10 +5,11 @@ import (
 	"time"
 
 	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestSubscriptionLinear(t *testing.T) {
-	e := &eventStream{}
+	e := newEventStream()
 
 	// add a genesis block to eventstream
 	e.push(&Event{
@@ -46,7 +47,7 @@ func TestSubscriptionLinear(t *testing.T) {
 }
 
 func TestSubscriptionSlowConsumer(t *testing.T) {
-	e := &eventStream{}
+	e := newEventStream()
 
 	e.push(&Event{
 		NewChain: []*types.Header{
@@ -73,3 +74,92 @@ func TestSubscriptionSlowConsumer(t *testing.T) {
 		}
 	}
 }
+
+func TestSubscriptionClose(t *testing.T) {
+	e := newEventStream()
+
+	sub := e.subscribe()
+	eventCh := sub.GetEventCh()
+
+	e.push(&Event{
+		NewChain: []*types.Header{
+			{Number: 1},
+		},
+	})
+
+	select {
+	case evnt := <-eventCh:
+		if evnt.NewChain[0].Number != 1 {
+			t.Fatal("bad")
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("timeout")
+	}
+
+	sub.Close()
+	sub.Close()
+
+	// the channel is closed with the subscription
+	select {
+	case _, ok := <-eventCh:
+		if ok {
+			t.Fatal("channel not closed")
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("timeout")
+	}
+
+	if sub.GetEvent() != nil {
+		t.Fatal("event after close")
+	}
+
+	// the stream does not notify the closed subscription anymore
+	if len(e.updateCh) != 0 {
+		t.Fatal("subscription not removed")
+	}
+}
+
+func TestSubscriptionReorg(t *testing.T) {
+	b := NewTestBlockchain(t, nil)
+
+	headers := NewTestHeaderChain(3)
+	if err := b.writeGenesisImpl(headers[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.WriteHeaders(headers[1:]); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := b.SubscribeEvents()
+	defer sub.Close()
+
+	// heavier fork from the genesis
+	fork := NewTestHeaderFromChainWithSeed(headers[:1], 3, 1)
+	if err := b.WriteHeaders(fork[1:]); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first header of the fork is lighter than the chain
+	if evnt := sub.GetEvent(); evnt.Type != EventFork {
+		t.Fatalf("expected fork but found %s", evnt.Type)
+	}
+	if evnt := sub.GetEvent(); evnt.Type != EventFork {
+		t.Fatalf("expected fork but found %s", evnt.Type)
+	}
+
+	evnt := sub.GetEvent()
+	if evnt.Type != EventReorg {
+		t.Fatalf("expected reorg but found %s", evnt.Type)
+	}
+	if len(evnt.OldChain) != 2 || evnt.OldChain[0].Hash != headers[1].Hash || evnt.OldChain[1].Hash != headers[2].Hash {
+		t.Fatal("bad old chain")
+	}
+	if len(evnt.NewChain) != 3 || evnt.Header().Hash != fork[3].Hash {
+		t.Fatal("bad new chain")
+	}
+}
+
+func TestEventType_String(t *testing.T) {
+	assert.Equal(t, "reorg", EventReorg.String())
+	assert.Equal(t, "unknown(10)", EventType(10).String())
+}
@@ -163,7 +163,7 @@ func NewTestBlockchain(t *testing.T, headers []*types.Header) *Blockchain {
 		t.Fatal(err)
 	}
 	if headers != nil {
-		if _, err := b.advanceHead(headers[0]); err != nil {
+		if err := b.writeGenesisImpl(headers[0]); err != nil {
 			t.Fatal(err)
 		}
 		if err := b.WriteHeaders(headers[1:]); err != nil {
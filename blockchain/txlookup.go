@@ -0,0 +1,146 @@
+package blockchain
+
+import (
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// txLookupTailInterval is the number of blocks indexed by the backfill between progress writes
+const txLookupTailInterval = 1000
+
+// writeTxLookups maps the hashes of the transactions of the block to their position
+func (b *Blockchain) writeTxLookups(batch storage.BatchWriter, block *types.Block) error {
+	for indx, txn := range block.Transactions {
+		lookup := &storage.TxLookup{
+			BlockHash:   block.Hash(),
+			BlockNumber: block.Number(),
+			Index:       uint64(indx),
+		}
+		if err := batch.WriteTxLookup(txn.Hash, lookup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeCanonicalTxLookups maps the transactions of a block that is now canonical.
+// The body is read from the DB if it is nil
+func (b *Blockchain) writeCanonicalTxLookups(batch storage.BatchWriter, header *types.Header, body *types.Body) error {
+	if body == nil {
+		var err error
+		if body, err = b.db.ReadBody(header.Hash); err != nil {
+			if err == storage.ErrNotFound {
+				// the header was written without body
+				return nil
+			}
+			return err
+		}
+	}
+	return b.writeTxLookups(batch, &types.Block{
+		Header:       header,
+		Transactions: body.Transactions,
+	})
+}
+
+// ReadTxLookup returns the position of the transaction in the canonical chain
+func (b *Blockchain) ReadTxLookup(hash types.Hash) (*storage.TxLookup, bool) {
+	lookup, ok := b.db.ReadTxLookup(hash)
+	if !ok {
+		return nil, false
+	}
+	if lookup.Legacy {
+		// the lookup was written before the position of the txns was
+		// indexed and it has not been backfilled yet, find it in the block
+		if lookup, ok = b.resolveLegacyTxLookup(hash, lookup.BlockHash); !ok {
+			return nil, false
+		}
+	}
+
+	// the block might have been reorged out of the chain
+	if canonical, ok := b.readCanonicalHash(lookup.BlockNumber); !ok || canonical != lookup.BlockHash {
+		return nil, false
+	}
+	return lookup, true
+}
+
+func (b *Blockchain) resolveLegacyTxLookup(hash, blockHash types.Hash) (*storage.TxLookup, bool) {
+	block, ok := b.GetBlockByHash(blockHash, true)
+	if !ok {
+		return nil, false
+	}
+	for indx, txn := range block.Transactions {
+		if txn.Hash == hash {
+			return &storage.TxLookup{
+				BlockHash:   block.Hash(),
+				BlockNumber: block.Number(),
+				Index:       uint64(indx),
+			}, true
+		}
+	}
+	return nil, false
+}
+
+// StartTxLookupBackfill indexes in the background the position of the transactions of the
+// canonical blocks written before it was indexed. The progress is stored so that the
+// backfill resumes after a restart
+func (b *Blockchain) StartTxLookupBackfill() {
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+
+		if err := b.backfillTxLookups(); err != nil {
+			b.logger.Error("failed to backfill txn lookups", "err", err)
+		}
+	}()
+}
+
+func (b *Blockchain) backfillTxLookups() error {
+	tail, ok := b.db.ReadTxLookupTail()
+	if !ok {
+		// the blocks written from now on are indexed as they are written
+		tail = b.Header().Number + 1
+		if err := b.db.WriteTxLookupTail(tail); err != nil {
+			return err
+		}
+	}
+	if tail == 0 {
+		return nil
+	}
+
+	b.logger.Info("backfill txn lookups", "from", tail-1)
+
+	// the lookups are committed with the progress, so that the
+	// backfill never skips blocks after a crash
+	batch := b.db.NewBatch()
+	for tail > 0 {
+		select {
+		case <-b.stopCh:
+			if err := batch.WriteTxLookupTail(tail); err != nil {
+				return err
+			}
+			return batch.Write()
+		default:
+		}
+
+		// the genesis block has no body
+		if block, ok := b.GetBlockByNumber(tail-1, true); ok {
+			if err := b.writeTxLookups(batch, block); err != nil {
+				return err
+			}
+		}
+
+		tail--
+		if tail%txLookupTailInterval == 0 {
+			if err := batch.WriteTxLookupTail(tail); err != nil {
+				return err
+			}
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch = b.db.NewBatch()
+		}
+	}
+
+	b.logger.Info("backfill txn lookups done")
+	return nil
+}
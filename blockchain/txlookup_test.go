@@ -0,0 +1,106 @@
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/blockchain/storage/memory"
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/0xPolygon/minimal/types/buildroot"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/fastrlp"
+)
+
+// newTxLookupChain creates a chain of blocks with one txn each
+func newTxLookupChain(n int) []*types.Block {
+	genesis := &types.Block{
+		Header: &types.Header{},
+	}
+	genesis.Header.ComputeHash()
+
+	blocks := []*types.Block{genesis}
+	for i := 1; i < n; i++ {
+		txn := &types.Transaction{
+			Nonce:    uint64(i),
+			Value:    big.NewInt(0),
+			GasPrice: big.NewInt(0),
+			V:        0x27,
+		}
+		txn.ComputeHash()
+
+		header := &types.Header{
+			ParentHash: blocks[i-1].Hash(),
+			Number:     uint64(i),
+			ExtraData:  []byte{},
+			TxRoot:     buildroot.CalculateTransactionsRoot([]*types.Transaction{txn}),
+			Sha3Uncles: types.EmptyUncleHash,
+		}
+		header.ComputeHash()
+
+		blocks = append(blocks, &types.Block{
+			Header:       header,
+			Transactions: []*types.Transaction{txn},
+		})
+	}
+	return blocks
+}
+
+func TestTxLookup(t *testing.T) {
+	backend := memory.NewBackend()
+	db := storage.NewKeyValueStorage(hclog.NewNullLogger(), backend)
+
+	config := &chain.Chain{
+		Genesis: &chain.Genesis{},
+	}
+	b, err := NewBlockchainWithStorage(hclog.NewNullLogger(), db, config, &MockVerifier{}, &mockExecutor{})
+	assert.NoError(t, err)
+
+	blocks := newTxLookupChain(5)
+	assert.NoError(t, b.writeGenesisImpl(blocks[0].Header))
+	for _, block := range blocks[1:] {
+		assert.NoError(t, b.writeBody(block))
+		assert.NoError(t, b.WriteBlock(block))
+	}
+
+	checkLookups := func() {
+		for _, block := range blocks[1:] {
+			lookup, ok := b.ReadTxLookup(block.Transactions[0].Hash)
+			assert.True(t, ok)
+			assert.Equal(t, block.Hash(), lookup.BlockHash)
+			assert.Equal(t, block.Number(), lookup.BlockNumber)
+			assert.Equal(t, uint64(0), lookup.Index)
+		}
+	}
+	checkLookups()
+
+	// write the lookups in the format of the older versions (only the block hash)
+	ar := &fastrlp.Arena{}
+	for _, block := range blocks[1:] {
+		key := append(append([]byte{}, storage.TX_LOOKUP_PREFIX...), block.Transactions[0].Hash.Bytes()...)
+		assert.NoError(t, backend.Set(key, ar.NewBytes(block.Hash().Bytes()).MarshalTo(nil)))
+	}
+
+	lookup, ok := db.ReadTxLookup(blocks[1].Transactions[0].Hash)
+	assert.True(t, ok)
+	assert.True(t, lookup.Legacy)
+
+	// the position of the legacy lookups is found in the block
+	checkLookups()
+
+	// backfill the position of the legacy lookups
+	assert.NoError(t, b.backfillTxLookups())
+
+	for _, block := range blocks[1:] {
+		lookup, ok := db.ReadTxLookup(block.Transactions[0].Hash)
+		assert.True(t, ok)
+		assert.False(t, lookup.Legacy)
+	}
+	checkLookups()
+
+	tail, ok := db.ReadTxLookupTail()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(0), tail)
+}
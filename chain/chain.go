@@ -1,11 +1,13 @@
 package chain
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"math/big"
+	"strings"
 
 	"github.com/0xPolygon/minimal/helper/hex"
 	"github.com/0xPolygon/minimal/types"
@@ -26,6 +28,10 @@ type Chain struct {
 	Genesis   *Genesis  `json:"genesis"`
 	Params    *Params   `json:"params"`
 	Bootnodes []string	`json:"bootnodes"`
+
+	// Warnings are the issues of the json of the chain that do not prevent its
+	// import (i.e. an unknown field), to be reported by the callers
+	Warnings []string `json:"-"`
 }
 
 // Genesis specifies the header fields, state of a genesis block
@@ -87,6 +93,31 @@ func (g *Genesis) GenesisHeader() *types.Header {
 	return head
 }
 
+// Validate checks the header fields and the alloc of the genesis
+func (g *Genesis) Validate() error {
+	if g.GasLimit == 0 {
+		return fmt.Errorf("the gas limit is required")
+	}
+	if g.GasUsed > g.GasLimit {
+		return fmt.Errorf("the gas used (%d) is over the gas limit (%d)", g.GasUsed, g.GasLimit)
+	}
+	for addr, account := range g.Alloc {
+		if account == nil {
+			return fmt.Errorf("account %s of the alloc is empty", addr)
+		}
+		if account.Balance != nil && account.Balance.Sign() < 0 {
+			return fmt.Errorf("account %s has a negative balance", addr)
+		}
+		if len(account.InitCode) != 0 && len(account.Code) != 0 {
+			return fmt.Errorf("account %s has both code and init code", addr)
+		}
+		if len(account.ConstructorArgs) != 0 && len(account.InitCode) == 0 {
+			return fmt.Errorf("account %s has constructor arguments without init code", addr)
+		}
+	}
+	return nil
+}
+
 // Hash computes the genesis hash
 func (g *Genesis) Hash() types.Hash {
 	header := g.GenesisHeader()
@@ -254,14 +285,21 @@ type GenesisAccount struct {
 	Balance    *big.Int                  `json:"balance,omitempty"`
 	Nonce      uint64                    `json:"nonce,omitempty"`
 	PrivateKey []byte                    `json:"secretKey,omitempty"` // for tests
+
+	// InitCode is the creation code of the contract deployed at the account, which
+	// is executed at genesis with the abi encoded constructor arguments appended
+	InitCode        []byte `json:"initCode,omitempty"`
+	ConstructorArgs []byte `json:"constructorArgs,omitempty"`
 }
 
 type genesisAccountEncoder struct {
-	Code       *string                   `json:"code,omitempty"`
-	Storage    map[types.Hash]types.Hash `json:"storage,omitempty"`
-	Balance    *string                   `json:"balance"`
-	Nonce      *string                   `json:"nonce,omitempty"`
-	PrivateKey *string                   `json:"secretKey,omitempty"`
+	Code            *string                   `json:"code,omitempty"`
+	Storage         map[types.Hash]types.Hash `json:"storage,omitempty"`
+	Balance         *string                   `json:"balance"`
+	Nonce           *string                   `json:"nonce,omitempty"`
+	PrivateKey      *string                   `json:"secretKey,omitempty"`
+	InitCode        *string                   `json:"initCode,omitempty"`
+	ConstructorArgs *string                   `json:"constructorArgs,omitempty"`
 }
 
 // ENCODING //
@@ -283,6 +321,12 @@ func (g *GenesisAccount) MarshalJSON() ([]byte, error) {
 	if g.PrivateKey != nil {
 		obj.PrivateKey = types.EncodeBytes(g.PrivateKey)
 	}
+	if g.InitCode != nil {
+		obj.InitCode = types.EncodeBytes(g.InitCode)
+	}
+	if g.ConstructorArgs != nil {
+		obj.ConstructorArgs = types.EncodeBytes(g.ConstructorArgs)
+	}
 	return json.Marshal(obj)
 }
 
@@ -290,11 +334,13 @@ func (g *GenesisAccount) MarshalJSON() ([]byte, error) {
 
 func (g *GenesisAccount) UnmarshalJSON(data []byte) error {
 	type GenesisAccount struct {
-		Code       *string                   `json:"code,omitempty"`
-		Storage    map[types.Hash]types.Hash `json:"storage,omitempty"`
-		Balance    *string                   `json:"balance"`
-		Nonce      *string                   `json:"nonce,omitempty"`
-		PrivateKey *string                   `json:"secretKey,omitempty"`
+		Code            *string                   `json:"code,omitempty"`
+		Storage         map[types.Hash]types.Hash `json:"storage,omitempty"`
+		Balance         *string                   `json:"balance"`
+		Nonce           *string                   `json:"nonce,omitempty"`
+		PrivateKey      *string                   `json:"secretKey,omitempty"`
+		InitCode        *string                   `json:"initCode,omitempty"`
+		ConstructorArgs *string                   `json:"constructorArgs,omitempty"`
 	}
 
 	var dec GenesisAccount
@@ -336,6 +382,20 @@ func (g *GenesisAccount) UnmarshalJSON(data []byte) error {
 		}
 	}
 
+	if dec.InitCode != nil {
+		g.InitCode, subErr = types.ParseBytes(dec.InitCode)
+		if subErr != nil {
+			parseError("initcode", subErr)
+		}
+	}
+
+	if dec.ConstructorArgs != nil {
+		g.ConstructorArgs, subErr = types.ParseBytes(dec.ConstructorArgs)
+		if subErr != nil {
+			parseError("constructorargs", subErr)
+		}
+	}
+
 	return err
 }
 
@@ -373,9 +433,66 @@ func importChain(content []byte) (*Chain, error) {
 	if err := json.Unmarshal(content, &chain); err != nil {
 		return nil, err
 	}
-	if engines := chain.Params.Engine; len(engines) != 1 {
-		return nil, fmt.Errorf("Expected one consensus engine but found %d", len(engines))
+	if chain == nil {
+		return nil, fmt.Errorf("empty chain")
+	}
+
+	// the unknown fields are not rejected, so that the files of newer versions can be
+	// imported, but they are reported since they are most likely typos. The decoder
+	// only reports the first one, arbitrary params go in the extra params
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&Chain{}); err != nil && strings.HasPrefix(err.Error(), "json: unknown field") {
+		chain.Warnings = append(chain.Warnings, strings.TrimPrefix(err.Error(), "json: "))
+	}
+	if err := chain.Validate(); err != nil {
+		return nil, err
 	}
 
 	return chain, nil
 }
+
+// Validate checks that the params and the genesis of the chain are consistent
+func (c *Chain) Validate() error {
+	if c.Params == nil {
+		return fmt.Errorf("the params are required")
+	}
+	if engines := c.Params.Engine; len(engines) != 1 {
+		return fmt.Errorf("Expected one consensus engine but found %d", len(engines))
+	}
+	if c.Params.ChainID <= 0 {
+		return fmt.Errorf("the chain id must be positive but found %d", c.Params.ChainID)
+	}
+	if c.Params.Forks != nil {
+		if err := c.Params.Forks.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Params.GasLimit != nil {
+		if err := c.Params.GasLimit.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := ValidatePrecompiles(c.Params.Precompiles); err != nil {
+		return err
+	}
+	if err := ValidateMaxCodeSize(c.Params.MaxCodeSizes); err != nil {
+		return err
+	}
+	if c.Params.NativeMinter != nil {
+		if err := c.Params.NativeMinter.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Genesis == nil {
+		return fmt.Errorf("the genesis is required")
+	}
+	if err := c.Genesis.Validate(); err != nil {
+		return fmt.Errorf("invalid genesis: %v", err)
+	}
+	if c.Params.GasLimit != nil && c.Genesis.GasLimit < MinBlockGasLimit {
+		return fmt.Errorf("the gas limit of the genesis must be at least %d but found %d", MinBlockGasLimit, c.Genesis.GasLimit)
+	}
+	return nil
+}
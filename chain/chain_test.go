@@ -78,6 +78,22 @@ func TestGenesisAlloc(t *testing.T) {
 				},
 			},
 		},
+		{
+			input: `{
+				"0x0000000000000000000000000000000000000000": {
+					"balance": "0x11",
+					"initCode": "0x6001",
+					"constructorArgs": "0x02"
+				}
+			}`,
+			output: map[types.Address]GenesisAccount{
+				emptyAddr: GenesisAccount{
+					Balance:         big.NewInt(17),
+					InitCode:        []byte{0x60, 0x01},
+					ConstructorArgs: []byte{0x02},
+				},
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -155,3 +171,133 @@ func TestChainFolder(t *testing.T) {
 		}
 	}
 }
+
+func TestImportChainValidation(t *testing.T) {
+	chainJSON := func(params string, genesis string) string {
+		return `{
+			"name": "test",
+			"params": ` + params + `,
+			"genesis": ` + genesis + `
+		}`
+	}
+
+	params := `{
+		"chainID": 100,
+		"forks": {
+			"homestead": 0,
+			"byzantium": 10
+		},
+		"engine": {
+			"ibft": {}
+		},
+		"extra": {
+			"foo": "bar"
+		}
+	}`
+	genesis := `{
+		"gasLimit": "0x1388",
+		"alloc": {
+			"0x0000000000000000000000000000000000000001": {
+				"balance": "0x11",
+				"code": "0x6001",
+				"storage": {
+					"` + hash("1").String() + `": "` + hash("2").String() + `"
+				}
+			}
+		}
+	}`
+
+	cases := []struct {
+		name  string
+		input string
+		valid bool
+	}{
+		{
+			"valid",
+			chainJSON(params, genesis),
+			true,
+		},
+		{
+			"chain id",
+			chainJSON(`{"chainID": 0, "engine": {"ibft": {}}}`, genesis),
+			false,
+		},
+		{
+			"two engines",
+			chainJSON(`{"chainID": 1, "engine": {"ibft": {}, "pow": {}}}`, genesis),
+			false,
+		},
+		{
+			"forks out of order",
+			chainJSON(`{"chainID": 1, "forks": {"homestead": 10, "byzantium": 5}, "engine": {"ibft": {}}}`, genesis),
+			false,
+		},
+		{
+			"gas used over the limit",
+			chainJSON(params, `{"gasLimit": "0x10", "gasUsed": "0x11"}`),
+			false,
+		},
+		{
+			"code and init code",
+			chainJSON(params, `{"gasLimit": "0x1388", "alloc": {"0x0000000000000000000000000000000000000001": {"code": "0x01", "initCode": "0x01"}}}`),
+			false,
+		},
+		{
+			"constructor args without init code",
+			chainJSON(params, `{"gasLimit": "0x1388", "alloc": {"0x0000000000000000000000000000000000000001": {"constructorArgs": "0x01"}}}`),
+			false,
+		},
+		{
+			"no genesis",
+			`{"name": "test", "params": ` + params + `}`,
+			false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			chain, err := importChain([]byte(c.input))
+			if !c.valid {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			account := chain.Genesis.Alloc[addr("1")]
+			if !reflect.DeepEqual(account.Code, []byte{0x60, 0x01}) {
+				t.Fatal("bad code")
+			}
+			if account.Storage[hash("1")] != hash("2") {
+				t.Fatal("bad storage")
+			}
+			if chain.Params.Extra["foo"] != "bar" {
+				t.Fatal("bad extra params")
+			}
+		})
+	}
+}
+
+func TestImportChainUnknownFields(t *testing.T) {
+	input := `{
+		"name": "test",
+		"foo": 1,
+		"params": {"chainID": 100, "engine": {"ibft": {}}},
+		"genesis": {"gasLimit": "0x1388"}
+	}`
+
+	// the unknown fields are reported but do not fail the import
+	chain, err := importChain([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain.Warnings) != 1 || !strings.Contains(chain.Warnings[0], `"foo"`) {
+		t.Fatalf("bad warnings %v", chain.Warnings)
+	}
+	if chain.Params.ChainID != 100 {
+		t.Fatal("bad chain id")
+	}
+}
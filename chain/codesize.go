@@ -0,0 +1,48 @@
+package chain
+
+import (
+	"fmt"
+)
+
+// DefaultMaxCodeSize is the max size of the code of the contracts deployed once
+// eip-158 is enabled (eip-170), unless the chain configures its own limits
+const DefaultMaxCodeSize uint64 = 24576
+
+// CodeSizeLimit is the max size of the code of the contracts deployed from a block
+type CodeSizeLimit struct {
+	Block   Fork   `json:"block"`
+	MaxSize uint64 `json:"maxSize"`
+}
+
+// ValidateMaxCodeSize checks that the code size limits are in order of their blocks
+func ValidateMaxCodeSize(limits []*CodeSizeLimit) error {
+	for i, limit := range limits {
+		if limit == nil {
+			return fmt.Errorf("code size limit %d is empty", i)
+		}
+		if limit.MaxSize == 0 {
+			return fmt.Errorf("the max code size at block %d must be positive", uint64(limit.Block))
+		}
+		if i > 0 && limit.Block <= limits[i-1].Block {
+			return fmt.Errorf(
+				"code size limit at block %d must be after the one at block %d",
+				uint64(limit.Block),
+				uint64(limits[i-1].Block),
+			)
+		}
+	}
+	return nil
+}
+
+// MaxCodeSize returns the max size of the code of the contracts deployed at the block,
+// which is the last limit activated or DefaultMaxCodeSize if there is none
+func (p *Params) MaxCodeSize(block uint64) uint64 {
+	size := DefaultMaxCodeSize
+	for _, limit := range p.MaxCodeSizes {
+		if !limit.Block.Active(block) {
+			break
+		}
+		size = limit.MaxSize
+	}
+	return size
+}
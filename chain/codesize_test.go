@@ -0,0 +1,49 @@
+package chain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxCodeSize(t *testing.T) {
+	params := &Params{}
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"maxCodeSize": [
+			{"block": 10, "maxSize": 49152},
+			{"block": 20, "maxSize": 65536}
+		]
+	}`), params))
+	assert.NoError(t, ValidateMaxCodeSize(params.MaxCodeSizes))
+
+	assert.Equal(t, DefaultMaxCodeSize, params.MaxCodeSize(9))
+	assert.Equal(t, uint64(49152), params.MaxCodeSize(10))
+	assert.Equal(t, uint64(49152), params.MaxCodeSize(19))
+	assert.Equal(t, uint64(65536), params.MaxCodeSize(20))
+
+	// the eip-170 limit applies without limits
+	assert.Equal(t, DefaultMaxCodeSize, (&Params{}).MaxCodeSize(100))
+}
+
+func TestValidateMaxCodeSize(t *testing.T) {
+	cases := []struct {
+		limits []*CodeSizeLimit
+		valid  bool
+	}{
+		{nil, true},
+		{[]*CodeSizeLimit{{Block: 0, MaxSize: 1}, {Block: 5, MaxSize: 2}}, true},
+		{[]*CodeSizeLimit{{Block: 0, MaxSize: 0}}, false},
+		{[]*CodeSizeLimit{{Block: 5, MaxSize: 1}, {Block: 5, MaxSize: 2}}, false},
+		{[]*CodeSizeLimit{{Block: 5, MaxSize: 1}, {Block: 1, MaxSize: 2}}, false},
+		{[]*CodeSizeLimit{nil}, false},
+	}
+	for _, c := range cases {
+		err := ValidateMaxCodeSize(c.limits)
+		if c.valid {
+			assert.NoError(t, err)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
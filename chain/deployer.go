@@ -0,0 +1,39 @@
+package chain
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/0xPolygon/minimal/helper/hex"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// DeterministicDeployer is the address of the deterministic deployment proxy, which is
+// at the same address on most of the chains since it is deployed with a presigned txn
+var DeterministicDeployer = types.StringToAddress("0x4e59b44847b379578588920ca78fbf26c0b4956c")
+
+// DeterministicDeployerCode is the code of the deterministic deployment proxy. The input of its
+// calls is a salt of 32 bytes followed by the init code of the contract, which it deploys with
+// CREATE2 so that the contract has the same address on every chain, and it returns that address
+var DeterministicDeployerCode = hex.MustDecodeHex("0x7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe03601600081602082378035828234f58015156039578182fd5b8082525050506014600cf3")
+
+// AddDeterministicDeployer adds the deterministic deployment proxy to the alloc of the genesis,
+// the balance of an account already allocated at its address is kept
+func (g *Genesis) AddDeterministicDeployer() error {
+	if g.Alloc == nil {
+		g.Alloc = map[types.Address]*GenesisAccount{}
+	}
+	account, ok := g.Alloc[DeterministicDeployer]
+	if !ok || account == nil {
+		g.Alloc[DeterministicDeployer] = &GenesisAccount{Code: DeterministicDeployerCode}
+		return nil
+	}
+	if len(account.Code) != 0 || len(account.InitCode) != 0 {
+		if bytes.Equal(account.Code, DeterministicDeployerCode) {
+			return nil
+		}
+		return fmt.Errorf("account %s of the deterministic deployer already has a code", DeterministicDeployer)
+	}
+	account.Code = DeterministicDeployerCode
+	return nil
+}
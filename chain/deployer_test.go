@@ -0,0 +1,28 @@
+package chain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddDeterministicDeployer(t *testing.T) {
+	g := &Genesis{}
+	assert.NoError(t, g.AddDeterministicDeployer())
+	assert.Equal(t, DeterministicDeployerCode, g.Alloc[DeterministicDeployer].Code)
+
+	// the balance premined at the address is kept
+	g.Alloc = map[types.Address]*GenesisAccount{
+		DeterministicDeployer: {Balance: big.NewInt(1)},
+	}
+	assert.NoError(t, g.AddDeterministicDeployer())
+	assert.Equal(t, big.NewInt(1), g.Alloc[DeterministicDeployer].Balance)
+	assert.Equal(t, DeterministicDeployerCode, g.Alloc[DeterministicDeployer].Code)
+	assert.NoError(t, g.AddDeterministicDeployer())
+
+	// another contract is not replaced
+	g.Alloc[DeterministicDeployer].Code = []byte{0x1}
+	assert.Error(t, g.AddDeterministicDeployer())
+}
@@ -0,0 +1,85 @@
+package chain
+
+import (
+	"fmt"
+)
+
+const (
+	// LegacyBlockGasLimit is the gas limit of the blocks of the chains without gas limit params
+	LegacyBlockGasLimit uint64 = 100000000
+
+	// DefaultGasLimitBoundDivisor bounds the change of the gas limit between blocks
+	DefaultGasLimitBoundDivisor uint64 = 1024
+
+	// MinBlockGasLimit is the minimum gas limit of a block
+	MinBlockGasLimit uint64 = 5000
+)
+
+// GasLimitParams manage the gas limit of the blocks. The first block has the gas limit
+// of the genesis, and the gas limit of each block moves from the one of its parent toward
+// the target of the block producer by less than 1/BoundDivisor of the parent gas limit
+type GasLimitParams struct {
+	BoundDivisor uint64 `json:"boundDivisor"`
+}
+
+// Validate checks the gas limit params
+func (g *GasLimitParams) Validate() error {
+	if g.BoundDivisor == 0 {
+		return fmt.Errorf("the gas limit bound divisor must be positive")
+	}
+	return nil
+}
+
+// BlockGasLimit returns the gas limit of a block on top of a parent with the given gas
+// limit, moved toward the target. The gas limit of the parent is kept if the target is 0
+func (p *Params) BlockGasLimit(parentGasLimit, target uint64) uint64 {
+	if p.GasLimit == nil {
+		return LegacyBlockGasLimit
+	}
+	if target == 0 {
+		return parentGasLimit
+	}
+
+	// the delta is strictly lower than the bound
+	delta := parentGasLimit/p.GasLimit.BoundDivisor - 1
+	if parentGasLimit/p.GasLimit.BoundDivisor == 0 {
+		delta = 0
+	}
+	if target < MinBlockGasLimit {
+		target = MinBlockGasLimit
+	}
+
+	limit := parentGasLimit
+	if limit < target {
+		limit += delta
+		if limit > target {
+			limit = target
+		}
+	} else if limit > target {
+		limit -= delta
+		if limit < target {
+			limit = target
+		}
+	}
+	return limit
+}
+
+// VerifyBlockGasLimit checks that the gas limit of a block is within the bounds
+// of the gas limit of its parent. Any gas limit is valid without gas limit params
+func (p *Params) VerifyBlockGasLimit(parentGasLimit, gasLimit uint64) error {
+	if p.GasLimit == nil {
+		return nil
+	}
+	if gasLimit < MinBlockGasLimit {
+		return fmt.Errorf("gas limit %d is below the minimum %d", gasLimit, MinBlockGasLimit)
+	}
+
+	diff := gasLimit - parentGasLimit
+	if gasLimit < parentGasLimit {
+		diff = parentGasLimit - gasLimit
+	}
+	if bound := parentGasLimit / p.GasLimit.BoundDivisor; diff != 0 && diff >= bound {
+		return fmt.Errorf("gas limit %d differs from the parent gas limit %d by %d, over the bound %d", gasLimit, parentGasLimit, diff, bound)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockGasLimit(t *testing.T) {
+	params := &Params{
+		GasLimit: &GasLimitParams{
+			BoundDivisor: 1024,
+		},
+	}
+
+	cases := []struct {
+		name   string
+		parent uint64
+		target uint64
+		limit  uint64
+	}{
+		{"keep the parent without target", 1024000, 0, 1024000},
+		{"move up by less than the bound", 1024000, 2000000, 1024999},
+		{"move down by less than the bound", 1024000, 5000, 1023001},
+		{"reach the target up", 1024000, 1024500, 1024500},
+		{"reach the target down", 1024000, 1023500, 1023500},
+		{"stop at the target", 1024000, 1024000, 1024000},
+		{"never below the minimum", 5002, 1, 5000},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			limit := params.BlockGasLimit(c.parent, c.target)
+			assert.Equal(t, c.limit, limit)
+			assert.NoError(t, params.VerifyBlockGasLimit(c.parent, limit))
+		})
+	}
+
+	// the chains without gas limit params use the legacy gas limit
+	assert.Equal(t, LegacyBlockGasLimit, (&Params{}).BlockGasLimit(5000, 1024000))
+}
+
+func TestVerifyBlockGasLimit(t *testing.T) {
+	params := &Params{
+		GasLimit: &GasLimitParams{
+			BoundDivisor: 1024,
+		},
+	}
+
+	assert.NoError(t, params.VerifyBlockGasLimit(1024000, 1024000))
+	assert.NoError(t, params.VerifyBlockGasLimit(1024000, 1024999))
+	assert.NoError(t, params.VerifyBlockGasLimit(1024000, 1023001))
+	assert.Error(t, params.VerifyBlockGasLimit(1024000, 1025000))
+	assert.Error(t, params.VerifyBlockGasLimit(1024000, 1023000))
+	assert.Error(t, params.VerifyBlockGasLimit(5000, 4999))
+
+	// any gas limit is valid without gas limit params
+	assert.NoError(t, (&Params{}).VerifyBlockGasLimit(1024000, 1))
+}
+
+func TestValidateGasLimitParams(t *testing.T) {
+	c := &Chain{
+		Genesis: &Genesis{
+			GasLimit: 1000,
+		},
+		Params: &Params{
+			ChainID: 100,
+			Engine: map[string]interface{}{
+				"dev": map[string]interface{}{},
+			},
+			GasLimit: &GasLimitParams{},
+		},
+	}
+	assert.Error(t, c.Validate())
+
+	c.Params.GasLimit.BoundDivisor = DefaultGasLimitBoundDivisor
+	assert.Error(t, c.Validate())
+
+	c.Genesis.GasLimit = MinBlockGasLimit
+	assert.NoError(t, c.Validate())
+}
@@ -0,0 +1,32 @@
+package chain
+
+// DefaultMaxTimestampDrift is the number of seconds the timestamp of a header
+// can be ahead of the clock of the node if the chain does not configure it
+const DefaultMaxTimestampDrift uint64 = 15
+
+// HeaderParams configure the validation of the fields of the headers
+type HeaderParams struct {
+	// MaxExtraDataSize is the maximum size of the extra data, unlimited if 0
+	MaxExtraDataSize uint64 `json:"maxExtraDataSize,omitempty"`
+
+	// MaxTimestampDrift is the number of seconds the timestamp of a header can be
+	// ahead of the clock of the node, DefaultMaxTimestampDrift if 0
+	MaxTimestampDrift uint64 `json:"maxTimestampDrift,omitempty"`
+}
+
+// MaxExtraDataSize returns the maximum size of the extra data of the headers, unlimited if 0
+func (p *Params) MaxExtraDataSize() uint64 {
+	if p.Header == nil {
+		return 0
+	}
+	return p.Header.MaxExtraDataSize
+}
+
+// MaxTimestampDrift returns the number of seconds the timestamp of
+// the headers can be ahead of the clock of the node
+func (p *Params) MaxTimestampDrift() uint64 {
+	if p.Header == nil || p.Header.MaxTimestampDrift == 0 {
+		return DefaultMaxTimestampDrift
+	}
+	return p.Header.MaxTimestampDrift
+}
@@ -0,0 +1,37 @@
+package chain
+
+import (
+	"fmt"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// NativeMinterAddress is the address called to mint and burn the native token. It has no code,
+// the calls are executed by the executor, which only accepts the ones of the minter contract
+var NativeMinterAddress = types.StringToAddress("0x0000000000000000000000000000000000001010")
+
+// NativeMinterParams enable the mint and burn of the native token by a system contract,
+// like the bridge contract that mints the deposits and burns the withdrawals
+type NativeMinterParams struct {
+	// Minter is the address of the system contract allowed to mint and burn the native token
+	Minter types.Address `json:"minter"`
+
+	// Block is the block in which the mint and burn are enabled, the genesis if nil
+	Block *Fork `json:"block,omitempty"`
+}
+
+// Active returns whether the mint and burn are enabled at the block
+func (n *NativeMinterParams) Active(block uint64) bool {
+	return n.Block == nil || n.Block.Active(block)
+}
+
+// Validate checks the native minter params
+func (n *NativeMinterParams) Validate() error {
+	if n.Minter == types.ZeroAddress {
+		return fmt.Errorf("the native minter contract is required")
+	}
+	if n.Minter == NativeMinterAddress {
+		return fmt.Errorf("the native minter contract cannot be at %s", NativeMinterAddress)
+	}
+	return nil
+}
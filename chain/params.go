@@ -1,14 +1,67 @@
 package chain
 
 import (
+	"fmt"
 	"math/big"
+
+	"github.com/0xPolygon/minimal/types"
 )
 
 // Params are all the set of params for the chain
 type Params struct {
-	Forks   *Forks                 `json:"forks"`
-	ChainID int                    `json:"chainID"`
-	Engine  map[string]interface{} `json:"engine"`
+	Forks     *Forks                 `json:"forks"`
+	ChainID   int                    `json:"chainID"`
+	Engine    map[string]interface{} `json:"engine"`
+	Allowlist *Allowlist             `json:"allowlist,omitempty"`
+
+	// DeployAllowlist restricts the deployment of contracts, anyone can deploy them if nil
+	DeployAllowlist *DeployAllowlist `json:"deployAllowlist,omitempty"`
+
+	// GasLimit manages the gas limit of the blocks. If nil, the blocks are
+	// built with the legacy gas limit and any gas limit is valid
+	GasLimit *GasLimitParams `json:"gasLimit,omitempty"`
+
+	// Header configures the validation of the headers, the defaults are used if nil
+	Header *HeaderParams `json:"header,omitempty"`
+
+	// MaxCodeSizes are the max sizes of the code of the contracts deployed from each
+	// block, in order of their blocks. DefaultMaxCodeSize is used before the first one
+	MaxCodeSizes []*CodeSizeLimit `json:"maxCodeSize,omitempty"`
+
+	// NativeMinter enables the mint and burn of the native token by a system contract, disabled if nil
+	NativeMinter *NativeMinterParams `json:"nativeMinter,omitempty"`
+
+	// Precompiles are the custom precompiled contracts of the chain, indexed
+	// by the name with which their implementation is registered
+	Precompiles map[string]*PrecompileParams `json:"precompiles,omitempty"`
+
+	// Extra are arbitrary params of the chain that are not used by the client
+	Extra map[string]interface{} `json:"extra,omitempty"`
+}
+
+// Allowlist restricts the transactions accepted by the pool to the ones
+// sent by an allowed sender or sent to an allowed contract
+type Allowlist struct {
+	// Senders is the list of allowed senders
+	Senders []types.Address `json:"senders,omitempty"`
+
+	// Contracts is the list of allowed receivers
+	Contracts []types.Address `json:"contracts,omitempty"`
+
+	// Registry is the address of an on-chain allowlist. A sender is allowed
+	// if it is set in the mapping(address => bool) at the storage slot 0
+	Registry *types.Address `json:"registry,omitempty"`
+}
+
+// DeployAllowlist restricts the contract deployments to the txns sent by an allowed
+// deployer, either the creation txns or the creations of the contracts they call
+type DeployAllowlist struct {
+	// Deployers is the list of allowed deployers
+	Deployers []types.Address `json:"deployers,omitempty"`
+
+	// Registry is the address of an on-chain allowlist. A deployer is allowed
+	// if it is set in the mapping(address => bool) at the storage slot 0
+	Registry *types.Address `json:"registry,omitempty"`
 }
 
 func (p *Params) GetEngine() string {
@@ -26,6 +79,7 @@ type Forks struct {
 	Constantinople *Fork `json:"constantinople,omitempty"`
 	Petersburg     *Fork `json:"petersburg,omitempty"`
 	Istanbul       *Fork `json:"istanbul,omitempty"`
+	Berlin         *Fork `json:"berlin,omitempty"`
 	EIP150         *Fork `json:"EIP150,omitempty"`
 	EIP158         *Fork `json:"EIP158,omitempty"`
 	EIP155         *Fork `json:"EIP155,omitempty"`
@@ -54,6 +108,14 @@ func (f *Forks) IsPetersburg(block uint64) bool {
 	return f.active(f.Petersburg, block)
 }
 
+func (f *Forks) IsIstanbul(block uint64) bool {
+	return f.active(f.Istanbul, block)
+}
+
+func (f *Forks) IsBerlin(block uint64) bool {
+	return f.active(f.Berlin, block)
+}
+
 func (f *Forks) IsEIP150(block uint64) bool {
 	return f.active(f.EIP150, block)
 }
@@ -73,12 +135,127 @@ func (f *Forks) At(block uint64) ForksInTime {
 		Constantinople: f.active(f.Constantinople, block),
 		Petersburg:     f.active(f.Petersburg, block),
 		Istanbul:       f.active(f.Istanbul, block),
+		Berlin:         f.active(f.Berlin, block),
 		EIP150:         f.active(f.EIP150, block),
 		EIP158:         f.active(f.EIP158, block),
 		EIP155:         f.active(f.EIP155, block),
 	}
 }
 
+// namedFork is a fork of the schedule with its name
+type namedFork struct {
+	name string
+	fork *Fork
+}
+
+// ordered returns the forks in the order in which they have to be activated
+func (f *Forks) ordered() []namedFork {
+	return []namedFork{
+		{"homestead", f.Homestead},
+		{"EIP150", f.EIP150},
+		{"EIP155", f.EIP155},
+		{"EIP158", f.EIP158},
+		{"byzantium", f.Byzantium},
+		{"constantinople", f.Constantinople},
+		{"petersburg", f.Petersburg},
+		{"istanbul", f.Istanbul},
+		{"berlin", f.Berlin},
+	}
+}
+
+// Validate checks that the forks are activated in order
+func (f *Forks) Validate() error {
+	ordered := f.ordered()
+
+	prev := -1
+	for i, ff := range ordered {
+		if ff.fork == nil {
+			continue
+		}
+		if prev != -1 && uint64(*ff.fork) < uint64(*ordered[prev].fork) {
+			return fmt.Errorf(
+				"fork %s at block %d is activated before %s at block %d",
+				ff.name,
+				uint64(*ff.fork),
+				ordered[prev].name,
+				uint64(*ordered[prev].fork),
+			)
+		}
+		prev = i
+	}
+	return nil
+}
+
+// ScheduledFork is a fork and the block in which it is activated
+type ScheduledFork struct {
+	Name  string
+	Block uint64
+}
+
+// Schedule returns the forks that are activated, in activation order
+func (f *Forks) Schedule() []ScheduledFork {
+	schedule := []ScheduledFork{}
+	for _, ff := range f.ordered() {
+		if ff.fork != nil {
+			schedule = append(schedule, ScheduledFork{Name: ff.name, Block: uint64(*ff.fork)})
+		}
+	}
+	return schedule
+}
+
+// Pending returns the forks that are activated after the given block
+func (f *Forks) Pending(block uint64) []ScheduledFork {
+	pending := []ScheduledFork{}
+	for _, ff := range f.Schedule() {
+		if ff.Block > block {
+			pending = append(pending, ff)
+		}
+	}
+	return pending
+}
+
+// CheckCompatible checks that the forks of a chain whose head is at the given block can
+// be replaced by the new ones. The activation of a fork can be scheduled, moved or
+// canceled as long as the blocks up to the head are processed with the same rules
+func (f *Forks) CheckCompatible(newForks *Forks, head uint64) error {
+	current, updated := f.ordered(), newForks.ordered()
+	for i, ff := range current {
+		if err := checkForkCompatible(ff.name, ff.fork, updated[i].fork, head); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkForkCompatible(name string, current, updated *Fork, head uint64) error {
+	switch {
+	case current == nil && updated == nil:
+		return nil
+
+	case current == nil:
+		if updated.Active(head) {
+			return fmt.Errorf("fork %s is scheduled at block %d but the head is at block %d", name, uint64(*updated), head)
+		}
+
+	case updated == nil:
+		if current.Active(head) {
+			return fmt.Errorf("fork %s was activated at block %d and cannot be removed", name, uint64(*current))
+		}
+
+	case *current != *updated:
+		if current.Active(head) || updated.Active(head) {
+			return fmt.Errorf(
+				"fork %s cannot be moved from block %d to block %d with the head at block %d",
+				name,
+				uint64(*current),
+				uint64(*updated),
+				head,
+			)
+		}
+	}
+	return nil
+}
+
 type Fork uint64
 
 func NewFork(n uint64) *Fork {
@@ -101,6 +278,7 @@ type ForksInTime struct {
 	Constantinople,
 	Petersburg,
 	Istanbul,
+	Berlin,
 	EIP150,
 	EIP158,
 	EIP155 bool
@@ -115,4 +293,5 @@ var AllForksEnabled = &Forks{
 	Constantinople: NewFork(0),
 	Petersburg:     NewFork(0),
 	Istanbul:       NewFork(0),
+	Berlin:         NewFork(0),
 }
@@ -5,6 +5,8 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestValidateChainID(t *testing.T) {
@@ -66,3 +68,108 @@ func TestParamsForksInTime(t *testing.T) {
 	expect("constantinople", ff.Constantinople, false)
 	expect("eip150", ff.EIP150, false)
 }
+
+func TestParamsForksValidate(t *testing.T) {
+	if err := AllForksEnabled.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	f := Forks{
+		Homestead: NewFork(10),
+		Istanbul:  NewFork(20),
+	}
+	if err := f.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	f.Petersburg = NewFork(30)
+	if err := f.Validate(); err == nil {
+		t.Fatal("petersburg is activated after istanbul")
+	}
+}
+
+func TestParamsForksSchedule(t *testing.T) {
+	f := &Forks{
+		Homestead: NewFork(0),
+		EIP150:    NewFork(10),
+		Byzantium: NewFork(20),
+	}
+
+	assert.Equal(t, []ScheduledFork{
+		{Name: "homestead", Block: 0},
+		{Name: "EIP150", Block: 10},
+		{Name: "byzantium", Block: 20},
+	}, f.Schedule())
+
+	assert.Equal(t, []ScheduledFork{
+		{Name: "byzantium", Block: 20},
+	}, f.Pending(10))
+}
+
+func TestParamsForksCheckCompatible(t *testing.T) {
+	current := &Forks{
+		Homestead: NewFork(0),
+		Byzantium: NewFork(20),
+	}
+
+	cases := []struct {
+		name       string
+		forks      *Forks
+		head       uint64
+		compatible bool
+	}{
+		{
+			"schedule a fork after the head",
+			&Forks{Homestead: NewFork(0), Byzantium: NewFork(20), Istanbul: NewFork(30)},
+			15,
+			true,
+		},
+		{
+			"schedule a fork before the head",
+			&Forks{Homestead: NewFork(0), Byzantium: NewFork(20), Istanbul: NewFork(10)},
+			15,
+			false,
+		},
+		{
+			"move a pending fork",
+			&Forks{Homestead: NewFork(0), Byzantium: NewFork(25)},
+			15,
+			true,
+		},
+		{
+			"move an active fork",
+			&Forks{Homestead: NewFork(0), Byzantium: NewFork(25)},
+			20,
+			false,
+		},
+		{
+			"move a pending fork before the head",
+			&Forks{Homestead: NewFork(0), Byzantium: NewFork(10)},
+			15,
+			false,
+		},
+		{
+			"cancel a pending fork",
+			&Forks{Homestead: NewFork(0)},
+			15,
+			true,
+		},
+		{
+			"cancel an active fork",
+			&Forks{Byzantium: NewFork(20)},
+			15,
+			false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := current.CheckCompatible(c.forks, c.head)
+			if c.compatible {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package chain
+
+import (
+	"fmt"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// builtinPrecompiles is the number of precompiled contracts of ethereum, which are at
+// the addresses from 0x1 to 0x9 and cannot be replaced by the custom ones
+const builtinPrecompiles = 9
+
+// PrecompileParams enable a custom precompiled contract of the chain. The contract is
+// implemented in Go and registered with the name under which the params are set
+type PrecompileParams struct {
+	// Address is the address of the contract
+	Address types.Address `json:"address"`
+
+	// Block is the block in which the contract is activated, the genesis if nil
+	Block *Fork `json:"block,omitempty"`
+
+	// Config is the config passed to the factory of the contract
+	Config map[string]interface{} `json:"config,omitempty"`
+}
+
+// Active returns whether the contract is activated at the block
+func (p *PrecompileParams) Active(block uint64) bool {
+	return p.Block == nil || p.Block.Active(block)
+}
+
+// ValidatePrecompiles checks that the custom precompiled contracts have different
+// addresses and that they do not replace the builtin precompiled contracts
+func ValidatePrecompiles(precompiles map[string]*PrecompileParams) error {
+	addrs := map[types.Address]string{}
+	for name, p := range precompiles {
+		if p == nil {
+			return fmt.Errorf("precompile %s has no params", name)
+		}
+		if isBuiltinPrecompile(p.Address) {
+			return fmt.Errorf("precompile %s cannot replace the builtin precompile at %s", name, p.Address)
+		}
+		if other, ok := addrs[p.Address]; ok {
+			return fmt.Errorf("precompiles %s and %s have the same address %s", other, name, p.Address)
+		}
+		addrs[p.Address] = name
+	}
+	return nil
+}
+
+func isBuiltinPrecompile(addr types.Address) bool {
+	for _, b := range addr[:types.AddressLength-1] {
+		if b != 0 {
+			return false
+		}
+	}
+	last := addr[types.AddressLength-1]
+	return last >= 1 && last <= builtinPrecompiles
+}
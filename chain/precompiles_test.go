@@ -0,0 +1,70 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePrecompiles(t *testing.T) {
+	cases := []struct {
+		name        string
+		precompiles map[string]*PrecompileParams
+		valid       bool
+	}{
+		{
+			"no precompiles",
+			nil,
+			true,
+		},
+		{
+			"different addresses",
+			map[string]*PrecompileParams{
+				"a": {Address: types.StringToAddress("1000")},
+				"b": {Address: types.StringToAddress("1001"), Block: NewFork(10)},
+			},
+			true,
+		},
+		{
+			"same address",
+			map[string]*PrecompileParams{
+				"a": {Address: types.StringToAddress("1000")},
+				"b": {Address: types.StringToAddress("1000")},
+			},
+			false,
+		},
+		{
+			"builtin address",
+			map[string]*PrecompileParams{
+				"a": {Address: types.StringToAddress("9")},
+			},
+			false,
+		},
+		{
+			"no params",
+			map[string]*PrecompileParams{
+				"a": nil,
+			},
+			false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidatePrecompiles(c.precompiles)
+			if c.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestPrecompileParams_Active(t *testing.T) {
+	assert.True(t, (&PrecompileParams{}).Active(0))
+
+	p := &PrecompileParams{Block: NewFork(10)}
+	assert.False(t, p.Active(9))
+	assert.True(t, p.Active(10))
+}
@@ -0,0 +1,125 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/0xPolygon/minimal/minimal/proto"
+)
+
+// BackupCommand is the command to back up the chain of a running node
+type BackupCommand struct {
+	Meta
+}
+
+// DefineFlags defines the command flags
+func (p *BackupCommand) DefineFlags() {
+	if p.flagMap == nil {
+		// Flag map not initialized
+		p.flagMap = make(map[string]FlagDescriptor)
+	}
+
+	p.flagMap["to"] = FlagDescriptor{
+		description: "Number of the last block in the backup. Default: the head",
+		arguments: []string{
+			"BLOCK_NUMBER",
+		},
+		argumentsOptional: false,
+	}
+}
+
+// GetHelperText returns a simple description of the command
+func (p *BackupCommand) GetHelperText() string {
+	return "Writes a tar archive with the blocks and the state of a running node"
+}
+
+// Help implements the cli.BackupCommand interface
+func (p *BackupCommand) Help() string {
+	p.Meta.DefineFlags()
+	p.DefineFlags()
+
+	usage := "backup FILE [--to BLOCK_NUMBER]"
+
+	return p.GenerateHelp(p.Synopsis(), usage)
+}
+
+// Synopsis implements the cli.BackupCommand interface
+func (p *BackupCommand) Synopsis() string {
+	return p.GetHelperText()
+}
+
+// Run implements the cli.BackupCommand interface
+func (p *BackupCommand) Run(args []string) int {
+	flags := p.FlagSet("backup")
+
+	var to uint64
+	flags.Uint64Var(&to, "to", 0, "")
+
+	if err := flags.Parse(args); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		p.UI.Error("required argument (file) not passed in")
+		return 1
+	}
+	path := args[0]
+
+	conn, err := p.Conn()
+	if err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	clt := proto.NewSystemClient(conn)
+	stream, err := clt.Backup(context.Background(), &proto.BackupRequest{To: to})
+	if err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	// the archive is written to a temporary file until it is complete
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	size, err := receiveBackup(stream, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		p.UI.Error(fmt.Sprintf("failed to back up: %v", err))
+		return 1
+	}
+
+	p.UI.Output(fmt.Sprintf("Backup written to %s (%d bytes)", path, size))
+	return 0
+}
+
+func receiveBackup(stream proto.System_BackupClient, w io.Writer) (int64, error) {
+	var size int64
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return size, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if _, err := w.Write(resp.Data); err != nil {
+			return 0, err
+		}
+		size += int64(len(resp.Data))
+	}
+}
@@ -0,0 +1,79 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xPolygon/minimal/minimal/proto"
+	"github.com/golang/protobuf/ptypes/empty"
+)
+
+// Bandwidth is the command to show the bandwidth used by the node
+type Bandwidth struct {
+	Meta
+}
+
+// GetHelperText returns a simple description of the command
+func (p *Bandwidth) GetHelperText() string {
+	return "Returns the bandwidth used by libp2p protocol, gossip topic and connected peer"
+}
+
+// Help implements the cli.Bandwidth interface
+func (p *Bandwidth) Help() string {
+	p.Meta.DefineFlags()
+
+	usage := "bandwidth"
+
+	return p.GenerateHelp(p.Synopsis(), usage)
+}
+
+// Synopsis implements the cli.Bandwidth interface
+func (p *Bandwidth) Synopsis() string {
+	return p.GetHelperText()
+}
+
+// Run implements the cli.Bandwidth interface
+func (p *Bandwidth) Run(args []string) int {
+	flags := p.FlagSet("bandwidth")
+	if err := flags.Parse(args); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	conn, err := p.Conn()
+	if err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	clt := proto.NewSystemClient(conn)
+	resp, err := clt.Bandwidth(context.Background(), &empty.Empty{})
+	if err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	output := "\n[TOTAL]\n" + formatBandwidth("Total", []*proto.Bandwidth{resp.Total}) + "\n"
+	output += "\n[PROTOCOLS]\n" + formatBandwidth("Protocol", resp.Protocols) + "\n"
+	output += "\n[GOSSIP TOPICS]\n" + formatBandwidth("Topic", resp.Topics) + "\n"
+	output += "\n[PEERS]\n" + formatBandwidth("Peer", resp.Peers) + "\n"
+
+	p.UI.Output(output)
+	return 0
+}
+
+func formatBandwidth(title string, stats []*proto.Bandwidth) string {
+	rows := []string{
+		fmt.Sprintf("%s|In|Out|In/s|Out/s", title),
+	}
+	for _, st := range stats {
+		rows = append(rows, fmt.Sprintf("%s|%s|%s|%s|%s",
+			st.Name,
+			formatSize(uint64(st.TotalIn)),
+			formatSize(uint64(st.TotalOut)),
+			formatSize(uint64(st.RateIn)),
+			formatSize(uint64(st.RateOut)),
+		))
+	}
+	return formatList(rows)
+}
@@ -0,0 +1,57 @@
+package command
+
+import (
+	"flag"
+
+	"github.com/0xPolygon/minimal/minimal"
+	"github.com/hashicorp/go-hclog"
+)
+
+// ChainDBCompact is the command to compact the databases of a stopped node
+type ChainDBCompact struct {
+	Meta
+}
+
+// GetHelperText returns a simple description of the command
+func (p *ChainDBCompact) GetHelperText() string {
+	return "Compacts the blockchain and the state databases of a stopped node to reclaim disk space, i.e. after a prune"
+}
+
+// Help implements the cli.ChainDBCompact interface
+func (p *ChainDBCompact) Help() string {
+	usage := "chain db compact DATA_DIRECTORY"
+
+	return p.GenerateHelp(p.Synopsis(), usage)
+}
+
+// Synopsis implements the cli.ChainDBCompact interface
+func (p *ChainDBCompact) Synopsis() string {
+	return p.GetHelperText()
+}
+
+// Run implements the cli.ChainDBCompact interface
+func (p *ChainDBCompact) Run(args []string) int {
+	flags := flag.NewFlagSet("chain db compact", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		p.UI.Error("required argument (data directory) not passed in")
+		return 1
+	}
+
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:  "storage",
+		Level: hclog.Info,
+	})
+	if err := minimal.CompactStorage(logger, args[0]); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	p.UI.Output("Done!")
+	return 0
+}
@@ -0,0 +1,82 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/0xPolygon/minimal/minimal"
+)
+
+// ChainDBStats is the command to show the number of keys and the size of the databases of a data dir
+type ChainDBStats struct {
+	Meta
+}
+
+// GetHelperText returns a simple description of the command
+func (p *ChainDBStats) GetHelperText() string {
+	return "Shows the number of keys and the size of each key prefix of the blockchain and the state databases"
+}
+
+// Help implements the cli.ChainDBStats interface
+func (p *ChainDBStats) Help() string {
+	usage := "chain db stats DATA_DIRECTORY"
+
+	return p.GenerateHelp(p.Synopsis(), usage)
+}
+
+// Synopsis implements the cli.ChainDBStats interface
+func (p *ChainDBStats) Synopsis() string {
+	return p.GetHelperText()
+}
+
+// Run implements the cli.ChainDBStats interface
+func (p *ChainDBStats) Run(args []string) int {
+	flags := flag.NewFlagSet("chain db stats", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		p.UI.Error("required argument (data directory) not passed in")
+		return 1
+	}
+
+	// the databases are opened in read-only mode, the node can be running
+	stats, err := minimal.StorageStats(args[0])
+	if err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	output := ""
+	for _, db := range stats {
+		output += fmt.Sprintf("\n[%s] (%s on disk)\n", db.Name, formatSize(db.DiskSize))
+
+		rows := []string{"Prefix|Keys|Size"}
+		for _, group := range db.Groups {
+			rows = append(rows, fmt.Sprintf("%s|%d|%s", group.Name, group.Keys, formatSize(group.Size)))
+		}
+		output += formatList(rows) + "\n"
+	}
+
+	p.UI.Output(output)
+	return 0
+}
+
+// formatSize formats a size in bytes with the largest unit under it
+func formatSize(size uint64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.2f %s", value, units[unit])
+}
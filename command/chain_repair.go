@@ -0,0 +1,96 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/0xPolygon/minimal/minimal"
+	"github.com/hashicorp/go-hclog"
+)
+
+// ChainRepair is the command to roll back the blocks not fully stored at the tail of the chain
+type ChainRepair struct {
+	Meta
+}
+
+// DefineFlags defines the command flags
+func (p *ChainRepair) DefineFlags() {
+	if p.flagMap == nil {
+		// Flag map not initialized
+		p.flagMap = make(map[string]FlagDescriptor)
+	}
+
+	p.flagMap["dry-run"] = FlagDescriptor{
+		description: "Only lists the blocks to discard, the data dir is opened in read-only mode",
+		arguments:   []string{},
+	}
+}
+
+// GetHelperText returns a simple description of the command
+func (p *ChainRepair) GetHelperText() string {
+	return "Rolls the head of a stopped node back to the last block fully stored, i.e. after a power loss"
+}
+
+// Help implements the cli.ChainRepair interface
+func (p *ChainRepair) Help() string {
+	p.DefineFlags()
+
+	usage := "chain repair DATA_DIRECTORY [--dry-run]"
+
+	return p.GenerateHelp(p.Synopsis(), usage)
+}
+
+// Synopsis implements the cli.ChainRepair interface
+func (p *ChainRepair) Synopsis() string {
+	return p.GetHelperText()
+}
+
+// Run implements the cli.ChainRepair interface
+func (p *ChainRepair) Run(args []string) int {
+	flags := flag.NewFlagSet("chain repair", flag.ContinueOnError)
+
+	var dryRun bool
+	flags.BoolVar(&dryRun, "dry-run", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		p.UI.Error("required argument (data directory) not passed in")
+		return 1
+	}
+
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:  "repair",
+		Level: hclog.Info,
+	})
+	result, err := minimal.RepairChain(logger, args[0], dryRun)
+	if err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	if len(result.Discarded) == 0 {
+		p.UI.Output(fmt.Sprintf("The chain is consistent up to the head %d", result.Head))
+		return 0
+	}
+
+	output := "\n[DISCARDED BLOCKS]\n"
+	rows := []string{"Number|Hash|Reason"}
+	for _, block := range result.Discarded {
+		rows = append(rows, fmt.Sprintf("%d|%s|%s", block.Number, block.Hash, block.Reason))
+	}
+	output += formatList(rows)
+	output += "\n"
+
+	if dryRun {
+		output += fmt.Sprintf("\nThe head would be rolled back from %d to %d (%s)", result.Head, result.NewHead, result.NewHeadHash)
+	} else {
+		output += fmt.Sprintf("\nThe head was rolled back from %d to %d (%s)", result.Head, result.NewHead, result.NewHeadHash)
+	}
+	p.UI.Output(output)
+	return 0
+}
@@ -0,0 +1,125 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/minimal"
+	"github.com/hashicorp/go-hclog"
+)
+
+// defaultVerifyChain is the chain of the nodes started without a chain flag
+const defaultVerifyChain = "test"
+
+// ChainVerify is the command to re-execute the blocks of a data dir
+type ChainVerify struct {
+	Meta
+}
+
+// DefineFlags defines the command flags
+func (p *ChainVerify) DefineFlags() {
+	if p.flagMap == nil {
+		// Flag map not initialized
+		p.flagMap = make(map[string]FlagDescriptor)
+	}
+
+	p.flagMap["chain"] = FlagDescriptor{
+		description: fmt.Sprintf("Name or genesis file of the chain of the node. Default: %s", defaultVerifyChain),
+		arguments: []string{
+			"CHAIN",
+		},
+		argumentsOptional: false,
+	}
+
+	p.flagMap["from"] = FlagDescriptor{
+		description: "First block to verify. Default: 1",
+		arguments: []string{
+			"BLOCK_NUMBER",
+		},
+		argumentsOptional: false,
+	}
+
+	p.flagMap["to"] = FlagDescriptor{
+		description: "Last block to verify. Default: the head",
+		arguments: []string{
+			"BLOCK_NUMBER",
+		},
+		argumentsOptional: false,
+	}
+}
+
+// GetHelperText returns a simple description of the command
+func (p *ChainVerify) GetHelperText() string {
+	return "Re-executes the stored blocks and checks their state roots, receipts roots and gas used"
+}
+
+// Help implements the cli.ChainVerify interface
+func (p *ChainVerify) Help() string {
+	p.DefineFlags()
+
+	usage := "chain verify DATA_DIRECTORY [--chain CHAIN] [--from BLOCK_NUMBER] [--to BLOCK_NUMBER]"
+
+	return p.GenerateHelp(p.Synopsis(), usage)
+}
+
+// Synopsis implements the cli.ChainVerify interface
+func (p *ChainVerify) Synopsis() string {
+	return p.GetHelperText()
+}
+
+// Run implements the cli.ChainVerify interface
+func (p *ChainVerify) Run(args []string) int {
+	flags := flag.NewFlagSet("chain verify", flag.ContinueOnError)
+
+	var chainName string
+	var from, to uint64
+	flags.StringVar(&chainName, "chain", defaultVerifyChain, "")
+	flags.Uint64Var(&from, "from", 1, "")
+	flags.Uint64Var(&to, "to", 0, "")
+
+	if err := flags.Parse(args); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		p.UI.Error("required argument (data directory) not passed in")
+		return 1
+	}
+
+	cc, err := chain.Import(chainName)
+	if err != nil {
+		p.UI.Error(fmt.Sprintf("Failed to load chain %s: %v", chainName, err))
+		return 1
+	}
+
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:  "verify",
+		Level: hclog.Info,
+	})
+	for _, warning := range cc.Warnings {
+		logger.Warn("chain config", "warning", warning)
+	}
+	err = minimal.VerifyChain(logger, args[0], cc.Params, from, to)
+	if mismatch, ok := err.(*minimal.BlockMismatch); ok {
+		output := "\n[MISMATCH]\n"
+		output += formatKV([]string{
+			fmt.Sprintf("Block|%d", mismatch.Number),
+			fmt.Sprintf("Hash|%s", mismatch.Hash),
+			fmt.Sprintf("Field|%s", mismatch.Field),
+			fmt.Sprintf("Stored|%s", mismatch.Expected),
+			fmt.Sprintf("Computed|%s", mismatch.Computed),
+		})
+		p.UI.Error(output + "\n")
+		return 1
+	}
+	if err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	p.UI.Output("Done!")
+	return 0
+}
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"github.com/0xPolygon/minimal/minimal"
@@ -11,6 +12,7 @@ import (
 	"github.com/mitchellh/cli"
 	"github.com/ryanuber/columnize"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 // Commands returns a mapping of all available commands
@@ -44,6 +46,11 @@ func Commands() map[string]cli.CommandFactory {
 				UI: ui,
 			}, nil
 		},
+		"genesis validate": func() (cli.Command, error) {
+			return &GenesisValidate{
+				Meta: meta,
+			}, nil
+		},
 
 		// PEER COMMANDS //
 
@@ -52,6 +59,11 @@ func Commands() map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"peers remove": func() (cli.Command, error) {
+			return &PeersRemove{
+				Meta: meta,
+			}, nil
+		},
 		"peers status": func() (cli.Command, error) {
 			return &PeersStatus{
 				Meta: meta,
@@ -62,6 +74,11 @@ func Commands() map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"swarm-key": func() (cli.Command, error) {
+			return &SwarmKey{
+				Meta: meta,
+			}, nil
+		},
 
 		// IBFT COMMANDS //
 
@@ -98,11 +115,77 @@ func Commands() map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"txpool account": func() (cli.Command, error) {
+			return &TxPoolAccount{
+				Meta: meta,
+			}, nil
+		},
 		"txpool status": func() (cli.Command, error) {
 			return &TxPoolStatus{
 				Meta: meta,
 			}, nil
 		},
+		"txpool subscribe": func() (cli.Command, error) {
+			return &TxPoolSubscribe{
+				Meta: meta,
+			}, nil
+		},
+
+		// STORAGE COMMANDS //
+
+		"storage migrate": func() (cli.Command, error) {
+			return &StorageMigrate{
+				Meta: meta,
+			}, nil
+		},
+		"storage prune": func() (cli.Command, error) {
+			return &StoragePrune{
+				Meta: meta,
+			}, nil
+		},
+		"state heal": func() (cli.Command, error) {
+			return &StateHeal{
+				Meta: meta,
+			}, nil
+		},
+		"state heal status": func() (cli.Command, error) {
+			return &StateHealStatus{
+				Meta: meta,
+			}, nil
+		},
+		"chain db stats": func() (cli.Command, error) {
+			return &ChainDBStats{
+				Meta: meta,
+			}, nil
+		},
+		"chain db compact": func() (cli.Command, error) {
+			return &ChainDBCompact{
+				Meta: meta,
+			}, nil
+		},
+		"chain verify": func() (cli.Command, error) {
+			return &ChainVerify{
+				Meta: meta,
+			}, nil
+		},
+		"chain repair": func() (cli.Command, error) {
+			return &ChainRepair{
+				Meta: meta,
+			}, nil
+		},
+
+		// BACKUP COMMANDS //
+
+		"backup": func() (cli.Command, error) {
+			return &BackupCommand{
+				Meta: meta,
+			}, nil
+		},
+		"restore": func() (cli.Command, error) {
+			return &RestoreCommand{
+				Meta: meta,
+			}, nil
+		},
 
 		// BLOCKCHAIN COMMANDS //
 
@@ -111,6 +194,11 @@ func Commands() map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"bandwidth": func() (cli.Command, error) {
+			return &Bandwidth{
+				Meta: meta,
+			}, nil
+		},
 		"monitor": func() (cli.Command, error) {
 			return &MonitorCommand{
 				Meta: meta,
@@ -140,6 +228,11 @@ type Meta struct {
 	UI   cli.Ui
 	addr string
 
+	// tls of the grpc connection, which is insecure if no file is set
+	tlsCA   string
+	tlsCert string
+	tlsKey  string
+
 	flagMap        map[string]FlagDescriptor
 	hasGlobalFlags bool
 }
@@ -217,19 +310,55 @@ func (m *Meta) DefineFlags() {
 		},
 		argumentsOptional: false,
 	}
+
+	m.flagMap["grpc-tls-ca"] = FlagDescriptor{
+		description: "Certificate authorities of the certificate of the gRPC API, which is connected over tls if set",
+		arguments: []string{
+			"CA_FILE",
+		},
+		argumentsOptional: false,
+	}
+
+	m.flagMap["grpc-tls-cert"] = FlagDescriptor{
+		description: "Client certificate presented to the gRPC API",
+		arguments: []string{
+			"CERT_FILE",
+		},
+		argumentsOptional: false,
+	}
+
+	m.flagMap["grpc-tls-key"] = FlagDescriptor{
+		description: "Key of the client certificate presented to the gRPC API",
+		arguments: []string{
+			"KEY_FILE",
+		},
+		argumentsOptional: false,
+	}
 }
 
 // FlagSet adds some default commands to handle grpc connections with the server
 func (m *Meta) FlagSet(n string) *flag.FlagSet {
 	f := flag.NewFlagSet(n, flag.ContinueOnError)
 	f.StringVar(&m.addr, "grpc-address", fmt.Sprintf("%s:%d", "127.0.0.1", minimal.DefaultGRPCPort), "")
+	f.StringVar(&m.tlsCA, "grpc-tls-ca", "", "")
+	f.StringVar(&m.tlsCert, "grpc-tls-cert", "", "")
+	f.StringVar(&m.tlsKey, "grpc-tls-key", "", "")
 
 	return f
 }
 
 // Conn returns a grpc connection
 func (m *Meta) Conn() (*grpc.ClientConn, error) {
-	conn, err := grpc.Dial(m.addr, grpc.WithInsecure())
+	opt := grpc.WithInsecure()
+	if m.tlsCA != "" || m.tlsCert != "" {
+		creds, err := m.tlsCredentials()
+		if err != nil {
+			return nil, err
+		}
+		opt = grpc.WithTransportCredentials(creds)
+	}
+
+	conn, err := grpc.Dial(m.addr, opt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to server: %v", err)
 	}
@@ -237,8 +366,31 @@ func (m *Meta) Conn() (*grpc.ClientConn, error) {
 	return conn, nil
 }
 
+// tlsCredentials returns the tls credentials of the grpc connection, the certificate of
+// the server is verified with the system roots unless the certificate authorities are set
+func (m *Meta) tlsCredentials() (credentials.TransportCredentials, error) {
+	conf := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if m.tlsCA != "" {
+		pool, err := minimal.LoadCertPool(m.tlsCA)
+		if err != nil {
+			return nil, err
+		}
+		conf.RootCAs = pool
+	}
+	if m.tlsCert != "" {
+		cert, err := tls.LoadX509KeyPair(m.tlsCert, m.tlsKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %v", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(conf), nil
+}
+
 func globalFlagsUsage() string {
-	return `[--grpc-address GRPC_ADDRESS]`
+	return `[--grpc-address GRPC_ADDRESS] [--grpc-tls-ca CA_FILE] [--grpc-tls-cert CERT_FILE --grpc-tls-key KEY_FILE]`
 }
 
 // OUTPUT FORMATTING //
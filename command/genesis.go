@@ -18,11 +18,13 @@ import (
 )
 
 const (
-	genesisFileName       = "./genesis.json"
-	defaultChainName	  = "example"
-	defaultChainID        = 100
-	defaultPremineBalance = "0x3635C9ADC5DEA00000" // 1000 ETH
-	defaultConsensus	  = "pow"
+	genesisFileName        = "./genesis.json"
+	defaultChainName       = "example"
+	defaultChainID         = 100
+	defaultPremineBalance  = "0x3635C9ADC5DEA00000" // 1000 ETH
+	defaultConsensus       = "pow"
+	defaultGasLimit        = chain.LegacyBlockGasLimit
+	defaultGasBoundDivisor = chain.DefaultGasLimitBoundDivisor
 )
 
 // GenesisCommand is the command to show the version of the agent
@@ -75,6 +77,22 @@ func (c *GenesisCommand) DefineFlags() {
 		argumentsOptional: false,
 	}
 
+	c.flagMap["block-gas-limit"] = FlagDescriptor{
+		description: fmt.Sprintf("Sets the gas limit of the genesis block. Default: %d", defaultGasLimit),
+		arguments: []string{
+			"BLOCK_GAS_LIMIT",
+		},
+		argumentsOptional: false,
+	}
+
+	c.flagMap["block-gas-bound-divisor"] = FlagDescriptor{
+		description: fmt.Sprintf("Sets the bound divisor of the change of the gas limit between blocks. The gas limit is not managed if 0. Default: %d", defaultGasBoundDivisor),
+		arguments: []string{
+			"BLOCK_GAS_BOUND_DIVISOR",
+		},
+		argumentsOptional: false,
+	}
+
 	c.flagMap["consensus"] = FlagDescriptor{
 		description: fmt.Sprintf("Sets consensus protocol. Default: %s", defaultConsensus),
 		arguments: []string{
@@ -91,6 +109,11 @@ func (c *GenesisCommand) DefineFlags() {
 		argumentsOptional: false,
 	}
 
+	c.flagMap["no-deterministic-deployer"] = FlagDescriptor{
+		description: "Does not add the deterministic deployment proxy to the genesis alloc",
+		arguments:   []string{},
+	}
+
 	c.flagMap["ibft-validator"] = FlagDescriptor{
 		description: "Sets passed in addresses as IBFT validators. Needs to be present if ibft-validators-prefix-path is omitted",
 		arguments: []string{
@@ -117,7 +140,8 @@ func (c *GenesisCommand) GetHelperText() string {
 func (c *GenesisCommand) Help() string {
 	c.DefineFlags()
 	usage := `genesis [--data-dir DATA_DIRECTORY] [--name NAME] [--chainid CHAIN_ID]
-	[--premine ADDRESS:VALUE] [--bootnode BOOTNODE_URL] [--consensus CONSENSUS_PROTOCOL]
+	[--premine ADDRESS:VALUE] [--block-gas-limit BLOCK_GAS_LIMIT] [--bootnode BOOTNODE_URL] [--consensus CONSENSUS_PROTOCOL]
+	[--no-deterministic-deployer]
 	[--ibft-validator IBFT_VALIDATOR_LIST] [--ibft-validators-prefix-path IBFT_VALIDATORS_PREFIX_PATH]`
 
 	return c.GenerateHelp(c.Synopsis(), usage)
@@ -136,9 +160,12 @@ func (c *GenesisCommand) Run(args []string) int {
 	var dataDir string
 	var premine helperFlags.ArrayFlags
 	var chainID uint64
+	var gasLimit uint64
+	var gasBoundDivisor uint64
 	var bootnodes = make(helperFlags.BootnodeFlags, 0)
 	var name string
 	var consensus string
+	var noDeterministicDeployer bool
 
 	// ibft flags
 	var ibftValidators helperFlags.ArrayFlags
@@ -148,8 +175,11 @@ func (c *GenesisCommand) Run(args []string) int {
 	flags.StringVar(&name, "name", defaultChainName, "")
 	flags.Var(&premine, "premine", "")
 	flags.Uint64Var(&chainID, "chainid", defaultChainID, "")
+	flags.Uint64Var(&gasLimit, "block-gas-limit", defaultGasLimit, "")
+	flags.Uint64Var(&gasBoundDivisor, "block-gas-bound-divisor", defaultGasBoundDivisor, "")
 	flags.Var(&bootnodes, "bootnode", "")
 	flags.StringVar(&consensus, "consensus", defaultConsensus, "")
+	flags.BoolVar(&noDeterministicDeployer, "no-deterministic-deployer", false, "")
 	flags.Var(&ibftValidators, "ibft-validator", "list of ibft validators")
 	flags.StringVar(&ibftValidatorsPrefixPath, "ibft-validators-prefix-path", "", "")
 
@@ -203,7 +233,7 @@ func (c *GenesisCommand) Run(args []string) int {
 	cc := &chain.Chain{
 		Name: name,
 		Genesis: &chain.Genesis{
-			GasLimit:   5000,
+			GasLimit:   gasLimit,
 			Difficulty: 1,
 			Alloc:      map[types.Address]*chain.GenesisAccount{},
 			ExtraData:  extraData,
@@ -217,6 +247,11 @@ func (c *GenesisCommand) Run(args []string) int {
 		},
 		Bootnodes: bootnodes,
 	}
+	if gasBoundDivisor != 0 {
+		cc.Params.GasLimit = &chain.GasLimitParams{
+			BoundDivisor: gasBoundDivisor,
+		}
+	}
 
 	if len(premine) != 0 {
 		for _, prem := range premine {
@@ -242,6 +277,19 @@ func (c *GenesisCommand) Run(args []string) int {
 		}
 	}
 
+	if !noDeterministicDeployer {
+		// the deployer keeps the balance premined at its address
+		if err := cc.Genesis.AddDeterministicDeployer(); err != nil {
+			c.UI.Error(fmt.Sprintf("failed to add the deterministic deployer: %v", err))
+			return 1
+		}
+	}
+
+	if err := cc.Validate(); err != nil {
+		c.UI.Error(fmt.Sprintf("Invalid genesis: %v", err))
+		return 1
+	}
+
 	data, err := json.MarshalIndent(cc, "", "    ")
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Failed to generate genesis: %v", err))
@@ -0,0 +1,112 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/consensus/ibft"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// GenesisValidate is the command to validate a genesis file
+type GenesisValidate struct {
+	Meta
+}
+
+// GetHelperText returns a simple description of the command
+func (p *GenesisValidate) GetHelperText() string {
+	return "Validates the params, the forks, the alloc and the validators of a genesis file"
+}
+
+// Help implements the cli.GenesisValidate interface
+func (p *GenesisValidate) Help() string {
+	usage := fmt.Sprintf("genesis validate [GENESIS_FILE]. Default: %s", genesisFileName)
+
+	return p.GenerateHelp(p.Synopsis(), usage)
+}
+
+// Synopsis implements the cli.GenesisValidate interface
+func (p *GenesisValidate) Synopsis() string {
+	return p.GetHelperText()
+}
+
+// Run implements the cli.GenesisValidate interface
+func (p *GenesisValidate) Run(args []string) int {
+	flags := flag.NewFlagSet("genesis validate", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	path := genesisFileName
+	args = flags.Args()
+	if len(args) > 1 {
+		p.UI.Error("too many arguments")
+		return 1
+	}
+	if len(args) == 1 {
+		path = args[0]
+	}
+
+	cc, err := chain.ImportFromFile(path)
+	if err != nil {
+		p.UI.Error(fmt.Sprintf("Invalid genesis (%s): %v", path, err))
+		return 1
+	}
+	for _, warning := range cc.Warnings {
+		p.UI.Warn(fmt.Sprintf("Genesis warning (%s): %s", path, warning))
+	}
+
+	output := "\n[GENESIS]\n"
+	output += formatKV([]string{
+		fmt.Sprintf("Name|%s", cc.Name),
+		fmt.Sprintf("Chain ID|%d", cc.Params.ChainID),
+		fmt.Sprintf("Engine|%s", cc.Params.GetEngine()),
+		fmt.Sprintf("Gas limit|%d", cc.Genesis.GasLimit),
+		fmt.Sprintf("Accounts|%d", len(cc.Genesis.Alloc)),
+	})
+
+	if cc.Params.GetEngine() == "ibft" {
+		validators, err := genesisIbftValidators(cc.Genesis)
+		if err != nil {
+			p.UI.Error(fmt.Sprintf("Invalid genesis (%s): %v", path, err))
+			return 1
+		}
+
+		output += "\n\n[IBFT VALIDATORS]\n"
+		rows := []string{}
+		for _, val := range validators {
+			rows = append(rows, val.String())
+		}
+		output += formatList(rows)
+	}
+
+	p.UI.Output(output + "\n")
+	return 0
+}
+
+// genesisIbftValidators returns the initial validator set
+// encoded in the extra data of the genesis
+func genesisIbftValidators(genesis *chain.Genesis) ([]types.Address, error) {
+	if len(genesis.ExtraData) < ibft.IstanbulExtraVanity {
+		return nil, fmt.Errorf("the extra data does not include the ibft validators")
+	}
+
+	extra := &ibft.IstanbulExtra{}
+	if err := extra.UnmarshalRLP(genesis.ExtraData[ibft.IstanbulExtraVanity:]); err != nil {
+		return nil, fmt.Errorf("failed to decode the ibft extra data: %v", err)
+	}
+	if len(extra.Validators) == 0 {
+		return nil, fmt.Errorf("the ibft validator set is empty")
+	}
+
+	seen := map[types.Address]bool{}
+	for _, val := range extra.Validators {
+		if seen[val] {
+			return nil, fmt.Errorf("validator %s is duplicated", val)
+		}
+		seen[val] = true
+	}
+	return extra.Validators, nil
+}
@@ -15,7 +15,7 @@ type PeersList struct {
 
 // GetHelperText returns a simple description of the command
 func (p *PeersList) GetHelperText() string {
-	return "Returns the list of connected peers, including the current node"
+	return "Returns the list of connected peers, with their direction, latency, score and client"
 }
 
 // Help implements the cli.PeersList interface
@@ -59,13 +59,28 @@ func (p *PeersList) Run(args []string) int {
 
 func formatPeers(peers []*proto.Peer) string {
 	if len(peers) == 0 {
-		return "No deployments found"
+		return "No peers connected"
 	}
 
 	rows := make([]string, len(peers)+1)
-	rows[0] = "ID"
+	rows[0] = "ID|Direction|Latency|Score|Client"
 	for i, d := range peers {
-		rows[i+1] = fmt.Sprintf("%s", d.Id)
+		rows[i+1] = fmt.Sprintf("%s|%s|%s|%g|%s", d.Id, formatDirection(d.Inbound), formatLatency(d.Latency), d.Score, d.Client)
 	}
 	return formatList(rows)
 }
+
+func formatDirection(inbound bool) string {
+	if inbound {
+		return "inbound"
+	}
+	return "outbound"
+}
+
+// formatLatency formats the latency in milliseconds, empty if unknown
+func formatLatency(latency float64) string {
+	if latency == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.1fms", latency)
+}
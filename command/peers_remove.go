@@ -0,0 +1,61 @@
+package command
+
+import (
+	"context"
+
+	"github.com/0xPolygon/minimal/minimal/proto"
+)
+
+// PeersRemove is the PeersRemove to start the sever
+type PeersRemove struct {
+	Meta
+}
+
+// GetHelperText returns a simple description of the command
+func (p *PeersRemove) GetHelperText() string {
+	return "Removes the peer from the static peers and disconnects it, using the libp2p ID of the peer"
+}
+
+// Help implements the cli.PeersRemove interface
+func (p *PeersRemove) Help() string {
+	p.Meta.DefineFlags()
+
+	usage := "peers remove PEER_ID"
+
+	return p.GenerateHelp(p.Synopsis(), usage)
+}
+
+// Synopsis implements the cli.PeersRemove interface
+func (p *PeersRemove) Synopsis() string {
+	return p.GetHelperText()
+}
+
+// Run implements the cli.PeersRemove interface
+func (p *PeersRemove) Run(args []string) int {
+	flags := p.FlagSet("peers remove")
+	if err := flags.Parse(args); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		p.UI.Error("peer id argument expected")
+		return 1
+	}
+
+	conn, err := p.Conn()
+	if err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	clt := proto.NewSystemClient(conn)
+	if _, err := clt.PeersRemove(context.Background(), &proto.PeersRemoveRequest{Id: args[0]}); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	p.UI.Info("Peer removed")
+	return 0
+}
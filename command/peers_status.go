@@ -3,6 +3,7 @@ package command
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/0xPolygon/minimal/minimal/proto"
 )
@@ -58,8 +59,19 @@ func (p *PeersStatus) Run(args []string) int {
 		return 1
 	}
 
-	fmt.Println("-- PEER STATUS --")
-	fmt.Println(resp)
-
+	p.UI.Output(formatPeerStatus(resp))
 	return 0
 }
+
+func formatPeerStatus(peer *proto.Peer) string {
+	rows := []string{
+		fmt.Sprintf("ID|%s", peer.Id),
+		fmt.Sprintf("Client|%s", peer.Client),
+		fmt.Sprintf("Direction|%s", formatDirection(peer.Inbound)),
+		fmt.Sprintf("Latency|%s", formatLatency(peer.Latency)),
+		fmt.Sprintf("Score|%g", peer.Score),
+		fmt.Sprintf("Addrs|%s", strings.Join(peer.Addrs, ", ")),
+		fmt.Sprintf("Protocols|%s", strings.Join(peer.Protocols, ", ")),
+	}
+	return formatKV(rows)
+}
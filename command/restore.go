@@ -0,0 +1,89 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/0xPolygon/minimal/minimal"
+	"github.com/hashicorp/go-hclog"
+)
+
+// RestoreCommand is the command to seed a data dir with a backup
+type RestoreCommand struct {
+	Meta
+}
+
+// DefineFlags defines the command flags
+func (p *RestoreCommand) DefineFlags() {
+	if p.flagMap == nil {
+		// Flag map not initialized
+		p.flagMap = make(map[string]FlagDescriptor)
+	}
+
+	p.flagMap["storage-backend"] = FlagDescriptor{
+		description: fmt.Sprintf("Storage backend of the data dir. Default: %s", minimal.DefaultStorageBackend),
+		arguments: []string{
+			"BACKEND",
+		},
+		argumentsOptional: false,
+	}
+}
+
+// GetHelperText returns a simple description of the command
+func (p *RestoreCommand) GetHelperText() string {
+	return "Restores the blocks and the state of a backup in an empty data dir"
+}
+
+// Help implements the cli.RestoreCommand interface
+func (p *RestoreCommand) Help() string {
+	p.DefineFlags()
+
+	usage := "restore DATA_DIRECTORY FILE [--storage-backend BACKEND]"
+
+	return p.GenerateHelp(p.Synopsis(), usage)
+}
+
+// Synopsis implements the cli.RestoreCommand interface
+func (p *RestoreCommand) Synopsis() string {
+	return p.GetHelperText()
+}
+
+// Run implements the cli.RestoreCommand interface
+func (p *RestoreCommand) Run(args []string) int {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+
+	var backend string
+	flags.StringVar(&backend, "storage-backend", minimal.DefaultStorageBackend, "")
+
+	if err := flags.Parse(args); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 2 {
+		p.UI.Error("required arguments (data directory and file) not passed in")
+		return 1
+	}
+
+	f, err := os.Open(args[1])
+	if err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+	defer f.Close()
+
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:  "restore",
+		Level: hclog.Info,
+	})
+	info, err := minimal.RestoreBackup(logger, args[0], backend, f)
+	if err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	p.UI.Output(fmt.Sprintf("Restored block %d (%s)", info.Number, info.Hash))
+	return 0
+}
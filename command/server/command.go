@@ -100,7 +100,17 @@ func readConfig(args []string) (*Config, error) {
 	config := defaultConfig()
 
 	cliConfig := &Config{
-		Network: &Network{},
+		Network:   &Network{},
+		Telemetry: &Telemetry{},
+		TxPool:    &TxPool{},
+		Cache:     &Cache{},
+		RPCLogs:   &RPCLogs{},
+
+		RPCRequests:  &RPCRequests{},
+		RPCWebsocket: &RPCWebsocket{},
+
+		JSONRPCTLS: &TLS{},
+		GRPCTLS:    &TLS{},
 	}
 
 	flags := flag.NewFlagSet("server", flag.ContinueOnError)
@@ -112,13 +122,66 @@ func readConfig(args []string) (*Config, error) {
 	flags.StringVar(&configFile, "config", "", "")
 	flags.StringVar(&cliConfig.Chain, "chain", "", "")
 	flags.StringVar(&cliConfig.DataDir, "data-dir", "", "")
+	flags.StringVar(&cliConfig.StorageBackend, "storage-backend", "", "")
+	flags.Uint64Var(&cliConfig.FreezerThreshold, "freezer-threshold", 0, "")
+	flags.BoolVar(&cliConfig.StorageCompression, "storage-compression", false, "")
+	flags.Uint64Var(&cliConfig.BlockGasTarget, "block-gas-target", 0, "")
+	flags.Uint64Var(&cliConfig.RPCGasCap, "rpc-gas-cap", 0, "")
+	flags.Uint64Var(&cliConfig.RPCWsSubscriptionLimit, "rpc-ws-subscription-limit", 0, "")
+	flags.BoolVar(&cliConfig.RPCDebug, "rpc-debug", false, "")
+	flags.BoolVar(&cliConfig.RPCTrace, "rpc-trace", false, "")
+	flags.BoolVar(&cliConfig.RPCAdmin, "rpc-admin", false, "")
+	flags.Uint64Var(&cliConfig.RPCLogs.BlockRange, "rpc-logs-block-range", 0, "")
+	flags.Uint64Var(&cliConfig.RPCLogs.MaxResults, "rpc-logs-max-results", 0, "")
+	flags.StringVar(&cliConfig.RPCLogs.ScanTimeout, "rpc-logs-scan-timeout", "", "")
+	flags.Uint64Var(&cliConfig.RPCRequests.MaxConcurrent, "rpc-max-concurrent-requests", 0, "")
+	flags.StringVar(&cliConfig.RPCRequests.Timeout, "rpc-request-timeout", "", "")
+	flags.StringVar(&cliConfig.RPCSlowRequest, "rpc-slow-request", "", "")
+	flags.StringVar(&cliConfig.RPCShutdownTimeout, "rpc-shutdown-timeout", "", "")
+	flags.Uint64Var(&cliConfig.RPCWebsocket.MaxConnections, "rpc-ws-max-connections", 0, "")
+	flags.StringVar(&cliConfig.RPCWebsocket.PingInterval, "rpc-ws-ping-interval", "", "")
+	flags.StringVar(&cliConfig.RPCWebsocket.IdleTimeout, "rpc-ws-idle-timeout", "", "")
+	flags.Uint64Var(&cliConfig.RPCWebsocket.SendBuffer, "rpc-ws-send-buffer", 0, "")
+	flags.StringVar(&cliConfig.Pruning, "pruning", "", "")
+	flags.Uint64Var(&cliConfig.PruningKeep, "pruning-keep", 0, "")
+	flags.BoolVar(&cliConfig.Snapshot, "snapshot", false, "")
+	flags.BoolVar(&cliConfig.Preimages, "preimages", false, "")
 	flags.StringVar(&cliConfig.GRPCAddr, "grpc", "", "")
 	flags.StringVar(&cliConfig.JSONRPCAddr, "jsonrpc", "", "")
+	flags.StringVar(&cliConfig.IPCPath, "ipc-path", "", "")
+	flags.StringVar(&cliConfig.JSONRPCTLS.CertFile, "jsonrpc-tls-cert", "", "")
+	flags.StringVar(&cliConfig.JSONRPCTLS.KeyFile, "jsonrpc-tls-key", "", "")
+	flags.StringVar(&cliConfig.GRPCTLS.CertFile, "grpc-tls-cert", "", "")
+	flags.StringVar(&cliConfig.GRPCTLS.KeyFile, "grpc-tls-key", "", "")
+	flags.StringVar(&cliConfig.GRPCTLS.ClientCAFile, "grpc-tls-client-ca", "", "")
 	flags.StringVar(&cliConfig.Join, "join", "", "")
+	flags.StringVar(&cliConfig.Telemetry.PrometheusAddr, "prometheus", "", "")
+	flags.Uint64Var(&cliConfig.TxPool.MaxCalldataSize, "max-calldata-size", 0, "")
+	flags.Uint64Var(&cliConfig.TxPool.MaxPoolBytes, "max-pool-bytes", 0, "")
+	flags.IntVar(&cliConfig.Cache.Headers, "cache-headers", 0, "")
+	flags.IntVar(&cliConfig.Cache.Canonical, "cache-canonical", 0, "")
+	flags.IntVar(&cliConfig.Cache.Bodies, "cache-bodies", 0, "")
+	flags.IntVar(&cliConfig.Cache.Receipts, "cache-receipts", 0, "")
+	flags.IntVar(&cliConfig.Cache.Nodes, "cache-nodes", 0, "")
+	flags.IntVar(&cliConfig.Cache.Code, "cache-code", 0, "")
+	flags.IntVar(&cliConfig.Cache.Accounts, "cache-accounts", 0, "")
 	flags.StringVar(&cliConfig.Network.Addr, "libp2p", "", "")
 	flags.StringVar(&cliConfig.Network.NatAddr, "nat", "", "the external IP address without port, as can be seen by peers")
+	flags.BoolVar(&cliConfig.Network.NatPortMap, "nat-portmap", false, "map the libp2p port on the gateway with UPnP or NAT-PMP")
+	flags.BoolVar(&cliConfig.Network.NoNatService, "no-nat-service", false, "do not dial back the peers that check whether they are reachable")
+	flags.BoolVar(&cliConfig.Network.RelayHop, "relay-hop", false, "relay the connections between other peers")
+	flags.StringVar(&cliConfig.Network.BanDuration, "ban-duration", "", "the duration of the bans of the peers with a bad reputation")
+	flags.StringVar(&cliConfig.Network.DNSRefresh, "dns-refresh", "", "the interval between the resolutions of the dns trees of the bootnodes")
+	flags.StringVar(&cliConfig.Network.Gossip.Heartbeat, "gossip-heartbeat", "", "the interval between the heartbeats of gossipsub")
+	flags.IntVar(&cliConfig.Network.Gossip.D, "gossip-mesh-degree", 0, "the target number of peers in the mesh of a gossip topic")
+	flags.BoolVar(&cliConfig.Network.Gossip.NoPeerScoring, "no-peer-scoring", false, "do not score the peers on the gossip topics")
+	flags.StringVar(&cliConfig.Network.SwarmKey, "swarm-key", "", "the swarm key file of the private network, only the peers with the same key can connect")
 	flags.BoolVar(&cliConfig.Network.NoDiscover, "no-discover", false, "")
 	flags.Uint64Var(&cliConfig.Network.MaxPeers, "max-peers", 0, "")
+	flags.Uint64Var(&cliConfig.Network.MaxInbound, "max-inbound", 0, "the max number of peers that dial the node, max-peers if 0")
+	flags.Uint64Var(&cliConfig.Network.MaxOutbound, "max-outbound", 0, "the max number of peers the node dials, max-peers if 0")
+	flags.StringVar(&cliConfig.Network.DiscoveryInterval, "discovery-interval", "", "the interval between the lookups of new peers")
+	flags.Uint64Var(&cliConfig.Network.TargetPeers, "target-peers", 0, "the number of peers above which no new peers are looked up")
 	flags.BoolVar(&cliConfig.Dev, "dev", false, "")
 	flags.Uint64Var(&cliConfig.DevInterval, "dev-interval", 0, "")
 
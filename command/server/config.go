@@ -7,9 +7,15 @@ import (
 	"io/ioutil"
 	"net"
 	"strings"
+	"time"
 
 	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/helper/hex"
+	"github.com/0xPolygon/minimal/jsonrpc"
 	"github.com/0xPolygon/minimal/minimal"
+	"github.com/0xPolygon/minimal/network"
+	"github.com/0xPolygon/minimal/txpool"
+	"github.com/0xPolygon/minimal/types"
 	"github.com/hashicorp/hcl"
 	"github.com/imdario/mergo"
 )
@@ -24,17 +30,317 @@ type Config struct {
 	Seal        bool                   `json:"seal"`
 	LogLevel    string                 `json:"log_level"`
 	Consensus   map[string]interface{} `json:"consensus"`
+	RateLimit   *RateLimit             `json:"rate_limit"`
+	Telemetry   *Telemetry             `json:"telemetry"`
+	TxPool      *TxPool                `json:"txpool"`
+	Cache       *Cache                 `json:"cache"`
 	Dev         bool
 	DevInterval uint64
 	Join        string
+
+	// StorageBackend is the key-value database of the blockchain and the state
+	StorageBackend string `json:"storage_backend"`
+
+	// FreezerThreshold is the number of recent blocks kept in the database
+	FreezerThreshold uint64 `json:"freezer_threshold"`
+
+	// StorageCompression enables the compression of the bodies and the receipts
+	StorageCompression bool `json:"storage_compression"`
+
+	// BlockGasTarget is the gas limit the sealed blocks move toward
+	BlockGasTarget uint64 `json:"block_gas_target"`
+
+	// RPCGasCap caps the gas of the calls and of the gas estimations through JSON-RPC
+	RPCGasCap uint64 `json:"rpc_gas_cap"`
+
+	// RPCWsSubscriptionLimit is the max number of subscriptions of a JSON-RPC websocket connection
+	RPCWsSubscriptionLimit uint64 `json:"rpc_ws_subscription_limit"`
+
+	// RPCDebug exposes the debug namespace of JSON-RPC
+	RPCDebug bool `json:"rpc_debug"`
+
+	// RPCTrace exposes the trace namespace of JSON-RPC
+	RPCTrace bool `json:"rpc_trace"`
+
+	// RPCAdmin exposes the admin namespace of JSON-RPC
+	RPCAdmin bool `json:"rpc_admin"`
+
+	// RPCAccess restricts the access to the JSON-RPC server
+	RPCAccess *RPCAccess `json:"rpc_access"`
+
+	// IPCPath is the path of the unix socket of the JSON-RPC ipc endpoint
+	IPCPath string `json:"ipc_path"`
+
+	// JSONRPCTLS and GRPCTLS are the certificates of the JSON-RPC and of the GRPC endpoints
+	JSONRPCTLS *TLS `json:"jsonrpc_tls"`
+	GRPCTLS    *TLS `json:"grpc_tls"`
+
+	// RPCLogs bounds the queries of the logs through JSON-RPC
+	RPCLogs *RPCLogs `json:"rpc_logs"`
+
+	// RPCRequests bounds the concurrency and the duration of the JSON-RPC requests
+	RPCRequests *RPCRequests `json:"rpc_requests"`
+
+	// RPCSlowRequest is the duration above which the JSON-RPC requests are logged
+	RPCSlowRequest string `json:"rpc_slow_request"`
+
+	// RPCShutdownTimeout is the time the shutdown waits for the JSON-RPC requests in flight
+	RPCShutdownTimeout string `json:"rpc_shutdown_timeout"`
+
+	// RPCWebsocket bounds the JSON-RPC websocket connections
+	RPCWebsocket *RPCWebsocket `json:"rpc_ws"`
+
+	// Pruning is the pruning mode of the state, archive or full
+	Pruning string `json:"pruning"`
+
+	// PruningKeep is the number of recent states kept in full pruning mode
+	PruningKeep uint64 `json:"pruning_keep"`
+
+	// Snapshot enables the flat snapshot of the state
+	Snapshot bool `json:"snapshot"`
+
+	// Preimages enables the storage of the preimages of the hashed keys of the state
+	Preimages bool `json:"preimages"`
+
+	// DevUnsignedSenders are the senders of unsigned txns allowed in dev mode
+	DevUnsignedSenders []string `json:"dev_unsigned_senders"`
 }
 
 // Network defines the network configuration params
 type Network struct {
-	NoDiscover bool   `json:"no_discover"`
-	Addr       string `json:"addr"`
-	NatAddr    string `json:"nat_addr"`
-	MaxPeers   uint64 `json:"max_peers"`
+	NoDiscover        bool     `json:"no_discover"`
+	Addr              string   `json:"addr"`
+	NatAddr           string   `json:"nat_addr"`
+	MaxPeers          uint64   `json:"max_peers"`
+	MaxInbound        uint64   `json:"max_inbound"`
+	MaxOutbound       uint64   `json:"max_outbound"`
+	DiscoveryInterval string   `json:"discovery_interval"`
+	TargetPeers       uint64   `json:"target_peers"`
+	StaticPeers       []string `json:"static_peers"`
+	TrustedPeers      []string `json:"trusted_peers"`
+	NatPortMap        bool     `json:"nat_portmap"`
+	NoNatService      bool     `json:"no_nat_service"`
+	Relays            []string `json:"relays"`
+	RelayHop          bool     `json:"relay_hop"`
+	BanDuration       string   `json:"ban_duration"`
+	DNSTrees          []string `json:"dns_trees"`
+	DNSRefresh        string   `json:"dns_refresh"`
+	Gossip            Gossip   `json:"gossip"`
+	SwarmKey          string   `json:"swarm_key"`
+}
+
+// Gossip defines the parameters of gossipsub and of the peer scoring on the topics
+type Gossip struct {
+	Heartbeat         string                  `json:"heartbeat"`
+	D                 int                     `json:"d"`
+	Dlo               int                     `json:"dlo"`
+	Dhi               int                     `json:"dhi"`
+	Dlazy             int                     `json:"dlazy"`
+	NoPeerScoring     bool                    `json:"no_peer_scoring"`
+	GossipThreshold   *float64                `json:"gossip_threshold"`
+	PublishThreshold  *float64                `json:"publish_threshold"`
+	GraylistThreshold *float64                `json:"graylist_threshold"`
+	Topics            map[string]*GossipTopic `json:"topics"`
+}
+
+// GossipTopic defines the score parameters of a topic, the unset ones keep the defaults
+type GossipTopic struct {
+	Weight                  *float64 `json:"weight"`
+	TimeInMeshWeight        *float64 `json:"time_in_mesh_weight"`
+	FirstDeliveriesWeight   *float64 `json:"first_deliveries_weight"`
+	MeshDeliveriesWeight    *float64 `json:"mesh_deliveries_weight"`
+	MeshDeliveriesThreshold *float64 `json:"mesh_deliveries_threshold"`
+	InvalidDeliveriesWeight *float64 `json:"invalid_deliveries_weight"`
+}
+
+// TxPool defines the txpool configuration params
+type TxPool struct {
+	MaxCalldataSize uint64 `json:"max_calldata_size"`
+	MaxPoolBytes    uint64 `json:"max_pool_bytes"`
+}
+
+// Cache defines the sizes of the blockchain and state caches
+type Cache struct {
+	Headers   int `json:"headers"`
+	Canonical int `json:"canonical"`
+	Bodies    int `json:"bodies"`
+	Receipts  int `json:"receipts"`
+	Nodes     int `json:"nodes"`
+	Code      int `json:"code"`
+	Accounts  int `json:"accounts"`
+}
+
+// Telemetry defines the config details for metric services
+type Telemetry struct {
+	PrometheusAddr string `json:"prometheus_addr"`
+}
+
+// RateLimit defines the limits of the requests and of the txn submissions through JSON-RPC
+type RateLimit struct {
+	IPRate       float64            `json:"ip_rate"`
+	IPBurst      uint64             `json:"ip_burst"`
+	SenderRate   float64            `json:"sender_rate"`
+	SenderBurst  uint64             `json:"sender_burst"`
+	BanDuration  string             `json:"ban_duration"`
+	RequestRate  float64            `json:"request_rate"`
+	RequestBurst uint64             `json:"request_burst"`
+	GlobalRate   float64            `json:"global_rate"`
+	GlobalBurst  uint64             `json:"global_burst"`
+	MethodCosts  map[string]float64 `json:"method_costs"`
+}
+
+// TLS defines the certificate of an endpoint served over tls
+type TLS struct {
+	CertFile     string `json:"cert_file"`
+	KeyFile      string `json:"key_file"`
+	ClientCAFile string `json:"client_ca_file"`
+}
+
+// build returns the tls config of the endpoint, nil if no file is set
+func (t *TLS) build() *minimal.TLSConfig {
+	if t == nil || (t.CertFile == "" && t.KeyFile == "" && t.ClientCAFile == "") {
+		return nil
+	}
+	return &minimal.TLSConfig{
+		CertFile:     t.CertFile,
+		KeyFile:      t.KeyFile,
+		ClientCAFile: t.ClientCAFile,
+	}
+}
+
+// merge sets the files set in the other tls config
+func (t *TLS) merge(other *TLS) {
+	if other == nil {
+		return
+	}
+	if other.CertFile != "" {
+		t.CertFile = other.CertFile
+	}
+	if other.KeyFile != "" {
+		t.KeyFile = other.KeyFile
+	}
+	if other.ClientCAFile != "" {
+		t.ClientCAFile = other.ClientCAFile
+	}
+}
+
+// build sets the parameters of gossipsub that are set on the network config
+func (g *Gossip) build(conf *network.GossipConfig) error {
+	if g.Heartbeat != "" {
+		heartbeat, err := time.ParseDuration(g.Heartbeat)
+		if err != nil {
+			return fmt.Errorf("failed to parse gossip heartbeat '%s': %v", g.Heartbeat, err)
+		}
+		conf.HeartbeatInterval = heartbeat
+	}
+
+	// the bounds of the mesh follow the degree unless they are set
+	if g.D != 0 {
+		conf.D = g.D
+		conf.Dlo = g.D - g.D/6
+		conf.Dhi = 2 * g.D
+	}
+	if g.Dlo != 0 {
+		conf.Dlo = g.Dlo
+	}
+	if g.Dhi != 0 {
+		conf.Dhi = g.Dhi
+	}
+	if g.Dlazy != 0 {
+		conf.Dlazy = g.Dlazy
+	}
+
+	conf.PeerScoring = !g.NoPeerScoring
+	if g.GossipThreshold != nil {
+		conf.GossipThreshold = *g.GossipThreshold
+	}
+	if g.PublishThreshold != nil {
+		conf.PublishThreshold = *g.PublishThreshold
+	}
+	if g.GraylistThreshold != nil {
+		conf.GraylistThreshold = *g.GraylistThreshold
+	}
+
+	for name, topic := range g.Topics {
+		tc := network.DefaultTopicScoreConfig()
+		set := func(dst *float64, val *float64) {
+			if val != nil {
+				*dst = *val
+			}
+		}
+		set(&tc.Weight, topic.Weight)
+		set(&tc.TimeInMeshWeight, topic.TimeInMeshWeight)
+		set(&tc.FirstDeliveriesWeight, topic.FirstDeliveriesWeight)
+		set(&tc.MeshDeliveriesWeight, topic.MeshDeliveriesWeight)
+		set(&tc.MeshDeliveriesThreshold, topic.MeshDeliveriesThreshold)
+		set(&tc.InvalidDeliveriesWeight, topic.InvalidDeliveriesWeight)
+		conf.Topics[name] = tc
+	}
+	return nil
+}
+
+// merge sets the parameters set in the other gossip config
+func (g *Gossip) merge(other *Gossip) {
+	if other.Heartbeat != "" {
+		g.Heartbeat = other.Heartbeat
+	}
+	if other.D != 0 {
+		g.D = other.D
+	}
+	if other.Dlo != 0 {
+		g.Dlo = other.Dlo
+	}
+	if other.Dhi != 0 {
+		g.Dhi = other.Dhi
+	}
+	if other.Dlazy != 0 {
+		g.Dlazy = other.Dlazy
+	}
+	if other.NoPeerScoring {
+		g.NoPeerScoring = true
+	}
+	if other.GossipThreshold != nil {
+		g.GossipThreshold = other.GossipThreshold
+	}
+	if other.PublishThreshold != nil {
+		g.PublishThreshold = other.PublishThreshold
+	}
+	if other.GraylistThreshold != nil {
+		g.GraylistThreshold = other.GraylistThreshold
+	}
+	if len(other.Topics) != 0 {
+		g.Topics = other.Topics
+	}
+}
+
+// RPCLogs defines the limits of the queries of the logs through JSON-RPC
+type RPCLogs struct {
+	BlockRange  uint64 `json:"block_range"`
+	MaxResults  uint64 `json:"max_results"`
+	ScanTimeout string `json:"scan_timeout"`
+}
+
+// RPCRequests defines the limits of the execution of the JSON-RPC requests
+type RPCRequests struct {
+	MaxConcurrent uint64 `json:"max_concurrent"`
+	Timeout       string `json:"timeout"`
+}
+
+// RPCWebsocket defines the limits of the JSON-RPC websocket connections
+type RPCWebsocket struct {
+	MaxConnections uint64 `json:"max_connections"`
+	PingInterval   string `json:"ping_interval"`
+	IdleTimeout    string `json:"idle_timeout"`
+	SendBuffer     uint64 `json:"send_buffer"`
+}
+
+// RPCAccess defines the cors origins, the auth and the methods of the JSON-RPC server
+type RPCAccess struct {
+	CorsOrigins  []string `json:"cors_origins"`
+	AuthTokens   []string `json:"auth_tokens"`
+	JWTSecret    string   `json:"jwt_secret"`
+	AllowMethods []string `json:"allow_methods"`
+	DenyMethods  []string `json:"deny_methods"`
 }
 
 // defaultConfig returns the default server configuration
@@ -49,6 +355,18 @@ func defaultConfig() *Config {
 		Seal:      false,
 		LogLevel:  "INFO",
 		Consensus: map[string]interface{}{},
+		Telemetry: &Telemetry{},
+		TxPool:    &TxPool{},
+		Cache:     &Cache{},
+		RPCLogs:   &RPCLogs{},
+
+		RPCRequests:  &RPCRequests{},
+		RPCWebsocket: &RPCWebsocket{},
+
+		JSONRPCTLS: &TLS{},
+		GRPCTLS:    &TLS{},
+
+		StorageBackend: minimal.DefaultStorageBackend,
 	}
 }
 
@@ -66,6 +384,27 @@ func (c *Config) BuildConfig() (*minimal.Config, error) {
 	conf.Chain = cc
 	conf.Seal = c.Seal
 	conf.DataDir = c.DataDir
+	conf.StorageBackend = c.StorageBackend
+	conf.FreezerThreshold = c.FreezerThreshold
+	conf.StorageCompression = c.StorageCompression
+	conf.BlockGasTarget = c.BlockGasTarget
+	if c.RPCGasCap != 0 {
+		conf.RPCGasCap = c.RPCGasCap
+	}
+	if c.RPCWsSubscriptionLimit != 0 {
+		conf.RPCWsSubscriptionLimit = c.RPCWsSubscriptionLimit
+	}
+	conf.RPCDebug = c.RPCDebug
+	conf.RPCTrace = c.RPCTrace
+	conf.RPCAdmin = c.RPCAdmin
+	if c.Pruning != "" {
+		conf.Pruning = c.Pruning
+	}
+	if c.PruningKeep != 0 {
+		conf.PruningKeep = c.PruningKeep
+	}
+	conf.Snapshot = c.Snapshot
+	conf.Preimages = c.Preimages
 
 	// JSON RPC + GRPC
 	if c.GRPCAddr != "" {
@@ -80,6 +419,132 @@ func (c *Config) BuildConfig() (*minimal.Config, error) {
 			return nil, err
 		}
 	}
+	conf.IPCPath = c.IPCPath
+	conf.JSONRPCTLS = c.JSONRPCTLS.build()
+	conf.GRPCTLS = c.GRPCTLS.build()
+
+	if c.Telemetry.PrometheusAddr != "" {
+		if conf.Telemetry.PrometheusAddr, err = resolveAddr(c.Telemetry.PrometheusAddr); err != nil {
+			return nil, err
+		}
+	}
+
+	// the txpool limits are the default ones unless they are set
+	if c.TxPool.MaxCalldataSize != 0 {
+		conf.TxPoolLimits.MaxCalldataSize = c.TxPool.MaxCalldataSize
+	}
+	if c.TxPool.MaxPoolBytes != 0 {
+		conf.TxPoolLimits.MaxPoolBytes = c.TxPool.MaxPoolBytes
+	}
+
+	// the cache sizes are the default ones unless they are set
+	if c.Cache.Headers != 0 {
+		conf.Cache.Headers = c.Cache.Headers
+	}
+	if c.Cache.Canonical != 0 {
+		conf.Cache.Canonical = c.Cache.Canonical
+	}
+	if c.Cache.Bodies != 0 {
+		conf.Cache.Bodies = c.Cache.Bodies
+	}
+	if c.Cache.Receipts != 0 {
+		conf.Cache.Receipts = c.Cache.Receipts
+	}
+	if c.Cache.Nodes != 0 {
+		conf.StateCache.Nodes = c.Cache.Nodes
+	}
+	if c.Cache.Code != 0 {
+		conf.StateCache.Code = c.Cache.Code
+	}
+	if c.Cache.Accounts != 0 {
+		conf.StateCache.Accounts = c.Cache.Accounts
+	}
+
+	if c.RateLimit != nil {
+		conf.RateLimit = &jsonrpc.RateLimitConfig{
+			IPRate:       c.RateLimit.IPRate,
+			IPBurst:      c.RateLimit.IPBurst,
+			SenderRate:   c.RateLimit.SenderRate,
+			SenderBurst:  c.RateLimit.SenderBurst,
+			RequestRate:  c.RateLimit.RequestRate,
+			RequestBurst: c.RateLimit.RequestBurst,
+			GlobalRate:   c.RateLimit.GlobalRate,
+			GlobalBurst:  c.RateLimit.GlobalBurst,
+			MethodCosts:  c.RateLimit.MethodCosts,
+		}
+		if c.RateLimit.BanDuration != "" {
+			if conf.RateLimit.BanDuration, err = time.ParseDuration(c.RateLimit.BanDuration); err != nil {
+				return nil, fmt.Errorf("failed to parse ban duration '%s': %v", c.RateLimit.BanDuration, err)
+			}
+		}
+	}
+
+	// the logs limits are the default ones unless they are set
+	if c.RPCLogs.BlockRange != 0 {
+		conf.RPCLogsLimits.BlockRange = c.RPCLogs.BlockRange
+	}
+	if c.RPCLogs.MaxResults != 0 {
+		conf.RPCLogsLimits.MaxResults = c.RPCLogs.MaxResults
+	}
+	if c.RPCLogs.ScanTimeout != "" {
+		if conf.RPCLogsLimits.ScanTimeout, err = time.ParseDuration(c.RPCLogs.ScanTimeout); err != nil {
+			return nil, fmt.Errorf("failed to parse logs scan timeout '%s': %v", c.RPCLogs.ScanTimeout, err)
+		}
+	}
+
+	if c.RPCSlowRequest != "" {
+		if conf.RPCSlowRequestThreshold, err = time.ParseDuration(c.RPCSlowRequest); err != nil {
+			return nil, fmt.Errorf("failed to parse slow request threshold '%s': %v", c.RPCSlowRequest, err)
+		}
+	}
+
+	if c.RPCShutdownTimeout != "" {
+		if conf.RPCShutdownTimeout, err = time.ParseDuration(c.RPCShutdownTimeout); err != nil {
+			return nil, fmt.Errorf("failed to parse rpc shutdown timeout '%s': %v", c.RPCShutdownTimeout, err)
+		}
+	}
+
+	// the request limits are the default ones unless they are set
+	if c.RPCRequests.MaxConcurrent != 0 {
+		conf.RPCRequestLimits.MaxConcurrent = c.RPCRequests.MaxConcurrent
+	}
+	if c.RPCRequests.Timeout != "" {
+		if conf.RPCRequestLimits.Timeout, err = time.ParseDuration(c.RPCRequests.Timeout); err != nil {
+			return nil, fmt.Errorf("failed to parse request timeout '%s': %v", c.RPCRequests.Timeout, err)
+		}
+	}
+
+	// the websocket limits are the default ones unless they are set
+	if c.RPCWebsocket.MaxConnections != 0 {
+		conf.RPCWebsocket.MaxConnections = c.RPCWebsocket.MaxConnections
+	}
+	if c.RPCWebsocket.PingInterval != "" {
+		if conf.RPCWebsocket.PingInterval, err = time.ParseDuration(c.RPCWebsocket.PingInterval); err != nil {
+			return nil, fmt.Errorf("failed to parse websocket ping interval '%s': %v", c.RPCWebsocket.PingInterval, err)
+		}
+	}
+	if c.RPCWebsocket.IdleTimeout != "" {
+		if conf.RPCWebsocket.IdleTimeout, err = time.ParseDuration(c.RPCWebsocket.IdleTimeout); err != nil {
+			return nil, fmt.Errorf("failed to parse websocket idle timeout '%s': %v", c.RPCWebsocket.IdleTimeout, err)
+		}
+	}
+	if c.RPCWebsocket.SendBuffer != 0 {
+		conf.RPCWebsocket.SendBuffer = c.RPCWebsocket.SendBuffer
+	}
+
+	if c.RPCAccess != nil {
+		conf.RPCAccess = &jsonrpc.AccessConfig{
+			CorsOrigins:  c.RPCAccess.CorsOrigins,
+			AuthTokens:   c.RPCAccess.AuthTokens,
+			AllowMethods: c.RPCAccess.AllowMethods,
+			DenyMethods:  c.RPCAccess.DenyMethods,
+		}
+		if c.RPCAccess.JWTSecret != "" {
+			if conf.RPCAccess.JWTSecret, err = hex.DecodeHex(c.RPCAccess.JWTSecret); err != nil {
+				return nil, fmt.Errorf("failed to decode jwt secret: %v", err)
+			}
+		}
+	}
 
 	// Network
 	{
@@ -93,6 +558,40 @@ func (c *Config) BuildConfig() (*minimal.Config, error) {
 
 		conf.Network.NoDiscover = c.Network.NoDiscover
 		conf.Network.MaxPeers = c.Network.MaxPeers
+		conf.Network.MaxInbound = c.Network.MaxInbound
+		conf.Network.MaxOutbound = c.Network.MaxOutbound
+		conf.Network.TargetPeers = c.Network.TargetPeers
+		conf.Network.StaticPeers = c.Network.StaticPeers
+		conf.Network.TrustedPeers = c.Network.TrustedPeers
+		conf.Network.NatPortMap = c.Network.NatPortMap
+		conf.Network.NatService = !c.Network.NoNatService
+		conf.Network.Relays = c.Network.Relays
+		conf.Network.RelayHop = c.Network.RelayHop
+		conf.Network.DNSTrees = c.Network.DNSTrees
+
+		if c.Network.DiscoveryInterval != "" {
+			if conf.Network.DiscoveryInterval, err = time.ParseDuration(c.Network.DiscoveryInterval); err != nil {
+				return nil, fmt.Errorf("failed to parse discovery interval '%s': %v", c.Network.DiscoveryInterval, err)
+			}
+		}
+		if c.Network.BanDuration != "" {
+			if conf.Network.BanDuration, err = time.ParseDuration(c.Network.BanDuration); err != nil {
+				return nil, fmt.Errorf("failed to parse ban duration '%s': %v", c.Network.BanDuration, err)
+			}
+		}
+		if c.Network.DNSRefresh != "" {
+			if conf.Network.DNSRefresh, err = time.ParseDuration(c.Network.DNSRefresh); err != nil {
+				return nil, fmt.Errorf("failed to parse dns refresh '%s': %v", c.Network.DNSRefresh, err)
+			}
+		}
+		if err := c.Network.Gossip.build(conf.Network.Gossip); err != nil {
+			return nil, err
+		}
+		if c.Network.SwarmKey != "" {
+			if conf.Network.SwarmKey, err = network.ReadSwarmKey(c.Network.SwarmKey); err != nil {
+				return nil, err
+			}
+		}
 
 		conf.Chain = cc
 	}
@@ -108,6 +607,16 @@ func (c *Config) BuildConfig() (*minimal.Config, error) {
 		if c.DevInterval != 0 {
 			engineConfig["interval"] = c.DevInterval
 		}
+		// accept unsigned txns from the allowed senders (or any sender)
+		conf.Dev = &txpool.DevConfig{}
+		for _, raw := range c.DevUnsignedSenders {
+			addr := types.Address{}
+			if err := addr.UnmarshalText([]byte(raw)); err != nil {
+				return nil, fmt.Errorf("failed to parse unsigned sender '%s': %v", raw, err)
+			}
+			conf.Dev.UnsignedSenders = append(conf.Dev.UnsignedSenders, addr)
+		}
+
 		conf.Chain.Params.Forks = chain.AllForksEnabled
 		conf.Chain.Params.Engine = map[string]interface{}{
 			"dev": engineConfig,
@@ -137,6 +646,58 @@ func (c *Config) mergeConfigWith(otherConfig *Config) error {
 		c.DataDir = otherConfig.DataDir
 	}
 
+	if otherConfig.StorageBackend != "" {
+		c.StorageBackend = otherConfig.StorageBackend
+	}
+
+	if otherConfig.FreezerThreshold != 0 {
+		c.FreezerThreshold = otherConfig.FreezerThreshold
+	}
+
+	if otherConfig.StorageCompression {
+		c.StorageCompression = true
+	}
+
+	if otherConfig.BlockGasTarget != 0 {
+		c.BlockGasTarget = otherConfig.BlockGasTarget
+	}
+
+	if otherConfig.RPCGasCap != 0 {
+		c.RPCGasCap = otherConfig.RPCGasCap
+	}
+
+	if otherConfig.RPCWsSubscriptionLimit != 0 {
+		c.RPCWsSubscriptionLimit = otherConfig.RPCWsSubscriptionLimit
+	}
+
+	if otherConfig.RPCDebug {
+		c.RPCDebug = true
+	}
+
+	if otherConfig.RPCTrace {
+		c.RPCTrace = true
+	}
+
+	if otherConfig.RPCAdmin {
+		c.RPCAdmin = true
+	}
+
+	if otherConfig.Pruning != "" {
+		c.Pruning = otherConfig.Pruning
+	}
+
+	if otherConfig.PruningKeep != 0 {
+		c.PruningKeep = otherConfig.PruningKeep
+	}
+
+	if otherConfig.Snapshot {
+		c.Snapshot = true
+	}
+
+	if otherConfig.Preimages {
+		c.Preimages = true
+	}
+
 	if otherConfig.Chain != "" {
 		c.Chain = otherConfig.Chain
 	}
@@ -149,6 +710,10 @@ func (c *Config) mergeConfigWith(otherConfig *Config) error {
 		c.DevInterval = otherConfig.DevInterval
 	}
 
+	if len(otherConfig.DevUnsignedSenders) != 0 {
+		c.DevUnsignedSenders = otherConfig.DevUnsignedSenders
+	}
+
 	if otherConfig.Seal {
 		c.Seal = true
 	}
@@ -165,6 +730,102 @@ func (c *Config) mergeConfigWith(otherConfig *Config) error {
 		c.JSONRPCAddr = otherConfig.JSONRPCAddr
 	}
 
+	if otherConfig.IPCPath != "" {
+		c.IPCPath = otherConfig.IPCPath
+	}
+
+	c.JSONRPCTLS.merge(otherConfig.JSONRPCTLS)
+	c.GRPCTLS.merge(otherConfig.GRPCTLS)
+
+	if otherConfig.Telemetry != nil && otherConfig.Telemetry.PrometheusAddr != "" {
+		c.Telemetry.PrometheusAddr = otherConfig.Telemetry.PrometheusAddr
+	}
+
+	if otherConfig.TxPool != nil {
+		if otherConfig.TxPool.MaxCalldataSize != 0 {
+			c.TxPool.MaxCalldataSize = otherConfig.TxPool.MaxCalldataSize
+		}
+		if otherConfig.TxPool.MaxPoolBytes != 0 {
+			c.TxPool.MaxPoolBytes = otherConfig.TxPool.MaxPoolBytes
+		}
+	}
+
+	if otherConfig.Cache != nil {
+		if otherConfig.Cache.Headers != 0 {
+			c.Cache.Headers = otherConfig.Cache.Headers
+		}
+		if otherConfig.Cache.Canonical != 0 {
+			c.Cache.Canonical = otherConfig.Cache.Canonical
+		}
+		if otherConfig.Cache.Bodies != 0 {
+			c.Cache.Bodies = otherConfig.Cache.Bodies
+		}
+		if otherConfig.Cache.Receipts != 0 {
+			c.Cache.Receipts = otherConfig.Cache.Receipts
+		}
+		if otherConfig.Cache.Nodes != 0 {
+			c.Cache.Nodes = otherConfig.Cache.Nodes
+		}
+		if otherConfig.Cache.Code != 0 {
+			c.Cache.Code = otherConfig.Cache.Code
+		}
+		if otherConfig.Cache.Accounts != 0 {
+			c.Cache.Accounts = otherConfig.Cache.Accounts
+		}
+	}
+
+	if otherConfig.RateLimit != nil {
+		c.RateLimit = otherConfig.RateLimit
+	}
+
+	if otherConfig.RPCLogs != nil {
+		if otherConfig.RPCLogs.BlockRange != 0 {
+			c.RPCLogs.BlockRange = otherConfig.RPCLogs.BlockRange
+		}
+		if otherConfig.RPCLogs.MaxResults != 0 {
+			c.RPCLogs.MaxResults = otherConfig.RPCLogs.MaxResults
+		}
+		if otherConfig.RPCLogs.ScanTimeout != "" {
+			c.RPCLogs.ScanTimeout = otherConfig.RPCLogs.ScanTimeout
+		}
+	}
+
+	if otherConfig.RPCSlowRequest != "" {
+		c.RPCSlowRequest = otherConfig.RPCSlowRequest
+	}
+
+	if otherConfig.RPCShutdownTimeout != "" {
+		c.RPCShutdownTimeout = otherConfig.RPCShutdownTimeout
+	}
+
+	if otherConfig.RPCRequests != nil {
+		if otherConfig.RPCRequests.MaxConcurrent != 0 {
+			c.RPCRequests.MaxConcurrent = otherConfig.RPCRequests.MaxConcurrent
+		}
+		if otherConfig.RPCRequests.Timeout != "" {
+			c.RPCRequests.Timeout = otherConfig.RPCRequests.Timeout
+		}
+	}
+
+	if otherConfig.RPCWebsocket != nil {
+		if otherConfig.RPCWebsocket.MaxConnections != 0 {
+			c.RPCWebsocket.MaxConnections = otherConfig.RPCWebsocket.MaxConnections
+		}
+		if otherConfig.RPCWebsocket.PingInterval != "" {
+			c.RPCWebsocket.PingInterval = otherConfig.RPCWebsocket.PingInterval
+		}
+		if otherConfig.RPCWebsocket.IdleTimeout != "" {
+			c.RPCWebsocket.IdleTimeout = otherConfig.RPCWebsocket.IdleTimeout
+		}
+		if otherConfig.RPCWebsocket.SendBuffer != 0 {
+			c.RPCWebsocket.SendBuffer = otherConfig.RPCWebsocket.SendBuffer
+		}
+	}
+
+	if otherConfig.RPCAccess != nil {
+		c.RPCAccess = otherConfig.RPCAccess
+	}
+
 	if otherConfig.Join != "" {
 		c.Join = otherConfig.Join
 	}
@@ -180,6 +841,49 @@ func (c *Config) mergeConfigWith(otherConfig *Config) error {
 		if otherConfig.Network.MaxPeers != 0 {
 			c.Network.MaxPeers = otherConfig.Network.MaxPeers
 		}
+		if otherConfig.Network.MaxInbound != 0 {
+			c.Network.MaxInbound = otherConfig.Network.MaxInbound
+		}
+		if otherConfig.Network.MaxOutbound != 0 {
+			c.Network.MaxOutbound = otherConfig.Network.MaxOutbound
+		}
+		if otherConfig.Network.DiscoveryInterval != "" {
+			c.Network.DiscoveryInterval = otherConfig.Network.DiscoveryInterval
+		}
+		if otherConfig.Network.TargetPeers != 0 {
+			c.Network.TargetPeers = otherConfig.Network.TargetPeers
+		}
+		if len(otherConfig.Network.StaticPeers) != 0 {
+			c.Network.StaticPeers = otherConfig.Network.StaticPeers
+		}
+		if len(otherConfig.Network.TrustedPeers) != 0 {
+			c.Network.TrustedPeers = otherConfig.Network.TrustedPeers
+		}
+		if otherConfig.Network.NatPortMap {
+			c.Network.NatPortMap = true
+		}
+		if otherConfig.Network.NoNatService {
+			c.Network.NoNatService = true
+		}
+		if len(otherConfig.Network.Relays) != 0 {
+			c.Network.Relays = otherConfig.Network.Relays
+		}
+		if otherConfig.Network.RelayHop {
+			c.Network.RelayHop = true
+		}
+		if otherConfig.Network.BanDuration != "" {
+			c.Network.BanDuration = otherConfig.Network.BanDuration
+		}
+		if len(otherConfig.Network.DNSTrees) != 0 {
+			c.Network.DNSTrees = otherConfig.Network.DNSTrees
+		}
+		if otherConfig.Network.DNSRefresh != "" {
+			c.Network.DNSRefresh = otherConfig.Network.DNSRefresh
+		}
+		c.Network.Gossip.merge(&otherConfig.Network.Gossip)
+		if otherConfig.Network.SwarmKey != "" {
+			c.Network.SwarmKey = otherConfig.Network.SwarmKey
+		}
 		if otherConfig.Network.NoDiscover {
 			c.Network.NoDiscover = true
 		}
@@ -195,7 +899,7 @@ func (c *Config) mergeConfigWith(otherConfig *Config) error {
 // readConfigFile reads the config file from the specified path, builds a Config object
 // and returns it.
 //
-//Supported file types: .json, .hcl
+// Supported file types: .json, .hcl
 func readConfigFile(path string) (*Config, error) {
 	data, err := ioutil.ReadFile(path)
 	if err != nil {
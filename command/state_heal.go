@@ -0,0 +1,121 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xPolygon/minimal/minimal/proto"
+	"github.com/golang/protobuf/ptypes/empty"
+)
+
+// StateHeal is the command to heal the trie nodes missing from the state
+type StateHeal struct {
+	Meta
+}
+
+// GetHelperText returns a simple description of the command
+func (p *StateHeal) GetHelperText() string {
+	return "Starts healing the trie nodes missing from the state of the head, they are requested to the peers"
+}
+
+// Help implements the cli.StateHeal interface
+func (p *StateHeal) Help() string {
+	p.Meta.DefineFlags()
+
+	usage := "state heal"
+
+	return p.GenerateHelp(p.Synopsis(), usage)
+}
+
+// Synopsis implements the cli.StateHeal interface
+func (p *StateHeal) Synopsis() string {
+	return p.GetHelperText()
+}
+
+// Run implements the cli.StateHeal interface
+func (p *StateHeal) Run(args []string) int {
+	flags := p.FlagSet("state heal")
+	if err := flags.Parse(args); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	conn, err := p.Conn()
+	if err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	clt := proto.NewSystemClient(conn)
+	resp, err := clt.StateHeal(context.Background(), &empty.Empty{})
+	if err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	p.UI.Output(formatHealStatus(resp))
+	return 0
+}
+
+// StateHealStatus is the command to show the progress of the healing of the state
+type StateHealStatus struct {
+	Meta
+}
+
+// GetHelperText returns a simple description of the command
+func (p *StateHealStatus) GetHelperText() string {
+	return "Returns the progress of the healing of the state"
+}
+
+// Help implements the cli.StateHealStatus interface
+func (p *StateHealStatus) Help() string {
+	p.Meta.DefineFlags()
+
+	usage := "state heal status"
+
+	return p.GenerateHelp(p.Synopsis(), usage)
+}
+
+// Synopsis implements the cli.StateHealStatus interface
+func (p *StateHealStatus) Synopsis() string {
+	return p.GetHelperText()
+}
+
+// Run implements the cli.StateHealStatus interface
+func (p *StateHealStatus) Run(args []string) int {
+	flags := p.FlagSet("state heal status")
+	if err := flags.Parse(args); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	conn, err := p.Conn()
+	if err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	clt := proto.NewSystemClient(conn)
+	resp, err := clt.StateHealStatus(context.Background(), &empty.Empty{})
+	if err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	p.UI.Output(formatHealStatus(resp))
+	return 0
+}
+
+func formatHealStatus(status *proto.HealStatus) string {
+	rows := []string{
+		fmt.Sprintf("Running|%t", status.Running),
+		fmt.Sprintf("Root|%s", status.Root),
+		fmt.Sprintf("Visited|%d", status.Visited),
+		fmt.Sprintf("Healed|%d", status.Healed),
+		fmt.Sprintf("Pending|%d", status.Pending),
+	}
+	if status.Error != "" {
+		rows = append(rows, fmt.Sprintf("Error|%s", status.Error))
+	}
+	return formatKV(rows)
+}
@@ -0,0 +1,58 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/0xPolygon/minimal/minimal"
+	"github.com/hashicorp/go-hclog"
+)
+
+// StorageMigrate is the command to migrate the data dir to another storage backend
+type StorageMigrate struct {
+	Meta
+}
+
+// GetHelperText returns a simple description of the command
+func (p *StorageMigrate) GetHelperText() string {
+	return "Migrates the blockchain and the state of a stopped node to another storage backend (leveldb, badger, pebble)"
+}
+
+// Help implements the cli.StorageMigrate interface
+func (p *StorageMigrate) Help() string {
+	usage := "storage migrate DATA_DIRECTORY BACKEND"
+
+	return p.GenerateHelp(p.Synopsis(), usage)
+}
+
+// Synopsis implements the cli.StorageMigrate interface
+func (p *StorageMigrate) Synopsis() string {
+	return p.GetHelperText()
+}
+
+// Run implements the cli.StorageMigrate interface
+func (p *StorageMigrate) Run(args []string) int {
+	flags := flag.NewFlagSet("storage migrate", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 2 {
+		p.UI.Error("required arguments (data directory and backend) not passed in")
+		return 1
+	}
+
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:  "storage",
+		Level: hclog.Info,
+	})
+	if err := minimal.MigrateStorage(logger, args[0], args[1]); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	p.UI.Output(fmt.Sprintf("Data dir migrated to %s. Start the node with --storage-backend %s", args[1], args[1]))
+	return 0
+}
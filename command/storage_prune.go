@@ -0,0 +1,82 @@
+package command
+
+import (
+	"flag"
+
+	"github.com/0xPolygon/minimal/minimal"
+	"github.com/hashicorp/go-hclog"
+)
+
+// defaultPruneKeep is the default number of recent states kept by the prune
+const defaultPruneKeep = 128
+
+// StoragePrune is the command to prune the old states of a non-archive node
+type StoragePrune struct {
+	Meta
+}
+
+// DefineFlags defines the command flags
+func (p *StoragePrune) DefineFlags() {
+	if p.flagMap == nil {
+		// Flag map not initialized
+		p.flagMap = make(map[string]FlagDescriptor)
+	}
+
+	p.flagMap["keep"] = FlagDescriptor{
+		description: "Number of recent blocks whose state is kept. Default: 128",
+		arguments: []string{
+			"BLOCKS",
+		},
+		argumentsOptional: false,
+	}
+}
+
+// GetHelperText returns a simple description of the command
+func (p *StoragePrune) GetHelperText() string {
+	return "Deletes the states of the old blocks of a stopped non-archive node"
+}
+
+// Help implements the cli.StoragePrune interface
+func (p *StoragePrune) Help() string {
+	p.DefineFlags()
+
+	usage := "storage prune DATA_DIRECTORY [--keep BLOCKS]"
+
+	return p.GenerateHelp(p.Synopsis(), usage)
+}
+
+// Synopsis implements the cli.StoragePrune interface
+func (p *StoragePrune) Synopsis() string {
+	return p.GetHelperText()
+}
+
+// Run implements the cli.StoragePrune interface
+func (p *StoragePrune) Run(args []string) int {
+	flags := flag.NewFlagSet("storage prune", flag.ContinueOnError)
+
+	var keep uint64
+	flags.Uint64Var(&keep, "keep", defaultPruneKeep, "")
+
+	if err := flags.Parse(args); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		p.UI.Error("required argument (data directory) not passed in")
+		return 1
+	}
+
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:  "storage",
+		Level: hclog.Info,
+	})
+	if err := minimal.PruneState(logger, args[0], keep); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	p.UI.Output("Done!")
+	return 0
+}
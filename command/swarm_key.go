@@ -0,0 +1,72 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/0xPolygon/minimal/network"
+)
+
+// SwarmKey is the command to generate the swarm key of a private network
+type SwarmKey struct {
+	Meta
+}
+
+// GetHelperText returns a simple description of the command
+func (p *SwarmKey) GetHelperText() string {
+	return "Generates the pre-shared swarm key of a private network, which all its nodes must share"
+}
+
+// Help implements the cli.SwarmKey interface
+func (p *SwarmKey) Help() string {
+	usage := "swarm-key [FILE]"
+
+	return p.GenerateHelp(p.Synopsis(), usage)
+}
+
+// Synopsis implements the cli.SwarmKey interface
+func (p *SwarmKey) Synopsis() string {
+	return p.GetHelperText()
+}
+
+// Run implements the cli.SwarmKey interface
+func (p *SwarmKey) Run(args []string) int {
+	flags := flag.NewFlagSet("swarm-key", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) > 1 {
+		p.UI.Error("too many arguments")
+		return 1
+	}
+
+	key, err := network.GenerateSwarmKey()
+	if err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	// print the key if no file is passed in
+	if len(args) == 0 {
+		p.UI.Output(string(key))
+		return 0
+	}
+
+	path := args[0]
+	if _, err := os.Stat(path); err == nil {
+		p.UI.Error(fmt.Sprintf("file %s already exists", path))
+		return 1
+	}
+	if err := ioutil.WriteFile(path, key, 0600); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	p.UI.Output(fmt.Sprintf("Swarm key written to %s", path))
+	return 0
+}
@@ -0,0 +1,93 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	txpoolOp "github.com/0xPolygon/minimal/txpool/proto"
+)
+
+// TxPoolAccount is the command to query the transactions of an account in the pool
+type TxPoolAccount struct {
+	Meta
+}
+
+// GetHelperText returns a simple description of the command
+func (p *TxPoolAccount) GetHelperText() string {
+	return "Returns the pending and queued transactions of an account in the pool"
+}
+
+// Help implements the cli.TxPoolAccount interface
+func (p *TxPoolAccount) Help() string {
+	p.Meta.DefineFlags()
+
+	usage := "txpool account ADDRESS"
+
+	return p.GenerateHelp(p.Synopsis(), usage)
+}
+
+// Synopsis implements the cli.TxPoolAccount interface
+func (p *TxPoolAccount) Synopsis() string {
+	return p.GetHelperText()
+}
+
+// Run implements the cli.TxPoolAccount interface
+func (p *TxPoolAccount) Run(args []string) int {
+	flags := p.FlagSet("txpool account")
+	if err := flags.Parse(args); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		p.UI.Error("address argument not provided")
+		return 1
+	}
+
+	conn, err := p.Conn()
+	if err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	clt := txpoolOp.NewTxnPoolOperatorClient(conn)
+	resp, err := clt.GetAccount(context.Background(), &txpoolOp.GetAccountReq{Address: args[0]})
+	if err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	output := "\n[TXPOOL ACCOUNT]\n"
+	output += formatKV([]string{
+		fmt.Sprintf("Next nonce|%d", resp.NextNonce),
+		fmt.Sprintf("Pending txns|%d", len(resp.Pending)),
+		fmt.Sprintf("Queued txns|%d", len(resp.Queued)),
+	})
+	output += "\n"
+
+	output += "\n[PENDING]\n"
+	output += formatTxnInfos(resp.Pending)
+	output += "\n"
+
+	output += "\n[QUEUED]\n"
+	output += formatTxnInfos(resp.Queued)
+	output += "\n"
+
+	p.UI.Output(output)
+
+	return 0
+}
+
+func formatTxnInfos(txns []*txpoolOp.TxnInfo) string {
+	if len(txns) == 0 {
+		return "No transactions found"
+	}
+
+	rows := make([]string, len(txns)+1)
+	rows[0] = "Nonce|Hash|Gas Price"
+	for i, txn := range txns {
+		rows[i+1] = fmt.Sprintf("%d|%s|%s", txn.Nonce, txn.Hash, txn.GasPrice)
+	}
+	return formatList(rows)
+}
@@ -60,6 +60,9 @@ func (p *TxPoolStatus) Run(args []string) int {
 
 	commandOutput := formatKV([]string{
 		fmt.Sprintf("Number of txns in pool:|%d", resp.Length),
+		fmt.Sprintf("Number of queued txns:|%d", resp.Queued),
+		fmt.Sprintf("Number of accounts:|%d", resp.Accounts),
+		fmt.Sprintf("Size of txns in pool:|%d bytes", resp.Bytes),
 	})
 
 	p.UI.Output(commandOutput)
@@ -0,0 +1,85 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	txpoolOp "github.com/0xPolygon/minimal/txpool/proto"
+)
+
+// TxPoolSubscribe is the command to subscribe to the txpool events
+type TxPoolSubscribe struct {
+	Meta
+}
+
+// GetHelperText returns a simple description of the command
+func (p *TxPoolSubscribe) GetHelperText() string {
+	return "Logs the events of the transactions in the pool"
+}
+
+// Help implements the cli.TxPoolSubscribe interface
+func (p *TxPoolSubscribe) Help() string {
+	p.Meta.DefineFlags()
+
+	usage := "txpool subscribe"
+
+	return p.GenerateHelp(p.Synopsis(), usage)
+}
+
+// Synopsis implements the cli.TxPoolSubscribe interface
+func (p *TxPoolSubscribe) Synopsis() string {
+	return p.GetHelperText()
+}
+
+// Run implements the cli.TxPoolSubscribe interface
+func (p *TxPoolSubscribe) Run(args []string) int {
+	flags := p.FlagSet("txpool subscribe")
+	if err := flags.Parse(args); err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	conn, err := p.Conn()
+	if err != nil {
+		p.UI.Error(err.Error())
+		return 1
+	}
+
+	clt := txpoolOp.NewTxnPoolOperatorClient(conn)
+	ctx, cancelFn := context.WithCancel(context.Background())
+
+	stream, err := clt.Subscribe(ctx, &txpoolOp.SubscribeRequest{})
+	if err != nil {
+		p.UI.Error(err.Error())
+		cancelFn()
+		return 1
+	}
+
+	doneCh := make(chan struct{})
+	go func() {
+		for {
+			evnt, err := stream.Recv()
+			if err != nil {
+				p.UI.Error(fmt.Sprintf("failed to read event: %v", err))
+				break
+			}
+			p.UI.Output(fmt.Sprintf("Txn %s: Hash %s From %s", evnt.Type, evnt.TxHash, evnt.From))
+		}
+		doneCh <- struct{}{}
+	}()
+
+	// wait for the user to quit with ctrl-c
+	signalCh := make(chan os.Signal, 4)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+
+	select {
+	case <-signalCh:
+	case <-doneCh:
+	}
+	cancelFn()
+
+	return 0
+}
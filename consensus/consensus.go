@@ -40,6 +40,9 @@ type Config struct {
 
 	// Path is the directory path for the consensus protocol tos tore information
 	Path string
+
+	// BlockGasTarget is the gas limit the sealed blocks move toward
+	BlockGasTarget uint64
 }
 
 // Factory is the factory function to create a discovery backend
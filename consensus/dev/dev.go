@@ -25,6 +25,8 @@ type Dev struct {
 	interval uint64
 	txpool   *txpool.TxPool
 
+	config *consensus.Config
+
 	blockchain *blockchain.Blockchain
 	executor   *state.Executor
 }
@@ -50,6 +52,7 @@ func Factory(
 		blockchain: blockchain,
 		executor:   executor,
 		txpool:     txpool,
+		config:     config,
 	}
 
 	rawInterval, ok := config.Config["interval"]
@@ -61,8 +64,6 @@ func Factory(
 		d.interval = interval
 	}
 
-	// enable dev mode so that we can accept non-signed txns
-	txpool.EnableDev()
 	txpool.NotifyCh = d.notifyCh
 
 	return d, nil
@@ -117,7 +118,7 @@ func (d *Dev) writeNewBlock(parent *types.Header) error {
 	header := &types.Header{
 		ParentHash: parent.Hash,
 		Number:     num + 1,
-		GasLimit:   100000000, // placeholder for now
+		GasLimit:   d.config.Params.BlockGasLimit(parent.GasLimit, d.config.BlockGasTarget),
 		Timestamp:  uint64(time.Now().Unix()),
 	}
 
@@ -126,27 +127,37 @@ func (d *Dev) writeNewBlock(parent *types.Header) error {
 		return err
 	}
 
+	session := d.txpool.NewPopSession()
+	defer session.Close()
+
 	txns := []*types.Transaction{}
 	for {
 		// Add transactions to the list until there are none left
-		txn, retFn := d.txpool.Pop()
+		// or the block is full
+		txn := session.Pop(header.GasLimit - transition.TotalGas())
 
 		if txn == nil {
 			break
 		}
 
-		// Execute the state transition
+		// Execute the state transition, the effects of a txn
+		// that cannot be included are dropped from the block
+		snap := transition.Snapshot()
 		if err := transition.Write(txn); err != nil {
-			retFn()
+			transition.RevertToSnapshot(snap)
+			session.Demote(txn)
 
-			break
+			continue
 		}
 
 		txns = append(txns, txn)
 	}
 
 	// Commit the changes
-	_, root := transition.Commit()
+	_, root, err := transition.Commit()
+	if err != nil {
+		return err
+	}
 
 	// Update the header
 	header.StateRoot = root
@@ -171,8 +182,8 @@ func (d *Dev) writeNewBlock(parent *types.Header) error {
 // REQUIRED BASE INTERFACE METHODS //
 
 func (d *Dev) VerifyHeader(parent *types.Header, header *types.Header) error {
-	// All blocks are valid
-	return nil
+	// All blocks are valid, as long as the fields of the header are
+	return consensus.VerifyHeaderFields(d.config.Params, parent, header)
 }
 
 func (d *Dev) Prepare(header *types.Header) error {
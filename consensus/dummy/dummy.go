@@ -19,6 +19,7 @@ type Dummy struct {
 	notifyCh   chan struct{}
 	closeCh    chan struct{}
 	txpool     *txpool.TxPool
+	config     *consensus.Config
 	blockchain *blockchain.Blockchain
 	executor   *state.Executor
 }
@@ -34,6 +35,7 @@ func Factory(ctx context.Context, sealing bool, config *consensus.Config, txpool
 		blockchain: blockchain,
 		executor:   executor,
 		txpool:     txpool,
+		config:     config,
 	}
 
 	txpool.NotifyCh = d.notifyCh
@@ -47,8 +49,8 @@ func (d *Dummy) Start() error {
 }
 
 func (d *Dummy) VerifyHeader(parent *types.Header, header *types.Header) error {
-	// All blocks are valid
-	return nil
+	// All blocks are valid, as long as the fields of the header are
+	return consensus.VerifyHeaderFields(d.config.Params, parent, header)
 }
 
 func (d *Dummy) Close() error {
@@ -0,0 +1,41 @@
+package consensus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// VerifyHeaderFields checks the fields of the header that all the consensus engines validate
+// the same way: the number, the gas used and the gas limit, the timestamp and the size of
+// the extra data. The engines check their own fields on top of these
+func VerifyHeaderFields(params *chain.Params, parent, header *types.Header) error {
+	return verifyHeaderFields(params, parent, header, time.Now())
+}
+
+func verifyHeaderFields(params *chain.Params, parent, header *types.Header, now time.Time) error {
+	if header.Number != parent.Number+1 {
+		return fmt.Errorf("header number %d is not the next of the parent number %d", header.Number, parent.Number)
+	}
+
+	if header.GasUsed > header.GasLimit {
+		return fmt.Errorf("gas used %d is over the gas limit %d", header.GasUsed, header.GasLimit)
+	}
+	if err := params.VerifyBlockGasLimit(parent.GasLimit, header.GasLimit); err != nil {
+		return err
+	}
+
+	if header.Timestamp < parent.Timestamp {
+		return fmt.Errorf("timestamp %d is before the parent timestamp %d", header.Timestamp, parent.Timestamp)
+	}
+	if max := uint64(now.Unix()) + params.MaxTimestampDrift(); header.Timestamp > max {
+		return fmt.Errorf("timestamp %d is too far in the future, the maximum is %d", header.Timestamp, max)
+	}
+
+	if max := params.MaxExtraDataSize(); max != 0 && uint64(len(header.ExtraData)) > max {
+		return fmt.Errorf("extra data has %d bytes, the maximum is %d", len(header.ExtraData), max)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyHeaderFields(t *testing.T) {
+	now := time.Unix(1000, 0)
+	parent := &types.Header{
+		Number:    10,
+		GasLimit:  1024000,
+		Timestamp: 900,
+	}
+
+	cases := []struct {
+		name   string
+		params *chain.Params
+		header *types.Header
+		err    bool
+	}{
+		{
+			name:   "valid header",
+			params: &chain.Params{},
+			header: &types.Header{Number: 11, GasLimit: 1024000, GasUsed: 1024000, Timestamp: 900},
+		},
+		{
+			name:   "wrong number",
+			params: &chain.Params{},
+			header: &types.Header{Number: 12, GasLimit: 1024000, Timestamp: 901},
+			err:    true,
+		},
+		{
+			name:   "gas used over the gas limit",
+			params: &chain.Params{},
+			header: &types.Header{Number: 11, GasLimit: 1024000, GasUsed: 1024001, Timestamp: 901},
+			err:    true,
+		},
+		{
+			name: "gas limit out of the bounds",
+			params: &chain.Params{
+				GasLimit: &chain.GasLimitParams{BoundDivisor: 1024},
+			},
+			header: &types.Header{Number: 11, GasLimit: 1025000, Timestamp: 901},
+			err:    true,
+		},
+		{
+			name:   "timestamp before the parent",
+			params: &chain.Params{},
+			header: &types.Header{Number: 11, GasLimit: 1024000, Timestamp: 899},
+			err:    true,
+		},
+		{
+			name:   "timestamp within the default drift",
+			params: &chain.Params{},
+			header: &types.Header{Number: 11, GasLimit: 1024000, Timestamp: 1015},
+		},
+		{
+			name:   "timestamp over the default drift",
+			params: &chain.Params{},
+			header: &types.Header{Number: 11, GasLimit: 1024000, Timestamp: 1016},
+			err:    true,
+		},
+		{
+			name: "timestamp within the drift of the chain",
+			params: &chain.Params{
+				Header: &chain.HeaderParams{MaxTimestampDrift: 60},
+			},
+			header: &types.Header{Number: 11, GasLimit: 1024000, Timestamp: 1060},
+		},
+		{
+			name: "extra data within the maximum",
+			params: &chain.Params{
+				Header: &chain.HeaderParams{MaxExtraDataSize: 2},
+			},
+			header: &types.Header{Number: 11, GasLimit: 1024000, Timestamp: 901, ExtraData: []byte{0x1, 0x2}},
+		},
+		{
+			name: "extra data over the maximum",
+			params: &chain.Params{
+				Header: &chain.HeaderParams{MaxExtraDataSize: 2},
+			},
+			header: &types.Header{Number: 11, GasLimit: 1024000, Timestamp: 901, ExtraData: []byte{0x1, 0x2, 0x3}},
+			err:    true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := verifyHeaderFields(c.params, parent, c.header, now)
+			if c.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
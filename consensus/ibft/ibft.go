@@ -28,6 +28,7 @@ type blockchainInterface interface {
 	Header() *types.Header
 	GetHeaderByNumber(i uint64) (*types.Header, bool)
 	WriteBlocks(blocks []*types.Block) error
+	SetPendingBlock(block *types.Block)
 }
 
 // Ibft represents the IBFT consensus mechanism object
@@ -154,6 +155,14 @@ func (i *Ibft) setupTransport() error {
 		return err
 	}
 
+	// The messages with an invalid signature are not relayed, and their peers are reported
+	err = topic.Validate(func(obj interface{}) error {
+		return validateMsg(obj.(*proto.MessageReq))
+	})
+	if err != nil {
+		return err
+	}
+
 	// Subscribe to the newly created topic
 	err = topic.Subscribe(func(obj interface{}) {
 		msg := obj.(*proto.MessageReq)
@@ -352,7 +361,7 @@ func (i *Ibft) buildBlock(snap *Snapshot, parent *types.Header) (*types.Block, e
 		Difficulty: parent.Number + 1,   // we need to do this because blockchain needs difficulty to organize blocks and forks
 		StateRoot:  types.EmptyRootHash, // this avoids needing state for now
 		Sha3Uncles: types.EmptyUncleHash,
-		GasLimit:   100000000, // placeholder for now
+		GasLimit:   i.config.Params.BlockGasLimit(parent.GasLimit, i.config.BlockGasTarget),
 	}
 
 	// try to pick a candidate
@@ -381,20 +390,29 @@ func (i *Ibft) buildBlock(snap *Snapshot, parent *types.Header) (*types.Block, e
 	if err != nil {
 		return nil, err
 	}
+	session := i.txpool.NewPopSession()
+	defer session.Close()
+
 	txns := []*types.Transaction{}
 	for {
-		txn, retFn := i.txpool.Pop()
+		txn := session.Pop(header.GasLimit - transition.TotalGas())
 		if txn == nil {
 			break
 		}
+		// the effects of a txn that cannot be included are dropped from the block
+		snap := transition.Snapshot()
 		if err := transition.Write(txn); err != nil {
-			retFn()
-			break
+			transition.RevertToSnapshot(snap)
+			session.Demote(txn)
+			continue
 		}
 		txns = append(txns, txn)
 	}
 
-	_, root := transition.Commit()
+	_, root, err := transition.Commit()
+	if err != nil {
+		return nil, err
+	}
 	header.StateRoot = root
 	header.GasUsed = transition.TotalGas()
 
@@ -479,6 +497,7 @@ func (i *Ibft) runAcceptState() { // start new round
 				i.setState(RoundChangeState)
 				return
 			}
+			i.blockchain.SetPendingBlock(i.state.block)
 
 			// calculate how much time do we have to wait to mine the block
 			delay := time.Until(time.Unix(int64(i.state.block.Header.Timestamp), 0))
@@ -653,10 +672,8 @@ func (i *Ibft) insertBlock(block *types.Block) error {
 	// broadcast the new block
 	i.syncer.Broadcast(block)
 
-	// after the block has been written we reset the txpool so that
-	// the old transactions are removed
-	i.txpool.ResetWithHeader(block.Header)
-
+	// the txpool prunes the included transactions from the blockchain
+	// events, the same as for the blocks written by the syncer
 	return nil
 }
 
@@ -880,6 +897,10 @@ func (i *Ibft) verifyHeaderImpl(snap *Snapshot, parent, header *types.Header) er
 		return fmt.Errorf("wrong difficulty")
 	}
 
+	if err := consensus.VerifyHeaderFields(i.config.Params, parent, header); err != nil {
+		return err
+	}
+
 	// verify the sealer
 	if err := verifySigner(snap, header); err != nil {
 		return err
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/0xPolygon/minimal/blockchain"
+	"github.com/0xPolygon/minimal/chain"
 	"github.com/0xPolygon/minimal/consensus"
 	"github.com/0xPolygon/minimal/consensus/ibft/proto"
 	"github.com/0xPolygon/minimal/helper/hex"
@@ -467,6 +468,9 @@ func (m *mockIbft) DummyBlock() *types.Block {
 	parent, _ := m.blockchain.GetHeaderByNumber(0)
 	block := &types.Block{
 		Header: &types.Header{
+			ParentHash: parent.Hash,
+			Number:     parent.Number + 1,
+			Difficulty: parent.Number + 1,
 			ExtraData:  parent.ExtraData,
 			MixHash:    IstanbulDigest,
 			Sha3Uncles: types.EmptyUncleHash,
@@ -487,6 +491,10 @@ func (m *mockIbft) WriteBlocks(blocks []*types.Block) error {
 	return nil
 }
 
+func (m *mockIbft) SetPendingBlock(block *types.Block) {
+	m.blockchain.SetPendingBlock(block)
+}
+
 func (m *mockIbft) emitMsg(msg *proto.MessageReq) {
 	// convert the address from the address pool
 	from := m.pool.get(msg.From).Address()
@@ -530,7 +538,7 @@ func newMockIbft(t *testing.T, accounts []string, account string) *mockIbft {
 	}
 	ibft := &Ibft{
 		logger:           hclog.NewNullLogger(),
-		config:           &consensus.Config{},
+		config:           &consensus.Config{Params: &chain.Params{}},
 		blockchain:       m,
 		validatorKey:     addr.priv,
 		validatorKeyAddr: addr.Address(),
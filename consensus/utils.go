@@ -29,6 +29,7 @@ func BuildBlock(params BuildBlockParams) *types.Block {
 	} else {
 		header.ReceiptsRoot = buildroot.CalculateReceiptsRoot(receipts)
 	}
+	header.LogsBloom = types.CreateBloom(receipts)
 
 	// TODO: Compute uncles
 	header.Sha3Uncles = types.EmptyUncleHash
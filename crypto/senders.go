@@ -0,0 +1,130 @@
+package crypto
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/0xPolygon/minimal/types"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// SenderCache is a cache of the senders recovered from the signatures of the
+// transactions, indexed by the hash of the transactions
+type SenderCache struct {
+	cache *lru.Cache
+}
+
+// NewSenderCache creates a sender cache with the given number of entries
+func NewSenderCache(size int) (*SenderCache, error) {
+	cache, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &SenderCache{cache: cache}, nil
+}
+
+// Get returns the sender of the transaction with the given hash
+func (c *SenderCache) Get(hash types.Hash) (types.Address, bool) {
+	from, ok := c.cache.Get(hash)
+	if !ok {
+		return types.Address{}, false
+	}
+	return from.(types.Address), true
+}
+
+// Add adds the sender of the transaction with the given hash
+func (c *SenderCache) Add(hash types.Hash, from types.Address) {
+	c.cache.Add(hash, from)
+}
+
+// RecoverSenders sets the senders of the transactions that do not have one. The
+// signatures are recovered in parallel by a pool of workers, one for each CPU,
+// unless the sender is in the cache. It returns the error of the first transaction
+// whose sender cannot be recovered
+func RecoverSenders(signer TxSigner, txns []*types.Transaction, cache *SenderCache) error {
+	pending := []*types.Transaction{}
+	for _, txn := range txns {
+		if txn.From != (types.Address{}) {
+			continue
+		}
+		if cache != nil {
+			if from, ok := cache.Get(txn.Hash); ok {
+				txn.From = from
+				continue
+			}
+		}
+		pending = append(pending, txn)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	workers := runtime.NumCPU()
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+
+	// the errors are indexed by transaction so that the error returned
+	// does not depend on the order in which the workers run
+	errs := make([]error, len(pending))
+
+	queue := make(chan int, len(pending))
+	for i := range pending {
+		queue <- i
+	}
+	close(queue)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for indx := range queue {
+				txn := pending[indx]
+				from, err := signer.Sender(txn)
+				if err != nil {
+					errs[indx] = err
+					continue
+				}
+				txn.From = from
+				if cache != nil {
+					cache.Add(txn.Hash, from)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cachedSigner is a signer that adds the senders it recovers to a sender cache
+type cachedSigner struct {
+	TxSigner
+
+	cache *SenderCache
+}
+
+// NewCachedSigner wraps a signer so that the senders it recovers are added to the cache,
+// i.e. the pool recovers the senders of the transactions before they are included in a block
+func NewCachedSigner(signer TxSigner, cache *SenderCache) TxSigner {
+	return &cachedSigner{TxSigner: signer, cache: cache}
+}
+
+func (c *cachedSigner) Sender(tx *types.Transaction) (types.Address, error) {
+	if from, ok := c.cache.Get(tx.Hash); ok {
+		return from, nil
+	}
+	from, err := c.TxSigner.Sender(tx)
+	if err != nil {
+		return types.Address{}, err
+	}
+	c.cache.Add(tx.Hash, from)
+	return from, nil
+}
@@ -0,0 +1,80 @@
+package crypto
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverSenders(t *testing.T) {
+	signer := NewEIP155Signer(100)
+
+	keys := map[types.Address]bool{}
+	txns := []*types.Transaction{}
+	for i := 0; i < 20; i++ {
+		key, err := GenerateKey()
+		assert.NoError(t, err)
+
+		txn, err := signer.SignTx(&types.Transaction{
+			Nonce:    uint64(i),
+			Value:    big.NewInt(1),
+			GasPrice: big.NewInt(1),
+		}, key)
+		assert.NoError(t, err)
+		txn.ComputeHash()
+
+		keys[PubKeyToAddress(&key.PublicKey)] = true
+		txns = append(txns, txn)
+	}
+
+	cache, err := NewSenderCache(10)
+	assert.NoError(t, err)
+
+	// the sender of a cached txn is not recovered
+	cached := types.StringToAddress("1")
+	cache.Add(txns[0].Hash, cached)
+
+	assert.NoError(t, RecoverSenders(signer, txns, cache))
+	assert.Equal(t, cached, txns[0].From)
+	for _, txn := range txns[1:] {
+		assert.True(t, keys[txn.From])
+	}
+
+	// the recovered senders are cached
+	from, ok := cache.Get(txns[19].Hash)
+	assert.True(t, ok)
+	assert.Equal(t, txns[19].From, from)
+
+	// the first invalid signature is returned
+	invalid := txns[5].Copy()
+	invalid.From = types.Address{}
+	invalid.R = []byte{}
+	invalid.ComputeHash()
+	assert.Error(t, RecoverSenders(signer, []*types.Transaction{invalid}, nil))
+}
+
+func TestCachedSigner(t *testing.T) {
+	cache, err := NewSenderCache(10)
+	assert.NoError(t, err)
+
+	key, err := GenerateKey()
+	assert.NoError(t, err)
+
+	signer := NewCachedSigner(NewEIP155Signer(100), cache)
+	txn, err := signer.SignTx(&types.Transaction{
+		Value:    big.NewInt(1),
+		GasPrice: big.NewInt(1),
+	}, key)
+	assert.NoError(t, err)
+	txn.ComputeHash()
+
+	from, err := signer.Sender(txn)
+	assert.NoError(t, err)
+	assert.Equal(t, PubKeyToAddress(&key.PublicKey), from)
+
+	cachedFrom, ok := cache.Get(txn.Hash)
+	assert.True(t, ok)
+	assert.Equal(t, from, cachedFrom)
+}
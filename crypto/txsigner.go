@@ -3,6 +3,7 @@ package crypto
 import (
 	"crypto/ecdsa"
 	"fmt"
+	"math/big"
 	"math/bits"
 
 	"github.com/0xPolygon/minimal/chain"
@@ -69,6 +70,22 @@ func calcTxHash(tx *types.Transaction, chainID uint64) types.Hash {
 	return types.BytesToHash(hash)
 }
 
+// calcTypedTxHash calculates the signing hash of a typed transaction
+// (keccak256 hash of the type and the unsigned payload)
+func calcTypedTxHash(tx *types.Transaction) types.Hash {
+	a := signerPool.Get()
+
+	dst := []byte{byte(tx.Type)}
+	dst = tx.MarshalTypedPayloadWith(a, false).MarshalTo(dst)
+
+	hash := keccak.Keccak256(nil, dst)
+	signerPool.Put(a)
+
+	return types.BytesToHash(hash)
+}
+
+var errTypedTxNotSupported = fmt.Errorf("typed transactions are not supported")
+
 // Hash is a wrapper function for the calcTxHash, with chainID 0
 func (f *FrontierSigner) Hash(tx *types.Transaction) types.Hash {
 	return calcTxHash(tx, 0)
@@ -76,6 +93,10 @@ func (f *FrontierSigner) Hash(tx *types.Transaction) types.Hash {
 
 // Sender decodes the signature and returns the sender of the transaction
 func (f *FrontierSigner) Sender(tx *types.Transaction) (types.Address, error) {
+	if tx.IsTyped() {
+		return types.Address{}, errTypedTxNotSupported
+	}
+
 	sig, err := encodeSignature(tx.R, tx.S, tx.V-27)
 	if err != nil {
 		return types.Address{}, err
@@ -96,6 +117,10 @@ func (f *FrontierSigner) SignTx(
 	tx *types.Transaction,
 	privateKey *ecdsa.PrivateKey,
 ) (*types.Transaction, error) {
+	if tx.IsTyped() {
+		return nil, errTypedTxNotSupported
+	}
+
 	tx = tx.Copy()
 
 	h := f.Hash(tx)
@@ -123,11 +148,18 @@ type EIP155Signer struct {
 
 // Hash is a wrapper function that calls calcTxHash with the EIP155Signer's chainID
 func (e *EIP155Signer) Hash(tx *types.Transaction) types.Hash {
+	if tx.IsTyped() {
+		return calcTypedTxHash(tx)
+	}
 	return calcTxHash(tx, e.chainID)
 }
 
 // Sender returns the transaction sender
 func (e *EIP155Signer) Sender(tx *types.Transaction) (types.Address, error) {
+	if tx.IsTyped() {
+		return e.typedSender(tx)
+	}
+
 	protected := true
 
 	if vv := uint(tx.V); bits.Len(vv) <= 8 {
@@ -157,6 +189,28 @@ func (e *EIP155Signer) Sender(tx *types.Transaction) (types.Address, error) {
 	return types.BytesToAddress(buf), nil
 }
 
+// typedSender returns the sender of a typed transaction, the v value
+// of the signature is the y parity and the chain id is in the payload
+func (e *EIP155Signer) typedSender(tx *types.Transaction) (types.Address, error) {
+	if tx.ChainID == nil || !tx.ChainID.IsUint64() || tx.ChainID.Uint64() != e.chainID {
+		return types.Address{}, fmt.Errorf("invalid chain id %v, expected %d", tx.ChainID, e.chainID)
+	}
+
+	sig, err := encodeSignature(tx.R, tx.S, tx.V)
+	if err != nil {
+		return types.Address{}, err
+	}
+
+	pub, err := Ecrecover(e.Hash(tx).Bytes(), sig)
+	if err != nil {
+		return types.Address{}, err
+	}
+
+	buf := Keccak256(pub[1:])[12:]
+
+	return types.BytesToAddress(buf), nil
+}
+
 // SignTx signs the transaction using the passed in private key
 func (e *EIP155Signer) SignTx(
 	tx *types.Transaction,
@@ -164,6 +218,10 @@ func (e *EIP155Signer) SignTx(
 ) (*types.Transaction, error) {
 	tx = tx.Copy()
 
+	if tx.IsTyped() && tx.ChainID == nil {
+		tx.ChainID = new(big.Int).SetUint64(e.chainID)
+	}
+
 	h := e.Hash(tx)
 
 	sig, err := Sign(privateKey, h[:])
@@ -173,7 +231,11 @@ func (e *EIP155Signer) SignTx(
 
 	tx.R = sig[:32]
 	tx.S = sig[32:64]
-	tx.V = byte(sig[64]+35) + (byte(e.chainID) * 2)
+	if tx.IsTyped() {
+		tx.V = sig[64]
+	} else {
+		tx.V = byte(sig[64]+35) + (byte(e.chainID) * 2)
+	}
 
 	return tx, nil
 }
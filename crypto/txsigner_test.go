@@ -52,3 +52,48 @@ func TestEIP1155Signer(t *testing.T) {
 	_, err = signer2.Sender(txn)
 	assert.Error(t, err)
 }
+
+func TestEIP1155Signer_TypedTransaction(t *testing.T) {
+	signer1 := NewEIP155Signer(1)
+
+	addr0 := types.Address{0x1}
+	key, err := GenerateKey()
+	assert.NoError(t, err)
+
+	txn := &types.Transaction{
+		Type:      types.DynamicFeeTx,
+		To:        &addr0,
+		Value:     big.NewInt(10),
+		GasPrice:  big.NewInt(2),
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(2),
+		AccessList: types.AccessList{
+			{Address: addr0, StorageKeys: []types.Hash{{0x1}}},
+		},
+	}
+	txn, err = signer1.SignTx(txn, key)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), txn.ChainID.Uint64())
+	assert.LessOrEqual(t, txn.V, byte(1))
+
+	from, err := signer1.Sender(txn)
+	assert.NoError(t, err)
+	assert.Equal(t, from, PubKeyToAddress(&key.PublicKey))
+
+	// the signature survives the envelope encoding
+	txn2 := new(types.Transaction)
+	assert.NoError(t, txn2.UnmarshalRLP(txn.MarshalRLP()))
+
+	from, err = signer1.Sender(txn2)
+	assert.NoError(t, err)
+	assert.Equal(t, from, PubKeyToAddress(&key.PublicKey))
+
+	// try to use a signer with another chain id
+	signer2 := NewEIP155Signer(2)
+	_, err = signer2.Sender(txn)
+	assert.Error(t, err)
+
+	// the frontier signer does not support typed transactions
+	_, err = (&FrontierSigner{}).Sender(txn)
+	assert.Error(t, err)
+}
@@ -0,0 +1,157 @@
+package gasprice
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// maxFeeHistory is the max number of blocks of a fee history
+const maxFeeHistory = 1024
+
+// FeeHistory are the fees paid in a range of blocks
+type FeeHistory struct {
+	// OldestBlock is the number of the first block of the range
+	OldestBlock uint64
+
+	// BaseFees are the base fees of the blocks, and of the block after the range
+	BaseFees []*big.Int
+
+	// GasUsedRatios are the ratios of the gas used to the gas limit of the blocks
+	GasUsedRatios []float64
+
+	// Rewards are the priority fees paid at the percentiles of the gas used in
+	// each block, nil if no percentiles are requested
+	Rewards [][]*big.Int
+}
+
+// txnReward is the priority fee paid by a txn and the gas it used
+type txnReward struct {
+	reward  *big.Int
+	gasUsed uint64
+}
+
+// baseFee returns the base fee of the header, which is zero
+// since the headers do not have a base fee until eip-1559
+func baseFee(header *types.Header) *big.Int {
+	return new(big.Int)
+}
+
+// FeeHistory returns the fees paid in up to blocks blocks ending at the newest block.
+// The percentiles (0-100) of the rewards must be in ascending order
+func (o *Oracle) FeeHistory(blocks uint64, newest uint64, percentiles []float64) (*FeeHistory, error) {
+	for i, p := range percentiles {
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("invalid reward percentile %f", p)
+		}
+		if i > 0 && p < percentiles[i-1] {
+			return nil, fmt.Errorf("reward percentiles are not in ascending order, %f after %f", p, percentiles[i-1])
+		}
+	}
+
+	header := o.store.Header()
+	if header == nil || newest > header.Number {
+		return nil, fmt.Errorf("block %d is not found", newest)
+	}
+
+	if blocks > maxFeeHistory {
+		blocks = maxFeeHistory
+	}
+	if blocks > newest+1 {
+		blocks = newest + 1
+	}
+
+	history := &FeeHistory{
+		OldestBlock:   newest + 1 - blocks,
+		BaseFees:      []*big.Int{},
+		GasUsedRatios: []float64{},
+	}
+	if blocks == 0 {
+		return history, nil
+	}
+	if len(percentiles) != 0 {
+		history.Rewards = [][]*big.Int{}
+	}
+
+	var last *types.Header
+	for num := history.OldestBlock; num <= newest; num++ {
+		block, ok := o.store.GetBlockByNumber(num, true)
+		if !ok {
+			return nil, fmt.Errorf("block %d is not found", num)
+		}
+		last = block.Header
+
+		history.BaseFees = append(history.BaseFees, baseFee(block.Header))
+
+		ratio := float64(0)
+		if block.Header.GasLimit != 0 {
+			ratio = float64(block.Header.GasUsed) / float64(block.Header.GasLimit)
+		}
+		history.GasUsedRatios = append(history.GasUsedRatios, ratio)
+
+		if len(percentiles) != 0 {
+			rewards, err := o.blockRewards(block, percentiles)
+			if err != nil {
+				return nil, err
+			}
+			history.Rewards = append(history.Rewards, rewards)
+		}
+	}
+
+	// the base fee of the block after the range is known from the last block
+	history.BaseFees = append(history.BaseFees, baseFee(last))
+
+	return history, nil
+}
+
+// blockRewards returns the priority fees paid at the percentiles of the gas used in the block,
+// the txns are sorted by their fee and each one counts as much as the gas it used
+func (o *Oracle) blockRewards(block *types.Block, percentiles []float64) ([]*big.Int, error) {
+	rewards := make([]*big.Int, len(percentiles))
+	if len(block.Transactions) == 0 {
+		for i := range rewards {
+			rewards[i] = new(big.Int)
+		}
+		return rewards, nil
+	}
+
+	receipts, err := o.store.GetReceiptsByHash(block.Header.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(receipts) != len(block.Transactions) {
+		return nil, fmt.Errorf("expected %d receipts for block %d but found %d", len(block.Transactions), block.Number(), len(receipts))
+	}
+
+	base := baseFee(block.Header)
+	txns := make([]*txnReward, len(block.Transactions))
+	cumulative := uint64(0)
+	for i, txn := range block.Transactions {
+		reward := new(big.Int)
+		if txn.GasPrice != nil && txn.GasPrice.Cmp(base) > 0 {
+			reward.Sub(txn.GasPrice, base)
+		}
+		txns[i] = &txnReward{
+			reward:  reward,
+			gasUsed: receipts[i].CumulativeGasUsed - cumulative,
+		}
+		cumulative = receipts[i].CumulativeGasUsed
+	}
+	sort.SliceStable(txns, func(i, j int) bool {
+		return txns[i].reward.Cmp(txns[j].reward) < 0
+	})
+
+	indx := 0
+	sum := txns[0].gasUsed
+	for i, p := range percentiles {
+		threshold := uint64(float64(block.Header.GasUsed) * p / 100)
+		for sum < threshold && indx < len(txns)-1 {
+			indx++
+			sum += txns[indx].gasUsed
+		}
+		rewards[i] = new(big.Int).Set(txns[indx].reward)
+	}
+	return rewards, nil
+}
@@ -0,0 +1,76 @@
+package gasprice
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// addFeeBlock adds a block with a txn per price, each one using its gas
+func (m *mockStore) addFeeBlock(gasLimit uint64, prices []int64, gasUsed []uint64) {
+	m.addBlock(prices...)
+	block := m.blocks[len(m.blocks)-1]
+	block.Header.GasLimit = gasLimit
+
+	if m.receipts == nil {
+		m.receipts = map[types.Hash][]*types.Receipt{}
+	}
+	receipts := []*types.Receipt{}
+	for _, gas := range gasUsed {
+		block.Header.GasUsed += gas
+		receipts = append(receipts, &types.Receipt{CumulativeGasUsed: block.Header.GasUsed})
+	}
+	m.receipts[block.Header.Hash] = receipts
+}
+
+func TestOracle_FeeHistory(t *testing.T) {
+	store := &mockStore{}
+	store.addFeeBlock(100, nil, nil)
+	store.addFeeBlock(100, []int64{30, 10, 20}, []uint64{10, 30, 10})
+	store.addFeeBlock(200, []int64{5}, []uint64{50})
+
+	o := NewOracle(store, nil)
+
+	history, err := o.FeeHistory(2, 2, []float64{0, 50, 70, 100})
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint64(1), history.OldestBlock)
+	assert.Equal(t, []*big.Int{big.NewInt(0), big.NewInt(0), big.NewInt(0)}, history.BaseFees)
+	assert.Equal(t, []float64{0.5, 0.25}, history.GasUsedRatios)
+
+	// the sorted rewards of the block 1 are 10 (30 gas), 20 (10 gas) and 30 (10 gas)
+	assert.Equal(t, [][]*big.Int{
+		{big.NewInt(10), big.NewInt(10), big.NewInt(20), big.NewInt(30)},
+		{big.NewInt(5), big.NewInt(5), big.NewInt(5), big.NewInt(5)},
+	}, history.Rewards)
+
+	// the range is capped by the genesis, and the empty blocks have no rewards
+	history, err = o.FeeHistory(10, 1, []float64{50})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), history.OldestBlock)
+	assert.Len(t, history.GasUsedRatios, 2)
+	assert.Equal(t, []*big.Int{big.NewInt(0)}, history.Rewards[0])
+
+	// the rewards are not returned without percentiles
+	history, err = o.FeeHistory(1, 2, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, history.Rewards)
+}
+
+func TestOracle_FeeHistoryErrors(t *testing.T) {
+	store := &mockStore{}
+	store.addFeeBlock(100, nil, nil)
+
+	o := NewOracle(store, nil)
+
+	_, err := o.FeeHistory(1, 1, nil)
+	assert.Error(t, err)
+
+	_, err = o.FeeHistory(1, 0, []float64{101})
+	assert.Error(t, err)
+
+	_, err = o.FeeHistory(1, 0, []float64{50, 10})
+	assert.Error(t, err)
+}
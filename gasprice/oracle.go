@@ -0,0 +1,196 @@
+package gasprice
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+const (
+	defaultBlocks     = 20
+	defaultPercentile = 60
+	defaultSampleSize = 3
+)
+
+var (
+	defaultPrice    = big.NewInt(1)
+	defaultMaxPrice = big.NewInt(500 * 1e9) // 500 gwei
+)
+
+// Config is the configuration of the gas price oracle
+type Config struct {
+	// Blocks is the number of recent blocks to sample
+	Blocks uint64
+
+	// Percentile of the sampled prices that is suggested
+	Percentile uint64
+
+	// SampleSize is the number of cheapest prices sampled from each block
+	SampleSize uint64
+
+	// Default is the price suggested when there are no samples
+	Default *big.Int
+
+	// MaxPrice is the highest price the oracle suggests
+	MaxPrice *big.Int
+}
+
+// DefaultConfig returns the default configuration of the oracle
+func DefaultConfig() *Config {
+	return &Config{
+		Blocks:     defaultBlocks,
+		Percentile: defaultPercentile,
+		SampleSize: defaultSampleSize,
+		Default:    new(big.Int).Set(defaultPrice),
+		MaxPrice:   new(big.Int).Set(defaultMaxPrice),
+	}
+}
+
+type store interface {
+	// Header returns the current header of the chain
+	Header() *types.Header
+
+	// GetBlockByNumber returns a block using the provided number
+	GetBlockByNumber(num uint64, full bool) (*types.Block, bool)
+
+	// GetPendingGasPrices returns the gas prices of the pending txns in the pool
+	GetPendingGasPrices() []*big.Int
+
+	// GetReceiptsByHash returns the receipts of the block
+	GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error)
+}
+
+// Oracle suggests gas prices based on the prices paid in
+// the recent blocks and the ones in the pool
+type Oracle struct {
+	config *Config
+	store  store
+
+	// price suggested for the last head
+	lock      sync.Mutex
+	lastHead  types.Hash
+	lastPrice *big.Int
+}
+
+// NewOracle creates a new gas price oracle. The fields of the config that are not
+// set take their default values, the config of the caller is not modified
+func NewOracle(store store, config *Config) *Oracle {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	c := *config
+	if c.Blocks == 0 {
+		c.Blocks = defaultBlocks
+	}
+	if c.Percentile == 0 {
+		c.Percentile = defaultPercentile
+	}
+	if c.Percentile > 100 {
+		c.Percentile = 100
+	}
+	if c.SampleSize == 0 {
+		c.SampleSize = defaultSampleSize
+	}
+	if c.Default == nil {
+		c.Default = defaultPrice
+	}
+	if c.MaxPrice == nil {
+		c.MaxPrice = defaultMaxPrice
+	}
+	c.Default = new(big.Int).Set(c.Default)
+	c.MaxPrice = new(big.Int).Set(c.MaxPrice)
+
+	config = &c
+	return &Oracle{
+		config:    config,
+		store:     store,
+		lastPrice: new(big.Int).Set(config.Default),
+	}
+}
+
+// SuggestGasPrice returns a gas price for a new transaction to be included
+// in a timely manner. The value is only recomputed once the head changes
+func (o *Oracle) SuggestGasPrice() *big.Int {
+	header := o.store.Header()
+	if header == nil {
+		return new(big.Int).Set(o.config.Default)
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if header.Hash == o.lastHead {
+		return new(big.Int).Set(o.lastPrice)
+	}
+
+	prices := []*big.Int{}
+
+	// sample the recent blocks, starting from the head
+	num := header.Number
+	for i := uint64(0); i < o.config.Blocks; i++ {
+		if block, ok := o.store.GetBlockByNumber(num, true); ok {
+			prices = append(prices, o.cheapest(BlockGasPrices(block))...)
+		}
+		if num == 0 {
+			break
+		}
+		num--
+	}
+
+	// sample the pending txns in the pool
+	prices = append(prices, o.cheapest(o.store.GetPendingGasPrices())...)
+
+	price := Percentile(prices, o.config.Percentile)
+	if price == nil {
+		price = new(big.Int).Set(o.config.Default)
+	}
+	if price.Cmp(o.config.MaxPrice) > 0 {
+		price = new(big.Int).Set(o.config.MaxPrice)
+	}
+
+	o.lastHead = header.Hash
+	o.lastPrice = price
+
+	return new(big.Int).Set(price)
+}
+
+// cheapest returns the sample size cheapest prices of the list
+func (o *Oracle) cheapest(prices []*big.Int) []*big.Int {
+	sortPrices(prices)
+	if uint64(len(prices)) > o.config.SampleSize {
+		prices = prices[:o.config.SampleSize]
+	}
+	return prices
+}
+
+// BlockGasPrices returns the gas prices of the transactions in the block
+func BlockGasPrices(block *types.Block) []*big.Int {
+	prices := make([]*big.Int, 0, len(block.Transactions))
+	for _, txn := range block.Transactions {
+		if txn.GasPrice == nil {
+			continue
+		}
+		prices = append(prices, new(big.Int).Set(txn.GasPrice))
+	}
+	return prices
+}
+
+// Percentile returns the given percentile (0-100) of the prices
+// or nil if the list is empty. The list is sorted in place
+func Percentile(prices []*big.Int, percentile uint64) *big.Int {
+	if len(prices) == 0 {
+		return nil
+	}
+	sortPrices(prices)
+
+	indx := (uint64(len(prices)) - 1) * percentile / 100
+	return new(big.Int).Set(prices[indx])
+}
+
+func sortPrices(prices []*big.Int) {
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].Cmp(prices[j]) < 0
+	})
+}
@@ -0,0 +1,113 @@
+package gasprice
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockStore struct {
+	blocks   []*types.Block
+	pending  []*big.Int
+	receipts map[types.Hash][]*types.Receipt
+}
+
+func (m *mockStore) Header() *types.Header {
+	return m.blocks[len(m.blocks)-1].Header
+}
+
+func (m *mockStore) GetBlockByNumber(num uint64, full bool) (*types.Block, bool) {
+	if num >= uint64(len(m.blocks)) {
+		return nil, false
+	}
+	return m.blocks[num], true
+}
+
+func (m *mockStore) GetPendingGasPrices() []*big.Int {
+	return m.pending
+}
+
+func (m *mockStore) GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error) {
+	return m.receipts[hash], nil
+}
+
+func (m *mockStore) addBlock(prices ...int64) {
+	block := &types.Block{
+		Header: &types.Header{
+			Number: uint64(len(m.blocks)),
+			Hash:   types.BytesToHash(big.NewInt(int64(len(m.blocks) + 1)).Bytes()),
+		},
+	}
+	for _, price := range prices {
+		block.Transactions = append(block.Transactions, &types.Transaction{
+			GasPrice: big.NewInt(price),
+		})
+	}
+	m.blocks = append(m.blocks, block)
+}
+
+func TestOracle_Default(t *testing.T) {
+	store := &mockStore{}
+	store.addBlock()
+
+	o := NewOracle(store, nil)
+	assert.Equal(t, defaultPrice, o.SuggestGasPrice())
+}
+
+func TestOracle_SuggestGasPrice(t *testing.T) {
+	store := &mockStore{}
+	store.addBlock()
+	store.addBlock(10, 20, 30, 40)
+	store.addBlock(50, 60)
+
+	o := NewOracle(store, &Config{
+		Percentile: 50,
+	})
+
+	// samples are 10, 20, 30, 50, 60
+	assert.Equal(t, big.NewInt(30), o.SuggestGasPrice())
+
+	// the value is cached until there is a new head
+	store.pending = []*big.Int{big.NewInt(100), big.NewInt(100)}
+	assert.Equal(t, big.NewInt(30), o.SuggestGasPrice())
+
+	// samples are 10, 20, 30, 50, 60, 70, 100, 100
+	store.addBlock(70)
+	assert.Equal(t, big.NewInt(50), o.SuggestGasPrice())
+}
+
+func TestOracle_Config(t *testing.T) {
+	store := &mockStore{}
+	store.addBlock(10, 20, 30, 40, 50)
+
+	// the config of the caller is kept and the unset fields are defaulted
+	config := &Config{SampleSize: 5}
+	o := NewOracle(store, config)
+	assert.Equal(t, &Config{SampleSize: 5}, config)
+	assert.Equal(t, uint64(defaultPercentile), o.config.Percentile)
+	assert.Equal(t, uint64(defaultBlocks), o.config.Blocks)
+
+	// samples are 10, 20, 30, 40, 50
+	assert.Equal(t, big.NewInt(30), o.SuggestGasPrice())
+}
+
+func TestOracle_MaxPrice(t *testing.T) {
+	store := &mockStore{}
+	store.addBlock(100)
+
+	o := NewOracle(store, &Config{
+		MaxPrice: big.NewInt(10),
+	})
+	assert.Equal(t, big.NewInt(10), o.SuggestGasPrice())
+}
+
+func TestPercentile(t *testing.T) {
+	prices := []*big.Int{big.NewInt(3), big.NewInt(1), big.NewInt(2)}
+
+	assert.Nil(t, Percentile(nil, 50))
+	assert.Equal(t, big.NewInt(1), Percentile(prices, 0))
+	assert.Equal(t, big.NewInt(2), Percentile(prices, 50))
+	assert.Equal(t, big.NewInt(3), Percentile(prices, 100))
+}
@@ -4,8 +4,11 @@ go 1.14
 
 require (
 	github.com/btcsuite/btcd v0.21.0-beta
+	github.com/cockroachdb/pebble v0.0.0-20220322140420-6e19e39957fb
+	github.com/dgraph-io/badger/v2 v2.2007.4
 	github.com/ethereum/go-ethereum v1.9.15
 	github.com/golang/protobuf v1.4.3
+	github.com/golang/snappy v0.0.4
 	github.com/google/gopacket v1.1.18 // indirect
 	github.com/google/uuid v1.1.4
 	github.com/gorilla/websocket v1.4.2
@@ -17,6 +20,7 @@ require (
 	github.com/hashicorp/hcl v1.0.0
 	github.com/imdario/mergo v0.3.7
 	github.com/libp2p/go-libp2p v0.12.0
+	github.com/libp2p/go-libp2p-circuit v0.4.0
 	github.com/libp2p/go-libp2p-core v0.7.0
 	github.com/libp2p/go-libp2p-kbucket v0.4.7
 	github.com/libp2p/go-libp2p-noise v0.1.1
@@ -25,6 +29,7 @@ require (
 	github.com/mitchellh/cli v1.0.0
 	github.com/multiformats/go-multiaddr v0.3.1
 	github.com/multiformats/go-multiaddr-net v0.2.0
+	github.com/prometheus/client_golang v1.10.0
 	github.com/ryanuber/columnize v2.1.2+incompatible
 	github.com/stretchr/testify v1.7.0
 	github.com/syndtr/goleveldb v1.0.1-0.20190923125748-758128399b1d
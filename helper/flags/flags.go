@@ -27,6 +27,11 @@ func (i *BootnodeFlags) String() string {
 }
 
 func (i *BootnodeFlags) Set(value string) error {
+	// the bootnodes are p2p addresses or the enrtree:// urls of DNS trees
+	if strings.HasPrefix(value, "enrtree://") {
+		*i = append(*i, value)
+		return nil
+	}
 	if _, err := multiaddr.NewMultiaddr(value); err != nil {
 		return err
 	}
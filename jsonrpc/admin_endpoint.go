@@ -0,0 +1,308 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/0xPolygon/minimal/version"
+)
+
+var errNetworkUnavailable = fmt.Errorf("p2p networking is not available")
+
+// networkInterface is the interface with the p2p layer of the node
+type networkInterface interface {
+	// NodeInfo returns the id and the p2p addresses of the node
+	NodeInfo() *NodeInfo
+
+	// Peers returns the connected peers
+	Peers() []*PeerInfo
+
+	// AddPeer adds the peer of the p2p address, i.e. /ip4/1.2.3.4/tcp/1478/p2p/<id>,
+	// to the static peers, which are kept connected
+	AddPeer(addr string) error
+
+	// RemovePeer removes the peer of the id or of the p2p address from the static peers and disconnects it
+	RemovePeer(id string) error
+
+	// AddTrustedPeer adds the peer of the p2p address to the trusted peers, which are accepted over the max peers
+	AddTrustedPeer(addr string) error
+
+	// RemoveTrustedPeer removes the peer of the id or of the p2p address from the trusted peers
+	RemoveTrustedPeer(id string) error
+
+	// BanPeer bans the peer of the id or of the p2p address for the duration, or permanently if 0
+	BanPeer(id string, duration time.Duration, reason string) error
+
+	// UnbanPeer lifts the ban of the peer of the id or of the p2p address
+	UnbanPeer(id string) error
+
+	// BannedPeers returns the banned peers
+	BannedPeers() []*BannedPeerInfo
+}
+
+// NodeInfo is the identity of the node in the p2p network
+type NodeInfo struct {
+	ID string
+
+	// Addrs are the p2p addresses the node listens on, with its id
+	Addrs []string
+
+	// ExternalAddrs are the public p2p addresses of the node discovered through the NAT traversal
+	ExternalAddrs []string
+
+	// Reachability is whether the peers can dial the node, as detected by AutoNAT
+	Reachability string
+}
+
+// PeerInfo is a peer connected to the node
+type PeerInfo struct {
+	ID string
+
+	// Addrs are the p2p addresses of the peer, with its id
+	Addrs []string
+
+	// Name is the client version sent by the peer in the handshake
+	Name string
+
+	// Protocols are the protocols supported by the peer
+	Protocols []string
+
+	// Capabilities are the protocols registered by the peer, as sent in the handshake
+	Capabilities []string
+
+	// Inbound is whether the peer opened the connection
+	Inbound bool
+
+	// Reputation is the score lost by the peer for its recent misbehaviors, 0 if none
+	Reputation float64
+}
+
+// BannedPeerInfo is a peer the node does not connect to
+type BannedPeerInfo struct {
+	ID string
+
+	// Until is the end of the ban, zero if the ban is permanent
+	Until time.Time
+
+	Reason string
+}
+
+// Admin is the admin jsonrpc endpoint, which manages the peers of the node
+type Admin struct {
+	d *Dispatcher
+}
+
+type ethProtocolInfo struct {
+	Network uint64 `json:"network"`
+	Genesis string `json:"genesis"`
+	Head    string `json:"head"`
+}
+
+type nodeInfo struct {
+	ID            string                      `json:"id"`
+	Name          string                      `json:"name"`
+	Enode         string                      `json:"enode"`
+	ListenAddr    string                      `json:"listenAddr"`
+	Addrs         []string                    `json:"addrs"`
+	ExternalAddrs []string                    `json:"externalAddrs"`
+	Reachability  string                      `json:"reachability"`
+	Protocols     map[string]*ethProtocolInfo `json:"protocols"`
+}
+
+type peerNetwork struct {
+	RemoteAddress string `json:"remoteAddress"`
+	Inbound       bool   `json:"inbound"`
+}
+
+type peerInfo struct {
+	ID         string       `json:"id"`
+	Name       string       `json:"name"`
+	Enode      string       `json:"enode"`
+	Caps       []string     `json:"caps"`
+	Network    *peerNetwork `json:"network"`
+	Reputation float64      `json:"reputation"`
+}
+
+type bannedPeer struct {
+	ID string `json:"id"`
+
+	// Until is the unix time of the end of the ban, null if the ban is permanent
+	Until  *argUint64 `json:"until"`
+	Reason string     `json:"reason"`
+}
+
+// firstAddr returns the first address, or an empty string if there are none
+func firstAddr(addrs []string) string {
+	if len(addrs) == 0 {
+		return ""
+	}
+	return addrs[0]
+}
+
+// NodeInfo returns the identity of the node, in the format of the admin_nodeInfo of geth.
+// The enode is the p2p address the peers can dial, the first external one if any
+func (a *Admin) NodeInfo() (interface{}, error) {
+	if a.d.network == nil {
+		return nil, errNetworkUnavailable
+	}
+	info := a.d.network.NodeInfo()
+
+	res := &nodeInfo{
+		ID:            info.ID,
+		Name:          version.ClientVersion(),
+		Enode:         firstAddr(info.Addrs),
+		ListenAddr:    strings.TrimSuffix(firstAddr(info.Addrs), "/p2p/"+info.ID),
+		Addrs:         info.Addrs,
+		ExternalAddrs: info.ExternalAddrs,
+		Reachability:  info.Reachability,
+		Protocols:     map[string]*ethProtocolInfo{},
+	}
+	if res.Addrs == nil {
+		res.Addrs = []string{}
+	}
+	if res.ExternalAddrs == nil {
+		res.ExternalAddrs = []string{}
+	}
+	if len(res.ExternalAddrs) != 0 {
+		res.Enode = res.ExternalAddrs[0]
+	}
+
+	eth := &ethProtocolInfo{
+		Network: a.d.chainID,
+	}
+	if genesis, ok := a.d.store.GetHeaderByNumber(0); ok {
+		eth.Genesis = genesis.Hash.String()
+	}
+	if head := a.d.store.Header(); head != nil {
+		eth.Head = head.Hash.String()
+	}
+	res.Protocols["eth"] = eth
+
+	return res, nil
+}
+
+// Peers returns the connected peers, in the format of the admin_peers of geth
+func (a *Admin) Peers() (interface{}, error) {
+	if a.d.network == nil {
+		return nil, errNetworkUnavailable
+	}
+
+	res := []*peerInfo{}
+	for _, p := range a.d.network.Peers() {
+		// the capabilities of the handshake, or the protocols known by libp2p
+		caps := p.Capabilities
+		if caps == nil {
+			caps = p.Protocols
+		}
+		if caps == nil {
+			caps = []string{}
+		}
+		res = append(res, &peerInfo{
+			ID:    p.ID,
+			Name:  p.Name,
+			Enode: firstAddr(p.Addrs),
+			Caps:  caps,
+			Network: &peerNetwork{
+				RemoteAddress: firstAddr(p.Addrs),
+				Inbound:       p.Inbound,
+			},
+			Reputation: p.Reputation,
+		})
+	}
+	return res, nil
+}
+
+// AddPeer adds the peer of the p2p address to the static peers, which are dialed in the
+// background and redialed once disconnected. The static peers are kept across restarts
+func (a *Admin) AddPeer(addr string) (interface{}, error) {
+	if a.d.network == nil {
+		return nil, errNetworkUnavailable
+	}
+	if err := a.d.network.AddPeer(addr); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+// RemovePeer removes the peer of the id or of the p2p address from the static peers and disconnects it
+func (a *Admin) RemovePeer(id string) (interface{}, error) {
+	if a.d.network == nil {
+		return nil, errNetworkUnavailable
+	}
+	if err := a.d.network.RemovePeer(id); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+// AddTrustedPeer adds the peer of the p2p address to the trusted peers, which are
+// accepted even if the node has no open slot. The trusted peers are kept across restarts
+func (a *Admin) AddTrustedPeer(addr string) (interface{}, error) {
+	if a.d.network == nil {
+		return nil, errNetworkUnavailable
+	}
+	if err := a.d.network.AddTrustedPeer(addr); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+// RemoveTrustedPeer removes the peer of the id or of the p2p address from the trusted peers
+func (a *Admin) RemoveTrustedPeer(id string) (interface{}, error) {
+	if a.d.network == nil {
+		return nil, errNetworkUnavailable
+	}
+	if err := a.d.network.RemoveTrustedPeer(id); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+// BanPeer bans the peer of the id or of the p2p address for the duration in seconds, or
+// permanently if not set, and disconnects it. The permanent bans are kept across restarts
+func (a *Admin) BanPeer(id string, seconds *uint64) (interface{}, error) {
+	if a.d.network == nil {
+		return nil, errNetworkUnavailable
+	}
+	var duration time.Duration
+	if seconds != nil {
+		duration = time.Duration(*seconds) * time.Second
+	}
+	if err := a.d.network.BanPeer(id, duration, "banned through the admin api"); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+// UnbanPeer lifts the ban of the peer of the id or of the p2p address and resets its reputation
+func (a *Admin) UnbanPeer(id string) (interface{}, error) {
+	if a.d.network == nil {
+		return nil, errNetworkUnavailable
+	}
+	if err := a.d.network.UnbanPeer(id); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+// BannedPeers returns the peers banned by the operator or for their bad reputation
+func (a *Admin) BannedPeers() (interface{}, error) {
+	if a.d.network == nil {
+		return nil, errNetworkUnavailable
+	}
+
+	res := []*bannedPeer{}
+	for _, p := range a.d.network.BannedPeers() {
+		b := &bannedPeer{
+			ID:     p.ID,
+			Reason: p.Reason,
+		}
+		if !p.Until.IsZero() {
+			until := argUint64(p.Until.Unix())
+			b.Until = &until
+		}
+		res = append(res, b)
+	}
+	return res, nil
+}
@@ -0,0 +1,176 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockNetwork struct {
+	peers   []*PeerInfo
+	added   []string
+	removed []string
+	trusted []string
+
+	// external are the external addresses of the node
+	external []string
+
+	banned []*BannedPeerInfo
+}
+
+func (m *mockNetwork) NodeInfo() *NodeInfo {
+	info := &NodeInfo{ID: "node", Addrs: []string{"/ip4/127.0.0.1/tcp/1478/p2p/node"}, Reachability: "Unknown"}
+	if len(m.external) != 0 {
+		info.ExternalAddrs = m.external
+		info.Reachability = "Public"
+	}
+	return info
+}
+
+func (m *mockNetwork) Peers() []*PeerInfo {
+	return m.peers
+}
+
+func (m *mockNetwork) AddPeer(addr string) error {
+	if addr == "" {
+		return fmt.Errorf("invalid address")
+	}
+	m.added = append(m.added, addr)
+	return nil
+}
+
+func (m *mockNetwork) RemovePeer(id string) error {
+	m.removed = append(m.removed, id)
+	return nil
+}
+
+func (m *mockNetwork) AddTrustedPeer(addr string) error {
+	m.trusted = append(m.trusted, addr)
+	return nil
+}
+
+func (m *mockNetwork) BanPeer(id string, duration time.Duration, reason string) error {
+	b := &BannedPeerInfo{ID: id, Reason: reason}
+	if duration != 0 {
+		b.Until = time.Unix(1000, 0).Add(duration)
+	}
+	m.banned = append(m.banned, b)
+	return nil
+}
+
+func (m *mockNetwork) UnbanPeer(id string) error {
+	for i, b := range m.banned {
+		if b.ID == id {
+			m.banned = append(m.banned[:i], m.banned[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("peer not banned")
+}
+
+func (m *mockNetwork) BannedPeers() []*BannedPeerInfo {
+	return m.banned
+}
+
+func (m *mockNetwork) RemoveTrustedPeer(id string) error {
+	for i, addr := range m.trusted {
+		if addr == id {
+			m.trusted = append(m.trusted[:i], m.trusted[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("peer not trusted")
+}
+
+func TestAdmin(t *testing.T) {
+	network := &mockNetwork{
+		peers: []*PeerInfo{
+			{ID: "peer", Addrs: []string{"/ip4/1.2.3.4/tcp/1478/p2p/peer"}, Protocols: []string{"/id/0.1"}, Inbound: true, Reputation: -10},
+			{ID: "peer1", Name: "polygon-sdk [0.1.0]", Addrs: []string{"/ip4/1.2.3.5/tcp/1478/p2p/peer1"}, Protocols: []string{"/id/0.1"}, Capabilities: []string{"/id/0.1", "/syncer/0.1"}},
+		},
+	}
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), newMockStore())
+	dispatcher.chainID = 100
+	dispatcher.network = network
+
+	handle := func(method string, params string) string {
+		resp, err := dispatcher.Handle([]byte(`{"id": 1, "method": "` + method + `", "params": ` + params + `}`))
+		assert.NoError(t, err)
+
+		var result json.RawMessage
+		assert.NoError(t, expectJSONResult(resp, &result))
+		return string(result)
+	}
+
+	var info nodeInfo
+	assert.NoError(t, json.Unmarshal([]byte(handle("admin_nodeInfo", "[]")), &info))
+	assert.Equal(t, "node", info.ID)
+	assert.Equal(t, "/ip4/127.0.0.1/tcp/1478/p2p/node", info.Enode)
+	assert.Equal(t, "/ip4/127.0.0.1/tcp/1478", info.ListenAddr)
+	assert.Empty(t, info.ExternalAddrs)
+	assert.Equal(t, uint64(100), info.Protocols["eth"].Network)
+
+	// the enode is the external address once discovered
+	network.external = []string{"/ip4/1.2.3.4/tcp/1478/p2p/node"}
+	assert.NoError(t, json.Unmarshal([]byte(handle("admin_nodeInfo", "[]")), &info))
+	assert.Equal(t, "/ip4/1.2.3.4/tcp/1478/p2p/node", info.Enode)
+	assert.Equal(t, "/ip4/127.0.0.1/tcp/1478", info.ListenAddr)
+	assert.Equal(t, "Public", info.Reachability)
+
+	assert.JSONEq(t, `[{
+		"id": "peer",
+		"name": "",
+		"enode": "/ip4/1.2.3.4/tcp/1478/p2p/peer",
+		"caps": ["/id/0.1"],
+		"network": {"remoteAddress": "/ip4/1.2.3.4/tcp/1478/p2p/peer", "inbound": true},
+		"reputation": -10
+	}, {
+		"id": "peer1",
+		"name": "polygon-sdk [0.1.0]",
+		"enode": "/ip4/1.2.3.5/tcp/1478/p2p/peer1",
+		"caps": ["/id/0.1", "/syncer/0.1"],
+		"network": {"remoteAddress": "/ip4/1.2.3.5/tcp/1478/p2p/peer1", "inbound": false},
+		"reputation": 0
+	}]`, handle("admin_peers", "[]"))
+
+	assert.Equal(t, "true", handle("admin_addPeer", `["/ip4/1.2.3.4/tcp/1478/p2p/peer2"]`))
+	assert.Equal(t, []string{"/ip4/1.2.3.4/tcp/1478/p2p/peer2"}, network.added)
+
+	_, err := dispatcher.Handle([]byte(`{"id": 1, "method": "admin_addPeer", "params": [""]}`))
+	assert.Error(t, err)
+
+	assert.Equal(t, "true", handle("admin_removePeer", `["peer"]`))
+	assert.Equal(t, []string{"peer"}, network.removed)
+
+	assert.Equal(t, "true", handle("admin_addTrustedPeer", `["/ip4/1.2.3.4/tcp/1478/p2p/peer3"]`))
+	assert.Equal(t, []string{"/ip4/1.2.3.4/tcp/1478/p2p/peer3"}, network.trusted)
+	assert.Equal(t, "true", handle("admin_removeTrustedPeer", `["/ip4/1.2.3.4/tcp/1478/p2p/peer3"]`))
+	assert.Empty(t, network.trusted)
+
+	// the bans are permanent unless they have a duration
+	assert.Equal(t, "true", handle("admin_banPeer", `["peer4"]`))
+	assert.Equal(t, "true", handle("admin_banPeer", `["peer5", 60]`))
+	assert.JSONEq(t, `[
+		{"id": "peer4", "until": null, "reason": "banned through the admin api"},
+		{"id": "peer5", "until": "0x424", "reason": "banned through the admin api"}
+	]`, handle("admin_bannedPeers", "[]"))
+	assert.Equal(t, "true", handle("admin_unbanPeer", `["peer4"]`))
+	assert.Len(t, network.banned, 1)
+
+	// the net namespace reports the peers
+	assert.Equal(t, "true", handle("net_listening", "[]"))
+	assert.Equal(t, `"0x2"`, handle("net_peerCount", "[]"))
+
+	// without networking the node has no peers and cannot manage them
+	dispatcher.network = nil
+
+	_, err = dispatcher.Handle([]byte(`{"id": 1, "method": "admin_peers", "params": []}`))
+	assert.Error(t, err)
+	assert.Equal(t, "false", handle("net_listening", "[]"))
+	assert.Equal(t, `"0x0"`, handle("net_peerCount", "[]"))
+}
@@ -1,10 +1,15 @@
 package jsonrpc
 
 import (
+	"fmt"
 	"math/big"
 
 	"github.com/0xPolygon/minimal/blockchain"
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/gasprice"
 	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/txpool"
 	"github.com/0xPolygon/minimal/types"
 )
 
@@ -14,6 +19,22 @@ type stateHelperInterface interface {
 	GetAccount(root types.Hash, addr types.Address) (*state.Account, error)
 	GetStorage(root types.Hash, addr types.Address, slot types.Hash) ([]byte, error)
 	GetCode(hash types.Hash) ([]byte, error)
+
+	// GetProof returns the merkle proof of the account and of its storage slots at the root
+	GetProof(root types.Hash, addr types.Address, slots []types.Hash) (*state.AccountProof, error)
+
+	// DumpState returns up to max accounts of the state at the root, from the start hashed address
+	DumpState(root types.Hash, start types.Hash, max uint64) (*state.Dump, error)
+
+	// StorageRange returns up to max storage slots of the account in the state at the root, from the start hashed slot
+	StorageRange(root types.Hash, addr types.Address, start types.Hash, max uint64) (*state.StorageRange, error)
+
+	// AccountStats returns the size of the code and the number of storage slots of the account in the state at the root
+	AccountStats(root types.Hash, addr types.Address) (*state.AccountStats, error)
+
+	// HasState returns whether the state at the root is stored,
+	// the old states are pruned unless the node runs in archive mode
+	HasState(root types.Hash) bool
 }
 
 // blockchain is the interface with the blockchain required
@@ -25,8 +46,14 @@ type blockchainInterface interface {
 	// GetReceiptsByHash returns the receipts for a block hash
 	GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error)
 
-	// ReadTxLookup returns a block hash in which a given txn was mined
-	ReadTxLookup(txnHash types.Hash) (types.Hash, bool)
+	// ReadTxLookup returns the block and the index in which a given txn was mined
+	ReadTxLookup(txnHash types.Hash) (*storage.TxLookup, bool)
+
+	// BloomSections returns the number of sections of blocks indexed in the bloom bits
+	BloomSections() uint64
+
+	// ReadBloomBits returns the bit vector of a bloom bit for an indexed section
+	ReadBloomBits(bit uint, section uint64) ([]byte, bool)
 
 	// SubscribeEvents subscribes for chain head events
 	SubscribeEvents() blockchain.Subscription
@@ -34,8 +61,17 @@ type blockchainInterface interface {
 	// GetHeaderByNumber returns the header by number
 	GetHeaderByNumber(block uint64) (*types.Header, bool)
 
-	// GetAvgGasPrice returns the average gas price
-	GetAvgGasPrice() *big.Int
+	// ResolveBlockTag returns the header of the block referred to by the tag
+	ResolveBlockTag(tag blockchain.BlockTag) (*types.Header, error)
+
+	// PendingBlock returns the block being built on top of the head, if any
+	PendingBlock() (*types.Block, bool)
+
+	// SuggestGasPrice returns the gas price suggested by the oracle
+	SuggestGasPrice() *big.Int
+
+	// FeeHistory returns the fees paid in up to blocks blocks ending at the newest block
+	FeeHistory(blocks uint64, newest uint64, percentiles []float64) (*gasprice.FeeHistory, error)
 
 	// AddTx adds a new transaction to the tx pool
 	AddTx(tx *types.Transaction) error
@@ -46,12 +82,32 @@ type blockchainInterface interface {
 	// GetBlockByNumber returns a block using the provided number
 	GetBlockByNumber(num uint64, full bool) (*types.Block, bool)
 
-	// ApplyTxn applies a transaction object to the blockchain
-	ApplyTxn(header *types.Header, txn *types.Transaction) ([]byte, bool, error)
+	// ApplyTxn applies a transaction object to the blockchain, on top
+	// of the state of the header with the overrides, if any
+	ApplyTxn(header *types.Header, txn *types.Transaction, override state.StateOverride) (*runtime.ExecutionResult, error)
+
+	// ApplyTxns applies the transactions in order on top of the state of the header,
+	// each one on top of the changes of the previous ones
+	ApplyTxns(header *types.Header, txns []*types.Transaction) ([]*runtime.ExecutionResult, error)
+
+	// TraceBlockTxn replays the txn at the index of the block with the tracer
+	TraceBlockTxn(block *types.Block, index int, tracer runtime.Tracer) ([]byte, uint64, bool, error)
+
+	// TraceBlock replays the txns of the block on top of the state of its parent, each one with its tracer
+	TraceBlock(block *types.Block, tracers []runtime.Tracer) ([]*state.TraceResult, error)
+
+	// Witness returns the trie nodes and the codes of the state of the parent of the block read by its execution
+	Witness(block *types.Block) (*state.Witness, error)
+
+	// TraceCall executes the txn on top of the state of the header with the tracer
+	TraceCall(header *types.Header, txn *types.Transaction, tracer runtime.Tracer) ([]byte, uint64, bool, error)
 
 	// GetNonce returns the next nonce for this address
 	GetNonce(addr types.Address) (uint64, bool)
 
+	// SubscribeTxnEvents subscribes for events in the tx pool
+	SubscribeTxnEvents(eventTypes ...txpool.EventType) *txpool.Subscription
+
 	stateHelperInterface
 }
 
@@ -66,26 +122,50 @@ func (b *nullBlockchainInterface) Header() *types.Header {
 	return nil
 }
 
-func (b *nullBlockchainInterface) ReadTxLookup(txnHash types.Hash) (types.Hash, bool) {
-	return types.Hash{}, false
+func (b *nullBlockchainInterface) ReadTxLookup(txnHash types.Hash) (*storage.TxLookup, bool) {
+	return nil, false
 }
 
 func (b *nullBlockchainInterface) GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error) {
 	return nil, nil
 }
 
+func (b *nullBlockchainInterface) BloomSections() uint64 {
+	return 0
+}
+
+func (b *nullBlockchainInterface) ReadBloomBits(bit uint, section uint64) ([]byte, bool) {
+	return nil, false
+}
+
 func (b *nullBlockchainInterface) SubscribeEvents() blockchain.Subscription {
 	return nil
 }
 
+func (b *nullBlockchainInterface) SubscribeTxnEvents(eventTypes ...txpool.EventType) *txpool.Subscription {
+	return nil
+}
+
 func (b *nullBlockchainInterface) GetHeaderByNumber(block uint64) (*types.Header, bool) {
 	return nil, false
 }
 
-func (b *nullBlockchainInterface) GetAvgGasPrice() *big.Int {
+func (b *nullBlockchainInterface) ResolveBlockTag(tag blockchain.BlockTag) (*types.Header, error) {
+	return nil, fmt.Errorf("block tag '%s' not found", tag)
+}
+
+func (b *nullBlockchainInterface) PendingBlock() (*types.Block, bool) {
+	return nil, false
+}
+
+func (b *nullBlockchainInterface) SuggestGasPrice() *big.Int {
 	return nil
 }
 
+func (b *nullBlockchainInterface) FeeHistory(blocks uint64, newest uint64, percentiles []float64) (*gasprice.FeeHistory, error) {
+	return nil, nil
+}
+
 func (b *nullBlockchainInterface) AddTx(tx *types.Transaction) error {
 	return nil
 }
@@ -98,6 +178,22 @@ func (b *nullBlockchainInterface) BeginTxn(parentRoot types.Hash, header *types.
 	return nil, nil
 }
 
+func (b *nullBlockchainInterface) TraceBlockTxn(block *types.Block, index int, tracer runtime.Tracer) ([]byte, uint64, bool, error) {
+	return nil, 0, false, nil
+}
+
+func (b *nullBlockchainInterface) TraceBlock(block *types.Block, tracers []runtime.Tracer) ([]*state.TraceResult, error) {
+	return nil, nil
+}
+
+func (b *nullBlockchainInterface) Witness(block *types.Block) (*state.Witness, error) {
+	return nil, nil
+}
+
+func (b *nullBlockchainInterface) TraceCall(header *types.Header, txn *types.Transaction, tracer runtime.Tracer) ([]byte, uint64, bool, error) {
+	return nil, 0, false, nil
+}
+
 func (b *nullBlockchainInterface) GetBlockByHash(hash types.Hash, full bool) (*types.Block, bool) {
 	return nil, false
 }
@@ -106,8 +202,12 @@ func (b *nullBlockchainInterface) GetBlockByNumber(num uint64, full bool) (*type
 	return nil, false
 }
 
-func (b *nullBlockchainInterface) ApplyTxn(header *types.Header, txn *types.Transaction) ([]byte, bool, error) {
-	return nil, false, nil
+func (b *nullBlockchainInterface) ApplyTxns(header *types.Header, txns []*types.Transaction) ([]*runtime.ExecutionResult, error) {
+	return nil, nil
+}
+
+func (b *nullBlockchainInterface) ApplyTxn(header *types.Header, txn *types.Transaction, override state.StateOverride) (*runtime.ExecutionResult, error) {
+	return nil, nil
 }
 
 func (b *nullBlockchainInterface) GetCode(hash types.Hash) ([]byte, error) {
@@ -118,6 +218,26 @@ func (b *nullBlockchainInterface) GetStorage(root types.Hash, addr types.Address
 	return nil, nil
 }
 
+func (b *nullBlockchainInterface) GetProof(root types.Hash, addr types.Address, slots []types.Hash) (*state.AccountProof, error) {
+	return nil, nil
+}
+
+func (b *nullBlockchainInterface) StorageRange(root types.Hash, addr types.Address, start types.Hash, max uint64) (*state.StorageRange, error) {
+	return nil, nil
+}
+
+func (b *nullBlockchainInterface) AccountStats(root types.Hash, addr types.Address) (*state.AccountStats, error) {
+	return nil, nil
+}
+
+func (b *nullBlockchainInterface) DumpState(root types.Hash, start types.Hash, max uint64) (*state.Dump, error) {
+	return nil, nil
+}
+
+func (b *nullBlockchainInterface) HasState(root types.Hash) bool {
+	return true
+}
+
 func (b *nullBlockchainInterface) GetAccount(root types.Hash, addr types.Address) (*state.Account, error) {
 	return nil, nil
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/0xPolygon/minimal/blockchain"
 	"github.com/0xPolygon/minimal/types"
 )
 
@@ -23,6 +24,13 @@ type Response struct {
 	Error   *ErrorObject    `json:"error,omitempty"`
 }
 
+// ErrorResponse is a jsonrpc response of a failed request, which has no result
+type ErrorResponse struct {
+	ID      interface{}  `json:"id"`
+	JSONRPC string       `json:"jsonrpc"`
+	Error   *ErrorObject `json:"error"`
+}
+
 // ErrorObject is a jsonrpc error
 type ErrorObject struct {
 	Code    int         `json:"code"`
@@ -40,13 +48,30 @@ func (e *ErrorObject) Error() string {
 }
 
 const (
-	PendingBlockNumber  = BlockNumber(-3)
-	LatestBlockNumber   = BlockNumber(-2)
-	EarliestBlockNumber = BlockNumber(-1)
+	FinalizedBlockNumber = BlockNumber(-5)
+	SafeBlockNumber      = BlockNumber(-4)
+	PendingBlockNumber   = BlockNumber(-3)
+	LatestBlockNumber    = BlockNumber(-2)
+	EarliestBlockNumber  = BlockNumber(-1)
 )
 
 type BlockNumber int64
 
+// blockTags are the tags of the block numbers resolved by the blockchain
+var blockTags = map[BlockNumber]blockchain.BlockTag{
+	FinalizedBlockNumber: blockchain.BlockTagFinalized,
+	SafeBlockNumber:      blockchain.BlockTagSafe,
+	PendingBlockNumber:   blockchain.BlockTagPending,
+	LatestBlockNumber:    blockchain.BlockTagLatest,
+	EarliestBlockNumber:  blockchain.BlockTagEarliest,
+}
+
+// tag returns the block tag of the block number, if it is not a number
+func (b BlockNumber) tag() (blockchain.BlockTag, bool) {
+	tag, ok := blockTags[b]
+	return tag, ok
+}
+
 func stringToBlockNumber(str string) (BlockNumber, error) {
 	if str == "" {
 		return 0, fmt.Errorf("value is empty")
@@ -60,6 +85,10 @@ func stringToBlockNumber(str string) (BlockNumber, error) {
 		return LatestBlockNumber, nil
 	case "earliest":
 		return EarliestBlockNumber, nil
+	case "safe":
+		return SafeBlockNumber, nil
+	case "finalized":
+		return FinalizedBlockNumber, nil
 	}
 
 	n, err := types.ParseUint64orHex(&str)
@@ -78,3 +107,44 @@ func (b *BlockNumber) UnmarshalJSON(buffer []byte) error {
 	*b = num
 	return nil
 }
+
+// BlockNumberOrHash refers to a block by its number or tag, or by its hash (eip-1898)
+type BlockNumberOrHash struct {
+	BlockNumber *BlockNumber `json:"blockNumber,omitempty"`
+	BlockHash   *types.Hash  `json:"blockHash,omitempty"`
+}
+
+// UnmarshalJSON decodes a block number, a tag or a block hash, either
+// as a string or as an object with a blockNumber or a blockHash field
+func (b *BlockNumberOrHash) UnmarshalJSON(buffer []byte) error {
+	type blockNumberOrHash BlockNumberOrHash
+
+	var obj blockNumberOrHash
+	if err := json.Unmarshal(buffer, &obj); err == nil {
+		if (obj.BlockNumber == nil) == (obj.BlockHash == nil) {
+			return fmt.Errorf("either the block number or the block hash must be set")
+		}
+		*b = BlockNumberOrHash(obj)
+		return nil
+	}
+
+	var str string
+	if err := json.Unmarshal(buffer, &str); err != nil {
+		return err
+	}
+	// the hashes are the only 32 bytes values
+	if len(str) == 2+2*types.HashLength {
+		var hash types.Hash
+		if err := hash.UnmarshalText([]byte(str)); err != nil {
+			return err
+		}
+		*b = BlockNumberOrHash{BlockHash: &hash}
+		return nil
+	}
+	num, err := stringToBlockNumber(str)
+	if err != nil {
+		return err
+	}
+	*b = BlockNumberOrHash{BlockNumber: &num}
+	return nil
+}
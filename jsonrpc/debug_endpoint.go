@@ -0,0 +1,558 @@
+package jsonrpc
+
+import (
+	"fmt"
+
+	"github.com/0xPolygon/minimal/helper/hex"
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// Debug is the debug jsonrpc endpoint
+type Debug struct {
+	d *Dispatcher
+}
+
+// names of the built-in tracers
+const (
+	callTracerName     = "callTracer"
+	prestateTracerName = "prestateTracer"
+)
+
+// TraceConfig is the configuration of a trace
+type TraceConfig struct {
+	DisableStack   bool `json:"disableStack"`
+	DisableStorage bool `json:"disableStorage"`
+	EnableMemory   bool `json:"enableMemory"`
+
+	// Tracer is the name of the built-in tracer, the struct logs are traced if empty
+	Tracer       string        `json:"tracer"`
+	TracerConfig *TracerConfig `json:"tracerConfig"`
+}
+
+// TracerConfig is the configuration of the built-in tracer
+type TracerConfig struct {
+	// OnlyTopCall only traces the first call with the call tracer
+	OnlyTopCall bool `json:"onlyTopCall"`
+}
+
+// trace runs the txn with the tracer of the config and returns its result
+func trace(config *TraceConfig, gas uint64, run func(tracer runtime.Tracer) ([]byte, uint64, bool, error)) (interface{}, error) {
+	tracer, err := newTracer(config)
+	if err != nil {
+		return nil, err
+	}
+	returnValue, gasUsed, failed, err := run(tracer)
+	if err != nil {
+		return nil, err
+	}
+	return traceResult(tracer, gas, returnValue, gasUsed, failed), nil
+}
+
+// newTracer returns the tracer of the config, the struct logger if it is not set
+func newTracer(config *TraceConfig) (runtime.Tracer, error) {
+	name := ""
+	if config != nil {
+		name = config.Tracer
+	}
+
+	switch name {
+	case "":
+		return newStructLogger(config), nil
+
+	case callTracerName:
+		onlyTopCall := config.TracerConfig != nil && config.TracerConfig.OnlyTopCall
+		return runtime.NewCallTracer(onlyTopCall), nil
+
+	case prestateTracerName:
+		return runtime.NewPrestateTracer(), nil
+
+	default:
+		return nil, fmt.Errorf("tracer '%s' not found", name)
+	}
+}
+
+// traceResult returns the result of the tracer once the txn is traced. The
+// gas of the txn is set in the first call traced by the call tracer
+func traceResult(tracer runtime.Tracer, gas uint64, returnValue []byte, gasUsed uint64, failed bool) interface{} {
+	switch tracer := tracer.(type) {
+	case *runtime.CallTracer:
+		frame := toCallFrame(tracer.Result())
+		if frame != nil {
+			frame.Gas = argUint64(gas)
+			frame.GasUsed = argUint64(gasUsed)
+		}
+		return frame
+
+	case *runtime.PrestateTracer:
+		return toPrestate(tracer.Result())
+
+	case *runtime.StructLogger:
+		return toExecutionTrace(returnValue, gasUsed, failed, tracer)
+
+	default:
+		return nil
+	}
+}
+
+func newStructLogger(config *TraceConfig) *runtime.StructLogger {
+	if config == nil {
+		return runtime.NewStructLogger(nil)
+	}
+	return runtime.NewStructLogger(&runtime.StructLogConfig{
+		DisableStack:   config.DisableStack,
+		DisableStorage: config.DisableStorage,
+		EnableMemory:   config.EnableMemory,
+	})
+}
+
+type structLog struct {
+	Pc      uint64            `json:"pc"`
+	Op      string            `json:"op"`
+	Gas     uint64            `json:"gas"`
+	GasCost uint64            `json:"gasCost"`
+	Depth   int               `json:"depth"`
+	Error   string            `json:"error,omitempty"`
+	Stack   []string          `json:"stack,omitempty"`
+	Memory  []string          `json:"memory,omitempty"`
+	Storage map[string]string `json:"storage,omitempty"`
+}
+
+type executionTrace struct {
+	Gas         uint64       `json:"gas"`
+	Failed      bool         `json:"failed"`
+	ReturnValue argBytes     `json:"returnValue"`
+	StructLogs  []*structLog `json:"structLogs"`
+}
+
+func toExecutionTrace(returnValue []byte, gas uint64, failed bool, logger *runtime.StructLogger) *executionTrace {
+	res := &executionTrace{
+		Gas:         gas,
+		Failed:      failed,
+		ReturnValue: argBytes(returnValue),
+		StructLogs:  []*structLog{},
+	}
+	for _, l := range logger.Logs() {
+		log := &structLog{
+			Pc:      l.PC,
+			Op:      l.OpName,
+			Gas:     l.Gas,
+			GasCost: l.Cost,
+			Depth:   l.Depth,
+		}
+		if l.Err != nil {
+			log.Error = l.Err.Error()
+		}
+		for _, v := range l.Stack {
+			log.Stack = append(log.Stack, hex.EncodeBig(v))
+		}
+		// the memory is split in words of 32 bytes
+		for i := 0; i+32 <= len(l.Memory); i += 32 {
+			log.Memory = append(log.Memory, hex.EncodeToString(l.Memory[i:i+32]))
+		}
+		if l.Storage != nil {
+			log.Storage = map[string]string{}
+			for k, v := range l.Storage {
+				log.Storage[hex.EncodeToString(k.Bytes())] = hex.EncodeToString(v.Bytes())
+			}
+		}
+		res.StructLogs = append(res.StructLogs, log)
+	}
+	return res
+}
+
+type callFrame struct {
+	Type    string        `json:"type"`
+	From    types.Address `json:"from"`
+	To      types.Address `json:"to"`
+	Value   *argBig       `json:"value,omitempty"`
+	Gas     argUint64     `json:"gas"`
+	GasUsed argUint64     `json:"gasUsed"`
+	Input   argBytes      `json:"input"`
+	Output  argBytes      `json:"output,omitempty"`
+	Error   string        `json:"error,omitempty"`
+	Calls   []*callFrame  `json:"calls,omitempty"`
+}
+
+func toCallFrame(f *runtime.CallFrame) *callFrame {
+	if f == nil {
+		return nil
+	}
+	res := &callFrame{
+		Type:    f.Type.String(),
+		From:    f.From,
+		To:      f.To,
+		Gas:     argUint64(f.Gas),
+		GasUsed: argUint64(f.GasUsed),
+		Input:   argBytes(f.Input),
+		Output:  argBytes(f.Output),
+	}
+	if f.Value != nil {
+		res.Value = argBigPtr(f.Value)
+	}
+	if f.Err != nil {
+		res.Error = f.Err.Error()
+	}
+	for _, call := range f.Calls {
+		res.Calls = append(res.Calls, toCallFrame(call))
+	}
+	return res
+}
+
+type prestateAccount struct {
+	Balance *argBig                   `json:"balance"`
+	Nonce   uint64                    `json:"nonce,omitempty"`
+	Code    argBytes                  `json:"code,omitempty"`
+	Storage map[types.Hash]types.Hash `json:"storage,omitempty"`
+}
+
+func toPrestate(accounts map[types.Address]*runtime.PrestateAccount) map[types.Address]*prestateAccount {
+	res := map[types.Address]*prestateAccount{}
+	for addr, account := range accounts {
+		res[addr] = &prestateAccount{
+			Balance: argBigPtr(account.Balance),
+			Nonce:   account.Nonce,
+			Code:    argBytes(account.Code),
+			Storage: account.Storage,
+		}
+	}
+	return res
+}
+
+// TraceTransaction replays a mined transaction on top of the state of its
+// block and returns the opcodes executed, or the result of the tracer
+func (d *Debug) TraceTransaction(hash types.Hash, config *TraceConfig) (interface{}, error) {
+	block, indx, ok := d.d.getTxnBlock(hash)
+	if !ok {
+		return nil, fmt.Errorf("txn %s not found", hash)
+	}
+	if block.Number() == 0 {
+		return nil, fmt.Errorf("genesis txns cannot be traced")
+	}
+
+	parent, ok := d.d.store.GetHeaderByNumber(block.Number() - 1)
+	if !ok {
+		return nil, fmt.Errorf("parent of block %d not found", block.Number())
+	}
+	if !d.d.store.HasState(parent.StateRoot) {
+		return nil, stateNotAvailable(parent.Number)
+	}
+
+	return trace(config, block.Transactions[indx].Gas, func(tracer runtime.Tracer) ([]byte, uint64, bool, error) {
+		return d.d.store.TraceBlockTxn(block, indx, tracer)
+	})
+}
+
+type txTrace struct {
+	TxHash types.Hash  `json:"txHash"`
+	Result interface{} `json:"result"`
+}
+
+// TraceBlockByNumber traces the txns of the block of the chain with the number
+func (d *Debug) TraceBlockByNumber(number BlockNumber, config *TraceConfig) (interface{}, error) {
+	header, err := d.d.getBlockHeaderImpl(number)
+	if err != nil {
+		return nil, err
+	}
+	block, ok := d.d.store.GetBlockByHash(header.Hash, true)
+	if !ok {
+		return nil, fmt.Errorf("block %d not found", header.Number)
+	}
+	return d.traceBlock(block, config)
+}
+
+// TraceBlockByHash traces the txns of the block of the chain with the hash
+func (d *Debug) TraceBlockByHash(hash types.Hash, config *TraceConfig) (interface{}, error) {
+	block, ok := d.d.store.GetBlockByHash(hash, true)
+	if !ok {
+		return nil, fmt.Errorf("block %s not found", hash)
+	}
+	return d.traceBlock(block, config)
+}
+
+// TraceBlock traces the txns of the rlp encoded block, which does not have to be in the chain
+func (d *Debug) TraceBlock(data argBytes, config *TraceConfig) (interface{}, error) {
+	block := &types.Block{}
+	if err := block.UnmarshalRLP(data); err != nil {
+		return nil, fmt.Errorf("failed to decode block: %v", err)
+	}
+	for _, txn := range block.Transactions {
+		txn.ComputeHash()
+	}
+	return d.traceBlock(block, config)
+}
+
+// traceBlock replays the txns of the block on top of the state of its parent and
+// returns the opcodes executed by each txn, or the result of the tracer
+func (d *Debug) traceBlock(block *types.Block, config *TraceConfig) (interface{}, error) {
+	tracers := make([]runtime.Tracer, 0, len(block.Transactions))
+	for range block.Transactions {
+		tracer, err := newTracer(config)
+		if err != nil {
+			return nil, err
+		}
+		tracers = append(tracers, tracer)
+	}
+
+	results, err := d.d.replayBlock(block, tracers)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*txTrace, 0, len(results))
+	for i, r := range results {
+		txn := block.Transactions[i]
+		res = append(res, &txTrace{
+			TxHash: txn.Hash,
+			Result: traceResult(tracers[i], txn.Gas, r.ReturnValue, r.GasUsed, r.Failed),
+		})
+	}
+	return res, nil
+}
+
+// GetBlockWitness returns the rlp encoding of the witness of the block, which are the trie
+// nodes and the codes of the state of its parent read by the execution of the block
+func (d *Debug) GetBlockWitness(number BlockNumber) (interface{}, error) {
+	header, err := d.d.getBlockHeaderImpl(number)
+	if err != nil {
+		return nil, err
+	}
+	block, ok := d.d.store.GetBlockByHash(header.Hash, true)
+	if !ok {
+		return nil, fmt.Errorf("block %d not found", header.Number)
+	}
+	if err := d.d.checkParentState(block); err != nil {
+		return nil, err
+	}
+
+	witness, err := d.d.store.Witness(block)
+	if err != nil {
+		return nil, err
+	}
+	return argBytes(witness.MarshalRLP()), nil
+}
+
+// TraceCall executes a call on top of the state of the block without creating
+// a transaction and returns the opcodes executed, or the result of the tracer
+func (d *Debug) TraceCall(arg *txnArgs, number BlockNumber, config *TraceConfig) (interface{}, error) {
+	transaction, err := d.d.decodeTxn(arg)
+	if err != nil {
+		return nil, err
+	}
+	header, err := d.d.getStateHeaderImpl(number)
+	if err != nil {
+		return nil, err
+	}
+
+	return trace(config, transaction.Gas, func(tracer runtime.Tracer) ([]byte, uint64, bool, error) {
+		return d.d.store.TraceCall(header, transaction, tracer)
+	})
+}
+
+const (
+	// defaultDumpAccounts is the number of accounts dumped if the max is not set
+	defaultDumpAccounts = 256
+
+	// maxDumpAccounts is the maximum number of accounts dumped in a call
+	maxDumpAccounts = 1024
+
+	// defaultStorageSlots is the number of storage slots returned if the max is not set
+	defaultStorageSlots = 256
+
+	// maxStorageSlots is the maximum number of storage slots returned in a call
+	maxStorageSlots = 1024
+)
+
+type dumpSlot struct {
+	Key   types.Hash  `json:"key"`
+	Slot  *types.Hash `json:"slot,omitempty"`
+	Value types.Hash  `json:"value"`
+}
+
+type dumpAccount struct {
+	Key      types.Hash     `json:"key"`
+	Address  *types.Address `json:"address,omitempty"`
+	Balance  *argBig        `json:"balance"`
+	Nonce    argUint64      `json:"nonce"`
+	Root     types.Hash     `json:"root"`
+	CodeHash types.Hash     `json:"codeHash"`
+	Code     argBytes       `json:"code,omitempty"`
+	Storage  []*dumpSlot    `json:"storage"`
+
+	// StorageNext is the start of the rest of the storage if it is truncated
+	StorageNext *types.Hash `json:"storageNext,omitempty"`
+}
+
+type dump struct {
+	Root     types.Hash     `json:"root"`
+	Accounts []*dumpAccount `json:"accounts"`
+	Next     *types.Hash    `json:"next,omitempty"`
+}
+
+func toDump(d *state.Dump) *dump {
+	res := &dump{
+		Root:     d.Root,
+		Accounts: []*dumpAccount{},
+		Next:     d.Next,
+	}
+	for _, account := range d.Accounts {
+		acct := &dumpAccount{
+			Key:      account.Key,
+			Address:  account.Address,
+			Balance:  argBigPtr(account.Balance),
+			Nonce:    argUint64(account.Nonce),
+			Root:     account.Root,
+			CodeHash: types.BytesToHash(account.CodeHash),
+			Code:     argBytes(account.Code),
+			Storage:  []*dumpSlot{},
+
+			StorageNext: account.StorageNext,
+		}
+		for _, slot := range account.Storage {
+			acct.Storage = append(acct.Storage, toDumpSlot(slot))
+		}
+		res.Accounts = append(res.Accounts, acct)
+	}
+	return res
+}
+
+func toDumpSlot(slot *state.DumpSlot) *dumpSlot {
+	return &dumpSlot{
+		Key:   slot.Key,
+		Slot:  slot.Slot,
+		Value: slot.Value,
+	}
+}
+
+type storageRange struct {
+	Storage []*dumpSlot `json:"storage"`
+	Next    *types.Hash `json:"next,omitempty"`
+}
+
+type accountStats struct {
+	Balance      *argBig    `json:"balance"`
+	Nonce        argUint64  `json:"nonce"`
+	Root         types.Hash `json:"root"`
+	CodeHash     types.Hash `json:"codeHash"`
+	CodeSize     argUint64  `json:"codeSize"`
+	StorageSlots argUint64  `json:"storageSlots"`
+}
+
+// DumpBlock returns the accounts of the state at the block with their code and storage, in
+// order of their hashed addresses from the optional start one. The next hashed address is
+// returned if there are more accounts than the max, which is the start of the next page
+func (d *Debug) DumpBlock(number BlockNumber, start *types.Hash, maxResults *argUint64) (interface{}, error) {
+	header, err := d.d.getStateHeaderImpl(number)
+	if err != nil {
+		return nil, err
+	}
+
+	from := types.Hash{}
+	if start != nil {
+		from = *start
+	}
+	max := uint64(defaultDumpAccounts)
+	if maxResults != nil {
+		max = uint64(*maxResults)
+	}
+	if max == 0 || max > maxDumpAccounts {
+		return nil, fmt.Errorf("the max number of accounts must be between 1 and %d", maxDumpAccounts)
+	}
+
+	res, err := d.d.store.DumpState(header.StateRoot, from, max)
+	if err != nil {
+		return nil, err
+	}
+	return toDump(res), nil
+}
+
+// StorageRange returns the storage slots of the account in the state at the block, in order
+// of their hashed slots from the optional start one. The next hashed slot is returned if there
+// are more slots than the max, which is the start of the next page
+func (d *Debug) StorageRange(address types.Address, number BlockNumber, start *types.Hash, maxResults *argUint64) (interface{}, error) {
+	header, err := d.d.getStateHeaderImpl(number)
+	if err != nil {
+		return nil, err
+	}
+
+	from := types.Hash{}
+	if start != nil {
+		from = *start
+	}
+	max := uint64(defaultStorageSlots)
+	if maxResults != nil {
+		max = uint64(*maxResults)
+	}
+	if max == 0 || max > maxStorageSlots {
+		return nil, fmt.Errorf("the max number of storage slots must be between 1 and %d", maxStorageSlots)
+	}
+
+	res, err := d.d.store.StorageRange(header.StateRoot, address, from, max)
+	if err != nil {
+		return nil, err
+	}
+	storage := &storageRange{
+		Storage: []*dumpSlot{},
+		Next:    res.Next,
+	}
+	for _, slot := range res.Slots {
+		storage.Storage = append(storage.Storage, toDumpSlot(slot))
+	}
+	return storage, nil
+}
+
+// AccountStats returns the size of the code and the number of storage slots of the account
+// in the state at the block. The whole storage of the account is read, which is slow for
+// the contracts with a large storage
+func (d *Debug) AccountStats(address types.Address, number BlockNumber) (interface{}, error) {
+	header, err := d.d.getStateHeaderImpl(number)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := d.d.store.AccountStats(header.StateRoot, address)
+	if err != nil {
+		return nil, err
+	}
+	return &accountStats{
+		Balance:      argBigPtr(res.Balance),
+		Nonce:        argUint64(res.Nonce),
+		Root:         res.Root,
+		CodeHash:     types.BytesToHash(res.CodeHash),
+		CodeSize:     argUint64(res.CodeSize),
+		StorageSlots: argUint64(res.StorageSlots),
+	}, nil
+}
+
+// GetRawBlock returns the rlp encoding of the block
+func (d *Debug) GetRawBlock(number BlockNumber) (interface{}, error) {
+	header, err := d.d.getBlockHeaderImpl(number)
+	if err != nil {
+		return nil, err
+	}
+	block, ok := d.d.store.GetBlockByHash(header.Hash, true)
+	if !ok {
+		return nil, fmt.Errorf("block %d not found", header.Number)
+	}
+	return argBytes(block.MarshalRLP()), nil
+}
+
+// GetRawHeader returns the rlp encoding of the header of the block
+func (d *Debug) GetRawHeader(number BlockNumber) (interface{}, error) {
+	header, err := d.d.getBlockHeaderImpl(number)
+	if err != nil {
+		return nil, err
+	}
+	return argBytes(header.MarshalRLP()), nil
+}
+
+// GetRawTransaction returns the rlp encoding of a mined transaction
+func (d *Debug) GetRawTransaction(hash types.Hash) (interface{}, error) {
+	block, indx, ok := d.d.getTxnBlock(hash)
+	if !ok {
+		// txn not found
+		return nil, nil
+	}
+	return argBytes(block.Transactions[indx].MarshalRLP()), nil
+}
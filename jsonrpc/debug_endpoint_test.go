@@ -0,0 +1,372 @@
+package jsonrpc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTraceStore struct {
+	mockTxLookupStore
+}
+
+func (m *mockTraceStore) TraceBlockTxn(block *types.Block, index int, tracer runtime.Tracer) ([]byte, uint64, bool, error) {
+	tracer.CaptureEnter(runtime.Call, types.StringToAddress("1"), types.StringToAddress("2"), nil, 1000, big.NewInt(0))
+	tracer.CaptureState(&runtime.TraceStep{
+		PC:     1,
+		OpName: "ADD",
+		Gas:    100,
+		Cost:   3,
+		Depth:  1,
+		Stack:  []*big.Int{big.NewInt(1), big.NewInt(2)},
+		Memory: make([]byte, 32),
+	})
+	tracer.CaptureExit([]byte{0x1}, 900, nil)
+	return []byte{0x1}, 21003, false, nil
+}
+
+func TestDebug_TraceTransaction(t *testing.T) {
+	txn := &types.Transaction{Value: big.NewInt(0), GasPrice: big.NewInt(0)}
+	txn.ComputeHash()
+
+	store := &mockTraceStore{}
+	store.add(&types.Block{Header: &types.Header{Number: 0}})
+	store.add(&types.Block{
+		Header:       &types.Header{Hash: hash1, Number: 1},
+		Transactions: []*types.Transaction{txn},
+	})
+	store.lookups = map[types.Hash]*storage.TxLookup{
+		txn.Hash: {BlockHash: hash1, BlockNumber: 1, Index: 0},
+	}
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	res, err := dispatcher.endpoints.Debug.TraceTransaction(txn.Hash, &TraceConfig{EnableMemory: true})
+	assert.NoError(t, err)
+
+	trace := res.(*executionTrace)
+	assert.Equal(t, uint64(21003), trace.Gas)
+	assert.False(t, trace.Failed)
+	assert.Equal(t, argBytes{0x1}, trace.ReturnValue)
+	assert.Equal(t, []*structLog{
+		{
+			Pc:      1,
+			Op:      "ADD",
+			Gas:     100,
+			GasCost: 3,
+			Depth:   1,
+			Stack:   []string{"0x1", "0x2"},
+			Memory:  []string{"0000000000000000000000000000000000000000000000000000000000000000"},
+		},
+	}, trace.StructLogs)
+
+	// call tracer
+	res, err = dispatcher.endpoints.Debug.TraceTransaction(txn.Hash, &TraceConfig{Tracer: "callTracer"})
+	assert.NoError(t, err)
+
+	frame := res.(*callFrame)
+	assert.Equal(t, "CALL", frame.Type)
+	assert.Equal(t, types.StringToAddress("2"), frame.To)
+	assert.Equal(t, argUint64(21003), frame.GasUsed)
+	assert.Equal(t, argBytes{0x1}, frame.Output)
+
+	// unknown tracer
+	_, err = dispatcher.endpoints.Debug.TraceTransaction(txn.Hash, &TraceConfig{Tracer: "unknown"})
+	assert.Error(t, err)
+
+	// txn not found
+	_, err = dispatcher.endpoints.Debug.TraceTransaction(hash2, nil)
+	assert.Error(t, err)
+}
+
+func (m *mockTraceStore) TraceBlock(block *types.Block, tracers []runtime.Tracer) ([]*state.TraceResult, error) {
+	results := []*state.TraceResult{}
+	for i, tracer := range tracers {
+		returnValue, gasUsed, failed, err := m.TraceBlockTxn(block, i, tracer)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, &state.TraceResult{ReturnValue: returnValue, GasUsed: gasUsed, Failed: failed})
+	}
+	return results, nil
+}
+
+func TestDebug_TraceBlock(t *testing.T) {
+	txn0 := &types.Transaction{Value: big.NewInt(0), GasPrice: big.NewInt(0), Gas: 30000, Nonce: 0}
+	txn0.ComputeHash()
+	txn1 := &types.Transaction{Value: big.NewInt(0), GasPrice: big.NewInt(0), Gas: 30000, Nonce: 1}
+	txn1.ComputeHash()
+
+	store := &mockTraceStore{}
+	store.add(&types.Block{Header: &types.Header{Hash: hash2, Number: 0}})
+	store.add(&types.Block{
+		Header:       &types.Header{Hash: hash1, Number: 1},
+		Transactions: []*types.Transaction{txn0, txn1},
+	})
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	res, err := dispatcher.endpoints.Debug.TraceBlockByNumber(BlockNumber(1), nil)
+	assert.NoError(t, err)
+
+	traces := res.([]*txTrace)
+	assert.Len(t, traces, 2)
+	assert.Equal(t, txn0.Hash, traces[0].TxHash)
+	assert.Equal(t, txn1.Hash, traces[1].TxHash)
+	for _, trace := range traces {
+		assert.Equal(t, uint64(21003), trace.Result.(*executionTrace).Gas)
+	}
+
+	// call tracer
+	res, err = dispatcher.endpoints.Debug.TraceBlockByHash(hash1, &TraceConfig{Tracer: "callTracer"})
+	assert.NoError(t, err)
+
+	traces = res.([]*txTrace)
+	assert.Len(t, traces, 2)
+	frame := traces[1].Result.(*callFrame)
+	assert.Equal(t, argUint64(30000), frame.Gas)
+	assert.Equal(t, argUint64(21003), frame.GasUsed)
+
+	// rlp encoded block
+	block, _ := store.GetBlockByHash(hash1, true)
+	res, err = dispatcher.endpoints.Debug.TraceBlock(block.MarshalRLP(), nil)
+	assert.NoError(t, err)
+
+	traces = res.([]*txTrace)
+	assert.Len(t, traces, 2)
+	assert.Equal(t, txn1.Hash, traces[1].TxHash)
+
+	_, err = dispatcher.endpoints.Debug.TraceBlock([]byte{0x1}, nil)
+	assert.Error(t, err)
+
+	// unknown tracer
+	_, err = dispatcher.endpoints.Debug.TraceBlockByHash(hash1, &TraceConfig{Tracer: "unknown"})
+	assert.Error(t, err)
+
+	// genesis
+	_, err = dispatcher.endpoints.Debug.TraceBlockByHash(hash2, nil)
+	assert.Error(t, err)
+
+	// block not found
+	_, err = dispatcher.endpoints.Debug.TraceBlockByNumber(BlockNumber(5), nil)
+	assert.Error(t, err)
+}
+
+func TestDebug_GetRaw(t *testing.T) {
+	txn := &types.Transaction{Value: big.NewInt(0), GasPrice: big.NewInt(0), Nonce: 1}
+	txn.ComputeHash()
+
+	block := &types.Block{
+		Header:       &types.Header{Number: 1, GasLimit: 100},
+		Transactions: []*types.Transaction{txn},
+	}
+	block.Header.ComputeHash()
+
+	store := &mockTraceStore{}
+	store.add(block)
+	store.lookups = map[types.Hash]*storage.TxLookup{
+		txn.Hash: {BlockHash: block.Hash(), BlockNumber: 1, Index: 0},
+	}
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	res, err := dispatcher.endpoints.Debug.GetRawBlock(BlockNumber(1))
+	assert.NoError(t, err)
+
+	decoded := &types.Block{}
+	assert.NoError(t, decoded.UnmarshalRLP(res.(argBytes)))
+	assert.Equal(t, block.Hash(), decoded.Hash())
+	assert.Len(t, decoded.Transactions, 1)
+
+	res, err = dispatcher.endpoints.Debug.GetRawHeader(BlockNumber(1))
+	assert.NoError(t, err)
+	assert.Equal(t, argBytes(block.Header.MarshalRLP()), res)
+
+	res, err = dispatcher.endpoints.Debug.GetRawTransaction(txn.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, argBytes(txn.MarshalRLP()), res)
+
+	// txn not found
+	res, err = dispatcher.endpoints.Debug.GetRawTransaction(hash2)
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+}
+
+func TestDispatcher_DisableService(t *testing.T) {
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), &mockTraceStore{})
+	dispatcher.disableService("debug")
+
+	_, err := dispatcher.Handle([]byte(`{"id": 1, "method": "debug_getRawBlock", "params": ["latest"]}`))
+	assert.Equal(t, invalidMethod("debug_getRawBlock"), err)
+}
+
+func (m *mockTraceStore) Witness(block *types.Block) (*state.Witness, error) {
+	return &state.Witness{Root: hash2, Nodes: [][]byte{{0x1}}, Codes: [][]byte{{0x2}}}, nil
+}
+
+func TestDebug_GetBlockWitness(t *testing.T) {
+	store := &mockTraceStore{}
+	store.add(&types.Block{Header: &types.Header{Hash: hash2, Number: 0}})
+	store.add(&types.Block{Header: &types.Header{Hash: hash1, Number: 1}})
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	res, err := dispatcher.endpoints.Debug.GetBlockWitness(BlockNumber(1))
+	assert.NoError(t, err)
+
+	w := &state.Witness{}
+	assert.NoError(t, w.UnmarshalRLP(res.(argBytes)))
+	assert.Equal(t, hash2, w.Root)
+	assert.Equal(t, [][]byte{{0x1}}, w.Nodes)
+	assert.Equal(t, [][]byte{{0x2}}, w.Codes)
+
+	// genesis
+	_, err = dispatcher.endpoints.Debug.GetBlockWitness(BlockNumber(0))
+	assert.Error(t, err)
+}
+
+type mockDumpStore struct {
+	mockAccessListStore
+
+	start types.Hash
+	max   uint64
+}
+
+func (m *mockDumpStore) DumpState(root types.Hash, start types.Hash, max uint64) (*state.Dump, error) {
+	m.start, m.max = start, max
+
+	next := types.StringToHash("3")
+	return &state.Dump{
+		Root: root,
+		Accounts: []*state.DumpAccount{
+			{
+				Account: &state.Account{
+					Nonce:    1,
+					Balance:  big.NewInt(10),
+					Root:     types.StringToHash("4"),
+					CodeHash: types.StringToHash("5").Bytes(),
+				},
+				Key:     types.StringToHash("2"),
+				Address: &addr0,
+				Code:    []byte{0x1},
+				Storage: []*state.DumpSlot{
+					{Key: types.StringToHash("6"), Value: types.StringToHash("7")},
+				},
+			},
+		},
+		Next: &next,
+	}, nil
+}
+
+func TestDebug_DumpBlock(t *testing.T) {
+	store := &mockDumpStore{}
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	res, err := dispatcher.endpoints.Debug.DumpBlock(BlockNumber(1), nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, types.Hash{}, store.start)
+	assert.Equal(t, uint64(defaultDumpAccounts), store.max)
+
+	next := types.StringToHash("3")
+	assert.Equal(t, &dump{
+		Accounts: []*dumpAccount{
+			{
+				Key:      types.StringToHash("2"),
+				Address:  &addr0,
+				Balance:  argBigPtr(big.NewInt(10)),
+				Nonce:    1,
+				Root:     types.StringToHash("4"),
+				CodeHash: types.StringToHash("5"),
+				Code:     argBytes{0x1},
+				Storage: []*dumpSlot{
+					{Key: types.StringToHash("6"), Value: types.StringToHash("7")},
+				},
+			},
+		},
+		Next: &next,
+	}, res)
+
+	// the next page starts at the next account
+	_, err = dispatcher.endpoints.Debug.DumpBlock(BlockNumber(1), &next, argUintPtr(10))
+	assert.NoError(t, err)
+	assert.Equal(t, next, store.start)
+	assert.Equal(t, uint64(10), store.max)
+
+	// the number of accounts is bounded
+	_, err = dispatcher.endpoints.Debug.DumpBlock(BlockNumber(1), nil, argUintPtr(maxDumpAccounts+1))
+	assert.Error(t, err)
+}
+
+func (m *mockDumpStore) StorageRange(root types.Hash, addr types.Address, start types.Hash, max uint64) (*state.StorageRange, error) {
+	m.start, m.max = start, max
+
+	next := types.StringToHash("3")
+	return &state.StorageRange{
+		Slots: []*state.DumpSlot{
+			{Key: types.StringToHash("6"), Slot: &hash1, Value: types.StringToHash("7")},
+		},
+		Next: &next,
+	}, nil
+}
+
+func (m *mockDumpStore) AccountStats(root types.Hash, addr types.Address) (*state.AccountStats, error) {
+	return &state.AccountStats{
+		Account: &state.Account{
+			Nonce:    1,
+			Balance:  big.NewInt(10),
+			Root:     types.StringToHash("4"),
+			CodeHash: types.StringToHash("5").Bytes(),
+		},
+		CodeSize:     100,
+		StorageSlots: 20,
+	}, nil
+}
+
+func TestDebug_StorageRange(t *testing.T) {
+	store := &mockDumpStore{}
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	res, err := dispatcher.endpoints.Debug.StorageRange(addr0, BlockNumber(1), nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, types.Hash{}, store.start)
+	assert.Equal(t, uint64(defaultStorageSlots), store.max)
+
+	next := types.StringToHash("3")
+	assert.Equal(t, &storageRange{
+		Storage: []*dumpSlot{
+			{Key: types.StringToHash("6"), Slot: &hash1, Value: types.StringToHash("7")},
+		},
+		Next: &next,
+	}, res)
+
+	// the next page starts at the next slot
+	_, err = dispatcher.endpoints.Debug.StorageRange(addr0, BlockNumber(1), &next, argUintPtr(10))
+	assert.NoError(t, err)
+	assert.Equal(t, next, store.start)
+	assert.Equal(t, uint64(10), store.max)
+
+	// the number of slots is bounded
+	_, err = dispatcher.endpoints.Debug.StorageRange(addr0, BlockNumber(1), nil, argUintPtr(maxStorageSlots+1))
+	assert.Error(t, err)
+}
+
+func TestDebug_AccountStats(t *testing.T) {
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), &mockDumpStore{})
+
+	res, err := dispatcher.endpoints.Debug.AccountStats(addr0, BlockNumber(1))
+	assert.NoError(t, err)
+	assert.Equal(t, &accountStats{
+		Balance:      argBigPtr(big.NewInt(10)),
+		Nonce:        1,
+		Root:         types.StringToHash("4"),
+		CodeHash:     types.StringToHash("5"),
+		CodeSize:     100,
+		StorageSlots: 20,
+	}, res)
+}
@@ -1,28 +1,92 @@
 package jsonrpc
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/big"
 	"reflect"
 	"strings"
+	"time"
 	"unicode"
 
+	"github.com/0xPolygon/minimal/helper/hex"
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/state/runtime"
 	"github.com/0xPolygon/minimal/types"
 	"github.com/hashicorp/go-hclog"
+	lru "github.com/hashicorp/golang-lru"
 )
 
 var (
+	parseError         = &ErrorObject{Code: -32700, Message: "parse error"}
 	invalidJSONRequest = &ErrorObject{Code: -32600, Message: "invalid json request"}
 	internalError      = &ErrorObject{Code: -32603, Message: "internal error"}
 )
 
+// serverErrorCode is the code of the errors of the endpoints that are not jsonrpc errors
+const serverErrorCode = -32000
+
 func invalidMethod(method string) error {
 	return &ErrorObject{Code: -32601, Message: fmt.Sprintf("The method %s does not exist/is not available", method)}
 }
 
-func invalidArguments(method string) error {
-	return &ErrorObject{Code: -32602, Message: fmt.Sprintf("invalid arguments to %s", method)}
+func invalidArguments(method string, err error) error {
+	return &ErrorObject{Code: -32602, Message: fmt.Sprintf("invalid arguments to %s: %v", method, err)}
+}
+
+// toErrorObject returns the jsonrpc error of the error, the errors
+// that are not jsonrpc errors are returned with the server error code
+func toErrorObject(err error) *ErrorObject {
+	if obj, ok := err.(*ErrorObject); ok {
+		return obj
+	}
+	return &ErrorObject{Code: serverErrorCode, Message: err.Error()}
+}
+
+// decodeRequest decodes the request, or returns a parse error if the body is not json
+func decodeRequest(reqBody []byte) (Request, error) {
+	var req Request
+	if err := json.Unmarshal(reqBody, &req); err != nil {
+		if _, ok := err.(*json.SyntaxError); ok {
+			return req, parseError
+		}
+		return req, invalidJSONRequest
+	}
+	return req, nil
+}
+
+// encodeError returns the response of the request that failed with the error,
+// with the id of the request if the request can be decoded
+func encodeError(reqBody []byte, err error) []byte {
+	req, _ := decodeRequest(reqBody)
+	resp, merr := json.Marshal(&ErrorResponse{ID: req.ID, JSONRPC: "2.0", Error: toErrorObject(err)})
+	if merr != nil {
+		resp, _ = json.Marshal(&ErrorResponse{ID: req.ID, JSONRPC: "2.0", Error: internalError})
+	}
+	return resp
+}
+
+// revertError is the error of a reverted call, with the reason decoded from the revert data
+func revertError(data []byte) error {
+	return &ErrorObject{Code: 3, Message: revertMessage(data), Data: hex.EncodeToHex(data)}
+}
+
+// revertMessage returns the message of a revert with its reason, if any
+func revertMessage(data []byte) string {
+	msg := "execution reverted"
+	if reason, err := runtime.UnpackRevertReason(data); err == nil {
+		msg += ": " + reason
+	}
+	return msg
+}
+
+func stateNotAvailable(number uint64) error {
+	return &ErrorObject{Code: -32000, Message: fmt.Sprintf("state of block %d is not available, it was pruned (only archive nodes keep all the states)", number)}
+}
+
+func errGasCapExceeded(gasCap uint64) *ErrorObject {
+	return &ErrorObject{Code: -32000, Message: fmt.Sprintf("gas required exceeds the rpc gas cap of %d", gasCap)}
 }
 
 type serviceData struct {
@@ -35,16 +99,30 @@ type funcData struct {
 	reqt  []reflect.Type
 	fv    reflect.Value
 	isDyn bool
+
+	// hasCtx is whether the first argument of the function is the context of the request
+	hasCtx bool
+}
+
+// paramsOffset is the index of the first param of the request in the arguments of the function
+func (f *funcData) paramsOffset() int {
+	if f.hasCtx {
+		return 2
+	}
+	return 1
 }
 
 func (f *funcData) numParams() int {
-	return f.inNum - 1
+	return f.inNum - f.paramsOffset()
 }
 
 type endpoints struct {
-	Eth  *Eth
-	Web3 *Web3
-	Net  *Net
+	Eth   *Eth
+	Web3  *Web3
+	Net   *Net
+	Debug *Debug
+	Admin *Admin
+	Trace *Trace
 }
 
 type enabledEndpoints map[string]struct{}
@@ -53,18 +131,63 @@ type enabledEndpoints map[string]struct{}
 type Dispatcher struct {
 	logger        hclog.Logger
 	store         blockchainInterface
+	network       networkInterface
 	serviceMap    map[string]*serviceData
 	endpoints     endpoints
 	filterManager *FilterManager
 	chainID       uint64
+	limiter       *txLimiter
+
+	// gasCap caps the gas of the calls and of the gas estimations, uncapped if 0
+	gasCap uint64
+
+	// acl decides which methods can be called, all of them if nil
+	acl *methodACL
+
+	// logsLimits bound the queries of the logs, unlimited if nil
+	logsLimits *LogsLimits
+
+	// workers bound the execution of the requests, unlimited if nil
+	workers *workerPool
+
+	// metrics of the requests
+	metrics *Metrics
+
+	// slowRequestThreshold is the duration above which the requests are logged, disabled if 0
+	slowRequestThreshold time.Duration
+
+	// blockCache keeps the responses of the recent full blocks by hash
+	blockCache *lru.Cache
+
+	// mining is whether the node seals blocks
+	mining bool
+
+	// accounts are the accounts returned by eth_accounts
+	accounts []types.Address
+
+	// ctx is the parent of the contexts of the requests, cancelled on close
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// capGas caps the gas of the simulated txn to the gas cap, and returns whether it was capped
+func (d *Dispatcher) capGas(txn *types.Transaction) bool {
+	if d.gasCap != 0 && txn.Gas > d.gasCap {
+		txn.Gas = d.gasCap
+		return true
+	}
+	return false
 }
 
 // newTestDispatcher returns a dispatcher without the filter manager, used for testing
 func newTestDispatcher(logger hclog.Logger, store blockchainInterface) *Dispatcher {
 	d := &Dispatcher{
-		logger: logger.Named("dispatcher"),
-		store:  store,
+		logger:  logger.Named("dispatcher"),
+		store:   store,
+		metrics: newMetrics(),
 	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	d.blockCache, _ = lru.New(blockCacheSize)
 
 	d.registerEndpoints()
 
@@ -76,7 +199,10 @@ func newDispatcher(logger hclog.Logger, store blockchainInterface, chainID uint6
 		logger:  logger.Named("dispatcher"),
 		store:   store,
 		chainID: chainID,
+		metrics: newMetrics(),
 	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	d.blockCache, _ = lru.New(blockCacheSize)
 	d.registerEndpoints()
 	if store != nil {
 		d.filterManager = NewFilterManager(logger, store)
@@ -89,10 +215,21 @@ func (d *Dispatcher) registerEndpoints() {
 	d.endpoints.Eth = &Eth{d}
 	d.endpoints.Net = &Net{d}
 	d.endpoints.Web3 = &Web3{d}
+	d.endpoints.Debug = &Debug{d}
+	d.endpoints.Admin = &Admin{d}
+	d.endpoints.Trace = &Trace{d}
 
 	d.registerService("eth", d.endpoints.Eth)
 	d.registerService("net", d.endpoints.Net)
 	d.registerService("web3", d.endpoints.Web3)
+	d.registerService("debug", d.endpoints.Debug)
+	d.registerService("admin", d.endpoints.Admin)
+	d.registerService("trace", d.endpoints.Trace)
+}
+
+// disableService removes the methods of a namespace, which are then not available
+func (d *Dispatcher) disableService(serviceName string) {
+	delete(d.serviceMap, serviceName)
 }
 
 func (d *Dispatcher) getFnHandler(req Request) (*serviceData, *funcData, error) {
@@ -132,25 +269,30 @@ func (d *Dispatcher) handleSubscribe(req Request, conn wsConn) (string, error) {
 		return "", fmt.Errorf("subscribe method '%s' not found", params[0])
 	}
 
-	var filterID string
-	if subscribeMethod == "newHeads" {
-		filterID = d.filterManager.NewBlockFilter(conn)
+	switch subscribeMethod {
+	case "newHeads":
+		return d.filterManager.NewBlockFilter(conn)
 
-	} else if subscribeMethod == "logs" {
-		logFilter, err := decodeLogFilterFromInterface(params[1])
-		if err != nil {
-			return "", err
+	case "logs":
+		// the logs are not filtered without a filter
+		logFilter := &LogFilter{}
+		if len(params) > 1 {
+			var err error
+			if logFilter, err = decodeLogFilterFromInterface(params[1]); err != nil {
+				return "", err
+			}
 		}
-		filterID = d.filterManager.NewLogFilter(logFilter, conn)
+		return d.filterManager.NewLogFilter(logFilter, conn)
 
-	} else {
+	case "newPendingTransactions":
+		return d.filterManager.NewPendingTxnFilter(conn)
+
+	default:
 		return "", fmt.Errorf("subscribe method %s not found", subscribeMethod)
 	}
-
-	return filterID, nil
 }
 
-func (d *Dispatcher) handleUnsubscribe(req Request) (bool, error) {
+func (d *Dispatcher) handleUnsubscribe(req Request, conn wsConn) (bool, error) {
 	var params []interface{}
 	if err := json.Unmarshal(req.Params, &params); err != nil {
 		return false, invalidJSONRequest
@@ -164,13 +306,16 @@ func (d *Dispatcher) handleUnsubscribe(req Request) (bool, error) {
 		return false, fmt.Errorf("unsubscribe filter not found")
 	}
 
-	return d.filterManager.Uninstall(filterID), nil
+	return d.filterManager.UninstallSubscription(filterID, conn), nil
 }
 
 func (d *Dispatcher) HandleWs(reqBody []byte, conn wsConn) ([]byte, error) {
-	var req Request
-	if err := json.Unmarshal(reqBody, &req); err != nil {
-		return nil, invalidJSONRequest
+	req, err := decodeRequest(reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.acl.check(req.Method); err != nil {
+		return nil, err
 	}
 
 	// if the request method is eth_subscribe we need to create a
@@ -180,23 +325,15 @@ func (d *Dispatcher) HandleWs(reqBody []byte, conn wsConn) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-
-		resp := fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":"%s"}`, req.ID, filterID)
-		return []byte(resp), nil
+		return d.wsResponse(req, filterID)
 	}
 
 	if req.Method == "eth_unsubscribe" {
-		ok, err := d.handleUnsubscribe(req)
+		ok, err := d.handleUnsubscribe(req, conn)
 		if err != nil {
 			return nil, err
 		}
-
-		res := "false"
-		if ok {
-			res = "true"
-		}
-		resp := fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":"%s"}`, req.ID, res)
-		return []byte(resp), nil
+		return d.wsResponse(req, ok)
 	}
 
 	// its a normal query that we handle with the dispatcher
@@ -207,10 +344,50 @@ func (d *Dispatcher) HandleWs(reqBody []byte, conn wsConn) ([]byte, error) {
 	return resp, nil
 }
 
+// CloseWs uninstalls the subscriptions of a closed websocket connection
+func (d *Dispatcher) CloseWs(conn wsConn) {
+	if d.filterManager != nil {
+		d.filterManager.UninstallWs(conn)
+	}
+}
+
+// cancelRequests cancels the context of the requests being handled and of the next ones
+func (d *Dispatcher) cancelRequests() {
+	d.cancel()
+}
+
+// waitRequests waits for the handlers of the requests that timed out to return
+func (d *Dispatcher) waitRequests() {
+	d.workers.wait()
+}
+
+// Close stops the filter manager, once the connections are closed
+func (d *Dispatcher) Close() {
+	d.cancel()
+	if d.filterManager != nil {
+		d.filterManager.Close()
+	}
+}
+
+func (d *Dispatcher) wsResponse(req Request, result interface{}) ([]byte, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, d.internalError(req.Method, err)
+	}
+	resp, err := json.Marshal(Response{ID: req.ID, JSONRPC: "2.0", Result: data})
+	if err != nil {
+		return nil, d.internalError(req.Method, err)
+	}
+	return resp, nil
+}
+
 func (d *Dispatcher) Handle(reqBody []byte) ([]byte, error) {
-	var req Request
-	if err := json.Unmarshal(reqBody, &req); err != nil {
-		return nil, invalidJSONRequest
+	req, err := decodeRequest(reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.acl.check(req.Method); err != nil {
+		return nil, err
 	}
 	return d.handleReq(req)
 }
@@ -220,30 +397,59 @@ func (d *Dispatcher) handleReq(req Request) ([]byte, error) {
 
 	service, fd, err := d.getFnHandler(req)
 	if err != nil {
+		d.metrics.trackRequest(unknownMethod, 0, 0, err)
 		return nil, err
 	}
 
+	start := time.Now()
+	resp, err := d.callFn(req, service, fd)
+	elapsed := time.Since(start)
+
+	d.metrics.trackRequest(req.Method, elapsed, len(resp), err)
+	if d.slowRequestThreshold != 0 && elapsed >= d.slowRequestThreshold {
+		d.logger.Warn("slow request", "method", req.Method, "duration", elapsed, "params", sanitizeParams(req.Params))
+	}
+	return resp, err
+}
+
+// callFn calls the function of the request with its params, and returns the response
+func (d *Dispatcher) callFn(req Request, service *serviceData, fd *funcData) ([]byte, error) {
 	inArgs := make([]reflect.Value, fd.inNum)
 	inArgs[0] = service.sv
 
+	offset := fd.paramsOffset()
 	inputs := make([]interface{}, fd.numParams())
-	for i := 0; i < fd.inNum-1; i++ {
-		val := reflect.New(fd.reqt[i+1])
+	for i := 0; i < fd.numParams(); i++ {
+		val := reflect.New(fd.reqt[i+offset])
+		if fd.reqt[i+offset] == blockNumberType {
+			// the block numbers omitted are the latest block, not the genesis
+			val.Elem().SetInt(int64(LatestBlockNumber))
+		}
 		inputs[i] = val.Interface()
-		inArgs[i+1] = val.Elem()
+		inArgs[i+offset] = val.Elem()
 	}
 
 	if err := json.Unmarshal(req.Params, &inputs); err != nil {
-		return nil, invalidJSONRequest
+		return nil, invalidArguments(req.Method, err)
 	}
 
-	output := fd.fv.Call(inArgs)
-	err = getError(output[1])
-	if err != nil {
-		return nil, d.internalError(req.Method, err)
+	var output []reflect.Value
+	if err := d.workers.run(d.ctx, func(ctx context.Context) {
+		if fd.hasCtx {
+			inArgs[1] = reflect.ValueOf(ctx)
+		}
+		output = fd.fv.Call(inArgs)
+	}); err != nil {
+		return nil, err
+	}
+	if err := getError(output[1]); err != nil {
+		return nil, toErrorObject(err)
 	}
 
-	var data []byte
+	var (
+		data []byte
+		err  error
+	)
 	res := output[0].Interface()
 	if res != nil {
 		data, err = json.Marshal(res)
@@ -270,16 +476,45 @@ func (d *Dispatcher) internalError(method string, err error) error {
 }
 
 func (d *Dispatcher) registerService(serviceName string, service interface{}) {
+	sd, err := newServiceData(serviceName, service)
+	if err != nil {
+		panic(fmt.Sprintf("jsonrpc: %s", err))
+	}
 	if d.serviceMap == nil {
 		d.serviceMap = map[string]*serviceData{}
 	}
+	d.serviceMap[serviceName] = sd
+}
+
+// registerCustomService registers a namespace that is not built in, which
+// cannot replace one of the built-in namespaces even if it is disabled
+func (d *Dispatcher) registerCustomService(serviceName string, service interface{}) error {
+	if strings.Contains(serviceName, "_") {
+		return fmt.Errorf("service name '%s' cannot include '_'", serviceName)
+	}
+	if _, ok := d.serviceMap[serviceName]; ok {
+		return fmt.Errorf("service '%s' is already registered", serviceName)
+	}
+	sd, err := newServiceData(serviceName, service)
+	if err != nil {
+		return err
+	}
+	if len(sd.funcMap) == 0 {
+		return fmt.Errorf("service '%s' has no exported methods", serviceName)
+	}
+	d.serviceMap[serviceName] = sd
+	return nil
+}
+
+// newServiceData returns the methods of the service, which have to return a value and an error
+func newServiceData(serviceName string, service interface{}) (*serviceData, error) {
 	if serviceName == "" {
-		panic(fmt.Sprintf("jsonrpc: serviceName cannot be empty"))
+		return nil, fmt.Errorf("serviceName cannot be empty")
 	}
 
 	st := reflect.TypeOf(service)
-	if st.Kind() == reflect.Struct {
-		panic(fmt.Sprintf("jsonrpc: service '%s' must be a pointer to struct", serviceName))
+	if st == nil || st.Kind() == reflect.Struct {
+		return nil, fmt.Errorf("service '%s' must be a pointer to struct", serviceName)
 	}
 
 	funcMap := make(map[string]*funcData)
@@ -297,11 +532,14 @@ func (d *Dispatcher) registerService(serviceName string, service interface{}) {
 		}
 		var err error
 		if fd.inNum, fd.reqt, err = validateFunc(funcName, fd.fv, true); err != nil {
-			panic(fmt.Sprintf("jsonrpc: %s", err))
+			return nil, err
 		}
+		// the functions that stop once the request is cancelled take its context first
+		fd.hasCtx = fd.inNum > 1 && fd.reqt[1] == contextType
+
 		// check if last item is a pointer
 		if fd.numParams() != 0 {
-			last := fd.reqt[fd.numParams()]
+			last := fd.reqt[fd.inNum-1]
 			if last.Kind() == reflect.Ptr {
 				fd.isDyn = true
 			}
@@ -309,10 +547,10 @@ func (d *Dispatcher) registerService(serviceName string, service interface{}) {
 		funcMap[name] = fd
 	}
 
-	d.serviceMap[serviceName] = &serviceData{
+	return &serviceData{
 		sv:      reflect.ValueOf(service),
 		funcMap: funcMap,
-	}
+	}, nil
 }
 
 func removePtr(t reflect.Type) reflect.Type {
@@ -355,6 +593,10 @@ func validateFunc(funcName string, fv reflect.Value, isMethod bool) (inNum int,
 
 var errt = reflect.TypeOf((*error)(nil)).Elem()
 
+var blockNumberType = reflect.TypeOf(BlockNumber(0))
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
 func isErrorType(t reflect.Type) bool {
 	return t.Implements(errt)
 }
@@ -374,35 +616,133 @@ func lowerCaseFirst(str string) string {
 }
 
 func (d *Dispatcher) getBlockHeaderImpl(number BlockNumber) (*types.Header, error) {
-	switch number {
-	case LatestBlockNumber:
-		return d.store.Header(), nil
-
-	case EarliestBlockNumber:
-		return nil, fmt.Errorf("fetching the earliest header is not supported")
+	if tag, ok := number.tag(); ok {
+		return d.store.ResolveBlockTag(tag)
+	}
+	if number < 0 {
+		return nil, fmt.Errorf("invalid block number %d", number)
+	}
 
-	case PendingBlockNumber:
-		return nil, fmt.Errorf("fetching the pending header is not supported")
+	// Convert the block number from hex to uint64
+	header, ok := d.store.GetHeaderByNumber(uint64(number))
+	if !ok {
+		return nil, fmt.Errorf("Error fetching block number %d header", uint64(number))
+	}
+	return header, nil
+}
 
-	default:
-		// Convert the block number from hex to uint64
-		header, ok := d.store.GetHeaderByNumber(uint64(number))
+// getBlockHeaderByNumberOrHash returns the header of the block referred to by its number or
+// by its hash, the latest block if it is omitted
+func (d *Dispatcher) getBlockHeaderByNumberOrHash(block BlockNumberOrHash) (*types.Header, error) {
+	if block.BlockHash != nil {
+		b, ok := d.store.GetBlockByHash(*block.BlockHash, false)
 		if !ok {
-			return nil, fmt.Errorf("Error fetching block number %d header", uint64(number))
+			return nil, fmt.Errorf("unable to get block by hash %v", *block.BlockHash)
 		}
-		return header, nil
+		return b.Header, nil
+	}
+	if block.BlockNumber == nil {
+		return d.getBlockHeaderImpl(LatestBlockNumber)
+	}
+	return d.getBlockHeaderImpl(*block.BlockNumber)
+}
+
+// getStateHeaderByNumberOrHash returns the header of the block, referred to by its number
+// or by its hash, whose state is queried. It fails if the state was pruned
+func (d *Dispatcher) getStateHeaderByNumberOrHash(block BlockNumberOrHash) (*types.Header, error) {
+	header, err := d.getBlockHeaderByNumberOrHash(block)
+	if err != nil {
+		return nil, err
+	}
+	if !d.store.HasState(header.StateRoot) {
+		return nil, stateNotAvailable(header.Number)
 	}
+	return header, nil
 }
 
-func (d *Dispatcher) getNextNonce(address types.Address, number BlockNumber) (uint64, error) {
+// resolveBlockNumber returns the number of a bound of a range of blocks. The pending
+// block is not written in the chain, so it is the latest one
+func (d *Dispatcher) resolveBlockNumber(number BlockNumber) (uint64, error) {
 	if number == PendingBlockNumber {
-		res, ok := d.store.GetNonce(address)
-		if ok {
-			return res, nil
-		}
 		number = LatestBlockNumber
 	}
+	if _, ok := number.tag(); !ok {
+		if number < 0 {
+			return 0, fmt.Errorf("invalid block number %d", number)
+		}
+		return uint64(number), nil
+	}
+	header, err := d.getBlockHeaderImpl(number)
+	if err != nil {
+		return 0, err
+	}
+	return header.Number, nil
+}
+
+// getStateHeaderImpl returns the header of the block whose state is queried,
+// it fails if the state was pruned
+func (d *Dispatcher) getStateHeaderImpl(number BlockNumber) (*types.Header, error) {
 	header, err := d.getBlockHeaderImpl(number)
+	if err != nil {
+		return nil, err
+	}
+	if !d.store.HasState(header.StateRoot) {
+		return nil, stateNotAvailable(header.Number)
+	}
+	return header, nil
+}
+
+// getTxnBlock returns the block in which the txn was mined and its index in the block
+func (d *Dispatcher) getTxnBlock(hash types.Hash) (*types.Block, int, bool) {
+	lookup, ok := d.store.ReadTxLookup(hash)
+	if !ok {
+		return nil, 0, false
+	}
+	block, ok := d.store.GetBlockByHash(lookup.BlockHash, true)
+	if !ok {
+		return nil, 0, false
+	}
+	indx := int(lookup.Index)
+	if indx >= len(block.Transactions) || block.Transactions[indx].Hash != hash {
+		// the lookup is stale (i.e. the block was reorged)
+		return nil, 0, false
+	}
+	return block, indx, true
+}
+
+// checkParentState fails if the state of the parent of the block is not available
+func (d *Dispatcher) checkParentState(block *types.Block) error {
+	if block.Number() == 0 {
+		return fmt.Errorf("genesis txns cannot be replayed")
+	}
+
+	parent, ok := d.store.GetHeaderByNumber(block.Number() - 1)
+	if !ok {
+		return fmt.Errorf("parent of block %d not found", block.Number())
+	}
+	if !d.store.HasState(parent.StateRoot) {
+		return stateNotAvailable(parent.Number)
+	}
+	return nil
+}
+
+// replayBlock replays the txns of the block on top of the state of its parent, each with its tracer
+func (d *Dispatcher) replayBlock(block *types.Block, tracers []runtime.Tracer) ([]*state.TraceResult, error) {
+	if err := d.checkParentState(block); err != nil {
+		return nil, err
+	}
+	return d.store.TraceBlock(block, tracers)
+}
+
+// getNextNonce returns the nonce of the account at the block, the next nonce
+// of its txns in the pool for the pending block
+func (d *Dispatcher) getNextNonce(address types.Address, block BlockNumberOrHash) (uint64, error) {
+	if block.BlockNumber != nil && *block.BlockNumber == PendingBlockNumber {
+		if res, ok := d.store.GetNonce(address); ok {
+			return res, nil
+		}
+	}
+	header, err := d.getStateHeaderByNumberOrHash(block)
 	if err != nil {
 		return 0, err
 	}
@@ -423,7 +763,7 @@ func (d *Dispatcher) decodeTxn(arg *txnArgs) (*types.Transaction, error) {
 	}
 	if arg.Nonce == nil {
 		// get nonce from the pool
-		nonce, err := d.getNextNonce(*arg.From, LatestBlockNumber)
+		nonce, err := d.getNextNonce(*arg.From, BlockNumberOrHash{})
 		if err != nil {
 			return nil, err
 		}
@@ -434,7 +774,7 @@ func (d *Dispatcher) decodeTxn(arg *txnArgs) (*types.Transaction, error) {
 	}
 	if arg.GasPrice == nil {
 		// use the suggested gas price
-		arg.GasPrice = argBytesPtr(d.store.GetAvgGasPrice().Bytes())
+		arg.GasPrice = argBytesPtr(d.store.SuggestGasPrice().Bytes())
 	}
 
 	var input []byte
@@ -468,6 +808,9 @@ func (d *Dispatcher) decodeTxn(arg *txnArgs) (*types.Transaction, error) {
 	if arg.To != nil {
 		txn.To = arg.To
 	}
+	if arg.AccessList != nil {
+		txn.AccessList = *arg.AccessList
+	}
 	txn.ComputeHash()
 	return txn, nil
 }
@@ -2,6 +2,7 @@ package jsonrpc
 
 import (
 	"encoding/json"
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
@@ -80,6 +81,40 @@ func TestDispatcherWebsocket(t *testing.T) {
 	}
 }
 
+func TestDispatcherWebsocketSubscribe(t *testing.T) {
+	store := newMockStore()
+	s := newDispatcher(hclog.NewNullLogger(), store, 0)
+
+	mock := &mockWsConn{
+		msgCh: make(chan []byte, 1),
+	}
+
+	// the logs are not filtered without a filter
+	resp, err := s.HandleWs([]byte(`{"id": 1, "method": "eth_subscribe", "params": ["logs"]}`), mock)
+	assert.NoError(t, err)
+
+	var id string
+	assert.NoError(t, expectJSONResult(resp, &id))
+	assert.True(t, s.filterManager.Exists(id))
+
+	// only the connection of the subscription can remove it
+	req := []byte(`{"id": 2, "method": "eth_unsubscribe", "params": ["` + id + `"]}`)
+
+	resp, err = s.HandleWs(req, &mockWsConn{})
+	assert.NoError(t, err)
+	var ok bool
+	assert.NoError(t, expectJSONResult(resp, &ok))
+	assert.False(t, ok)
+
+	resp, err = s.HandleWs(req, mock)
+	assert.NoError(t, err)
+	assert.NoError(t, expectJSONResult(resp, &ok))
+	assert.True(t, ok)
+
+	_, err = s.HandleWs([]byte(`{"id": 3, "method": "eth_subscribe", "params": ["syncing"]}`), mock)
+	assert.Error(t, err)
+}
+
 type mockService struct {
 	msgCh chan interface{}
 }
@@ -103,6 +138,11 @@ func (m *mockService) BlockPtr(a string, f *BlockNumber) (interface{}, error) {
 	return nil, nil
 }
 
+func (m *mockService) BlockOrHash(b BlockNumberOrHash) (interface{}, error) {
+	m.msgCh <- b
+	return nil, nil
+}
+
 func (m *mockService) Filter(f LogFilter) (interface{}, error) {
 	m.msgCh <- f
 	return nil, nil
@@ -124,6 +164,9 @@ func TestDispatcherFuncDecode(t *testing.T) {
 	}
 
 	addr1 := types.Address{0x1}
+	hash1 := types.Hash{0x1}
+	latest := LatestBlockNumber
+	one := BlockNumber(1)
 
 	cases := []struct {
 		typ string
@@ -160,6 +203,26 @@ func TestDispatcherFuncDecode(t *testing.T) {
 			`["a", "latest"]`,
 			LatestBlockNumber,
 		},
+		{
+			"blockOrHash",
+			`["latest"]`,
+			BlockNumberOrHash{BlockNumber: &latest},
+		},
+		{
+			"blockOrHash",
+			`[{"blockNumber": "0x1"}]`,
+			BlockNumberOrHash{BlockNumber: &one},
+		},
+		{
+			"blockOrHash",
+			`["` + hash1.String() + `"]`,
+			BlockNumberOrHash{BlockHash: &hash1},
+		},
+		{
+			"blockOrHash",
+			`[{"blockHash": "` + hash1.String() + `"}]`,
+			BlockNumberOrHash{BlockHash: &hash1},
+		},
 		{
 			"filter",
 			`[{"fromBlock": "pending", "toBlock": "earliest"}]`,
@@ -173,3 +236,99 @@ func TestDispatcherFuncDecode(t *testing.T) {
 		}
 	}
 }
+
+type mockErrorService struct{}
+
+func (m *mockErrorService) Fail() (interface{}, error) {
+	return nil, fmt.Errorf("failed")
+}
+
+func (m *mockErrorService) Revert() (interface{}, error) {
+	return nil, revertError([]byte{0x1, 0x2})
+}
+
+func (m *mockErrorService) Args(n argUint64) (interface{}, error) {
+	return nil, nil
+}
+
+func TestDispatcherErrors(t *testing.T) {
+	s := newDispatcher(hclog.NewNullLogger(), newMockStore(), 0)
+	s.registerService("mock", &mockErrorService{})
+
+	handle := func(req string) *ErrorObject {
+		_, err := s.Handle([]byte(req))
+		assert.Error(t, err)
+
+		// the error responses have the id of the request, null if it cannot be decoded, and no result
+		var resp map[string]json.RawMessage
+		assert.NoError(t, json.Unmarshal(encodeError([]byte(req), err), &resp))
+		assert.Equal(t, `"2.0"`, string(resp["jsonrpc"]))
+		assert.NotContains(t, resp, "result")
+		if id := string(resp["id"]); id != "null" {
+			assert.Equal(t, "1", id)
+		}
+
+		var obj *ErrorObject
+		assert.NoError(t, json.Unmarshal(resp["error"], &obj))
+		return obj
+	}
+
+	assert.Equal(t, -32700, handle(`{"id": 1, "method"`).Code)
+	assert.Equal(t, -32600, handle(`[1]`).Code)
+	assert.Equal(t, -32601, handle(`{"id": 1, "method": "mock_foo", "params": []}`).Code)
+	assert.Equal(t, -32602, handle(`{"id": 1, "method": "mock_args", "params": [{}]}`).Code)
+
+	obj := handle(`{"id": 1, "method": "mock_fail", "params": []}`)
+	assert.Equal(t, serverErrorCode, obj.Code)
+	assert.Equal(t, "failed", obj.Message)
+
+	obj = handle(`{"id": 1, "method": "mock_revert", "params": []}`)
+	assert.Equal(t, 3, obj.Code)
+	assert.Equal(t, "execution reverted", obj.Message)
+	assert.Equal(t, "0x0102", obj.Data)
+}
+
+func TestBlockNumberOrHash_Invalid(t *testing.T) {
+	cases := []string{
+		`{}`,
+		`{"blockNumber": "0x1", "blockHash": "0x0000000000000000000000000000000000000000000000000000000000000001"}`,
+		`"0xz"`,
+		`1`,
+	}
+	for _, c := range cases {
+		var b BlockNumberOrHash
+		assert.Error(t, json.Unmarshal([]byte(c), &b), c)
+	}
+}
+
+type mockCustomService struct{}
+
+func (m *mockCustomService) Echo(s string) (interface{}, error) {
+	return s, nil
+}
+
+type mockInvalidService struct{}
+
+func (m *mockInvalidService) Echo(s string) string {
+	return s
+}
+
+func TestDispatcher_CustomService(t *testing.T) {
+	s := newDispatcher(hclog.NewNullLogger(), newMockStore(), 0)
+	assert.NoError(t, s.registerCustomService("chain", &mockCustomService{}))
+
+	resp, err := s.Handle([]byte(`{"id": 1, "method": "chain_echo", "params": ["a"]}`))
+	assert.NoError(t, err)
+	assert.Contains(t, string(resp), `"result":"a"`)
+
+	// the built-in namespaces cannot be replaced
+	assert.Error(t, s.registerCustomService("eth", &mockCustomService{}))
+	assert.Error(t, s.registerCustomService("chain", &mockCustomService{}))
+
+	// the names and the methods are validated
+	assert.Error(t, s.registerCustomService("", &mockCustomService{}))
+	assert.Error(t, s.registerCustomService("my_chain", &mockCustomService{}))
+	assert.Error(t, s.registerCustomService("empty", &struct{}{}))
+	assert.Error(t, s.registerCustomService("invalid", &mockInvalidService{}))
+	assert.Error(t, s.registerCustomService("nil", nil))
+}
@@ -1,54 +1,112 @@
 package jsonrpc
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"math/big"
+	"reflect"
+	"time"
 
+	"github.com/0xPolygon/minimal/blockchain/bloombits"
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/crypto"
 	"github.com/0xPolygon/minimal/helper/hex"
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/state/runtime"
 	"github.com/0xPolygon/minimal/types"
 )
 
+// blockCacheSize is the number of full blocks whose responses are cached
+const blockCacheSize = 128
+
+// ethProtocolVersion is the version of the eth wire protocol reported to the tools,
+// the peers of the node do not use it since they sync over libp2p
+const ethProtocolVersion = 65
+
 // Eth is the eth jsonrpc endpoint
 type Eth struct {
 	d *Dispatcher
 }
 
+// getBlock returns the response of the block. The blocks do not change once written,
+// so the responses of the full blocks, which are the expensive ones to build and the
+// ones polled by the explorers, are cached by hash and shared by the requests
+func (e *Eth) getBlock(hash types.Hash, full bool) (*block, bool) {
+	if full {
+		if res, ok := e.d.blockCache.Get(hash); ok {
+			return res.(*block), true
+		}
+	}
+	b, ok := e.d.store.GetBlockByHash(hash, full)
+	if !ok {
+		return nil, false
+	}
+	res := toBlock(b)
+	if full {
+		e.d.blockCache.Add(hash, res)
+	}
+	return res, true
+}
+
 // ChainId returns the chain id of the client
 func (e *Eth) ChainId() (interface{}, error) {
 	return argUintPtr(e.d.chainID), nil
 }
 
-// GetBlockByNumber returns information about a block by block number
-func (e *Eth) GetBlockByNumber(number BlockNumber, full bool) (interface{}, error) {
-	var num uint64
-	switch number {
-	case LatestBlockNumber:
-		num = e.d.store.Header().Number
+// ProtocolVersion returns the version of the eth protocol
+func (e *Eth) ProtocolVersion() (interface{}, error) {
+	return argUintPtr(ethProtocolVersion), nil
+}
 
-	case EarliestBlockNumber:
-		return nil, fmt.Errorf("fetching the earliest header is not supported")
+// Mining returns true if the node seals new blocks
+func (e *Eth) Mining() (interface{}, error) {
+	return e.d.mining, nil
+}
 
-	case PendingBlockNumber:
-		return nil, fmt.Errorf("fetching the pending header is not supported")
+// Hashrate returns the hashes per second of the mining, which is always 0
+// since the blocks are not sealed with proof of work
+func (e *Eth) Hashrate() (interface{}, error) {
+	return argUintPtr(0), nil
+}
 
-	default:
-		num = uint64(number)
+// Accounts returns the accounts that can send unsigned txns, which are the
+// senders allowed by the dev mode. The node does not hold any key otherwise
+func (e *Eth) Accounts() (interface{}, error) {
+	accounts := e.d.accounts
+	if accounts == nil {
+		accounts = []types.Address{}
 	}
+	return accounts, nil
+}
 
-	block, ok := e.d.store.GetBlockByNumber(num, full)
+// GetBlockByNumber returns information about a block by block number
+func (e *Eth) GetBlockByNumber(number BlockNumber, full bool) (interface{}, error) {
+	if number == PendingBlockNumber {
+		// the pending block is not written in the chain
+		if block, ok := e.d.store.PendingBlock(); ok {
+			return toBlock(block), nil
+		}
+	}
+
+	header, err := e.d.getBlockHeaderImpl(number)
+	if err != nil {
+		return nil, err
+	}
+	block, ok := e.getBlock(header.Hash, full)
 	if !ok {
-		return nil, fmt.Errorf("unable to get block by num %v", num)
+		return nil, fmt.Errorf("unable to get block by num %v", header.Number)
 	}
-	return toBlock(block), nil
+	return block, nil
 }
 
 // GetBlockByHash returns information about a block by hash
 func (e *Eth) GetBlockByHash(hash types.Hash, full bool) (interface{}, error) {
-	block, ok := e.d.store.GetBlockByHash(hash, full)
+	block, ok := e.getBlock(hash, full)
 	if !ok {
 		return nil, fmt.Errorf("unable to get block by hash %v", hash)
 	}
-	return toBlock(block), nil
+	return block, nil
 }
 
 // BlockNumber returns current block number
@@ -70,6 +128,9 @@ func (e *Eth) SendRawTransaction(input string) (interface{}, error) {
 	}
 	tx.ComputeHash()
 
+	if err := e.d.limiter.allowTxn(crypto.NewEIP155Signer(e.d.chainID), tx); err != nil {
+		return nil, err
+	}
 	if err := e.d.store.AddTx(tx); err != nil {
 		return nil, err
 	}
@@ -90,104 +151,76 @@ func (e *Eth) SendTransaction(arg *txnArgs) (interface{}, error) {
 
 // GetTransactionByHash returns a transaction by his hash
 func (e *Eth) GetTransactionByHash(hash types.Hash) (interface{}, error) {
-	blockHash, ok := e.d.store.ReadTxLookup(hash)
+	block, indx, ok := e.d.getTxnBlock(hash)
 	if !ok {
 		// txn not found
 		return nil, nil
 	}
-	block, ok := e.d.store.GetBlockByHash(blockHash, true)
-	if !ok {
-		// block receipts not found
-		return nil, nil
-	}
-	for _, txn := range block.Transactions {
-		if txn.Hash == hash {
-			// todo: need to add blockHash, blockNumber, and transactionIndex
-			return toTransaction(txn), nil
-		}
-	}
-	// txn not found (this should not happen)
-	return nil, nil
+	return toTransaction(block.Transactions[indx], block.Header, indx), nil
 }
 
 // GetTransactionReceipt returns a transaction receipt by his hash
 func (e *Eth) GetTransactionReceipt(hash types.Hash) (interface{}, error) {
-	blockHash, ok := e.d.store.ReadTxLookup(hash)
+	block, indx, ok := e.d.getTxnBlock(hash)
 	if !ok {
 		// txn not found
 		return nil, nil
 	}
 
-	block, ok := e.d.store.GetBlockByHash(blockHash, true)
-	if !ok {
-		fmt.Println("CCCC")
-		// block not found
-		return nil, nil
-	}
-
-	receipts, err := e.d.store.GetReceiptsByHash(blockHash)
+	receipts, err := e.d.store.GetReceiptsByHash(block.Hash())
 	if err != nil {
-		fmt.Println("AAAA", err)
 		// block receipts not found
 		return nil, nil
 	}
-	if len(receipts) == 0 {
-		// receitps not written yet on the db
-		fmt.Println("BBBB")
+	if len(receipts) <= indx {
+		// receipts not written yet on the db
 		return nil, nil
 	}
-	// find the transaction in the body
-	indx := -1
-	for i, txn := range block.Transactions {
-		if txn.Hash == hash {
-			indx = i
-			break
-		}
+
+	// the logs are indexed in the block
+	logIndex := 0
+	for _, raw := range receipts[:indx] {
+		logIndex += len(raw.Logs)
 	}
-	if indx == -1 {
-		// txn not found
-		return nil, nil
+	return toReceipt(block, indx, receipts[indx], logIndex), nil
+}
+
+// GetBlockReceipts returns the receipts of all the transactions of a block, referred to by its number or by its hash
+func (e *Eth) GetBlockReceipts(blockNumOrHash BlockNumberOrHash) (interface{}, error) {
+	header, err := e.d.getBlockHeaderByNumberOrHash(blockNumOrHash)
+	if err != nil {
+		return nil, err
+	}
+	block, ok := e.d.store.GetBlockByHash(header.Hash, true)
+	if !ok {
+		return nil, fmt.Errorf("unable to get block by hash %v", header.Hash)
 	}
 
-	txn := block.Transactions[indx]
-	raw := receipts[indx]
-
-	logs := make([]*Log, len(raw.Logs))
-	for indx, elem := range raw.Logs {
-		logs[indx] = &Log{
-			Address:     elem.Address,
-			Topics:      elem.Topics,
-			Data:        argBytes(elem.Data),
-			BlockHash:   block.Hash(),
-			BlockNumber: argUint64(block.Number()),
-			TxHash:      txn.Hash,
-			TxIndex:     argUint64(indx),
-			LogIndex:    argUint64(indx),
-			Removed:     false,
+	receipts, err := e.d.store.GetReceiptsByHash(header.Hash)
+	if err != nil {
+		if err != storage.ErrNotFound || len(block.Transactions) != 0 {
+			return nil, err
 		}
+		// the blocks without transactions might not have receipts stored
+		receipts = []*types.Receipt{}
+	}
+	if len(receipts) != len(block.Transactions) {
+		return nil, fmt.Errorf("block %d has %d receipts for %d transactions", header.Number, len(receipts), len(block.Transactions))
 	}
-	res := &receipt{
-		Root:              raw.Root,
-		CumulativeGasUsed: argUint64(raw.CumulativeGasUsed),
-		LogsBloom:         raw.LogsBloom,
-		Status:            raw.Status,
-		TxHash:            txn.Hash,
-		TxIndex:           argUint64(indx),
-		BlockHash:         block.Hash(),
-		BlockNumber:       argUint64(block.Number()),
-		GasUsed:           argUint64(raw.GasUsed),
-		ContractAddress:   raw.ContractAddress,
-		FromAddr:          txn.From,
-		ToAddr:            txn.To,
-		Logs:              logs,
+
+	res := make([]*receipt, len(receipts))
+	logIndex := 0
+	for indx, raw := range receipts {
+		res[indx] = toReceipt(block, indx, raw, logIndex)
+		logIndex += len(raw.Logs)
 	}
 	return res, nil
 }
 
 // GetStorageAt returns the contract storage at the index position
-func (e *Eth) GetStorageAt(address types.Address, index types.Hash, number BlockNumber) (interface{}, error) {
+func (e *Eth) GetStorageAt(address types.Address, index types.Hash, block BlockNumberOrHash) (interface{}, error) {
 	// Fetch the requested header
-	header, err := e.d.getBlockHeaderImpl(number)
+	header, err := e.d.getStateHeaderByNumberOrHash(block)
 	if err != nil {
 		return nil, err
 	}
@@ -200,47 +233,257 @@ func (e *Eth) GetStorageAt(address types.Address, index types.Hash, number Block
 	return argBytesPtr(result), nil
 }
 
-// GasPrice returns the average gas price based on the last x blocks
-func (e *Eth) GasPrice() (interface{}, error) {
+type storageProof struct {
+	Key   types.Hash `json:"key"`
+	Value *argBig    `json:"value"`
+	Proof []argBytes `json:"proof"`
+}
 
-	// Grab the average gas price and convert it to a hex value
-	avgGasPrice := hex.EncodeBig(e.d.store.GetAvgGasPrice())
+type accountProof struct {
+	Address      types.Address   `json:"address"`
+	AccountProof []argBytes      `json:"accountProof"`
+	Balance      *argBig         `json:"balance"`
+	CodeHash     types.Hash      `json:"codeHash"`
+	Nonce        argUint64       `json:"nonce"`
+	StorageHash  types.Hash      `json:"storageHash"`
+	StorageProof []*storageProof `json:"storageProof"`
+}
 
-	return avgGasPrice, nil
+func toArgBytesList(list [][]byte) []argBytes {
+	res := make([]argBytes, len(list))
+	for i, b := range list {
+		res[i] = argBytes(b)
+	}
+	return res
 }
 
-// Call executes a smart contract call using the transaction object data
-func (e *Eth) Call(arg *txnArgs, number BlockNumber) (interface{}, error) {
+// GetProof returns the merkle proof of the account and of its storage slots
+// at the block. The proofs of the missing accounts and slots prove their absence
+func (e *Eth) GetProof(address types.Address, keys []types.Hash, block BlockNumberOrHash) (interface{}, error) {
+	header, err := e.d.getStateHeaderByNumberOrHash(block)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := e.d.store.GetProof(header.StateRoot, address, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &accountProof{
+		Address:      address,
+		AccountProof: toArgBytesList(proof.Proof),
+		Balance:      argBigPtr(big.NewInt(0)),
+		CodeHash:     types.BytesToHash(crypto.Keccak256(nil)),
+		StorageHash:  types.EmptyRootHash,
+		StorageProof: []*storageProof{},
+	}
+	if account := proof.Account; account != nil {
+		res.Balance = argBigPtr(account.Balance)
+		res.CodeHash = types.BytesToHash(account.CodeHash)
+		res.Nonce = argUint64(account.Nonce)
+		res.StorageHash = account.Root
+	}
+	for _, slot := range proof.StorageProof {
+		res.StorageProof = append(res.StorageProof, &storageProof{
+			Key:   slot.Key,
+			Value: argBigPtr(new(big.Int).SetBytes(slot.Value)),
+			Proof: toArgBytesList(slot.Proof),
+		})
+	}
+	return res, nil
+}
+
+// GasPrice returns the gas price suggested by the oracle, based
+// on the last x blocks and the transactions in the pool
+func (e *Eth) GasPrice() (interface{}, error) {
+	return hex.EncodeBig(e.d.store.SuggestGasPrice()), nil
+}
+
+// FeeHistory returns the base fees, the gas used ratios and the priority fees paid at the
+// percentiles (0-100) of the gas used in up to blocks blocks ending at the newest block
+func (e *Eth) FeeHistory(blocks argUint64, newest BlockNumber, percentiles []float64) (interface{}, error) {
+	header, err := e.d.getBlockHeaderImpl(newest)
+	if err != nil {
+		return nil, err
+	}
+	history, err := e.d.store.FeeHistory(uint64(blocks), header.Number, percentiles)
+	if err != nil {
+		return nil, err
+	}
+	return toFeeHistory(history), nil
+}
+
+// Call executes a smart contract call using the transaction object data. The
+// accounts of the optional state override are replaced before the execution
+func (e *Eth) Call(arg *txnArgs, block BlockNumberOrHash, override *stateOverride) (interface{}, error) {
 	transaction, err := e.d.decodeTxn(arg)
 	if err != nil {
 		return nil, err
 	}
 	// Fetch the requested header
-	header, err := e.d.getBlockHeaderImpl(number)
+	header, err := e.d.getStateHeaderByNumberOrHash(block)
 	if err != nil {
 		return nil, err
 	}
 
-	// The return value of the execution is saved in the transition (returnValue field)
-	returnValue, failed, err := e.d.store.ApplyTxn(header, transaction)
+	var stateOverride state.StateOverride
+	if override != nil {
+		stateOverride = override.toStateOverride()
+	}
+
+	capped := e.d.capGas(transaction)
+
+	result, err := e.d.store.ApplyTxn(header, transaction, stateOverride)
 	if err != nil {
 		return nil, err
 	}
 
-	if failed {
-		return nil, fmt.Errorf("unable to execute call")
+	if result.Reverted() {
+		return nil, revertError(result.ReturnValue)
+	}
+	if capped && isOutOfGas(result.Err) {
+		return nil, errGasCapExceeded(e.d.gasCap)
+	}
+	if result.Failed() {
+		return nil, fmt.Errorf("unable to execute call: %v", result.Err)
 	}
-	return argBytesPtr(returnValue), nil
+	return argBytesPtr(result.ReturnValue), nil
 }
 
-// EstimateGas estimates the gas needed to execute a transaction
-func (e *Eth) EstimateGas(arg *txnArgs, rawNum *BlockNumber) (interface{}, error) {
-	transaction, err := e.d.decodeTxn(arg)
+// isOutOfGas returns true if the execution failed because it ran out of gas
+func isOutOfGas(err error) bool {
+	return err == runtime.ErrOutOfGas || err == runtime.ErrCodeStoreOutOfGas
+}
+
+// maxBundleCalls is the max number of calls simulated in a bundle
+const maxBundleCalls = 100
+
+// blockOverride replaces the context of the block of the calls of a bundle,
+// i.e. to simulate them in a future block
+type blockOverride struct {
+	Number    *argUint64 `json:"number"`
+	Timestamp *argUint64 `json:"timestamp"`
+}
+
+type bundleCallResult struct {
+	GasUsed     argUint64 `json:"gasUsed"`
+	ReturnValue argBytes  `json:"returnValue"`
+	Error       string    `json:"error,omitempty"`
+}
+
+type bundleResult struct {
+	Results          []*bundleCallResult `json:"results"`
+	TotalGasUsed     argUint64           `json:"totalGasUsed"`
+	StateBlockNumber argUint64           `json:"stateBlockNumber"`
+}
+
+// CallBundle executes the calls in order on top of the state of the block, each call on top
+// of the changes of the previous ones, and returns the result of each call. The nonces not
+// set are the next ones of the senders along the bundle. The calls that fail or revert do
+// not abort the bundle, but the ones that cannot be applied (i.e. nonce too low) do. The gas
+// cap bounds the gas of the whole bundle, each call gets at most the gas left by the previous ones
+func (e *Eth) CallBundle(args []*txnArgs, number BlockNumber, override *blockOverride) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty bundle")
+	}
+	if len(args) > maxBundleCalls {
+		return nil, fmt.Errorf("bundle of %d calls exceeds the max of %d calls", len(args), maxBundleCalls)
+	}
+
+	header, err := e.d.getStateHeaderImpl(number)
 	if err != nil {
 		return nil, err
 	}
 
-	const standardGas uint64 = 21000
+	gasLeft := e.d.gasCap
+	nonces := map[types.Address]uint64{}
+	txns := make([]*types.Transaction, 0, len(args))
+	capped := make([]bool, 0, len(args))
+	for i, arg := range args {
+		if arg.From != nil && arg.Nonce == nil {
+			nonce, ok := nonces[*arg.From]
+			if !ok {
+				if nonce, err = e.d.getNextNonce(*arg.From, BlockNumberOrHash{BlockNumber: &number}); err != nil {
+					return nil, err
+				}
+			}
+			arg.Nonce = argUintPtr(nonce)
+		}
+		txn, err := e.d.decodeTxn(arg)
+		if err != nil {
+			return nil, fmt.Errorf("call %d: %v", i, err)
+		}
+		nonces[txn.From] = txn.Nonce + 1
+		txns = append(txns, txn)
+
+		isCapped := false
+		if e.d.gasCap != 0 {
+			if gasLeft == 0 {
+				return nil, errGasCapExceeded(e.d.gasCap)
+			}
+			if txn.Gas > gasLeft {
+				txn.Gas = gasLeft
+				isCapped = true
+			}
+			gasLeft -= txn.Gas
+		}
+		capped = append(capped, isCapped)
+	}
+
+	stateNumber := header.Number
+	if override != nil {
+		header = header.Copy()
+		if override.Number != nil {
+			header.Number = uint64(*override.Number)
+		}
+		if override.Timestamp != nil {
+			header.Timestamp = uint64(*override.Timestamp)
+		}
+	}
+
+	results, err := e.d.store.ApplyTxns(header, txns)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &bundleResult{
+		Results:          make([]*bundleCallResult, 0, len(results)),
+		StateBlockNumber: argUint64(stateNumber),
+	}
+	for i, result := range results {
+		call := &bundleCallResult{
+			GasUsed:     argUint64(result.GasUsed),
+			ReturnValue: argBytes(result.ReturnValue),
+		}
+		if result.Reverted() {
+			call.Error = revertMessage(result.ReturnValue)
+		} else if i < len(capped) && capped[i] && isOutOfGas(result.Err) {
+			call.Error = errGasCapExceeded(e.d.gasCap).Message
+		} else if result.Failed() {
+			call.Error = result.Err.Error()
+		}
+		res.Results = append(res.Results, call)
+		res.TotalGasUsed += argUint64(result.GasUsed)
+	}
+	return res, nil
+}
+
+// EstimateGas estimates the gas needed to execute a transaction with a binary search between
+// the gas used by the txn and the lowest of the block gas limit, the gas of the txn and the gas
+// cap. The revert reason is returned if the txn cannot be executed with any gas. The search
+// stops once the request is cancelled
+func (e *Eth) EstimateGas(ctx context.Context, arg *txnArgs, rawNum *BlockNumber) (interface{}, error) {
+	// the gas of the txn is only a ceiling if it is set
+	var gasLimit uint64
+	if arg.Gas != nil {
+		gasLimit = uint64(*arg.Gas)
+	}
+
+	transaction, err := e.d.decodeTxn(arg)
+	if err != nil {
+		return nil, err
+	}
 
 	number := LatestBlockNumber
 	if rawNum != nil {
@@ -248,128 +491,189 @@ func (e *Eth) EstimateGas(arg *txnArgs, rawNum *BlockNumber) (interface{}, error
 	}
 
 	// Fetch the requested header
-	header, err := e.d.getBlockHeaderImpl(number)
+	header, err := e.d.getStateHeaderImpl(number)
 	if err != nil {
 		return nil, err
 	}
 
-	var (
-		lowEnd  = standardGas
-		highEnd uint64
-		gasCap  uint64
-	)
-
-	// If the gas limit was passed in, use it as a ceiling
-	if transaction.Gas != 0 && uint64(transaction.Gas) >= standardGas {
-		highEnd = uint64(transaction.Gas)
-	} else {
-		// If not, use the referenced block number
-		highEnd = header.GasLimit
+	highEnd := header.GasLimit
+	if gasLimit != 0 && gasLimit < highEnd {
+		highEnd = gasLimit
+	}
+	capped := false
+	if e.d.gasCap != 0 && highEnd > e.d.gasCap {
+		highEnd = e.d.gasCap
+		capped = true
 	}
 
-	gasPriceInt := new(big.Int).Set(transaction.GasPrice)
-	valueInt := new(big.Int).Set(transaction.Value)
-
-	// If the sender address is present, recalculate the ceiling to his balance
-	if transaction.GasPrice != nil && gasPriceInt.BitLen() != 0 {
-
-		// Get the account balance
+	// the sender cannot pay for more gas than its balance allows
+	if transaction.GasPrice.BitLen() != 0 {
 		acc, err := e.d.store.GetAccount(header.StateRoot, transaction.From)
 		if err != nil {
 			return nil, err
 		}
 
-		var accountBalance *big.Int
-		accountBalance = acc.Balance
-
-		available := new(big.Int).Set(accountBalance)
-
+		available := new(big.Int).Set(acc.Balance)
 		if transaction.Value != nil {
-			if valueInt.Cmp(available) >= 0 {
-				return 0, fmt.Errorf("insufficient funds for transfer")
+			if transaction.Value.Cmp(available) >= 0 {
+				return nil, fmt.Errorf("insufficient funds for transfer")
 			}
-
-			available.Sub(available, valueInt)
+			available.Sub(available, transaction.Value)
 		}
 
-		allowance := new(big.Int).Div(available, gasPriceInt)
-
-		// If the allowance is larger than maximum uint64, skip checking
+		allowance := new(big.Int).Div(available, transaction.GasPrice)
 		if allowance.IsUint64() && highEnd > allowance.Uint64() {
 			highEnd = allowance.Uint64()
+			capped = false
 		}
 	}
 
-	if highEnd > types.GasCap.Uint64() {
-		// The high end is greater than the environment gas cap
-		highEnd = types.GasCap.Uint64()
-	}
-
-	gasCap = highEnd
-
-	// Run the transaction with the estimated gas
-	testTransaction := func(gas uint64) (bool, error) {
-		// Create a dummy transaction with the new gas
+	// Run the transaction with the gas, it fails if the gas is lower than its intrinsic gas
+	testTransaction := func(gas uint64) (*runtime.ExecutionResult, error) {
 		txn := transaction.Copy()
 		txn.Gas = gas
 
-		_, failed, err := e.d.store.ApplyTxn(header, txn)
-		if err != nil {
-			return failed, err
+		result, err := e.d.store.ApplyTxn(header, txn, nil)
+		if err == state.ErrIntrinsicGas {
+			return &runtime.ExecutionResult{Err: err}, nil
 		}
+		return result, err
+	}
 
-		return failed, nil
+	// the txn has to be executed with the highest gas
+	result, err := testTransaction(highEnd)
+	if err != nil {
+		return nil, err
+	}
+	if result.Reverted() {
+		return nil, revertError(result.ReturnValue)
+	}
+	if result.Failed() {
+		if !isOutOfGas(result.Err) && result.Err != state.ErrIntrinsicGas {
+			// the txn fails with any gas
+			return nil, fmt.Errorf("unable to execute call: %v", result.Err)
+		}
+		if capped {
+			return nil, errGasCapExceeded(highEnd)
+		}
+		return nil, fmt.Errorf("gas required exceeds allowance (%d)", highEnd)
 	}
 
-	// Start the binary search for the lowest possible gas price
-	for lowEnd <= highEnd {
-		mid := (lowEnd + highEnd) / 2
+	// the txn needs at least the gas it used, the refunds are only paid
+	// once it ends. The txn fails with the low end and succeeds with the high end
+	lowEnd := uint64(0)
+	if result.GasUsed > 0 {
+		lowEnd = result.GasUsed - 1
+	}
+	for lowEnd+1 < highEnd {
+		if err := requestError(ctx); err != nil {
+			return nil, err
+		}
+		mid := lowEnd + (highEnd-lowEnd)/2
 
-		failed, err := testTransaction(mid)
+		result, err := testTransaction(mid)
 		if err != nil {
-			return 0, err
+			return nil, err
 		}
-
-		if failed {
-			// If the transaction failed => increase the gas
-			lowEnd = mid + 1
+		if result.Failed() {
+			lowEnd = mid
 		} else {
-			// If the transaction didn't fail => lower the gas
-			highEnd = mid - 1
+			highEnd = mid
 		}
 	}
 
-	// we stopped the binary search at the last gas limit
-	// at which the txn could not be executed
-	highEnd += 1
+	return hex.EncodeUint64(highEnd), nil
+}
+
+type accessListResult struct {
+	AccessList types.AccessList `json:"accessList"`
+	GasUsed    argUint64        `json:"gasUsed"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// CreateAccessList simulates the txn and returns the access list with the addresses and
+// storage slots it accesses, and the gas used by the txn with the access list. The txn is
+// executed again with the access list found until it does not access any new slot
+func (e *Eth) CreateAccessList(arg *txnArgs, rawNum *BlockNumber) (interface{}, error) {
+	transaction, err := e.d.decodeTxn(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	number := LatestBlockNumber
+	if rawNum != nil {
+		number = *rawNum
+	}
+	header, err := e.d.getStateHeaderImpl(number)
+	if err != nil {
+		return nil, err
+	}
+
+	// the sender, the receiver and the precompiled contracts are always warm
+	excluded := []types.Address{transaction.From}
+	if transaction.To != nil {
+		excluded = append(excluded, *transaction.To)
+	} else {
+		excluded = append(excluded, crypto.CreateAddress(transaction.From, transaction.Nonce))
+	}
+	excluded = append(excluded, state.PrecompileAddresses...)
 
-	// Check the edge case if even the highest cap is not enough to complete the transaction
-	if highEnd == gasCap {
-		failed, err := testTransaction(gasCap)
+	list := transaction.AccessList
+	for {
+		tracer := runtime.NewAccessListTracer(list, excluded)
+
+		txn := transaction.Copy()
+		txn.AccessList = list
 
+		_, gasUsed, failed, err := e.d.store.TraceCall(header, txn, tracer)
 		if err != nil {
-			return 0, err
+			return nil, err
 		}
 
-		if failed {
-			return 0, fmt.Errorf("gas required exceeds allowance (%d)", gasCap)
+		accessList := tracer.AccessList()
+		if reflect.DeepEqual(list, accessList) {
+			res := &accessListResult{
+				AccessList: accessList,
+				GasUsed:    argUint64(gasUsed),
+			}
+			if failed {
+				res.Error = "unable to execute call"
+			}
+			return res, nil
 		}
+		list = accessList
 	}
-
-	return hex.EncodeUint64(highEnd), nil
 }
 
-// GetLogs returns an array of logs matching the filter options
-func (e *Eth) GetLogs(filterOptions *LogFilter) (interface{}, error) {
+// GetLogs returns an array of logs matching the filter options. The blocks are scanned
+// within the limits of the dispatcher, and the query fails once any of them is exceeded
+// or once the request is cancelled
+func (e *Eth) GetLogs(ctx context.Context, filterOptions *LogFilter) (interface{}, error) {
+	limits := e.d.logsLimits
+	if limits == nil {
+		limits = &LogsLimits{}
+	}
+	var deadline time.Time
+	if limits.ScanTimeout != 0 {
+		deadline = time.Now().Add(limits.ScanTimeout)
+	}
+
 	var result []*Log
 	parseReceipts := func(header *types.Header) error {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return errLogsScanTimeout
+		}
+		if err := requestError(ctx); err != nil {
+			return err
+		}
 		receipts, err := e.d.store.GetReceiptsByHash(header.Hash)
 		if err != nil {
 			return err
 		}
 
+		logIndex := 0
 		for indx, receipt := range receipts {
-			for logIndx, log := range receipt.Logs {
+			for _, log := range receipt.Logs {
 				if filterOptions.Match(log) {
 					result = append(result, &Log{
 						Address:     log.Address,
@@ -379,9 +683,13 @@ func (e *Eth) GetLogs(filterOptions *LogFilter) (interface{}, error) {
 						BlockHash:   header.Hash,
 						TxHash:      receipt.TxHash,
 						TxIndex:     argUint64(indx),
-						LogIndex:    argUint64(logIndx),
+						LogIndex:    argUint64(logIndex),
 					})
+					if limits.MaxResults != 0 && uint64(len(result)) > limits.MaxResults {
+						return errLogsMaxResults(limits.MaxResults)
+					}
 				}
+				logIndex++
 			}
 		}
 		return nil
@@ -398,43 +706,76 @@ func (e *Eth) GetLogs(filterOptions *LogFilter) (interface{}, error) {
 		return result, nil
 	}
 
-	head := e.d.store.Header().Number
-
-	resolveNum := func(num BlockNumber) uint64 {
-		if num == PendingBlockNumber || num == EarliestBlockNumber {
-			num = LatestBlockNumber
-		}
-		if num == LatestBlockNumber {
-			return head
-		}
-		return uint64(num)
+	// the logs of the pending block are not indexed
+	from, err := e.d.resolveBlockNumber(filterOptions.fromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := e.d.resolveBlockNumber(filterOptions.toBlock)
+	if err != nil {
+		return nil, err
 	}
-
-	from := resolveNum(filterOptions.fromBlock)
-	to := resolveNum(filterOptions.toBlock)
 
 	if to < from {
 		return nil, fmt.Errorf("incorrect range")
 	}
-	for i := from; i < to; i++ {
+	if limits.BlockRange != 0 && to-from >= limits.BlockRange {
+		return nil, errLogsBlockRange(limits.BlockRange)
+	}
+
+	matcher := filterOptions.bloomMatcher()
+	sections := e.d.store.BloomSections()
+
+	for i := from; i <= to; {
+		if section := i / bloombits.SectionSize; section < sections && !matcher.Empty() {
+			// find the blocks of the section that might include logs in the bloom bits index
+			numbers, err := matcher.Match(section, func(bit uint) ([]byte, error) {
+				bits, ok := e.d.store.ReadBloomBits(bit, section)
+				if !ok {
+					return nil, fmt.Errorf("bloom bits %d of section %d not found", bit, section)
+				}
+				return bits, nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, num := range numbers {
+				if num < i || num > to || num == 0 {
+					continue
+				}
+				header, ok := e.d.store.GetHeaderByNumber(num)
+				if !ok {
+					return nil, fmt.Errorf("header %d not found", num)
+				}
+				if err := parseReceipts(header); err != nil {
+					return nil, err
+				}
+			}
+			i = (section + 1) * bloombits.SectionSize
+			continue
+		}
+
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return nil, errLogsScanTimeout
+		}
 		header, ok := e.d.store.GetHeaderByNumber(i)
 		if !ok {
 			break
 		}
-		if header.Number == 0 {
-			// do not check logs in genesis
-			continue
-		}
-		if err := parseReceipts(header); err != nil {
-			return nil, err
+		// do not check logs in genesis
+		if header.Number != 0 && filterOptions.MatchHeader(header) {
+			if err := parseReceipts(header); err != nil {
+				return nil, err
+			}
 		}
+		i++
 	}
 	return result, nil
 }
 
 // GetBalance returns the account's balance at the referenced block
-func (e *Eth) GetBalance(address types.Address, number BlockNumber) (interface{}, error) {
-	header, err := e.d.getBlockHeaderImpl(number)
+func (e *Eth) GetBalance(address types.Address, block BlockNumberOrHash) (interface{}, error) {
+	header, err := e.d.getStateHeaderByNumberOrHash(block)
 	if err != nil {
 		return nil, err
 	}
@@ -449,8 +790,8 @@ func (e *Eth) GetBalance(address types.Address, number BlockNumber) (interface{}
 }
 
 // GetTransactionCount returns account nonce
-func (e *Eth) GetTransactionCount(address types.Address, number BlockNumber) (interface{}, error) {
-	nonce, err := e.d.getNextNonce(address, number)
+func (e *Eth) GetTransactionCount(address types.Address, block BlockNumberOrHash) (interface{}, error) {
+	nonce, err := e.d.getNextNonce(address, block)
 	if err != nil {
 		return nil, err
 	}
@@ -458,8 +799,8 @@ func (e *Eth) GetTransactionCount(address types.Address, number BlockNumber) (in
 }
 
 // GetCode returns account code at given block number
-func (e *Eth) GetCode(address types.Address, number BlockNumber) (interface{}, error) {
-	header, err := e.d.getBlockHeaderImpl(number)
+func (e *Eth) GetCode(address types.Address, block BlockNumberOrHash) (interface{}, error) {
+	header, err := e.d.getStateHeaderByNumberOrHash(block)
 	if err != nil {
 		return nil, err
 	}
@@ -481,17 +822,36 @@ func (e *Eth) GetCode(address types.Address, number BlockNumber) (interface{}, e
 
 // NewFilter creates a filter object, based on filter options, to notify when the state changes (logs).
 func (e *Eth) NewFilter(filter *LogFilter) (interface{}, error) {
-	return e.d.filterManager.NewLogFilter(filter, nil), nil
+	return e.d.filterManager.NewLogFilter(filter, nil)
 }
 
 // NewBlockFilter creates a filter in the node, to notify when a new block arrives
 func (e *Eth) NewBlockFilter() (interface{}, error) {
-	return e.d.filterManager.NewBlockFilter(nil), nil
+	return e.d.filterManager.NewBlockFilter(nil)
+}
+
+// NewPendingTransactionFilter creates a filter in the node, to notify when new txns are promoted in the pool
+func (e *Eth) NewPendingTransactionFilter() (interface{}, error) {
+	return e.d.filterManager.NewPendingTxnFilter(nil)
 }
 
 // GetFilterChanges is a polling method for a filter, which returns an array of logs which occurred since last poll.
 func (e *Eth) GetFilterChanges(id string) (interface{}, error) {
-	return e.d.filterManager.GetFilterChanges(id)
+	res, err := e.d.filterManager.GetFilterChanges(id)
+	if err != nil {
+		return nil, err
+	}
+	// the updates are already encoded
+	return json.RawMessage(res), nil
+}
+
+// GetFilterLogs returns all the logs of the chain that match a filter installed with eth_newFilter
+func (e *Eth) GetFilterLogs(ctx context.Context, id string) (interface{}, error) {
+	filter, err := e.d.filterManager.GetLogFilter(id)
+	if err != nil {
+		return nil, err
+	}
+	return e.GetLogs(ctx, filter)
 }
 
 // UninstallFilter uninstalls a filter with given ID
@@ -2,18 +2,36 @@ package jsonrpc
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/0xPolygon/minimal/blockchain"
+	"github.com/0xPolygon/minimal/blockchain/bloombits"
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	chainpkg "github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/crypto"
+	"github.com/0xPolygon/minimal/gasprice"
 	"github.com/0xPolygon/minimal/helper/hex"
 	"github.com/0xPolygon/minimal/state"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/state/runtime/evm"
+	"github.com/0xPolygon/minimal/txpool"
 	"github.com/0xPolygon/minimal/types"
 )
 
+// numberOrHash refers to a block by its number or tag
+func numberOrHash(n BlockNumber) BlockNumberOrHash {
+	return BlockNumberOrHash{BlockNumber: &n}
+}
+
 type mockAccount2 struct {
 	store   *mockAccountStore
 	address types.Address
@@ -63,6 +81,10 @@ func (m *mockAccountStore) Header() *types.Header {
 	return &types.Header{}
 }
 
+func (m *mockAccountStore) ResolveBlockTag(tag blockchain.BlockTag) (*types.Header, error) {
+	return m.Header(), nil
+}
+
 func (m *mockAccountStore) GetAccount(root types.Hash, addr types.Address) (*state.Account, error) {
 	acct, ok := m.accounts[addr]
 	if !ok {
@@ -138,6 +160,13 @@ func (m *mockBlockStore2) Header() *types.Header {
 	return m.blocks[len(m.blocks)-1].Header
 }
 
+func (m *mockBlockStore2) ResolveBlockTag(tag blockchain.BlockTag) (*types.Header, error) {
+	if tag == blockchain.BlockTagEarliest {
+		return m.blocks[0].Header, nil
+	}
+	return m.Header(), nil
+}
+
 func TestEth_Block_GetBlockByNumber(t *testing.T) {
 	store := &mockBlockStore2{}
 	for i := 0; i < 10; i++ {
@@ -155,7 +184,10 @@ func TestEth_Block_GetBlockByNumber(t *testing.T) {
 		err      bool
 	}{
 		{LatestBlockNumber, false},
-		{EarliestBlockNumber, true},
+		{EarliestBlockNumber, false},
+		{SafeBlockNumber, false},
+		{FinalizedBlockNumber, false},
+		{PendingBlockNumber, false},
 		{BlockNumber(-50), true},
 		{BlockNumber(0), false},
 		{BlockNumber(2), false},
@@ -189,6 +221,209 @@ func TestEth_Block_GetBlockByHash(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestEth_Compatibility(t *testing.T) {
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), newMockStore())
+
+	handle := func(method string, res interface{}) {
+		resp, err := dispatcher.Handle([]byte(`{"id": 1, "method": "` + method + `", "params": []}`))
+		assert.NoError(t, err)
+		assert.NoError(t, expectJSONResult(resp, res))
+	}
+
+	var version, hashrate string
+	handle("eth_protocolVersion", &version)
+	assert.Equal(t, "0x41", version)
+	handle("eth_hashrate", &hashrate)
+	assert.Equal(t, "0x0", hashrate)
+
+	var mining bool
+	handle("eth_mining", &mining)
+	assert.False(t, mining)
+
+	// no accounts unless the dev mode is enabled
+	var accounts []types.Address
+	handle("eth_accounts", &accounts)
+	assert.NotNil(t, accounts)
+	assert.Empty(t, accounts)
+
+	dispatcher.mining = true
+	dispatcher.accounts = []types.Address{addr0}
+
+	handle("eth_mining", &mining)
+	assert.True(t, mining)
+	handle("eth_accounts", &accounts)
+	assert.Equal(t, []types.Address{addr0}, accounts)
+}
+
+type mockCountingBlockStore struct {
+	mockBlockStore2
+	reads int
+}
+
+func (m *mockCountingBlockStore) GetBlockByHash(hash types.Hash, full bool) (*types.Block, bool) {
+	m.reads++
+	return m.mockBlockStore2.GetBlockByHash(hash, full)
+}
+
+func TestEth_Block_Cache(t *testing.T) {
+	txn := &types.Transaction{Value: big.NewInt(0), GasPrice: big.NewInt(0), From: addr0}
+	txn.ComputeHash()
+
+	store := &mockCountingBlockStore{}
+	store.add(&types.Block{
+		Header:       &types.Header{Hash: hash1, Number: 0},
+		Transactions: []*types.Transaction{txn},
+	})
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	res, err := dispatcher.endpoints.Eth.GetBlockByNumber(BlockNumber(0), true)
+	assert.NoError(t, err)
+	assert.Equal(t, addr0, res.(*block).Transactions[0].From)
+
+	// the full block is served from the cache by number and by hash
+	res2, err := dispatcher.endpoints.Eth.GetBlockByHash(hash1, true)
+	assert.NoError(t, err)
+	assert.Same(t, res, res2)
+	assert.Equal(t, 1, store.reads)
+
+	// the blocks without the txns are not cached
+	_, err = dispatcher.endpoints.Eth.GetBlockByHash(hash1, false)
+	assert.NoError(t, err)
+	_, err = dispatcher.endpoints.Eth.GetBlockByHash(hash1, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, store.reads)
+}
+
+type mockTxLookupStore struct {
+	mockBlockStore2
+	lookups map[types.Hash]*storage.TxLookup
+}
+
+func (m *mockTxLookupStore) ReadTxLookup(hash types.Hash) (*storage.TxLookup, bool) {
+	lookup, ok := m.lookups[hash]
+	return lookup, ok
+}
+
+func TestEth_Block_GetTransactionByHash(t *testing.T) {
+	txn0 := &types.Transaction{Value: big.NewInt(0), GasPrice: big.NewInt(0), Nonce: 0}
+	txn0.ComputeHash()
+	txn1 := &types.Transaction{Value: big.NewInt(0), GasPrice: big.NewInt(0), Nonce: 1}
+	txn1.ComputeHash()
+
+	store := &mockTxLookupStore{}
+	store.add(&types.Block{
+		Header: &types.Header{
+			Hash:   hash1,
+			Number: 5,
+		},
+		Transactions: []*types.Transaction{txn0, txn1},
+	})
+	store.lookups = map[types.Hash]*storage.TxLookup{
+		txn1.Hash: {BlockHash: hash1, BlockNumber: 5, Index: 1},
+		// stale lookup pointing to another txn of the block
+		hash2: {BlockHash: hash1, BlockNumber: 5, Index: 0},
+	}
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	res, err := dispatcher.endpoints.Eth.GetTransactionByHash(txn1.Hash)
+	assert.NoError(t, err)
+
+	txn := res.(*transaction)
+	assert.Equal(t, txn1.Hash, txn.Hash)
+	assert.Equal(t, hash1, *txn.BlockHash)
+	assert.Equal(t, argUint64(5), *txn.BlockNumber)
+	assert.Equal(t, argUint64(1), *txn.TxIndex)
+
+	res, err = dispatcher.endpoints.Eth.GetTransactionByHash(hash2)
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+}
+
+type mockReceiptsStore struct {
+	mockBlockStore2
+	receipts map[types.Hash][]*types.Receipt
+}
+
+func (m *mockReceiptsStore) GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error) {
+	receipts, ok := m.receipts[hash]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return receipts, nil
+}
+
+func TestEth_Block_GetBlockReceipts(t *testing.T) {
+	txn0 := &types.Transaction{Value: big.NewInt(0), GasPrice: big.NewInt(0), Nonce: 0}
+	txn0.ComputeHash()
+	txn1 := &types.Transaction{Value: big.NewInt(0), GasPrice: big.NewInt(0), Nonce: 1}
+	txn1.ComputeHash()
+
+	store := &mockReceiptsStore{}
+	store.add(&types.Block{
+		Header: &types.Header{
+			Hash:   hash1,
+			Number: 0,
+		},
+	}, &types.Block{
+		Header: &types.Header{
+			Hash:   hash2,
+			Number: 1,
+		},
+		Transactions: []*types.Transaction{txn0, txn1},
+	})
+	store.receipts = map[types.Hash][]*types.Receipt{
+		hash2: {
+			{GasUsed: 1, Logs: []*types.Log{{}, {}}},
+			{GasUsed: 2, Logs: []*types.Log{{}}},
+		},
+	}
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	getReceipts := func(number BlockNumber) (interface{}, error) {
+		return dispatcher.endpoints.Eth.GetBlockReceipts(BlockNumberOrHash{BlockNumber: &number})
+	}
+
+	res, err := getReceipts(LatestBlockNumber)
+	assert.NoError(t, err)
+
+	receipts := res.([]*receipt)
+	assert.Len(t, receipts, 2)
+	for indx, txn := range []*types.Transaction{txn0, txn1} {
+		assert.Equal(t, txn.Hash, receipts[indx].TxHash)
+		assert.Equal(t, argUint64(indx), receipts[indx].TxIndex)
+		assert.Equal(t, hash2, receipts[indx].BlockHash)
+		assert.Equal(t, argUint64(indx+1), receipts[indx].GasUsed)
+	}
+	// the logs are indexed in the block
+	assert.Equal(t, argUint64(1), receipts[0].Logs[1].LogIndex)
+	assert.Equal(t, argUint64(2), receipts[1].Logs[0].LogIndex)
+	assert.Equal(t, argUint64(1), receipts[1].Logs[0].TxIndex)
+
+	// the blocks without transactions might not have receipts
+	res, err = getReceipts(BlockNumber(0))
+	assert.NoError(t, err)
+	assert.Len(t, res.([]*receipt), 0)
+
+	_, err = getReceipts(BlockNumber(2))
+	assert.Error(t, err)
+
+	// the block can be referred to by its hash
+	for _, param := range []string{`"` + hash2.String() + `"`, `{"blockHash": "` + hash2.String() + `"}`} {
+		resp, err := dispatcher.Handle([]byte(`{"id": 1, "method": "eth_getBlockReceipts", "params": [` + param + `]}`))
+		assert.NoError(t, err)
+
+		var receipts []*receipt
+		assert.NoError(t, expectJSONResult(resp, &receipts))
+		assert.Len(t, receipts, 2)
+	}
+
+	_, err = dispatcher.endpoints.Eth.GetBlockReceipts(BlockNumberOrHash{BlockHash: &hash3})
+	assert.Error(t, err)
+}
+
 func TestEth_Block_BlockNumber(t *testing.T) {
 	store := &mockBlockStore2{}
 	store.add(&types.Block{
@@ -203,6 +438,231 @@ func TestEth_Block_BlockNumber(t *testing.T) {
 	assert.Equal(t, argUintPtr(10), num)
 }
 
+type mockFeeHistoryStore struct {
+	mockBlockStore2
+	newest uint64
+}
+
+func (m *mockFeeHistoryStore) FeeHistory(blocks uint64, newest uint64, percentiles []float64) (*gasprice.FeeHistory, error) {
+	m.newest = newest
+	history := &gasprice.FeeHistory{
+		OldestBlock:   newest + 1 - blocks,
+		BaseFees:      []*big.Int{big.NewInt(0), big.NewInt(0)},
+		GasUsedRatios: []float64{0.5},
+	}
+	if len(percentiles) != 0 {
+		history.Rewards = [][]*big.Int{{big.NewInt(10), big.NewInt(20)}}
+	}
+	return history, nil
+}
+
+func TestEth_FeeHistory(t *testing.T) {
+	store := &mockFeeHistoryStore{}
+	store.add(&types.Block{Header: &types.Header{Number: 0}})
+	store.add(&types.Block{Header: &types.Header{Number: 1}})
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	resp, err := dispatcher.Handle([]byte(`{"id": 1, "method": "eth_feeHistory", "params": ["0x1", "latest", [10, 90]]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), store.newest)
+
+	var res struct {
+		Result json.RawMessage `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(resp, &res))
+	assert.JSONEq(t, `{
+		"oldestBlock": "0x1",
+		"baseFeePerGas": ["0x0", "0x0"],
+		"gasUsedRatio": [0.5],
+		"reward": [["0xa", "0x14"]]
+	}`, string(res.Result))
+
+	// the rewards are omitted without percentiles
+	resp, err = dispatcher.Handle([]byte(`{"id": 1, "method": "eth_feeHistory", "params": ["0x1", "0x0"]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), store.newest)
+	assert.NotContains(t, string(resp), "reward")
+}
+
+type mockLogsStore struct {
+	mockBlockStore2
+	receipts     map[types.Hash][]*types.Receipt
+	gen          *bloombits.Generator
+	receiptsRead int
+}
+
+func (m *mockLogsStore) GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error) {
+	m.receiptsRead++
+	return m.receipts[hash], nil
+}
+
+func (m *mockLogsStore) GetHeaderByNumber(blockNumber uint64) (*types.Header, bool) {
+	// the blocks are added in order
+	if blockNumber >= uint64(len(m.blocks)) {
+		return nil, false
+	}
+	return m.blocks[blockNumber].Header, true
+}
+
+func (m *mockLogsStore) BloomSections() uint64 {
+	return 1
+}
+
+func (m *mockLogsStore) ReadBloomBits(bit uint, section uint64) ([]byte, bool) {
+	if section != 0 {
+		return nil, false
+	}
+	bits, err := m.gen.Bitset(bit)
+	return bits, err == nil
+}
+
+func TestEth_Block_GetLogs_BloomBits(t *testing.T) {
+	addr1 := types.StringToAddress("1")
+	addr2 := types.StringToAddress("2")
+
+	// the first section is indexed in the bloom bits
+	logs := map[uint64]types.Address{
+		5:                         addr1,
+		100:                       addr2,
+		bloombits.SectionSize + 3: addr1,
+		bloombits.SectionSize + 5: addr2,
+	}
+
+	store := &mockLogsStore{
+		receipts: map[types.Hash][]*types.Receipt{},
+		gen:      bloombits.NewGenerator(),
+	}
+	for i := uint64(0); i < bloombits.SectionSize+10; i++ {
+		header := &types.Header{
+			Number:       i,
+			ReceiptsRoot: types.EmptyRootHash,
+		}
+		var receipts []*types.Receipt
+		if addr, ok := logs[i]; ok {
+			receipts = []*types.Receipt{
+				{
+					Logs: []*types.Log{
+						{Address: addr},
+					},
+				},
+			}
+			header.LogsBloom = types.CreateBloom(receipts)
+			header.ReceiptsRoot = types.StringToHash("receipts")
+		}
+		header.ComputeHash()
+
+		store.receipts[header.Hash] = receipts
+		store.add(&types.Block{Header: header})
+		if i < bloombits.SectionSize {
+			assert.NoError(t, store.gen.AddBloom(i, header.LogsBloom))
+		}
+	}
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	res, err := dispatcher.endpoints.Eth.GetLogs(context.Background(), &LogFilter{
+		fromBlock: 0,
+		toBlock:   LatestBlockNumber,
+		Addresses: []types.Address{addr1},
+	})
+	assert.NoError(t, err)
+
+	found := res.([]*Log)
+	assert.Len(t, found, 2)
+	assert.Equal(t, argUint64(5), found[0].BlockNumber)
+	assert.Equal(t, argUint64(bloombits.SectionSize+3), found[1].BlockNumber)
+
+	// only the receipts of the blocks that match the blooms are read
+	assert.Equal(t, 2, store.receiptsRead)
+
+	res, err = dispatcher.endpoints.Eth.GetLogs(context.Background(), &LogFilter{
+		fromBlock: 6,
+		toBlock:   BlockNumber(bloombits.SectionSize + 5),
+	})
+	assert.NoError(t, err)
+
+	found = res.([]*Log)
+	assert.Len(t, found, 3)
+	assert.Equal(t, argUint64(100), found[0].BlockNumber)
+	assert.Equal(t, argUint64(bloombits.SectionSize+5), found[2].BlockNumber)
+}
+
+type mockUnindexedLogsStore struct {
+	mockLogsStore
+}
+
+func (m *mockUnindexedLogsStore) BloomSections() uint64 {
+	return 0
+}
+
+func TestEth_Block_GetLogs_Limits(t *testing.T) {
+	addr1 := types.StringToAddress("1")
+
+	// every block has two logs
+	store := &mockUnindexedLogsStore{}
+	store.receipts = map[types.Hash][]*types.Receipt{}
+	for i := uint64(0); i < 10; i++ {
+		receipts := []*types.Receipt{
+			{
+				Logs: []*types.Log{{Address: addr1}, {Address: addr1}},
+			},
+		}
+		header := &types.Header{
+			Number:       i,
+			ReceiptsRoot: types.StringToHash("receipts"),
+			LogsBloom:    types.CreateBloom(receipts),
+		}
+		header.ComputeHash()
+
+		store.receipts[header.Hash] = receipts
+		store.add(&types.Block{Header: header})
+	}
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+	dispatcher.logsLimits = &LogsLimits{BlockRange: 5, MaxResults: 6}
+
+	getLogs := func(from, to BlockNumber) (int, error) {
+		res, err := dispatcher.endpoints.Eth.GetLogs(context.Background(), &LogFilter{fromBlock: from, toBlock: to})
+		if err != nil {
+			return 0, err
+		}
+		return len(res.([]*Log)), nil
+	}
+
+	// 3 blocks with logs (the genesis is skipped)
+	num, err := getLogs(0, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, num)
+
+	_, err = getLogs(1, 6)
+	assert.Equal(t, errLogsBlockRange(5), err)
+
+	// the scan stops once there are more results than the max
+	store.receiptsRead = 0
+	_, err = getLogs(1, 5)
+	assert.Equal(t, errLogsMaxResults(6), err)
+	assert.Equal(t, 4, store.receiptsRead)
+
+	// the scan stops once the timeout is exceeded
+	dispatcher.logsLimits = &LogsLimits{ScanTimeout: time.Nanosecond}
+	_, err = getLogs(1, 9)
+	assert.Equal(t, errLogsScanTimeout, err)
+
+	// the limits are disabled without a config
+	dispatcher.logsLimits = nil
+	num, err = getLogs(0, LatestBlockNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, 18, num)
+
+	// the scan stops once the request is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	store.receiptsRead = 0
+	_, err = dispatcher.endpoints.Eth.GetLogs(ctx, &LogFilter{fromBlock: 1, toBlock: 9})
+	assert.Equal(t, errShuttingDown, err)
+	assert.Equal(t, 0, store.receiptsRead)
+}
+
 func TestEth_Block_GetLogs(t *testing.T) {
 
 	/*
@@ -291,6 +751,7 @@ func TestEth_Block_GetLogs(t *testing.T) {
 
 			t.Run(testCase.name, func(t *testing.T) {
 				foundLogs, logError := dispatcher.endpoints.Eth.GetLogs(
+					context.Background(),
 					testCase.filterOptions,
 				)
 
@@ -317,12 +778,12 @@ func TestEth_State_GetBalance(t *testing.T) {
 
 	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
 
-	balance, err := dispatcher.endpoints.Eth.GetBalance(addr0, LatestBlockNumber)
+	balance, err := dispatcher.endpoints.Eth.GetBalance(addr0, numberOrHash(LatestBlockNumber))
 	assert.NoError(t, err)
 	assert.Equal(t, balance, argBigPtr(big.NewInt(100)))
 
 	// address not found
-	balance, err = dispatcher.endpoints.Eth.GetBalance(addr1, LatestBlockNumber)
+	balance, err = dispatcher.endpoints.Eth.GetBalance(addr1, numberOrHash(LatestBlockNumber))
 	assert.NoError(t, err)
 	assert.Equal(t, balance, argUintPtr(0))
 }
@@ -335,12 +796,12 @@ func TestEth_State_GetTransactionCount(t *testing.T) {
 
 	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
 
-	balance, err := dispatcher.endpoints.Eth.GetTransactionCount(addr0, LatestBlockNumber)
+	balance, err := dispatcher.endpoints.Eth.GetTransactionCount(addr0, numberOrHash(LatestBlockNumber))
 	assert.NoError(t, err)
 	assert.Equal(t, balance, argUintPtr(100))
 
 	// address not found
-	_, err = dispatcher.endpoints.Eth.GetTransactionCount(addr1, LatestBlockNumber)
+	_, err = dispatcher.endpoints.Eth.GetTransactionCount(addr1, numberOrHash(LatestBlockNumber))
 	assert.Error(t, err)
 }
 
@@ -355,7 +816,7 @@ func TestEth_State_GetCode(t *testing.T) {
 	acct0.Code(code0)
 
 	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
-	code, err := dispatcher.endpoints.Eth.GetCode(acct0.address, LatestBlockNumber)
+	code, err := dispatcher.endpoints.Eth.GetCode(acct0.address, numberOrHash(LatestBlockNumber))
 	assert.NoError(t, err)
 	assert.Equal(t, code, argBytesPtr(code0))
 }
@@ -367,12 +828,12 @@ func TestEth_State_GetStorageAt(t *testing.T) {
 	acct0.Storage(hash1, hash1)
 
 	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
-	res, err := dispatcher.endpoints.Eth.GetStorageAt(acct0.address, hash1, LatestBlockNumber)
+	res, err := dispatcher.endpoints.Eth.GetStorageAt(acct0.address, hash1, numberOrHash(LatestBlockNumber))
 	assert.NoError(t, err)
 	assert.Equal(t, res, argBytesPtr(hash1.Bytes()))
 
 	// slot not found
-	_, err = dispatcher.endpoints.Eth.GetStorageAt(acct0.address, hash2, LatestBlockNumber)
+	_, err = dispatcher.endpoints.Eth.GetStorageAt(acct0.address, hash2, numberOrHash(LatestBlockNumber))
 	assert.Error(t, err)
 }
 
@@ -391,6 +852,133 @@ func (m *mockStoreTxn) GetNonce(addr types.Address) (uint64, bool) {
 	return 1, false
 }
 
+type mockPrunedStore struct {
+	mockAccountStore
+	pruned uint64
+}
+
+func (m *mockPrunedStore) GetHeaderByNumber(n uint64) (*types.Header, bool) {
+	return &types.Header{Number: n, StateRoot: types.BytesToHash([]byte{byte(n)})}, true
+}
+
+func (m *mockPrunedStore) HasState(root types.Hash) bool {
+	return uint64(root[types.HashLength-1]) > m.pruned
+}
+
+func TestEth_State_Pruned(t *testing.T) {
+	store := &mockPrunedStore{pruned: 5}
+
+	acct0 := store.AddAccount(addr0)
+	acct0.Balance(100)
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	// the historical states are available until they are pruned
+	balance, err := dispatcher.endpoints.Eth.GetBalance(addr0, numberOrHash(BlockNumber(6)))
+	assert.NoError(t, err)
+	assert.Equal(t, balance, argBigPtr(big.NewInt(100)))
+
+	_, err = dispatcher.endpoints.Eth.GetBalance(addr0, numberOrHash(BlockNumber(5)))
+	assert.Equal(t, err, stateNotAvailable(5))
+
+	_, err = dispatcher.endpoints.Eth.GetTransactionCount(addr0, numberOrHash(BlockNumber(5)))
+	assert.Equal(t, err, stateNotAvailable(5))
+
+	_, err = dispatcher.endpoints.Eth.GetCode(addr0, numberOrHash(BlockNumber(5)))
+	assert.Equal(t, err, stateNotAvailable(5))
+
+	_, err = dispatcher.endpoints.Eth.GetStorageAt(addr0, hash1, numberOrHash(BlockNumber(5)))
+	assert.Equal(t, err, stateNotAvailable(5))
+}
+
+// mockTagStore resolves each tag to a different block, whose state
+// has the accounts with the number of the block as balance and nonce
+type mockTagStore struct {
+	nullBlockchainInterface
+}
+
+func tagStoreHeader(n uint64) *types.Header {
+	return &types.Header{Number: n, StateRoot: types.BytesToHash([]byte{byte(n)})}
+}
+
+func (m *mockTagStore) ResolveBlockTag(tag blockchain.BlockTag) (*types.Header, error) {
+	numbers := map[blockchain.BlockTag]uint64{
+		blockchain.BlockTagEarliest:  0,
+		blockchain.BlockTagFinalized: 7,
+		blockchain.BlockTagSafe:      8,
+		blockchain.BlockTagLatest:    10,
+		blockchain.BlockTagPending:   11,
+	}
+	return tagStoreHeader(numbers[tag]), nil
+}
+
+func (m *mockTagStore) GetHeaderByNumber(n uint64) (*types.Header, bool) {
+	return tagStoreHeader(n), true
+}
+
+func (m *mockTagStore) GetBlockByHash(hash types.Hash, full bool) (*types.Block, bool) {
+	if hash != hash1 {
+		return nil, false
+	}
+	return &types.Block{Header: tagStoreHeader(3)}, true
+}
+
+func (m *mockTagStore) GetAccount(root types.Hash, addr types.Address) (*state.Account, error) {
+	n := root[types.HashLength-1]
+	return &state.Account{Balance: big.NewInt(int64(n)), Nonce: uint64(n)}, nil
+}
+
+func TestEth_State_BlockTags(t *testing.T) {
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), &mockTagStore{})
+
+	cases := map[string]string{
+		`, "latest"`:                 "0xa",
+		`, "pending"`:                "0xb",
+		`, "earliest"`:               "0x0",
+		`, "safe"`:                   "0x8",
+		`, "finalized"`:              "0x7",
+		`, "0x5"`:                    "0x5",
+		`, {"blockNumber": "safe"}`:  "0x8",
+		`, "` + hash1.String() + `"`: "0x3",
+		`, {"blockHash": "` + hash1.String() + `"}`: "0x3",
+
+		// the latest block if omitted
+		``: "0xa",
+	}
+	for _, method := range []string{"eth_getBalance", "eth_getTransactionCount"} {
+		for block, expected := range cases {
+			resp, err := dispatcher.Handle([]byte(`{"id": 1, "method": "` + method + `", "params": ["` + addr0.String() + `"` + block + `]}`))
+			assert.NoError(t, err, method+block)
+			assert.Contains(t, string(resp), `"result":"`+expected+`"`, method+block)
+		}
+	}
+
+	// the block hash is not found
+	_, err := dispatcher.Handle([]byte(`{"id": 1, "method": "eth_getBalance", "params": ["` + addr0.String() + `", "` + hash2.String() + `"]}`))
+	assert.Error(t, err)
+}
+
+func TestEth_State_BlockRange(t *testing.T) {
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), &mockTagStore{})
+
+	// the pending block is not written in the chain
+	for num, expected := range map[BlockNumber]uint64{
+		PendingBlockNumber:   10,
+		LatestBlockNumber:    10,
+		SafeBlockNumber:      8,
+		FinalizedBlockNumber: 7,
+		EarliestBlockNumber:  0,
+		BlockNumber(5):       5,
+	} {
+		n, err := dispatcher.resolveBlockNumber(num)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, n)
+	}
+
+	_, err := dispatcher.resolveBlockNumber(BlockNumber(-10))
+	assert.Error(t, err)
+}
+
 func TestEth_TxnPool_SendRawTransaction(t *testing.T) {
 	store := &mockStoreTxn{}
 	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
@@ -426,3 +1014,494 @@ func TestEth_TxnPool_SendTransaction(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEqual(t, store.txn.Hash, types.ZeroHash)
 }
+
+type mockAccessListStore struct {
+	nullBlockchainInterface
+}
+
+func (m *mockAccessListStore) GetHeaderByNumber(n uint64) (*types.Header, bool) {
+	return &types.Header{Number: n}, true
+}
+
+func (m *mockAccessListStore) TraceCall(header *types.Header, txn *types.Transaction, tracer runtime.Tracer) ([]byte, uint64, bool, error) {
+	// the slot is cheaper to access once it is in the access list
+	gas := uint64(23100)
+	if txn.AccessList.StorageKeys() != 0 {
+		gas = 21000 + 2400 + 1900 + 100
+	}
+	tracer.CaptureState(&runtime.TraceStep{
+		Op:      0x54,
+		Address: *txn.To,
+		Stack:   []*big.Int{big.NewInt(1)},
+	})
+	return nil, gas, false, nil
+}
+
+func TestEth_CreateAccessList(t *testing.T) {
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), &mockAccessListStore{})
+
+	to := types.StringToAddress("2")
+	arg := &txnArgs{
+		From:     &addr0,
+		To:       &to,
+		GasPrice: argBytesPtr([]byte{0x1}),
+		Nonce:    argUintPtr(0),
+	}
+
+	number := BlockNumber(1)
+	res, err := dispatcher.endpoints.Eth.CreateAccessList(arg, &number)
+	assert.NoError(t, err)
+
+	result := res.(*accessListResult)
+	assert.Equal(t, types.AccessList{
+		{Address: to, StorageKeys: []types.Hash{types.StringToHash("1")}},
+	}, result.AccessList)
+	assert.Equal(t, argUint64(25400), result.GasUsed)
+	assert.Empty(t, result.Error)
+}
+
+type mockOverrideStore struct {
+	mockAccessListStore
+
+	override state.StateOverride
+}
+
+func (m *mockOverrideStore) ApplyTxn(header *types.Header, txn *types.Transaction, override state.StateOverride) (*runtime.ExecutionResult, error) {
+	m.override = override
+	return &runtime.ExecutionResult{ReturnValue: []byte{0x1}}, nil
+}
+
+func TestEth_Call_StateOverride(t *testing.T) {
+	store := &mockOverrideStore{}
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	resp, err := dispatcher.Handle([]byte(`{
+		"method": "eth_call",
+		"params": [
+			{"from": "0x0000000000000000000000000000000000000001", "to": "0x0000000000000000000000000000000000000002", "gasPrice": "0x1", "nonce": "0x0"},
+			"0x1",
+			{
+				"0x0000000000000000000000000000000000000002": {
+					"balance": "0x64",
+					"nonce": "0x5",
+					"code": "0x6001",
+					"stateDiff": {
+						"0x0000000000000000000000000000000000000000000000000000000000000001": "0x0000000000000000000000000000000000000000000000000000000000000002"
+					}
+				}
+			}
+		]
+	}`))
+	assert.NoError(t, err)
+
+	var res string
+	assert.NoError(t, expectJSONResult(resp, &res))
+	assert.Equal(t, "0x01", res)
+
+	nonce := uint64(5)
+	assert.Equal(t, state.StateOverride{
+		types.StringToAddress("2"): {
+			Nonce:   &nonce,
+			Code:    []byte{0x60, 0x01},
+			Balance: big.NewInt(100),
+			StateDiff: map[types.Hash]types.Hash{
+				types.StringToHash("1"): types.StringToHash("2"),
+			},
+		},
+	}, store.override)
+
+	// the override is optional
+	_, err = dispatcher.endpoints.Eth.Call(&txnArgs{
+		From:     &addr0,
+		To:       &addr1,
+		GasPrice: argBytesPtr([]byte{0x1}),
+		Nonce:    argUintPtr(0),
+	}, numberOrHash(BlockNumber(1)), nil)
+	assert.NoError(t, err)
+	assert.Nil(t, store.override)
+}
+
+type mockProofStore struct {
+	mockAccessListStore
+}
+
+func (m *mockProofStore) GetProof(root types.Hash, addr types.Address, slots []types.Hash) (*state.AccountProof, error) {
+	proof := &state.AccountProof{
+		Proof: [][]byte{{0x1}, {0x2}},
+	}
+	if addr == addr0 {
+		proof.Account = &state.Account{
+			Nonce:    1,
+			Balance:  big.NewInt(100),
+			Root:     hash1,
+			CodeHash: hash2.Bytes(),
+		}
+	}
+	for _, slot := range slots {
+		slotProof := &state.StorageProof{Key: slot, Proof: [][]byte{{0x3}}}
+		if slot == hash1 {
+			slotProof.Value = []byte{0x5}
+		}
+		proof.StorageProof = append(proof.StorageProof, slotProof)
+	}
+	return proof, nil
+}
+
+func TestEth_GetProof(t *testing.T) {
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), &mockProofStore{})
+
+	res, err := dispatcher.endpoints.Eth.GetProof(addr0, []types.Hash{hash1, hash2}, numberOrHash(BlockNumber(1)))
+	assert.NoError(t, err)
+	assert.Equal(t, &accountProof{
+		Address:      addr0,
+		AccountProof: []argBytes{{0x1}, {0x2}},
+		Balance:      argBigPtr(big.NewInt(100)),
+		CodeHash:     hash2,
+		Nonce:        1,
+		StorageHash:  hash1,
+		StorageProof: []*storageProof{
+			{Key: hash1, Value: argBigPtr(big.NewInt(5)), Proof: []argBytes{{0x3}}},
+			{Key: hash2, Value: argBigPtr(big.NewInt(0)), Proof: []argBytes{{0x3}}},
+		},
+	}, res)
+
+	// the proof of a missing account has an empty state
+	res, err = dispatcher.endpoints.Eth.GetProof(addr1, []types.Hash{}, numberOrHash(BlockNumber(1)))
+	assert.NoError(t, err)
+
+	proof := res.(*accountProof)
+	assert.Equal(t, argBigPtr(big.NewInt(0)), proof.Balance)
+	assert.Equal(t, argUint64(0), proof.Nonce)
+	assert.Equal(t, types.BytesToHash(crypto.Keccak256(nil)), proof.CodeHash)
+	assert.Equal(t, types.EmptyRootHash, proof.StorageHash)
+	assert.Empty(t, proof.StorageProof)
+}
+
+// revertReasonData is the revert data of revert("reason")
+var revertReasonData = hex.MustDecodeHex("0x08c379a0" +
+	"0000000000000000000000000000000000000000000000000000000000000020" +
+	"0000000000000000000000000000000000000000000000000000000000000006" +
+	"726561736f6e0000000000000000000000000000000000000000000000000000")
+
+type mockRevertStore struct {
+	mockAccessListStore
+}
+
+func (m *mockRevertStore) ApplyTxn(header *types.Header, txn *types.Transaction, override state.StateOverride) (*runtime.ExecutionResult, error) {
+	return &runtime.ExecutionResult{ReturnValue: revertReasonData, Err: runtime.ErrExecutionReverted}, nil
+}
+
+func TestEth_Call_Revert(t *testing.T) {
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), &mockRevertStore{})
+
+	_, err := dispatcher.Handle([]byte(`{
+		"method": "eth_call",
+		"params": [
+			{"from": "0x0000000000000000000000000000000000000001", "to": "0x0000000000000000000000000000000000000002", "gasPrice": "0x1", "nonce": "0x0"},
+			"0x1"
+		]
+	}`))
+
+	// the revert reason is returned in the jsonrpc error
+	obj, ok := err.(*ErrorObject)
+	assert.True(t, ok)
+	assert.Equal(t, 3, obj.Code)
+	assert.Equal(t, "execution reverted: reason", obj.Message)
+	assert.Equal(t, hex.EncodeToHex(revertReasonData), obj.Data)
+
+	// the gas cannot be estimated if the txn reverts with any gas
+	number := BlockNumber(1)
+	_, err = dispatcher.endpoints.Eth.EstimateGas(context.Background(), &txnArgs{
+		From:     &addr0,
+		To:       &addr1,
+		Gas:      argUintPtr(30000),
+		GasPrice: argBytesPtr([]byte{0x0}),
+		Nonce:    argUintPtr(0),
+	}, &number)
+	assert.Equal(t, revertError(revertReasonData), err)
+}
+
+// mockEstimateStore executes txns that need 50000 gas and use 45000 after the refunds
+type mockEstimateStore struct {
+	nullBlockchainInterface
+}
+
+func (m *mockEstimateStore) GetHeaderByNumber(n uint64) (*types.Header, bool) {
+	return &types.Header{Number: n, GasLimit: 100000}, true
+}
+
+func (m *mockEstimateStore) ApplyTxn(header *types.Header, txn *types.Transaction, override state.StateOverride) (*runtime.ExecutionResult, error) {
+	if txn.Gas < 21000 {
+		return nil, state.ErrIntrinsicGas
+	}
+	if txn.Gas < 50000 {
+		return &runtime.ExecutionResult{GasUsed: txn.Gas, Err: runtime.ErrOutOfGas}, nil
+	}
+	return &runtime.ExecutionResult{GasUsed: 45000}, nil
+}
+
+func TestEth_EstimateGas(t *testing.T) {
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), &mockEstimateStore{})
+
+	number := BlockNumber(1)
+	newArg := func(gas *argUint64) *txnArgs {
+		return &txnArgs{
+			From:     &addr0,
+			To:       &addr1,
+			Gas:      gas,
+			GasPrice: argBytesPtr([]byte{0x0}),
+			Nonce:    argUintPtr(0),
+		}
+	}
+
+	// the block gas limit is the ceiling if the gas is not set
+	res, err := dispatcher.endpoints.Eth.EstimateGas(context.Background(), newArg(nil), &number)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xc350", res)
+
+	// the gas of the txn is the ceiling if it is set
+	_, err = dispatcher.endpoints.Eth.EstimateGas(context.Background(), newArg(argUintPtr(40000)), &number)
+	assert.EqualError(t, err, "gas required exceeds allowance (40000)")
+
+	// and so is the gas cap, which is reported as such
+	dispatcher.gasCap = 30000
+	_, err = dispatcher.endpoints.Eth.EstimateGas(context.Background(), newArg(nil), &number)
+	assert.Equal(t, errGasCapExceeded(30000), err)
+}
+
+func TestEth_Call_GasCap(t *testing.T) {
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), &mockEstimateStore{})
+	dispatcher.gasCap = 40000
+
+	newArg := func(gas uint64) *txnArgs {
+		return &txnArgs{
+			From:     &addr0,
+			To:       &addr1,
+			Gas:      argUintPtr(gas),
+			GasPrice: argBytesPtr([]byte{0x0}),
+			Nonce:    argUintPtr(0),
+		}
+	}
+
+	// the call runs out of gas because of the cap
+	_, err := dispatcher.endpoints.Eth.Call(newArg(60000), numberOrHash(BlockNumber(1)), nil)
+	assert.Equal(t, errGasCapExceeded(40000), err)
+
+	// the calls that run out of their own gas fail as usual
+	_, err = dispatcher.endpoints.Eth.Call(newArg(30000), numberOrHash(BlockNumber(1)), nil)
+	assert.EqualError(t, err, "unable to execute call: "+runtime.ErrOutOfGas.Error())
+
+	dispatcher.gasCap = 0
+	_, err = dispatcher.endpoints.Eth.Call(newArg(60000), numberOrHash(BlockNumber(1)), nil)
+	assert.NoError(t, err)
+}
+
+type mockBundleStore struct {
+	mockAccessListStore
+
+	header *types.Header
+	txns   []*types.Transaction
+}
+
+func (m *mockBundleStore) GetAccount(root types.Hash, addr types.Address) (*state.Account, error) {
+	return &state.Account{Nonce: 3}, nil
+}
+
+func (m *mockBundleStore) ApplyTxns(header *types.Header, txns []*types.Transaction) ([]*runtime.ExecutionResult, error) {
+	m.header, m.txns = header, txns
+	return []*runtime.ExecutionResult{
+		{ReturnValue: []byte{0x1}, GasUsed: 21000},
+		{ReturnValue: revertReasonData, GasUsed: 25000, Err: runtime.ErrExecutionReverted},
+		{GasUsed: 30000, Err: runtime.ErrOutOfGas},
+	}, nil
+}
+
+func TestEth_CallBundle(t *testing.T) {
+	store := &mockBundleStore{}
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	newArg := func(from types.Address) *txnArgs {
+		return &txnArgs{
+			From:     &from,
+			To:       &addr1,
+			GasPrice: argBytesPtr([]byte{0x0}),
+			Gas:      argUintPtr(100000),
+		}
+	}
+
+	timestamp := argUint64(1000)
+	res, err := dispatcher.endpoints.Eth.CallBundle(
+		[]*txnArgs{newArg(addr0), newArg(addr1), newArg(addr0)},
+		BlockNumber(1),
+		&blockOverride{Timestamp: &timestamp},
+	)
+	assert.NoError(t, err)
+
+	// the nonces follow the senders along the bundle
+	assert.Len(t, store.txns, 3)
+	assert.Equal(t, uint64(3), store.txns[0].Nonce)
+	assert.Equal(t, uint64(3), store.txns[1].Nonce)
+	assert.Equal(t, uint64(4), store.txns[2].Nonce)
+
+	assert.Equal(t, uint64(1), store.header.Number)
+	assert.Equal(t, uint64(1000), store.header.Timestamp)
+
+	result := res.(*bundleResult)
+	assert.Equal(t, argUint64(1), result.StateBlockNumber)
+	assert.Equal(t, argUint64(76000), result.TotalGasUsed)
+	assert.Len(t, result.Results, 3)
+	assert.Equal(t, argBytes{0x1}, result.Results[0].ReturnValue)
+	assert.Empty(t, result.Results[0].Error)
+	assert.Equal(t, "execution reverted: reason", result.Results[1].Error)
+	assert.Equal(t, runtime.ErrOutOfGas.Error(), result.Results[2].Error)
+
+	// the gas cap applies to each call
+	dispatcher.gasCap = 50000
+	_, err = dispatcher.endpoints.Eth.CallBundle([]*txnArgs{newArg(addr0)}, BlockNumber(1), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(50000), store.txns[0].Gas)
+
+	// and to the whole bundle, the calls get the gas left by the previous ones
+	dispatcher.gasCap = 150000
+	_, err = dispatcher.endpoints.Eth.CallBundle([]*txnArgs{newArg(addr0), newArg(addr1)}, BlockNumber(1), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100000), store.txns[0].Gas)
+	assert.Equal(t, uint64(50000), store.txns[1].Gas)
+
+	_, err = dispatcher.endpoints.Eth.CallBundle([]*txnArgs{newArg(addr0), newArg(addr1), newArg(addr0)}, BlockNumber(1), nil)
+	assert.Equal(t, errGasCapExceeded(150000), err)
+
+	// empty bundle
+	_, err = dispatcher.endpoints.Eth.CallBundle([]*txnArgs{}, BlockNumber(1), nil)
+	assert.Error(t, err)
+}
+
+func TestEth_Filters(t *testing.T) {
+	store := newMockStore()
+	dispatcher := newDispatcher(hclog.NewNullLogger(), store, 0)
+	eth := dispatcher.endpoints.Eth
+
+	res, err := eth.NewBlockFilter()
+	assert.NoError(t, err)
+	blockID := res.(string)
+
+	res, err = eth.NewPendingTransactionFilter()
+	assert.NoError(t, err)
+	txnID := res.(string)
+
+	// the updates are encoded as arrays, even if there are none
+	hash := types.StringToHash("1")
+	assert.NoError(t, dispatcher.filterManager.dispatchTxnEvent(&txpool.Event{Hash: hash}))
+
+	res, err = eth.GetFilterChanges(txnID)
+	assert.NoError(t, err)
+	data, err := json.Marshal(res)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `["`+hash.String()+`"]`, string(data))
+
+	res, err = eth.GetFilterChanges(blockID)
+	assert.NoError(t, err)
+	data, err = json.Marshal(res)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[]`, string(data))
+
+	// only the log filters have logs
+	_, err = eth.GetFilterLogs(context.Background(), blockID)
+	assert.Equal(t, errFilterDoesNotExists, err)
+
+	ok, err := eth.UninstallFilter(txnID)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = eth.GetFilterChanges(txnID)
+	assert.Equal(t, errFilterDoesNotExists, err)
+}
+
+var (
+	invalidAddr   = types.StringToAddress("fe")
+	underflowAddr = types.StringToAddress("fd")
+	badJumpAddr   = types.StringToAddress("fc")
+)
+
+// mockEVMStore executes the txns with the evm on top of a state in which the code at addr1
+// loops until it runs out of gas, the code at invalidAddr is invalid, the code at underflowAddr
+// underflows the stack and the code at badJumpAddr jumps to an invalid destination
+type mockEVMStore struct {
+	nullBlockchainInterface
+
+	executor *state.Executor
+	root     types.Hash
+}
+
+func newMockEVMStore(t *testing.T) *mockEVMStore {
+	st := itrie.NewState(itrie.NewMemoryStorage())
+
+	txn := state.NewTxn(st, st.NewSnapshot())
+	txn.SetCode(addr1, []byte{byte(evm.JUMPDEST), byte(evm.PUSH1), 0x0, byte(evm.JUMP)})
+	txn.SetCode(invalidAddr, []byte{0xfe})
+	txn.SetCode(underflowAddr, []byte{byte(evm.ADD)})
+	txn.SetCode(badJumpAddr, []byte{byte(evm.PUSH1), 0x10, byte(evm.JUMP)})
+	_, root, err := txn.Commit(false)
+	assert.NoError(t, err)
+
+	executor := state.NewExecutor(&chainpkg.Params{Forks: chainpkg.AllForksEnabled, ChainID: 1}, st)
+	executor.SetRuntime(evm.NewEVM())
+	executor.GetHash = func(*types.Header) state.GetHashByNumber {
+		return func(i uint64) types.Hash {
+			return types.Hash{}
+		}
+	}
+	return &mockEVMStore{executor: executor, root: types.BytesToHash(root)}
+}
+
+func (m *mockEVMStore) GetHeaderByNumber(n uint64) (*types.Header, bool) {
+	return &types.Header{Number: n, GasLimit: 100000, StateRoot: m.root}, true
+}
+
+func (m *mockEVMStore) ApplyTxn(header *types.Header, txn *types.Transaction, override state.StateOverride) (*runtime.ExecutionResult, error) {
+	transition, err := m.executor.BeginTxn(header.StateRoot, header)
+	if err != nil {
+		return nil, err
+	}
+	gasUsed, _, err := transition.Apply(txn)
+	if err != nil {
+		return nil, err
+	}
+	return &runtime.ExecutionResult{
+		ReturnValue: transition.ReturnValue(),
+		GasUsed:     gasUsed,
+		Err:         transition.ExecutionError(),
+	}, nil
+}
+
+func TestEth_GasErrors_EVM(t *testing.T) {
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), newMockEVMStore(t))
+	dispatcher.gasCap = 50000
+
+	newArg := func(to types.Address, gas *argUint64) *txnArgs {
+		return &txnArgs{
+			From:     &addr0,
+			To:       &to,
+			Gas:      gas,
+			GasPrice: argBytesPtr([]byte{0x0}),
+			Nonce:    argUintPtr(0),
+		}
+	}
+
+	// the calls that run out of gas because of the cap report the cap
+	_, err := dispatcher.endpoints.Eth.Call(newArg(addr1, argUintPtr(60000)), numberOrHash(BlockNumber(1)), nil)
+	assert.Equal(t, errGasCapExceeded(50000), err)
+
+	number := BlockNumber(1)
+	_, err = dispatcher.endpoints.Eth.EstimateGas(context.Background(), newArg(addr1, nil), &number)
+	assert.Equal(t, errGasCapExceeded(50000), err)
+
+	// but not the ones that fail for other reasons
+	_, err = dispatcher.endpoints.Eth.Call(newArg(invalidAddr, argUintPtr(60000)), numberOrHash(BlockNumber(1)), nil)
+	assert.Error(t, err)
+	assert.NotEqual(t, errGasCapExceeded(50000), err)
+
+	_, err = dispatcher.endpoints.Eth.EstimateGas(context.Background(), newArg(invalidAddr, nil), &number)
+	assert.Error(t, err)
+	assert.NotEqual(t, errGasCapExceeded(50000), err)
+}
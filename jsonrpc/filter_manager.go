@@ -4,11 +4,11 @@ import (
 	"container/heap"
 	"encoding/json"
 	"fmt"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/0xPolygon/minimal/blockchain"
+	"github.com/0xPolygon/minimal/txpool"
 	"github.com/0xPolygon/minimal/types"
 	"github.com/google/uuid"
 	"github.com/hashicorp/go-hclog"
@@ -26,6 +26,12 @@ type Filter struct {
 	// log filter
 	logFilter *LogFilter
 
+	// pending txn filter
+	pendingTxns bool
+
+	// pending txn hashes cache
+	txnHashes []types.Hash
+
 	// index of the filter in the timer array
 	index int
 
@@ -42,11 +48,24 @@ func (f *Filter) getFilterUpdates() (string, error) {
 		headers, newHead := f.block.getUpdates()
 		f.block = newHead
 
-		updates := []string{}
+		updates := []types.Hash{}
 		for _, header := range headers {
-			updates = append(updates, header.Hash.String())
+			updates = append(updates, header.Hash)
+		}
+		res, err := json.Marshal(updates)
+		if err != nil {
+			return "", err
 		}
-		return fmt.Sprintf("[\"%s\"]", strings.Join(updates, "\",\"")), nil
+		return string(res), nil
+	}
+	if f.isPendingTxnFilter() {
+		// pending txn filter
+		res, err := json.Marshal(f.txnHashes)
+		if err != nil {
+			return "", err
+		}
+		f.txnHashes = []types.Hash{}
+		return string(res), nil
 	}
 	// log filter
 	res, err := json.Marshal(f.logs)
@@ -93,6 +112,18 @@ func (f *Filter) flush() error {
 				return err
 			}
 		}
+	} else if f.isPendingTxnFilter() {
+		// send each txn hash independently
+		for _, hash := range f.txnHashes {
+			raw, err := json.Marshal(hash)
+			if err != nil {
+				return err
+			}
+			if err := f.sendMessage(string(raw)); err != nil {
+				return err
+			}
+		}
+		f.txnHashes = []types.Hash{}
 	} else {
 		// log filter
 		for _, log := range f.logs {
@@ -117,6 +148,10 @@ func (f *Filter) isBlockFilter() bool {
 	return f.block != nil
 }
 
+func (f *Filter) isPendingTxnFilter() bool {
+	return f.pendingTxns
+}
+
 func (f *Filter) match() bool {
 	return false
 }
@@ -131,9 +166,17 @@ type FilterManager struct {
 
 	subscription blockchain.Subscription
 
+	// subscription to the promoted txns of the pool
+	txnSubscription *txpool.Subscription
+
 	filters map[string]*Filter
 	lock    sync.Mutex
 
+	// wsFilters is the number of filters of each websocket connection,
+	// which cannot be over wsLimit unless it is 0
+	wsFilters map[wsConn]uint64
+	wsLimit   uint64
+
 	updateCh chan struct{}
 	timer    timeHeapImpl
 	timeout  time.Duration
@@ -147,6 +190,7 @@ func NewFilterManager(logger hclog.Logger, store blockchainInterface) *FilterMan
 		store:       store,
 		closeCh:     make(chan struct{}),
 		filters:     map[string]*Filter{},
+		wsFilters:   map[wsConn]uint64{},
 		updateCh:    make(chan struct{}),
 		timer:       timeHeapImpl{},
 		blockStream: &blockStream{},
@@ -160,6 +204,9 @@ func NewFilterManager(logger hclog.Logger, store blockchainInterface) *FilterMan
 	// start the head watcher
 	m.subscription = store.SubscribeEvents()
 
+	// start the pending txns watcher
+	m.txnSubscription = store.SubscribeTxnEvents(txpool.EventPromoted)
+
 	return m
 }
 
@@ -177,14 +224,19 @@ func (f *FilterManager) Run() {
 		}
 	}()
 
+	// watch for new pending transactions in the pool
+	var txnCh chan *txpool.Event
+	if f.txnSubscription != nil {
+		txnCh = f.txnSubscription.GetEventCh()
+	}
+
 	var timeoutCh <-chan time.Time
 	for {
 		// check for the next filter to be removed
-		filter := f.nextTimeoutFilter()
-		if filter == nil {
-			timeoutCh = nil
+		if timestamp, ok := f.nextTimeout(); ok {
+			timeoutCh = time.After(timestamp.Sub(time.Now()))
 		} else {
-			timeoutCh = time.After(filter.timestamp.Sub(time.Now()))
+			timeoutCh = nil
 		}
 
 		select {
@@ -194,57 +246,115 @@ func (f *FilterManager) Run() {
 				f.logger.Error("failed to dispatch event", "err", err)
 			}
 
-		case <-timeoutCh:
-			// timeout for filter
-			if !f.Uninstall(filter.id) {
-				f.logger.Error("failed to uninstall filter", "id", filter.id)
+		case evnt, ok := <-txnCh:
+			if !ok {
+				txnCh = nil
+				continue
+			}
+			// new pending txn in the pool
+			if err := f.dispatchTxnEvent(evnt); err != nil {
+				f.logger.Error("failed to dispatch txn event", "err", err)
 			}
 
+		case <-timeoutCh:
+			// timeout for the filters, unless they were polled in the meantime
+			f.uninstallExpired()
+
 		case <-f.updateCh:
 			// there is a new filter, reset the loop to start the timeout timer
 
 		case <-f.closeCh:
 			// stop the filter manager
+			if f.subscription != nil {
+				f.subscription.Close()
+			}
+			if f.txnSubscription != nil {
+				f.txnSubscription.Close()
+			}
 			return
 		}
 	}
 }
 
-func (f *FilterManager) nextTimeoutFilter() *Filter {
+// nextTimeout returns the time in which the next filter times out, if any
+func (f *FilterManager) nextTimeout() (time.Time, bool) {
 	f.lock.Lock()
-	if len(f.filters) == 0 {
-		f.lock.Unlock()
-		return nil
+	defer f.lock.Unlock()
+
+	if len(f.timer) == 0 {
+		return time.Time{}, false
 	}
+	return f.timer[0].timestamp, true
+}
 
-	// pop the first item
-	item := f.timer[0]
-	f.lock.Unlock()
-	return item
+// uninstallExpired uninstalls the filters that were not polled within the timeout
+func (f *FilterManager) uninstallExpired() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	now := time.Now()
+	for len(f.timer) != 0 && !f.timer[0].timestamp.After(now) {
+		f.removeFilter(f.timer[0])
+	}
+}
+
+// refresh extends the timeout of a polled filter
+func (f *FilterManager) refresh(filter *Filter) {
+	if filter.isWS() {
+		return
+	}
+	filter.timestamp = time.Now().Add(f.timeout)
+	heap.Fix(&f.timer, filter.index)
+
+	select {
+	case f.updateCh <- struct{}{}:
+	default:
+	}
 }
 
 func (f *FilterManager) dispatchEvent(evnt *blockchain.Event) error {
 	f.lock.Lock()
 	defer f.lock.Unlock()
 
+	if evnt.Type == blockchain.EventFork {
+		// the canonical chain did not change
+		return nil
+	}
+
 	// first include all the new headers in the blockstream for the block filters
 	for _, header := range evnt.NewChain {
 		f.blockStream.push(header)
 	}
 
+	// processBlock adds the logs of the block to the log filters that match them,
+	// so that the subscriptions only receive the logs they asked for
 	processBlock := func(h *types.Header, removed bool) error {
+		// skip the receipts if the logs bloom does not match any log filter
+		match := false
+		for _, filter := range f.filters {
+			if filter.isLogFilter() && filter.logFilter.MatchHeader(h) {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return nil
+		}
+
 		// get the logs from the transaction
 		receipts, err := f.store.GetReceiptsByHash(h.Hash)
 		if err != nil {
 			return err
 		}
 
+		// the index of a log is its position in the block, as in the receipts
+		logIndex := 0
 		for indx, receipt := range receipts {
 			// check the logs with the filters
 			for _, log := range receipt.Logs {
-				for _, f := range f.filters {
-					if f.isLogFilter() {
-						if f.logFilter.Match(log) {
+				for _, filter := range f.filters {
+					if filter.isLogFilter() {
+						if filter.logFilter.Match(log) {
 							nn := &Log{
 								Address:     log.Address,
 								Topics:      log.Topics,
@@ -253,30 +363,59 @@ func (f *FilterManager) dispatchEvent(evnt *blockchain.Event) error {
 								BlockHash:   h.Hash,
 								TxHash:      receipt.TxHash,
 								TxIndex:     argUint64(indx),
+								LogIndex:    argUint64(logIndex),
 								Removed:     removed,
 							}
-							f.logs = append(f.logs, nn)
+							filter.logs = append(filter.logs, nn)
 						}
 					}
 				}
+				logIndex++
 			}
 		}
 		return nil
 	}
 
-	// process old chain
-	for _, i := range evnt.OldChain {
-		processBlock(i, true)
+	// the logs of the removed blocks are sent first, from the newest block to the
+	// oldest one, so that the clients revert them in order before the new logs
+	for i := len(evnt.OldChain) - 1; i >= 0; i-- {
+		if err := processBlock(evnt.OldChain[i], true); err != nil {
+			f.logger.Error("failed to get the logs of a removed block", "hash", evnt.OldChain[i].Hash, "err", err)
+		}
 	}
-	// process new chain
-	for _, i := range evnt.NewChain {
-		processBlock(i, false)
+	for _, header := range evnt.NewChain {
+		if err := processBlock(header, false); err != nil {
+			f.logger.Error("failed to get the logs of a new block", "hash", header.Hash, "err", err)
+		}
 	}
 
 	// flush all the websocket values
-	for _, f := range f.filters {
-		if f.isWS() {
-			f.flush()
+	for _, filter := range f.filters {
+		if filter.isWS() {
+			// a subscriber that fails is closed by its connection, the others are still notified
+			if err := filter.flush(); err != nil {
+				f.logger.Debug("failed to notify chain event", "id", filter.id, "err", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (f *FilterManager) dispatchTxnEvent(evnt *txpool.Event) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, filter := range f.filters {
+		if !filter.isPendingTxnFilter() {
+			continue
+		}
+		filter.txnHashes = append(filter.txnHashes, evnt.Hash)
+
+		if filter.isWS() {
+			// a subscriber that fails is closed by its connection, the others are still notified
+			if err := filter.flush(); err != nil {
+				f.logger.Debug("failed to notify pending txn", "id", filter.id, "err", err)
+			}
 		}
 	}
 	return nil
@@ -304,6 +443,8 @@ func (f *FilterManager) GetFilterChanges(id string) (string, error) {
 		return "", errFilterDoesNotExists
 	}
 
+	f.refresh(item)
+
 	res, err := item.getFilterUpdates()
 	if err != nil {
 		return "", err
@@ -311,34 +452,87 @@ func (f *FilterManager) GetFilterChanges(id string) (string, error) {
 	return res, nil
 }
 
+// GetLogFilter returns the log filter of a filter installed with eth_newFilter
+func (f *FilterManager) GetLogFilter(id string) (*LogFilter, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	item, ok := f.filters[id]
+	if !ok || item.isWS() || !item.isLogFilter() {
+		return nil, errFilterDoesNotExists
+	}
+	f.refresh(item)
+
+	return item.logFilter, nil
+}
+
 func (f *FilterManager) Uninstall(id string) bool {
 	f.lock.Lock()
+	defer f.lock.Unlock()
 
 	item, ok := f.filters[id]
 	if !ok {
 		return false
 	}
+	f.removeFilter(item)
+	return true
+}
 
-	delete(f.filters, id)
-	heap.Remove(&f.timer, item.index)
+// UninstallSubscription uninstalls a filter of the websocket connection
+func (f *FilterManager) UninstallSubscription(id string, ws wsConn) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
 
-	f.lock.Unlock()
+	item, ok := f.filters[id]
+	if !ok || item.ws != ws {
+		return false
+	}
+	f.removeFilter(item)
 	return true
 }
 
-func (f *FilterManager) NewBlockFilter(ws wsConn) string {
+// UninstallWs uninstalls all the filters of the websocket connection, once it is closed
+func (f *FilterManager) UninstallWs(ws wsConn) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, item := range f.filters {
+		if item.ws == ws {
+			f.removeFilter(item)
+		}
+	}
+}
+
+func (f *FilterManager) removeFilter(item *Filter) {
+	delete(f.filters, item.id)
+	if item.isWS() {
+		if f.wsFilters[item.ws]--; f.wsFilters[item.ws] == 0 {
+			delete(f.wsFilters, item.ws)
+		}
+	} else {
+		heap.Remove(&f.timer, item.index)
+	}
+}
+
+func (f *FilterManager) NewBlockFilter(ws wsConn) (string, error) {
 	return f.addFilter(nil, ws)
 }
 
-func (f *FilterManager) NewLogFilter(logFilter *LogFilter, ws wsConn) string {
+func (f *FilterManager) NewLogFilter(logFilter *LogFilter, ws wsConn) (string, error) {
 	return f.addFilter(logFilter, ws)
 }
 
-func (f *FilterManager) addFilter(logFilter *LogFilter, ws wsConn) string {
-	f.lock.Lock()
+func (f *FilterManager) NewPendingTxnFilter(ws wsConn) (string, error) {
+	filter := &Filter{
+		ws:          ws,
+		pendingTxns: true,
+		txnHashes:   []types.Hash{},
+	}
+	return f.installFilter(filter)
+}
 
+func (f *FilterManager) addFilter(logFilter *LogFilter, ws wsConn) (string, error) {
 	filter := &Filter{
-		id: uuid.New().String(),
 		ws: ws,
 	}
 
@@ -347,13 +541,38 @@ func (f *FilterManager) addFilter(logFilter *LogFilter, ws wsConn) string {
 		// take the reference from the stream
 		filter.block = f.blockStream.Head()
 	} else {
-		// log filter
+		// log filter, the matcher is built before the filter is shared
+		if logFilter.matcher == nil {
+			logFilter.matcher = logFilter.newBloomMatcher()
+		}
 		filter.logFilter = logFilter
 	}
 
+	return f.installFilter(filter)
+}
+
+// errTooManySubscriptions is returned if a websocket connection is over the subscription limit
+var errTooManySubscriptions = fmt.Errorf("too many subscriptions in the connection")
+
+func (f *FilterManager) installFilter(filter *Filter) (string, error) {
+	f.lock.Lock()
+
+	if filter.isWS() {
+		// the subscriptions of the websocket connections do not time out,
+		// they are uninstalled with eth_unsubscribe or once the connection is closed
+		if f.wsLimit != 0 && f.wsFilters[filter.ws] >= f.wsLimit {
+			f.lock.Unlock()
+			return "", errTooManySubscriptions
+		}
+		f.wsFilters[filter.ws]++
+	}
+
+	filter.id = uuid.New().String()
 	f.filters[filter.id] = filter
-	filter.timestamp = time.Now().Add(f.timeout)
-	heap.Push(&f.timer, filter)
+	if !filter.isWS() {
+		filter.timestamp = time.Now().Add(f.timeout)
+		heap.Push(&f.timer, filter)
+	}
 
 	f.lock.Unlock()
 
@@ -362,7 +581,7 @@ func (f *FilterManager) addFilter(logFilter *LogFilter, ws wsConn) string {
 	default:
 	}
 
-	return filter.id
+	return filter.id, nil
 }
 
 func (f *FilterManager) Close() {
@@ -1,12 +1,16 @@
 package jsonrpc
 
 import (
+	"encoding/json"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/0xPolygon/minimal/blockchain"
 	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/txpool"
 	"github.com/0xPolygon/minimal/types"
 	"github.com/hashicorp/go-hclog"
 	"github.com/stretchr/testify/assert"
@@ -18,11 +22,17 @@ func TestFilterLog(t *testing.T) {
 	m := NewFilterManager(hclog.NewNullLogger(), store)
 	go m.Run()
 
-	id := m.addFilter(&LogFilter{
+	id, err := m.addFilter(&LogFilter{
 		Topics: [][]types.Hash{
 			{hash1},
 		},
 	}, nil)
+	assert.NoError(t, err)
+
+	// the matcher is built before the filter is shared with the dispatch of the events
+	logFilter, err := m.GetLogFilter(id)
+	assert.NoError(t, err)
+	assert.NotNil(t, logFilter.matcher)
 
 	store.emitEvent(&mockEvent{
 		NewChain: []*mockHeader{
@@ -75,7 +85,8 @@ func TestFilterBlock(t *testing.T) {
 	go m.Run()
 
 	// add block filter
-	id := m.addFilter(nil, nil)
+	id, err := m.addFilter(nil, nil)
+	assert.NoError(t, err)
 
 	// emit two events
 	store.emitEvent(&mockEvent{
@@ -134,13 +145,42 @@ func TestFilterTimeout(t *testing.T) {
 	go m.Run()
 
 	// add block filter
-	id := m.addFilter(nil, nil)
+	id, err := m.addFilter(nil, nil)
+	assert.NoError(t, err)
 
 	assert.True(t, m.Exists(id))
 	time.Sleep(3 * time.Second)
 	assert.False(t, m.Exists(id))
 }
 
+func TestFilterIdleTimeout(t *testing.T) {
+	store := newMockStore()
+
+	m := NewFilterManager(hclog.NewNullLogger(), store)
+	m.timeout = 500 * time.Millisecond
+
+	go m.Run()
+	defer m.Close()
+
+	logID, err := m.NewLogFilter(&LogFilter{Addresses: []types.Address{addr1}}, nil)
+	assert.NoError(t, err)
+	blockID, err := m.NewBlockFilter(nil)
+	assert.NoError(t, err)
+
+	// the polled filters are kept
+	for i := 0; i < 3; i++ {
+		time.Sleep(300 * time.Millisecond)
+
+		_, err := m.GetLogFilter(logID)
+		assert.NoError(t, err)
+	}
+	assert.True(t, m.Exists(logID))
+	assert.False(t, m.Exists(blockID))
+
+	time.Sleep(time.Second)
+	assert.False(t, m.Exists(logID))
+}
+
 func TestFilterWebsocket(t *testing.T) {
 	store := newMockStore()
 
@@ -151,10 +191,11 @@ func TestFilterWebsocket(t *testing.T) {
 	m := NewFilterManager(hclog.NewNullLogger(), store)
 	go m.Run()
 
-	id := m.NewBlockFilter(mock)
+	id, err := m.NewBlockFilter(mock)
+	assert.NoError(t, err)
 
 	// we cannot call get filter changes for a websocket filter
-	_, err := m.GetFilterChanges(id)
+	_, err = m.GetFilterChanges(id)
 	assert.Equal(t, err, errFilterDoesNotExists)
 
 	// emit two events
@@ -175,6 +216,138 @@ func TestFilterWebsocket(t *testing.T) {
 	}
 }
 
+func TestFilterWebsocketLogs(t *testing.T) {
+	store := newMockStore()
+
+	mock := &mockWsConn{
+		msgCh: make(chan []byte, 10),
+	}
+
+	m := NewFilterManager(hclog.NewNullLogger(), store)
+	go m.Run()
+	defer m.Close()
+
+	_, err := m.NewLogFilter(&LogFilter{Addresses: []types.Address{addr0}}, mock)
+	assert.NoError(t, err)
+
+	other := types.StringToAddress("2")
+	mockBlock := func(hash types.Hash, addrs ...types.Address) *mockHeader {
+		receipts := []*types.Receipt{}
+		for _, addr := range addrs {
+			receipts = append(receipts, &types.Receipt{Logs: []*types.Log{{Address: addr}}})
+		}
+		return &mockHeader{header: &types.Header{Hash: hash}, receipts: receipts}
+	}
+
+	// a reorg replaces two blocks, the logs of the other address are not sent
+	store.emitEvent(&mockEvent{
+		OldChain: []*mockHeader{
+			mockBlock(hash1, addr0),
+			mockBlock(hash2, other, addr0),
+		},
+		NewChain: []*mockHeader{
+			mockBlock(hash3, other, other, addr0),
+		},
+	})
+
+	expected := []struct {
+		hash     types.Hash
+		logIndex argUint64
+		removed  bool
+	}{
+		{hash2, 1, true},
+		{hash1, 0, true},
+		{hash3, 2, false},
+	}
+	for _, c := range expected {
+		select {
+		case msg := <-mock.msgCh:
+			var notification struct {
+				Params struct {
+					Result *Log
+				}
+			}
+			assert.NoError(t, json.Unmarshal(msg, &notification))
+
+			log := notification.Params.Result
+			assert.Equal(t, addr0, log.Address)
+			assert.Equal(t, c.hash, log.BlockHash)
+			assert.Equal(t, c.logIndex, log.LogIndex)
+			assert.Equal(t, c.removed, log.Removed)
+		case <-time.After(2 * time.Second):
+			t.Fatal("log not received")
+		}
+	}
+
+	select {
+	case <-mock.msgCh:
+		t.Fatal("unexpected log")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestFilterWebsocketSubscriptions(t *testing.T) {
+	store := newMockStore()
+
+	m := NewFilterManager(hclog.NewNullLogger(), store)
+	m.timeout = 100 * time.Millisecond
+	m.wsLimit = 2
+
+	go m.Run()
+	defer m.Close()
+
+	ws1, ws2 := &mockWsConn{}, &mockWsConn{}
+
+	id1, err := m.NewBlockFilter(ws1)
+	assert.NoError(t, err)
+	id2, err := m.NewLogFilter(&LogFilter{}, ws1)
+	assert.NoError(t, err)
+
+	// the connection is over the limit but not the other ones
+	_, err = m.NewPendingTxnFilter(ws1)
+	assert.Equal(t, errTooManySubscriptions, err)
+	id3, err := m.NewPendingTxnFilter(ws2)
+	assert.NoError(t, err)
+
+	// the subscriptions do not time out
+	time.Sleep(300 * time.Millisecond)
+	assert.True(t, m.Exists(id1))
+
+	// a connection cannot uninstall the subscriptions of another one
+	assert.False(t, m.UninstallSubscription(id1, ws2))
+	assert.True(t, m.UninstallSubscription(id1, ws1))
+	_, err = m.NewPendingTxnFilter(ws1)
+	assert.NoError(t, err)
+
+	// the subscriptions are uninstalled once the connection is closed
+	m.UninstallWs(ws1)
+	assert.False(t, m.Exists(id2))
+	assert.True(t, m.Exists(id3))
+	assert.Len(t, m.wsFilters, 1)
+}
+
+func TestFilterPendingTxns(t *testing.T) {
+	store := newMockStore()
+
+	m := NewFilterManager(hclog.NewNullLogger(), store)
+
+	id, err := m.NewPendingTxnFilter(nil)
+	assert.NoError(t, err)
+
+	hash1, hash2 := types.StringToHash("1"), types.StringToHash("2")
+	assert.NoError(t, m.dispatchTxnEvent(&txpool.Event{Hash: hash1}))
+	assert.NoError(t, m.dispatchTxnEvent(&txpool.Event{Hash: hash2}))
+
+	res, err := m.GetFilterChanges(id)
+	assert.NoError(t, err)
+	assert.Equal(t, res, fmt.Sprintf("[\"%s\",\"%s\"]", hash1, hash2))
+
+	// there are no new entries
+	res, err = m.GetFilterChanges(id)
+	assert.NoError(t, err)
+	assert.Equal(t, res, "[]")
+}
+
 type mockWsConn struct {
 	msgCh chan []byte
 }
@@ -215,7 +388,7 @@ type mockStore struct {
 	receipts     map[types.Hash][]*types.Receipt
 }
 
-func (m *mockStore) ApplyTxn(header *types.Header, txn *types.Transaction) ([]byte, bool, error) {
+func (m *mockStore) ApplyTxn(header *types.Header, txn *types.Transaction, override state.StateOverride) (*runtime.ExecutionResult, error) {
 	panic("implement me")
 }
 
@@ -272,6 +445,10 @@ func (m *mockStore) Header() *types.Header {
 	return m.header
 }
 
+func (m *mockStore) ResolveBlockTag(tag blockchain.BlockTag) (*types.Header, error) {
+	return m.header, nil
+}
+
 func (m *mockStore) GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error) {
 	m.receiptsLock.Lock()
 	defer m.receiptsLock.Unlock()
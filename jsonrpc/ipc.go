@@ -0,0 +1,90 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"net"
+	"sync"
+
+	"github.com/0xPolygon/minimal/helper/ipc"
+)
+
+// ipcConn is a connection to the ipc endpoint, which supports the subscriptions
+// like the websocket connections. The messages are json values written one per line
+type ipcConn struct {
+	// the writes of the responses and of the subscriptions are concurrent
+	lock sync.Mutex
+	conn net.Conn
+}
+
+func (c *ipcConn) WriteMessage(b []byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	_, err := c.conn.Write(append(b, '\n'))
+	return err
+}
+
+// setupIPC listens on the unix socket (named pipe on windows) of the ipc path.
+// The local clients are trusted, so the requests are neither authenticated nor rate limited
+func (j *JSONRPC) setupIPC() error {
+	// the socket left by a node that did not shut down cleanly is replaced
+	lis, err := ipc.Listen(j.config.IPCPath)
+	if err != nil {
+		return err
+	}
+	j.ipcListener = lis
+
+	j.logger.Info("ipc server started", "path", j.config.IPCPath)
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				j.logger.Debug("closed ipc listener", "err", err)
+				return
+			}
+			go j.handleIPC(conn)
+		}
+	}()
+	return nil
+}
+
+func (j *JSONRPC) handleIPC(conn net.Conn) {
+	defer conn.Close()
+
+	wrapConn := &ipcConn{conn: conn}
+	if !j.trackConn(wrapConn) {
+		return
+	}
+	defer j.untrackConn(wrapConn)
+	defer j.dispatcher.CloseWs(wrapConn)
+
+	dec := json.NewDecoder(conn)
+	slots := make(chan struct{}, maxConnRequests)
+	for {
+		var message json.RawMessage
+		if err := dec.Decode(&message); err != nil {
+			if _, ok := err.(*json.SyntaxError); ok {
+				wrapConn.WriteMessage(encodeError(nil, parseError))
+			}
+			return
+		}
+		slots <- struct{}{}
+		if !j.inflight.add() {
+			<-slots
+			wrapConn.WriteMessage(encodeError(message, errShuttingDown))
+			continue
+		}
+		go func() {
+			defer func() { <-slots }()
+			defer j.inflight.done()
+
+			resp, err := j.dispatcher.HandleWs(message, wrapConn)
+			if err != nil {
+				wrapConn.WriteMessage(encodeError(message, err))
+			} else {
+				wrapConn.WriteMessage(resp)
+			}
+		}()
+	}
+}
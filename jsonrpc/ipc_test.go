@@ -0,0 +1,93 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPC(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "jsonrpc_ipc")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the socket left by a previous run is replaced
+	path := filepath.Join(dir, "minimal.ipc")
+	assert.NoError(t, ioutil.WriteFile(path, nil, 0600))
+
+	srv := &JSONRPC{
+		logger:     hclog.NewNullLogger(),
+		config:     &Config{IPCPath: path},
+		dispatcher: newTestDispatcher(hclog.NewNullLogger(), newMockStore()),
+	}
+	assert.NoError(t, srv.setupIPC())
+
+	conn, err := net.Dial("unix", path)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	// the requests are not delimited, and the responses are one per line
+	_, err = conn.Write([]byte(`{"id": 1, "method": "web3_clientVersion", "params": []}{"id": 2, "method": "eth_foo", "params": []}`))
+	assert.NoError(t, err)
+
+	responses := map[string]string{}
+	reader := bufio.NewReader(conn)
+	for i := 0; i < 2; i++ {
+		line, err := reader.ReadBytes('\n')
+		assert.NoError(t, err)
+
+		var resp struct {
+			ID json.RawMessage `json:"id"`
+		}
+		assert.NoError(t, json.Unmarshal(line, &resp))
+		responses[string(resp.ID)] = string(line)
+	}
+	assert.Contains(t, responses["1"], "polygon-sdk")
+	assert.Contains(t, responses["2"], "-32601")
+
+	// closing the server removes the socket
+	assert.NoError(t, srv.Close())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestIPC_ManyRequests(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "jsonrpc_ipc")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "minimal.ipc")
+	srv := &JSONRPC{
+		logger:     hclog.NewNullLogger(),
+		config:     &Config{IPCPath: path},
+		dispatcher: newTestDispatcher(hclog.NewNullLogger(), newMockStore()),
+	}
+	assert.NoError(t, srv.setupIPC())
+	defer srv.Close()
+
+	conn, err := net.Dial("unix", path)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	// the requests over the limit of the connection wait for a slot
+	num := 5 * maxConnRequests
+	go func() {
+		for i := 0; i < num; i++ {
+			conn.Write([]byte(`{"id": 1, "method": "web3_clientVersion", "params": []}`))
+		}
+	}()
+
+	reader := bufio.NewReader(conn)
+	for i := 0; i < num; i++ {
+		line, err := reader.ReadBytes('\n')
+		assert.NoError(t, err)
+		assert.Contains(t, string(line), "polygon-sdk")
+	}
+}
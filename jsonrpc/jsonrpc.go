@@ -1,11 +1,16 @@
 package jsonrpc
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/0xPolygon/minimal/types"
 	"github.com/gorilla/websocket"
 	"github.com/hashicorp/go-hclog"
 )
@@ -42,17 +47,98 @@ type JSONRPC struct {
 	logger     hclog.Logger
 	config     *Config
 	dispatcher dispatcherImpl
+	limiter    *txLimiter
+	requests   *requestLimiter
+	metrics    *Metrics
+
+	httpServer  *http.Server
+	ipcListener net.Listener
+
+	// requests being handled, which the shutdown waits for
+	inflight inflightRequests
+
+	// number of open websocket connections
+	wsConns int64
+
+	// websocket and ipc connections, which are closed on shutdown
+	connsLock   sync.Mutex
+	conns       map[streamConn]struct{}
+	connsClosed bool
 }
 
 type dispatcherImpl interface {
 	HandleWs(reqBody []byte, conn wsConn) ([]byte, error)
+	CloseWs(conn wsConn)
 	Handle([]byte) ([]byte, error)
+	Close()
+
+	// cancelRequests cancels the requests being handled, and waitRequests waits
+	// for the ones still running after their timeout
+	cancelRequests()
+	waitRequests()
 }
 
 type Config struct {
 	Store   blockchainInterface
 	Addr    *net.TCPAddr
 	ChainID uint64
+
+	// RateLimit limits the txn submissions, disabled if nil
+	RateLimit *RateLimitConfig
+
+	// GasCap caps the gas of the calls and of the gas estimations, uncapped if 0
+	GasCap uint64
+
+	// WsSubscriptionLimit is the max number of subscriptions of a websocket connection, unlimited if 0
+	WsSubscriptionLimit uint64
+
+	// EnableDebug exposes the debug namespace, which replays the blocks and iterates the state
+	EnableDebug bool
+
+	// EnableTrace exposes the trace namespace, which replays the blocks
+	EnableTrace bool
+
+	// Services are the namespaces added to the built-in ones by their name. The exported
+	// methods of a service are its rpc methods, which return a value and an error
+	Services map[string]interface{}
+
+	// Network is the p2p layer of the node, the net and the admin namespaces
+	// report no peers and fail respectively if nil
+	Network networkInterface
+
+	// EnableAdmin exposes the admin namespace, which adds and removes the peers of the node
+	EnableAdmin bool
+
+	// Access restricts the access to the server, which is open if nil
+	Access *AccessConfig
+
+	// IPCPath is the path of the unix socket of the ipc endpoint, disabled if empty
+	IPCPath string
+
+	// LogsLimits bound the queries of the logs, unlimited if nil
+	LogsLimits *LogsLimits
+
+	// RequestLimits bound the concurrency and the duration of the requests, unlimited if nil
+	RequestLimits *RequestLimits
+
+	// SlowRequestThreshold is the duration above which the requests are logged with their params, disabled if 0
+	SlowRequestThreshold time.Duration
+
+	// TLS serves the http and the websocket endpoints over tls, if set
+	TLS *tls.Config
+
+	// ShutdownTimeout is the time the shutdown waits for the requests in flight
+	ShutdownTimeout time.Duration
+
+	// Websocket bounds the websocket connections, unlimited if nil
+	Websocket *WebsocketConfig
+
+	// Mining is whether the node seals blocks, as reported by eth_mining
+	Mining bool
+
+	// Accounts are the accounts returned by eth_accounts, which are the
+	// senders of the unsigned txns accepted by the dev mode
+	Accounts []types.Address
 }
 
 // NewJSONRPC returns the JsonRPC http server
@@ -60,16 +146,69 @@ func NewJSONRPC(logger hclog.Logger, config *Config) (*JSONRPC, error) {
 	if config.Addr == nil {
 		config.Addr = defaultHttpAddr
 	}
+	if config.Websocket != nil {
+		if err := config.Websocket.validate(); err != nil {
+			return nil, err
+		}
+	}
+	dispatcher := newDispatcher(logger, config.Store, config.ChainID)
+	dispatcher.gasCap = config.GasCap
+	dispatcher.logsLimits = config.LogsLimits
+	dispatcher.workers = newWorkerPool(config.RequestLimits)
+	dispatcher.slowRequestThreshold = config.SlowRequestThreshold
+	dispatcher.network = config.Network
+	dispatcher.mining = config.Mining
+	dispatcher.accounts = config.Accounts
+	for name, service := range config.Services {
+		if err := dispatcher.registerCustomService(name, service); err != nil {
+			return nil, fmt.Errorf("failed to register service '%s': %v", name, err)
+		}
+	}
+	if !config.EnableDebug {
+		dispatcher.disableService("debug")
+	}
+	if !config.EnableAdmin {
+		dispatcher.disableService("admin")
+	}
+	if !config.EnableTrace {
+		dispatcher.disableService("trace")
+	}
+	if dispatcher.filterManager != nil {
+		dispatcher.filterManager.wsLimit = config.WsSubscriptionLimit
+	}
 	srv := &JSONRPC{
 		logger:     logger.Named("jsonrpc"),
 		config:     config,
-		dispatcher: newDispatcher(logger, config.Store, config.ChainID),
+		dispatcher: dispatcher,
+		metrics:    dispatcher.metrics,
+	}
+	if config.RateLimit != nil {
+		srv.limiter = newTxLimiter(config.RateLimit)
+		dispatcher.limiter = srv.limiter
+
+		requests, err := newRequestLimiter(config.RateLimit)
+		if err != nil {
+			return nil, err
+		}
+		srv.requests = requests
+	}
+	if config.Access != nil {
+		acl, err := newMethodACL(config.Access.AllowMethods, config.Access.DenyMethods)
+		if err != nil {
+			return nil, err
+		}
+		dispatcher.acl = acl
 	}
 
 	// start http server
 	if err := srv.setupHTTP(); err != nil {
 		return nil, err
 	}
+	if config.IPCPath != "" {
+		if err := srv.setupIPC(); err != nil {
+			return nil, err
+		}
+	}
 	return srv, nil
 }
 
@@ -81,66 +220,57 @@ func (j *JSONRPC) setupHTTP() error {
 		return err
 	}
 
-	mux := http.DefaultServeMux
+	mux := http.NewServeMux()
 	mux.HandleFunc("/", j.handle)
 	mux.HandleFunc("/ws", j.handleWs)
 
-	srv := http.Server{
-		Handler: mux,
+	srv := &http.Server{
+		Handler: j.middlewares(mux),
+	}
+	if j.config.TLS != nil {
+		srv.TLSConfig = j.config.TLS.Clone()
 	}
+	j.httpServer = srv
+
 	go func() {
-		if err := srv.Serve(lis); err != nil {
+		var err error
+		if srv.TLSConfig != nil {
+			// the server negotiates http/2 over tls, the websockets stay on http/1.1
+			err = srv.ServeTLS(lis, "", "")
+		} else {
+			err = srv.Serve(lis)
+		}
+		if err != nil && err != http.ErrServerClosed {
 			j.logger.Error("closed http connection", "err", err)
 		}
 	}()
 	return nil
 }
 
-type wrapWsConn struct {
-	conn *websocket.Conn
-}
-
-func (w *wrapWsConn) WriteMessage(b []byte) error {
-	return w.conn.WriteMessage(0, b)
-}
-
-func (j *JSONRPC) handleWs(w http.ResponseWriter, req *http.Request) {
-	c, err := upgrader.Upgrade(w, req, nil)
-	if err != nil {
-		return
+// middlewares wraps the handler with the cors and the auth of the access config
+func (j *JSONRPC) middlewares(h http.Handler) http.Handler {
+	access := j.config.Access
+	if access == nil {
+		access = &AccessConfig{}
 	}
-	defer c.Close()
 
-	wrapConn := &wrapWsConn{conn: c}
-	for {
-		_, message, err := c.ReadMessage()
-		if err != nil {
-			break
-		}
-		go func() {
-			resp, err := j.dispatcher.HandleWs(message, wrapConn)
-			if err != nil {
-				wrapConn.WriteMessage(resp)
-			} else {
-				wrapConn.WriteMessage([]byte(fmt.Sprintf(err.Error())))
-			}
-		}()
+	middlewares := []middleware{corsMiddleware(access.CorsOrigins)}
+	if len(access.AuthTokens) != 0 || len(access.JWTSecret) != 0 {
+		middlewares = append(middlewares, authMiddleware(access.AuthTokens, access.JWTSecret))
 	}
+	middlewares = append(middlewares, gzipMiddleware)
+	return chain(h, middlewares...)
 }
 
-func (j *JSONRPC) handle(w http.ResponseWriter, req *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-
-	if (*req).Method == "OPTIONS" {
-		return
+// allowRequest checks the rate limits of the requests and of the txn submissions
+func (j *JSONRPC) allowRequest(remoteAddr string, reqBody []byte) error {
+	if err := j.requests.allowRequest(remoteAddr, reqBody); err != nil {
+		return err
 	}
+	return j.limiter.allowRequest(remoteAddr, reqBody)
+}
 
-	handleErr := func(err error) {
-		w.Write([]byte(err.Error()))
-		return
-	}
+func (j *JSONRPC) handle(w http.ResponseWriter, req *http.Request) {
 	if req.Method == "GET" {
 		w.Write([]byte("PolygonSDK JSON-RPC"))
 		return
@@ -149,14 +279,33 @@ func (j *JSONRPC) handle(w http.ResponseWriter, req *http.Request) {
 		w.Write([]byte("method " + req.Method + " not allowed"))
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+
+	if !j.inflight.add() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write(encodeError(nil, errShuttingDown))
+		return
+	}
+	defer j.inflight.done()
+
 	data, err := ioutil.ReadAll(req.Body)
 	if err != nil {
-		handleErr(err)
+		w.Write(encodeError(nil, err))
+		return
+	}
+	if err := j.allowRequest(req.RemoteAddr, data); err != nil {
+		if isRateLimited(err) {
+			if hint, ok := err.(*ErrorObject).Data.(*retryHint); ok {
+				w.Header().Set("Retry-After", strconv.FormatUint(hint.RetryAfter, 10))
+			}
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+		w.Write(encodeError(data, err))
 		return
 	}
 	resp, err := j.dispatcher.Handle(data)
 	if err != nil {
-		handleErr(err)
+		w.Write(encodeError(data, err))
 		return
 	}
 	w.Write(resp)
@@ -1,10 +1,16 @@
 package jsonrpc
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestHTTPServer(t *testing.T) {
@@ -18,3 +24,31 @@ func TestHTTPServer(t *testing.T) {
 	}
 	fmt.Println(srv)
 }
+
+func TestHTTPServer_HTTP2(t *testing.T) {
+	// the certificate and the client of a test server
+	certSrv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	certSrv.EnableHTTP2 = true
+	certSrv.StartTLS()
+	defer certSrv.Close()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := lis.Addr().(*net.TCPAddr)
+	lis.Close()
+
+	srv, err := NewJSONRPC(hclog.NewNullLogger(), &Config{
+		Store: newMockStore(),
+		Addr:  addr,
+		TLS:   &tls.Config{Certificates: certSrv.TLS.Certificates},
+	})
+	assert.NoError(t, err)
+	defer srv.Close()
+
+	resp, err := certSrv.Client().Post("https://"+addr.String(), "application/json", strings.NewReader(`{"id": 1, "method": "web3_clientVersion", "params": []}`))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, 2, resp.ProtoMajor)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,132 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "jsonrpc"
+
+// unknownMethod is the label of the requests of the methods that do not exist,
+// so the clients cannot add labels with arbitrary method names
+const unknownMethod = "unknown"
+
+// Results of a request
+const (
+	resultSuccess = "success"
+	resultError   = "error"
+)
+
+const (
+	// maxLoggedParamLen is the max length of a string of the params of a slow request,
+	// the longer ones (i.e. raw txns or call data) are truncated
+	maxLoggedParamLen = 66
+
+	// maxLoggedParamsLen is the max length of the params of a slow request
+	maxLoggedParamsLen = 1024
+)
+
+// Metrics are the prometheus metrics of the requests
+type Metrics struct {
+	// number of requests by method and result
+	requests *prometheus.CounterVec
+
+	// time to handle a request by method
+	duration *prometheus.HistogramVec
+
+	// size of the responses by method
+	responseSize *prometheus.HistogramVec
+}
+
+func newMetrics() *Metrics {
+	return &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "requests_total",
+			Help:      "Number of requests by method and result (success, error)",
+		}, []string{"method", "result"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "request_duration_seconds",
+			Help:      "Time to handle a request by method",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 16),
+		}, []string{"method"}),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "response_size_bytes",
+			Help:      "Size of the responses by method",
+			Buckets:   prometheus.ExponentialBuckets(64, 4, 10),
+		}, []string{"method"}),
+	}
+}
+
+// trackRequest records the request of the method, which was handled in the duration
+// and either failed or returned a response of the size
+func (m *Metrics) trackRequest(method string, duration time.Duration, size int, err error) {
+	result := resultSuccess
+	if err != nil {
+		result = resultError
+	}
+	m.requests.WithLabelValues(method, result).Inc()
+	m.duration.WithLabelValues(method).Observe(duration.Seconds())
+	if err == nil {
+		m.responseSize.WithLabelValues(method).Observe(float64(size))
+	}
+}
+
+// RegisterMetrics registers the metrics of the requests in the prometheus registerer
+func (j *JSONRPC) RegisterMetrics(reg prometheus.Registerer) error {
+	m := j.metrics
+	collectors := []prometheus.Collector{
+		m.requests,
+		m.duration,
+		m.responseSize,
+	}
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sanitizeParams returns the params of a request to be logged, with the long
+// strings truncated so the logs do not hold whole txns or contract codes
+func sanitizeParams(params json.RawMessage) string {
+	var obj interface{}
+	if err := json.Unmarshal(params, &obj); err != nil {
+		return "<invalid>"
+	}
+	data, err := json.Marshal(truncateStrings(obj))
+	if err != nil {
+		return "<invalid>"
+	}
+	if len(data) > maxLoggedParamsLen {
+		return string(data[:maxLoggedParamsLen]) + "..."
+	}
+	return string(data)
+}
+
+func truncateStrings(obj interface{}) interface{} {
+	switch obj := obj.(type) {
+	case string:
+		if len(obj) > maxLoggedParamLen {
+			return obj[:maxLoggedParamLen] + "..."
+		}
+		return obj
+	case []interface{}:
+		for i, v := range obj {
+			obj[i] = truncateStrings(v)
+		}
+		return obj
+	case map[string]interface{}:
+		for k, v := range obj {
+			obj[k] = truncateStrings(v)
+		}
+		return obj
+	default:
+		return obj
+	}
+}
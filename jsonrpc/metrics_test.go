@@ -0,0 +1,49 @@
+package jsonrpc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_Requests(t *testing.T) {
+	d := newTestDispatcher(hclog.NewNullLogger(), newMockStore())
+	srv := &JSONRPC{dispatcher: d, metrics: d.metrics}
+	assert.NoError(t, srv.RegisterMetrics(prometheus.NewRegistry()))
+
+	handle := func(method string, params string) {
+		d.Handle([]byte(`{"id": 1, "method": "` + method + `", "params": ` + params + `}`))
+	}
+	handle("web3_clientVersion", "[]")
+	handle("web3_clientVersion", "[]")
+	handle("web3_sha3", `["0xz"]`)
+
+	// the methods that do not exist share a label
+	handle("eth_foo", "[]")
+	handle("eth_bar", "[]")
+
+	requests := d.metrics.requests
+	assert.Equal(t, float64(2), testutil.ToFloat64(requests.WithLabelValues("web3_clientVersion", resultSuccess)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(requests.WithLabelValues("web3_sha3", resultError)))
+	assert.Equal(t, float64(2), testutil.ToFloat64(requests.WithLabelValues(unknownMethod, resultError)))
+
+	// the sizes are only observed for the responses
+	assert.Equal(t, 1, testutil.CollectAndCount(d.metrics.responseSize))
+	assert.Equal(t, 3, testutil.CollectAndCount(d.metrics.duration))
+}
+
+func TestSanitizeParams(t *testing.T) {
+	long := "0x" + strings.Repeat("ab", 100)
+
+	assert.Equal(t, `["0x1",true]`, sanitizeParams([]byte(`["0x1", true]`)))
+	assert.Equal(t, `[{"data":"`+long[:maxLoggedParamLen]+`..."}]`, sanitizeParams([]byte(`[{"data": "`+long+`"}]`)))
+	assert.Equal(t, "<invalid>", sanitizeParams([]byte(`[`)))
+
+	// the params are capped
+	many := "[" + strings.Repeat(`"0x1",`, 300) + `"0x1"]`
+	assert.Len(t, sanitizeParams([]byte(many)), maxLoggedParamsLen+3)
+}
@@ -0,0 +1,313 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// jwtClockSkew is the tolerance on the times of the claims of the jwt tokens
+const jwtClockSkew = 60 * time.Second
+
+// gzipMinSize is the min size of the responses that are compressed, the smaller
+// ones are not worth it
+const gzipMinSize = 1024
+
+// AccessConfig restricts the access to the server, so that a node can expose a public endpoint
+type AccessConfig struct {
+	// CorsOrigins are the origins allowed to call the server from a browser, any origin if empty
+	CorsOrigins []string
+
+	// AuthTokens are the bearer tokens accepted by the server
+	AuthTokens []string
+
+	// JWTSecret is the secret of the HS256 jwt bearer tokens accepted by the server.
+	// The requests are not authenticated if neither the tokens nor the secret are set
+	JWTSecret []byte
+
+	// AllowMethods are the methods allowed, all of them if empty. The patterns
+	// match the method names like path.Match, for example 'eth_*'
+	AllowMethods []string
+
+	// DenyMethods are the methods denied even if they are allowed
+	DenyMethods []string
+}
+
+func methodNotAllowed(method string) error {
+	return &ErrorObject{Code: -32601, Message: fmt.Sprintf("the method %s is not allowed", method)}
+}
+
+// middleware wraps the handler of the http requests
+type middleware func(http.Handler) http.Handler
+
+// chain wraps the handler with the middlewares, the first one handles the requests first
+func chain(h http.Handler, middlewares ...middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// corsMiddleware sets the cors headers of the allowed origins and answers the preflight requests
+func corsMiddleware(origins []string) middleware {
+	allowed := map[string]struct{}{}
+	for _, origin := range origins {
+		allowed[origin] = struct{}{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if len(allowed) == 0 {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else if origin := req.Header.Get("Origin"); origin != "" {
+				w.Header().Add("Vary", "Origin")
+				if _, ok := allowed[origin]; ok {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+				}
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+
+			if req.Method == "OPTIONS" {
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// authMiddleware rejects the requests without a valid bearer token
+func authMiddleware(tokens []string, jwtSecret []byte) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+			if !validToken(token, tokens, jwtSecret, time.Now()) {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+func validToken(token string, tokens []string, jwtSecret []byte, now time.Time) bool {
+	if token == "" {
+		return false
+	}
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return true
+		}
+	}
+	if len(jwtSecret) != 0 {
+		return verifyJWT(token, jwtSecret, now) == nil
+	}
+	return false
+}
+
+// verifyJWT checks the HS256 signature of the jwt token and the times of its claims, if set
+func verifyJWT(token string, secret []byte, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("expected 3 parts in the token but found %d", len(parts))
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return err
+	}
+	if header.Alg != "HS256" {
+		return fmt.Errorf("unsupported algorithm '%s'", header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	var claims struct {
+		Exp *int64 `json:"exp"`
+		Nbf *int64 `json:"nbf"`
+		Iat *int64 `json:"iat"`
+	}
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return err
+	}
+	if claims.Exp != nil && now.After(time.Unix(*claims.Exp, 0).Add(jwtClockSkew)) {
+		return fmt.Errorf("the token is expired")
+	}
+	if claims.Nbf != nil && now.Add(jwtClockSkew).Before(time.Unix(*claims.Nbf, 0)) {
+		return fmt.Errorf("the token is not valid yet")
+	}
+	if claims.Iat != nil && now.Add(jwtClockSkew).Before(time.Unix(*claims.Iat, 0)) {
+		return fmt.Errorf("the token is issued in the future")
+	}
+	return nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	buf, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, v)
+}
+
+// methodACL decides which methods can be called
+type methodACL struct {
+	allow []string
+	deny  []string
+}
+
+func newMethodACL(allow, deny []string) (*methodACL, error) {
+	for _, pattern := range append(append([]string{}, allow...), deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid method pattern '%s': %v", pattern, err)
+		}
+	}
+	return &methodACL{allow: allow, deny: deny}, nil
+}
+
+// check returns an error if the method is denied or is not allowed
+func (a *methodACL) check(method string) error {
+	if a == nil {
+		return nil
+	}
+	if matchMethod(a.deny, method) {
+		return methodNotAllowed(method)
+	}
+	if len(a.allow) != 0 && !matchMethod(a.allow, method) {
+		return methodNotAllowed(method)
+	}
+	return nil
+}
+
+func matchMethod(patterns []string, method string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, method); ok {
+			return true
+		}
+	}
+	return false
+}
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// acceptsGzip returns true if the Accept-Encoding header of a request accepts gzip
+func acceptsGzip(header string) bool {
+	for _, enc := range strings.Split(header, ",") {
+		parts := strings.Split(enc, ";")
+		if name := strings.TrimSpace(parts[0]); name != "gzip" && name != "*" {
+			continue
+		}
+		if len(parts) == 1 {
+			return true
+		}
+		q := strings.TrimSpace(parts[1])
+		if !strings.HasPrefix(q, "q=") {
+			return true
+		}
+		if v, err := strconv.ParseFloat(strings.TrimPrefix(q, "q="), 64); err == nil && v > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the response until it is large enough to be compressed
+type gzipResponseWriter struct {
+	http.ResponseWriter
+
+	status int
+	buf    bytes.Buffer
+	gz     *gzip.Writer
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	w.buf.Write(b)
+	if w.buf.Len() < gzipMinSize {
+		return len(b), nil
+	}
+
+	// the response is compressed from now on
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Del("Content-Length")
+	w.writeHeader()
+
+	w.gz = gzipWriters.Get().(*gzip.Writer)
+	w.gz.Reset(w.ResponseWriter)
+	if _, err := w.gz.Write(w.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	w.buf.Reset()
+	return len(b), nil
+}
+
+func (w *gzipResponseWriter) writeHeader() {
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// close writes the response, which is not compressed if it is too small
+func (w *gzipResponseWriter) close() error {
+	if w.gz == nil {
+		w.writeHeader()
+		_, err := w.ResponseWriter.Write(w.buf.Bytes())
+		return err
+	}
+	err := w.gz.Close()
+	gzipWriters.Put(w.gz)
+	return err
+}
+
+// gzipMiddleware compresses the large responses of the requests that accept gzip.
+// The websocket upgrades are not compressed, since the connection is hijacked
+func gzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if websocket.IsWebSocketUpgrade(req) {
+			next.ServeHTTP(w, req)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(req.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.close()
+		next.ServeHTTP(gw, req)
+	})
+}
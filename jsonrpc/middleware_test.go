@@ -0,0 +1,173 @@
+package jsonrpc
+
+import (
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func signJWT(secret []byte, claims string) string {
+	enc := base64.RawURLEncoding
+	token := enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + enc.EncodeToString([]byte(claims))
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(token))
+	return token + "." + enc.EncodeToString(mac.Sum(nil))
+}
+
+func serveAccess(access *AccessConfig, method string, header http.Header) *httptest.ResponseRecorder {
+	j := &JSONRPC{config: &Config{Access: access}}
+	h := j.middlewares(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	req := httptest.NewRequest(method, "/", nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_Cors(t *testing.T) {
+	// any origin is allowed by default
+	w := serveAccess(nil, "POST", http.Header{"Origin": {"http://a.com"}})
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "ok", w.Body.String())
+
+	access := &AccessConfig{CorsOrigins: []string{"http://a.com"}}
+
+	w = serveAccess(access, "POST", http.Header{"Origin": {"http://a.com"}})
+	assert.Equal(t, "http://a.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Origin", w.Header().Get("Vary"))
+
+	w = serveAccess(access, "POST", http.Header{"Origin": {"http://b.com"}})
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	// the preflight requests are answered by the middleware
+	w = serveAccess(access, "OPTIONS", http.Header{"Origin": {"http://a.com"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestMiddleware_Auth(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Now().Unix()
+	access := &AccessConfig{AuthTokens: []string{"token"}, JWTSecret: secret}
+
+	cases := []struct {
+		auth string
+		code int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer token", http.StatusOK},
+		{"Bearer token2", http.StatusUnauthorized},
+		{"Bearer " + signJWT(secret, `{"iat":1}`), http.StatusOK},
+		{"Bearer " + signJWT([]byte("other"), `{"iat":1}`), http.StatusUnauthorized},
+		{"Bearer " + signJWT(secret, `{"exp":1}`), http.StatusUnauthorized},
+		{"Bearer " + signJWT(secret, `{"nbf":`+strconv.FormatInt(now+3600, 10)+`}`), http.StatusUnauthorized},
+		{"Bearer " + signJWT(secret, `{"exp":`+strconv.FormatInt(now+3600, 10)+`}`), http.StatusOK},
+	}
+	for _, c := range cases {
+		w := serveAccess(access, "POST", http.Header{"Authorization": {c.auth}})
+		assert.Equal(t, c.code, w.Code, c.auth)
+	}
+
+	// the requests are not authenticated without tokens nor secret
+	w := serveAccess(&AccessConfig{}, "POST", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestVerifyJWT_Algorithm(t *testing.T) {
+	enc := base64.RawURLEncoding
+	token := enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." + enc.EncodeToString([]byte(`{}`)) + "."
+	assert.Error(t, verifyJWT(token, []byte("secret"), time.Now()))
+}
+
+func TestMethodACL(t *testing.T) {
+	_, err := newMethodACL([]string{"eth_["}, nil)
+	assert.Error(t, err)
+
+	acl, err := newMethodACL([]string{"eth_*", "web3_*"}, []string{"eth_sendRawTransaction"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, acl.check("eth_blockNumber"))
+	assert.NoError(t, acl.check("web3_clientVersion"))
+	assert.Equal(t, methodNotAllowed("debug_traceBlock"), acl.check("debug_traceBlock"))
+	assert.Equal(t, methodNotAllowed("eth_sendRawTransaction"), acl.check("eth_sendRawTransaction"))
+
+	// every method is allowed without allow list
+	acl, err = newMethodACL(nil, []string{"debug_*"})
+	assert.NoError(t, err)
+	assert.NoError(t, acl.check("net_version"))
+	assert.Error(t, acl.check("debug_getRawBlock"))
+}
+
+func TestDispatcher_MethodACL(t *testing.T) {
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), newMockStore())
+	dispatcher.acl, _ = newMethodACL(nil, []string{"eth_*"})
+
+	_, err := dispatcher.Handle([]byte(`{"id": 1, "method": "eth_blockNumber", "params": []}`))
+	assert.Equal(t, methodNotAllowed("eth_blockNumber"), err)
+
+	_, err = dispatcher.HandleWs([]byte(`{"id": 1, "method": "eth_subscribe", "params": ["newHeads"]}`), &mockWsConn{})
+	assert.Equal(t, methodNotAllowed("eth_subscribe"), err)
+
+	_, err = dispatcher.Handle([]byte(`{"id": 1, "method": "web3_clientVersion", "params": []}`))
+	assert.NoError(t, err)
+}
+
+func serveGzip(body string, acceptEncoding string) *httptest.ResponseRecorder {
+	h := gzipMiddleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(body))
+	}))
+
+	req := httptest.NewRequest("POST", "/", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_Gzip(t *testing.T) {
+	large := strings.Repeat("a", gzipMinSize)
+
+	w := serveGzip(large, "gzip, deflate")
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+
+	r, err := gzip.NewReader(w.Body)
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, large, string(body))
+
+	// the small responses are not compressed
+	w = serveGzip("ok", "gzip")
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "ok", w.Body.String())
+
+	// gzip is not accepted
+	for _, enc := range []string{"", "deflate", "gzip;q=0"} {
+		w = serveGzip(large, enc)
+		assert.Empty(t, w.Header().Get("Content-Encoding"), enc)
+		assert.Equal(t, large, w.Body.String())
+	}
+}
@@ -13,10 +13,13 @@ func (n *Net) Version() (interface{}, error) {
 
 // Listening returns true if client is actively listening for network connections
 func (n *Net) Listening() (interface{}, error) {
-	return true, nil
+	return n.d.network != nil, nil
 }
 
 // PeerCount returns number of peers currently connected to the client
 func (n *Net) PeerCount() (interface{}, error) {
-	return argUintPtr(0), nil
+	if n.d.network == nil {
+		return argUintPtr(0), nil
+	}
+	return argUintPtr(uint64(len(n.d.network.Peers()))), nil
 }
@@ -3,10 +3,49 @@ package jsonrpc
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/0xPolygon/minimal/blockchain/bloombits"
 	"github.com/0xPolygon/minimal/types"
 )
 
+const (
+	defaultLogsBlockRange  = 10000
+	defaultLogsMaxResults  = 10000
+	defaultLogsScanTimeout = 10 * time.Second
+)
+
+var errLogsScanTimeout = &ErrorObject{Code: -32005, Message: "query timeout exceeded, retry with a smaller block range"}
+
+func errLogsBlockRange(max uint64) error {
+	return &ErrorObject{Code: -32005, Message: fmt.Sprintf("query exceeds the max block range of %d blocks", max)}
+}
+
+func errLogsMaxResults(max uint64) error {
+	return &ErrorObject{Code: -32005, Message: fmt.Sprintf("query returned more than %d results", max)}
+}
+
+// LogsLimits bound the work of a query of the logs, each limit is disabled if 0
+type LogsLimits struct {
+	// BlockRange is the max number of blocks of a query
+	BlockRange uint64
+
+	// MaxResults is the max number of logs returned by a query
+	MaxResults uint64
+
+	// ScanTimeout is the max time spent scanning the blocks of a query
+	ScanTimeout time.Duration
+}
+
+// DefaultLogsLimits returns the default limits of the queries of the logs
+func DefaultLogsLimits() *LogsLimits {
+	return &LogsLimits{
+		BlockRange:  defaultLogsBlockRange,
+		MaxResults:  defaultLogsMaxResults,
+		ScanTimeout: defaultLogsScanTimeout,
+	}
+}
+
 // LogFilter is a filter for logs
 type LogFilter struct {
 	BlockHash *types.Hash
@@ -16,6 +55,9 @@ type LogFilter struct {
 
 	Addresses []types.Address
 	Topics    [][]types.Hash
+
+	// matcher of the logs blooms, built once the filter is installed
+	matcher *bloombits.Matcher
 }
 
 // addTopicSet adds specific topics to the log filter topics
@@ -199,3 +241,40 @@ func (l *LogFilter) Match(log *types.Log) bool {
 	}
 	return true
 }
+
+// bloomMatcher returns the matcher of the logs blooms that might include logs of the filter. The
+// matcher of a filter that is not installed is built on every call and not cached, so that the
+// filter is never written once it is shared
+func (l *LogFilter) bloomMatcher() *bloombits.Matcher {
+	if l.matcher != nil {
+		return l.matcher
+	}
+	return l.newBloomMatcher()
+}
+
+func (l *LogFilter) newBloomMatcher() *bloombits.Matcher {
+	addrs := [][]byte{}
+	for _, addr := range l.Addresses {
+		addrs = append(addrs, addr.Bytes())
+	}
+	groups := [][][]byte{addrs}
+	for _, sub := range l.Topics {
+		topics := [][]byte{}
+		for _, topic := range sub {
+			topics = append(topics, topic.Bytes())
+		}
+		groups = append(groups, topics)
+	}
+
+	return bloombits.NewMatcher(groups)
+}
+
+// MatchHeader returns whether the block of the header might include logs for this filter
+func (l *LogFilter) MatchHeader(header *types.Header) bool {
+	if header.LogsBloom == (types.Bloom{}) {
+		// the blocks sealed before the logs bloom was set in the
+		// header have an empty bloom, check the receipts if any
+		return header.ReceiptsRoot != types.EmptyRootHash
+	}
+	return l.bloomMatcher().MatchBloom(&header.LogsBloom)
+}
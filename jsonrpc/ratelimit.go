@@ -0,0 +1,356 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/0xPolygon/minimal/crypto"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// maxLimiterEntries is the number of tracked clients after which
+// the idle entries are pruned from the limiter
+const maxLimiterEntries = 10000
+
+var errRateLimited = &ErrorObject{Code: -32005, Message: "transaction submission rate limit exceeded"}
+
+// defaultMethodCosts are the costs in tokens of the heavy methods, the other methods cost 1
+var defaultMethodCosts = map[string]float64{
+	"eth_getLogs":       10,
+	"eth_getFilterLogs": 10,
+	"eth_feeHistory":    10,
+	"eth_call":          2,
+	"eth_estimateGas":   2,
+	"debug_*":           20,
+}
+
+// retryHint is the data of the rate limit errors
+type retryHint struct {
+	// RetryAfter is the number of seconds after which the client can retry
+	RetryAfter uint64 `json:"retryAfter"`
+}
+
+// isRateLimited reports whether the error is a rate limit error of the requests or of the txns
+func isRateLimited(err error) bool {
+	obj, ok := err.(*ErrorObject)
+	return ok && obj.Code == errRateLimited.Code
+}
+
+func errRequestLimited(retry time.Duration) error {
+	secs := uint64(math.Ceil(retry.Seconds()))
+	if secs == 0 {
+		secs = 1
+	}
+	return &ErrorObject{Code: -32005, Message: "request rate limit exceeded", Data: &retryHint{RetryAfter: secs}}
+}
+
+// RateLimitConfig is the configuration of the rate limits applied to the
+// requests and to the transaction submissions (eth_sendRawTransaction)
+type RateLimitConfig struct {
+	// RequestRate is the number of tokens per second a single IP can spend on requests,
+	// each request costs the tokens of its method
+	RequestRate float64
+
+	// RequestBurst is the number of tokens an IP can spend at once
+	RequestBurst uint64
+
+	// GlobalRate is the number of tokens per second all the clients can spend on requests
+	GlobalRate float64
+
+	// GlobalBurst is the number of tokens all the clients can spend at once
+	GlobalBurst uint64
+
+	// MethodCosts are the costs in tokens of the methods, which override the default
+	// costs. The patterns match the method names like path.Match, for example 'debug_*'
+	MethodCosts map[string]float64
+
+	// IPRate is the number of txns per second accepted from a single IP
+	IPRate float64
+
+	// IPBurst is the number of txns an IP can submit at once
+	IPBurst uint64
+
+	// SenderRate is the number of txns per second accepted from a single sender
+	SenderRate float64
+
+	// SenderBurst is the number of txns a sender can submit at once
+	SenderBurst uint64
+
+	// BanDuration is the time a client is rejected after exceeding its limit
+	BanDuration time.Duration
+}
+
+// txLimiter limits the transaction submissions per IP and per sender
+type txLimiter struct {
+	ip     *rateLimiter
+	sender *rateLimiter
+}
+
+func newTxLimiter(config *RateLimitConfig) *txLimiter {
+	l := &txLimiter{}
+	if config.IPRate > 0 {
+		l.ip = newRateLimiter(config.IPRate, config.IPBurst, config.BanDuration)
+	}
+	if config.SenderRate > 0 {
+		l.sender = newRateLimiter(config.SenderRate, config.SenderBurst, config.BanDuration)
+	}
+	return l
+}
+
+// allowRequest checks the IP limit if the request body is a txn submission
+func (l *txLimiter) allowRequest(remoteAddr string, reqBody []byte) error {
+	if l == nil || l.ip == nil {
+		return nil
+	}
+
+	var req Request
+	if err := json.Unmarshal(reqBody, &req); err != nil {
+		// let the dispatcher report the invalid request
+		return nil
+	}
+	if req.Method != "eth_sendRawTransaction" {
+		return nil
+	}
+
+	ip, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		ip = remoteAddr
+	}
+	if !l.ip.allow(ip) {
+		return errRateLimited
+	}
+	return nil
+}
+
+// allowTxn checks the sender limit of the txn
+func (l *txLimiter) allowTxn(signer crypto.TxSigner, txn *types.Transaction) error {
+	if l == nil || l.sender == nil {
+		return nil
+	}
+
+	from, err := signer.Sender(txn)
+	if err != nil {
+		// the txpool rejects the txns with an invalid signature
+		return nil
+	}
+	if !l.sender.allow(from.String()) {
+		return errRateLimited
+	}
+	return nil
+}
+
+// requestLimiter limits the requests per IP and of all the clients, weighted by the cost of their methods
+type requestLimiter struct {
+	ip     *rateLimiter
+	global *rateLimiter
+	costs  map[string]float64
+
+	// patterns are the method patterns of the costs, the longest first, so the
+	// most specific pattern that matches a method is always the one used
+	patterns []string
+}
+
+func newRequestLimiter(config *RateLimitConfig) (*requestLimiter, error) {
+	if config.RequestRate <= 0 && config.GlobalRate <= 0 {
+		return nil, nil
+	}
+
+	l := &requestLimiter{costs: map[string]float64{}}
+	for method, cost := range defaultMethodCosts {
+		l.costs[method] = cost
+	}
+	for method, cost := range config.MethodCosts {
+		if _, err := path.Match(method, ""); err != nil {
+			return nil, fmt.Errorf("invalid method pattern '%s': %v", method, err)
+		}
+		if cost < 0 {
+			return nil, fmt.Errorf("the cost of the method '%s' is negative", method)
+		}
+		l.costs[method] = cost
+	}
+	for pattern := range l.costs {
+		l.patterns = append(l.patterns, pattern)
+	}
+	sort.Slice(l.patterns, func(i, j int) bool {
+		if len(l.patterns[i]) != len(l.patterns[j]) {
+			return len(l.patterns[i]) > len(l.patterns[j])
+		}
+		return l.patterns[i] < l.patterns[j]
+	})
+
+	if config.RequestRate > 0 {
+		l.ip = newRateLimiter(config.RequestRate, config.RequestBurst, 0)
+	}
+	if config.GlobalRate > 0 {
+		l.global = newRateLimiter(config.GlobalRate, config.GlobalBurst, 0)
+	}
+	return l, nil
+}
+
+// cost returns the tokens spent by a call to the method
+func (l *requestLimiter) cost(method string) float64 {
+	if cost, ok := l.costs[method]; ok {
+		return cost
+	}
+	for _, pattern := range l.patterns {
+		if ok, _ := path.Match(pattern, method); ok {
+			return l.costs[pattern]
+		}
+	}
+	return 1
+}
+
+// allowRequest spends the cost of the method of the request from the limit of the IP
+// and from the global limit. The IP gets its tokens back if the global limit is reached
+func (l *requestLimiter) allowRequest(remoteAddr string, reqBody []byte) error {
+	if l == nil {
+		return nil
+	}
+
+	var req Request
+	if err := json.Unmarshal(reqBody, &req); err != nil {
+		// let the dispatcher report the invalid request
+		return nil
+	}
+	cost := l.cost(req.Method)
+
+	ip, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		ip = remoteAddr
+	}
+	if l.ip != nil {
+		if ok, retry := l.ip.take(ip, cost); !ok {
+			return errRequestLimited(retry)
+		}
+	}
+	if l.global != nil {
+		if ok, retry := l.global.take("", cost); !ok {
+			if l.ip != nil {
+				l.ip.refund(ip, cost)
+			}
+			return errRequestLimited(retry)
+		}
+	}
+	return nil
+}
+
+// rateLimiter is a token bucket rate limiter keyed by client
+type rateLimiter struct {
+	rate        float64
+	burst       float64
+	banDuration time.Duration
+
+	lock    sync.Mutex
+	buckets map[string]*bucket
+
+	// now returns the current time, replaced in tests
+	now func() time.Time
+}
+
+type bucket struct {
+	tokens      float64
+	last        time.Time
+	bannedUntil time.Time
+}
+
+func newRateLimiter(rate float64, burst uint64, banDuration time.Duration) *rateLimiter {
+	if burst == 0 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:        rate,
+		burst:       float64(burst),
+		banDuration: banDuration,
+		buckets:     map[string]*bucket{},
+		now:         time.Now,
+	}
+}
+
+// allow consumes a token for the key and returns false if the key
+// has no tokens left or is banned
+func (r *rateLimiter) allow(key string) bool {
+	ok, _ := r.take(key, 1)
+	return ok
+}
+
+// take consumes the cost in tokens for the key, or returns false and the time after
+// which the key can retry if it has not enough tokens or is banned. The cost is capped
+// to the burst so that every request can eventually be served
+func (r *rateLimiter) take(key string, cost float64) (bool, time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.now()
+	if len(r.buckets) > maxLimiterEntries {
+		r.prune(now)
+	}
+
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &bucket{tokens: r.burst, last: now}
+		r.buckets[key] = b
+	}
+	if now.Before(b.bannedUntil) {
+		return false, b.bannedUntil.Sub(now)
+	}
+
+	if cost > r.burst {
+		cost = r.burst
+	}
+	b.refill(now, r.rate, r.burst)
+	if b.tokens < cost {
+		if r.banDuration != 0 {
+			b.bannedUntil = now.Add(r.banDuration)
+			return false, r.banDuration
+		}
+		return false, time.Duration((cost - b.tokens) / r.rate * float64(time.Second))
+	}
+	b.tokens -= cost
+	return true, 0
+}
+
+// refund gives back the cost in tokens taken for the key, up to the burst
+func (r *rateLimiter) refund(key string, cost float64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	b, ok := r.buckets[key]
+	if !ok {
+		return
+	}
+	if cost > r.burst {
+		cost = r.burst
+	}
+	b.refill(r.now(), r.rate, r.burst)
+	if b.tokens += cost; b.tokens > r.burst {
+		b.tokens = r.burst
+	}
+}
+
+// prune removes the clients that are not banned and have a full bucket
+func (r *rateLimiter) prune(now time.Time) {
+	for key, b := range r.buckets {
+		if now.Before(b.bannedUntil) {
+			continue
+		}
+		if b.refill(now, r.rate, r.burst); b.tokens >= r.burst {
+			delete(r.buckets, key)
+		}
+	}
+}
+
+func (b *bucket) refill(now time.Time, rate, burst float64) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * rate
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+	}
+	b.last = now
+}
@@ -0,0 +1,255 @@
+package jsonrpc
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xPolygon/minimal/crypto"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRateLimiter(rate float64, burst uint64, ban time.Duration) (*rateLimiter, *time.Time) {
+	now := time.Unix(0, 0)
+	r := newRateLimiter(rate, burst, ban)
+	r.now = func() time.Time {
+		return now
+	}
+	return r, &now
+}
+
+func TestRateLimiter_Burst(t *testing.T) {
+	r, now := newTestRateLimiter(1, 2, 0)
+
+	assert.True(t, r.allow("a"))
+	assert.True(t, r.allow("a"))
+	assert.False(t, r.allow("a"))
+
+	// other keys have their own bucket
+	assert.True(t, r.allow("b"))
+
+	// the bucket refills with time
+	*now = now.Add(time.Second)
+	assert.True(t, r.allow("a"))
+	assert.False(t, r.allow("a"))
+}
+
+func TestRateLimiter_Ban(t *testing.T) {
+	r, now := newTestRateLimiter(1, 1, 10*time.Second)
+
+	assert.True(t, r.allow("a"))
+	assert.False(t, r.allow("a"))
+
+	// the key is rejected while banned even if the bucket refilled
+	*now = now.Add(5 * time.Second)
+	assert.False(t, r.allow("a"))
+
+	*now = now.Add(5 * time.Second)
+	assert.True(t, r.allow("a"))
+}
+
+func TestRateLimiter_Prune(t *testing.T) {
+	r, now := newTestRateLimiter(1, 1, 0)
+	r.allow("a")
+
+	// full buckets are pruned
+	*now = now.Add(time.Second)
+	r.prune(*now)
+	assert.Len(t, r.buckets, 0)
+}
+
+func TestTxLimiter_IP(t *testing.T) {
+	l := newTxLimiter(&RateLimitConfig{IPRate: 1, IPBurst: 1})
+
+	sendRaw := []byte(`{"method": "eth_sendRawTransaction"}`)
+
+	assert.NoError(t, l.allowRequest("127.0.0.1:1000", sendRaw))
+	assert.Equal(t, errRateLimited, l.allowRequest("127.0.0.1:2000", sendRaw))
+	assert.NoError(t, l.allowRequest("127.0.0.2:1000", sendRaw))
+
+	// other methods are not limited
+	assert.NoError(t, l.allowRequest("127.0.0.1:1000", []byte(`{"method": "eth_blockNumber"}`)))
+
+	// a nil limiter allows everything
+	var nilLimiter *txLimiter
+	assert.NoError(t, nilLimiter.allowRequest("127.0.0.1:1000", sendRaw))
+}
+
+func TestTxLimiter_Sender(t *testing.T) {
+	l := newTxLimiter(&RateLimitConfig{SenderRate: 1, SenderBurst: 1})
+	signer := crypto.NewEIP155Signer(100)
+
+	key0, _ := crypto.GenerateKey()
+	key1, _ := crypto.GenerateKey()
+
+	signTxn := func(key *ecdsa.PrivateKey, nonce uint64) *types.Transaction {
+		txn, err := signer.SignTx(&types.Transaction{
+			Nonce:    nonce,
+			Value:    big.NewInt(1),
+			GasPrice: big.NewInt(1),
+		}, key)
+		assert.NoError(t, err)
+		return txn
+	}
+
+	assert.NoError(t, l.allowTxn(signer, signTxn(key0, 0)))
+	assert.Equal(t, errRateLimited, l.allowTxn(signer, signTxn(key0, 1)))
+
+	// another sender is not limited
+	assert.NoError(t, l.allowTxn(signer, signTxn(key1, 0)))
+}
+
+func TestRateLimiter_Take(t *testing.T) {
+	r, now := newTestRateLimiter(2, 10, 0)
+
+	ok, _ := r.take("a", 8)
+	assert.True(t, ok)
+
+	// the retry hint is the time to refill the missing tokens
+	ok, retry := r.take("a", 5)
+	assert.False(t, ok)
+	assert.Equal(t, 1500*time.Millisecond, retry)
+
+	*now = now.Add(retry)
+	ok, _ = r.take("a", 5)
+	assert.True(t, ok)
+
+	// the cost is capped to the burst
+	*now = now.Add(time.Hour)
+	ok, _ = r.take("a", 100)
+	assert.True(t, ok)
+}
+
+func TestRateLimiter_TakeBanned(t *testing.T) {
+	r, now := newTestRateLimiter(1, 1, 10*time.Second)
+
+	r.take("a", 1)
+	ok, retry := r.take("a", 1)
+	assert.False(t, ok)
+	assert.Equal(t, 10*time.Second, retry)
+
+	*now = now.Add(4 * time.Second)
+	_, retry = r.take("a", 1)
+	assert.Equal(t, 6*time.Second, retry)
+}
+
+func TestRequestLimiter(t *testing.T) {
+	l, err := newRequestLimiter(&RateLimitConfig{
+		RequestRate:  1,
+		RequestBurst: 10,
+		MethodCosts:  map[string]float64{"eth_call": 0, "net_*": 4},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, float64(10), l.cost("eth_getLogs"))
+	assert.Equal(t, float64(20), l.cost("debug_traceBlock"))
+	assert.Equal(t, float64(0), l.cost("eth_call"))
+	assert.Equal(t, float64(4), l.cost("net_version"))
+	assert.Equal(t, float64(1), l.cost("eth_blockNumber"))
+
+	getLogs := []byte(`{"method": "eth_getLogs"}`)
+
+	assert.NoError(t, l.allowRequest("127.0.0.1:1000", getLogs))
+	err = l.allowRequest("127.0.0.1:2000", getLogs)
+	assert.Equal(t, errRequestLimited(10*time.Second), err)
+	assert.Equal(t, uint64(10), err.(*ErrorObject).Data.(*retryHint).RetryAfter)
+
+	// other IPs have their own limit and the free methods are not limited
+	assert.NoError(t, l.allowRequest("127.0.0.2:1000", getLogs))
+	assert.NoError(t, l.allowRequest("127.0.0.1:1000", []byte(`{"method": "eth_call"}`)))
+
+	// a nil limiter allows everything
+	var nilLimiter *requestLimiter
+	assert.NoError(t, nilLimiter.allowRequest("127.0.0.1:1000", getLogs))
+}
+
+func TestRequestLimiter_Global(t *testing.T) {
+	l, err := newRequestLimiter(&RateLimitConfig{GlobalRate: 1, GlobalBurst: 2})
+	assert.NoError(t, err)
+
+	req := []byte(`{"method": "eth_blockNumber"}`)
+	assert.NoError(t, l.allowRequest("127.0.0.1:1000", req))
+	assert.NoError(t, l.allowRequest("127.0.0.2:1000", req))
+	assert.Error(t, l.allowRequest("127.0.0.3:1000", req))
+}
+
+func TestRequestLimiter_Patterns(t *testing.T) {
+	l, err := newRequestLimiter(&RateLimitConfig{
+		RequestRate: 1,
+		MethodCosts: map[string]float64{"eth_*": 3, "eth_get*": 5},
+	})
+	assert.NoError(t, err)
+
+	// the exact method first, and the longest pattern otherwise
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, float64(10), l.cost("eth_getLogs"))
+		assert.Equal(t, float64(5), l.cost("eth_getBalance"))
+		assert.Equal(t, float64(3), l.cost("eth_chainId"))
+	}
+}
+
+func TestRequestLimiter_GlobalRefund(t *testing.T) {
+	l, err := newRequestLimiter(&RateLimitConfig{RequestRate: 1, RequestBurst: 2, GlobalRate: 1, GlobalBurst: 1})
+	assert.NoError(t, err)
+
+	now := time.Unix(0, 0)
+	l.ip.now = func() time.Time {
+		return now
+	}
+	l.global.now = l.ip.now
+
+	req := []byte(`{"method": "eth_blockNumber"}`)
+	assert.NoError(t, l.allowRequest("127.0.0.1:1000", req))
+
+	// the IP keeps its tokens if the global limit rejects the request
+	assert.Error(t, l.allowRequest("127.0.0.1:1000", req))
+	assert.Equal(t, float64(1), l.ip.buckets["127.0.0.1"].tokens)
+}
+
+func TestRequestLimiter_Config(t *testing.T) {
+	// the limiter is disabled without rates
+	l, err := newRequestLimiter(&RateLimitConfig{IPRate: 1})
+	assert.NoError(t, err)
+	assert.Nil(t, l)
+
+	_, err = newRequestLimiter(&RateLimitConfig{RequestRate: 1, MethodCosts: map[string]float64{"eth_[": 1}})
+	assert.Error(t, err)
+
+	_, err = newRequestLimiter(&RateLimitConfig{RequestRate: 1, MethodCosts: map[string]float64{"eth_call": -1}})
+	assert.Error(t, err)
+}
+
+func TestHTTPRequestLimited(t *testing.T) {
+	requests, _ := newRequestLimiter(&RateLimitConfig{RequestRate: 1, RequestBurst: 1})
+	j := &JSONRPC{
+		dispatcher: newTestDispatcher(hclog.NewNullLogger(), newMockStore()),
+		requests:   requests,
+	}
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(`{"id": 1, "method": "web3_clientVersion", "params": []}`))
+		w := httptest.NewRecorder()
+		j.handle(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, send().Code)
+
+	w := send()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+}
+
+func TestIsRateLimited(t *testing.T) {
+	// only the rate limits are sent as 429
+	assert.True(t, isRateLimited(errRateLimited))
+	assert.True(t, isRateLimited(errRequestLimited(time.Second)))
+	assert.False(t, isRateLimited(&ErrorObject{Code: -32000, Message: "internal"}))
+	assert.False(t, isRateLimited(errShuttingDown))
+}
@@ -0,0 +1,150 @@
+package jsonrpc
+
+import (
+	"context"
+	"sync"
+)
+
+var errShuttingDown = &ErrorObject{Code: -32000, Message: "server is shutting down"}
+
+// inflightRequests tracks the requests being handled, which the shutdown waits for
+type inflightRequests struct {
+	lock    sync.Mutex
+	closing bool
+	wg      sync.WaitGroup
+}
+
+// add tracks a new request, and returns false if the server is shutting down
+func (r *inflightRequests) add() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closing {
+		return false
+	}
+	r.wg.Add(1)
+	return true
+}
+
+func (r *inflightRequests) done() {
+	r.wg.Done()
+}
+
+// stop rejects the new requests
+func (r *inflightRequests) stop() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.closing = true
+}
+
+// wait waits for the requests in flight, and returns false if they did not finish before the context
+func (r *inflightRequests) wait(ctx context.Context) bool {
+	doneCh := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(doneCh)
+	}()
+
+	select {
+	case <-doneCh:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// streamConn is a websocket or an ipc connection, which stays open
+// across the requests and has to be closed on shutdown
+type streamConn interface {
+	wsConn
+
+	// shutdown notifies the client that the server is shutting down and closes the connection
+	shutdown()
+}
+
+func (c *ipcConn) shutdown() {
+	c.conn.Close()
+}
+
+// trackConn adds the connection to the ones closed on shutdown, and returns false if the server is shutting down
+func (j *JSONRPC) trackConn(conn streamConn) bool {
+	j.connsLock.Lock()
+	defer j.connsLock.Unlock()
+
+	if j.connsClosed {
+		return false
+	}
+	if j.conns == nil {
+		j.conns = map[streamConn]struct{}{}
+	}
+	j.conns[conn] = struct{}{}
+	return true
+}
+
+func (j *JSONRPC) untrackConn(conn streamConn) {
+	j.connsLock.Lock()
+	defer j.connsLock.Unlock()
+
+	delete(j.conns, conn)
+}
+
+// closeConns closes the websocket and the ipc connections
+func (j *JSONRPC) closeConns() {
+	j.connsLock.Lock()
+	conns := j.conns
+	j.conns = nil
+	j.connsClosed = true
+	j.connsLock.Unlock()
+
+	for conn := range conns {
+		conn.shutdown()
+	}
+}
+
+// Close shuts down the server. It stops accepting connections and requests, waits for the
+// requests in flight up to the shutdown timeout, and then closes the websocket and the ipc
+// connections, which uninstalls their subscriptions. The requests still running afterwards
+// are cancelled and do not get a response, but they are waited for since they read the
+// stores closed after the server. The ipc socket is removed
+func (j *JSONRPC) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), j.config.ShutdownTimeout)
+	defer cancel()
+
+	j.inflight.stop()
+
+	var err error
+	if j.ipcListener != nil {
+		// closing the listener removes the socket
+		err = j.ipcListener.Close()
+	}
+
+	// the http requests are tracked as the other ones, so the http server is only
+	// forcibly closed if the requests did not finish in time
+	drained := true
+	if j.httpServer != nil {
+		if shutdownErr := j.httpServer.Shutdown(ctx); shutdownErr != nil {
+			drained = false
+		}
+	}
+	if !j.inflight.wait(ctx) {
+		drained = false
+	}
+	// the connections are closed before the requests still running are
+	// cancelled, so that the cancelled requests cannot write a response
+	j.closeConns()
+	if !drained {
+		j.logger.Warn("requests in flight did not finish before the shutdown timeout, cancelling them", "timeout", j.config.ShutdownTimeout)
+		if j.httpServer != nil {
+			j.httpServer.Close()
+		}
+		j.dispatcher.cancelRequests()
+	}
+
+	// the handlers that timed out run in the background of their requests
+	j.inflight.wait(context.Background())
+	j.dispatcher.waitRequests()
+	j.dispatcher.Close()
+
+	return err
+}
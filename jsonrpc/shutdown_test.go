@@ -0,0 +1,113 @@
+package jsonrpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBlockingService struct {
+	startedCh   chan struct{}
+	releaseCh   chan struct{}
+	cancelledCh chan struct{}
+}
+
+func (m *mockBlockingService) Block(ctx context.Context) (interface{}, error) {
+	m.startedCh <- struct{}{}
+	select {
+	case <-m.releaseCh:
+		return "done", nil
+	case <-ctx.Done():
+		close(m.cancelledCh)
+		return nil, requestError(ctx)
+	}
+}
+
+func newShutdownTestServer(t *testing.T, timeout time.Duration) (*JSONRPC, *mockBlockingService, *websocket.Conn, func()) {
+	service := &mockBlockingService{
+		startedCh:   make(chan struct{}, 1),
+		releaseCh:   make(chan struct{}),
+		cancelledCh: make(chan struct{}),
+	}
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), newMockStore())
+	dispatcher.registerService("mock", service)
+
+	srv := &JSONRPC{
+		logger:     hclog.NewNullLogger(),
+		config:     &Config{ShutdownTimeout: timeout},
+		dispatcher: dispatcher,
+	}
+	httpSrv := httptest.NewServer(http.HandlerFunc(srv.handleWs))
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpSrv.URL, "http"), nil)
+	assert.NoError(t, err)
+
+	return srv, service, conn, func() {
+		conn.Close()
+		httpSrv.Close()
+	}
+}
+
+func TestShutdown_Drain(t *testing.T) {
+	srv, service, conn, closeFn := newShutdownTestServer(t, 5*time.Second)
+	defer closeFn()
+
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"id": 1, "method": "mock_block", "params": []}`)))
+	<-service.startedCh
+
+	closedCh := make(chan error)
+	go func() {
+		closedCh <- srv.Close()
+	}()
+
+	// the new requests are rejected once the shutdown starts
+	for {
+		assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"id": 2, "method": "web3_clientVersion", "params": []}`)))
+		_, msg, err := conn.ReadMessage()
+		assert.NoError(t, err)
+		if strings.Contains(string(msg), "shutting down") {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the request in flight gets its response before the connection is closed
+	close(service.releaseCh)
+
+	_, msg, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Contains(t, string(msg), `"done"`)
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+
+	assert.NoError(t, <-closedCh)
+}
+
+func TestShutdown_Timeout(t *testing.T) {
+	srv, service, conn, closeFn := newShutdownTestServer(t, 50*time.Millisecond)
+	defer closeFn()
+	defer close(service.releaseCh)
+
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"id": 1, "method": "mock_block", "params": []}`)))
+	<-service.startedCh
+
+	// the shutdown cancels the request in flight past the timeout, and waits for it to return
+	assert.NoError(t, srv.Close())
+
+	select {
+	case <-service.cancelledCh:
+	default:
+		t.Fatal("the request in flight was not cancelled")
+	}
+
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+}
@@ -0,0 +1,294 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// traceFilterBlockRange is the max number of blocks replayed by a trace filter
+const traceFilterBlockRange = 100
+
+// Trace is the trace jsonrpc endpoint, which returns the calls of the txns
+// as the flat traces of OpenEthereum
+type Trace struct {
+	d *Dispatcher
+}
+
+type traceAction struct {
+	CallType string         `json:"callType,omitempty"`
+	From     types.Address  `json:"from"`
+	To       *types.Address `json:"to,omitempty"`
+	Gas      argUint64      `json:"gas"`
+	Input    *argBytes      `json:"input,omitempty"`
+	Init     *argBytes      `json:"init,omitempty"`
+	Value    *argBig        `json:"value"`
+}
+
+type traceCallResult struct {
+	GasUsed argUint64      `json:"gasUsed"`
+	Output  *argBytes      `json:"output,omitempty"`
+	Address *types.Address `json:"address,omitempty"`
+	Code    *argBytes      `json:"code,omitempty"`
+}
+
+// flatTrace is a call of a txn, which is located in the tree of the calls by its trace address
+type flatTrace struct {
+	Action              *traceAction     `json:"action"`
+	BlockHash           types.Hash       `json:"blockHash"`
+	BlockNumber         uint64           `json:"blockNumber"`
+	Error               string           `json:"error,omitempty"`
+	Result              *traceCallResult `json:"result"`
+	Subtraces           int              `json:"subtraces"`
+	TraceAddress        []int            `json:"traceAddress"`
+	TransactionHash     types.Hash       `json:"transactionHash"`
+	TransactionPosition uint64           `json:"transactionPosition"`
+	Type                string           `json:"type"`
+}
+
+// traceError returns the error of a call as reported by OpenEthereum
+func traceError(err error) string {
+	switch err {
+	case runtime.ErrExecutionReverted:
+		return "Reverted"
+	case runtime.ErrOutOfGas, runtime.ErrGasConsumed, runtime.ErrCodeStoreOutOfGas:
+		return "Out of gas"
+	case runtime.ErrOpcodeNotFound:
+		return "Bad instruction"
+	case runtime.ErrJumpDestNotValid:
+		return "Bad jump destination"
+	case runtime.ErrStackUnderflow:
+		return "Stack underflow"
+	case runtime.ErrStackOverflow:
+		return "Out of stack"
+	case runtime.ErrWriteProtection:
+		return "Mutable Call In Static Context"
+	default:
+		return err.Error()
+	}
+}
+
+// flattenCalls appends the traces of the frame and of its calls in depth first order
+func flattenCalls(res []*flatTrace, f *runtime.CallFrame, address []int, block *types.Block, indx int) []*flatTrace {
+	value := f.Value
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	trace := &flatTrace{
+		Action: &traceAction{
+			From:  f.From,
+			Gas:   argUint64(f.Gas),
+			Value: argBigPtr(value),
+		},
+		BlockHash:           block.Hash(),
+		BlockNumber:         block.Number(),
+		Subtraces:           len(f.Calls),
+		TraceAddress:        append([]int{}, address...),
+		TransactionHash:     block.Transactions[indx].Hash,
+		TransactionPosition: uint64(indx),
+	}
+
+	to := f.To
+	if f.Type.IsCreate() {
+		trace.Type = "create"
+		trace.Action.Init = argBytesPtr(f.Input)
+		if f.Err == nil {
+			trace.Result = &traceCallResult{
+				GasUsed: argUint64(f.GasUsed),
+				Address: &to,
+				Code:    argBytesPtr(f.Output),
+			}
+		}
+	} else {
+		trace.Type = "call"
+		trace.Action.CallType = strings.ToLower(f.Type.String())
+		trace.Action.To = &to
+		trace.Action.Input = argBytesPtr(f.Input)
+		if f.Err == nil {
+			trace.Result = &traceCallResult{
+				GasUsed: argUint64(f.GasUsed),
+				Output:  argBytesPtr(f.Output),
+			}
+		}
+	}
+	if f.Err != nil {
+		trace.Error = traceError(f.Err)
+	}
+	res = append(res, trace)
+
+	for i, call := range f.Calls {
+		res = flattenCalls(res, call, append(address, i), block, indx)
+	}
+	return res
+}
+
+// traceBlock replays the txns of the block and returns the traces of their calls
+func (t *Trace) traceBlock(block *types.Block) ([]*flatTrace, error) {
+	res := []*flatTrace{}
+	if len(block.Transactions) == 0 {
+		return res, nil
+	}
+
+	tracers := make([]runtime.Tracer, 0, len(block.Transactions))
+	for range block.Transactions {
+		tracers = append(tracers, runtime.NewCallTracer(false))
+	}
+	if _, err := t.d.replayBlock(block, tracers); err != nil {
+		return nil, err
+	}
+
+	for indx, tracer := range tracers {
+		if root := tracer.(*runtime.CallTracer).Result(); root != nil {
+			res = flattenCalls(res, root, []int{}, block, indx)
+		}
+	}
+	return res, nil
+}
+
+// Transaction replays a mined transaction on top of the state of its block and returns the traces of its calls
+func (t *Trace) Transaction(hash types.Hash) (interface{}, error) {
+	block, indx, ok := t.d.getTxnBlock(hash)
+	if !ok {
+		return nil, fmt.Errorf("txn %s not found", hash)
+	}
+	if err := t.d.checkParentState(block); err != nil {
+		return nil, err
+	}
+
+	tracer := runtime.NewCallTracer(false)
+	if _, _, _, err := t.d.store.TraceBlockTxn(block, indx, tracer); err != nil {
+		return nil, err
+	}
+
+	res := []*flatTrace{}
+	if root := tracer.Result(); root != nil {
+		res = flattenCalls(res, root, []int{}, block, indx)
+	}
+	return res, nil
+}
+
+// Block replays the txns of the block on top of the state of its parent and returns the traces of their calls
+func (t *Trace) Block(number BlockNumber) (interface{}, error) {
+	header, err := t.d.getBlockHeaderImpl(number)
+	if err != nil {
+		return nil, err
+	}
+	block, ok := t.d.store.GetBlockByHash(header.Hash, true)
+	if !ok {
+		return nil, fmt.Errorf("block %d not found", header.Number)
+	}
+	return t.traceBlock(block)
+}
+
+// TraceFilter selects the traces of a range of blocks by the addresses of their calls
+type TraceFilter struct {
+	// FromBlock and ToBlock are the range of the blocks, the latest block if not set
+	FromBlock *BlockNumber `json:"fromBlock"`
+	ToBlock   *BlockNumber `json:"toBlock"`
+
+	// FromAddress and ToAddress are the senders and the receivers of the calls, any if empty
+	FromAddress []types.Address `json:"fromAddress"`
+	ToAddress   []types.Address `json:"toAddress"`
+
+	// After is the number of matching traces skipped, and Count the max number returned
+	After *uint64 `json:"after"`
+	Count *uint64 `json:"count"`
+}
+
+func containsAddress(addrs []types.Address, addr types.Address) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns true if the trace matches the addresses of the filter. The receiver
+// of a create is the address of the contract, which is only known if it succeeded
+func (f *TraceFilter) match(trace *flatTrace) bool {
+	if len(f.FromAddress) != 0 && !containsAddress(f.FromAddress, trace.Action.From) {
+		return false
+	}
+	if len(f.ToAddress) != 0 {
+		to := trace.Action.To
+		if trace.Result != nil && trace.Result.Address != nil {
+			to = trace.Result.Address
+		}
+		if to == nil || !containsAddress(f.ToAddress, *to) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter replays the blocks of the range and returns the traces of their calls that match
+// the filter. The replay stops once the request is cancelled
+func (t *Trace) Filter(ctx context.Context, filter *TraceFilter) (interface{}, error) {
+	if filter == nil {
+		filter = &TraceFilter{}
+	}
+	resolveNum := func(num *BlockNumber) (uint64, error) {
+		if num == nil {
+			return t.d.resolveBlockNumber(LatestBlockNumber)
+		}
+		return t.d.resolveBlockNumber(*num)
+	}
+
+	from, err := resolveNum(filter.FromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := resolveNum(filter.ToBlock)
+	if err != nil {
+		return nil, err
+	}
+	if to < from {
+		return nil, fmt.Errorf("incorrect range")
+	}
+	if to-from >= traceFilterBlockRange {
+		return nil, errLogsBlockRange(traceFilterBlockRange)
+	}
+
+	var after uint64
+	if filter.After != nil {
+		after = *filter.After
+	}
+
+	res := []*flatTrace{}
+	for i := from; i <= to; i++ {
+		if err := requestError(ctx); err != nil {
+			return nil, err
+		}
+		header, ok := t.d.store.GetHeaderByNumber(i)
+		if !ok {
+			break
+		}
+		block, ok := t.d.store.GetBlockByHash(header.Hash, true)
+		if !ok {
+			return nil, fmt.Errorf("block %d not found", i)
+		}
+		traces, err := t.traceBlock(block)
+		if err != nil {
+			return nil, err
+		}
+		for _, trace := range traces {
+			if !filter.match(trace) {
+				continue
+			}
+			if after > 0 {
+				after--
+				continue
+			}
+			if filter.Count != nil && uint64(len(res)) >= *filter.Count {
+				return res, nil
+			}
+			res = append(res, trace)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,185 @@
+package jsonrpc
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrace_FlattenCalls(t *testing.T) {
+	txn := &types.Transaction{Value: big.NewInt(0), GasPrice: big.NewInt(0)}
+	txn.ComputeHash()
+	block := &types.Block{
+		Header:       &types.Header{Hash: hash1, Number: 1},
+		Transactions: []*types.Transaction{txn},
+	}
+
+	addr1, addr2, addr3 := types.StringToAddress("1"), types.StringToAddress("2"), types.StringToAddress("3")
+
+	// a call that creates a contract and makes a static call that reverts
+	root := &runtime.CallFrame{
+		Type: runtime.Call, From: addr1, To: addr2, Gas: 1000, GasUsed: 500, Input: []byte{0x1}, Output: []byte{0x2},
+		Calls: []*runtime.CallFrame{
+			{Type: runtime.Create2, From: addr2, To: addr3, Gas: 400, GasUsed: 200, Input: []byte{0x3}, Output: []byte{0x4}},
+			{Type: runtime.StaticCall, From: addr2, To: addr1, Gas: 100, GasUsed: 100, Err: runtime.ErrExecutionReverted},
+		},
+	}
+
+	traces := flattenCalls(nil, root, []int{}, block, 0)
+	assert.Len(t, traces, 3)
+
+	assert.Equal(t, "call", traces[0].Type)
+	assert.Equal(t, "call", traces[0].Action.CallType)
+	assert.Equal(t, &addr2, traces[0].Action.To)
+	assert.Equal(t, argBytesPtr([]byte{0x2}), traces[0].Result.Output)
+	assert.Equal(t, 2, traces[0].Subtraces)
+	assert.Equal(t, []int{}, traces[0].TraceAddress)
+	assert.Equal(t, txn.Hash, traces[0].TransactionHash)
+	assert.Equal(t, uint64(1), traces[0].BlockNumber)
+
+	assert.Equal(t, "create", traces[1].Type)
+	assert.Empty(t, traces[1].Action.CallType)
+	assert.Equal(t, argBytesPtr([]byte{0x3}), traces[1].Action.Init)
+	assert.Equal(t, &addr3, traces[1].Result.Address)
+	assert.Equal(t, argBytesPtr([]byte{0x4}), traces[1].Result.Code)
+	assert.Equal(t, []int{0}, traces[1].TraceAddress)
+
+	assert.Equal(t, "staticcall", traces[2].Action.CallType)
+	assert.Equal(t, "Reverted", traces[2].Error)
+	assert.Nil(t, traces[2].Result)
+	assert.Equal(t, []int{1}, traces[2].TraceAddress)
+}
+
+func newTraceTestDispatcher() (*Dispatcher, []*types.Transaction) {
+	txns := []*types.Transaction{}
+	store := &mockTraceStore{}
+	store.lookups = map[types.Hash]*storage.TxLookup{}
+	store.add(&types.Block{Header: &types.Header{Hash: hash3, Number: 0}})
+	for i, hash := range []types.Hash{hash1, hash2} {
+		txn := &types.Transaction{Value: big.NewInt(0), GasPrice: big.NewInt(0), Nonce: uint64(i)}
+		txn.ComputeHash()
+		txns = append(txns, txn)
+
+		store.add(&types.Block{
+			Header:       &types.Header{Hash: hash, Number: uint64(i + 1)},
+			Transactions: []*types.Transaction{txn},
+		})
+		store.lookups[txn.Hash] = &storage.TxLookup{BlockHash: hash, BlockNumber: uint64(i + 1), Index: 0}
+	}
+	return newTestDispatcher(hclog.NewNullLogger(), store), txns
+}
+
+func TestTrace_TransactionAndBlock(t *testing.T) {
+	dispatcher, txns := newTraceTestDispatcher()
+
+	res, err := dispatcher.endpoints.Trace.Transaction(txns[1].Hash)
+	assert.NoError(t, err)
+
+	traces := res.([]*flatTrace)
+	assert.Len(t, traces, 1)
+	assert.Equal(t, txns[1].Hash, traces[0].TransactionHash)
+	assert.Equal(t, hash2, traces[0].BlockHash)
+	assert.Equal(t, types.StringToAddress("1"), traces[0].Action.From)
+	assert.Equal(t, argUint64(100), traces[0].Result.GasUsed)
+
+	res, err = dispatcher.endpoints.Trace.Block(BlockNumber(1))
+	assert.NoError(t, err)
+	assert.Len(t, res.([]*flatTrace), 1)
+
+	// the genesis has no txns
+	res, err = dispatcher.endpoints.Trace.Block(BlockNumber(0))
+	assert.NoError(t, err)
+	assert.Len(t, res.([]*flatTrace), 0)
+
+	_, err = dispatcher.endpoints.Trace.Transaction(hash1)
+	assert.Error(t, err)
+}
+
+func TestTrace_ErrorsEVM(t *testing.T) {
+	store := newMockEVMStore(t)
+	header, _ := store.GetHeaderByNumber(1)
+
+	cases := map[types.Address]string{
+		addr1:         "Out of gas",
+		invalidAddr:   "Bad instruction",
+		underflowAddr: "Stack underflow",
+		badJumpAddr:   "Bad jump destination",
+	}
+	for to, expected := range cases {
+		to := to
+		transition, err := store.executor.BeginTxn(store.root, header)
+		assert.NoError(t, err)
+
+		tracer := runtime.NewCallTracer(false)
+		_, failed, err := transition.TraceWrite(&types.Transaction{
+			From:     addr0,
+			To:       &to,
+			Gas:      50000,
+			GasPrice: big.NewInt(0),
+			Value:    big.NewInt(0),
+		}, tracer)
+		assert.NoError(t, err)
+		assert.True(t, failed)
+
+		frame := tracer.Result()
+		assert.NotNil(t, frame)
+		assert.Equal(t, expected, traceError(frame.Err))
+	}
+}
+
+func TestTrace_Filter(t *testing.T) {
+	dispatcher, txns := newTraceTestDispatcher()
+
+	uint64Ptr := func(n uint64) *uint64 {
+		return &n
+	}
+	blockPtr := func(n BlockNumber) *BlockNumber {
+		return &n
+	}
+
+	// all the blocks up to the latest
+	res, err := dispatcher.endpoints.Trace.Filter(context.Background(), &TraceFilter{FromBlock: blockPtr(0)})
+	assert.NoError(t, err)
+	assert.Len(t, res.([]*flatTrace), 2)
+
+	// by address
+	res, err = dispatcher.endpoints.Trace.Filter(context.Background(), &TraceFilter{
+		FromBlock: blockPtr(0),
+		ToAddress: []types.Address{types.StringToAddress("2")},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, res.([]*flatTrace), 2)
+
+	res, err = dispatcher.endpoints.Trace.Filter(context.Background(), &TraceFilter{
+		FromBlock:   blockPtr(0),
+		FromAddress: []types.Address{types.StringToAddress("2")},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, res.([]*flatTrace), 0)
+
+	// pagination
+	res, err = dispatcher.endpoints.Trace.Filter(context.Background(), &TraceFilter{FromBlock: blockPtr(0), After: uint64Ptr(1), Count: uint64Ptr(1)})
+	assert.NoError(t, err)
+	traces := res.([]*flatTrace)
+	assert.Len(t, traces, 1)
+	assert.Equal(t, txns[1].Hash, traces[0].TransactionHash)
+
+	// the params as sent by the clients
+	resp, err := dispatcher.Handle([]byte(`{"id": 1, "method": "trace_filter", "params": [{"fromBlock": "0x1", "toBlock": "latest", "after": 0, "count": 10}]}`))
+	assert.NoError(t, err)
+	assert.Contains(t, string(resp), `"traceAddress":[]`)
+	assert.Contains(t, string(resp), `"callType":"call"`)
+
+	// invalid ranges
+	_, err = dispatcher.endpoints.Trace.Filter(context.Background(), &TraceFilter{FromBlock: blockPtr(2), ToBlock: blockPtr(1)})
+	assert.Error(t, err)
+
+	_, err = dispatcher.endpoints.Trace.Filter(context.Background(), &TraceFilter{FromBlock: blockPtr(0), ToBlock: blockPtr(traceFilterBlockRange)})
+	assert.Error(t, err)
+}
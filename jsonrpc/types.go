@@ -5,7 +5,9 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/0xPolygon/minimal/gasprice"
 	"github.com/0xPolygon/minimal/helper/hex"
+	"github.com/0xPolygon/minimal/state"
 	"github.com/0xPolygon/minimal/types"
 )
 
@@ -21,10 +23,24 @@ type transaction struct {
 	S        argBytes       `json:"s"`
 	Hash     types.Hash     `json:"hash"`
 	From     types.Address  `json:"from"`
+
+	// position of the txn, null if it is not mined
+	BlockHash   *types.Hash `json:"blockHash"`
+	BlockNumber *argUint64  `json:"blockNumber"`
+	TxIndex     *argUint64  `json:"transactionIndex"`
+
+	// typed transaction fields
+	Type       argUint64        `json:"type"`
+	ChainID    *argBig          `json:"chainId,omitempty"`
+	AccessList types.AccessList `json:"accessList,omitempty"`
+	GasTipCap  *argBig          `json:"maxPriorityFeePerGas,omitempty"`
+	GasFeeCap  *argBig          `json:"maxFeePerGas,omitempty"`
 }
 
-func toTransaction(t *types.Transaction) *transaction {
-	return &transaction{
+// toTransaction converts the txn mined at the index of the block. The
+// position is not set if the header is nil (i.e. pending txns)
+func toTransaction(t *types.Transaction, header *types.Header, index int) *transaction {
+	res := &transaction{
 		Nonce:    argUint64(t.Nonce),
 		GasPrice: argBig(*t.GasPrice),
 		Gas:      argUint64(t.Gas),
@@ -36,7 +52,24 @@ func toTransaction(t *types.Transaction) *transaction {
 		S:        argBytes(t.S),
 		Hash:     t.Hash,
 		From:     t.From,
+		Type:     argUint64(t.Type),
+	}
+	if header != nil {
+		res.BlockHash = &header.Hash
+		res.BlockNumber = argUintPtr(header.Number)
+		res.TxIndex = argUintPtr(uint64(index))
+	}
+	if t.IsTyped() && t.ChainID != nil {
+		res.ChainID = argBigPtr(t.ChainID)
 	}
+	if t.IsTyped() {
+		res.AccessList = t.AccessList
+	}
+	if t.Type == types.DynamicFeeTx && t.GasTipCap != nil && t.GasFeeCap != nil {
+		res.GasTipCap = argBigPtr(t.GasTipCap)
+		res.GasFeeCap = argBigPtr(t.GasFeeCap)
+	}
+	return res
 }
 
 type block struct {
@@ -80,8 +113,8 @@ func toBlock(b *types.Block) *block {
 		Hash:         h.Hash,
 		Transactions: []*transaction{},
 	}
-	for _, txn := range b.Transactions {
-		res.Transactions = append(res.Transactions, toTransaction(txn))
+	for indx, txn := range b.Transactions {
+		res.Transactions = append(res.Transactions, toTransaction(txn, h, indx))
 	}
 	return res
 }
@@ -102,6 +135,68 @@ type receipt struct {
 	ToAddr            *types.Address       `json:"to"`
 }
 
+// toReceipt converts the receipt of the txn at the index of the block, whose
+// first log has the given index in the block
+func toReceipt(b *types.Block, indx int, raw *types.Receipt, logIndex int) *receipt {
+	txn := b.Transactions[indx]
+
+	logs := make([]*Log, len(raw.Logs))
+	for i, elem := range raw.Logs {
+		logs[i] = &Log{
+			Address:     elem.Address,
+			Topics:      elem.Topics,
+			Data:        argBytes(elem.Data),
+			BlockHash:   b.Hash(),
+			BlockNumber: argUint64(b.Number()),
+			TxHash:      txn.Hash,
+			TxIndex:     argUint64(indx),
+			LogIndex:    argUint64(logIndex + i),
+			Removed:     false,
+		}
+	}
+	return &receipt{
+		Root:              raw.Root,
+		CumulativeGasUsed: argUint64(raw.CumulativeGasUsed),
+		LogsBloom:         raw.LogsBloom,
+		Status:            raw.Status,
+		TxHash:            txn.Hash,
+		TxIndex:           argUint64(indx),
+		BlockHash:         b.Hash(),
+		BlockNumber:       argUint64(b.Number()),
+		GasUsed:           argUint64(raw.GasUsed),
+		ContractAddress:   raw.ContractAddress,
+		FromAddr:          txn.From,
+		ToAddr:            txn.To,
+		Logs:              logs,
+	}
+}
+
+type feeHistory struct {
+	OldestBlock  argUint64   `json:"oldestBlock"`
+	BaseFee      []*argBig   `json:"baseFeePerGas"`
+	GasUsedRatio []float64   `json:"gasUsedRatio"`
+	Reward       [][]*argBig `json:"reward,omitempty"`
+}
+
+func toFeeHistory(h *gasprice.FeeHistory) *feeHistory {
+	res := &feeHistory{
+		OldestBlock:  argUint64(h.OldestBlock),
+		BaseFee:      []*argBig{},
+		GasUsedRatio: h.GasUsedRatios,
+	}
+	for _, fee := range h.BaseFees {
+		res.BaseFee = append(res.BaseFee, argBigPtr(fee))
+	}
+	for _, rewards := range h.Rewards {
+		block := make([]*argBig, 0, len(rewards))
+		for _, reward := range rewards {
+			block = append(block, argBigPtr(reward))
+		}
+		res.Reward = append(res.Reward, block)
+	}
+	return res
+}
+
 type Log struct {
 	Address     types.Address `json:"address"`
 	Topics      []types.Hash  `json:"topics"`
@@ -210,6 +305,47 @@ func encodeToHex(b []byte) []byte {
 	return []byte("0x" + str)
 }
 
+// stateOverride is the set of accounts overridden in the state before a call
+type stateOverride map[types.Address]*accountOverride
+
+// accountOverride overrides the fields of an account, the fields that are not set are kept
+type accountOverride struct {
+	Nonce     *argUint64                `json:"nonce"`
+	Code      *argBytes                 `json:"code"`
+	Balance   *argBig                   `json:"balance"`
+	State     map[types.Hash]types.Hash `json:"state"`
+	StateDiff map[types.Hash]types.Hash `json:"stateDiff"`
+}
+
+// toStateOverride converts the override to the one applied in the state
+func (o stateOverride) toStateOverride() state.StateOverride {
+	if o == nil {
+		return nil
+	}
+	res := state.StateOverride{}
+	for addr, account := range o {
+		if account == nil {
+			continue
+		}
+		override := &state.AccountOverride{
+			State:     account.State,
+			StateDiff: account.StateDiff,
+		}
+		if account.Nonce != nil {
+			nonce := uint64(*account.Nonce)
+			override.Nonce = &nonce
+		}
+		if account.Code != nil {
+			override.Code = append([]byte{}, *account.Code...)
+		}
+		if account.Balance != nil {
+			override.Balance = new(big.Int).Set((*big.Int)(account.Balance))
+		}
+		res[addr] = override
+	}
+	return res
+}
+
 // txnArgs is the transaction argument for the rpc endpoints
 type txnArgs struct {
 	From     *types.Address
@@ -220,4 +356,7 @@ type txnArgs struct {
 	Input    *argBytes
 	Data     *argBytes
 	Nonce    *argUint64
+
+	// AccessList is the access list of the txn (eip-2930)
+	AccessList *types.AccessList
 }
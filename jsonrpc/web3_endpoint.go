@@ -1,8 +1,6 @@
 package jsonrpc
 
 import (
-	"fmt"
-
 	"github.com/0xPolygon/minimal/helper/hex"
 	"github.com/0xPolygon/minimal/helper/keccak"
 	"github.com/0xPolygon/minimal/version"
@@ -13,9 +11,10 @@ type Web3 struct {
 	d *Dispatcher
 }
 
+// clientVersion returns the name and the version of the client
 // ClientVersion returns the version of the web3 client (web3_clientVersion)
 func (w *Web3) ClientVersion() (interface{}, error) {
-	return fmt.Sprintf("polygon-sdk [%s]", version.GetVersion()), nil
+	return version.ClientVersion(), nil
 }
 
 // Sha3 returns Keccak-256 (not the standardized SHA3-256) of the given data
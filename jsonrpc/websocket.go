@@ -0,0 +1,279 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultWsMaxConnections = 1000
+	defaultWsPingInterval   = 30 * time.Second
+	defaultWsIdleTimeout    = 60 * time.Second
+	defaultWsSendBuffer     = 1024
+
+	// wsWriteTimeout is the max time to write a message to a websocket connection
+	wsWriteTimeout = 10 * time.Second
+)
+
+var (
+	errWsClosed       = fmt.Errorf("websocket connection closed")
+	errWsSlowConsumer = fmt.Errorf("websocket connection dropped, the messages are not read fast enough")
+)
+
+// WebsocketConfig bounds the websocket connections, each limit is disabled if 0
+type WebsocketConfig struct {
+	// MaxConnections is the max number of open connections
+	MaxConnections uint64
+
+	// PingInterval is the interval between the pings sent to the connections
+	PingInterval time.Duration
+
+	// IdleTimeout is the time after which a connection that sent neither a message
+	// nor a pong is closed. It has to be longer than the ping interval
+	IdleTimeout time.Duration
+
+	// SendBuffer is the max number of messages queued to be sent to a connection,
+	// which is dropped if it does not read them fast enough. The messages are
+	// written directly if 0, so a slow connection slows down the notifications
+	SendBuffer uint64
+}
+
+// DefaultWebsocketConfig returns the default limits of the websocket connections
+func DefaultWebsocketConfig() *WebsocketConfig {
+	return &WebsocketConfig{
+		MaxConnections: defaultWsMaxConnections,
+		PingInterval:   defaultWsPingInterval,
+		IdleTimeout:    defaultWsIdleTimeout,
+		SendBuffer:     defaultWsSendBuffer,
+	}
+}
+
+func (c *WebsocketConfig) validate() error {
+	if c.PingInterval != 0 && c.IdleTimeout != 0 && c.PingInterval >= c.IdleTimeout {
+		return fmt.Errorf("websocket ping interval %s is not shorter than the idle timeout %s", c.PingInterval, c.IdleTimeout)
+	}
+	return nil
+}
+
+type wrapWsConn struct {
+	// the writes of the messages and of the control messages are concurrent
+	lock sync.Mutex
+	conn *websocket.Conn
+
+	// sendCh queues the messages sent by the writer, nil if they are written directly
+	sendCh chan []byte
+
+	closeCh   chan struct{}
+	doneCh    chan struct{}
+	closeOnce sync.Once
+
+	// flush is whether the queued messages are written on close
+	flush bool
+
+	// closed is set once the close message is written, no message is written afterwards
+	closed bool
+}
+
+// newWrapWsConn wraps the connection, and starts the writer of its queued messages and of its pings
+func newWrapWsConn(conn *websocket.Conn, config *WebsocketConfig) *wrapWsConn {
+	w := &wrapWsConn{
+		conn:    conn,
+		closeCh: make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+	if config.SendBuffer != 0 {
+		w.sendCh = make(chan []byte, config.SendBuffer)
+	}
+	go w.run(config.PingInterval)
+	return w
+}
+
+func (w *wrapWsConn) write(b []byte) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.closed {
+		return errWsClosed
+	}
+	w.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return w.conn.WriteMessage(websocket.TextMessage, b)
+}
+
+// WriteMessage queues the message, and drops the connection if its queue is full
+func (w *wrapWsConn) WriteMessage(b []byte) error {
+	if w.sendCh == nil {
+		return w.write(b)
+	}
+
+	select {
+	case <-w.closeCh:
+		return errWsClosed
+	default:
+	}
+
+	select {
+	case w.sendCh <- b:
+		return nil
+	default:
+		// the close waits for the write of the close message, which is not
+		// done by the caller since it might be notifying other connections
+		go w.close(websocket.ClosePolicyViolation, "messages not read fast enough", false)
+		return errWsSlowConsumer
+	}
+}
+
+// run writes the queued messages and the pings until the connection is closed
+func (w *wrapWsConn) run(pingInterval time.Duration) {
+	defer close(w.doneCh)
+
+	var pingCh <-chan time.Time
+	if pingInterval != 0 {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+		pingCh = ticker.C
+	}
+
+	for {
+		select {
+		case msg := <-w.sendCh:
+			if err := w.write(msg); err != nil {
+				// the reader stops once the connection is closed
+				w.conn.Close()
+				return
+			}
+
+		case <-pingCh:
+			w.lock.Lock()
+			err := w.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+			w.lock.Unlock()
+			if err != nil {
+				w.conn.Close()
+				return
+			}
+
+		case <-w.closeCh:
+			if !w.flush {
+				return
+			}
+			// write the messages queued before the close
+			for {
+				select {
+				case msg := <-w.sendCh:
+					if err := w.write(msg); err != nil {
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// close stops the writer and closes the connection with the close message,
+// after the queued messages are written if flush is set
+func (w *wrapWsConn) close(code int, reason string, flush bool) {
+	w.closeOnce.Do(func() {
+		if flush {
+			w.flush = true
+		} else {
+			// abort the write in progress, i.e. to a connection that does not read
+			w.conn.UnderlyingConn().SetWriteDeadline(time.Now())
+		}
+		close(w.closeCh)
+		<-w.doneCh
+
+		w.lock.Lock()
+		msg := websocket.FormatCloseMessage(code, reason)
+		w.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+		w.closed = true
+		w.lock.Unlock()
+
+		w.conn.Close()
+	})
+}
+
+func (w *wrapWsConn) shutdown() {
+	w.close(websocket.CloseGoingAway, "server is shutting down", true)
+}
+
+func (j *JSONRPC) websocketConfig() *WebsocketConfig {
+	if j.config == nil || j.config.Websocket == nil {
+		return &WebsocketConfig{}
+	}
+	return j.config.Websocket
+}
+
+func (j *JSONRPC) handleWs(w http.ResponseWriter, req *http.Request) {
+	config := j.websocketConfig()
+
+	if num := atomic.AddInt64(&j.wsConns, 1); config.MaxConnections != 0 && uint64(num) > config.MaxConnections {
+		atomic.AddInt64(&j.wsConns, -1)
+		http.Error(w, "too many websocket connections", http.StatusServiceUnavailable)
+		return
+	}
+	defer atomic.AddInt64(&j.wsConns, -1)
+
+	c, err := upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		return
+	}
+	defer c.Close()
+
+	wrapConn := newWrapWsConn(c, config)
+	if !j.trackConn(wrapConn) {
+		wrapConn.shutdown()
+		return
+	}
+	defer j.untrackConn(wrapConn)
+	defer j.dispatcher.CloseWs(wrapConn)
+	defer wrapConn.close(websocket.CloseNormalClosure, "", false)
+
+	// the connection is alive as long as it sends messages or answers the pings
+	extendDeadline := func() {
+		if config.IdleTimeout != 0 {
+			c.SetReadDeadline(time.Now().Add(config.IdleTimeout))
+		}
+	}
+	extendDeadline()
+	c.SetPongHandler(func(string) error {
+		extendDeadline()
+		return nil
+	})
+
+	slots := make(chan struct{}, maxConnRequests)
+	for {
+		_, message, err := c.ReadMessage()
+		if err != nil {
+			break
+		}
+		extendDeadline()
+
+		slots <- struct{}{}
+		if !j.inflight.add() {
+			<-slots
+			wrapConn.WriteMessage(encodeError(message, errShuttingDown))
+			continue
+		}
+		go func() {
+			defer func() { <-slots }()
+			defer j.inflight.done()
+
+			if err := j.allowRequest(req.RemoteAddr, message); err != nil {
+				wrapConn.WriteMessage(encodeError(message, err))
+				return
+			}
+			resp, err := j.dispatcher.HandleWs(message, wrapConn)
+			if err != nil {
+				wrapConn.WriteMessage(encodeError(message, err))
+			} else {
+				wrapConn.WriteMessage(resp)
+			}
+		}()
+	}
+}
@@ -0,0 +1,119 @@
+package jsonrpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func newWebsocketTestServer(t *testing.T, config *WebsocketConfig) (*JSONRPC, string, func()) {
+	srv := &JSONRPC{
+		logger:     hclog.NewNullLogger(),
+		config:     &Config{Websocket: config},
+		dispatcher: newTestDispatcher(hclog.NewNullLogger(), newMockStore()),
+	}
+	httpSrv := httptest.NewServer(http.HandlerFunc(srv.handleWs))
+
+	return srv, "ws" + strings.TrimPrefix(httpSrv.URL, "http"), httpSrv.Close
+}
+
+func TestWebsocket_MaxConnections(t *testing.T) {
+	_, url, closeFn := newWebsocketTestServer(t, &WebsocketConfig{MaxConnections: 1})
+	defer closeFn()
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.NoError(t, err)
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	// the slot is released once the connection is closed
+	conn.Close()
+	assert.Eventually(t, func() bool {
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestWebsocket_IdleTimeout(t *testing.T) {
+	_, url, closeFn := newWebsocketTestServer(t, &WebsocketConfig{IdleTimeout: 100 * time.Millisecond})
+	defer closeFn()
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	// the client does not answer the pings, nor sends messages
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+}
+
+func TestWebsocket_Ping(t *testing.T) {
+	_, url, closeFn := newWebsocketTestServer(t, &WebsocketConfig{
+		PingInterval: 50 * time.Millisecond,
+		IdleTimeout:  150 * time.Millisecond,
+	})
+	defer closeFn()
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	pingCh := make(chan struct{}, 10)
+	conn.SetPingHandler(func(data string) error {
+		pingCh <- struct{}{}
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	go conn.ReadMessage()
+
+	// the pongs keep the connection alive past the idle timeout
+	for i := 0; i < 5; i++ {
+		select {
+		case <-pingCh:
+		case <-time.After(time.Second):
+			t.Fatal("ping not received")
+		}
+	}
+}
+
+func TestWebsocket_SlowConsumer(t *testing.T) {
+	upgraded := make(chan *websocket.Conn, 1)
+	httpSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		assert.NoError(t, err)
+		upgraded <- c
+	}))
+	defer httpSrv.Close()
+
+	// the peer of a server side connection never reads its messages
+	peer, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpSrv.URL, "http"), nil)
+	assert.NoError(t, err)
+	defer peer.Close()
+
+	conn := newWrapWsConn(<-upgraded, &WebsocketConfig{SendBuffer: 2})
+
+	// the messages are queued until the socket buffers are full, and then the queue
+	msg := []byte(strings.Repeat("a", 64*1024))
+	var dropErr error
+	for i := 0; i < 1000 && dropErr == nil; i++ {
+		dropErr = conn.WriteMessage(msg)
+	}
+	assert.Equal(t, errWsSlowConsumer, dropErr)
+
+	// the connection is closed once it is dropped
+	assert.Eventually(t, func() bool {
+		return conn.WriteMessage(msg) == errWsClosed
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,132 @@
+package jsonrpc
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxConcurrentRequests = 256
+	defaultRequestTimeout        = 30 * time.Second
+
+	// maxConnRequests is the max number of requests of a websocket or ipc connection
+	// handled at the same time, the connection is not read until one of them finishes
+	maxConnRequests = 16
+)
+
+var errRequestTimeout = &ErrorObject{Code: -32002, Message: "request timed out"}
+
+// RequestLimits bound the execution of the requests, each limit is disabled if 0
+type RequestLimits struct {
+	// MaxConcurrent is the max number of requests executed at the same time,
+	// the other requests wait for a slot until their timeout
+	MaxConcurrent uint64
+
+	// Timeout is the max time to wait for the result of a request
+	Timeout time.Duration
+}
+
+// DefaultRequestLimits returns the default limits of the execution of the requests
+func DefaultRequestLimits() *RequestLimits {
+	return &RequestLimits{
+		MaxConcurrent: defaultMaxConcurrentRequests,
+		Timeout:       defaultRequestTimeout,
+	}
+}
+
+// workerPool runs the requests with a bounded concurrency and a deadline
+type workerPool struct {
+	// slots are the requests being executed, unbounded if nil
+	slots chan struct{}
+
+	timeout time.Duration
+
+	// running are the handlers run in the background, which outlive their request once they time out
+	running sync.WaitGroup
+}
+
+func newWorkerPool(limits *RequestLimits) *workerPool {
+	if limits == nil || (limits.MaxConcurrent == 0 && limits.Timeout == 0) {
+		return nil
+	}
+	p := &workerPool{
+		timeout: limits.Timeout,
+	}
+	if limits.MaxConcurrent != 0 {
+		p.slots = make(chan struct{}, limits.MaxConcurrent)
+	}
+	return p
+}
+
+// run runs the handler once there is a free slot, and returns a timeout error
+// if it does not finish in time. The context of the handler is cancelled once it
+// times out, but it keeps its slot until it returns, so the slow requests cannot
+// pile up beyond the max concurrency
+func (p *workerPool) run(ctx context.Context, handler func(ctx context.Context)) error {
+	if p == nil {
+		handler(ctx)
+		return nil
+	}
+
+	if p.timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	if p.slots != nil {
+		select {
+		case p.slots <- struct{}{}:
+		case <-ctx.Done():
+			return requestError(ctx)
+		}
+	}
+	release := func() {
+		if p.slots != nil {
+			<-p.slots
+		}
+	}
+
+	if p.timeout == 0 {
+		defer release()
+		handler(ctx)
+		return nil
+	}
+
+	doneCh := make(chan struct{})
+	p.running.Add(1)
+	go func() {
+		defer p.running.Done()
+		defer release()
+		handler(ctx)
+		close(doneCh)
+	}()
+
+	select {
+	case <-doneCh:
+		return nil
+	case <-ctx.Done():
+		return requestError(ctx)
+	}
+}
+
+// wait waits for the handlers that timed out to return
+func (p *workerPool) wait() {
+	if p != nil {
+		p.running.Wait()
+	}
+}
+
+// requestError returns the error of the request once its context is done, either
+// because it timed out or because it was cancelled by the shutdown of the server
+func requestError(ctx context.Context) error {
+	switch ctx.Err() {
+	case nil:
+		return nil
+	case context.DeadlineExceeded:
+		return errRequestTimeout
+	default:
+		return errShuttingDown
+	}
+}
@@ -0,0 +1,130 @@
+package jsonrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool_Disabled(t *testing.T) {
+	assert.Nil(t, newWorkerPool(nil))
+	assert.Nil(t, newWorkerPool(&RequestLimits{}))
+
+	// the nil pool runs the handlers right away
+	var p *workerPool
+	done := false
+	assert.NoError(t, p.run(context.Background(), func(context.Context) {
+		done = true
+	}))
+	assert.True(t, done)
+}
+
+func TestWorkerPool_Timeout(t *testing.T) {
+	p := newWorkerPool(&RequestLimits{MaxConcurrent: 1, Timeout: 50 * time.Millisecond})
+
+	releaseCh := make(chan struct{})
+	defer close(releaseCh)
+
+	// the slow handler times out
+	assert.Equal(t, errRequestTimeout, p.run(context.Background(), func(context.Context) {
+		<-releaseCh
+	}))
+
+	// and keeps its slot until it returns, so the next handler times out while waiting
+	called := false
+	assert.Equal(t, errRequestTimeout, p.run(context.Background(), func(context.Context) {
+		called = true
+	}))
+	assert.False(t, called)
+}
+
+func TestWorkerPool_Cancel(t *testing.T) {
+	p := newWorkerPool(&RequestLimits{Timeout: 50 * time.Millisecond})
+
+	// the context of the handler is cancelled once it times out
+	cancelled := false
+	assert.Equal(t, errRequestTimeout, p.run(context.Background(), func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		cancelled = true
+	}))
+
+	// and the handler is waited for
+	p.wait()
+	assert.True(t, cancelled)
+
+	// the handlers are cancelled with their parent context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, errShuttingDown, p.run(ctx, func(ctx context.Context) {
+		<-ctx.Done()
+	}))
+}
+
+func TestWorkerPool_Release(t *testing.T) {
+	p := newWorkerPool(&RequestLimits{MaxConcurrent: 1, Timeout: time.Second})
+
+	startedCh := make(chan struct{})
+	releaseCh := make(chan struct{})
+	doneCh := make(chan error)
+	go func() {
+		doneCh <- p.run(context.Background(), func(context.Context) {
+			close(startedCh)
+			<-releaseCh
+		})
+	}()
+	<-startedCh
+
+	// the handler waits for the slot of the running one
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(releaseCh)
+	}()
+	called := false
+	assert.NoError(t, p.run(context.Background(), func(context.Context) {
+		called = true
+	}))
+	assert.True(t, called)
+	assert.NoError(t, <-doneCh)
+}
+
+func TestDispatcher_RequestTimeout(t *testing.T) {
+	s := newTestDispatcher(hclog.NewNullLogger(), newMockStore())
+	s.workers = newWorkerPool(&RequestLimits{Timeout: time.Nanosecond})
+	s.registerService("mock", &mockSlowService{})
+
+	_, err := s.Handle([]byte(`{"id": 1, "method": "mock_slow", "params": []}`))
+	assert.Equal(t, errRequestTimeout, err)
+}
+
+func TestDispatcher_RequestContext(t *testing.T) {
+	s := newTestDispatcher(hclog.NewNullLogger(), newMockStore())
+	s.registerService("mock", &mockSlowService{})
+
+	// the context is passed before the params
+	resp, err := s.Handle([]byte(`{"id": 1, "method": "mock_echo", "params": ["a"]}`))
+	assert.NoError(t, err)
+	assert.Contains(t, string(resp), `"a"`)
+
+	// and it is cancelled once the dispatcher is closed
+	s.Close()
+	_, err = s.Handle([]byte(`{"id": 1, "method": "mock_echo", "params": ["a"]}`))
+	assert.Equal(t, errShuttingDown, err)
+}
+
+type mockSlowService struct{}
+
+func (m *mockSlowService) Slow() (interface{}, error) {
+	time.Sleep(100 * time.Millisecond)
+	return nil, nil
+}
+
+func (m *mockSlowService) Echo(ctx context.Context, str string) (interface{}, error) {
+	if err := requestError(ctx); err != nil {
+		return nil, err
+	}
+	return str, nil
+}
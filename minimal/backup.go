@@ -0,0 +1,446 @@
+package minimal
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+)
+
+const (
+	// backupInfoFile is the first entry of the backup archive
+	backupInfoFile = "backup.json"
+
+	// backupEntrySize is the size of the entries of the archive with the blocks and the state
+	backupEntrySize = 4 * 1024 * 1024
+
+	// backupChunkSize is the size of the writes of the archive
+	backupChunkSize = 64 * 1024
+
+	// restoreBatchSize is the number of records written in each batch during a restore
+	restoreBatchSize = 1000
+)
+
+// BackupInfo describes the last block of a backup
+type BackupInfo struct {
+	Number    uint64     `json:"number"`
+	Hash      types.Hash `json:"hash"`
+	StateRoot types.Hash `json:"stateRoot"`
+}
+
+// Backup writes to w a tar archive with the blocks of the canonical chain up to the
+// given number (the head if it is zero) and the state of the last block. The blocks are
+// collected by their parent hashes, so a reorg during the backup does not mix chains
+func (s *Server) Backup(w io.Writer, to uint64) (*BackupInfo, error) {
+	head := s.blockchain.Header()
+	if to == 0 {
+		to = head.Number
+	}
+	if to > head.Number {
+		return nil, fmt.Errorf("block %d is over the head %d", to, head.Number)
+	}
+	last, ok := s.blockchain.GetHeaderByNumber(to)
+	if !ok {
+		return nil, fmt.Errorf("header of block %d not found", to)
+	}
+
+	hashes := make([]types.Hash, to+1)
+	for h := last; ; {
+		hashes[h.Number] = h.Hash
+		if h.Number == 0 {
+			break
+		}
+		parent, ok := s.blockchain.GetHeaderByHash(h.ParentHash)
+		if !ok {
+			return nil, fmt.Errorf("header %s not found", h.ParentHash)
+		}
+		h = parent
+	}
+
+	info := &BackupInfo{
+		Number:    last.Number,
+		Hash:      last.Hash,
+		StateRoot: last.StateRoot,
+	}
+
+	bw := bufio.NewWriterSize(w, backupChunkSize)
+	tw := tar.NewWriter(bw)
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeTarEntry(tw, backupInfoFile, data); err != nil {
+		return nil, err
+	}
+
+	blocks := newBackupEntryWriter(tw, "blocks")
+	for _, hash := range hashes {
+		header, ok := s.blockchain.GetHeaderByHash(hash)
+		if !ok {
+			return nil, fmt.Errorf("header %s not found", hash)
+		}
+		// the genesis block has neither body nor receipts
+		body, ok := s.blockchain.GetBodyByHash(hash)
+		if !ok {
+			body = &types.Body{}
+		}
+		receipts, err := s.blockchain.GetReceiptsByHash(hash)
+		if err != nil && err != storage.ErrNotFound {
+			return nil, err
+		}
+		err = blocks.writeRecord(
+			header.MarshalRLP(),
+			body.MarshalRLPTo(nil),
+			types.Receipts(receipts).MarshalRLPTo(nil),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := blocks.flush(); err != nil {
+		return nil, err
+	}
+
+	nodes := newBackupEntryWriter(tw, "state")
+	err = itrie.ExportState(itrie.NewKVStorage(s.trieDB), last.StateRoot, func(k, v []byte) error {
+		return nodes.writeRecord(k, v)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := nodes.flush(); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Backup done", "number", info.Number, "hash", info.Hash)
+	return info, nil
+}
+
+func writeTarEntry(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  time.Now(),
+		Typeflag: tar.TypeReg,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// backupEntryWriter writes records of length prefixed fields in numbered entries of the archive
+type backupEntryWriter struct {
+	tw     *tar.Writer
+	prefix string
+	index  int
+	buf    bytes.Buffer
+}
+
+func newBackupEntryWriter(tw *tar.Writer, prefix string) *backupEntryWriter {
+	return &backupEntryWriter{
+		tw:     tw,
+		prefix: prefix,
+	}
+}
+
+func (w *backupEntryWriter) writeRecord(fields ...[]byte) error {
+	var size [binary.MaxVarintLen64]byte
+	for _, field := range fields {
+		n := binary.PutUvarint(size[:], uint64(len(field)))
+		w.buf.Write(size[:n])
+		w.buf.Write(field)
+	}
+	if w.buf.Len() >= backupEntrySize {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *backupEntryWriter) flush() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	name := fmt.Sprintf("%s/%08d", w.prefix, w.index)
+	if err := writeTarEntry(w.tw, name, w.buf.Bytes()); err != nil {
+		return err
+	}
+	w.buf.Reset()
+	w.index++
+	return nil
+}
+
+// readRecords calls fn with the records of n fields of an entry of the archive
+func readRecords(data []byte, n int, fn func(fields [][]byte) error) error {
+	for len(data) != 0 {
+		fields := make([][]byte, n)
+		for i := range fields {
+			size, read := binary.Uvarint(data)
+			if read <= 0 || uint64(len(data)-read) < size {
+				return fmt.Errorf("truncated record")
+			}
+			fields[i] = data[read : read+int(size)]
+			data = data[read+int(size):]
+		}
+		if err := fn(fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreBackup seeds an empty data dir with the blocks and the state of a backup archive.
+// The backup is restored in a temporary dir inside the data dir, which is moved in place
+// once the restore completes and removed if it fails
+func RestoreBackup(logger hclog.Logger, dataDir string, backend string, r io.Reader) (*BackupInfo, error) {
+	empty, err := isEmptyDataDir(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	if !empty {
+		return nil, fmt.Errorf("data dir %s is not empty", dataDir)
+	}
+
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, err
+	}
+	tmpDir, err := ioutil.TempDir(dataDir, "restore")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	info, err := restoreBackup(logger, tmpDir, backend, r)
+	if err != nil {
+		return nil, err
+	}
+
+	// the empty dirs of the storage are replaced, and the blockchain is moved last
+	// since the data dir is not empty once it is in place
+	paths := []string{"trie", storageBackendFile, schemaVersionFile, "blockchain"}
+	for _, path := range paths {
+		if err := os.Remove(filepath.Join(dataDir, path)); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err := os.Rename(filepath.Join(tmpDir, path), filepath.Join(dataDir, path)); err != nil {
+			return nil, err
+		}
+	}
+
+	logger.Info("Restored backup", "number", info.Number, "hash", info.Hash)
+	return info, nil
+}
+
+func restoreBackup(logger hclog.Logger, dataDir string, backend string, r io.Reader) (*BackupInfo, error) {
+	blockchainDB, trieDB, err := openStorageBackends(dataDir, backend)
+	if err != nil {
+		return nil, err
+	}
+	defer blockchainDB.Close()
+	defer trieDB.Close()
+
+	restore := &backupRestore{
+		db:     storage.NewKeyValueStorage(logger, blockchainDB),
+		trieDB: trieDB,
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		if err := restore.readEntry(hdr.Name, data); err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %v", hdr.Name, err)
+		}
+	}
+
+	return restore.commit()
+}
+
+// backupRestore writes the entries of a backup archive
+type backupRestore struct {
+	db     storage.Storage
+	trieDB storage.Backend
+	info   *BackupInfo
+
+	// the last block restored and its total difficulty
+	last *types.Header
+	diff *big.Int
+}
+
+func (r *backupRestore) readEntry(name string, data []byte) error {
+	if name == backupInfoFile {
+		r.info = &BackupInfo{}
+		return json.Unmarshal(data, r.info)
+	}
+	if r.info == nil {
+		return fmt.Errorf("the archive does not start with %s", backupInfoFile)
+	}
+
+	switch {
+	case strings.HasPrefix(name, "blocks/"):
+		return r.restoreBlocks(data)
+	case strings.HasPrefix(name, "state/"):
+		return r.restoreState(data)
+	default:
+		return fmt.Errorf("unknown entry")
+	}
+}
+
+func (r *backupRestore) restoreBlocks(data []byte) error {
+	batch := r.db.NewBatch()
+	pending := 0
+
+	err := readRecords(data, 3, func(fields [][]byte) error {
+		header := &types.Header{}
+		if err := header.UnmarshalRLP(fields[0]); err != nil {
+			return err
+		}
+		body := &types.Body{}
+		if err := body.UnmarshalRLP(fields[1]); err != nil {
+			return err
+		}
+		receipts := types.Receipts{}
+		if err := receipts.UnmarshalRLP(fields[2]); err != nil {
+			return err
+		}
+
+		// the blocks are restored in order from the genesis
+		if r.last == nil {
+			if header.Number != 0 {
+				return fmt.Errorf("expected the genesis but found block %d", header.Number)
+			}
+			r.diff = big.NewInt(0)
+		} else if header.Number != r.last.Number+1 || header.ParentHash != r.last.Hash {
+			return fmt.Errorf("block %d does not follow block %d", header.Number, r.last.Number)
+		}
+		r.diff = new(big.Int).Add(r.diff, new(big.Int).SetUint64(header.Difficulty))
+
+		if err := batch.WriteHeader(header); err != nil {
+			return err
+		}
+		if err := batch.WriteCanonicalHash(header.Number, header.Hash); err != nil {
+			return err
+		}
+		if err := batch.WriteDiff(header.Hash, r.diff); err != nil {
+			return err
+		}
+		if header.Number != 0 {
+			if err := batch.WriteBody(header.Hash, body); err != nil {
+				return err
+			}
+			if err := batch.WriteReceipts(header.Hash, receipts); err != nil {
+				return err
+			}
+		}
+		for indx, txn := range body.Transactions {
+			lookup := &storage.TxLookup{
+				BlockHash:   header.Hash,
+				BlockNumber: header.Number,
+				Index:       uint64(indx),
+			}
+			if err := batch.WriteTxLookup(txn.Hash, lookup); err != nil {
+				return err
+			}
+		}
+		r.last = header
+
+		if pending++; pending == restoreBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch = r.db.NewBatch()
+			pending = 0
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+func (r *backupRestore) restoreState(data []byte) error {
+	batch := r.trieDB.NewBatch()
+	pending := 0
+
+	err := readRecords(data, 2, func(fields [][]byte) error {
+		batch.Set(fields[0], fields[1])
+
+		if pending++; pending == restoreBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch = r.trieDB.NewBatch()
+			pending = 0
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// commit checks that the backup is complete and writes the head
+func (r *backupRestore) commit() (*BackupInfo, error) {
+	if r.info == nil {
+		return nil, fmt.Errorf("%s not found in the archive", backupInfoFile)
+	}
+	if r.last == nil || r.last.Hash != r.info.Hash {
+		return nil, fmt.Errorf("the archive does not include the block %d (%s)", r.info.Number, r.info.Hash)
+	}
+	if r.last.StateRoot != types.EmptyRootHash {
+		if _, ok, err := r.trieDB.Get(r.last.StateRoot.Bytes()); err != nil || !ok {
+			return nil, fmt.Errorf("the archive does not include the state %s", r.last.StateRoot)
+		}
+	}
+
+	batch := r.db.NewBatch()
+	// all the txns of the restored blocks are indexed
+	if err := batch.WriteTxLookupTail(0); err != nil {
+		return nil, err
+	}
+	if err := batch.WriteHeadHash(r.last.Hash); err != nil {
+		return nil, err
+	}
+	if err := batch.WriteHeadNumber(r.last.Number); err != nil {
+		return nil, err
+	}
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	return r.info, nil
+}
@@ -0,0 +1,179 @@
+package minimal
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain"
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/blockchain/storage/memory"
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/state"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/0xPolygon/minimal/types/buildroot"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupRestore(t *testing.T) {
+	logger := hclog.NewNullLogger()
+	addr := types.StringToAddress("1")
+	code := []byte{0x60, 0x01}
+
+	// the state of the genesis is the state of every block
+	trieDB := memory.NewBackend()
+	params := &chain.Params{Forks: chain.AllForksEnabled}
+	executor := state.NewExecutor(params, itrie.NewState(itrie.NewKVStorage(trieDB)))
+
+	genesis := &chain.Genesis{
+		Alloc: map[types.Address]*chain.GenesisAccount{
+			addr: {
+				Balance: big.NewInt(10),
+				Code:    code,
+			},
+		},
+	}
+	root, err := executor.WriteGenesis(genesis)
+	assert.NoError(t, err)
+	genesis.StateRoot = root
+
+	db, err := memory.NewMemoryStorage(nil)
+	assert.NoError(t, err)
+	b, err := blockchain.NewBlockchainWithStorage(logger, db, &chain.Chain{Genesis: genesis, Params: params}, &blockchain.MockVerifier{}, executor)
+	assert.NoError(t, err)
+	assert.NoError(t, b.ComputeGenesis())
+
+	txns := []*types.Transaction{}
+	for i := 1; i <= 3; i++ {
+		txn := &types.Transaction{
+			Nonce:    uint64(i),
+			Value:    big.NewInt(0),
+			GasPrice: big.NewInt(0),
+			V:        0x27,
+		}
+		txn.ComputeHash()
+		txns = append(txns, txn)
+
+		receipts := []*types.Receipt{{CumulativeGasUsed: uint64(i)}}
+		header := &types.Header{
+			ParentHash:   b.Header().Hash,
+			Number:       uint64(i),
+			Difficulty:   1,
+			StateRoot:    genesis.StateRoot,
+			TxRoot:       buildroot.CalculateTransactionsRoot([]*types.Transaction{txn}),
+			ReceiptsRoot: buildroot.CalculateReceiptsRoot(receipts),
+			Sha3Uncles:   types.EmptyUncleHash,
+			ExtraData:    []byte{},
+		}
+		header.ComputeHash()
+
+		assert.NoError(t, db.WriteBody(header.Hash, &types.Body{Transactions: []*types.Transaction{txn}}))
+		assert.NoError(t, db.WriteReceipts(header.Hash, receipts))
+		assert.NoError(t, b.WriteBlock(&types.Block{Header: header, Transactions: []*types.Transaction{txn}}))
+	}
+
+	s := &Server{
+		logger:     logger,
+		blockchain: b,
+		trieDB:     trieDB,
+	}
+
+	restore := func(to uint64) (string, *BackupInfo) {
+		var buf bytes.Buffer
+		info, err := s.Backup(&buf, to)
+		assert.NoError(t, err)
+
+		dataDir, err := ioutil.TempDir("/tmp", "minimal_restore")
+		assert.NoError(t, err)
+
+		restored, err := RestoreBackup(logger, dataDir, "leveldb", bytes.NewReader(buf.Bytes()))
+		assert.NoError(t, err)
+		assert.Equal(t, info, restored)
+
+		// the data dir is not empty anymore
+		_, err = RestoreBackup(logger, dataDir, "leveldb", bytes.NewReader(buf.Bytes()))
+		assert.Error(t, err)
+
+		return dataDir, restored
+	}
+
+	// a failed restore leaves the data dir empty
+	failedDir, err := ioutil.TempDir("/tmp", "minimal_restore")
+	assert.NoError(t, err)
+	defer os.RemoveAll(failedDir)
+
+	var buf bytes.Buffer
+	_, err = s.Backup(&buf, 0)
+	assert.NoError(t, err)
+	_, err = RestoreBackup(logger, failedDir, "leveldb", bytes.NewReader(buf.Bytes()[:buf.Len()/2]))
+	assert.Error(t, err)
+
+	files, err := ioutil.ReadDir(failedDir)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+
+	// back up the head
+	dataDir, info := restore(0)
+	defer os.RemoveAll(dataDir)
+
+	head := b.Header()
+	assert.Equal(t, head.Number, info.Number)
+	assert.Equal(t, head.Hash, info.Hash)
+
+	blockchainDB, restoredTrieDB, err := openStorageBackends(dataDir, "leveldb")
+	assert.NoError(t, err)
+	defer blockchainDB.Close()
+	defer restoredTrieDB.Close()
+
+	restoredDB := storage.NewKeyValueStorage(logger, blockchainDB)
+
+	hash, ok := restoredDB.ReadHeadHash()
+	assert.True(t, ok)
+	assert.Equal(t, head.Hash, hash)
+
+	for i := uint64(0); i <= head.Number; i++ {
+		header, _ := b.GetHeaderByNumber(i)
+		hash, ok := restoredDB.ReadCanonicalHash(i)
+		assert.True(t, ok)
+		assert.Equal(t, header.Hash, hash)
+
+		expected, _ := b.GetTD(hash)
+		diff, ok := restoredDB.ReadDiff(hash)
+		assert.True(t, ok)
+		assert.Equal(t, expected, diff)
+	}
+
+	body, err := restoredDB.ReadBody(head.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, txns[2].Hash, body.Transactions[0].Hash)
+
+	receipts, err := restoredDB.ReadReceipts(head.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), receipts[0].CumulativeGasUsed)
+
+	lookup, ok := restoredDB.ReadTxLookup(txns[0].Hash)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), lookup.BlockNumber)
+
+	// the state of the head is restored
+	st := itrie.NewState(itrie.NewKVStorage(restoredTrieDB))
+	snap, err := st.NewSnapshotAt(head.StateRoot)
+	assert.NoError(t, err)
+
+	txn := state.NewTxn(st, snap)
+	assert.Equal(t, big.NewInt(10), txn.GetBalance(addr))
+	assert.Equal(t, code, txn.GetCode(addr))
+
+	// back up an older block
+	dataDir2, info := restore(2)
+	defer os.RemoveAll(dataDir2)
+	assert.Equal(t, uint64(2), info.Number)
+
+	// the blocks over the head cannot be backed up
+	_, err = s.Backup(ioutil.Discard, 10)
+	assert.Error(t, err)
+}
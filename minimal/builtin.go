@@ -1,10 +1,14 @@
 package minimal
 
 import (
+	"github.com/0xPolygon/minimal/blockchain/storage/badger"
+	"github.com/0xPolygon/minimal/blockchain/storage/leveldb"
+	"github.com/0xPolygon/minimal/blockchain/storage/pebble"
 	consensusDev "github.com/0xPolygon/minimal/consensus/dev"
 	consensusDummy "github.com/0xPolygon/minimal/consensus/dummy"
 	consensusIBFT "github.com/0xPolygon/minimal/consensus/ibft"
 
+	"github.com/0xPolygon/minimal/blockchain/storage"
 	"github.com/0xPolygon/minimal/consensus"
 )
 
@@ -14,3 +18,15 @@ var consensusBackends = map[string]consensus.Factory{
 	"ibft":  consensusIBFT.Factory,
 	"dummy": consensusDummy.Factory,
 }
+
+var storageBackends = map[string]storage.BackendFactory{
+	"leveldb": leveldb.NewBackend,
+	"badger":  badger.NewBackend,
+	"pebble":  pebble.NewBackend,
+}
+
+var readOnlyStorageBackends = map[string]storage.ReadOnlyBackendFactory{
+	"leveldb": leveldb.NewReadOnlyBackend,
+	"badger":  badger.NewReadOnlyBackend,
+	"pebble":  pebble.NewReadOnlyBackend,
+}
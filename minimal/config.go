@@ -2,14 +2,30 @@ package minimal
 
 import (
 	"net"
+	"time"
 
+	"github.com/0xPolygon/minimal/blockchain"
 	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/gasprice"
+	"github.com/0xPolygon/minimal/jsonrpc"
 	"github.com/0xPolygon/minimal/network"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/txpool"
 )
 
 const DefaultGRPCPort int = 8545
 const DefaultJSONRPCPort int = 9632
 
+// DefaultRPCGasCap is the default cap of the gas of the calls through JSON-RPC
+const DefaultRPCGasCap uint64 = 50000000
+
+// DefaultRPCShutdownTimeout is the default time the shutdown waits for the JSON-RPC requests in flight,
+// which is below the time the node waits for the shutdown before exiting
+const DefaultRPCShutdownTimeout = 3 * time.Second
+
+// DefaultRPCWsSubscriptionLimit is the default max number of subscriptions of a JSON-RPC websocket connection
+const DefaultRPCWsSubscriptionLimit uint64 = 100
+
 // Config is used to parametrize the minimal client
 type Config struct {
 	Chain *chain.Chain
@@ -18,9 +34,106 @@ type Config struct {
 	GRPCAddr    *net.TCPAddr
 	LibP2PAddr  *net.TCPAddr
 
+	// IPCPath is the path of the unix socket of the JSON-RPC ipc endpoint, disabled if empty
+	IPCPath string
+
+	// JSONRPCTLS and GRPCTLS are the certificates of the JSON-RPC and of
+	// the GRPC endpoints, which are served without tls if nil
+	JSONRPCTLS *TLSConfig
+	GRPCTLS    *TLSConfig
+
 	Network *network.Config
 	DataDir string
 	Seal    bool
+
+	// StorageBackend is the key-value database of the blockchain and the state
+	StorageBackend string
+
+	// FreezerThreshold is the number of recent blocks kept in the database. The
+	// older blocks are moved to the freezer. Disabled if 0
+	FreezerThreshold uint64
+
+	// StorageCompression enables the compression of the bodies and the receipts written
+	StorageCompression bool
+
+	// Pruning is the pruning mode of the state, PruningArchive if empty
+	Pruning string
+
+	// PruningKeep is the number of recent states kept in PruningFull mode
+	PruningKeep uint64
+
+	// Snapshot enables the flat snapshot of the state for the reads of the accounts and storage
+	Snapshot bool
+
+	// Preimages enables the storage of the addresses and slots of the hashed keys of the
+	// state, which are reported by the dumps of the state
+	Preimages bool
+
+	// BlockGasTarget is the gas limit the sealed blocks move toward, if the chain
+	// manages the gas limit. The gas limit of the parent is kept if 0
+	BlockGasTarget uint64
+
+	// Cache are the sizes of the in-memory caches of the blockchain
+	Cache *blockchain.CacheConfig
+
+	// StateCache are the sizes of the in-memory caches of the state
+	StateCache *itrie.CacheConfig
+
+	GasPriceOracle *gasprice.Config
+
+	// RateLimit limits the txn submissions through JSON-RPC, disabled if nil
+	RateLimit *jsonrpc.RateLimitConfig
+
+	// RPCGasCap caps the gas of the calls and of the gas estimations through JSON-RPC, uncapped if 0
+	RPCGasCap uint64
+
+	// RPCWsSubscriptionLimit is the max number of subscriptions of a JSON-RPC websocket connection, unlimited if 0
+	RPCWsSubscriptionLimit uint64
+
+	// RPCDebug exposes the debug namespace of JSON-RPC, which replays the blocks and iterates the state
+	RPCDebug bool
+
+	// RPCTrace exposes the trace namespace of JSON-RPC, which replays the blocks
+	RPCTrace bool
+
+	// RPCServices are the JSON-RPC namespaces of the chain added to the built-in ones by their name
+	RPCServices map[string]interface{}
+
+	// RPCAdmin exposes the admin namespace of JSON-RPC, which adds and removes the peers of the node
+	RPCAdmin bool
+
+	// RPCAccess restricts the access to the JSON-RPC server, which is open if nil
+	RPCAccess *jsonrpc.AccessConfig
+
+	// RPCLogsLimits bound the queries of the logs through JSON-RPC
+	RPCLogsLimits *jsonrpc.LogsLimits
+
+	// RPCRequestLimits bound the concurrency and the duration of the JSON-RPC requests
+	RPCRequestLimits *jsonrpc.RequestLimits
+
+	// RPCSlowRequestThreshold is the duration above which the JSON-RPC requests are logged, disabled if 0
+	RPCSlowRequestThreshold time.Duration
+
+	// RPCShutdownTimeout is the time the shutdown waits for the JSON-RPC requests in flight
+	RPCShutdownTimeout time.Duration
+
+	// RPCWebsocket bounds the JSON-RPC websocket connections
+	RPCWebsocket *jsonrpc.WebsocketConfig
+
+	Telemetry *Telemetry
+
+	// TxPoolLimits are the size limits of the txns in the pool
+	TxPoolLimits *txpool.Limits
+
+	// Dev enables the acceptance of unsigned txns in the pool. It is
+	// only honored if the consensus of the chain is 'dev'
+	Dev *txpool.DevConfig
+}
+
+// Telemetry holds the config details for metric services
+type Telemetry struct {
+	// PrometheusAddr is the address of the prometheus metrics endpoint, disabled if nil
+	PrometheusAddr *net.TCPAddr
 }
 
 // DefaultConfig returns the default config for JSON-RPC, GRPC (ports) and Networking
@@ -29,5 +142,23 @@ func DefaultConfig() *Config {
 		JSONRPCAddr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: DefaultJSONRPCPort},
 		GRPCAddr:    &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: DefaultGRPCPort},
 		Network:     network.DefaultConfig(),
+		Telemetry:   &Telemetry{},
+
+		StorageBackend: DefaultStorageBackend,
+		Pruning:        PruningArchive,
+		PruningKeep:    DefaultPruningKeep,
+		Cache:          blockchain.DefaultCacheConfig(),
+		StateCache:     itrie.DefaultCacheConfig(),
+
+		TxPoolLimits: txpool.DefaultLimits(),
+
+		GasPriceOracle: gasprice.DefaultConfig(),
+
+		RPCGasCap:              DefaultRPCGasCap,
+		RPCWsSubscriptionLimit: DefaultRPCWsSubscriptionLimit,
+		RPCLogsLimits:          jsonrpc.DefaultLogsLimits(),
+		RPCRequestLimits:       jsonrpc.DefaultRequestLimits(),
+		RPCShutdownTimeout:     DefaultRPCShutdownTimeout,
+		RPCWebsocket:           jsonrpc.DefaultWebsocketConfig(),
 	}
 }
@@ -0,0 +1,115 @@
+package minimal
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/crypto"
+	"github.com/0xPolygon/minimal/state"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/state/runtime/evm"
+	"github.com/0xPolygon/minimal/state/runtime/precompiled"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteGenesis_InitCode(t *testing.T) {
+	params := &chain.Params{Forks: chain.AllForksEnabled, ChainID: 100}
+	st := itrie.NewState(itrie.NewMemoryStorage())
+
+	executor := state.NewExecutor(params, st)
+	executor.SetRuntime(precompiled.NewPrecompiled())
+	executor.SetRuntime(evm.NewEVM())
+
+	// the constructor stores its argument in the slot 0 and deploys 0x6001
+	initCode := []byte{
+		0x60, 0x20, 0x60, 0x20, 0x38, 0x03, 0x60, 0x00, 0x39, // codecopy(0, codesize-32, 32)
+		0x60, 0x00, 0x51, 0x60, 0x00, 0x55, // sstore(0, mload(0))
+		0x61, 0x60, 0x01, 0x60, 0x00, 0x52, // mstore(0, 0x6001)
+		0x60, 0x02, 0x60, 0x1e, 0xf3, // return(30, 2)
+	}
+	arg := types.StringToHash("1")
+
+	addr := types.StringToAddress("100")
+	genesis := &chain.Genesis{
+		GasLimit: 1000000,
+		Alloc: map[types.Address]*chain.GenesisAccount{
+			addr: {
+				Balance:         big.NewInt(10),
+				InitCode:        initCode,
+				ConstructorArgs: arg.Bytes(),
+			},
+		},
+	}
+
+	root, err := executor.WriteGenesis(genesis)
+	assert.NoError(t, err)
+
+	snap, err := st.NewSnapshotAt(root)
+	assert.NoError(t, err)
+
+	txn := state.NewTxn(st, snap)
+	assert.Equal(t, []byte{0x60, 0x01}, txn.GetCode(addr))
+	assert.Equal(t, arg, txn.GetState(addr, types.Hash{}))
+	assert.Equal(t, big.NewInt(10), txn.GetBalance(addr))
+	assert.Equal(t, uint64(1), txn.GetNonce(addr))
+
+	// the genesis is not written if a constructor reverts
+	genesis.Alloc[addr].InitCode = []byte{0x60, 0x00, 0x60, 0x00, 0xfd}
+	_, err = executor.WriteGenesis(genesis)
+	assert.Error(t, err)
+}
+
+func TestWriteGenesis_DeterministicDeployer(t *testing.T) {
+	params := &chain.Params{Forks: chain.AllForksEnabled, ChainID: 100}
+	st := itrie.NewState(itrie.NewMemoryStorage())
+
+	executor := state.NewExecutor(params, st)
+	executor.SetRuntime(precompiled.NewPrecompiled())
+	executor.SetRuntime(evm.NewEVM())
+	executor.GetHash = func(*types.Header) state.GetHashByNumber {
+		return func(uint64) types.Hash {
+			return types.Hash{}
+		}
+	}
+
+	sender := types.StringToAddress("100")
+	genesis := &chain.Genesis{
+		GasLimit: 1000000,
+		Alloc: map[types.Address]*chain.GenesisAccount{
+			sender: {Balance: big.NewInt(1000000)},
+		},
+	}
+	assert.NoError(t, genesis.AddDeterministicDeployer())
+
+	root, err := executor.WriteGenesis(genesis)
+	assert.NoError(t, err)
+
+	transition, err := executor.BeginTxn(root, &types.Header{Number: 1, GasLimit: 1000000})
+	assert.NoError(t, err)
+
+	// the proxy deploys 0x6001 with CREATE2 at an address that only depends on the salt and the init code
+	initCode := []byte{
+		0x61, 0x60, 0x01, 0x60, 0x00, 0x52, // mstore(0, 0x6001)
+		0x60, 0x02, 0x60, 0x1e, 0xf3, // return(30, 2)
+	}
+	salt := types.StringToHash("1")
+
+	deployer := chain.DeterministicDeployer
+	_, failed, err := transition.Apply(&types.Transaction{
+		From:     sender,
+		To:       &deployer,
+		Value:    big.NewInt(0),
+		Gas:      200000,
+		GasPrice: big.NewInt(1),
+		Input:    append(salt.Bytes(), initCode...),
+	})
+	assert.NoError(t, err)
+	assert.False(t, failed)
+
+	addr := crypto.CreateAddress2(deployer, salt, initCode)
+	assert.Equal(t, addr.Bytes(), transition.ReturnValue())
+	assert.Equal(t, []byte{0x60, 0x01}, transition.Txn().GetCode(addr))
+	assert.Equal(t, uint64(1), transition.Txn().GetNonce(addr))
+}
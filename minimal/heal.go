@@ -0,0 +1,252 @@
+package minimal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/0xPolygon/minimal/network"
+	"github.com/0xPolygon/minimal/protocol"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// healBatchSize is the number of missing trie nodes and codes requested to a peer at once
+const healBatchSize = 128
+
+// healRequestTimeout is the timeout of a request of missing trie nodes to a peer
+const healRequestTimeout = 10 * time.Second
+
+// healRetryInterval is the wait before the missing trie nodes are requested
+// again when none of the peers has them
+var healRetryInterval = 5 * time.Second
+
+var errHealAborted = errors.New("state healing aborted")
+
+// HealStatus is the progress of the healing of the state
+type HealStatus struct {
+	itrie.HealStats
+
+	Running bool
+	Root    types.Hash
+
+	// Err is the error of the last healing, if it failed
+	Err error
+}
+
+// healNetwork requests the trie nodes and the contract codes to the peers
+type healNetwork interface {
+	Peers() []peer.ID
+	RequestNodeData(ctx context.Context, id peer.ID, hashes []types.Hash) ([][]byte, error)
+
+	// ReportPeer lowers the reputation of a peer that timed out or delivered invalid entries
+	ReportPeer(id peer.ID, m network.Misbehavior, reason string)
+}
+
+// stateHealer finds the trie nodes and the contract codes missing from the state of a
+// root, i.e. after a snapshot sync or a corruption, and requests them to the peers
+// while the node is running. The entries delivered are verified and written back
+type stateHealer struct {
+	logger  hclog.Logger
+	storage itrie.Storage
+	network healNetwork
+
+	lock   sync.Mutex
+	status HealStatus
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+func newStateHealer(logger hclog.Logger, storage itrie.Storage, network healNetwork) *stateHealer {
+	return &stateHealer{
+		logger:  logger,
+		storage: storage,
+		network: network,
+		closeCh: make(chan struct{}),
+	}
+}
+
+// Heal starts healing the state of the root in the background,
+// it fails if a healing is already running
+func (h *stateHealer) Heal(root types.Hash) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.status.Running {
+		return fmt.Errorf("the state %s is already being healed", h.status.Root)
+	}
+	select {
+	case <-h.closeCh:
+		return fmt.Errorf("the state healer is closed")
+	default:
+	}
+	h.status = HealStatus{Running: true, Root: root}
+
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+
+		start := time.Now()
+		err := h.heal(itrie.NewHealer(h.storage, root))
+
+		h.lock.Lock()
+		h.status.Running = false
+		h.status.Err = err
+		status := h.status
+		h.lock.Unlock()
+
+		if err != nil {
+			if err != errHealAborted {
+				h.logger.Error("failed to heal the state", "root", root, "err", err)
+			}
+			return
+		}
+		h.logger.Info("Healed state", "root", root, "visited", status.Visited, "healed", status.Healed, "elapsed", time.Since(start))
+	}()
+	return nil
+}
+
+// Status returns the progress of the current or of the last healing
+func (h *stateHealer) Status() HealStatus {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return h.status
+}
+
+func (h *stateHealer) heal(healer *itrie.Healer) error {
+	for {
+		missing, err := healer.Missing(healBatchSize)
+		if err != nil {
+			return err
+		}
+		h.setStats(healer.Stats())
+
+		if len(missing) == 0 {
+			return nil
+		}
+		if h.fetch(healer, missing) != 0 {
+			continue
+		}
+
+		h.logger.Debug("missing trie nodes not found in the peers", "missing", len(missing))
+		select {
+		case <-time.After(healRetryInterval):
+		case <-h.closeCh:
+			return errHealAborted
+		}
+	}
+}
+
+// fetch requests the missing entries to the peers until all of them are
+// delivered, and returns the number of entries delivered
+func (h *stateHealer) fetch(healer *itrie.Healer, missing []types.Hash) int {
+	delivered := 0
+
+	for _, id := range h.network.Peers() {
+		select {
+		case <-h.closeCh:
+			return delivered
+		default:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), healRequestTimeout)
+		resp, err := h.network.RequestNodeData(ctx, id, missing)
+		timedOut := ctx.Err() == context.DeadlineExceeded
+		cancel()
+		if err != nil {
+			h.logger.Debug("failed to request trie nodes", "peer", id, "err", err)
+			if timedOut {
+				h.network.ReportPeer(id, network.MisbehaviorTimeout, "trie nodes request timed out")
+			}
+			continue
+		}
+
+		// the entries not delivered by the peer are requested to the next one
+		pending := []types.Hash{}
+		for i, hash := range missing {
+			if i >= len(resp) || resp[i] == nil {
+				pending = append(pending, hash)
+				continue
+			}
+			if err := healer.Deliver(hash, resp[i]); err != nil {
+				h.logger.Debug("invalid trie node delivered", "peer", id, "err", err)
+				h.network.ReportPeer(id, network.MisbehaviorProtocolError, err.Error())
+				pending = append(pending, hash)
+				continue
+			}
+			delivered++
+		}
+		h.setStats(healer.Stats())
+
+		if missing = pending; len(missing) == 0 {
+			break
+		}
+	}
+	return delivered
+}
+
+func (h *stateHealer) setStats(stats itrie.HealStats) {
+	h.lock.Lock()
+	h.status.HealStats = stats
+	h.lock.Unlock()
+}
+
+// Close stops the healing in progress, if any
+func (h *stateHealer) Close() error {
+	h.once.Do(func() {
+		h.lock.Lock()
+		close(h.closeCh)
+		h.lock.Unlock()
+	})
+	h.wg.Wait()
+	return nil
+}
+
+// stateNetwork requests the trie nodes to the peers connected with the state protocol
+type stateNetwork struct {
+	server  *network.Server
+	service *protocol.StateService
+}
+
+func (n *stateNetwork) Peers() []peer.ID {
+	ids := []peer.ID{}
+	for _, p := range n.server.Peers() {
+		ids = append(ids, p.Info.ID)
+	}
+	return ids
+}
+
+func (n *stateNetwork) RequestNodeData(ctx context.Context, id peer.ID, hashes []types.Hash) ([][]byte, error) {
+	return n.service.RequestNodeData(ctx, id, hashes)
+}
+
+func (n *stateNetwork) ReportPeer(id peer.ID, m network.Misbehavior, reason string) {
+	n.server.ReportPeer(id, m, reason)
+}
+
+// trieNodeReader serves the trie nodes and the contract codes of the state
+type trieNodeReader struct {
+	storage itrie.Storage
+}
+
+func (r *trieNodeReader) GetNodeData(hash types.Hash) ([]byte, bool) {
+	return itrie.GetNodeData(r.storage, hash)
+}
+
+// HealState starts healing the trie nodes missing from the state of the head in the
+// background, they are requested to the peers. It fails if a healing is already running
+func (s *Server) HealState() error {
+	return s.stateHealer.Heal(s.blockchain.Header().StateRoot)
+}
+
+// HealStatus returns the progress of the current or of the last healing of the state
+func (s *Server) HealStatus() HealStatus {
+	return s.stateHealer.Status()
+}
@@ -0,0 +1,146 @@
+package minimal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/0xPolygon/minimal/blockchain/storage/memory"
+	"github.com/0xPolygon/minimal/network"
+	"github.com/0xPolygon/minimal/state"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockHealNetwork serves the trie nodes of each peer from its storage,
+// the peers without storage fail the requests
+type mockHealNetwork struct {
+	peers map[peer.ID]itrie.Storage
+
+	// invalid are the peers that deliver invalid trie nodes
+	invalid map[peer.ID]bool
+
+	// reports are the number of misbehaviors reported for each peer
+	reports map[peer.ID]int
+}
+
+func (m *mockHealNetwork) Peers() []peer.ID {
+	return []peer.ID{"a", "b", "c"}
+}
+
+func (m *mockHealNetwork) RequestNodeData(ctx context.Context, id peer.ID, hashes []types.Hash) ([][]byte, error) {
+	s, ok := m.peers[id]
+	if !ok {
+		return nil, fmt.Errorf("peer %s not connected", id)
+	}
+	resp := make([][]byte, len(hashes))
+	for i, hash := range hashes {
+		if m.invalid[id] {
+			resp[i] = []byte{0x1}
+			continue
+		}
+		resp[i], _ = itrie.GetNodeData(s, hash)
+	}
+	return resp, nil
+}
+
+func (m *mockHealNetwork) ReportPeer(id peer.ID, misbehavior network.Misbehavior, reason string) {
+	if m.reports == nil {
+		m.reports = map[peer.ID]int{}
+	}
+	m.reports[id]++
+}
+
+func waitHealed(t *testing.T, h *stateHealer) HealStatus {
+	for i := 0; i < 100; i++ {
+		if status := h.Status(); !status.Running {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("state not healed")
+	return HealStatus{}
+}
+
+func TestStateHealer(t *testing.T) {
+	db := memory.NewBackend()
+	st := itrie.NewState(itrie.NewKVStorage(db))
+
+	addr := types.StringToAddress("1")
+	txn := state.NewTxn(st, st.NewSnapshot())
+	for i := 0; i < 20; i++ {
+		txn.SetState(addr, types.BytesToHash([]byte{byte(i)}), types.StringToHash("1"))
+	}
+	txn.SetCode(addr, []byte{0x1})
+	_, raw, err := txn.Commit(false)
+	assert.NoError(t, err)
+	root := types.BytesToHash(raw)
+
+	// the local state only has the root node
+	localDB := memory.NewBackend()
+	data, ok := itrie.GetNodeData(itrie.NewKVStorage(db), root)
+	assert.True(t, ok)
+	assert.NoError(t, localDB.Set(root.Bytes(), data))
+
+	// the first peer is not connected and the second one delivers invalid nodes
+	network := &mockHealNetwork{
+		peers: map[peer.ID]itrie.Storage{
+			"b": itrie.NewKVStorage(db),
+			"c": itrie.NewKVStorage(db),
+		},
+		invalid: map[peer.ID]bool{"b": true},
+	}
+	h := newStateHealer(hclog.NewNullLogger(), itrie.NewKVStorage(localDB), network)
+	defer h.Close()
+
+	assert.NoError(t, h.Heal(root))
+	status := waitHealed(t, h)
+	assert.NoError(t, status.Err)
+	assert.Equal(t, root, status.Root)
+	assert.NotZero(t, status.Healed)
+	assert.Zero(t, status.Pending)
+
+	// only the peer that delivered invalid nodes is reported
+	assert.NotZero(t, network.reports["b"])
+	assert.Zero(t, network.reports["a"])
+	assert.Zero(t, network.reports["c"])
+
+	local := itrie.NewState(itrie.NewKVStorage(localDB))
+	snap, err := local.NewSnapshotAt(root)
+	assert.NoError(t, err)
+
+	txn = state.NewTxn(local, snap)
+	assert.Equal(t, []byte{0x1}, txn.GetCode(addr))
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, types.StringToHash("1"), txn.GetState(addr, types.BytesToHash([]byte{byte(i)})))
+	}
+}
+
+func TestStateHealer_Close(t *testing.T) {
+	root := types.StringToHash("1")
+
+	// none of the peers has the missing root
+	network := &mockHealNetwork{
+		peers: map[peer.ID]itrie.Storage{
+			"a": itrie.NewMemoryStorage(),
+		},
+	}
+	h := newStateHealer(hclog.NewNullLogger(), itrie.NewMemoryStorage(), network)
+
+	assert.NoError(t, h.Heal(root))
+	assert.Error(t, h.Heal(root))
+
+	// the healing waiting for the peers is aborted
+	assert.NoError(t, h.Close())
+
+	status := h.Status()
+	assert.False(t, status.Running)
+	assert.Equal(t, errHealAborted, status.Err)
+	assert.Equal(t, uint64(1), status.Pending)
+
+	assert.Error(t, h.Heal(root))
+}
@@ -0,0 +1,71 @@
+package minimal
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+
+	"github.com/0xPolygon/minimal/blockchain"
+	"github.com/hashicorp/go-hclog"
+)
+
+// IndexerParams are the params passed to the factory of a chain indexer
+type IndexerParams struct {
+	Logger     hclog.Logger
+	Blockchain *blockchain.Blockchain
+
+	// DataDir is the dir of the node data dir where the indexer stores its index
+	DataDir string
+}
+
+// IndexerFactory creates a chain indexer. If the indexer implements io.Closer,
+// it is closed once the blockchain stops
+type IndexerFactory func(params *IndexerParams) (blockchain.ChainIndexer, error)
+
+var (
+	indexersLock sync.Mutex
+	indexers     = map[string]IndexerFactory{}
+)
+
+// RegisterIndexer registers a chain indexer that is run by the nodes created afterwards.
+// The name identifies the progress of the indexer, which resumes after a restart.
+// It panics if an indexer is already registered with the name
+func RegisterIndexer(name string, factory IndexerFactory) {
+	indexersLock.Lock()
+	defer indexersLock.Unlock()
+
+	if _, ok := indexers[name]; ok {
+		panic(fmt.Sprintf("chain indexer '%s' already registered", name))
+	}
+	indexers[name] = factory
+}
+
+// setupIndexers starts the registered chain indexers
+func (s *Server) setupIndexers() error {
+	indexersLock.Lock()
+	defer indexersLock.Unlock()
+
+	for name, factory := range indexers {
+		dataDir := filepath.Join(s.config.DataDir, "indexers", name)
+		if err := createDir(dataDir); err != nil {
+			return err
+		}
+
+		indexer, err := factory(&IndexerParams{
+			Logger:     s.logger.Named("indexer." + name),
+			Blockchain: s.blockchain,
+			DataDir:    dataDir,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create chain indexer '%s': %v", name, err)
+		}
+		s.blockchain.StartIndexer(name, indexer, s.state)
+
+		if closer, ok := indexer.(io.Closer); ok {
+			s.indexerClosers = append(s.indexerClosers, closer)
+		}
+		s.logger.Info("Chain indexer started", "name", name)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package minimal
+
+import (
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterIndexer(t *testing.T) {
+	defer delete(indexers, "test")
+
+	factory := func(params *IndexerParams) (blockchain.ChainIndexer, error) {
+		return nil, nil
+	}
+	RegisterIndexer("test", factory)
+
+	// the name of an indexer identifies its progress
+	assert.Panics(t, func() {
+		RegisterIndexer("test", factory)
+	})
+}
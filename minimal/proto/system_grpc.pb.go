@@ -23,12 +23,22 @@ type SystemClient interface {
 	GetStatus(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ServerStatus, error)
 	// PeersAdd adds a new peer
 	PeersAdd(ctx context.Context, in *PeersAddRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	// PeersRemove removes a peer from the static peers and disconnects it
+	PeersRemove(ctx context.Context, in *PeersRemoveRequest, opts ...grpc.CallOption) (*empty.Empty, error)
 	// PeersList returns the list of peers
 	PeersList(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*PeersListResponse, error)
 	// PeersInfo returns the info of a peer
 	PeersStatus(ctx context.Context, in *PeersStatusRequest, opts ...grpc.CallOption) (*Peer, error)
+	// Bandwidth returns the bandwidth used by protocol, gossip topic and peer
+	Bandwidth(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*BandwidthResponse, error)
 	// Subscribe subscribes to blockchain events
 	Subscribe(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (System_SubscribeClient, error)
+	// Backup streams a tar archive with the blocks and the state of the chain
+	Backup(ctx context.Context, in *BackupRequest, opts ...grpc.CallOption) (System_BackupClient, error)
+	// StateHeal starts healing the trie nodes missing from the state of the head
+	StateHeal(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*HealStatus, error)
+	// StateHealStatus returns the progress of the healing of the state
+	StateHealStatus(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*HealStatus, error)
 }
 
 type systemClient struct {
@@ -57,6 +67,15 @@ func (c *systemClient) PeersAdd(ctx context.Context, in *PeersAddRequest, opts .
 	return out, nil
 }
 
+func (c *systemClient) PeersRemove(ctx context.Context, in *PeersRemoveRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/v1.System/PeersRemove", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *systemClient) PeersList(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*PeersListResponse, error) {
 	out := new(PeersListResponse)
 	err := c.cc.Invoke(ctx, "/v1.System/PeersList", in, out, opts...)
@@ -75,6 +94,15 @@ func (c *systemClient) PeersStatus(ctx context.Context, in *PeersStatusRequest,
 	return out, nil
 }
 
+func (c *systemClient) Bandwidth(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*BandwidthResponse, error) {
+	out := new(BandwidthResponse)
+	err := c.cc.Invoke(ctx, "/v1.System/Bandwidth", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *systemClient) Subscribe(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (System_SubscribeClient, error) {
 	stream, err := c.cc.NewStream(ctx, &System_ServiceDesc.Streams[0], "/v1.System/Subscribe", opts...)
 	if err != nil {
@@ -107,6 +135,56 @@ func (x *systemSubscribeClient) Recv() (*BlockchainEvent, error) {
 	return m, nil
 }
 
+func (c *systemClient) Backup(ctx context.Context, in *BackupRequest, opts ...grpc.CallOption) (System_BackupClient, error) {
+	stream, err := c.cc.NewStream(ctx, &System_ServiceDesc.Streams[1], "/v1.System/Backup", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &systemBackupClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type System_BackupClient interface {
+	Recv() (*BackupResponse, error)
+	grpc.ClientStream
+}
+
+type systemBackupClient struct {
+	grpc.ClientStream
+}
+
+func (x *systemBackupClient) Recv() (*BackupResponse, error) {
+	m := new(BackupResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *systemClient) StateHeal(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*HealStatus, error) {
+	out := new(HealStatus)
+	err := c.cc.Invoke(ctx, "/v1.System/StateHeal", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *systemClient) StateHealStatus(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*HealStatus, error) {
+	out := new(HealStatus)
+	err := c.cc.Invoke(ctx, "/v1.System/StateHealStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SystemServer is the server API for System service.
 // All implementations must embed UnimplementedSystemServer
 // for forward compatibility
@@ -115,12 +193,22 @@ type SystemServer interface {
 	GetStatus(context.Context, *empty.Empty) (*ServerStatus, error)
 	// PeersAdd adds a new peer
 	PeersAdd(context.Context, *PeersAddRequest) (*empty.Empty, error)
+	// PeersRemove removes a peer from the static peers and disconnects it
+	PeersRemove(context.Context, *PeersRemoveRequest) (*empty.Empty, error)
 	// PeersList returns the list of peers
 	PeersList(context.Context, *empty.Empty) (*PeersListResponse, error)
 	// PeersInfo returns the info of a peer
 	PeersStatus(context.Context, *PeersStatusRequest) (*Peer, error)
+	// Bandwidth returns the bandwidth used by protocol, gossip topic and peer
+	Bandwidth(context.Context, *empty.Empty) (*BandwidthResponse, error)
 	// Subscribe subscribes to blockchain events
 	Subscribe(*empty.Empty, System_SubscribeServer) error
+	// Backup streams a tar archive with the blocks and the state of the chain
+	Backup(*BackupRequest, System_BackupServer) error
+	// StateHeal starts healing the trie nodes missing from the state of the head
+	StateHeal(context.Context, *empty.Empty) (*HealStatus, error)
+	// StateHealStatus returns the progress of the healing of the state
+	StateHealStatus(context.Context, *empty.Empty) (*HealStatus, error)
 	mustEmbedUnimplementedSystemServer()
 }
 
@@ -134,15 +222,30 @@ func (UnimplementedSystemServer) GetStatus(context.Context, *empty.Empty) (*Serv
 func (UnimplementedSystemServer) PeersAdd(context.Context, *PeersAddRequest) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method PeersAdd not implemented")
 }
+func (UnimplementedSystemServer) PeersRemove(context.Context, *PeersRemoveRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PeersRemove not implemented")
+}
 func (UnimplementedSystemServer) PeersList(context.Context, *empty.Empty) (*PeersListResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method PeersList not implemented")
 }
 func (UnimplementedSystemServer) PeersStatus(context.Context, *PeersStatusRequest) (*Peer, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method PeersStatus not implemented")
 }
+func (UnimplementedSystemServer) Bandwidth(context.Context, *empty.Empty) (*BandwidthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Bandwidth not implemented")
+}
 func (UnimplementedSystemServer) Subscribe(*empty.Empty, System_SubscribeServer) error {
 	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
 }
+func (UnimplementedSystemServer) Backup(*BackupRequest, System_BackupServer) error {
+	return status.Errorf(codes.Unimplemented, "method Backup not implemented")
+}
+func (UnimplementedSystemServer) StateHeal(context.Context, *empty.Empty) (*HealStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StateHeal not implemented")
+}
+func (UnimplementedSystemServer) StateHealStatus(context.Context, *empty.Empty) (*HealStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StateHealStatus not implemented")
+}
 func (UnimplementedSystemServer) mustEmbedUnimplementedSystemServer() {}
 
 // UnsafeSystemServer may be embedded to opt out of forward compatibility for this service.
@@ -192,6 +295,24 @@ func _System_PeersAdd_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _System_PeersRemove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PeersRemoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SystemServer).PeersRemove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.System/PeersRemove",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SystemServer).PeersRemove(ctx, req.(*PeersRemoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _System_PeersList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(empty.Empty)
 	if err := dec(in); err != nil {
@@ -228,6 +349,24 @@ func _System_PeersStatus_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _System_Bandwidth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SystemServer).Bandwidth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.System/Bandwidth",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SystemServer).Bandwidth(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _System_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(empty.Empty)
 	if err := stream.RecvMsg(m); err != nil {
@@ -249,6 +388,63 @@ func (x *systemSubscribeServer) Send(m *BlockchainEvent) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _System_Backup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BackupRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SystemServer).Backup(m, &systemBackupServer{stream})
+}
+
+type System_BackupServer interface {
+	Send(*BackupResponse) error
+	grpc.ServerStream
+}
+
+type systemBackupServer struct {
+	grpc.ServerStream
+}
+
+func (x *systemBackupServer) Send(m *BackupResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _System_StateHeal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SystemServer).StateHeal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.System/StateHeal",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SystemServer).StateHeal(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _System_StateHealStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SystemServer).StateHealStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.System/StateHealStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SystemServer).StateHealStatus(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // System_ServiceDesc is the grpc.ServiceDesc for System service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -264,6 +460,10 @@ var System_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "PeersAdd",
 			Handler:    _System_PeersAdd_Handler,
 		},
+		{
+			MethodName: "PeersRemove",
+			Handler:    _System_PeersRemove_Handler,
+		},
 		{
 			MethodName: "PeersList",
 			Handler:    _System_PeersList_Handler,
@@ -272,6 +472,18 @@ var System_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "PeersStatus",
 			Handler:    _System_PeersStatus_Handler,
 		},
+		{
+			MethodName: "Bandwidth",
+			Handler:    _System_Bandwidth_Handler,
+		},
+		{
+			MethodName: "StateHeal",
+			Handler:    _System_StateHeal_Handler,
+		},
+		{
+			MethodName: "StateHealStatus",
+			Handler:    _System_StateHealStatus_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -279,6 +491,11 @@ var System_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _System_Subscribe_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "Backup",
+			Handler:       _System_Backup_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "minimal/proto/system.proto",
 }
@@ -0,0 +1,128 @@
+package minimal
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/0xPolygon/minimal/blockchain"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+)
+
+const (
+	// PruningArchive keeps the states of all the blocks
+	PruningArchive = "archive"
+
+	// PruningFull keeps the states of the most recent blocks
+	PruningFull = "full"
+
+	// DefaultPruningKeep is the default number of recent states kept in full pruning mode
+	DefaultPruningKeep uint64 = 128
+)
+
+// validatePruning checks the pruning mode of the config
+func validatePruning(mode string, keep uint64) error {
+	switch mode {
+	case "", PruningArchive:
+		return nil
+	case PruningFull:
+		if keep == 0 {
+			return fmt.Errorf("at least the state of the head must be kept")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown pruning mode '%s'", mode)
+	}
+}
+
+// statePruner deletes the states of the blocks older than the most recent ones while the
+// node is running. The states are pruned each time the chain grows by the number of states
+// kept, so at most twice that number of states are stored
+type statePruner struct {
+	logger     hclog.Logger
+	blockchain *blockchain.Blockchain
+	state      *itrie.State
+	pruner     *itrie.Pruner
+	keep       uint64
+
+	// head at the last prune
+	last uint64
+
+	sub  blockchain.Subscription
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+func newStatePruner(logger hclog.Logger, b *blockchain.Blockchain, st *itrie.State, pruner *itrie.Pruner, keep uint64) *statePruner {
+	return &statePruner{
+		logger:     logger,
+		blockchain: b,
+		state:      st,
+		pruner:     pruner,
+		keep:       keep,
+	}
+}
+
+// start prunes the states in the background as the chain grows
+func (p *statePruner) start() {
+	p.last = p.blockchain.Header().Number
+	p.sub = p.blockchain.SubscribeEvents()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		for {
+			if p.sub.GetEvent() == nil {
+				return
+			}
+			head := p.blockchain.Header().Number
+			if head < p.last+p.keep {
+				continue
+			}
+			if err := p.prune(head); err != nil {
+				p.logger.Error("failed to prune the state", "err", err)
+			}
+			p.last = head
+		}
+	}()
+}
+
+// prune deletes the states older than the most recent ones from the given head
+func (p *statePruner) prune(head uint64) error {
+	from := uint64(0)
+	if head >= p.keep {
+		from = head - p.keep + 1
+	}
+
+	roots := []types.Hash{}
+	for n := from; n <= head; n++ {
+		header, ok := p.blockchain.GetHeaderByNumber(n)
+		if !ok {
+			return fmt.Errorf("header of block %d not found", n)
+		}
+		roots = append(roots, header.StateRoot)
+	}
+
+	deleted, err := p.pruner.Prune(roots)
+	if err != nil {
+		return err
+	}
+	// the cached tries of the pruned states cannot be read anymore
+	p.state.ResetCache()
+
+	p.logger.Info("Pruned state", "from", from, "to", head, "nodes", deleted)
+	return nil
+}
+
+// Close stops the pruner and waits for the prune in progress, if any
+func (p *statePruner) Close() error {
+	p.once.Do(func() {
+		if p.sub != nil {
+			p.sub.Close()
+		}
+	})
+	p.wg.Wait()
+	return nil
+}
@@ -0,0 +1,196 @@
+package minimal
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/0xPolygon/minimal/blockchain/bloombits"
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/blockchain/storage/freezer"
+	"github.com/0xPolygon/minimal/state"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/0xPolygon/minimal/types/buildroot"
+	"github.com/hashicorp/go-hclog"
+)
+
+// DiscardedBlock is a block at the tail of the chain that is not fully stored
+type DiscardedBlock struct {
+	Number uint64
+	Hash   types.Hash
+	Reason string
+}
+
+// RepairResult is the head of the chain before and after a repair
+type RepairResult struct {
+	Head        uint64
+	NewHead     uint64
+	NewHeadHash types.Hash
+
+	// Discarded are the blocks removed from the chain, from the newest to the oldest
+	Discarded []*DiscardedBlock
+}
+
+// RepairChain checks the blocks at the tail of the chain of the data dir and rolls the head
+// back to the last block fully stored, i.e. after a power loss. If dryRun is set, the data dir
+// is opened in read-only mode and only the blocks to discard are returned. Otherwise, the node
+// must not be running
+func RepairChain(logger hclog.Logger, dataDir string, dryRun bool) (*RepairResult, error) {
+	if dryRun {
+		dir, err := OpenReadOnlyDataDir(logger, dataDir)
+		if err != nil {
+			return nil, err
+		}
+		defer dir.Close()
+
+		return repairChain(logger, dir.Blockchain, dir.State, true)
+	}
+
+	name, err := readStorageBackend(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	blockchainDB, trieDB, err := openStorageBackends(dataDir, name)
+	if err != nil {
+		return nil, err
+	}
+	defer trieDB.Close()
+
+	ancients, err := freezer.Open(filepath.Join(dataDir, "ancient"))
+	if err != nil {
+		blockchainDB.Close()
+		return nil, err
+	}
+	db := storage.NewFreezerStorage(logger, blockchainDB, ancients)
+	defer db.Close()
+
+	return repairChain(logger, db, itrie.NewState(itrie.NewKVStorage(trieDB)), false)
+}
+
+// repairChain rolls the head back to the newest canonical block whose header, body,
+// receipts and state are stored. The blocks above it are logged and removed from the
+// canonical chain, they are synced again from the peers
+func repairChain(logger hclog.Logger, db storage.Storage, st state.State, dryRun bool) (*RepairResult, error) {
+	head, ok := db.ReadHeadNumber()
+	if !ok {
+		// the genesis has not been written yet
+		return &RepairResult{}, nil
+	}
+	headHash, ok := db.ReadHeadHash()
+	if !ok {
+		return nil, fmt.Errorf("head hash not found")
+	}
+
+	result := &RepairResult{Head: head}
+	for n := head; ; n-- {
+		hash, reason := checkStoredBlock(db, st, n)
+		if reason == "" && n == head && hash != headHash {
+			reason = fmt.Sprintf("the head hash is %s", headHash)
+		}
+		if reason == "" {
+			result.NewHead = n
+			result.NewHeadHash = hash
+			break
+		}
+
+		result.Discarded = append(result.Discarded, &DiscardedBlock{
+			Number: n,
+			Hash:   hash,
+			Reason: reason,
+		})
+		if n == 0 {
+			return nil, fmt.Errorf("genesis block is not stored: %s", reason)
+		}
+	}
+
+	for _, block := range result.Discarded {
+		logger.Warn("Discarding block", "number", block.Number, "hash", block.Hash, "reason", block.Reason, "dry-run", dryRun)
+	}
+	if dryRun || len(result.Discarded) == 0 {
+		return result, nil
+	}
+
+	if s, ok := db.(storage.AncientStorage); ok && result.NewHead+1 < s.Ancients() {
+		return nil, fmt.Errorf("block %d is in the freezer, the chain cannot be rolled back to it", result.NewHead)
+	}
+
+	batch := db.NewBatch()
+	if err := batch.WriteHeadHash(result.NewHeadHash); err != nil {
+		return nil, err
+	}
+	if err := batch.WriteHeadNumber(result.NewHead); err != nil {
+		return nil, err
+	}
+	for _, block := range result.Discarded {
+		if err := batch.DeleteCanonicalHash(block.Number); err != nil {
+			return nil, err
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+
+	// the bloom sections with discarded blocks are indexed again
+	if sections, ok := db.ReadBloomSections(); ok {
+		if max := (result.NewHead + 1) / bloombits.SectionSize; sections > max {
+			if err := db.WriteBloomSections(max); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	logger.Info("Repaired chain", "head", result.NewHead, "hash", result.NewHeadHash, "discarded", len(result.Discarded))
+	return result, nil
+}
+
+// checkStoredBlock returns the canonical hash of the block and the reason
+// why the block is not fully stored, or an empty reason if it is
+func checkStoredBlock(db storage.Storage, st state.State, n uint64) (types.Hash, string) {
+	hash, ok := db.ReadCanonicalHash(n)
+	if !ok {
+		return hash, "canonical hash not found"
+	}
+
+	header, err := db.ReadHeader(hash)
+	if err != nil {
+		return hash, fmt.Sprintf("header not found: %v", err)
+	}
+	if header.Number != n {
+		return hash, fmt.Sprintf("header has number %d", header.Number)
+	}
+	if _, ok := db.ReadDiff(hash); !ok {
+		return hash, "difficulty not found"
+	}
+	if n == 0 {
+		// the genesis has neither body nor receipts and its state
+		// is written again when the node starts
+		return hash, ""
+	}
+
+	body, err := db.ReadBody(hash)
+	if err != nil {
+		if err != storage.ErrNotFound || header.TxRoot != types.EmptyRootHash {
+			return hash, fmt.Sprintf("body not found: %v", err)
+		}
+		// the bodies of the blocks without transactions might not be stored
+		body = &types.Body{}
+	}
+	if root := buildroot.CalculateTransactionsRoot(body.Transactions); root != header.TxRoot {
+		return hash, fmt.Sprintf("body has transactions root %s", root)
+	}
+
+	receipts, err := db.ReadReceipts(hash)
+	if err != nil {
+		if err != storage.ErrNotFound || len(body.Transactions) != 0 {
+			return hash, fmt.Sprintf("receipts not found: %v", err)
+		}
+	}
+	if len(receipts) != len(body.Transactions) {
+		return hash, fmt.Sprintf("%d receipts for %d transactions", len(receipts), len(body.Transactions))
+	}
+
+	if _, err := st.NewSnapshotAt(header.StateRoot); err != nil {
+		return hash, fmt.Sprintf("state not found: %v", err)
+	}
+	return hash, ""
+}
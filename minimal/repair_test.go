@@ -0,0 +1,95 @@
+package minimal
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/blockchain/storage/memory"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepairChain(t *testing.T) {
+	db := storage.NewKeyValueStorage(hclog.NewNullLogger(), memory.NewBackend())
+	st := itrie.NewState(itrie.NewMemoryStorage())
+
+	// an empty chain is not repaired
+	result, err := repairChain(hclog.NewNullLogger(), db, st, false)
+	assert.NoError(t, err)
+	assert.Len(t, result.Discarded, 0)
+
+	headers := []*types.Header{}
+	for i := uint64(0); i < 6; i++ {
+		header := &types.Header{
+			Number:       i,
+			TxRoot:       types.EmptyRootHash,
+			ReceiptsRoot: types.EmptyRootHash,
+			StateRoot:    types.EmptyRootHash,
+			ExtraData:    []byte{},
+		}
+		if i > 0 {
+			header.ParentHash = headers[i-1].Hash
+		}
+		switch i {
+		case 4:
+			// the body was not stored
+			header.TxRoot = types.StringToHash("1")
+		case 5:
+			// the state was not stored
+			header.StateRoot = types.StringToHash("2")
+		}
+		header.ComputeHash()
+		headers = append(headers, header)
+
+		assert.NoError(t, db.WriteCanonicalHeader(header, big.NewInt(int64(i))))
+	}
+	assert.NoError(t, db.WriteBloomSections(1))
+
+	checkHead := func(n uint64) {
+		head, ok := db.ReadHeadNumber()
+		assert.True(t, ok)
+		assert.Equal(t, n, head)
+
+		hash, ok := db.ReadHeadHash()
+		assert.True(t, ok)
+		assert.Equal(t, headers[n].Hash, hash)
+	}
+
+	// the dry run does not modify the chain
+	result, err = repairChain(hclog.NewNullLogger(), db, st, true)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), result.Head)
+	assert.Equal(t, uint64(3), result.NewHead)
+	assert.Equal(t, headers[3].Hash, result.NewHeadHash)
+	assert.Len(t, result.Discarded, 2)
+	assert.Equal(t, headers[5].Hash, result.Discarded[0].Hash)
+	assert.Contains(t, result.Discarded[0].Reason, "state not found")
+	assert.Equal(t, headers[4].Hash, result.Discarded[1].Hash)
+	assert.Contains(t, result.Discarded[1].Reason, "body not found")
+	checkHead(5)
+
+	result, err = repairChain(hclog.NewNullLogger(), db, st, false)
+	assert.NoError(t, err)
+	assert.Len(t, result.Discarded, 2)
+	checkHead(3)
+
+	// the discarded blocks are removed from the canonical chain
+	_, ok := db.ReadCanonicalHash(4)
+	assert.False(t, ok)
+	_, ok = db.ReadCanonicalHash(5)
+	assert.False(t, ok)
+
+	// and from the bloom sections
+	sections, ok := db.ReadBloomSections()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(0), sections)
+
+	// the repaired chain is consistent
+	result, err = repairChain(hclog.NewNullLogger(), db, st, false)
+	assert.NoError(t, err)
+	assert.Len(t, result.Discarded, 0)
+	checkHead(3)
+}
@@ -0,0 +1,381 @@
+package minimal
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/blockchain/storage/freezer"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+)
+
+const (
+	// schemaVersionFile is the file in the data dir that records the version of its key layout
+	schemaVersionFile = "schema_version"
+
+	// schemaBackupSuffix is the suffix of the copies of the dirs taken before a migration
+	schemaBackupSuffix = ".schema-backup"
+
+	// schemaLogInterval is the number of keys migrated between the progress logs
+	schemaLogInterval = 100000
+
+	// schemaBatchSize is the number of keys written in each batch of a migration
+	schemaBatchSize = 1000
+)
+
+// schemaPaths are the dirs of the data dir modified by the migrations of the schema. The
+// freezer is not copied, it only grows with the ancient blocks and the migrations open it
+// in read-only mode
+var schemaPaths = []string{
+	"blockchain",
+}
+
+// schemaMigration upgrades the key layout of the blockchain from the previous version.
+// A migration must be idempotent, since it runs again if the node stops after it completes
+// but before the new version is recorded
+type schemaMigration struct {
+	// description is logged when the migration starts
+	description string
+
+	// migrate rewrites the blockchain storage of the data dir
+	migrate func(m *schemaMigrator) error
+}
+
+// schemaMigrations are the migrations of the schema, the data dir has
+// the version i+1 once the migration i has been applied
+var schemaMigrations = []*schemaMigration{
+	{
+		description: "index the position of the legacy txn lookups",
+		migrate:     migrateLegacyTxLookups,
+	},
+}
+
+// SchemaVersion is the version of the key layout of the data dirs created by this node
+func SchemaVersion() uint64 {
+	return uint64(len(schemaMigrations))
+}
+
+// readSchemaVersion returns the schema version recorded in the data dir
+func readSchemaVersion(dataDir string) (uint64, bool, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dataDir, schemaVersionFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	version, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid schema version: %v", err)
+	}
+	return version, true, nil
+}
+
+// writeSchemaVersion records the schema version in the data dir
+func writeSchemaVersion(dataDir string, version uint64) error {
+	return ioutil.WriteFile(filepath.Join(dataDir, schemaVersionFile), []byte(strconv.FormatUint(version, 10)), 0644)
+}
+
+// checkSchemaVersion fails if the data dir was written by a newer version of the node
+func checkSchemaVersion(dataDir string) error {
+	version, _, err := readSchemaVersion(dataDir)
+	if err != nil {
+		return err
+	}
+	if version > SchemaVersion() {
+		return fmt.Errorf("data dir has schema version %d, this node supports up to version %d", version, SchemaVersion())
+	}
+	return nil
+}
+
+// MigrateSchema upgrades the key layout of the data dir to the schema version of the node.
+// The data dirs without version were created before the schema was versioned and are
+// migrated from the first version. The dirs modified by each migration are copied before
+// it runs and restored if it fails, also if the node stops in the middle of a migration.
+// The node must not be running during the migration
+func MigrateSchema(logger hclog.Logger, dataDir string, backend string) error {
+	if err := restoreSchemaBackup(logger, dataDir); err != nil {
+		return err
+	}
+
+	empty, err := isEmptyDataDir(dataDir)
+	if err != nil {
+		return err
+	}
+	if empty {
+		// the version is written when the storage is created
+		return nil
+	}
+	if err := checkSchemaVersion(dataDir); err != nil {
+		return err
+	}
+
+	version, _, err := readSchemaVersion(dataDir)
+	if err != nil {
+		return err
+	}
+	for ; version < SchemaVersion(); version++ {
+		migration := schemaMigrations[version]
+		logger.Info("Migrating schema", "version", version+1, "migration", migration.description)
+
+		if err := runSchemaMigration(logger, dataDir, backend, migration); err != nil {
+			return fmt.Errorf("failed to migrate the data dir to schema version %d: %v", version+1, err)
+		}
+		// the backup is removed before the version is written, a migration
+		// might run again but it is never rolled back once it is recorded
+		if err := removeSchemaBackup(dataDir); err != nil {
+			return err
+		}
+		if err := writeSchemaVersion(dataDir, version+1); err != nil {
+			return err
+		}
+		logger.Info("Migrated schema", "version", version+1)
+	}
+	return nil
+}
+
+func runSchemaMigration(logger hclog.Logger, dataDir string, backend string, migration *schemaMigration) error {
+	if err := backupSchemaPaths(dataDir); err != nil {
+		return err
+	}
+
+	err := applySchemaMigration(logger, dataDir, backend, migration)
+	if err != nil {
+		if restoreErr := restoreSchemaBackup(logger, dataDir); restoreErr != nil {
+			return fmt.Errorf("%v, and the data dir could not be restored: %v", err, restoreErr)
+		}
+	}
+	return err
+}
+
+func applySchemaMigration(logger hclog.Logger, dataDir string, backend string, migration *schemaMigration) error {
+	blockchainDB, trieDB, err := openStorageBackends(dataDir, backend)
+	if err != nil {
+		return err
+	}
+	defer trieDB.Close()
+
+	ancients, err := freezer.OpenReadOnly(filepath.Join(dataDir, "ancient"))
+	if err != nil {
+		blockchainDB.Close()
+		return err
+	}
+	db := storage.NewFreezerStorage(logger, blockchainDB, ancients)
+
+	m := &schemaMigrator{
+		logger:  logger,
+		db:      db,
+		backend: blockchainDB,
+	}
+	if err := migration.migrate(m); err != nil {
+		db.Close()
+		return err
+	}
+	return db.Close()
+}
+
+// schemaMigrator gives a migration access to the blockchain storage of the data dir
+type schemaMigrator struct {
+	logger hclog.Logger
+
+	// db is the blockchain storage, including the freezer
+	db *storage.FreezerStorage
+
+	// backend is the key-value database of the blockchain
+	backend storage.Backend
+}
+
+// progress logs the number of keys migrated every schemaLogInterval keys
+func (m *schemaMigrator) progress(count uint64) {
+	if count%schemaLogInterval == 0 {
+		m.logger.Info("Migrating schema", "keys", count)
+	}
+}
+
+// backupSchemaPaths copies the dirs modified by the migrations. The copy is taken
+// in a temporary dir and renamed once it is complete, so an incomplete copy is never restored
+func backupSchemaPaths(dataDir string) error {
+	for _, path := range schemaPaths {
+		path := filepath.Join(dataDir, path)
+		tmpPath := path + schemaBackupSuffix + ".tmp"
+
+		// remove any leftover of a failed copy
+		if err := os.RemoveAll(tmpPath); err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if err := copyDir(path, tmpPath); err != nil {
+			return err
+		}
+		if err := os.Rename(tmpPath, path+schemaBackupSuffix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreSchemaBackup replaces the dirs with their backups, if any. The backups
+// only remain in the data dir if a migration did not complete
+func restoreSchemaBackup(logger hclog.Logger, dataDir string) error {
+	for _, path := range schemaPaths {
+		path := filepath.Join(dataDir, path)
+		backupPath := path + schemaBackupSuffix
+
+		if err := os.RemoveAll(backupPath + ".tmp"); err != nil {
+			return err
+		}
+		if _, err := os.Stat(backupPath); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+
+		logger.Warn("Restoring the data dir from an incomplete schema migration", "path", path)
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		if err := os.Rename(backupPath, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeSchemaBackup removes the backups once the migration is recorded
+func removeSchemaBackup(dataDir string) error {
+	for _, path := range schemaPaths {
+		if err := os.RemoveAll(filepath.Join(dataDir, path+schemaBackupSuffix)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyDir copies the files of the src dir to the dst dir
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode())
+		}
+		return copyFile(path, target, info.Mode())
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// migrateLegacyTxLookups rewrites the txn lookups written before the position of
+// the txns was indexed, which only have the hash of the block, with their position.
+// The lookups whose block is not found are kept, they are resolved when they are read
+func migrateLegacyTxLookups(m *schemaMigrator) error {
+	// the lookups are collected first since the database cannot be
+	// written while it is iterated
+	legacy := []types.Hash{}
+	err := m.backend.Iterate(storage.TX_LOOKUP_PREFIX, func(k, v []byte) bool {
+		if len(k) != len(storage.TX_LOOKUP_PREFIX)+types.HashLength {
+			// not a lookup, i.e. the tail of the backfill
+			return true
+		}
+		hash := types.BytesToHash(k[len(storage.TX_LOOKUP_PREFIX):])
+		if lookup, ok := m.db.ReadTxLookup(hash); ok && lookup.Legacy {
+			legacy = append(legacy, hash)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	skipped := 0
+	batch := m.db.NewBatch()
+	for i, hash := range legacy {
+		lookup, ok := m.resolveTxLookup(hash)
+		if ok {
+			if err := batch.WriteTxLookup(hash, lookup); err != nil {
+				return err
+			}
+		} else {
+			skipped++
+		}
+
+		count := uint64(i + 1)
+		if count%schemaBatchSize == 0 {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch = m.db.NewBatch()
+		}
+		m.progress(count)
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+
+	m.logger.Info("Migrated legacy txn lookups", "lookups", len(legacy)-skipped, "skipped", skipped)
+	return nil
+}
+
+// resolveTxLookup finds the position of the txn of a legacy lookup in its block
+func (m *schemaMigrator) resolveTxLookup(hash types.Hash) (*storage.TxLookup, bool) {
+	lookup, ok := m.db.ReadTxLookup(hash)
+	if !ok {
+		return nil, false
+	}
+	header, err := m.db.ReadHeader(lookup.BlockHash)
+	if err != nil {
+		return nil, false
+	}
+	body, err := m.db.ReadBody(lookup.BlockHash)
+	if err != nil {
+		return nil, false
+	}
+	for indx, txn := range body.Transactions {
+		if txn.Hash == hash {
+			return &storage.TxLookup{
+				BlockHash:   header.Hash,
+				BlockNumber: header.Number,
+				Index:       uint64(indx),
+			}, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,202 @@
+package minimal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/fastrlp"
+)
+
+func newSchemaDataDir(t *testing.T) string {
+	dataDir, err := ioutil.TempDir("/tmp", "minimal_schema")
+	assert.NoError(t, err)
+	assert.NoError(t, SetupDataDir(dataDir, dirPaths))
+	return dataDir
+}
+
+func TestSchemaVersion_NewDataDir(t *testing.T) {
+	dataDir := newSchemaDataDir(t)
+	defer os.RemoveAll(dataDir)
+
+	assert.NoError(t, MigrateSchema(hclog.NewNullLogger(), dataDir, "leveldb"))
+
+	blockchainDB, trieDB, err := openStorageBackends(dataDir, "leveldb")
+	assert.NoError(t, err)
+	blockchainDB.Close()
+	trieDB.Close()
+
+	version, ok, err := readSchemaVersion(dataDir)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, SchemaVersion(), version)
+
+	// a data dir written by a newer node is not opened
+	assert.NoError(t, writeSchemaVersion(dataDir, SchemaVersion()+1))
+	_, _, err = openStorageBackends(dataDir, "leveldb")
+	assert.Error(t, err)
+	assert.Error(t, MigrateSchema(hclog.NewNullLogger(), dataDir, "leveldb"))
+}
+
+func TestMigrateSchema_LegacyTxLookups(t *testing.T) {
+	dataDir := newSchemaDataDir(t)
+	defer os.RemoveAll(dataDir)
+
+	blockchainDB, trieDB, err := openStorageBackends(dataDir, "leveldb")
+	assert.NoError(t, err)
+	db := storage.NewKeyValueStorage(hclog.NewNullLogger(), blockchainDB)
+
+	txns := []*types.Transaction{}
+	for i := 0; i < 3; i++ {
+		txn := &types.Transaction{Nonce: uint64(i), Value: big.NewInt(0), GasPrice: big.NewInt(0), V: 0x27}
+		txn.ComputeHash()
+		txns = append(txns, txn)
+	}
+	header := &types.Header{Number: 1, ExtraData: []byte{}}
+	header.ComputeHash()
+	assert.NoError(t, db.WriteHeader(header))
+	assert.NoError(t, db.WriteBody(header.Hash, &types.Body{Transactions: txns}))
+
+	// write the lookups in the format of the data dirs without schema version
+	ar := &fastrlp.Arena{}
+	for _, txn := range txns {
+		key := append(append([]byte{}, storage.TX_LOOKUP_PREFIX...), txn.Hash.Bytes()...)
+		assert.NoError(t, blockchainDB.Set(key, ar.NewBytes(header.Hash.Bytes()).MarshalTo(nil)))
+	}
+	// the block of this lookup is not found
+	missing := types.StringToHash("1")
+	key := append(append([]byte{}, storage.TX_LOOKUP_PREFIX...), missing.Bytes()...)
+	assert.NoError(t, blockchainDB.Set(key, ar.NewBytes(types.StringToHash("2").Bytes()).MarshalTo(nil)))
+	assert.NoError(t, db.WriteTxLookupTail(0))
+
+	blockchainDB.Close()
+	trieDB.Close()
+	assert.NoError(t, os.Remove(filepath.Join(dataDir, schemaVersionFile)))
+
+	assert.NoError(t, MigrateSchema(hclog.NewNullLogger(), dataDir, "leveldb"))
+
+	version, _, err := readSchemaVersion(dataDir)
+	assert.NoError(t, err)
+	assert.Equal(t, SchemaVersion(), version)
+
+	// the backups are removed
+	_, err = os.Stat(filepath.Join(dataDir, "blockchain"+schemaBackupSuffix))
+	assert.True(t, os.IsNotExist(err))
+
+	blockchainDB, trieDB, err = openStorageBackends(dataDir, "leveldb")
+	assert.NoError(t, err)
+	defer trieDB.Close()
+	db = storage.NewKeyValueStorage(hclog.NewNullLogger(), blockchainDB)
+	defer db.Close()
+
+	for indx, txn := range txns {
+		lookup, ok := db.ReadTxLookup(txn.Hash)
+		assert.True(t, ok)
+		assert.False(t, lookup.Legacy)
+		assert.Equal(t, header.Hash, lookup.BlockHash)
+		assert.Equal(t, uint64(1), lookup.BlockNumber)
+		assert.Equal(t, uint64(indx), lookup.Index)
+	}
+
+	lookup, ok := db.ReadTxLookup(missing)
+	assert.True(t, ok)
+	assert.True(t, lookup.Legacy)
+}
+
+func TestMigrateSchema_Rollback(t *testing.T) {
+	dataDir := newSchemaDataDir(t)
+	defer os.RemoveAll(dataDir)
+
+	blockchainDB, trieDB, err := openStorageBackends(dataDir, "leveldb")
+	assert.NoError(t, err)
+	assert.NoError(t, blockchainDB.Set([]byte("a"), []byte{1}))
+	blockchainDB.Close()
+	trieDB.Close()
+
+	migrations := schemaMigrations
+	defer func() {
+		schemaMigrations = migrations
+	}()
+
+	version := SchemaVersion()
+	schemaMigrations = append(schemaMigrations[:len(schemaMigrations):len(schemaMigrations)], &schemaMigration{
+		description: "failing migration",
+		migrate: func(m *schemaMigrator) error {
+			if err := m.backend.Set([]byte("a"), []byte{2}); err != nil {
+				return err
+			}
+			return fmt.Errorf("failed")
+		},
+	})
+
+	assert.Error(t, MigrateSchema(hclog.NewNullLogger(), dataDir, "leveldb"))
+
+	// the data dir is restored and keeps its version
+	current, _, err := readSchemaVersion(dataDir)
+	assert.NoError(t, err)
+	assert.Equal(t, version, current)
+
+	_, err = os.Stat(filepath.Join(dataDir, "blockchain"+schemaBackupSuffix))
+	assert.True(t, os.IsNotExist(err))
+
+	schemaMigrations = migrations
+
+	blockchainDB, trieDB, err = openStorageBackends(dataDir, "leveldb")
+	assert.NoError(t, err)
+	defer blockchainDB.Close()
+	defer trieDB.Close()
+
+	v, ok, err := blockchainDB.Get([]byte("a"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte{1}, v)
+}
+
+func TestMigrateSchema_InterruptedMigration(t *testing.T) {
+	dataDir := newSchemaDataDir(t)
+	defer os.RemoveAll(dataDir)
+
+	blockchainDB, trieDB, err := openStorageBackends(dataDir, "leveldb")
+	assert.NoError(t, err)
+	assert.NoError(t, blockchainDB.Set([]byte("a"), []byte{1}))
+	blockchainDB.Close()
+	trieDB.Close()
+
+	assert.NoError(t, backupSchemaPaths(dataDir))
+
+	// the node stops in the middle of the migration
+	blockchainDB, trieDB, err = openStorageBackends(dataDir, "leveldb")
+	assert.NoError(t, err)
+	assert.NoError(t, blockchainDB.Set([]byte("a"), []byte{2}))
+	blockchainDB.Close()
+	trieDB.Close()
+
+	// an incomplete backup is never restored
+	assert.NoError(t, os.MkdirAll(filepath.Join(dataDir, "blockchain"+schemaBackupSuffix+".tmp"), 0755))
+
+	assert.NoError(t, MigrateSchema(hclog.NewNullLogger(), dataDir, "leveldb"))
+
+	_, err = os.Stat(filepath.Join(dataDir, "blockchain"+schemaBackupSuffix+".tmp"))
+	assert.True(t, os.IsNotExist(err))
+
+	// the freezer is not copied by the migrations
+	_, err = os.Stat(filepath.Join(dataDir, "ancient"+schemaBackupSuffix))
+	assert.True(t, os.IsNotExist(err))
+
+	blockchainDB, trieDB, err = openStorageBackends(dataDir, "leveldb")
+	assert.NoError(t, err)
+	defer blockchainDB.Close()
+	defer trieDB.Close()
+
+	v, ok, err := blockchainDB.Get([]byte("a"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte{1}, v)
+}
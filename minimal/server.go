@@ -3,29 +3,41 @@ package minimal
 import (
 	"context"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/0xPolygon/minimal/chain"
 	"github.com/0xPolygon/minimal/crypto"
+	"github.com/0xPolygon/minimal/gasprice"
 	"github.com/0xPolygon/minimal/helper/keccak"
 	"github.com/0xPolygon/minimal/jsonrpc"
 	"github.com/0xPolygon/minimal/minimal/proto"
 	"github.com/0xPolygon/minimal/network"
+	"github.com/0xPolygon/minimal/protocol"
 	"github.com/0xPolygon/minimal/state"
 	"github.com/0xPolygon/minimal/txpool"
 	"github.com/0xPolygon/minimal/types"
 
 	"github.com/hashicorp/go-hclog"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/state/runtime"
 	"github.com/0xPolygon/minimal/state/runtime/evm"
 	"github.com/0xPolygon/minimal/state/runtime/precompiled"
+	"github.com/0xPolygon/minimal/state/snapshot"
 
 	"github.com/0xPolygon/minimal/blockchain"
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/blockchain/storage/freezer"
 	"github.com/0xPolygon/minimal/consensus"
 )
 
@@ -35,6 +47,9 @@ type Server struct {
 	config *Config
 	state  state.State
 
+	// tries of the state, below the snapshot if there is one
+	trieState *itrie.State
+
 	consensus consensus.Consensus
 
 	// blockchain stack
@@ -44,6 +59,9 @@ type Server struct {
 	// state executor
 	executor *state.Executor
 
+	// database of the state trie
+	trieDB storage.Backend
+
 	// jsonrpc stack
 	jsonrpcServer *jsonrpc.JSONRPC
 
@@ -55,6 +73,25 @@ type Server struct {
 
 	// transaction pool
 	txpool *txpool.TxPool
+
+	// chain indexers closed once the blockchain stops
+	indexerClosers []io.Closer
+
+	// pruner of the old states in full pruning mode, nil in archive mode
+	statePruner *statePruner
+
+	// updater of the flat snapshot of the state, nil if it is disabled
+	snapshotUpdater *snapshotUpdater
+
+	// state protocol serving the trie nodes to the peers
+	stateService *protocol.StateService
+
+	// healer of the trie nodes missing from the state
+	stateHealer *stateHealer
+
+	// prometheus metrics
+	prometheusRegistry *prometheus.Registry
+	prometheusServer   *http.Server
 }
 
 var dirPaths = []string{
@@ -67,55 +104,157 @@ var dirPaths = []string{
 
 // NewServer creates a new Minimal server, using the passed in configuration
 func NewServer(logger hclog.Logger, config *Config) (*Server, error) {
+	grpcOpts := []grpc.ServerOption{}
+	if config.GRPCTLS != nil {
+		tlsConfig, err := config.GRPCTLS.Load()
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup grpc tls: %v", err)
+		}
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
 	m := &Server{
-		logger:     logger,
-		config:     config,
-		chain:      config.Chain,
-		grpcServer: grpc.NewServer(),
+		logger:             logger,
+		config:             config,
+		chain:              config.Chain,
+		grpcServer:         grpc.NewServer(grpcOpts...),
+		prometheusRegistry: prometheus.NewRegistry(),
 	}
 
 	m.logger.Info("Data dir", "path", config.DataDir)
+	for _, warning := range config.Chain.Warnings {
+		m.logger.Warn("chain config", "warning", warning)
+	}
 
 	// Generate all the paths in the dataDir
 	if err := SetupDataDir(config.DataDir, dirPaths); err != nil {
 		return nil, fmt.Errorf("failed to create data directories: %v", err)
 	}
 
-	// start libp2p
+	// upgrade the key layout of the data dirs written by older versions
+	if err := MigrateSchema(logger, m.config.DataDir, m.config.StorageBackend); err != nil {
+		return nil, err
+	}
+
+	// start blockchain object
+	blockchainDB, trieDB, err := openStorageBackends(m.config.DataDir, m.config.StorageBackend)
+	if err != nil {
+		return nil, err
+	}
+	m.trieDB = trieDB
+
+	if err := validatePruning(m.config.Pruning, m.config.PruningKeep); err != nil {
+		return nil, err
+	}
+
+	// in full pruning mode the writes to the state are tracked by the
+	// pruner so that the states being committed are never pruned
+	var pruner *itrie.Pruner
+	trieStorage := itrie.NewKVStorage(trieDB)
+	if m.config.Pruning == PruningFull {
+		pruner = itrie.NewPruner(trieDB)
+		trieStorage = pruner.Storage()
+	}
+
+	st := itrie.NewState(trieStorage)
+	st.SetPreimages(m.config.Preimages)
+	if m.config.StateCache != nil {
+		if err := st.SetCacheConfig(m.config.StateCache); err != nil {
+			return nil, err
+		}
+	}
+	if err := st.RegisterMetrics(m.prometheusRegistry); err != nil {
+		return nil, err
+	}
+	m.state = st
+	m.trieState = st
+
+	// read the accounts and storage from the flat snapshot on top of the tries
+	var snapshotTree *snapshot.Tree
+	if m.config.Snapshot {
+		snapshotTree = snapshot.NewTree(logger.Named("snapshot"), trieDB, st)
+		m.state = snapshotTree
+	}
+
+	m.executor = state.NewExecutor(config.Chain.Params, m.state)
+	m.executor.SetPrefetchWorkers(state.DefaultPrefetchWorkers)
+	if err := m.executor.RegisterMetrics(m.prometheusRegistry); err != nil {
+		return nil, err
+	}
+	precompiles := precompiled.NewPrecompiled()
+	if err := precompiles.EnableCustom(config.Chain.Params.Precompiles); err != nil {
+		return nil, err
+	}
+	m.executor.SetRuntime(precompiles)
+	m.executor.SetRuntime(evm.NewEVM())
+
+	// compute the genesis root state
+	genesisRoot, err := m.executor.WriteGenesis(config.Chain.Genesis)
+	if err != nil {
+		return nil, err
+	}
+	config.Chain.Genesis.StateRoot = genesisRoot
+
+	// start libp2p, once the genesis hash that identifies the chain is known
 	{
 		netConfig := config.Network
 		netConfig.Chain = m.config.Chain
 		netConfig.DataDir = filepath.Join(m.config.DataDir, "libp2p")
 
+		genesis := config.Chain.Genesis.GenesisHeader()
+		genesis.ComputeHash()
+		netConfig.GenesisHash = genesis.Hash
+
 		network, err := network.NewServer(logger, netConfig)
 		if err != nil {
 			return nil, err
 		}
 		m.network = network
+
+		if err := m.network.RegisterMetrics(m.prometheusRegistry); err != nil {
+			return nil, err
+		}
 	}
 
-	// start blockchain object
-	stateStorage, err := itrie.NewLevelDBStorage(filepath.Join(m.config.DataDir, "trie"), logger)
+	// serve the trie nodes to the peers and request the missing ones to them
+	m.stateService = protocol.NewStateService(logger, m.network, &trieNodeReader{storage: trieStorage})
+	m.stateService.Start()
+	m.stateHealer = newStateHealer(logger.Named("heal"), trieStorage, &stateNetwork{server: m.network, service: m.stateService})
+
+	// blockchain object
+	// the freezer is always opened since it might have blocks
+	// even if the freezing is disabled now
+	ancients, err := freezer.Open(filepath.Join(m.config.DataDir, "ancient"))
 	if err != nil {
 		return nil, err
 	}
 
-	st := itrie.NewState(stateStorage)
-	m.state = st
-
-	m.executor = state.NewExecutor(config.Chain.Params, st)
-	m.executor.SetRuntime(precompiled.NewPrecompiled())
-	m.executor.SetRuntime(evm.NewEVM())
-
-	// compute the genesis root state
-	genesisRoot := m.executor.WriteGenesis(config.Chain.Genesis.Alloc)
-	config.Chain.Genesis.StateRoot = genesisRoot
+	blockchainStorage := storage.NewFreezerStorage(logger.Named(m.config.StorageBackend), blockchainDB, ancients)
+	blockchainStorage.SetCompression(m.config.StorageCompression)
 
-	// blockchain object
-	m.blockchain, err = blockchain.NewBlockchain(logger, m.config.DataDir, config.Chain, nil, m.executor)
+	// roll back the blocks at the tail of the chain left incomplete by a crash
+	if _, err := repairChain(logger.Named("repair"), blockchainStorage, st, false); err != nil {
+		return nil, fmt.Errorf("failed to repair the chain: %v", err)
+	}
+	m.blockchain, err = blockchain.NewBlockchainWithStorage(logger, blockchainStorage, config.Chain, nil, m.executor)
 	if err != nil {
 		return nil, err
 	}
+	m.blockchain.SetFreezeThreshold(m.config.FreezerThreshold)
+	if m.config.Cache != nil {
+		if err := m.blockchain.SetCacheConfig(m.config.Cache); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.blockchain.RegisterMetrics(m.prometheusRegistry); err != nil {
+		return nil, err
+	}
+	if snapshotTree != nil {
+		m.snapshotUpdater = newSnapshotUpdater(logger.Named("snapshot"), m.blockchain, snapshotTree)
+	}
+	if pruner != nil {
+		m.statePruner = newStatePruner(logger.Named("pruner"), m.blockchain, st, pruner, m.config.PruningKeep)
+	}
 
 	m.executor.GetHash = m.blockchain.GetHashHelper
 
@@ -129,9 +268,36 @@ func NewServer(logger hclog.Logger, config *Config) (*Server, error) {
 			return nil, err
 		}
 
-		// use the eip155 signer
+		// use the eip155 signer. The senders recovered by the pool are
+		// cached for the execution of the blocks that include the txns
 		signer := crypto.NewEIP155Signer(uint64(m.config.Chain.Params.ChainID))
-		m.txpool.AddSigner(signer)
+		m.txpool.AddSigner(crypto.NewCachedSigner(signer, m.executor.SenderCache()))
+		m.txpool.SetForks(m.config.Chain.Params.Forks)
+
+		if m.config.TxPoolLimits != nil {
+			m.txpool.SetLimits(m.config.TxPoolLimits)
+		}
+
+		// only accept unsigned txns in dev chains
+		if devConfig := m.config.Dev; devConfig != nil {
+			if engine := m.config.Chain.Params.GetEngine(); engine == "dev" {
+				m.txpool.SetDevConfig(devConfig)
+			} else {
+				m.logger.Warn("dev mode ignored, the consensus is not dev", "engine", engine)
+			}
+		}
+
+		// only accept the allowlisted txns in permissioned chains
+		if allowlist := m.config.Chain.Params.Allowlist; allowlist != nil {
+			m.txpool.SetAllowlist(allowlist)
+		}
+
+		if err := m.txpool.RegisterMetrics(m.prometheusRegistry); err != nil {
+			return nil, err
+		}
+
+		// prune the pool with the new blocks
+		m.txpool.Start()
 	}
 
 	{
@@ -149,6 +315,23 @@ func NewServer(logger hclog.Logger, config *Config) (*Server, error) {
 		return nil, err
 	}
 
+	// index the position of the txns of the blocks written by older versions
+	m.blockchain.StartTxLookupBackfill()
+	m.blockchain.StartBloomIndexer()
+
+	if m.statePruner != nil {
+		m.statePruner.start()
+	}
+	if m.snapshotUpdater != nil {
+		if err := m.snapshotUpdater.start(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := m.setupIndexers(); err != nil {
+		return nil, err
+	}
+
 	// setup grpc server
 	if err := m.setupGRPC(); err != nil {
 		return nil, err
@@ -159,6 +342,11 @@ func NewServer(logger hclog.Logger, config *Config) (*Server, error) {
 		return nil, err
 	}
 
+	// setup the prometheus endpoint
+	if err := m.setupTelemetry(); err != nil {
+		return nil, err
+	}
+
 	if err := m.consensus.Start(); err != nil {
 		return nil, err
 	}
@@ -171,22 +359,46 @@ type txpoolHub struct {
 	*blockchain.Blockchain
 }
 
-func (t *txpoolHub) GetNonce(root types.Hash, addr types.Address) uint64 {
+func (t *txpoolHub) getAccount(root types.Hash, addr types.Address) (*state.Account, bool) {
 	snap, err := t.state.NewSnapshotAt(root)
 	if err != nil {
-		return 0
+		return nil, false
 	}
 	result, ok := snap.Get(keccak.Keccak256(nil, addr.Bytes()))
 	if !ok {
-		return 0
+		return nil, false
 	}
 	var account state.Account
 	if err := account.UnmarshalRlp(result); err != nil {
+		return nil, false
+	}
+	return &account, true
+}
+
+func (t *txpoolHub) GetNonce(root types.Hash, addr types.Address) uint64 {
+	account, ok := t.getAccount(root, addr)
+	if !ok {
 		return 0
 	}
 	return account.Nonce
 }
 
+func (t *txpoolHub) GetStorage(root types.Hash, addr types.Address, slot types.Hash) []byte {
+	account, ok := t.getAccount(root, addr)
+	if !ok {
+		return nil
+	}
+	snap, err := t.state.NewSnapshotAt(account.Root)
+	if err != nil {
+		return nil
+	}
+	result, ok := snap.Get(keccak.Keccak256(nil, slot.Bytes()))
+	if !ok {
+		return nil
+	}
+	return result
+}
+
 // setupConsensus sets up the consensus mechanism
 func (s *Server) setupConsensus() error {
 	engineName := s.config.Chain.Params.GetEngine()
@@ -203,6 +415,8 @@ func (s *Server) setupConsensus() error {
 		Params: s.config.Chain.Params,
 		Config: engineConfig,
 		Path:   filepath.Join(s.config.DataDir, "consensus"),
+
+		BlockGasTarget: s.config.BlockGasTarget,
 	}
 	consensus, err := engine(context.Background(), s.config.Seal, config, s.txpool, s.network, s.blockchain, s.executor, s.grpcServer, s.logger.Named("consensus"))
 	if err != nil {
@@ -214,11 +428,16 @@ func (s *Server) setupConsensus() error {
 }
 
 type jsonRPCHub struct {
-	state state.State
+	state     state.State
+	trieState *itrie.State
 
 	*blockchain.Blockchain
 	*txpool.TxPool
 	*state.Executor
+	*gasprice.Oracle
+
+	// p2p is the networking of the node, exposed through the net and the admin namespaces
+	p2p *network.Server
 }
 
 // HELPER + WRAPPER METHODS //
@@ -238,6 +457,11 @@ func (j *jsonRPCHub) getState(root types.Hash, slot []byte) ([]byte, error) {
 	return result, nil
 }
 
+func (j *jsonRPCHub) HasState(root types.Hash) bool {
+	_, err := j.state.NewSnapshotAt(root)
+	return err == nil
+}
+
 func (j *jsonRPCHub) GetAccount(root types.Hash, addr types.Address) (*state.Account, error) {
 	obj, err := j.getState(root, addr.Bytes())
 	if err != nil {
@@ -257,6 +481,23 @@ func (j *jsonRPCHub) GetStorage(root types.Hash, addr types.Address, slot types.
 		return nil, err
 	}
 
+	// read the slot from the snapshot of the state, if there is one
+	snap, err := j.state.NewSnapshotAt(root)
+	if err != nil {
+		return nil, err
+	}
+	if storageSnap, ok := snap.(state.StorageSnapshot); ok {
+		reader, err := storageSnap.GetStorage(types.BytesToHash(keccak.Keccak256(nil, addr.Bytes())), account.Root)
+		if err != nil {
+			return nil, err
+		}
+		obj, ok := reader.Get(keccak.Keccak256(nil, slot.Bytes()))
+		if !ok {
+			return nil, fmt.Errorf("error getting storage snapshot")
+		}
+		return obj, nil
+	}
+
 	obj, err := j.getState(account.Root, slot.Bytes())
 
 	if err != nil {
@@ -276,20 +517,223 @@ func (j *jsonRPCHub) GetCode(hash types.Hash) ([]byte, error) {
 	return res, nil
 }
 
-func (j *jsonRPCHub) ApplyTxn(header *types.Header, txn *types.Transaction) ([]byte, bool, error) {
+func (j *jsonRPCHub) GetProof(root types.Hash, addr types.Address, slots []types.Hash) (*state.AccountProof, error) {
+	return j.trieState.GetProof(root, addr, slots)
+}
+
+func (j *jsonRPCHub) DumpState(root types.Hash, start types.Hash, max uint64) (*state.Dump, error) {
+	return j.trieState.Dump(root, start, max)
+}
+
+func (j *jsonRPCHub) StorageRange(root types.Hash, addr types.Address, start types.Hash, max uint64) (*state.StorageRange, error) {
+	return j.trieState.StorageRange(root, addr, start, max)
+}
+
+func (j *jsonRPCHub) AccountStats(root types.Hash, addr types.Address) (*state.AccountStats, error) {
+	return j.trieState.AccountStats(root, addr)
+}
+
+func (j *jsonRPCHub) TraceBlockTxn(block *types.Block, index int, tracer runtime.Tracer) ([]byte, uint64, bool, error) {
+	parent, ok := j.GetHeaderByHash(block.ParentHash())
+	if !ok {
+		return nil, 0, false, fmt.Errorf("parent of block %d not found", block.Number())
+	}
+	return j.Executor.TraceTxn(parent.StateRoot, block, index, tracer)
+}
+
+func (j *jsonRPCHub) TraceBlock(block *types.Block, tracers []runtime.Tracer) ([]*state.TraceResult, error) {
+	parent, ok := j.GetHeaderByHash(block.ParentHash())
+	if !ok {
+		return nil, fmt.Errorf("parent of block %d not found", block.Number())
+	}
+	return j.Executor.TraceBlock(parent.StateRoot, block, tracers)
+}
+
+func (j *jsonRPCHub) Witness(block *types.Block) (*state.Witness, error) {
+	parent, ok := j.GetHeaderByHash(block.ParentHash())
+	if !ok {
+		return nil, fmt.Errorf("parent of block %d not found", block.Number())
+	}
+	return j.Executor.Witness(parent.StateRoot, block)
+}
+
+func (j *jsonRPCHub) TraceCall(header *types.Header, txn *types.Transaction, tracer runtime.Tracer) ([]byte, uint64, bool, error) {
+	transition, err := j.BeginTxn(header.StateRoot, header)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	gas, failed, err := transition.Trace(txn, tracer)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return transition.ReturnValue(), gas, failed, nil
+}
+
+func (j *jsonRPCHub) ApplyTxn(header *types.Header, txn *types.Transaction, override state.StateOverride) (*runtime.ExecutionResult, error) {
 	transition, err := j.BeginTxn(header.StateRoot, header)
 
 	if err != nil {
-		return nil, false, err
+		return nil, err
+	}
+
+	if override != nil {
+		if err := transition.WithStateOverride(override); err != nil {
+			return nil, err
+		}
 	}
 
-	_, failed, err := transition.Apply(txn)
+	gasUsed, _, err := transition.Apply(txn)
 
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 
-	return transition.ReturnValue(), failed, nil
+	return &runtime.ExecutionResult{
+		ReturnValue: transition.ReturnValue(),
+		GasUsed:     gasUsed,
+		Err:         transition.ExecutionError(),
+	}, nil
+}
+
+func (j *jsonRPCHub) ApplyTxns(header *types.Header, txns []*types.Transaction) ([]*runtime.ExecutionResult, error) {
+	transition, err := j.BeginTxn(header.StateRoot, header)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*runtime.ExecutionResult, 0, len(txns))
+	for i, txn := range txns {
+		gasUsed, _, err := transition.Apply(txn)
+		if err != nil {
+			return nil, fmt.Errorf("txn %d: %v", i, err)
+		}
+		results = append(results, &runtime.ExecutionResult{
+			ReturnValue: transition.ReturnValue(),
+			GasUsed:     gasUsed,
+			Err:         transition.ExecutionError(),
+		})
+	}
+	return results, nil
+}
+
+// NodeInfo returns the id and the p2p addresses of the node
+func (j *jsonRPCHub) NodeInfo() *jsonrpc.NodeInfo {
+	info := j.p2p.AddrInfo()
+	return &jsonrpc.NodeInfo{
+		ID:            info.ID.String(),
+		Addrs:         p2pAddrs(info),
+		ExternalAddrs: p2pAddrs(&peer.AddrInfo{ID: info.ID, Addrs: j.p2p.ExternalAddrs()}),
+		Reachability:  j.p2p.Reachability().String(),
+	}
+}
+
+// Peers returns the connected peers
+func (j *jsonRPCHub) Peers() []*jsonrpc.PeerInfo {
+	peers := []*jsonrpc.PeerInfo{}
+	for _, p := range j.p2p.Peers() {
+		// the protocols are unknown if the peer disconnected in the meantime
+		protocols, _ := j.p2p.GetProtocols(p.Info.ID)
+		info := j.p2p.GetPeerInfo(p.Info.ID)
+
+		peer := &jsonrpc.PeerInfo{
+			ID:         p.Info.ID.String(),
+			Addrs:      p2pAddrs(&info),
+			Protocols:  protocols,
+			Inbound:    j.p2p.IsInbound(p.Info.ID),
+			Reputation: j.p2p.PeerReputation(p.Info.ID),
+		}
+		if metadata, ok := j.p2p.GetPeerMetadata(p.Info.ID); ok {
+			peer.Name = metadata.Client
+			peer.Capabilities = metadata.Capabilities
+		}
+		peers = append(peers, peer)
+	}
+	return peers
+}
+
+// AddPeer adds the peer of the p2p address to the static peers
+func (j *jsonRPCHub) AddPeer(addr string) error {
+	return j.p2p.AddStaticPeer(addr)
+}
+
+// decodePeerID returns the peer id of the id or of the p2p address
+func decodePeerID(id string) (peer.ID, error) {
+	peerID, err := peer.Decode(id)
+	if err != nil {
+		info, err := network.StringToAddrInfo(id)
+		if err != nil {
+			return "", fmt.Errorf("invalid peer id or address '%s'", id)
+		}
+		peerID = info.ID
+	}
+	return peerID, nil
+}
+
+// RemovePeer removes the peer of the id or of the p2p address from the static peers and disconnects it
+func (j *jsonRPCHub) RemovePeer(id string) error {
+	peerID, err := decodePeerID(id)
+	if err != nil {
+		return err
+	}
+	if err := j.p2p.RemoveStaticPeer(peerID); err != nil {
+		return err
+	}
+	j.p2p.Disconnect(peerID, "removed through the admin api")
+	return nil
+}
+
+// AddTrustedPeer adds the peer of the p2p address to the trusted peers
+func (j *jsonRPCHub) AddTrustedPeer(addr string) error {
+	return j.p2p.AddTrustedPeer(addr)
+}
+
+// RemoveTrustedPeer removes the peer of the id or of the p2p address from the trusted peers
+func (j *jsonRPCHub) RemoveTrustedPeer(id string) error {
+	peerID, err := decodePeerID(id)
+	if err != nil {
+		return err
+	}
+	return j.p2p.RemoveTrustedPeer(peerID)
+}
+
+// BanPeer bans the peer of the id or of the p2p address for the duration, or permanently if 0
+func (j *jsonRPCHub) BanPeer(id string, duration time.Duration, reason string) error {
+	peerID, err := decodePeerID(id)
+	if err != nil {
+		return err
+	}
+	return j.p2p.BanPeer(peerID, duration, reason)
+}
+
+// UnbanPeer lifts the ban of the peer of the id or of the p2p address
+func (j *jsonRPCHub) UnbanPeer(id string) error {
+	peerID, err := decodePeerID(id)
+	if err != nil {
+		return err
+	}
+	return j.p2p.UnbanPeer(peerID)
+}
+
+// BannedPeers returns the banned peers
+func (j *jsonRPCHub) BannedPeers() []*jsonrpc.BannedPeerInfo {
+	res := []*jsonrpc.BannedPeerInfo{}
+	for _, p := range j.p2p.BannedPeers() {
+		res = append(res, &jsonrpc.BannedPeerInfo{
+			ID:     p.ID.String(),
+			Until:  p.Until,
+			Reason: p.Reason,
+		})
+	}
+	return res
+}
+
+// p2pAddrs returns the addresses of the peer that can be dialed, with its id
+func p2pAddrs(info *peer.AddrInfo) []string {
+	addrs := make([]string, 0, len(info.Addrs))
+	for _, addr := range info.Addrs {
+		addrs = append(addrs, addr.String()+"/p2p/"+info.ID.String())
+	}
+	return addrs
 }
 
 // SETUP //
@@ -298,15 +742,45 @@ func (j *jsonRPCHub) ApplyTxn(header *types.Header, txn *types.Transaction) ([]b
 func (s *Server) setupJSONRPC() error {
 	hub := &jsonRPCHub{
 		state:      s.state,
+		trieState:  s.trieState,
 		Blockchain: s.blockchain,
 		TxPool:     s.txpool,
 		Executor:   s.executor,
+		p2p:        s.network,
 	}
+	hub.Oracle = gasprice.NewOracle(hub, s.config.GasPriceOracle)
 
 	conf := &jsonrpc.Config{
-		Store:   hub,
-		Addr:    s.config.JSONRPCAddr,
-		ChainID: uint64(s.config.Chain.Params.ChainID),
+		Store:                hub,
+		Addr:                 s.config.JSONRPCAddr,
+		ChainID:              uint64(s.config.Chain.Params.ChainID),
+		RateLimit:            s.config.RateLimit,
+		GasCap:               s.config.RPCGasCap,
+		WsSubscriptionLimit:  s.config.RPCWsSubscriptionLimit,
+		EnableDebug:          s.config.RPCDebug,
+		EnableTrace:          s.config.RPCTrace,
+		Network:              hub,
+		EnableAdmin:          s.config.RPCAdmin,
+		Access:               s.config.RPCAccess,
+		IPCPath:              s.config.IPCPath,
+		LogsLimits:           s.config.RPCLogsLimits,
+		RequestLimits:        s.config.RPCRequestLimits,
+		SlowRequestThreshold: s.config.RPCSlowRequestThreshold,
+		ShutdownTimeout:      s.config.RPCShutdownTimeout,
+		Websocket:            s.config.RPCWebsocket,
+		Services:             s.config.RPCServices,
+		Mining:               s.config.Seal,
+	}
+	// the unsigned senders of the dev mode are the accounts of the node
+	if s.config.Dev != nil && s.config.Chain.Params.GetEngine() == "dev" {
+		conf.Accounts = s.config.Dev.UnsignedSenders
+	}
+	if s.config.JSONRPCTLS != nil {
+		tlsConfig, err := s.config.JSONRPCTLS.Load()
+		if err != nil {
+			return fmt.Errorf("failed to setup jsonrpc tls: %v", err)
+		}
+		conf.TLS = tlsConfig
 	}
 
 	srv, err := jsonrpc.NewJSONRPC(s.logger, conf)
@@ -315,6 +789,10 @@ func (s *Server) setupJSONRPC() error {
 	}
 	s.jsonrpcServer = srv
 
+	if err := srv.RegisterMetrics(s.prometheusRegistry); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -338,6 +816,35 @@ func (s *Server) setupGRPC() error {
 	return nil
 }
 
+// setupTelemetry starts the prometheus metrics endpoint, if enabled
+func (s *Server) setupTelemetry() error {
+	if s.config.Telemetry == nil || s.config.Telemetry.PrometheusAddr == nil {
+		return nil
+	}
+	addr := s.config.Telemetry.PrometheusAddr.String()
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(s.prometheusRegistry, promhttp.HandlerOpts{}))
+
+	s.prometheusServer = &http.Server{
+		Handler: mux,
+	}
+	go func() {
+		if err := s.prometheusServer.Serve(lis); err != nil && err != http.ErrServerClosed {
+			s.logger.Error("prometheus server failed", "err", err)
+		}
+	}()
+
+	s.logger.Info("Prometheus server running", "addr", addr)
+
+	return nil
+}
+
 // Chain returns the chain object of the client
 func (s *Server) Chain() *chain.Chain {
 	return s.chain
@@ -349,12 +856,15 @@ func (s *Server) Join(addr0 string, dur time.Duration) error {
 
 // Close closes the Minimal server (blockchain, networking, consensus)
 func (s *Server) Close() {
-	// Close the blockchain layer
-	if err := s.blockchain.Close(); err != nil {
-		s.logger.Error("failed to close blockchain", "err", err.Error())
+	// Drain the JSON-RPC requests before the stores they read are closed
+	if s.jsonrpcServer != nil {
+		if err := s.jsonrpcServer.Close(); err != nil {
+			s.logger.Error("failed to close JSON-RPC server", "err", err.Error())
+		}
 	}
 
-	// Close the networking layer
+	// Close the networking layer, the consensus and the transaction pool before the
+	// stores, since the peer requests read the state and the sealer writes it
 	if err := s.network.Close(); err != nil {
 		s.logger.Error("failed to close networking", "err", err.Error())
 	}
@@ -363,6 +873,52 @@ func (s *Server) Close() {
 	if err := s.consensus.Close(); err != nil {
 		s.logger.Error("failed to close consensus", "err", err.Error())
 	}
+
+	// Close the transaction pool
+	s.txpool.Close()
+
+	// Stop the pruning of the state before the storage is closed
+	if s.statePruner != nil {
+		if err := s.statePruner.Close(); err != nil {
+			s.logger.Error("failed to close state pruner", "err", err.Error())
+		}
+	}
+
+	// Stop the healing of the state before the storage is closed
+	if err := s.stateHealer.Close(); err != nil {
+		s.logger.Error("failed to close state healer", "err", err.Error())
+	}
+
+	// Write the state snapshot of the head before the storage is closed
+	if s.snapshotUpdater != nil {
+		if err := s.snapshotUpdater.Close(); err != nil {
+			s.logger.Error("failed to close state snapshot", "err", err.Error())
+		}
+	}
+
+	// Close the blockchain layer
+	if err := s.blockchain.Close(); err != nil {
+		s.logger.Error("failed to close blockchain", "err", err.Error())
+	}
+
+	// Close the chain indexers, which are no longer called
+	for _, closer := range s.indexerClosers {
+		if err := closer.Close(); err != nil {
+			s.logger.Error("failed to close chain indexer", "err", err.Error())
+		}
+	}
+
+	// Close the state storage
+	if err := s.trieDB.Close(); err != nil {
+		s.logger.Error("failed to close state storage", "err", err.Error())
+	}
+
+	// Close the prometheus server
+	if s.prometheusServer != nil {
+		if err := s.prometheusServer.Close(); err != nil {
+			s.logger.Error("failed to close prometheus server", "err", err.Error())
+		}
+	}
 }
 
 // Entry is a backend configuration entry
@@ -0,0 +1,66 @@
+package minimal
+
+import (
+	"sync"
+
+	"github.com/0xPolygon/minimal/blockchain"
+	"github.com/0xPolygon/minimal/state/snapshot"
+	"github.com/hashicorp/go-hclog"
+)
+
+// snapshotUpdater flattens the diff layers of the state snapshot as the head of the chain moves
+type snapshotUpdater struct {
+	logger     hclog.Logger
+	blockchain *blockchain.Blockchain
+	tree       *snapshot.Tree
+
+	sub  blockchain.Subscription
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+func newSnapshotUpdater(logger hclog.Logger, b *blockchain.Blockchain, tree *snapshot.Tree) *snapshotUpdater {
+	return &snapshotUpdater{
+		logger:     logger,
+		blockchain: b,
+		tree:       tree,
+	}
+}
+
+// start loads the snapshot at the head and updates it in the background
+func (u *snapshotUpdater) start() error {
+	if err := u.tree.Start(u.blockchain.Header().StateRoot); err != nil {
+		return err
+	}
+	u.sub = u.blockchain.SubscribeEvents()
+
+	u.wg.Add(1)
+	go func() {
+		defer u.wg.Done()
+
+		for {
+			if u.sub.GetEvent() == nil {
+				return
+			}
+			if err := u.tree.Cap(u.blockchain.Header().StateRoot, snapshot.DefaultLayers); err != nil {
+				u.logger.Error("failed to update the state snapshot", "err", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// Close stops the updates and writes the snapshot of the head on disk,
+// so that it is not generated again once the node restarts
+func (u *snapshotUpdater) Close() error {
+	u.once.Do(func() {
+		if u.sub != nil {
+			u.sub.Close()
+		}
+	})
+	u.wg.Wait()
+
+	err := u.tree.Cap(u.blockchain.Header().StateRoot, 0)
+	u.tree.Close()
+	return err
+}
@@ -0,0 +1,335 @@
+package minimal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/blockchain/storage/freezer"
+	"github.com/0xPolygon/minimal/state"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+)
+
+const (
+	// DefaultStorageBackend is the storage backend of the data dirs without a marker
+	DefaultStorageBackend = "leveldb"
+
+	// storageBackendFile is the file in the data dir that records its storage backend
+	storageBackendFile = "storage_backend"
+)
+
+// storagePaths are the dirs in the data dir stored with the storage backend
+var storagePaths = []string{
+	"blockchain",
+	"trie",
+}
+
+// readStorageBackend returns the storage backend recorded in the data dir
+func readStorageBackend(dataDir string) (string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dataDir, storageBackendFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			// data dirs created before the backends were pluggable only use leveldb
+			return DefaultStorageBackend, nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// writeStorageBackend records the storage backend in the data dir
+func writeStorageBackend(dataDir string, name string) error {
+	return ioutil.WriteFile(filepath.Join(dataDir, storageBackendFile), []byte(name), 0644)
+}
+
+// isEmptyDataDir returns true if no data has been stored yet with any backend
+func isEmptyDataDir(dataDir string) (bool, error) {
+	for _, path := range storagePaths {
+		files, err := ioutil.ReadDir(filepath.Join(dataDir, path))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return false, err
+		}
+		if len(files) != 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// openStorageBackends opens the blockchain and the trie backends in the data dir. It fails
+// if the data dir was created with a different backend, which has to be migrated first
+func openStorageBackends(dataDir string, name string) (storage.Backend, storage.Backend, error) {
+	factory, ok := storageBackends[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("storage backend '%s' not found", name)
+	}
+
+	empty, err := isEmptyDataDir(dataDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if empty {
+		if err := writeStorageBackend(dataDir, name); err != nil {
+			return nil, nil, err
+		}
+		// a new data dir has the key layout of this node
+		if err := writeSchemaVersion(dataDir, SchemaVersion()); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		current, err := readStorageBackend(dataDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		if current != name {
+			return nil, nil, fmt.Errorf("data dir uses the '%s' storage backend, migrate it to '%s' first", current, name)
+		}
+		if err := checkSchemaVersion(dataDir); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	blockchainDB, err := factory(filepath.Join(dataDir, "blockchain"))
+	if err != nil {
+		return nil, nil, err
+	}
+	trieDB, err := factory(filepath.Join(dataDir, "trie"))
+	if err != nil {
+		blockchainDB.Close()
+		return nil, nil, err
+	}
+	return blockchainDB, trieDB, nil
+}
+
+// openReadOnlyStorageBackends opens the blockchain and the trie backends in the data dir in read-only mode
+func openReadOnlyStorageBackends(dataDir string) (storage.Backend, storage.Backend, error) {
+	empty, err := isEmptyDataDir(dataDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if empty {
+		return nil, nil, fmt.Errorf("data dir %s is empty", dataDir)
+	}
+
+	name, err := readStorageBackend(dataDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	factory, ok := readOnlyStorageBackends[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("storage backend '%s' not found", name)
+	}
+
+	blockchainDB, err := factory(filepath.Join(dataDir, "blockchain"))
+	if err != nil {
+		return nil, nil, err
+	}
+	trieDB, err := factory(filepath.Join(dataDir, "trie"))
+	if err != nil {
+		blockchainDB.Close()
+		return nil, nil, err
+	}
+	return blockchainDB, trieDB, nil
+}
+
+// ReadOnlyDataDir is a data dir open in read-only mode, i.e. by the tools that
+// inspect the data dir of a node. The writes to its storages fail
+type ReadOnlyDataDir struct {
+	Blockchain storage.Storage
+	State      state.State
+
+	trieDB storage.Backend
+}
+
+// OpenReadOnlyDataDir opens the blockchain and the state of the data dir in read-only
+// mode. Depending on the storage backend, it can be opened while a node is running on it
+// and then the blocks written after it is opened might not be visible
+func OpenReadOnlyDataDir(logger hclog.Logger, dataDir string) (*ReadOnlyDataDir, error) {
+	name, err := readStorageBackend(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	blockchainDB, trieDB, err := openReadOnlyStorageBackends(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	ancients, err := freezer.OpenReadOnly(filepath.Join(dataDir, "ancient"))
+	if err != nil {
+		blockchainDB.Close()
+		trieDB.Close()
+		return nil, err
+	}
+
+	return &ReadOnlyDataDir{
+		Blockchain: storage.NewFreezerStorage(logger.Named(name), blockchainDB, ancients),
+		State:      itrie.NewState(itrie.NewKVStorage(trieDB)),
+		trieDB:     trieDB,
+	}, nil
+}
+
+// Close closes the storages of the data dir
+func (d *ReadOnlyDataDir) Close() error {
+	err := d.Blockchain.Close()
+	if trieErr := d.trieDB.Close(); trieErr != nil && err == nil {
+		err = trieErr
+	}
+	return err
+}
+
+// MigrateStorage copies the blockchain and the trie of the data dir to a new storage backend.
+// The old databases are kept in the data dir with the name of their backend as suffix.
+// The node must not be running during the migration
+func MigrateStorage(logger hclog.Logger, dataDir string, to string) error {
+	toFactory, ok := storageBackends[to]
+	if !ok {
+		return fmt.Errorf("storage backend '%s' not found", to)
+	}
+	from, err := readStorageBackend(dataDir)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return fmt.Errorf("data dir already uses the '%s' storage backend", to)
+	}
+	fromFactory, ok := storageBackends[from]
+	if !ok {
+		return fmt.Errorf("storage backend '%s' not found", from)
+	}
+
+	// the databases are copied into a temp dir, which is removed if the migration fails
+	tmpDir := filepath.Join(dataDir, "migrate")
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tmpDir, 0755); err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, path := range storagePaths {
+		count, err := migrateBackend(fromFactory, filepath.Join(dataDir, path), toFactory, filepath.Join(tmpDir, path))
+		if err != nil {
+			return fmt.Errorf("failed to migrate %s: %v", path, err)
+		}
+		logger.Info("Migrated storage", "path", path, "from", from, "to", to, "keys", count)
+	}
+
+	// swap the databases once all of them have been copied, the renames
+	// are undone in reverse order if any of them fails
+	undo := []func() error{}
+	rollback := func(err error) error {
+		for i := len(undo) - 1; i >= 0; i-- {
+			if undoErr := undo[i](); undoErr != nil {
+				logger.Error("failed to roll back the migration", "err", undoErr)
+			}
+		}
+		return err
+	}
+	rename := func(from, to string) error {
+		if err := os.Rename(from, to); err != nil {
+			return err
+		}
+		undo = append(undo, func() error {
+			return os.Rename(to, from)
+		})
+		return nil
+	}
+
+	for _, path := range storagePaths {
+		dst := filepath.Join(dataDir, path)
+		if err := rename(dst, dst+"."+from); err != nil {
+			return rollback(err)
+		}
+		if err := rename(filepath.Join(tmpDir, path), dst); err != nil {
+			return rollback(err)
+		}
+	}
+	if err := writeStorageBackend(dataDir, to); err != nil {
+		return rollback(err)
+	}
+	return nil
+}
+
+func migrateBackend(fromFactory storage.BackendFactory, fromPath string, toFactory storage.BackendFactory, toPath string) (uint64, error) {
+	from, err := fromFactory(fromPath)
+	if err != nil {
+		return 0, err
+	}
+	defer from.Close()
+
+	to, err := toFactory(toPath)
+	if err != nil {
+		return 0, err
+	}
+	count, err := storage.Migrate(from, to)
+	if err != nil {
+		to.Close()
+		return 0, err
+	}
+	return count, to.Close()
+}
+
+// PruneState deletes the state trie nodes that are not reachable from the states of the
+// most recent blocks. The older states cannot be accessed after the prune, so it must not
+// be used on archive nodes. The node must not be running during the prune
+func PruneState(logger hclog.Logger, dataDir string, keep uint64) error {
+	if keep == 0 {
+		return fmt.Errorf("at least the state of the head must be kept")
+	}
+
+	name, err := readStorageBackend(dataDir)
+	if err != nil {
+		return err
+	}
+	blockchainDB, trieDB, err := openStorageBackends(dataDir, name)
+	if err != nil {
+		return err
+	}
+	defer trieDB.Close()
+
+	ancients, err := freezer.Open(filepath.Join(dataDir, "ancient"))
+	if err != nil {
+		blockchainDB.Close()
+		return err
+	}
+	db := storage.NewFreezerStorage(logger, blockchainDB, ancients)
+	defer db.Close()
+
+	head, ok := db.ReadHeadNumber()
+	if !ok {
+		return fmt.Errorf("head not found")
+	}
+
+	from := uint64(0)
+	if head >= keep {
+		from = head - keep + 1
+	}
+
+	roots := []types.Hash{}
+	for n := from; n <= head; n++ {
+		hash, ok := db.ReadCanonicalHash(n)
+		if !ok {
+			return fmt.Errorf("canonical hash of block %d not found", n)
+		}
+		header, err := db.ReadHeader(hash)
+		if err != nil {
+			return fmt.Errorf("header of block %d not found: %v", n, err)
+		}
+		roots = append(roots, header.StateRoot)
+	}
+
+	deleted, err := itrie.Prune(trieDB, roots)
+	if err != nil {
+		return err
+	}
+	logger.Info("Pruned state", "from", from, "to", head, "nodes", deleted)
+	return nil
+}
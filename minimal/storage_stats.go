@@ -0,0 +1,217 @@
+package minimal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/blockchain/storage/freezer"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+)
+
+// blockchainKeyGroups are the names of the prefixes of the blockchain database
+var blockchainKeyGroups = []struct {
+	prefix []byte
+	name   string
+}{
+	{storage.HEADER, "headers"},
+	{storage.BODY, "bodies"},
+	{storage.RECEIPTS, "receipts"},
+	{storage.DIFFICULTY, "difficulties"},
+	{storage.CANONICAL, "canonical"},
+	{storage.TX_LOOKUP_PREFIX, "txlookups"},
+	{storage.BLOOM_BITS, "bloombits"},
+	{storage.ANCIENT, "ancients"},
+	{storage.SNAPSHOTS, "snapshots"},
+	{storage.FORK, "forks"},
+	{storage.HEAD, "head"},
+	{storage.CHAIN_CONFIG, "config"},
+	{storage.INDEXER, "indexers"},
+}
+
+// otherKeyGroup is the group of the keys without a known prefix
+const otherKeyGroup = "other"
+
+func blockchainKeyGroup(k []byte) string {
+	for _, group := range blockchainKeyGroups {
+		if len(k) != 0 && k[0] == group.prefix[0] {
+			return group.name
+		}
+	}
+	return otherKeyGroup
+}
+
+func trieKeyGroup(k []byte) string {
+	if len(k) == types.HashLength {
+		return "nodes"
+	}
+	if itrie.IsCodeKey(k) {
+		return "code"
+	}
+	return otherKeyGroup
+}
+
+// KeyGroupStats are the number of keys of a group and the size of their pairs in bytes
+type KeyGroupStats struct {
+	Name string
+	Keys uint64
+	Size uint64
+}
+
+// DatabaseStats are the stats of the key groups of a database and its size on disk
+type DatabaseStats struct {
+	Name     string
+	DiskSize uint64
+	Groups   []*KeyGroupStats
+}
+
+// StorageStats reads the stats of the blockchain, the trie and the freezer of the data
+// dir. The databases are opened in read-only mode, so the node can be running
+func StorageStats(dataDir string) ([]*DatabaseStats, error) {
+	blockchainDB, trieDB, err := openReadOnlyStorageBackends(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	defer blockchainDB.Close()
+	defer trieDB.Close()
+
+	stats := []*DatabaseStats{}
+	for _, db := range []struct {
+		name    string
+		backend storage.Backend
+		group   func(k []byte) string
+	}{
+		{"blockchain", blockchainDB, blockchainKeyGroup},
+		{"trie", trieDB, trieKeyGroup},
+	} {
+		dbStats, err := backendStats(db.backend, db.group)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the stats of %s: %v", db.name, err)
+		}
+		dbStats.Name = db.name
+		if dbStats.DiskSize, err = dirSize(filepath.Join(dataDir, db.name)); err != nil {
+			return nil, err
+		}
+		stats = append(stats, dbStats)
+	}
+
+	ancientStats, err := freezerStats(filepath.Join(dataDir, "ancient"))
+	if err != nil {
+		return nil, err
+	}
+	return append(stats, ancientStats), nil
+}
+
+func backendStats(b storage.Backend, group func(k []byte) string) (*DatabaseStats, error) {
+	stats := &DatabaseStats{Groups: []*KeyGroupStats{}}
+	groups := map[string]*KeyGroupStats{}
+
+	err := b.Iterate(nil, func(k, v []byte) bool {
+		name := group(k)
+		g, ok := groups[name]
+		if !ok {
+			g = &KeyGroupStats{Name: name}
+			groups[name] = g
+			stats.Groups = append(stats.Groups, g)
+		}
+		g.Keys++
+		g.Size += uint64(len(k) + len(v))
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// freezerStats returns the number of ancient blocks as the keys of the freezer
+func freezerStats(path string) (*DatabaseStats, error) {
+	f, err := freezer.OpenReadOnly(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	size, err := dirSize(path)
+	if err != nil {
+		return nil, err
+	}
+	return &DatabaseStats{
+		Name:     "ancient",
+		DiskSize: size,
+		Groups: []*KeyGroupStats{
+			{Name: "blocks", Keys: f.Ancients(), Size: size},
+		},
+	}, nil
+}
+
+// dirSize returns the size of the files in the dir, which might not exist
+func dirSize(path string) (uint64, error) {
+	size := uint64(0)
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			size += uint64(info.Size())
+		}
+		return nil
+	})
+	return size, err
+}
+
+// CompactStorage compacts the blockchain and the trie databases of the data dir to
+// reclaim the space of the deleted pairs, i.e. after a prune. The node must not be running
+func CompactStorage(logger hclog.Logger, dataDir string) error {
+	empty, err := isEmptyDataDir(dataDir)
+	if err != nil {
+		return err
+	}
+	if empty {
+		return fmt.Errorf("data dir %s is empty", dataDir)
+	}
+	name, err := readStorageBackend(dataDir)
+	if err != nil {
+		return err
+	}
+	factory, ok := storageBackends[name]
+	if !ok {
+		return fmt.Errorf("storage backend '%s' not found", name)
+	}
+
+	for _, path := range storagePaths {
+		path := filepath.Join(dataDir, path)
+		if err := compactBackend(factory, path); err != nil {
+			return fmt.Errorf("failed to compact %s: %v", path, err)
+		}
+		size, err := dirSize(path)
+		if err != nil {
+			return err
+		}
+		logger.Info("Compacted storage", "path", path, "backend", name, "size", size)
+	}
+	return nil
+}
+
+func compactBackend(factory storage.BackendFactory, path string) error {
+	b, err := factory(path)
+	if err != nil {
+		return err
+	}
+	c, ok := b.(storage.Compacter)
+	if !ok {
+		b.Close()
+		return fmt.Errorf("the storage backend does not support compaction")
+	}
+	if err := c.Compact(); err != nil {
+		b.Close()
+		return err
+	}
+	return b.Close()
+}
@@ -0,0 +1,70 @@
+package minimal
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorageStatsAndCompact(t *testing.T) {
+	for _, backend := range []string{"leveldb", "badger", "pebble"} {
+		t.Run(backend, func(t *testing.T) {
+			dataDir, err := ioutil.TempDir("/tmp", "minimal_storage")
+			assert.NoError(t, err)
+			defer os.RemoveAll(dataDir)
+
+			assert.NoError(t, SetupDataDir(dataDir, storagePaths))
+
+			// the stats of an empty data dir cannot be read
+			_, err = StorageStats(dataDir)
+			assert.Error(t, err)
+
+			blockchainDB, trieDB, err := openStorageBackends(dataDir, backend)
+			assert.NoError(t, err)
+
+			db := storage.NewKeyValueStorage(hclog.NewNullLogger(), blockchainDB)
+			for i := uint64(0); i < 3; i++ {
+				assert.NoError(t, db.WriteHeader(&types.Header{Number: i, Hash: types.Hash{byte(i)}}))
+			}
+			assert.NoError(t, db.WriteHeadNumber(2))
+			assert.NoError(t, trieDB.Set(types.Hash{1}.Bytes(), []byte{1}))
+			assert.NoError(t, trieDB.Set(append([]byte("code"), types.Hash{2}.Bytes()...), []byte{2}))
+			assert.NoError(t, blockchainDB.Close())
+			assert.NoError(t, trieDB.Close())
+
+			stats, err := StorageStats(dataDir)
+			assert.NoError(t, err)
+			assert.Len(t, stats, 3)
+
+			keys := func(db *DatabaseStats) map[string]uint64 {
+				res := map[string]uint64{}
+				for _, group := range db.Groups {
+					res[group.Name] = group.Keys
+				}
+				return res
+			}
+			assert.Equal(t, "blockchain", stats[0].Name)
+			assert.Equal(t, map[string]uint64{"headers": 3, "head": 1}, keys(stats[0]))
+			assert.NotZero(t, stats[0].DiskSize)
+
+			assert.Equal(t, "trie", stats[1].Name)
+			assert.Equal(t, map[string]uint64{"nodes": 1, "code": 1}, keys(stats[1]))
+
+			assert.Equal(t, "ancient", stats[2].Name)
+			assert.Equal(t, map[string]uint64{"blocks": 0}, keys(stats[2]))
+
+			assert.NoError(t, CompactStorage(hclog.NewNullLogger(), dataDir))
+
+			// the pairs are kept after the compaction
+			after, err := StorageStats(dataDir)
+			assert.NoError(t, err)
+			assert.Equal(t, keys(stats[0]), keys(after[0]))
+			assert.Equal(t, keys(stats[1]), keys(after[1]))
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package minimal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/blockchain/storage/pebble"
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateStorage(t *testing.T) {
+	dataDir, err := ioutil.TempDir("/tmp", "minimal_storage")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	assert.NoError(t, SetupDataDir(dataDir, storagePaths))
+
+	blockchainDB, trieDB, err := openStorageBackends(dataDir, "leveldb")
+	assert.NoError(t, err)
+	assert.NoError(t, blockchainDB.Set([]byte("a"), []byte{1}))
+	assert.NoError(t, trieDB.Set([]byte("b"), []byte{2}))
+	blockchainDB.Close()
+	trieDB.Close()
+
+	// the data dir cannot be opened with another backend before the migration
+	_, _, err = openStorageBackends(dataDir, "pebble")
+	assert.Error(t, err)
+
+	assert.NoError(t, MigrateStorage(hclog.NewNullLogger(), dataDir, "pebble"))
+
+	backend, err := readStorageBackend(dataDir)
+	assert.NoError(t, err)
+	assert.Equal(t, "pebble", backend)
+
+	blockchainDB, trieDB, err = openStorageBackends(dataDir, "pebble")
+	assert.NoError(t, err)
+	defer blockchainDB.Close()
+	defer trieDB.Close()
+
+	v, ok, err := blockchainDB.Get([]byte("a"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte{1}, v)
+
+	v, ok, err = trieDB.Get([]byte("b"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte{2}, v)
+}
+
+// failingBackend is a backend whose batches fail to be written, i.e. with a full disk
+type failingBackend struct {
+	storage.Backend
+}
+
+func (b *failingBackend) NewBatch() storage.Batch {
+	return &failingBatch{b.Backend.NewBatch()}
+}
+
+type failingBatch struct {
+	storage.Batch
+}
+
+func (b *failingBatch) Write() error {
+	return fmt.Errorf("no space left on device")
+}
+
+func TestMigrateStorage_Rollback(t *testing.T) {
+	dataDir, err := ioutil.TempDir("/tmp", "minimal_storage")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	assert.NoError(t, SetupDataDir(dataDir, storagePaths))
+
+	blockchainDB, trieDB, err := openStorageBackends(dataDir, "leveldb")
+	assert.NoError(t, err)
+	assert.NoError(t, trieDB.Set([]byte("b"), []byte{2}))
+	blockchainDB.Close()
+	trieDB.Close()
+
+	storageBackends["failing"] = func(path string) (storage.Backend, error) {
+		b, err := pebble.NewBackend(path)
+		if err != nil {
+			return nil, err
+		}
+		return &failingBackend{b}, nil
+	}
+	defer delete(storageBackends, "failing")
+
+	// the data dir is left as it was if the migration fails
+	assert.Error(t, MigrateStorage(hclog.NewNullLogger(), dataDir, "failing"))
+
+	backend, err := readStorageBackend(dataDir)
+	assert.NoError(t, err)
+	assert.Equal(t, "leveldb", backend)
+
+	_, err = os.Stat(filepath.Join(dataDir, "migrate"))
+	assert.True(t, os.IsNotExist(err))
+
+	blockchainDB, trieDB, err = openStorageBackends(dataDir, "leveldb")
+	assert.NoError(t, err)
+	defer blockchainDB.Close()
+	defer trieDB.Close()
+
+	v, ok, err := trieDB.Get([]byte("b"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte{2}, v)
+}
+
+func TestOpenReadOnlyDataDir(t *testing.T) {
+	dataDir, err := ioutil.TempDir("/tmp", "minimal_storage")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	assert.NoError(t, SetupDataDir(dataDir, storagePaths))
+
+	// an empty data dir is not initialized
+	_, err = OpenReadOnlyDataDir(hclog.NewNullLogger(), dataDir)
+	assert.Error(t, err)
+
+	blockchainDB, trieDB, err := openStorageBackends(dataDir, "leveldb")
+	assert.NoError(t, err)
+	defer blockchainDB.Close()
+	defer trieDB.Close()
+
+	db := storage.NewKeyValueStorage(hclog.NewNullLogger(), blockchainDB)
+	assert.NoError(t, db.WriteHeadNumber(5))
+
+	// the data dir is opened while the node is running
+	ro, err := OpenReadOnlyDataDir(hclog.NewNullLogger(), dataDir)
+	assert.NoError(t, err)
+	defer ro.Close()
+
+	head, ok := ro.Blockchain.ReadHeadNumber()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(5), head)
+
+	assert.Equal(t, storage.ErrReadOnly, ro.Blockchain.WriteHeadNumber(6))
+
+	// the commits of the state fail as well
+	txn := state.NewTxn(ro.State, ro.State.NewSnapshot())
+	txn.SetNonce(types.StringToAddress("1"), 1)
+	_, _, err = txn.Commit(false)
+	assert.Error(t, err)
+}
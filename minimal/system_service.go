@@ -2,14 +2,20 @@ package minimal
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"github.com/0xPolygon/minimal/minimal/proto"
 	"github.com/0xPolygon/minimal/network"
+	"github.com/0xPolygon/minimal/types"
 	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/libp2p/go-libp2p-core/metrics"
 	"github.com/libp2p/go-libp2p-core/peer"
 )
 
+// peerPingTimeout is the timeout of the ping of the 'peers status' operator service
+const peerPingTimeout = 2 * time.Second
+
 type systemService struct {
 	proto.UnimplementedSystemServer
 
@@ -43,6 +49,10 @@ func (s *systemService) Subscribe(req *empty.Empty, stream proto.System_Subscrib
 
 	for {
 		evnt := sub.GetEvent()
+		if evnt == nil {
+			// the subscription is closed
+			break
+		}
 		pEvent := &proto.BlockchainEvent{
 			Added:   []*proto.BlockchainEvent_Header{},
 			Removed: []*proto.BlockchainEvent_Header{},
@@ -71,6 +81,60 @@ func (s *systemService) Subscribe(req *empty.Empty, stream proto.System_Subscrib
 	return nil
 }
 
+// Backup implements the backup operator service
+func (s *systemService) Backup(req *proto.BackupRequest, stream proto.System_BackupServer) error {
+	_, err := s.s.Backup(&backupStreamWriter{stream: stream}, req.To)
+	return err
+}
+
+// backupStreamWriter sends the backup archive in chunks over the stream
+type backupStreamWriter struct {
+	stream proto.System_BackupServer
+}
+
+func (w *backupStreamWriter) Write(p []byte) (int, error) {
+	for sent := 0; sent < len(p); {
+		n := len(p) - sent
+		if n > backupChunkSize {
+			n = backupChunkSize
+		}
+		if err := w.stream.Send(&proto.BackupResponse{Data: p[sent : sent+n]}); err != nil {
+			return sent, err
+		}
+		sent += n
+	}
+	return len(p), nil
+}
+
+// StateHeal implements the 'state heal' operator service
+func (s *systemService) StateHeal(ctx context.Context, req *empty.Empty) (*proto.HealStatus, error) {
+	if err := s.s.HealState(); err != nil {
+		return nil, err
+	}
+	return healStatusToProto(s.s.HealStatus()), nil
+}
+
+// StateHealStatus implements the 'state heal status' operator service
+func (s *systemService) StateHealStatus(ctx context.Context, req *empty.Empty) (*proto.HealStatus, error) {
+	return healStatusToProto(s.s.HealStatus()), nil
+}
+
+func healStatusToProto(status HealStatus) *proto.HealStatus {
+	resp := &proto.HealStatus{
+		Running: status.Running,
+		Visited: status.Visited,
+		Healed:  status.Healed,
+		Pending: status.Pending,
+	}
+	if status.Root != types.ZeroHash {
+		resp.Root = status.Root.String()
+	}
+	if status.Err != nil {
+		resp.Error = status.Err.Error()
+	}
+	return resp
+}
+
 // PeersAdd implements the 'peers add' operator service
 func (s *systemService) PeersAdd(ctx context.Context, req *proto.PeersAddRequest) (*empty.Empty, error) {
 	dur := time.Duration(0)
@@ -83,6 +147,20 @@ func (s *systemService) PeersAdd(ctx context.Context, req *proto.PeersAddRequest
 	return &empty.Empty{}, err
 }
 
+// PeersRemove implements the 'peers remove' operator service
+func (s *systemService) PeersRemove(ctx context.Context, req *proto.PeersRemoveRequest) (*empty.Empty, error) {
+	peerID, err := peer.Decode(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.s.network.RemoveStaticPeer(peerID); err != nil {
+		return nil, err
+	}
+	s.s.network.Disconnect(peerID, "removed by the operator")
+
+	return &empty.Empty{}, nil
+}
+
 // PeersStatus implements the 'peers status' operator service
 func (s *systemService) PeersStatus(ctx context.Context, req *proto.PeersStatusRequest) (*proto.Peer, error) {
 	peerID, err := peer.Decode(req.Id)
@@ -90,6 +168,14 @@ func (s *systemService) PeersStatus(ctx context.Context, req *proto.PeersStatusR
 		return nil, err
 	}
 
+	// refresh the latency, the peer might not answer the pings
+	pingCtx, cancel := context.WithTimeout(ctx, peerPingTimeout)
+	defer cancel()
+
+	if _, err := s.s.network.Ping(pingCtx, peerID); err != nil {
+		s.s.logger.Debug("failed to ping peer", "id", peerID, "err", err)
+	}
+
 	peer, err := s.getPeer(peerID)
 	if err != nil {
 		return nil, err
@@ -115,6 +201,12 @@ func (s *systemService) getPeer(id peer.ID) (*proto.Peer, error) {
 		Id:        id.String(),
 		Protocols: protocols,
 		Addrs:     addrs,
+		Inbound:   s.s.network.IsInbound(id),
+		Latency:   float64(s.s.network.PeerLatency(id)) / float64(time.Millisecond),
+		Score:     s.s.network.PeerReputation(id),
+	}
+	if metadata, ok := s.s.network.GetPeerMetadata(id); ok {
+		peer.Client = metadata.Client
 	}
 
 	return peer, nil
@@ -141,3 +233,42 @@ func (s *systemService) PeersList(
 	
 	return resp, nil
 }
+
+// Bandwidth implements the 'bandwidth' operator service
+func (s *systemService) Bandwidth(ctx context.Context, req *empty.Empty) (*proto.BandwidthResponse, error) {
+	stats := s.s.network.Bandwidth()
+
+	peers := map[string]metrics.Stats{}
+	for id, st := range stats.Peers {
+		peers[id.String()] = st
+	}
+	resp := &proto.BandwidthResponse{
+		Total:     bandwidthToProto("", stats.Total),
+		Protocols: bandwidthListToProto(stats.Protocols),
+		Topics:    bandwidthListToProto(stats.Topics),
+		Peers:     bandwidthListToProto(peers),
+	}
+	return resp, nil
+}
+
+func bandwidthToProto(name string, st metrics.Stats) *proto.Bandwidth {
+	return &proto.Bandwidth{
+		Name:     name,
+		TotalIn:  st.TotalIn,
+		TotalOut: st.TotalOut,
+		RateIn:   st.RateIn,
+		RateOut:  st.RateOut,
+	}
+}
+
+// bandwidthListToProto returns the bandwidth sorted by name
+func bandwidthListToProto(stats map[string]metrics.Stats) []*proto.Bandwidth {
+	res := []*proto.Bandwidth{}
+	for name, st := range stats {
+		res = append(res, bandwidthToProto(name, st))
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
@@ -0,0 +1,54 @@
+package minimal
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+)
+
+// TLSConfig is the certificate of an endpoint served over tls
+type TLSConfig struct {
+	// CertFile and KeyFile are the pem files of the certificate and of its key
+	CertFile string
+	KeyFile  string
+
+	// ClientCAFile is the pem file of the certificate authorities of the certificates
+	// the clients must present. The clients are not authenticated if empty
+	ClientCAFile string
+}
+
+// Load returns the tls config of the endpoint
+func (c *TLSConfig) Load() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tls certificate: %v", err)
+	}
+
+	conf := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if c.ClientCAFile != "" {
+		pool, err := LoadCertPool(c.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		conf.ClientCAs = pool
+		conf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return conf, nil
+}
+
+// LoadCertPool returns the pool of the certificates of the pem file
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificates: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in '%s'", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,123 @@
+package minimal
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCert writes the pem files of a certificate signed by the parent, self signed if nil
+func writeCert(t *testing.T, dir, name string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name+".crt"), certPem, 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name+".key"), keyPem, 0600))
+	return cert, key
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "minimal_tls")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca, caKey := writeCert(t, dir, "ca", true, nil, nil)
+	writeCert(t, dir, "server", false, ca, caKey)
+	writeCert(t, dir, "client", false, ca, caKey)
+
+	file := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+
+	// the files must exist
+	_, err = (&TLSConfig{CertFile: file("none.crt"), KeyFile: file("server.key")}).Load()
+	assert.Error(t, err)
+	_, err = (&TLSConfig{CertFile: file("server.crt"), KeyFile: file("server.key"), ClientCAFile: file("none.crt")}).Load()
+	assert.Error(t, err)
+
+	conf, err := (&TLSConfig{
+		CertFile:     file("server.crt"),
+		KeyFile:      file("server.key"),
+		ClientCAFile: file("ca.crt"),
+	}).Load()
+	assert.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, conf.ClientAuth)
+
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", conf)
+	assert.NoError(t, err)
+	defer lis.Close()
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	roots, err := LoadCertPool(file("ca.crt"))
+	assert.NoError(t, err)
+
+	dial := func(certs []tls.Certificate) error {
+		conn, err := tls.Dial("tcp", lis.Addr().String(), &tls.Config{RootCAs: roots, Certificates: certs})
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		// the server rejects the missing client certificates after the handshake of the client
+		_, err = conn.Read(make([]byte, 1))
+		return err
+	}
+
+	clientCert, err := tls.LoadX509KeyPair(file("client.crt"), file("client.key"))
+	assert.NoError(t, err)
+
+	// the server closes the connection once authenticated
+	assert.Equal(t, "EOF", dial([]tls.Certificate{clientCert}).Error())
+
+	// the clients without a certificate are rejected
+	err = dial(nil)
+	assert.Error(t, err)
+	assert.NotEqual(t, "EOF", err.Error())
+}
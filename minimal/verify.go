@@ -0,0 +1,169 @@
+package minimal
+
+import (
+	"fmt"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/state"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/state/runtime/evm"
+	"github.com/0xPolygon/minimal/state/runtime/precompiled"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/0xPolygon/minimal/types/buildroot"
+	"github.com/hashicorp/go-hclog"
+)
+
+// verifyLogInterval is the number of blocks between the progress logs of a verification
+const verifyLogInterval = 1000
+
+// BlockMismatch is a field of a block whose value differs from the one computed
+// by the re-execution of the block
+type BlockMismatch struct {
+	Number   uint64
+	Hash     types.Hash
+	Field    string
+	Expected string
+	Computed string
+}
+
+func (m *BlockMismatch) Error() string {
+	return fmt.Sprintf(
+		"block %d (%s) has %s %s but the execution computes %s",
+		m.Number,
+		m.Hash,
+		m.Field,
+		m.Expected,
+		m.Computed,
+	)
+}
+
+// VerifyChain re-executes the canonical blocks in the given range on the state of their
+// parents and checks their state roots, receipts roots and gas used. It returns the first
+// block that does not match as a *BlockMismatch. The data dir is opened in read-only mode
+// and the states computed are kept in memory, so the node can be running. They are discarded
+// before every block whose parent state is in the database, so only the states computed on
+// top of the pruned states are kept
+func VerifyChain(logger hclog.Logger, dataDir string, params *chain.Params, from, to uint64) error {
+	if from == 0 {
+		// the genesis has no parent to execute it on
+		from = 1
+	}
+
+	dir, err := OpenReadOnlyDataDir(logger, dataDir)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	head, ok := dir.Blockchain.ReadHeadNumber()
+	if !ok {
+		return fmt.Errorf("head not found")
+	}
+	if to == 0 || to > head {
+		to = head
+	}
+	if from > to {
+		return fmt.Errorf("no blocks to verify from block %d to block %d", from, to)
+	}
+
+	base := itrie.NewState(itrie.NewKVStorage(dir.trieDB))
+	overlay := itrie.NewOverlayStorage(itrie.NewKVStorage(dir.trieDB))
+	st := itrie.NewState(overlay)
+	executor := state.NewExecutor(params, st)
+	precompiles := precompiled.NewPrecompiled()
+	if err := precompiles.EnableCustom(params.Precompiles); err != nil {
+		return err
+	}
+	executor.SetRuntime(precompiles)
+	executor.SetRuntime(evm.NewEVM())
+	executor.GetHash = func(*types.Header) state.GetHashByNumber {
+		// only canonical blocks are verified, so are their ancestors
+		return func(n uint64) types.Hash {
+			hash, _ := dir.Blockchain.ReadCanonicalHash(n)
+			return hash
+		}
+	}
+
+	parent, err := readCanonicalHeader(dir.Blockchain, from-1)
+	if err != nil {
+		return err
+	}
+	for n := from; n <= to; n++ {
+		header, err := readCanonicalHeader(dir.Blockchain, n)
+		if err != nil {
+			return err
+		}
+		if _, err := base.NewSnapshotAt(parent.StateRoot); err == nil {
+			// the state computed for the parent is the one in the database
+			overlay.Discard()
+		}
+		if err := verifyBlock(dir.Blockchain, executor, parent, header); err != nil {
+			return err
+		}
+		if (n-from+1)%verifyLogInterval == 0 {
+			logger.Info("Verifying blocks", "number", n, "to", to)
+		}
+		parent = header
+	}
+
+	logger.Info("Verified blocks", "from", from, "to", to)
+	return nil
+}
+
+func readCanonicalHeader(db storage.Storage, n uint64) (*types.Header, error) {
+	hash, ok := db.ReadCanonicalHash(n)
+	if !ok {
+		return nil, fmt.Errorf("canonical hash of block %d not found", n)
+	}
+	header, err := db.ReadHeader(hash)
+	if err != nil {
+		return nil, fmt.Errorf("header of block %d not found: %v", n, err)
+	}
+	return header, nil
+}
+
+func verifyBlock(db storage.Storage, executor *state.Executor, parent, header *types.Header) error {
+	body, err := db.ReadBody(header.Hash)
+	if err != nil {
+		if err != storage.ErrNotFound || header.TxRoot != types.EmptyRootHash {
+			return fmt.Errorf("body of block %d not found: %v", header.Number, err)
+		}
+		// the bodies of the blocks without transactions might not be stored
+		body = &types.Body{}
+	}
+
+	if _, err := executor.StateAt(parent.StateRoot); err != nil {
+		return fmt.Errorf("state of block %d not found, it might have been pruned: %v", parent.Number, err)
+	}
+
+	block := &types.Block{
+		Header:       header,
+		Transactions: body.Transactions,
+		Uncles:       body.Uncles,
+	}
+	result, err := executor.ProcessBlock(parent.StateRoot, block)
+	if err != nil {
+		return fmt.Errorf("failed to execute block %d: %v", header.Number, err)
+	}
+
+	mismatch := func(field string, expected, computed interface{}) error {
+		return &BlockMismatch{
+			Number:   header.Number,
+			Hash:     header.Hash,
+			Field:    field,
+			Expected: fmt.Sprint(expected),
+			Computed: fmt.Sprint(computed),
+		}
+	}
+	if result.TotalGas != header.GasUsed {
+		return mismatch("gas used", header.GasUsed, result.TotalGas)
+	}
+	if root := buildroot.CalculateReceiptsRoot(result.Receipts); root != header.ReceiptsRoot {
+		return mismatch("receipts root", header.ReceiptsRoot, root)
+	}
+	if result.Root != header.StateRoot {
+		return mismatch("state root", header.StateRoot, result.Root)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package minimal
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/crypto"
+	"github.com/0xPolygon/minimal/state"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/state/runtime/evm"
+	"github.com/0xPolygon/minimal/state/runtime/precompiled"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/0xPolygon/minimal/types/buildroot"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyChain(t *testing.T) {
+	logger := hclog.NewNullLogger()
+
+	dataDir, err := ioutil.TempDir("/tmp", "minimal_verify")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	assert.NoError(t, SetupDataDir(dataDir, storagePaths))
+	blockchainDB, trieDB, err := openStorageBackends(dataDir, "leveldb")
+	assert.NoError(t, err)
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	sender := crypto.PubKeyToAddress(&key.PublicKey)
+	signer := crypto.NewEIP155Signer(100)
+
+	params := &chain.Params{Forks: chain.AllForksEnabled, ChainID: 100}
+	executor := state.NewExecutor(params, itrie.NewState(itrie.NewKVStorage(trieDB)))
+	executor.SetRuntime(precompiled.NewPrecompiled())
+	executor.SetRuntime(evm.NewEVM())
+	executor.GetHash = func(*types.Header) state.GetHashByNumber {
+		return func(uint64) types.Hash {
+			return types.Hash{}
+		}
+	}
+
+	genesis := &chain.Genesis{
+		GasLimit: 1000000,
+		Alloc: map[types.Address]*chain.GenesisAccount{
+			sender: {Balance: big.NewInt(1000000000)},
+		},
+	}
+	genesis.StateRoot, err = executor.WriteGenesis(genesis)
+	assert.NoError(t, err)
+
+	db := storage.NewKeyValueStorage(logger, blockchainDB)
+	writeBlock := func(header *types.Header, body *types.Body) {
+		header.ComputeHash()
+		assert.NoError(t, db.WriteHeader(header))
+		assert.NoError(t, db.WriteBody(header.Hash, body))
+		assert.NoError(t, db.WriteCanonicalHash(header.Number, header.Hash))
+		assert.NoError(t, db.WriteHeadHash(header.Hash))
+		assert.NoError(t, db.WriteHeadNumber(header.Number))
+	}
+
+	parent := genesis.GenesisHeader()
+	writeBlock(parent, &types.Body{})
+
+	headers := []*types.Header{parent}
+	for i := uint64(1); i <= 3; i++ {
+		to := types.StringToAddress("2")
+		txn, err := signer.SignTx(&types.Transaction{
+			Nonce:    i - 1,
+			To:       &to,
+			Value:    big.NewInt(1),
+			Gas:      21000,
+			GasPrice: big.NewInt(1),
+		}, key)
+		assert.NoError(t, err)
+
+		header := &types.Header{
+			ParentHash: parent.Hash,
+			Number:     i,
+			GasLimit:   1000000,
+			Sha3Uncles: types.EmptyUncleHash,
+			ExtraData:  []byte{},
+			TxRoot:     buildroot.CalculateTransactionsRoot([]*types.Transaction{txn}),
+		}
+		result, err := executor.ProcessBlock(parent.StateRoot, &types.Block{Header: header, Transactions: []*types.Transaction{txn}})
+		assert.NoError(t, err)
+
+		header.StateRoot = result.Root
+		header.GasUsed = result.TotalGas
+		header.ReceiptsRoot = buildroot.CalculateReceiptsRoot(result.Receipts)
+		writeBlock(header, &types.Body{Transactions: []*types.Transaction{txn}})
+
+		headers = append(headers, header)
+		parent = header
+	}
+
+	// the blocks are verified while the node is running
+	assert.NoError(t, VerifyChain(logger, dataDir, params, 0, 0))
+	assert.NoError(t, VerifyChain(logger, dataDir, params, 2, 3))
+	assert.Error(t, VerifyChain(logger, dataDir, params, 4, 0))
+
+	// replace the second block with one that has a wrong gas used
+	body, err := db.ReadBody(headers[2].Hash)
+	assert.NoError(t, err)
+
+	corrupted := headers[2].Copy()
+	corrupted.GasUsed++
+	writeBlock(corrupted, body)
+	assert.NoError(t, db.WriteHeadHash(headers[3].Hash))
+	assert.NoError(t, db.WriteHeadNumber(3))
+
+	err = VerifyChain(logger, dataDir, params, 1, 3)
+	mismatch, ok := err.(*BlockMismatch)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), mismatch.Number)
+	assert.Equal(t, "gas used", mismatch.Field)
+
+	// the first block is still valid
+	assert.NoError(t, VerifyChain(logger, dataDir, params, 1, 1))
+
+	assert.NoError(t, blockchainDB.Close())
+	assert.NoError(t, trieDB.Close())
+}
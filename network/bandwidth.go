@@ -0,0 +1,224 @@
+package network
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/metrics"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "network"
+
+	// bandwidthTrimInterval is the interval between the removals of the idle meters
+	bandwidthTrimInterval = 1 * time.Minute
+
+	// bandwidthIdleTimeout is the time after which the meters of the disconnected peers,
+	// the unused protocols and the topics without messages are removed
+	bandwidthIdleTimeout = 10 * time.Minute
+)
+
+// BandwidthStats is the bandwidth used by the node, the bytes and the rates are
+// the ones of the libp2p streams except for the gossip topics
+type BandwidthStats struct {
+	Total metrics.Stats
+
+	// Protocols is the bandwidth by protocol, the gossip of all the topics is the pubsub protocol
+	Protocols map[string]metrics.Stats
+
+	// Topics is the bandwidth of the messages published by the node and delivered
+	// to it by gossip topic, without the messages relayed to other peers
+	Topics map[string]metrics.Stats
+
+	// Peers is the bandwidth of the connected peers
+	Peers map[peer.ID]metrics.Stats
+}
+
+// Bandwidth returns the bandwidth used by the node
+func (s *Server) Bandwidth() *BandwidthStats {
+	stats := &BandwidthStats{
+		Total:     s.bandwidth.GetBandwidthTotals(),
+		Protocols: map[string]metrics.Stats{},
+		Topics:    map[string]metrics.Stats{},
+		Peers:     map[peer.ID]metrics.Stats{},
+	}
+	for id, st := range s.bandwidth.GetBandwidthByProtocol() {
+		// the streams that failed to negotiate a protocol have none
+		if id != "" {
+			stats.Protocols[string(id)] = st
+		}
+	}
+	for id, st := range s.topicBandwidth.GetBandwidthByProtocol() {
+		stats.Topics[string(id)] = st
+	}
+	for _, p := range s.Peers() {
+		stats.Peers[p.Info.ID] = s.bandwidth.GetBandwidthForPeer(p.Info.ID)
+	}
+	return stats
+}
+
+// logTopicMessage meters a message published (from is the node) or delivered in a gossip topic.
+// The topics are not metered by peer, the bandwidth of the peers is the one of their streams
+func (s *Server) logTopicMessage(topic string, from peer.ID, size int) {
+	if from == s.host.ID() {
+		s.topicBandwidth.LogSentMessageStream(int64(size), protocol.ID(topic), "")
+	} else {
+		s.topicBandwidth.LogRecvMessageStream(int64(size), protocol.ID(topic), "")
+	}
+}
+
+// runBandwidthTrim removes the meters of the idle peers, so the counter does not grow with every peer seen
+func (s *Server) runBandwidthTrim() {
+	ticker := time.NewTicker(bandwidthTrimInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.bandwidth.TrimIdle(time.Now().Add(-bandwidthIdleTimeout))
+		case <-s.closeCh:
+			return
+		}
+	}
+}
+
+// bandwidthCounter meters the libp2p streams like metrics.BandwidthCounter, but the meters
+// of the peers are kept apart so they can be removed. The meters of the registries of
+// metrics.BandwidthCounter are never removed by TrimIdle
+type bandwidthCounter struct {
+	*metrics.BandwidthCounter
+
+	lock  sync.Mutex
+	peers map[peer.ID]*peerBandwidth
+}
+
+type peerBandwidth struct {
+	counter *metrics.BandwidthCounter
+	updated time.Time
+}
+
+func newBandwidthCounter() *bandwidthCounter {
+	return &bandwidthCounter{
+		BandwidthCounter: metrics.NewBandwidthCounter(),
+		peers:            map[peer.ID]*peerBandwidth{},
+	}
+}
+
+// peer returns the counter of the peer
+func (b *bandwidthCounter) peer(p peer.ID) *metrics.BandwidthCounter {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	bw, ok := b.peers[p]
+	if !ok {
+		bw = &peerBandwidth{counter: metrics.NewBandwidthCounter()}
+		b.peers[p] = bw
+	}
+	bw.updated = time.Now()
+	return bw.counter
+}
+
+// LogSentMessageStream meters the message in the protocol and in the peer
+func (b *bandwidthCounter) LogSentMessageStream(size int64, proto protocol.ID, p peer.ID) {
+	b.BandwidthCounter.LogSentMessageStream(size, proto, "")
+	b.peer(p).LogSentMessage(size)
+}
+
+// LogRecvMessageStream meters the message in the protocol and in the peer
+func (b *bandwidthCounter) LogRecvMessageStream(size int64, proto protocol.ID, p peer.ID) {
+	b.BandwidthCounter.LogRecvMessageStream(size, proto, "")
+	b.peer(p).LogRecvMessage(size)
+}
+
+// GetBandwidthForPeer returns the bandwidth of the peer since it was last trimmed
+func (b *bandwidthCounter) GetBandwidthForPeer(p peer.ID) metrics.Stats {
+	b.lock.Lock()
+	bw, ok := b.peers[p]
+	b.lock.Unlock()
+
+	if !ok {
+		return metrics.Stats{}
+	}
+	return bw.counter.GetBandwidthTotals()
+}
+
+// GetBandwidthByPeer returns the bandwidth of the metered peers
+func (b *bandwidthCounter) GetBandwidthByPeer() map[peer.ID]metrics.Stats {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	res := map[peer.ID]metrics.Stats{}
+	for p, bw := range b.peers {
+		res[p] = bw.counter.GetBandwidthTotals()
+	}
+	return res
+}
+
+// TrimIdle removes the meters of the peers idle since the given time
+func (b *bandwidthCounter) TrimIdle(since time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for p, bw := range b.peers {
+		if bw.updated.Before(since) {
+			delete(b.peers, p)
+		}
+	}
+}
+
+// bandwidthCollector exports the bandwidth by protocol and topic, which are not known
+// in advance, as prometheus metrics. The bandwidth of the peers is only available in
+// the operator api, since a label per peer would grow the series with every peer seen
+type bandwidthCollector struct {
+	srv *Server
+
+	protocolBytes *prometheus.Desc
+	protocolRate  *prometheus.Desc
+	topicBytes    *prometheus.Desc
+	topicRate     *prometheus.Desc
+}
+
+func newBandwidthCollector(srv *Server) *bandwidthCollector {
+	desc := func(name, help, label string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", name), help, []string{label, "direction"}, nil)
+	}
+	return &bandwidthCollector{
+		srv:           srv,
+		protocolBytes: desc("protocol_bytes_total", "Bytes sent and received by libp2p protocol", "protocol"),
+		protocolRate:  desc("protocol_bytes_per_second", "Bytes per second sent and received by libp2p protocol", "protocol"),
+		topicBytes:    desc("topic_bytes_total", "Bytes of the messages published and delivered by gossip topic", "topic"),
+		topicRate:     desc("topic_bytes_per_second", "Bytes per second of the messages published and delivered by gossip topic", "topic"),
+	}
+}
+
+func (c *bandwidthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.protocolBytes
+	ch <- c.protocolRate
+	ch <- c.topicBytes
+	ch <- c.topicRate
+}
+
+func (c *bandwidthCollector) Collect(ch chan<- prometheus.Metric) {
+	collect := func(bytes, rate *prometheus.Desc, label string, st metrics.Stats) {
+		ch <- prometheus.MustNewConstMetric(bytes, prometheus.CounterValue, float64(st.TotalIn), label, "in")
+		ch <- prometheus.MustNewConstMetric(bytes, prometheus.CounterValue, float64(st.TotalOut), label, "out")
+		ch <- prometheus.MustNewConstMetric(rate, prometheus.GaugeValue, st.RateIn, label, "in")
+		ch <- prometheus.MustNewConstMetric(rate, prometheus.GaugeValue, st.RateOut, label, "out")
+	}
+
+	stats := c.srv.Bandwidth()
+	for id, st := range stats.Protocols {
+		collect(c.protocolBytes, c.protocolRate, id, st)
+	}
+	for id, st := range stats.Topics {
+		collect(c.topicBytes, c.topicRate, id, st)
+	}
+}
+
+// RegisterMetrics registers the metrics of the bandwidth in the prometheus registerer
+func (s *Server) RegisterMetrics(reg prometheus.Registerer) error {
+	return reg.Register(newBandwidthCollector(s))
+}
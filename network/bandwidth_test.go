@@ -0,0 +1,58 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	testproto "github.com/0xPolygon/minimal/network/proto/test"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBandwidth(t *testing.T) {
+	conf := func(c *Config) {
+		c.NoDiscover = true
+	}
+	srv0 := CreateServer(t, conf)
+	srv1 := CreateServer(t, conf)
+
+	MultiJoin(t, srv0, srv1)
+
+	topic0, err := srv0.NewTopic("topic/0.1", &testproto.AReq{})
+	assert.NoError(t, err)
+	topic1, err := srv1.NewTopic("topic/0.1", &testproto.AReq{})
+	assert.NoError(t, err)
+	assert.NoError(t, topic1.Subscribe(func(obj interface{}) {}))
+
+	// the messages are published until the mesh is formed, the meters are updated every second
+	assert.Eventually(t, func() bool {
+		assert.NoError(t, topic0.Publish(&testproto.AReq{Msg: "a"}))
+		return srv1.Bandwidth().Topics["topic/0.1"].TotalIn != 0
+	}, 10*time.Second, 100*time.Millisecond)
+
+	stats := srv0.Bandwidth()
+	assert.NotZero(t, stats.Total.TotalOut)
+	assert.NotZero(t, stats.Protocols[identityProtoV1].TotalOut)
+	assert.NotZero(t, stats.Topics["topic/0.1"].TotalOut)
+	assert.NotZero(t, stats.Peers[srv1.AddrInfo().ID].TotalOut)
+
+	// the bandwidth of each protocol and topic is exported in both directions, but not the one of each peer
+	reg := prometheus.NewRegistry()
+	assert.NoError(t, srv0.RegisterMetrics(reg))
+	families, err := reg.Gather()
+	assert.NoError(t, err)
+
+	names := []string{}
+	for _, family := range families {
+		names = append(names, family.GetName())
+	}
+	assert.Contains(t, names, "network_protocol_bytes_total")
+	assert.Contains(t, names, "network_topic_bytes_per_second")
+	assert.NotContains(t, names, "network_peer_bytes_total")
+	assert.Equal(t, 4, testutil.CollectAndCount(newBandwidthCollector(srv0), "network_topic_bytes_total", "network_topic_bytes_per_second"))
+
+	// the meters of the peers are removed once they are idle
+	srv0.bandwidth.TrimIdle(time.Now().Add(time.Hour))
+	assert.Zero(t, srv0.Bandwidth().Peers[srv1.AddrInfo().ID].TotalOut)
+}
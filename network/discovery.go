@@ -2,12 +2,15 @@ package network
 
 import (
 	"context"
+	"encoding/hex"
+	"fmt"
 	"math/rand"
 	"sync"
 	"time"
 
 	"github.com/0xPolygon/minimal/network/grpc"
 	"github.com/0xPolygon/minimal/network/proto"
+	"github.com/0xPolygon/minimal/types"
 	rawGrpc "google.golang.org/grpc"
 
 	"github.com/libp2p/go-libp2p-core/peer"
@@ -21,33 +24,86 @@ func init() {
 
 var discProto = "/disc/0.1"
 
-const defaultBucketSize = 20
+// discoveryProtocol returns the discovery protocol of the chain, which is prefixed by its
+// genesis hash so that the nodes only find the peers of the same chain
+func discoveryProtocol(genesis types.Hash) string {
+	return fmt.Sprintf("/%s%s", hex.EncodeToString(genesis[:]), discProto)
+}
+
+const (
+	defaultBucketSize = 20
+
+	// lookupPeers is the number of peers queried by a lookup
+	lookupPeers = 3
+)
 
 type discovery struct {
 	proto.UnimplementedDiscoveryServer
 	srv          *Server
 	routingTable *kb.RoutingTable
 
-	peers     []peer.ID
-	peersLock sync.Mutex
+	// protocol is the discovery protocol of the chain
+	protocol string
 
 	notifyCh chan struct{}
 	closeCh  chan struct{}
 
-	bootnodes []*peer.AddrInfo
+	bootnodes     []*peer.AddrInfo
+	dnsBootnodes  []*peer.AddrInfo
+	bootnodesLock sync.Mutex
 }
 
 func (d *discovery) setBootnodes(bootnodes []*peer.AddrInfo) {
+	d.bootnodesLock.Lock()
+	defer d.bootnodesLock.Unlock()
+
 	d.bootnodes = bootnodes
 }
 
+// getBootnodes returns the bootnodes of the config and the ones resolved from the DNS trees
+func (d *discovery) getBootnodes() []*peer.AddrInfo {
+	d.bootnodesLock.Lock()
+	defer d.bootnodesLock.Unlock()
+
+	return append(append([]*peer.AddrInfo{}, d.bootnodes...), d.dnsBootnodes...)
+}
+
+// runDNS resolves the bootnodes from the DNS trees, and again on every refresh
+// so that the operators of the network can rotate them
+func (d *discovery) runDNS(client *dnsClient, urls []string) {
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), dnsTimeout)
+		nodes := client.sync(ctx, urls)
+		cancel()
+
+		bootnodes := []*peer.AddrInfo{}
+		for _, node := range nodes {
+			if node.ID == d.srv.host.ID() {
+				continue
+			}
+			d.srv.host.Peerstore().AddAddr(node.ID, node.Addrs[0], peerstore.AddressTTL)
+			bootnodes = append(bootnodes, node)
+		}
+
+		d.bootnodesLock.Lock()
+		d.dnsBootnodes = bootnodes
+		d.bootnodesLock.Unlock()
+
+		select {
+		case <-time.After(d.srv.config.DNSRefresh):
+		case <-d.srv.closeCh:
+			return
+		}
+	}
+}
+
 func (d *discovery) notify() {
 	d.notifyCh <- struct{}{}
 }
 
 func (d *discovery) setup() error {
 	d.notifyCh = make(chan struct{}, 5)
-	d.peers = []peer.ID{}
+	d.protocol = discoveryProtocol(d.srv.config.GenesisHash)
 
 	keyID := kb.ConvertPeerID(d.srv.host.ID())
 
@@ -68,7 +124,7 @@ func (d *discovery) setup() error {
 	grpc := grpc.NewGrpcStream()
 	proto.RegisterDiscoveryServer(grpc.GrpcServer(), d)
 
-	d.srv.Register(discProto, grpc)
+	d.srv.Register(d.protocol, grpc)
 
 	// send all the nodes we connect to the routing table
 	err = d.srv.SubscribeFn(func(evnt *PeerEvent) {
@@ -77,16 +133,11 @@ func (d *discovery) setup() error {
 		}
 		peerID := evnt.PeerID
 
-		// add peer to the routing table and to our local peer
+		// add peer to the routing table
 		_, err := d.routingTable.TryAddPeer(peerID, false, false)
 		if err != nil {
 			d.srv.logger.Error("failed to add peer to routing table", "err", err)
-			return
 		}
-
-		d.peersLock.Lock()
-		d.peers = append(d.peers, peerID)
-		d.peersLock.Unlock()
 	})
 	if err != nil {
 		return err
@@ -97,8 +148,9 @@ func (d *discovery) setup() error {
 	return nil
 }
 
-func (d *discovery) call(peerID peer.ID) error {
-	nodes, err := d.findPeersCall(peerID)
+// call asks the peer for its peers nearest to the key, and adds them to the routing table
+func (d *discovery) call(peerID peer.ID, key string) error {
+	nodes, err := d.findPeersCall(peerID, key)
 	if err != nil {
 		return err
 	}
@@ -116,14 +168,15 @@ func (d *discovery) call(peerID peer.ID) error {
 	return nil
 }
 
-func (d *discovery) findPeersCall(peerID peer.ID) ([]*peer.AddrInfo, error) {
-	conn, err := d.srv.NewProtoStream(discProto, peerID)
+// findPeersCall returns the peers of the peer nearest to the key, or to our id if empty
+func (d *discovery) findPeersCall(peerID peer.ID, key string) ([]*peer.AddrInfo, error) {
+	conn, err := d.srv.NewProtoStream(d.protocol, peerID)
 	if err != nil {
 		return nil, err
 	}
 	clt := proto.NewDiscoveryClient(conn.(*rawGrpc.ClientConn))
 
-	resp, err := clt.FindPeers(context.Background(), &proto.FindPeersReq{Count: 16})
+	resp, err := clt.FindPeers(context.Background(), &proto.FindPeersReq{Key: key, Count: 16})
 	if err != nil {
 		return nil, err
 	}
@@ -143,7 +196,7 @@ func (d *discovery) findPeersCall(peerID peer.ID) ([]*peer.AddrInfo, error) {
 func (d *discovery) run() {
 	for {
 		select {
-		case <-time.After(5 * time.Second):
+		case <-time.After(d.srv.config.DiscoveryInterval):
 		case <-d.notifyCh:
 		case <-d.closeCh:
 			return
@@ -152,21 +205,50 @@ func (d *discovery) run() {
 	}
 }
 
+// targetPeers returns the number of peers above which no new peers are looked up
+func (d *discovery) targetPeers() int {
+	if target := d.srv.config.TargetPeers; target != 0 {
+		return int(target)
+	}
+	return int(d.srv.config.MaxPeers)
+}
+
+// randomKey returns a random key of the routing table space
+func randomKey() string {
+	buf := make([]byte, 32)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
 func (d *discovery) handleDiscovery() {
 	if d.routingTable.Size() == 0 {
 		// if there are no peers on the table try to include the bootnodes
-		for _, node := range d.bootnodes {
+		for _, node := range d.getBootnodes() {
 			if _, err := d.routingTable.TryAddPeer(node.ID, false, false); err != nil {
 				d.srv.logger.Error("failed to add bootnode", "err", err)
 			}
 		}
-	} else {
-		// take a random peer and find peers
-		if len(d.peers) > 0 {
-			target := d.peers[rand.Intn(len(d.peers))]
-			if err := d.call(target); err != nil {
-				// d.srv.logger.Error("failed to dial bootnode", "err", err)
-			}
+		return
+	}
+	if len(d.srv.Peers()) >= d.targetPeers() {
+		return
+	}
+
+	// look up the peers nearest to a random key, which fills the buckets of the
+	// whole key space instead of only the ones near our id. The peers are asked
+	// to the connected peers nearest to the key, so no new connection is opened
+	key := randomKey()
+	queried := 0
+	for _, peerID := range d.routingTable.NearestPeers(kb.ConvertKey(key), defaultBucketSize) {
+		if queried == lookupPeers {
+			break
+		}
+		if !d.srv.isConnected(peerID) {
+			continue
+		}
+		queried++
+		if err := d.call(peerID, key); err != nil {
+			d.srv.logger.Trace("failed to find peers", "peer", peerID, "err", err)
 		}
 	}
 }
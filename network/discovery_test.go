@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/0xPolygon/minimal/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -34,11 +35,40 @@ func TestDiscovery_ProtocolFindPeers(t *testing.T) {
 	time.Sleep(1 * time.Second)
 
 	// find peers should not include our identity
-	resp, err := srv0.discovery.findPeersCall(srv1.AddrInfo().ID)
+	resp, err := srv0.discovery.findPeersCall(srv1.AddrInfo().ID, "")
 	assert.NoError(t, err)
 	assert.Empty(t, resp)
 }
 
+func TestDiscovery_ChainProtocol(t *testing.T) {
+	genesis1, genesis2 := types.StringToHash("1"), types.StringToHash("2")
+	assert.NotEqual(t, discoveryProtocol(genesis1), discoveryProtocol(genesis2))
+
+	srv0 := CreateServer(t, func(c *Config) {
+		c.GenesisHash = genesis1
+	})
+	srv1 := CreateServer(t, func(c *Config) {
+		c.GenesisHash = genesis2
+	})
+
+	// the peers of another chain are rejected in the handshake
+	assert.Error(t, srv0.Join(srv1.AddrInfo(), 2*time.Second))
+
+	// and do not serve the discovery of the chain
+	_, err := srv0.discovery.findPeersCall(srv1.AddrInfo().ID, "")
+	assert.Error(t, err)
+}
+
+func TestDiscovery_TargetPeers(t *testing.T) {
+	srv0 := CreateServer(t, nil)
+	assert.Equal(t, int(srv0.config.MaxPeers), srv0.discovery.targetPeers())
+
+	srv1 := CreateServer(t, func(c *Config) {
+		c.TargetPeers = 3
+	})
+	assert.Equal(t, 3, srv1.discovery.targetPeers())
+}
+
 func TestDiscovery_PeerAdded(t *testing.T) {
 	srv0 := CreateServer(t, discoveryConfig)
 	srv1 := CreateServer(t, discoveryConfig)
@@ -0,0 +1,330 @@
+package network
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/base32"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xPolygon/minimal/crypto"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/hashicorp/go-hclog"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// DefaultDNSRefresh is the default interval between the resolutions of the DNS trees
+const DefaultDNSRefresh = 30 * time.Minute
+
+// The DNS trees follow EIP-1459, except that the leaves of the nodes are the
+// p2p addresses of the nodes instead of ENRs. A p2p address does not need to
+// be signed since the node proves its peer id in the handshake
+const (
+	dnsTreePrefix   = "enrtree://"
+	dnsRootPrefix   = "enrtree-root:v1"
+	dnsBranchPrefix = "enrtree-branch:"
+	dnsNodePrefix   = "p2p:"
+
+	// dnsMaxRecords is the max number of records resolved in a tree
+	dnsMaxRecords = 1000
+
+	// dnsMaxTrees is the max number of trees resolved by following the links
+	dnsMaxTrees = 16
+
+	// dnsTimeout is the timeout of the resolution of all the trees
+	dnsTimeout = 1 * time.Minute
+)
+
+var dnsEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// IsDNSTree returns true if the bootnode is the url of a DNS tree
+func IsDNSTree(url string) bool {
+	return strings.HasPrefix(url, dnsTreePrefix)
+}
+
+// dnsTree is the url of a tree, enrtree://<public key>@<domain>, where the
+// public key is the base32 compressed secp256k1 key that signs the root
+type dnsTree struct {
+	domain string
+	pubKey *ecdsa.PublicKey
+}
+
+func parseDNSTree(url string) (*dnsTree, error) {
+	if !IsDNSTree(url) {
+		return nil, fmt.Errorf("url does not start with %s", dnsTreePrefix)
+	}
+	parts := strings.Split(strings.TrimPrefix(url, dnsTreePrefix), "@")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("url is not %s<key>@<domain>", dnsTreePrefix)
+	}
+	buf, err := dnsEncoding.DecodeString(strings.ToUpper(parts[0]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %v", err)
+	}
+	pub, err := btcec.ParsePubKey(buf, crypto.S256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %v", err)
+	}
+	tree := &dnsTree{
+		domain: parts[1],
+		pubKey: pub.ToECDSA(),
+	}
+	return tree, nil
+}
+
+// dnsRoot is the root record of a tree, which points to the subtree of the
+// nodes and to the subtree of the links to other trees
+type dnsRoot struct {
+	nodes string
+	links string
+	seq   uint64
+	sig   []byte
+}
+
+func (r *dnsRoot) signedText() string {
+	return fmt.Sprintf("%s e=%s l=%s seq=%d", dnsRootPrefix, r.nodes, r.links, r.seq)
+}
+
+func parseDNSRoot(txt string) (*dnsRoot, error) {
+	fields := strings.Fields(txt)
+	if len(fields) != 5 || fields[0] != dnsRootPrefix {
+		return nil, fmt.Errorf("invalid root record '%s'", txt)
+	}
+
+	root := &dnsRoot{}
+	for _, field := range fields[1:] {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid root field '%s'", field)
+		}
+		switch val := parts[1]; parts[0] {
+		case "e":
+			root.nodes = val
+		case "l":
+			root.links = val
+		case "seq":
+			seq, err := strconv.ParseUint(val, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid root seq '%s'", val)
+			}
+			root.seq = seq
+		case "sig":
+			sig, err := base64.RawURLEncoding.DecodeString(val)
+			if err != nil || len(sig) != 65 {
+				return nil, fmt.Errorf("invalid root signature '%s'", val)
+			}
+			root.sig = sig
+		default:
+			return nil, fmt.Errorf("invalid root field '%s'", field)
+		}
+	}
+	if root.nodes == "" || root.links == "" || root.sig == nil {
+		return nil, fmt.Errorf("incomplete root record '%s'", txt)
+	}
+	return root, nil
+}
+
+// verify checks that the root is signed by the key of the tree
+func (r *dnsRoot) verify(pub *ecdsa.PublicKey) error {
+	signer, err := crypto.RecoverPubkey(r.sig, crypto.Keccak256([]byte(r.signedText())))
+	if err != nil {
+		return err
+	}
+	if signer.X.Cmp(pub.X) != 0 || signer.Y.Cmp(pub.Y) != 0 {
+		return fmt.Errorf("root is not signed by the tree key")
+	}
+	return nil
+}
+
+// dnsHash returns the subdomain of a record, the base32 of its truncated hash
+func dnsHash(txt string) string {
+	return dnsEncoding.EncodeToString(crypto.Keccak256([]byte(txt))[:16])
+}
+
+type txtResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// dnsTreeState is the last resolution of a tree, which is not walked again
+// until the sequence number of its root changes
+type dnsTreeState struct {
+	seq   uint64
+	nodes []*peer.AddrInfo
+	links []string
+}
+
+// dnsClient resolves the bootnodes from the DNS trees
+type dnsClient struct {
+	logger   hclog.Logger
+	resolver txtResolver
+
+	lock  sync.Mutex
+	trees map[string]*dnsTreeState
+}
+
+func newDNSClient(logger hclog.Logger, resolver txtResolver) *dnsClient {
+	return &dnsClient{
+		logger:   logger,
+		resolver: resolver,
+		trees:    map[string]*dnsTreeState{},
+	}
+}
+
+// sync resolves the trees and the trees they link to, and returns their nodes. A tree
+// that fails to resolve keeps the nodes of its last resolution
+func (c *dnsClient) sync(ctx context.Context, urls []string) []*peer.AddrInfo {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	nodes := []*peer.AddrInfo{}
+	seen := map[peer.ID]struct{}{}
+
+	visited := map[string]struct{}{}
+	queue := append([]string{}, urls...)
+	for len(queue) != 0 && len(visited) < dnsMaxTrees {
+		url := queue[0]
+		queue = queue[1:]
+
+		if _, ok := visited[url]; ok {
+			continue
+		}
+		visited[url] = struct{}{}
+
+		state, err := c.resolveTree(ctx, url)
+		if err != nil {
+			c.logger.Warn("failed to resolve dns tree", "url", url, "err", err)
+			if state = c.trees[url]; state == nil {
+				continue
+			}
+		}
+		c.trees[url] = state
+
+		for _, node := range state.nodes {
+			if _, ok := seen[node.ID]; !ok {
+				seen[node.ID] = struct{}{}
+				nodes = append(nodes, node)
+			}
+		}
+		queue = append(queue, state.links...)
+	}
+	return nodes
+}
+
+func (c *dnsClient) resolveTree(ctx context.Context, url string) (*dnsTreeState, error) {
+	tree, err := parseDNSTree(url)
+	if err != nil {
+		return nil, err
+	}
+	root, err := c.resolveRoot(ctx, tree)
+	if err != nil {
+		return nil, err
+	}
+	if state, ok := c.trees[url]; ok && state.seq == root.seq {
+		return state, nil
+	}
+
+	state := &dnsTreeState{
+		seq:   root.seq,
+		nodes: []*peer.AddrInfo{},
+		links: []string{},
+	}
+	leaves, err := c.walk(ctx, tree.domain, root.nodes, dnsNodePrefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, leaf := range leaves {
+		node, err := StringToAddrInfo(strings.TrimPrefix(leaf, dnsNodePrefix))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse node %s: %v", leaf, err)
+		}
+		state.nodes = append(state.nodes, node)
+	}
+
+	links, err := c.walk(ctx, tree.domain, root.links, dnsTreePrefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		if _, err := parseDNSTree(link); err != nil {
+			return nil, fmt.Errorf("failed to parse link %s: %v", link, err)
+		}
+		state.links = append(state.links, link)
+	}
+
+	c.logger.Debug("resolved dns tree", "url", url, "seq", root.seq, "nodes", len(state.nodes), "links", len(state.links))
+	return state, nil
+}
+
+func (c *dnsClient) resolveRoot(ctx context.Context, tree *dnsTree) (*dnsRoot, error) {
+	txts, err := c.resolver.LookupTXT(ctx, tree.domain)
+	if err != nil {
+		return nil, err
+	}
+	for _, txt := range txts {
+		if !strings.HasPrefix(txt, dnsRootPrefix) {
+			continue
+		}
+		root, err := parseDNSRoot(txt)
+		if err != nil {
+			return nil, err
+		}
+		if err := root.verify(tree.pubKey); err != nil {
+			return nil, err
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("no root record at %s", tree.domain)
+}
+
+// walk returns the leaves of the subtree, which must have the prefix
+func (c *dnsClient) walk(ctx context.Context, domain string, hash string, prefix string) ([]string, error) {
+	leaves := []string{}
+
+	records := 0
+	queue := []string{hash}
+	for len(queue) != 0 {
+		if records == dnsMaxRecords {
+			return nil, fmt.Errorf("tree has more than %d records", dnsMaxRecords)
+		}
+		records++
+
+		hash := queue[0]
+		queue = queue[1:]
+
+		txt, err := c.resolveRecord(ctx, domain, hash)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(txt, dnsBranchPrefix) {
+			for _, child := range strings.Split(strings.TrimPrefix(txt, dnsBranchPrefix), ",") {
+				if child != "" {
+					queue = append(queue, child)
+				}
+			}
+			continue
+		}
+		if !strings.HasPrefix(txt, prefix) {
+			return nil, fmt.Errorf("unexpected record '%s' at %s.%s", txt, hash, domain)
+		}
+		leaves = append(leaves, txt)
+	}
+	return leaves, nil
+}
+
+// resolveRecord returns the record at the subdomain of its hash
+func (c *dnsClient) resolveRecord(ctx context.Context, domain string, hash string) (string, error) {
+	txts, err := c.resolver.LookupTXT(ctx, hash+"."+domain)
+	if err != nil {
+		return "", err
+	}
+	for _, txt := range txts {
+		if dnsHash(txt) == strings.ToUpper(hash) {
+			return txt, nil
+		}
+	}
+	return "", fmt.Errorf("no record matches %s.%s", hash, domain)
+}
@@ -0,0 +1,160 @@
+package network
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/0xPolygon/minimal/crypto"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/hashicorp/go-hclog"
+	libp2pCrypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockResolver map[string][]string
+
+func (m mockResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	txts, ok := m[name]
+	if !ok {
+		return nil, fmt.Errorf("no such host %s", name)
+	}
+	return txts, nil
+}
+
+// addTree adds the records of a tree with the leaves in branches of two
+// records, and returns its url
+func (m mockResolver) addTree(t *testing.T, key *ecdsa.PrivateKey, domain string, seq uint64, nodes []string, links []string) string {
+	subtree := func(leaves []string) string {
+		if len(leaves) == 0 {
+			m[dnsHash(dnsBranchPrefix)+"."+domain] = []string{dnsBranchPrefix}
+			return dnsHash(dnsBranchPrefix)
+		}
+		hashes := []string{}
+		for _, leaf := range leaves {
+			m[dnsHash(leaf)+"."+domain] = []string{leaf}
+			hashes = append(hashes, dnsHash(leaf))
+		}
+		for len(hashes) != 1 {
+			next := []string{}
+			for i := 0; i < len(hashes); i += 2 {
+				end := i + 2
+				if end > len(hashes) {
+					end = len(hashes)
+				}
+				branch := dnsBranchPrefix + strings.Join(hashes[i:end], ",")
+				m[dnsHash(branch)+"."+domain] = []string{branch}
+				next = append(next, dnsHash(branch))
+			}
+			hashes = next
+		}
+		return hashes[0]
+	}
+
+	root := &dnsRoot{
+		nodes: subtree(nodes),
+		links: subtree(links),
+		seq:   seq,
+	}
+	sig, err := crypto.Sign(key, crypto.Keccak256([]byte(root.signedText())))
+	assert.NoError(t, err)
+
+	m[domain] = []string{"v=spf1 -all", root.signedText() + " sig=" + base64.RawURLEncoding.EncodeToString(sig)}
+
+	pub := (*btcec.PublicKey)(&key.PublicKey).SerializeCompressed()
+	return dnsTreePrefix + dnsEncoding.EncodeToString(pub) + "@" + domain
+}
+
+func dnsNodes(t *testing.T, n int) []string {
+	nodes := []string{}
+	for i := 0; i < n; i++ {
+		_, pub, err := libp2pCrypto.GenerateKeyPair(libp2pCrypto.Secp256k1, 256)
+		assert.NoError(t, err)
+		id, err := peer.IDFromPublicKey(pub)
+		assert.NoError(t, err)
+
+		nodes = append(nodes, fmt.Sprintf("%s/ip4/127.0.0.%d/tcp/1478/p2p/%s", dnsNodePrefix, i, id))
+	}
+	return nodes
+}
+
+func dnsNodeIDs(nodes []*peer.AddrInfo) []string {
+	res := []string{}
+	for _, node := range nodes {
+		res = append(res, dnsNodePrefix+AddrInfoToString(node))
+	}
+	return res
+}
+
+func TestDNS_ParseTree(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	pub := dnsEncoding.EncodeToString((*btcec.PublicKey)(&key.PublicKey).SerializeCompressed())
+
+	tree, err := parseDNSTree(dnsTreePrefix + strings.ToLower(pub) + "@nodes.example.org")
+	assert.NoError(t, err)
+	assert.Equal(t, "nodes.example.org", tree.domain)
+	assert.Equal(t, key.PublicKey.X, tree.pubKey.X)
+
+	cases := []string{
+		"/ip4/127.0.0.1/tcp/1478",
+		dnsTreePrefix + "nodes.example.org",
+		dnsTreePrefix + pub + "@",
+		dnsTreePrefix + "AAAA@nodes.example.org",
+	}
+	for _, c := range cases {
+		_, err := parseDNSTree(c)
+		assert.Error(t, err, c)
+	}
+}
+
+func TestDNS_Sync(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	resolver := mockResolver{}
+
+	nodes := dnsNodes(t, 6)
+	linked := resolver.addTree(t, key, "linked.example.org", 1, []string{nodes[5], nodes[0]}, nil)
+	url := resolver.addTree(t, key, "nodes.example.org", 1, nodes[:5], []string{linked})
+
+	// the nodes of the linked trees are included once
+	client := newDNSClient(hclog.NewNullLogger(), resolver)
+	assert.ElementsMatch(t, nodes, dnsNodeIDs(client.sync(context.Background(), []string{url})))
+
+	// the nodes are rotated with a new sequence number
+	url = resolver.addTree(t, key, "nodes.example.org", 2, nodes[3:5], nil)
+	assert.ElementsMatch(t, nodes[3:5], dnsNodeIDs(client.sync(context.Background(), []string{url})))
+
+	// the last nodes are kept if the tree fails to resolve
+	delete(resolver, "nodes.example.org")
+	assert.ElementsMatch(t, nodes[3:5], dnsNodeIDs(client.sync(context.Background(), []string{url})))
+}
+
+func TestDNS_Verify(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	resolver := mockResolver{}
+
+	nodes := dnsNodes(t, 2)
+
+	// the root is signed by another key
+	url := resolver.addTree(t, key, "nodes.example.org", 1, nodes[:1], nil)
+	resolver.addTree(t, other, "nodes.example.org", 2, nodes[1:], nil)
+
+	client := newDNSClient(hclog.NewNullLogger(), resolver)
+	_, err := client.resolveTree(context.Background(), url)
+	assert.Error(t, err)
+
+	// a record does not match its hash
+	url = resolver.addTree(t, key, "nodes.example.org", 3, nodes[:1], nil)
+	resolver[dnsHash(nodes[0])+".nodes.example.org"] = nodes[1:]
+	_, err = client.resolveTree(context.Background(), url)
+	assert.Error(t, err)
+
+	// a link in the subtree of the nodes
+	url = resolver.addTree(t, key, "nodes.example.org", 4, []string{url}, nil)
+	_, err = client.resolveTree(context.Background(), url)
+	assert.Error(t, err)
+}
@@ -6,11 +6,13 @@ import (
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hashicorp/go-hclog"
+	"github.com/libp2p/go-libp2p-core/peer"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 )
 
 type Topic struct {
 	logger hclog.Logger
+	srv    *Server
 
 	topic   *pubsub.Topic
 	typ     reflect.Type
@@ -27,9 +29,28 @@ func (t *Topic) Publish(obj proto.Message) error {
 		return err
 	}
 
+	t.srv.logTopicMessage(t.topic.String(), t.srv.host.ID(), len(data))
 	return t.topic.Publish(context.Background(), data)
 }
 
+// Validate validates the messages before they are handled and relayed to the other peers.
+// The messages that cannot be decoded or that fail the validation are dropped, and the
+// peers that delivered them are reported
+func (t *Topic) Validate(validate func(obj interface{}) error) error {
+	return t.srv.ps.RegisterTopicValidator(t.topic.String(), func(ctx context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+		obj := t.createObj()
+		if err := proto.Unmarshal(msg.Data, obj); err != nil {
+			t.srv.ReportPeer(from, MisbehaviorProtocolError, err.Error())
+			return pubsub.ValidationReject
+		}
+		if err := validate(obj); err != nil {
+			t.srv.ReportPeer(from, MisbehaviorInvalidMessage, err.Error())
+			return pubsub.ValidationReject
+		}
+		return pubsub.ValidationAccept
+	})
+}
+
 func (t *Topic) Subscribe(handler func(obj interface{})) error {
 	sub, err := t.topic.Subscribe()
 	if err != nil {
@@ -55,6 +76,11 @@ func (t *Topic) readLoop(sub *pubsub.Subscription, handler func(obj interface{})
 			continue
 		}
 
+		// the messages published by the node are metered when published
+		if msg.ReceivedFrom != t.srv.host.ID() {
+			t.srv.logTopicMessage(t.topic.String(), msg.ReceivedFrom, len(msg.Data))
+		}
+
 		obj := t.createObj()
 		if err := proto.Unmarshal(msg.Data, obj); err != nil {
 			t.logger.Error("failed to unmarshal topic", "err", err)
@@ -69,9 +95,15 @@ func (s *Server) NewTopic(protoID string, obj proto.Message) (*Topic, error) {
 	if err != nil {
 		return nil, err
 	}
+	if s.config.Gossip.PeerScoring {
+		if err := topic.SetScoreParams(s.config.Gossip.topicScoreParams(protoID)); err != nil {
+			return nil, err
+		}
+	}
 
 	tt := &Topic{
 		logger: s.logger.Named(protoID),
+		srv:    s,
 		topic:  topic,
 		typ:    reflect.TypeOf(obj).Elem(),
 	}
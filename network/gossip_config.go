@@ -0,0 +1,217 @@
+package network
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+// GossipConfig are the parameters of the gossipsub router. The heartbeat and the
+// degrees of the meshes are global in gossipsub, so they are shared by all the
+// servers of a process
+type GossipConfig struct {
+	// HeartbeatInterval is the interval between the heartbeats, which graft and prune
+	// the peers of the meshes and gossip the ids of the recent messages
+	HeartbeatInterval time.Duration
+
+	// D is the target number of peers in the mesh of a topic, Dlo and Dhi the bounds under
+	// and over which peers are grafted and pruned. A higher degree lowers the latency of
+	// the propagation but raises the amplification, since every peer of the mesh relays
+	// the full messages
+	D   int
+	Dlo int
+	Dhi int
+
+	// Dlazy is the min number of peers outside the mesh the ids of the messages are gossiped to
+	Dlazy int
+
+	// PeerScoring enables the scoring of the peers on the topics
+	PeerScoring bool
+
+	// GossipThreshold, PublishThreshold and GraylistThreshold are the scores under which
+	// a peer gets no gossip, gets no published messages and is ignored
+	GossipThreshold   float64
+	PublishThreshold  float64
+	GraylistThreshold float64
+
+	// Topics are the score parameters by topic name, the default ones for the other topics
+	Topics map[string]*TopicScoreConfig
+}
+
+// TopicScoreConfig are the score parameters of the peers on a topic
+type TopicScoreConfig struct {
+	// Weight is the weight of the topic in the score of the peers
+	Weight float64
+
+	// TimeInMeshWeight rewards the peers for every second in the mesh, up to an hour
+	TimeInMeshWeight float64
+
+	// FirstDeliveriesWeight rewards the peers for the messages they deliver first
+	FirstDeliveriesWeight float64
+
+	// MeshDeliveriesWeight penalizes the peers of the mesh that deliver less than
+	// MeshDeliveriesThreshold messages per minute, 0 disables it for the topics
+	// without steady traffic
+	MeshDeliveriesWeight    float64
+	MeshDeliveriesThreshold float64
+
+	// InvalidDeliveriesWeight penalizes the peers for the square of the number of
+	// messages that fail the validation
+	InvalidDeliveriesWeight float64
+}
+
+// DefaultTopicScoreConfig returns the default score parameters of a topic
+func DefaultTopicScoreConfig() *TopicScoreConfig {
+	return &TopicScoreConfig{
+		Weight:                  1,
+		TimeInMeshWeight:        0.01,
+		FirstDeliveriesWeight:   1,
+		InvalidDeliveriesWeight: -10,
+	}
+}
+
+// DefaultGossipConfig returns the default parameters of gossipsub, with the peer scoring enabled
+func DefaultGossipConfig() *GossipConfig {
+	return &GossipConfig{
+		HeartbeatInterval: 1 * time.Second,
+		D:                 6,
+		Dlo:               5,
+		Dhi:               12,
+		Dlazy:             6,
+		PeerScoring:       true,
+		GossipThreshold:   -40,
+		PublishThreshold:  -80,
+		GraylistThreshold: -160,
+		Topics:            map[string]*TopicScoreConfig{},
+	}
+}
+
+func (c *GossipConfig) validate() error {
+	if c.HeartbeatInterval <= 0 {
+		return fmt.Errorf("heartbeat interval must be positive")
+	}
+	if c.Dlo < 1 || c.D < c.Dlo || c.Dhi < c.D {
+		return fmt.Errorf("mesh degrees must be 1 <= dlo (%d) <= d (%d) <= dhi (%d)", c.Dlo, c.D, c.Dhi)
+	}
+	if c.GossipThreshold > 0 || c.PublishThreshold > c.GossipThreshold || c.GraylistThreshold > c.PublishThreshold {
+		return fmt.Errorf("score thresholds must be graylist <= publish <= gossip <= 0")
+	}
+	return nil
+}
+
+// gossipParamsLock guards the global parameters of gossipsub
+var gossipParamsLock sync.Mutex
+
+// setGossipParams sets the global parameters of gossipsub, which are read when a
+// router is created. They are only written if changed, since the heartbeats of
+// the running routers read them
+func setGossipParams(c *GossipConfig) {
+	gossipParamsLock.Lock()
+	defer gossipParamsLock.Unlock()
+
+	// the outbound quota must be under dlo and at most half of d, and the
+	// peers retained by score at most d
+	dout := min(2, c.D/2, c.Dlo-1)
+	dscore := min(4, c.D)
+
+	params := []struct {
+		global *int
+		value  int
+	}{
+		{&pubsub.GossipSubD, c.D},
+		{&pubsub.GossipSubDlo, c.Dlo},
+		{&pubsub.GossipSubDhi, c.Dhi},
+		{&pubsub.GossipSubDlazy, c.Dlazy},
+		{&pubsub.GossipSubDout, dout},
+		{&pubsub.GossipSubDscore, dscore},
+	}
+	for _, p := range params {
+		if *p.global != p.value {
+			*p.global = p.value
+		}
+	}
+	if pubsub.GossipSubHeartbeatInterval != c.HeartbeatInterval {
+		pubsub.GossipSubHeartbeatInterval = c.HeartbeatInterval
+	}
+}
+
+func min(values ...int) int {
+	res := values[0]
+	for _, v := range values[1:] {
+		if v < res {
+			res = v
+		}
+	}
+	return res
+}
+
+// gossipOptions returns the options of the gossipsub router. The score of a peer also
+// includes its reputation, so the misbehaving peers are the first pruned from the meshes
+func (s *Server) gossipOptions() []pubsub.Option {
+	c := s.config.Gossip
+	if !c.PeerScoring {
+		return nil
+	}
+
+	params := &pubsub.PeerScoreParams{
+		Topics: map[string]*pubsub.TopicScoreParams{},
+		AppSpecificScore: func(p peer.ID) float64 {
+			return s.reputation.score(p)
+		},
+		AppSpecificWeight: 1,
+
+		// penalize the peers that do not follow the protocol, i.e. graft while backing off
+		BehaviourPenaltyWeight:    -10,
+		BehaviourPenaltyThreshold: 6,
+		BehaviourPenaltyDecay:     pubsub.ScoreParameterDecay(10 * time.Minute),
+
+		DecayInterval: pubsub.DefaultDecayInterval,
+		DecayToZero:   pubsub.DefaultDecayToZero,
+		RetainScore:   10 * time.Minute,
+	}
+	thresholds := &pubsub.PeerScoreThresholds{
+		GossipThreshold:   c.GossipThreshold,
+		PublishThreshold:  c.PublishThreshold,
+		GraylistThreshold: c.GraylistThreshold,
+	}
+	return []pubsub.Option{pubsub.WithPeerScore(params, thresholds)}
+}
+
+// topicScoreParams returns the score parameters of the topic
+func (c *GossipConfig) topicScoreParams(topic string) *pubsub.TopicScoreParams {
+	tc, ok := c.Topics[topic]
+	if !ok {
+		tc = DefaultTopicScoreConfig()
+	}
+
+	params := &pubsub.TopicScoreParams{
+		TopicWeight: tc.Weight,
+
+		TimeInMeshWeight:  tc.TimeInMeshWeight,
+		TimeInMeshQuantum: 1 * time.Second,
+		TimeInMeshCap:     3600,
+
+		FirstMessageDeliveriesWeight: tc.FirstDeliveriesWeight,
+		FirstMessageDeliveriesDecay:  pubsub.ScoreParameterDecay(10 * time.Minute),
+		FirstMessageDeliveriesCap:    100,
+
+		InvalidMessageDeliveriesWeight: tc.InvalidDeliveriesWeight,
+		InvalidMessageDeliveriesDecay:  pubsub.ScoreParameterDecay(1 * time.Hour),
+	}
+	if tc.MeshDeliveriesWeight != 0 {
+		params.MeshMessageDeliveriesWeight = tc.MeshDeliveriesWeight
+		params.MeshMessageDeliveriesDecay = pubsub.ScoreParameterDecay(1 * time.Minute)
+		params.MeshMessageDeliveriesThreshold = tc.MeshDeliveriesThreshold
+		params.MeshMessageDeliveriesCap = 10 * tc.MeshDeliveriesThreshold
+		params.MeshMessageDeliveriesWindow = 10 * time.Millisecond
+		params.MeshMessageDeliveriesActivation = 1 * time.Minute
+
+		// the peers pruned while under the threshold keep the penalty for a while
+		params.MeshFailurePenaltyWeight = tc.MeshDeliveriesWeight
+		params.MeshFailurePenaltyDecay = pubsub.ScoreParameterDecay(1 * time.Minute)
+	}
+	return params
+}
@@ -0,0 +1,59 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	testproto "github.com/0xPolygon/minimal/network/proto/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGossipConfig_Validate(t *testing.T) {
+	cases := []func(c *GossipConfig){
+		func(c *GossipConfig) { c.HeartbeatInterval = 0 },
+		func(c *GossipConfig) { c.Dlo = 0 },
+		func(c *GossipConfig) { c.Dlo = 7 },
+		func(c *GossipConfig) { c.Dhi = 5 },
+		func(c *GossipConfig) { c.GossipThreshold = 1 },
+		func(c *GossipConfig) { c.PublishThreshold = -10 },
+		func(c *GossipConfig) { c.GraylistThreshold = -50 },
+	}
+
+	assert.NoError(t, DefaultGossipConfig().validate())
+	for i, c := range cases {
+		config := DefaultGossipConfig()
+		c(config)
+		assert.Error(t, config.validate(), i)
+	}
+}
+
+func TestGossip_PeerScoring(t *testing.T) {
+	conf := func(c *Config) {
+		c.NoDiscover = true
+		c.Gossip.Topics["invalid/0.1"] = &TopicScoreConfig{Weight: -1}
+	}
+	srv0 := CreateServer(t, conf)
+	srv1 := CreateServer(t, conf)
+
+	MultiJoin(t, srv0, srv1)
+
+	// the score parameters of the topic are validated when it is joined
+	_, err := srv0.NewTopic("invalid/0.1", &testproto.AReq{})
+	assert.Error(t, err)
+
+	topic0, err := srv0.NewTopic("topic/0.1", &testproto.AReq{})
+	assert.NoError(t, err)
+	topic1, err := srv1.NewTopic("topic/0.1", &testproto.AReq{})
+	assert.NoError(t, err)
+
+	msgCh := make(chan *testproto.AReq, 10)
+	assert.NoError(t, topic1.Subscribe(func(obj interface{}) {
+		msgCh <- obj.(*testproto.AReq)
+	}))
+
+	// the messages are published until the mesh is formed
+	assert.Eventually(t, func() bool {
+		assert.NoError(t, topic0.Publish(&testproto.AReq{Msg: "a"}))
+		return len(msgCh) != 0
+	}, 10*time.Second, 100*time.Millisecond)
+}
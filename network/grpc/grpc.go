@@ -130,7 +130,7 @@ type wrapLibp2pAddr struct {
 func (c *streamConn) LocalAddr() net.Addr {
 	addr, err := manet.ToNetAddr(c.Stream.Conn().LocalMultiaddr())
 	if err != nil {
-		return fakeRemoteAddr()
+		addr = fakeLocalAddr()
 	}
 	return &wrapLibp2pAddr{Addr: addr, id: c.Stream.Conn().LocalPeer()}
 }
@@ -139,7 +139,9 @@ func (c *streamConn) LocalAddr() net.Addr {
 func (c *streamConn) RemoteAddr() net.Addr {
 	addr, err := manet.ToNetAddr(c.Stream.Conn().RemoteMultiaddr())
 	if err != nil {
-		return fakeRemoteAddr()
+		// the interceptor expects the peer id, even if the address is
+		// not a net address, i.e. a relayed connection
+		addr = fakeRemoteAddr()
 	}
 	return &wrapLibp2pAddr{Addr: addr, id: c.Stream.Conn().RemotePeer()}
 }
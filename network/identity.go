@@ -3,6 +3,7 @@ package network
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 
@@ -10,6 +11,8 @@ import (
 
 	"github.com/0xPolygon/minimal/network/grpc"
 	"github.com/0xPolygon/minimal/network/proto"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/0xPolygon/minimal/version"
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
@@ -17,12 +20,37 @@ import (
 
 var identityProtoV1 = "/id/0.1"
 
+const (
+	// metadataClient and metadataCapabilities are the keys of the metadata of the
+	// status with the client version and the comma separated protocols of the node
+	metadataClient       = "client"
+	metadataCapabilities = "capabilities"
+
+	// peerMetadataKey is the key of the metadata of the handshake in the peerstore
+	peerMetadataKey = "polygon/metadata"
+)
+
+// PeerMetadata is the identity of a peer exchanged in the handshake
+type PeerMetadata struct {
+	// Client is the name and the version of the client of the peer
+	Client string
+
+	ChainID int64
+	Genesis types.Hash
+
+	// Capabilities are the protocols registered by the peer
+	Capabilities []string
+}
+
 type identity struct {
 	proto.UnimplementedIdentityServer
 
 	pending     sync.Map
 	pendingSize int64
 
+	// pendingInbound is the number of the pending handshakes of the inbound connections
+	pendingInbound int64
+
 	srv *Server
 }
 
@@ -30,14 +58,24 @@ func (i *identity) numPending() int64 {
 	return atomic.LoadInt64(&i.pendingSize)
 }
 
-func (i *identity) delPending(id peer.ID) {
+func (i *identity) numPendingInbound() int64 {
+	return atomic.LoadInt64(&i.pendingInbound)
+}
+
+func (i *identity) delPending(id peer.ID, inbound bool) {
 	i.pending.Delete(id)
 	atomic.AddInt64(&i.pendingSize, -1)
+	if inbound {
+		atomic.AddInt64(&i.pendingInbound, -1)
+	}
 }
 
-func (i *identity) setPending(id peer.ID) {
+func (i *identity) setPending(id peer.ID, inbound bool) {
 	i.pending.Store(id, true)
 	atomic.AddInt64(&i.pendingSize, 1)
+	if inbound {
+		atomic.AddInt64(&i.pendingInbound, 1)
+	}
 }
 
 func (i *identity) setup() {
@@ -53,22 +91,28 @@ func (i *identity) setup() {
 			peerID := conn.RemotePeer()
 			i.srv.logger.Trace("Conn", "peer", peerID, "direction", conn.Stat().Direction)
 
-			// limit by MaxPeers on incomming requests since we already limit
-			// the outgoing requests
-			if conn.Stat().Direction == network.DirInbound {
-				if i.srv.numOpenSlots() == 0 {
+			if i.srv.IsBanned(peerID) {
+				i.srv.Disconnect(peerID, "banned")
+				return
+			}
+
+			// limit by MaxPeers and MaxInbound on incomming requests since we already
+			// limit the outgoing requests. The trusted peers are always accepted
+			inbound := conn.Stat().Direction == network.DirInbound
+			if inbound && !i.srv.peerSet.isTrusted(peerID) {
+				if i.srv.numInboundSlots() == 0 {
 					i.srv.Disconnect(peerID, "no available slots")
 					return
 				}
 			}
 
 			// pending of handshake
-			i.setPending(peerID)
+			i.setPending(peerID, inbound)
 
 			go func() {
-				defer i.delPending(peerID)
+				defer i.delPending(peerID, inbound)
 
-				if err := i.handleConnected(peerID); err != nil {
+				if err := i.handleConnected(peerID, inbound); err != nil {
 					i.srv.Disconnect(peerID, err.Error())
 				}
 			}()
@@ -78,11 +122,40 @@ func (i *identity) setup() {
 
 func (i *identity) getStatus() *proto.Status {
 	return &proto.Status{
-		Chain: int64(i.srv.config.Chain.Params.ChainID),
+		Metadata: map[string]string{
+			metadataClient:       version.ClientVersion(),
+			metadataCapabilities: strings.Join(i.srv.capabilities(), ","),
+		},
+		Chain:   int64(i.srv.config.Chain.Params.ChainID),
+		Genesis: i.srv.config.GenesisHash.String(),
 	}
 }
 
-func (i *identity) handleConnected(peerID peer.ID) error {
+// checkStatus returns an error if the peer is on another chain
+func (i *identity) checkStatus(status *proto.Status) error {
+	if chainID := int64(i.srv.config.Chain.Params.ChainID); status.Chain != chainID {
+		return fmt.Errorf("incorrect chain id %d, expected %d", status.Chain, chainID)
+	}
+	if genesis := i.srv.config.GenesisHash.String(); status.Genesis != genesis {
+		return fmt.Errorf("incorrect genesis %s, expected %s", status.Genesis, genesis)
+	}
+	return nil
+}
+
+func statusToMetadata(status *proto.Status) *PeerMetadata {
+	metadata := &PeerMetadata{
+		Client:       status.Metadata[metadataClient],
+		ChainID:      status.Chain,
+		Genesis:      types.StringToHash(status.Genesis),
+		Capabilities: []string{},
+	}
+	if caps := status.Metadata[metadataCapabilities]; caps != "" {
+		metadata.Capabilities = strings.Split(caps, ",")
+	}
+	return metadata
+}
+
+func (i *identity) handleConnected(peerID peer.ID, inbound bool) error {
 	// we initiated the connection, now we perform the handshake
 	conn, err := i.srv.NewProtoStream(identityProtoV1, peerID)
 	if err != nil {
@@ -97,15 +170,26 @@ func (i *identity) handleConnected(peerID peer.ID) error {
 	}
 
 	// validation
-	if status.Chain != resp.Chain {
-		return fmt.Errorf("incorrect chain id")
+	if err := i.checkStatus(resp); err != nil {
+		return err
+	}
+	if err := i.srv.host.Peerstore().Put(peerID, peerMetadataKey, statusToMetadata(resp)); err != nil {
+		return err
 	}
 
-	i.srv.addPeer(peerID)
+	i.srv.addPeer(peerID, inbound)
 	return nil
 }
 
 func (i *identity) Hello(ctx context.Context, req *proto.Status) (*proto.Status, error) {
+	// the connection of a banned peer might not be closed yet
+	if i.srv.IsBanned(ctx.(*grpc.Context).PeerID) {
+		return nil, fmt.Errorf("peer is banned")
+	}
+	// reject the peers of other chains before they complete their handshake
+	if err := i.checkStatus(req); err != nil {
+		return nil, err
+	}
 	return i.getStatus(), nil
 }
 
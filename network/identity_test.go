@@ -3,6 +3,10 @@ package network
 import (
 	"testing"
 	"time"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/0xPolygon/minimal/version"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestGrpcStream(t *testing.T) {
@@ -17,4 +21,51 @@ func TestGrpcStream(t *testing.T) {
 	time.Sleep(5 * time.Second)
 }
 
+func TestIdentity_Metadata(t *testing.T) {
+	conf := func(c *Config) {
+		c.NoDiscover = true
+		c.GenesisHash = types.StringToHash("1")
+	}
+	srv0 := CreateServer(t, conf)
+	srv1 := CreateServer(t, conf)
+
+	MultiJoin(t, srv0, srv1)
+
+	metadata, ok := srv0.GetPeerMetadata(srv1.AddrInfo().ID)
+	assert.True(t, ok)
+	assert.Equal(t, version.ClientVersion(), metadata.Client)
+	assert.Equal(t, int64(1), metadata.ChainID)
+	assert.Equal(t, types.StringToHash("1"), metadata.Genesis)
+	assert.Contains(t, metadata.Capabilities, identityProtoV1)
+}
+
+func TestIdentity_Mismatch(t *testing.T) {
+	srv0 := CreateServer(t, func(c *Config) {
+		c.NoDiscover = true
+		c.GenesisHash = types.StringToHash("1")
+	})
+
+	cases := []func(c *Config){
+		func(c *Config) {
+			c.GenesisHash = types.StringToHash("2")
+		},
+		func(c *Config) {
+			c.GenesisHash = types.StringToHash("1")
+			c.Chain.Params.ChainID = 2
+		},
+	}
+	for _, conf := range cases {
+		srv := CreateServer(t, func(c *Config) {
+			c.NoDiscover = true
+			conf(c)
+		})
+
+		// the peers of other chains are disconnected in the handshake
+		assert.Error(t, srv.Join(srv0.AddrInfo(), 2*time.Second))
+		_, ok := srv0.GetPeerMetadata(srv.AddrInfo().ID)
+		assert.False(t, ok)
+		assert.NoError(t, srv.Close())
+	}
+}
+
 // Test: Connect maxPeers
@@ -0,0 +1,95 @@
+package network
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/libp2p/go-libp2p"
+	circuit "github.com/libp2p/go-libp2p-circuit"
+	"github.com/libp2p/go-libp2p-core/event"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr-net"
+)
+
+// natOptions returns the libp2p options of the NAT traversal. The listen port is mapped
+// on the gateway with UPnP or NAT-PMP, the reachability is detected with AutoNAT and,
+// if the node is not reachable, it announces the addresses of the relays instead
+func natOptions(config *Config) ([]libp2p.Option, error) {
+	opts := []libp2p.Option{}
+	if config.NatPortMap {
+		opts = append(opts, libp2p.NATPortMap())
+	}
+	if config.NatService {
+		opts = append(opts, libp2p.EnableNATService())
+	}
+
+	if config.RelayHop {
+		if len(config.Relays) != 0 {
+			return nil, fmt.Errorf("a relay cannot use other relays")
+		}
+		opts = append(opts, libp2p.EnableRelay(circuit.OptHop))
+	} else {
+		opts = append(opts, libp2p.EnableRelay())
+	}
+
+	if len(config.Relays) != 0 {
+		relays := []peer.AddrInfo{}
+		for _, raw := range config.Relays {
+			info, err := StringToAddrInfo(raw)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse relay %s: %v", raw, err)
+			}
+			relays = append(relays, *info)
+		}
+		opts = append(opts, libp2p.EnableAutoRelay(), libp2p.StaticRelays(relays))
+	}
+	return opts, nil
+}
+
+// trackReachability tracks the reachability of the node as detected by AutoNAT
+func (s *Server) trackReachability() error {
+	sub, err := s.host.EventBus().Subscribe(new(event.EvtLocalReachabilityChanged))
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer sub.Close()
+
+		for {
+			select {
+			case evnt, ok := <-sub.Out():
+				if !ok {
+					return
+				}
+				reachability := evnt.(event.EvtLocalReachabilityChanged).Reachability
+				atomic.StoreInt32(&s.reachability, int32(reachability))
+
+				s.logger.Info("reachability changed", "reachability", reachability.String(), "addrs", s.ExternalAddrs())
+
+			case <-s.closeCh:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Reachability returns whether the node is reachable by the peers, unknown until AutoNAT detects it
+func (s *Server) Reachability() network.Reachability {
+	return network.Reachability(atomic.LoadInt32(&s.reachability))
+}
+
+// ExternalAddrs returns the public addresses of the node: the nat address, the ports
+// mapped on the gateway, the addresses observed by the peers and the relay addresses
+func (s *Server) ExternalAddrs() []multiaddr.Multiaddr {
+	addrs := []multiaddr.Multiaddr{}
+	for _, addr := range s.host.Addrs() {
+		if manet.IsPublicAddr(addr) {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
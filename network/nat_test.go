@@ -0,0 +1,72 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNat_Options(t *testing.T) {
+	relay := "/ip4/1.2.3.4/tcp/1478/p2p/16Uiu2HAmJxxH1tScDX2rLGSU9exnuvZKNM9SoK3v315azp68DLPW"
+
+	_, err := natOptions(&Config{Relays: []string{relay}})
+	assert.NoError(t, err)
+
+	// a relay does not use other relays
+	_, err = natOptions(&Config{Relays: []string{relay}, RelayHop: true})
+	assert.Error(t, err)
+
+	_, err = natOptions(&Config{Relays: []string{"/ip4/1.2.3.4/tcp/1478"}})
+	assert.Error(t, err)
+}
+
+func TestNat_ExternalAddrs(t *testing.T) {
+	srv := CreateServer(t, func(c *Config) {
+		c.NatAddr = net.ParseIP("1.2.3.4")
+		c.NoDiscover = true
+	})
+	defer srv.Close()
+
+	// only the nat address is public
+	assert.Equal(t, []string{fmt.Sprintf("/ip4/1.2.3.4/tcp/%d", srv.config.Addr.Port)}, multiaddrStrings(srv.ExternalAddrs()))
+	assert.Equal(t, network.ReachabilityUnknown, srv.Reachability())
+}
+
+func TestNat_Relay(t *testing.T) {
+	conf := func(c *Config) {
+		c.NoDiscover = true
+	}
+	relay := CreateServer(t, func(c *Config) {
+		c.NoDiscover = true
+		c.RelayHop = true
+	})
+	srv0 := CreateServer(t, conf)
+	srv1 := CreateServer(t, conf)
+
+	MultiJoin(t, srv0, relay, srv1, relay)
+
+	// srv0 dials srv1 through the relay
+	circuitAddr, err := multiaddr.NewMultiaddr("/p2p/" + relay.AddrInfo().ID.String() + "/p2p-circuit")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, srv0.host.Connect(ctx, peer.AddrInfo{ID: srv1.AddrInfo().ID, Addrs: []multiaddr.Multiaddr{circuitAddr}}))
+	assert.True(t, srv1.waitForEvent(5*time.Second, connectedPeerHandler(srv0.AddrInfo().ID)))
+}
+
+func multiaddrStrings(addrs []multiaddr.Multiaddr) []string {
+	res := []string{}
+	for _, addr := range addrs {
+		res = append(res, addr.String())
+	}
+	return res
+}
@@ -0,0 +1,296 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// PeersFile is the file of the data dir where the static, the trusted and the denied peers are persisted
+const PeersFile = "peers.json"
+
+const (
+	// staticMinBackoff and staticMaxBackoff bound the time between the dials of a
+	// disconnected static peer, which doubles on every failed dial
+	staticMinBackoff = 1 * time.Second
+	staticMaxBackoff = 5 * time.Minute
+
+	// staticDialInterval is the interval between the checks of the static peers
+	staticDialInterval = 1 * time.Second
+)
+
+// peerLists are the p2p addresses of the static and of the trusted peers,
+// and the ids of the denied peers with the reasons of their bans
+type peerLists struct {
+	Static  []string          `json:"static"`
+	Trusted []string          `json:"trusted"`
+	Denied  map[string]string `json:"denied"`
+}
+
+type staticPeer struct {
+	info *peer.AddrInfo
+
+	// nextDial is the time of the next dial, and backoff the time
+	// waited after it for the following one if it fails
+	backoff  time.Duration
+	nextDial time.Time
+}
+
+// peerSet tracks the static peers, which are kept connected and redialed with backoff
+// once they disconnect, the trusted peers, which do not count for the max peers, and
+// the denylist of the peers banned permanently. The peers are persisted in the data dir,
+// so the ones added at runtime are restored on restart together with the ones of the config
+type peerSet struct {
+	srv *Server
+
+	// path is the file where the peers are persisted, they are not persisted if empty
+	path string
+
+	lock    sync.Mutex
+	static  map[peer.ID]*staticPeer
+	trusted map[peer.ID]*peer.AddrInfo
+	denied  map[peer.ID]string
+}
+
+func newPeerSet(srv *Server) *peerSet {
+	p := &peerSet{
+		srv:     srv,
+		static:  map[peer.ID]*staticPeer{},
+		trusted: map[peer.ID]*peer.AddrInfo{},
+		denied:  map[peer.ID]string{},
+	}
+	if srv.config.DataDir != "" {
+		p.path = filepath.Join(srv.config.DataDir, PeersFile)
+	}
+	return p
+}
+
+// load adds the persisted peers and the peers of the config
+func (p *peerSet) load() error {
+	lists := &peerLists{}
+	if p.path != "" {
+		data, err := ioutil.ReadFile(p.path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err == nil {
+			if err := json.Unmarshal(data, lists); err != nil {
+				return fmt.Errorf("failed to decode the peers file %s: %v", p.path, err)
+			}
+		}
+	}
+	lists.Static = append(lists.Static, p.srv.config.StaticPeers...)
+	lists.Trusted = append(lists.Trusted, p.srv.config.TrustedPeers...)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, raw := range lists.Static {
+		info, err := StringToAddrInfo(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse static peer %s: %v", raw, err)
+		}
+		p.static[info.ID] = &staticPeer{info: info, backoff: staticMinBackoff}
+	}
+	for _, raw := range lists.Trusted {
+		info, err := StringToAddrInfo(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse trusted peer %s: %v", raw, err)
+		}
+		p.trusted[info.ID] = info
+	}
+	for raw, reason := range lists.Denied {
+		id, err := peer.Decode(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse denied peer %s: %v", raw, err)
+		}
+		p.denied[id] = reason
+	}
+	return p.save()
+}
+
+// save persists the peers, the lock has to be held
+func (p *peerSet) save() error {
+	if p.path == "" {
+		return nil
+	}
+	lists := &peerLists{
+		Static:  []string{},
+		Trusted: []string{},
+		Denied:  map[string]string{},
+	}
+	for _, s := range p.static {
+		lists.Static = append(lists.Static, AddrInfoToString(s.info))
+	}
+	for _, info := range p.trusted {
+		lists.Trusted = append(lists.Trusted, AddrInfoToString(info))
+	}
+	for id, reason := range p.denied {
+		lists.Denied[id.String()] = reason
+	}
+	data, err := json.MarshalIndent(lists, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p.path, data, 0644)
+}
+
+func (p *peerSet) addStatic(info *peer.AddrInfo) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.static[info.ID] = &staticPeer{info: info, backoff: staticMinBackoff}
+	return p.save()
+}
+
+func (p *peerSet) removeStatic(id peer.ID) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.static, id)
+	return p.save()
+}
+
+func (p *peerSet) addTrusted(info *peer.AddrInfo) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.trusted[info.ID] = info
+	return p.save()
+}
+
+func (p *peerSet) removeTrusted(id peer.ID) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.trusted, id)
+	return p.save()
+}
+
+func (p *peerSet) addDenied(id peer.ID, reason string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.denied[id] = reason
+	return p.save()
+}
+
+func (p *peerSet) removeDenied(id peer.ID) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, ok := p.denied[id]; !ok {
+		return nil
+	}
+	delete(p.denied, id)
+	return p.save()
+}
+
+func (p *peerSet) isDenied(id peer.ID) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	_, ok := p.denied[id]
+	return ok
+}
+
+// denylist returns the peers banned permanently
+func (p *peerSet) denylist() []*BannedPeer {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	res := []*BannedPeer{}
+	for id, reason := range p.denied {
+		res = append(res, &BannedPeer{ID: id, Reason: reason})
+	}
+	return res
+}
+
+func (p *peerSet) isStatic(id peer.ID) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	_, ok := p.static[id]
+	return ok
+}
+
+func (p *peerSet) isTrusted(id peer.ID) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	_, ok := p.trusted[id]
+	return ok
+}
+
+// connected resets the backoff of a static peer once it is connected
+func (p *peerSet) connected(id peer.ID) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if s, ok := p.static[id]; ok {
+		s.backoff = staticMinBackoff
+		s.nextDial = time.Time{}
+	}
+}
+
+// dialStatic dials the static peers that are not connected and whose backoff expired.
+// They are dialed even if the node has no open slot, since they are always kept connected,
+// but not while they are banned
+func (p *peerSet) dialStatic() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := time.Now()
+	for _, s := range p.static {
+		if _, ok := p.denied[s.info.ID]; ok || p.srv.reputation.isBanned(s.info.ID) {
+			continue
+		}
+		if p.srv.isConnected(s.info.ID) || now.Before(s.nextDial) {
+			continue
+		}
+		s.nextDial = now.Add(s.backoff)
+		if s.backoff *= 2; s.backoff > staticMaxBackoff {
+			s.backoff = staticMaxBackoff
+		}
+
+		go func(info peer.AddrInfo) {
+			ctx, cancel := context.WithTimeout(context.Background(), DefaultJoinTimeout)
+			defer cancel()
+
+			if err := p.srv.host.Connect(ctx, info); err != nil {
+				p.srv.logger.Debug("failed to dial static peer", "addr", info.String(), "err", err)
+			}
+		}(*s.info)
+	}
+}
+
+func (p *peerSet) run() {
+	err := p.srv.SubscribeFn(func(evnt *PeerEvent) {
+		if evnt.Type == PeerEventConnected {
+			p.connected(evnt.PeerID)
+		}
+	})
+	if err != nil {
+		p.srv.logger.Error("static peers failed to subscribe", "err", err)
+	}
+
+	ticker := time.NewTicker(staticDialInterval)
+	defer ticker.Stop()
+
+	for {
+		p.dialStatic()
+
+		select {
+		case <-ticker.C:
+		case <-p.srv.closeCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package network
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeers_StaticReconnect(t *testing.T) {
+	conf := func(c *Config) {
+		c.NoDiscover = true
+	}
+	srv0 := CreateServer(t, conf)
+	srv1 := CreateServer(t, conf)
+
+	assert.NoError(t, srv0.AddStaticPeer(AddrInfoToString(srv1.AddrInfo())))
+	assert.True(t, srv0.waitForEvent(5*time.Second, connectedPeerHandler(srv1.AddrInfo().ID)))
+
+	// the static peer is redialed once it disconnects
+	srv1.Disconnect(srv0.AddrInfo().ID, "bye")
+	assert.True(t, srv0.waitForEvent(5*time.Second, disconnectedPeerHandler(srv1.AddrInfo().ID)))
+	assert.True(t, srv0.waitForEvent(5*time.Second, connectedPeerHandler(srv1.AddrInfo().ID)))
+
+	// and not anymore once removed
+	assert.NoError(t, srv0.RemoveStaticPeer(srv1.AddrInfo().ID))
+	srv0.Disconnect(srv1.AddrInfo().ID, "bye")
+	assert.False(t, srv0.waitForEvent(2*time.Second, connectedPeerHandler(srv1.AddrInfo().ID)))
+}
+
+func TestPeers_TrustedOverMaxPeers(t *testing.T) {
+	conf := func(c *Config) {
+		c.MaxPeers = 1
+		c.NoDiscover = true
+	}
+	srv0 := CreateServer(t, conf)
+	srv1 := CreateServer(t, conf)
+	srv2 := CreateServer(t, conf)
+
+	assert.NoError(t, srv0.Join(srv1.AddrInfo(), 5*time.Second))
+
+	// srv0 has no open slot but accepts the trusted peer, which is not counted
+	assert.NoError(t, srv0.AddTrustedPeer(AddrInfoToString(srv2.AddrInfo())))
+	assert.NoError(t, srv2.Join(srv0.AddrInfo(), 5*time.Second))
+	assert.Equal(t, int64(1), srv0.numPeers())
+}
+
+func TestPeers_Persisted(t *testing.T) {
+	dataDir, err := ioutil.TempDir("/tmp", "minimal-peers-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	srv0 := CreateServer(t, func(c *Config) {
+		c.NoDiscover = true
+	})
+	srv1 := CreateServer(t, func(c *Config) {
+		c.NoDiscover = true
+	})
+
+	conf := func(c *Config) {
+		c.NoDiscover = true
+		c.DataDir = dataDir
+	}
+	srv := CreateServer(t, conf)
+	assert.NoError(t, srv.AddStaticPeer(AddrInfoToString(srv0.AddrInfo())))
+	assert.NoError(t, srv.AddTrustedPeer(AddrInfoToString(srv1.AddrInfo())))
+	assert.NoError(t, srv.Close())
+
+	// the peers added at runtime are restored on restart
+	srv = CreateServer(t, conf)
+	defer srv.Close()
+
+	assert.Contains(t, srv.peerSet.static, srv0.AddrInfo().ID)
+	assert.True(t, srv.peerSet.isTrusted(srv1.AddrInfo().ID))
+}
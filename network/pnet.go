@@ -0,0 +1,54 @@
+package network
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/libp2p/go-libp2p-core/pnet"
+)
+
+// SwarmKeyName is the name of the pre-shared key of the private network in the data directory
+var SwarmKeyName = "swarm.key"
+
+// GenerateSwarmKey returns a new pre-shared key encoded as a swarm key file,
+// the format of the private networks of libp2p
+func GenerateSwarmKey() ([]byte, error) {
+	psk := make([]byte, 32)
+	if _, err := rand.Read(psk); err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("/key/swarm/psk/1.0.0/\n/base16/\n%s\n", hex.EncodeToString(psk))), nil
+}
+
+// ReadSwarmKey reads the pre-shared key of the private network from the swarm key file
+func ReadSwarmKey(path string) (pnet.PSK, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	psk, err := pnet.DecodeV1PSK(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode swarm key (%s): %v", path, err)
+	}
+	return psk, nil
+}
+
+// readDataDirSwarmKey reads the swarm key of the data directory, nil if there is none
+func readDataDirSwarmKey(dataDir string) (pnet.PSK, error) {
+	if dataDir == "" {
+		return nil, nil
+	}
+	path := filepath.Join(dataDir, SwarmKeyName)
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to stat (%s): %v", path, err)
+	}
+	return ReadSwarmKey(path)
+}
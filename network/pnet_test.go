@@ -0,0 +1,70 @@
+package network
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/pnet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrivateNetwork(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "swarm-key-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	readKey := func(name string) pnet.PSK {
+		buf, err := GenerateSwarmKey()
+		assert.NoError(t, err)
+		path := filepath.Join(dir, name)
+		assert.NoError(t, ioutil.WriteFile(path, buf, 0600))
+
+		psk, err := ReadSwarmKey(path)
+		assert.NoError(t, err)
+		assert.Len(t, psk, 32)
+		return psk
+	}
+	key0, key1 := readKey("key0"), readKey("key1")
+
+	conf := func(key pnet.PSK) func(c *Config) {
+		return func(c *Config) {
+			c.NoDiscover = true
+			c.SwarmKey = key
+		}
+	}
+	srv0 := CreateServer(t, conf(key0))
+	srv1 := CreateServer(t, conf(key0))
+	srv2 := CreateServer(t, conf(key1))
+	srv3 := CreateServer(t, nil)
+
+	// only the peers with the same key can connect
+	MultiJoin(t, srv0, srv1)
+	assert.Error(t, srv0.Join(srv2.AddrInfo(), 2*time.Second))
+	assert.Error(t, srv0.Join(srv3.AddrInfo(), 2*time.Second))
+	assert.Error(t, srv3.Join(srv1.AddrInfo(), 2*time.Second))
+}
+
+func TestPrivateNetwork_DataDir(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "swarm-key-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// no swarm key in the data directory
+	psk, err := readDataDirSwarmKey(dir)
+	assert.NoError(t, err)
+	assert.Nil(t, psk)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, SwarmKeyName), []byte("/key/swarm/psk/1.0.0/\n/base16/\nzz\n"), 0600))
+	_, err = readDataDirSwarmKey(dir)
+	assert.Error(t, err)
+
+	buf, err := GenerateSwarmKey()
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, SwarmKeyName), buf, 0600))
+	psk, err = readDataDirSwarmKey(dir)
+	assert.NoError(t, err)
+	assert.Len(t, psk, 32)
+}
@@ -0,0 +1,267 @@
+package network
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// DefaultBanDuration is the default duration of the bans of the peers with a bad reputation
+const DefaultBanDuration = 1 * time.Hour
+
+const (
+	// banThreshold is the reputation below which a peer is banned
+	banThreshold = -100
+
+	// reputationRecovery is the reputation a peer recovers per minute, up to 0
+	reputationRecovery = 5
+
+	// reputationPruneInterval is the interval between the removals of the expired bans
+	// and of the peers that recovered their reputation
+	reputationPruneInterval = 1 * time.Minute
+)
+
+// Misbehavior is a kind of misbehavior of a peer, which lowers its reputation
+type Misbehavior int
+
+const (
+	// MisbehaviorBadBlock is a block that fails the validation
+	MisbehaviorBadBlock Misbehavior = iota
+
+	// MisbehaviorInvalidMessage is a gossip message that fails the validation, i.e. a consensus message
+	MisbehaviorInvalidMessage
+
+	// MisbehaviorProtocolError is a malformed request or response of a protocol
+	MisbehaviorProtocolError
+
+	// MisbehaviorTimeout is a request not answered in time
+	MisbehaviorTimeout
+)
+
+func (m Misbehavior) String() string {
+	switch m {
+	case MisbehaviorBadBlock:
+		return "bad block"
+	case MisbehaviorInvalidMessage:
+		return "invalid message"
+	case MisbehaviorProtocolError:
+		return "protocol error"
+	case MisbehaviorTimeout:
+		return "timeout"
+	default:
+		return fmt.Sprintf("misbehavior %d", int(m))
+	}
+}
+
+// penalty returns the reputation a peer loses for the misbehavior
+func (m Misbehavior) penalty() float64 {
+	switch m {
+	case MisbehaviorBadBlock:
+		return 60
+	case MisbehaviorInvalidMessage:
+		return 20
+	case MisbehaviorProtocolError:
+		return 10
+	default:
+		return 5
+	}
+}
+
+// BannedPeer is a peer the node does not connect to
+type BannedPeer struct {
+	ID peer.ID
+
+	// Until is the end of the ban, zero if the ban is permanent
+	Until time.Time
+
+	Reason string
+}
+
+type peerReputation struct {
+	score   float64
+	updated time.Time
+}
+
+// current returns the score recovered since the last update
+func (p *peerReputation) current(now time.Time) float64 {
+	score := p.score + now.Sub(p.updated).Minutes()*reputationRecovery
+	if score > 0 {
+		score = 0
+	}
+	return score
+}
+
+// reputation tracks the scores of the misbehaving peers and the timed bans. The permanent
+// bans are the denylist of the peer set, which is persisted in the data dir
+type reputation struct {
+	srv *Server
+
+	lock   sync.Mutex
+	scores map[peer.ID]*peerReputation
+	bans   map[peer.ID]*BannedPeer
+}
+
+func newReputation(srv *Server) *reputation {
+	return &reputation{
+		srv:    srv,
+		scores: map[peer.ID]*peerReputation{},
+		bans:   map[peer.ID]*BannedPeer{},
+	}
+}
+
+// report lowers the score of the peer, and returns true if it dropped below the ban threshold
+func (r *reputation) report(id peer.ID, m Misbehavior) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	rep, ok := r.scores[id]
+	if !ok {
+		rep = &peerReputation{}
+		r.scores[id] = rep
+	}
+	rep.score = rep.current(now) - m.penalty()
+	rep.updated = now
+
+	if rep.score > banThreshold {
+		return false
+	}
+	delete(r.scores, id)
+	return true
+}
+
+func (r *reputation) score(id peer.ID) float64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if rep, ok := r.scores[id]; ok {
+		return rep.current(time.Now())
+	}
+	return 0
+}
+
+func (r *reputation) ban(id peer.ID, until time.Time, reason string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.bans[id] = &BannedPeer{ID: id, Until: until, Reason: reason}
+}
+
+// unban lifts the timed ban of the peer and resets its score
+func (r *reputation) unban(id peer.ID) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.bans, id)
+	delete(r.scores, id)
+}
+
+func (r *reputation) isBanned(id peer.ID) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	b, ok := r.bans[id]
+	return ok && time.Now().Before(b.Until)
+}
+
+func (r *reputation) banned() []*BannedPeer {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	res := []*BannedPeer{}
+	for _, b := range r.bans {
+		if now.Before(b.Until) {
+			res = append(res, b)
+		}
+	}
+	return res
+}
+
+// prune removes the expired bans and the peers that recovered their reputation
+func (r *reputation) prune() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	for id, b := range r.bans {
+		if !now.Before(b.Until) {
+			delete(r.bans, id)
+		}
+	}
+	for id, rep := range r.scores {
+		if rep.current(now) == 0 {
+			delete(r.scores, id)
+		}
+	}
+}
+
+func (r *reputation) run() {
+	ticker := time.NewTicker(reputationPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.prune()
+		case <-r.srv.closeCh:
+			return
+		}
+	}
+}
+
+// ReportPeer lowers the reputation of the peer for a misbehavior, and bans it for
+// the ban duration once it drops below the threshold. The trusted peers are not banned
+func (s *Server) ReportPeer(id peer.ID, m Misbehavior, reason string) {
+	if id == s.host.ID() || s.peerSet.isTrusted(id) {
+		return
+	}
+	s.logger.Debug("peer misbehaved", "id", id, "misbehavior", m.String(), "reason", reason)
+
+	if s.reputation.report(id, m) {
+		s.logger.Warn("banning peer with a bad reputation", "id", id, "duration", s.config.BanDuration, "misbehavior", m.String(), "reason", reason)
+
+		s.reputation.ban(id, time.Now().Add(s.config.BanDuration), fmt.Sprintf("%s: %s", m.String(), reason))
+		s.Disconnect(id, "banned")
+	}
+}
+
+// PeerReputation returns the reputation of the peer, which is 0 unless it misbehaved recently
+func (s *Server) PeerReputation(id peer.ID) float64 {
+	return s.reputation.score(id)
+}
+
+// BanPeer bans the peer for the duration, or permanently if the duration is 0, and disconnects
+// it. The permanent bans are the denylist, which is persisted across restarts
+func (s *Server) BanPeer(id peer.ID, duration time.Duration, reason string) error {
+	if id == s.host.ID() {
+		return fmt.Errorf("cannot ban the local node")
+	}
+	if duration == 0 {
+		if err := s.peerSet.addDenied(id, reason); err != nil {
+			return err
+		}
+	} else {
+		s.reputation.ban(id, time.Now().Add(duration), reason)
+	}
+	s.Disconnect(id, "banned")
+	return nil
+}
+
+// UnbanPeer lifts the timed and the permanent bans of the peer and resets its reputation
+func (s *Server) UnbanPeer(id peer.ID) error {
+	s.reputation.unban(id)
+	return s.peerSet.removeDenied(id)
+}
+
+// IsBanned returns true if the peer is banned, temporarily or permanently
+func (s *Server) IsBanned(id peer.ID) bool {
+	return s.reputation.isBanned(id) || s.peerSet.isDenied(id)
+}
+
+// BannedPeers returns the peers banned, temporarily or permanently
+func (s *Server) BannedPeers() []*BannedPeer {
+	return append(s.reputation.banned(), s.peerSet.denylist()...)
+}
@@ -0,0 +1,101 @@
+package network
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReputation_Score(t *testing.T) {
+	r := newReputation(nil)
+	id := peer.ID("a")
+
+	// the peer is banned once it drops below the threshold
+	assert.False(t, r.report(id, MisbehaviorBadBlock))
+	assert.InDelta(t, float64(-60), r.score(id), 0.1)
+	assert.True(t, r.report(id, MisbehaviorBadBlock))
+	assert.Equal(t, float64(0), r.score(id))
+
+	// the reputation recovers over time
+	r.report(id, MisbehaviorProtocolError)
+	r.scores[id].updated = time.Now().Add(-time.Minute)
+	assert.InDelta(t, float64(-5), r.score(id), 0.1)
+
+	r.scores[id].updated = time.Now().Add(-time.Hour)
+	r.prune()
+	assert.NotContains(t, r.scores, id)
+
+	// the expired bans are removed
+	r.ban(id, time.Now().Add(-time.Second), "expired")
+	assert.False(t, r.isBanned(id))
+	assert.Empty(t, r.banned())
+}
+
+func TestReputation_Ban(t *testing.T) {
+	conf := func(c *Config) {
+		c.NoDiscover = true
+	}
+	srv0 := CreateServer(t, conf)
+	srv1 := CreateServer(t, conf)
+	srv2 := CreateServer(t, conf)
+
+	MultiJoin(t, srv0, srv1, srv0, srv2)
+
+	// srv1 is banned and disconnected once its reputation is too low
+	srv0.ReportPeer(srv1.AddrInfo().ID, MisbehaviorBadBlock, "bad block")
+	assert.False(t, srv0.IsBanned(srv1.AddrInfo().ID))
+	srv0.ReportPeer(srv1.AddrInfo().ID, MisbehaviorBadBlock, "bad block")
+	assert.True(t, srv0.IsBanned(srv1.AddrInfo().ID))
+	assert.True(t, srv0.waitForEvent(5*time.Second, disconnectedPeerHandler(srv1.AddrInfo().ID)))
+	assert.Eventually(t, func() bool {
+		return !srv1.isConnected(srv0.AddrInfo().ID)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Error(t, srv1.Join(srv0.AddrInfo(), 2*time.Second))
+
+	// the trusted peers are not banned
+	assert.NoError(t, srv0.AddTrustedPeer(AddrInfoToString(srv2.AddrInfo())))
+	for i := 0; i < 5; i++ {
+		srv0.ReportPeer(srv2.AddrInfo().ID, MisbehaviorBadBlock, "bad block")
+	}
+	assert.False(t, srv0.IsBanned(srv2.AddrInfo().ID))
+
+	assert.NoError(t, srv0.UnbanPeer(srv1.AddrInfo().ID))
+	assert.NoError(t, srv1.Join(srv0.AddrInfo(), 5*time.Second))
+}
+
+func TestReputation_Denylist(t *testing.T) {
+	dataDir, err := ioutil.TempDir("/tmp", "minimal-denylist-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	conf := func(c *Config) {
+		c.NoDiscover = true
+		c.DataDir = dataDir
+	}
+	srv := CreateServer(t, conf)
+
+	id, err := peer.Decode("16Uiu2HAmJxxH1tScDX2rLGSU9exnuvZKNM9SoK3v315azp68DLPW")
+	assert.NoError(t, err)
+	id1, err := peer.Decode("16Uiu2HAm14xAsnJHDqnQNQ2Qqo1SapdRk9j8mBKY6mghVDP9B9u5")
+	assert.NoError(t, err)
+
+	assert.NoError(t, srv.BanPeer(id, 0, "spam"))
+	assert.NoError(t, srv.BanPeer(id1, time.Hour, "spam"))
+	assert.Len(t, srv.BannedPeers(), 2)
+	assert.NoError(t, srv.Close())
+
+	// only the permanent bans are restored on restart
+	srv = CreateServer(t, conf)
+	defer srv.Close()
+
+	assert.True(t, srv.IsBanned(id))
+	assert.Equal(t, []*BannedPeer{{ID: id, Reason: "spam"}}, srv.BannedPeers())
+
+	assert.NoError(t, srv.UnbanPeer(id))
+	assert.False(t, srv.IsBanned(id))
+}
@@ -4,25 +4,33 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/types"
 	"github.com/hashicorp/go-hclog"
 	"github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p-core/event"
 	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/metrics"
 	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/pnet"
 	"github.com/libp2p/go-libp2p-core/protocol"
 	noise "github.com/libp2p/go-libp2p-noise"
 	peerstore "github.com/libp2p/go-libp2p-peerstore"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
 	"github.com/multiformats/go-multiaddr"
 )
 
 const DefaultLibp2pPort int = 1478
 
+// DefaultDiscoveryInterval is the default interval between the lookups of new peers
+const DefaultDiscoveryInterval = 5 * time.Second
+
 type Config struct {
 	NoDiscover bool
 	Addr       *net.TCPAddr
@@ -30,13 +38,69 @@ type Config struct {
 	DataDir    string
 	MaxPeers   uint64
 	Chain      *chain.Chain
+
+	// GenesisHash is the hash of the genesis block, which prefixes the discovery
+	// protocol so that the nodes of other chains are not discovered
+	GenesisHash types.Hash
+
+	// DiscoveryInterval is the interval between the lookups of new peers
+	DiscoveryInterval time.Duration
+
+	// TargetPeers is the number of peers above which the discovery stops
+	// looking up new peers, MaxPeers if 0
+	TargetPeers uint64
+
+	// StaticPeers are the p2p addresses of the peers always kept connected
+	StaticPeers []string
+
+	// TrustedPeers are the p2p addresses of the peers accepted over the max peers
+	TrustedPeers []string
+
+	// MaxInbound and MaxOutbound are the max number of the peers that dialed the
+	// node and of the peers it dialed, MaxPeers if 0
+	MaxInbound  uint64
+	MaxOutbound uint64
+
+	// BanDuration is the duration of the bans of the peers with a bad reputation
+	BanDuration time.Duration
+
+	// DNSTrees are the enrtree:// urls of the DNS trees of the bootnodes, in
+	// addition to the ones in the bootnodes of the chain
+	DNSTrees []string
+
+	// DNSRefresh is the interval between the resolutions of the DNS trees
+	DNSRefresh time.Duration
+
+	// NatPortMap maps the listen port on the gateway of the NAT with UPnP or NAT-PMP
+	NatPortMap bool
+
+	// NatService dials back the peers that ask with AutoNAT whether they are reachable
+	NatService bool
+
+	// Relays are the p2p addresses of the circuit relays announced if the node is not reachable
+	Relays []string
+
+	// RelayHop relays the connections between other peers
+	RelayHop bool
+
+	// Gossip are the parameters of the gossipsub router and of the peer scoring
+	Gossip *GossipConfig
+
+	// SwarmKey is the pre-shared key of the private network, only the peers with the
+	// same key can connect. If nil, the swarm key of the data directory if any
+	SwarmKey pnet.PSK
 }
 
 func DefaultConfig() *Config {
 	return &Config{
-		NoDiscover: false,
-		Addr:       &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: DefaultLibp2pPort},
-		MaxPeers:   10,
+		NoDiscover:        false,
+		Addr:              &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: DefaultLibp2pPort},
+		MaxPeers:          10,
+		DiscoveryInterval: DefaultDiscoveryInterval,
+		NatService:        true,
+		BanDuration:       DefaultBanDuration,
+		DNSRefresh:        DefaultDNSRefresh,
+		Gossip:            DefaultGossipConfig(),
 	}
 }
 
@@ -46,17 +110,32 @@ type Server struct {
 
 	closeCh chan struct{}
 
-	host  host.Host
-	addrs []multiaddr.Multiaddr
+	host host.Host
+
+	// reachability is the network.Reachability detected by AutoNAT
+	reachability int32
 
 	peers     map[peer.ID]*Peer
 	peersLock sync.Mutex
 
+	// protected reports the peers that are never churned, i.e. the validators. Set with SetProtectedPeers
+	protected func(id peer.ID) bool
+
 	dialQueue *dialQueue
 
 	identity  *identity
 	discovery *discovery
 
+	// peerSet tracks the static, the trusted and the denied peers
+	peerSet *peerSet
+
+	// reputation tracks the misbehaving and the banned peers
+	reputation *reputation
+
+	// bandwidth meters the libp2p streams, and topicBandwidth the messages of the gossip topics
+	bandwidth      *bandwidthCounter
+	topicBandwidth *metrics.BandwidthCounter
+
 	protocols     map[string]Protocol
 	protocolsLock sync.Mutex
 
@@ -73,11 +152,33 @@ type Peer struct {
 	srv *Server
 
 	Info peer.AddrInfo
+
+	// inbound is whether the peer dialed the node
+	inbound bool
+
+	// connectedAt is the time of the handshake
+	connectedAt time.Time
 }
 
 func NewServer(logger hclog.Logger, config *Config) (*Server, error) {
 	logger = logger.Named("network")
 
+	if config.DiscoveryInterval == 0 {
+		config.DiscoveryInterval = DefaultDiscoveryInterval
+	}
+	if config.BanDuration == 0 {
+		config.BanDuration = DefaultBanDuration
+	}
+	if config.DNSRefresh == 0 {
+		config.DNSRefresh = DefaultDNSRefresh
+	}
+	if config.Gossip == nil {
+		config.Gossip = DefaultGossipConfig()
+	}
+	if err := config.Gossip.validate(); err != nil {
+		return nil, fmt.Errorf("invalid gossip config: %v", err)
+	}
+
 	key, err := ReadLibp2pKey(config.DataDir)
 	if err != nil {
 		return nil, err
@@ -100,13 +201,35 @@ func NewServer(logger hclog.Logger, config *Config) (*Server, error) {
 		return addrs
 	}
 
+	natOpts, err := natOptions(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.SwarmKey == nil {
+		if config.SwarmKey, err = readDataDirSwarmKey(config.DataDir); err != nil {
+			return nil, err
+		}
+	}
+	pnetOpts := []libp2p.Option{}
+	if config.SwarmKey != nil {
+		// the connections are encrypted with the key before the handshake of the security protocol
+		pnetOpts = append(pnetOpts, libp2p.PrivateNetwork(config.SwarmKey))
+		logger.Info("private network enabled")
+	}
+
+	bandwidth := newBandwidthCounter()
+
 	host, err := libp2p.New(
 		context.Background(),
-		// Use noise as the encryption protocol
-		libp2p.Security(noise.ID, noise.New),
-		libp2p.ListenAddrs(listenAddr),
-		libp2p.AddrsFactory(addrsFactory),
-		libp2p.Identity(key),
+		append([]libp2p.Option{
+			// Use noise as the encryption protocol
+			libp2p.Security(noise.ID, noise.New),
+			libp2p.ListenAddrs(listenAddr),
+			libp2p.AddrsFactory(addrsFactory),
+			libp2p.Identity(key),
+			libp2p.BandwidthReporter(bandwidth),
+		}, append(natOpts, pnetOpts...)...)...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create libp2p stack: %v", err)
@@ -121,19 +244,34 @@ func NewServer(logger hclog.Logger, config *Config) (*Server, error) {
 		logger:           logger,
 		config:           config,
 		host:             host,
-		addrs:            host.Addrs(),
 		peers:            map[peer.ID]*Peer{},
 		dialQueue:        newDialQueue(),
 		closeCh:          make(chan struct{}),
 		emitterPeerEvent: emitter,
 		protocols:        map[string]Protocol{},
+		bandwidth:        bandwidth,
+		topicBandwidth:   metrics.NewBandwidthCounter(),
 	}
 
+	srv.peerSet = newPeerSet(srv)
+	if err := srv.peerSet.load(); err != nil {
+		return nil, err
+	}
+	srv.reputation = newReputation(srv)
+
 	// start identity
 	srv.identity = &identity{srv: srv}
 	srv.identity.setup()
 
+	if err := srv.trackReachability(); err != nil {
+		return nil, err
+	}
+
 	go srv.runDial()
+	go srv.runChurn()
+	go srv.peerSet.run()
+	go srv.reputation.run()
+	go srv.runBandwidthTrim()
 
 	logger.Info("LibP2P server running", "addr", AddrInfoToString(srv.AddrInfo()))
 
@@ -142,9 +280,14 @@ func NewServer(logger hclog.Logger, config *Config) (*Server, error) {
 		srv.discovery = &discovery{srv: srv}
 		srv.discovery.setup()
 
-		// try to decode the bootnodes
+		// try to decode the bootnodes, the DNS trees are resolved later
 		bootnodes := []*peer.AddrInfo{}
+		dnsTrees := append([]string{}, config.DNSTrees...)
 		for _, raw := range config.Chain.Bootnodes {
+			if IsDNSTree(raw) {
+				dnsTrees = append(dnsTrees, raw)
+				continue
+			}
 			node, err := StringToAddrInfo(raw)
 			if err != nil {
 				return nil, fmt.Errorf("failed to parse bootnode %s: %v", raw, err)
@@ -155,10 +298,20 @@ func NewServer(logger hclog.Logger, config *Config) (*Server, error) {
 		}
 
 		srv.discovery.setBootnodes(bootnodes)
+
+		for _, url := range dnsTrees {
+			if _, err := parseDNSTree(url); err != nil {
+				return nil, fmt.Errorf("failed to parse dns tree %s: %v", url, err)
+			}
+		}
+		if len(dnsTrees) != 0 {
+			go srv.discovery.runDNS(newDNSClient(logger, net.DefaultResolver), dnsTrees)
+		}
 	}
 
 	// start gossip protocol
-	ps, err := pubsub.NewGossipSub(context.Background(), host)
+	setGossipParams(config.Gossip)
+	ps, err := pubsub.NewGossipSub(context.Background(), host, srv.gossipOptions()...)
 	if err != nil {
 		return nil, err
 	}
@@ -195,7 +348,7 @@ func (s *Server) runDial() {
 	}
 
 	for {
-		slots := s.numOpenSlots()
+		slots := s.numOutboundSlots()
 
 		// TODO: Right now the dial task are done sequentially because Connect
 		// is a blocking request. In the future we should try to make up to
@@ -208,7 +361,9 @@ func (s *Server) runDial() {
 			}
 			s.logger.Debug("dial", "local", s.host.ID(), "addr", tt.addr.String())
 
-			if s.isConnected(tt.addr.ID) {
+			if s.IsBanned(tt.addr.ID) {
+				s.logger.Debug("skip banned peer", "addr", tt.addr.String())
+			} else if s.isConnected(tt.addr.ID) {
 				// the node is already connected, send an event to wake up
 				// any join watchers
 				s.emitEvent(&PeerEvent{
@@ -234,9 +389,28 @@ func (s *Server) runDial() {
 	}
 }
 
-// PeerEventDialConnectedNode
+// numPeers returns the number of connected peers that take a slot, the trusted peers do not
 func (s *Server) numPeers() int64 {
-	return int64(len(s.peers))
+	inbound, outbound := s.numPeersByDirection()
+	return inbound + outbound
+}
+
+// numPeersByDirection returns the number of inbound and of outbound peers that take a slot
+func (s *Server) numPeersByDirection() (inbound int64, outbound int64) {
+	s.peersLock.Lock()
+	defer s.peersLock.Unlock()
+
+	for id, p := range s.peers {
+		if s.peerSet.isTrusted(id) {
+			continue
+		}
+		if p.inbound {
+			inbound++
+		} else {
+			outbound++
+		}
+	}
+	return
 }
 
 func (s *Server) Peers() []*Peer {
@@ -258,6 +432,160 @@ func (s *Server) numOpenSlots() int64 {
 	return n
 }
 
+// directionLimit returns the max number of peers of a direction, MaxPeers if not set
+func (s *Server) directionLimit(limit uint64) int64 {
+	if limit == 0 {
+		return int64(s.config.MaxPeers)
+	}
+	return int64(limit)
+}
+
+// numInboundSlots returns the number of peers that can still dial the node
+func (s *Server) numInboundSlots() int64 {
+	inbound, _ := s.numPeersByDirection()
+	n := s.directionLimit(s.config.MaxInbound) - (inbound + s.identity.numPendingInbound())
+	if slots := s.numOpenSlots(); slots < n {
+		n = slots
+	}
+	if n < 0 {
+		n = 0
+	}
+	return n
+}
+
+// numOutboundSlots returns the number of peers the node can still dial
+func (s *Server) numOutboundSlots() int64 {
+	_, outbound := s.numPeersByDirection()
+	pending := s.identity.numPending() - s.identity.numPendingInbound()
+
+	n := s.directionLimit(s.config.MaxOutbound) - (outbound + pending)
+	if slots := s.numOpenSlots(); slots < n {
+		n = slots
+	}
+	if n < 0 {
+		n = 0
+	}
+	return n
+}
+
+// SetProtectedPeers sets the function that reports the peers that are never
+// disconnected by the churn, i.e. the validators of the consensus
+func (s *Server) SetProtectedPeers(protected func(id peer.ID) bool) {
+	s.peersLock.Lock()
+	defer s.peersLock.Unlock()
+
+	s.protected = protected
+}
+
+// churn disconnects the peers over the limits, i.e. once the static peers, which
+// are dialed without a slot, are connected. The peers with the lowest reputation are
+// disconnected first, the most recently connected ones among the peers with the same
+// reputation, and the inbound ones before the outbound ones for MaxPeers. The static,
+// the trusted and the protected peers are never disconnected
+func (s *Server) churn() {
+	var numInbound, numOutbound int64
+	inbound, outbound := []*Peer{}, []*Peer{}
+
+	s.peersLock.Lock()
+	for id, p := range s.peers {
+		if s.peerSet.isTrusted(id) {
+			continue
+		}
+		if p.inbound {
+			numInbound++
+		} else {
+			numOutbound++
+		}
+		if s.peerSet.isStatic(id) || (s.protected != nil && s.protected(id)) {
+			continue
+		}
+		if p.inbound {
+			inbound = append(inbound, p)
+		} else {
+			outbound = append(outbound, p)
+		}
+	}
+	s.peersLock.Unlock()
+
+	byScore := func(peers []*Peer) {
+		scores := map[peer.ID]float64{}
+		for _, p := range peers {
+			scores[p.Info.ID] = s.reputation.score(p.Info.ID)
+		}
+		sort.Slice(peers, func(i, j int) bool {
+			if si, sj := scores[peers[i].Info.ID], scores[peers[j].Info.ID]; si != sj {
+				return si < sj
+			}
+			return peers[i].connectedAt.After(peers[j].connectedAt)
+		})
+	}
+	byScore(inbound)
+	byScore(outbound)
+
+	drop := []*Peer{}
+	for ; numInbound > s.directionLimit(s.config.MaxInbound) && len(inbound) != 0; numInbound-- {
+		drop, inbound = append(drop, inbound[0]), inbound[1:]
+	}
+	for ; numOutbound > s.directionLimit(s.config.MaxOutbound) && len(outbound) != 0; numOutbound-- {
+		drop, outbound = append(drop, outbound[0]), outbound[1:]
+	}
+	for num := numInbound + numOutbound; num > int64(s.config.MaxPeers); num-- {
+		if len(inbound) != 0 {
+			drop, inbound = append(drop, inbound[0]), inbound[1:]
+		} else if len(outbound) != 0 {
+			drop, outbound = append(drop, outbound[0]), outbound[1:]
+		} else {
+			break
+		}
+	}
+
+	for _, p := range drop {
+		s.logger.Debug("churn peer", "id", p.Info.ID, "inbound", p.inbound)
+		s.Disconnect(p.Info.ID, "too many peers")
+	}
+}
+
+// runChurn drops the peers over the limits every time a peer connects
+func (s *Server) runChurn() {
+	err := s.SubscribeFn(func(evnt *PeerEvent) {
+		if evnt.Type == PeerEventConnected {
+			s.churn()
+		}
+	})
+	if err != nil {
+		s.logger.Error("churn failed to subscribe", "err", err)
+	}
+}
+
+// AddStaticPeer adds the peer of the p2p address to the static peers, which are kept connected
+func (s *Server) AddStaticPeer(addr string) error {
+	info, err := StringToAddrInfo(addr)
+	if err != nil {
+		return err
+	}
+	s.host.Peerstore().AddAddr(info.ID, info.Addrs[0], peerstore.PermanentAddrTTL)
+	return s.peerSet.addStatic(info)
+}
+
+// RemoveStaticPeer removes the peer from the static peers, it is not disconnected
+func (s *Server) RemoveStaticPeer(id peer.ID) error {
+	return s.peerSet.removeStatic(id)
+}
+
+// AddTrustedPeer adds the peer of the p2p address to the trusted peers, which are accepted over the max peers
+func (s *Server) AddTrustedPeer(addr string) error {
+	info, err := StringToAddrInfo(addr)
+	if err != nil {
+		return err
+	}
+	return s.peerSet.addTrusted(info)
+}
+
+// RemoveTrustedPeer removes the peer from the trusted peers
+func (s *Server) RemoveTrustedPeer(id peer.ID) error {
+	return s.peerSet.removeTrusted(id)
+}
+
 func (s *Server) isConnected(peerID peer.ID) bool {
 	return s.host.Network().Connectedness(peerID) == network.Connected
 }
@@ -266,19 +594,54 @@ func (s *Server) GetProtocols(peerID peer.ID) ([]string, error) {
 	return s.host.Peerstore().GetProtocols(peerID)
 }
 
+// IsInbound returns whether the connection to the peer was opened by the peer
+func (s *Server) IsInbound(peerID peer.ID) bool {
+	for _, conn := range s.host.Network().ConnsToPeer(peerID) {
+		if conn.Stat().Direction == network.DirInbound {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Server) GetPeerInfo(peerID peer.ID) peer.AddrInfo {
 	return s.host.Peerstore().PeerInfo(peerID)
 }
 
-func (s *Server) addPeer(id peer.ID) {
-	s.logger.Info("Peer connected", "id", id.String())
+// PeerLatency returns the average round trip time of the pings to the peer, zero if it was never pinged
+func (s *Server) PeerLatency(peerID peer.ID) time.Duration {
+	return s.host.Peerstore().LatencyEWMA(peerID)
+}
+
+// Ping pings the peer and returns the round trip time, which is also averaged in the peer latency
+func (s *Server) Ping(ctx context.Context, peerID peer.ID) (time.Duration, error) {
+	res := <-ping.Ping(ctx, s.host, peerID)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	return res.RTT, nil
+}
+
+// GetPeerMetadata returns the identity the peer sent in the handshake
+func (s *Server) GetPeerMetadata(peerID peer.ID) (*PeerMetadata, bool) {
+	metadata, err := s.host.Peerstore().Get(peerID, peerMetadataKey)
+	if err != nil {
+		return nil, false
+	}
+	return metadata.(*PeerMetadata), true
+}
+
+func (s *Server) addPeer(id peer.ID, inbound bool) {
+	s.logger.Info("Peer connected", "id", id.String(), "inbound", inbound)
 
 	s.peersLock.Lock()
 	defer s.peersLock.Unlock()
 
 	p := &Peer{
-		srv:  s,
-		Info: s.host.Peerstore().PeerInfo(id),
+		srv:         s,
+		Info:        s.host.Peerstore().PeerInfo(id),
+		inbound:     inbound,
+		connectedAt: time.Now(),
 	}
 	s.peers[id] = p
 
@@ -448,6 +811,19 @@ func (s *Server) Register(id string, p Protocol) {
 	s.protocolsLock.Unlock()
 }
 
+// capabilities returns the protocols registered by the node
+func (s *Server) capabilities() []string {
+	s.protocolsLock.Lock()
+	defer s.protocolsLock.Unlock()
+
+	caps := make([]string, 0, len(s.protocols))
+	for id := range s.protocols {
+		caps = append(caps, id)
+	}
+	sort.Strings(caps)
+	return caps
+}
+
 func (s *Server) wrapStream(id string, handle func(network.Stream)) {
 	s.host.SetStreamHandler(protocol.ID(id), func(stream network.Stream) {
 		peerID := stream.Conn().RemotePeer()
@@ -457,10 +833,12 @@ func (s *Server) wrapStream(id string, handle func(network.Stream)) {
 	})
 }
 
+// AddrInfo returns the id and the addresses of the node, which include the
+// external addresses discovered through the NAT traversal
 func (s *Server) AddrInfo() *peer.AddrInfo {
 	return &peer.AddrInfo{
 		ID:    s.host.ID(),
-		Addrs: s.addrs,
+		Addrs: s.host.Addrs(),
 	}
 }
 
@@ -478,7 +856,11 @@ type Subscription struct {
 func (s *Subscription) run() {
 	// convert interface{} to *PeerEvent channels
 	for {
-		evnt := <-s.sub.Out()
+		evnt, ok := <-s.sub.Out()
+		if !ok {
+			// the subscription is closed
+			return
+		}
 		if obj, ok := evnt.(PeerEvent); ok {
 			s.ch <- &obj
 		}
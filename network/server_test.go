@@ -1,6 +1,7 @@
 package network
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"testing"
@@ -62,6 +63,91 @@ func TestConnLimit_Outbound(t *testing.T) {
 	assert.True(t, connected)
 }
 
+func TestConnLimit_Direction(t *testing.T) {
+	srv0 := CreateServer(t, func(c *Config) {
+		c.MaxPeers = 3
+		c.MaxInbound = 1
+		c.MaxOutbound = 1
+		c.NoDiscover = true
+	})
+	srv1 := CreateServer(t, nil)
+	srv2 := CreateServer(t, nil)
+	srv3 := CreateServer(t, nil)
+
+	// one inbound slot
+	assert.NoError(t, srv1.Join(srv0.AddrInfo(), 5*time.Second))
+	assert.Error(t, srv2.Join(srv0.AddrInfo(), 5*time.Second))
+
+	// one outbound slot, even if there are open slots
+	assert.NoError(t, srv0.Join(srv3.AddrInfo(), 5*time.Second))
+	assert.Error(t, srv0.Join(srv2.AddrInfo(), 1*time.Second))
+}
+
+func TestConnLimit_Churn(t *testing.T) {
+	conf := func(c *Config) {
+		c.MaxPeers = 1
+		c.NoDiscover = true
+	}
+	srv0 := CreateServer(t, conf)
+	srv1 := CreateServer(t, conf)
+	srv2 := CreateServer(t, conf)
+
+	assert.NoError(t, srv0.Join(srv1.AddrInfo(), 5*time.Second))
+
+	// the static peer is dialed over the max peers, so srv1 is dropped to make room for it
+	assert.NoError(t, srv0.AddStaticPeer(AddrInfoToString(srv2.AddrInfo())))
+	assert.True(t, srv0.waitForEvent(5*time.Second, disconnectedPeerHandler(srv1.AddrInfo().ID)))
+	assert.True(t, srv0.isConnected(srv2.AddrInfo().ID))
+	assert.Equal(t, int64(1), srv0.numPeers())
+}
+
+func TestConnLimit_ChurnReputation(t *testing.T) {
+	conf := func(c *Config) {
+		c.MaxPeers = 2
+		c.NoDiscover = true
+	}
+	srv0 := CreateServer(t, conf)
+	srv1 := CreateServer(t, conf)
+	srv2 := CreateServer(t, conf)
+	srv3 := CreateServer(t, conf)
+
+	assert.NoError(t, srv0.Join(srv1.AddrInfo(), 5*time.Second))
+	assert.NoError(t, srv0.Join(srv2.AddrInfo(), 5*time.Second))
+
+	// srv1 misbehaves, so it is dropped before the most recent peer
+	srv0.ReportPeer(srv1.AddrInfo().ID, MisbehaviorTimeout, "timeout")
+
+	assert.NoError(t, srv0.AddStaticPeer(AddrInfoToString(srv3.AddrInfo())))
+	assert.True(t, srv0.waitForEvent(5*time.Second, disconnectedPeerHandler(srv1.AddrInfo().ID)))
+	assert.True(t, srv0.isConnected(srv2.AddrInfo().ID))
+	assert.True(t, srv0.isConnected(srv3.AddrInfo().ID))
+}
+
+func TestConnLimit_ChurnProtected(t *testing.T) {
+	conf := func(c *Config) {
+		c.MaxPeers = 1
+		c.NoDiscover = true
+	}
+	srv0 := CreateServer(t, conf)
+	srv1 := CreateServer(t, conf)
+	srv2 := CreateServer(t, conf)
+
+	// srv1 is protected, i.e. a validator
+	srv0.SetProtectedPeers(func(id peer.ID) bool {
+		return id == srv1.AddrInfo().ID
+	})
+	assert.NoError(t, srv0.Join(srv1.AddrInfo(), 5*time.Second))
+	srv0.ReportPeer(srv1.AddrInfo().ID, MisbehaviorTimeout, "timeout")
+
+	// it is kept over the max peers
+	assert.NoError(t, srv0.AddStaticPeer(AddrInfoToString(srv2.AddrInfo())))
+	assert.True(t, srv0.waitForEvent(5*time.Second, connectedPeerHandler(srv2.AddrInfo().ID)))
+
+	time.Sleep(500 * time.Millisecond)
+	assert.True(t, srv0.isConnected(srv1.AddrInfo().ID))
+	assert.Equal(t, int64(2), srv0.numPeers())
+}
+
 func TestPeersLifecycle(t *testing.T) {
 	srv0 := CreateServer(t, nil)
 	srv1 := CreateServer(t, nil)
@@ -181,3 +267,21 @@ func TestNat(t *testing.T) {
 		assert.True(t, found)
 	})
 }
+
+func TestPeerLatency(t *testing.T) {
+	conf := func(c *Config) {
+		c.NoDiscover = true
+	}
+	srv0 := CreateServer(t, conf)
+	srv1 := CreateServer(t, conf)
+
+	MultiJoin(t, srv0, srv1)
+
+	// the latency is unknown until the peer is pinged
+	assert.Equal(t, time.Duration(0), srv0.PeerLatency(srv1.AddrInfo().ID))
+
+	rtt, err := srv0.Ping(context.Background(), srv1.AddrInfo().ID)
+	assert.NoError(t, err)
+	assert.NotZero(t, rtt)
+	assert.NotZero(t, srv0.PeerLatency(srv1.AddrInfo().ID))
+}
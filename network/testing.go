@@ -1,6 +1,8 @@
 package network
 
 import (
+	"fmt"
+	"net"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -12,10 +14,21 @@ import (
 
 var initialPort = uint64(2000)
 
+// nextPort returns the next port not in use by another process
+func nextPort() int {
+	for {
+		port := int(atomic.AddUint64(&initialPort, 1))
+		if lis, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port)); err == nil {
+			lis.Close()
+			return port
+		}
+	}
+}
+
 func CreateServer(t *testing.T, callback func(c *Config)) *Server {
 	// create the server
 	cfg := DefaultConfig()
-	cfg.Addr.Port = int(atomic.AddUint64(&initialPort, 1))
+	cfg.Addr.Port = nextPort()
 	cfg.Chain = &chain.Chain{
 		Params: &chain.Params{
 			ChainID: 1,
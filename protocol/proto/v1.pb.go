@@ -557,8 +557,12 @@ var file_protocol_proto_v1_proto_rawDesc = []byte{
 	0x12, 0x2f, 0x0a, 0x06, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x79, 0x12, 0x0d, 0x2e, 0x76, 0x31, 0x2e,
 	0x4e, 0x6f, 0x74, 0x69, 0x66, 0x79, 0x52, 0x65, 0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
 	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74,
-	0x79, 0x42, 0x11, 0x5a, 0x0f, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2f, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x79, 0x32, 0x35, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x2c, 0x0a, 0x0b, 0x47, 0x65,
+	0x74, 0x4e, 0x6f, 0x64, 0x65, 0x44, 0x61, 0x74, 0x61, 0x12, 0x0f, 0x2e, 0x76, 0x31, 0x2e, 0x48,
+	0x61, 0x73, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0c, 0x2e, 0x76, 0x31, 0x2e,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x11, 0x5a, 0x0f, 0x2f, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
 }
 
 var (
@@ -598,12 +602,14 @@ var file_protocol_proto_v1_proto_depIdxs = []int32{
 	3,  // 6: v1.V1.GetObjectsByHash:input_type -> v1.HashRequest
 	2,  // 7: v1.V1.GetHeaders:input_type -> v1.GetHeadersRequest
 	7,  // 8: v1.V1.Notify:input_type -> v1.NotifyReq
-	6,  // 9: v1.V1.GetCurrent:output_type -> v1.V1Status
-	5,  // 10: v1.V1.GetObjectsByHash:output_type -> v1.Response
-	5,  // 11: v1.V1.GetHeaders:output_type -> v1.Response
-	10, // 12: v1.V1.Notify:output_type -> google.protobuf.Empty
-	9,  // [9:13] is the sub-list for method output_type
-	5,  // [5:9] is the sub-list for method input_type
+	3,  // 9: v1.State.GetNodeData:input_type -> v1.HashRequest
+	6,  // 10: v1.V1.GetCurrent:output_type -> v1.V1Status
+	5,  // 11: v1.V1.GetObjectsByHash:output_type -> v1.Response
+	5,  // 12: v1.V1.GetHeaders:output_type -> v1.Response
+	10, // 13: v1.V1.Notify:output_type -> google.protobuf.Empty
+	5,  // 14: v1.State.GetNodeData:output_type -> v1.Response
+	10, // [10:15] is the sub-list for method output_type
+	5,  // [5:10] is the sub-list for method input_type
 	5,  // [5:5] is the sub-list for extension type_name
 	5,  // [5:5] is the sub-list for extension extendee
 	0,  // [0:5] is the sub-list for field type_name
@@ -720,7 +726,7 @@ func file_protocol_proto_v1_proto_init() {
 			NumEnums:      1,
 			NumMessages:   8,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   2,
 		},
 		GoTypes:           file_protocol_proto_v1_proto_goTypes,
 		DependencyIndexes: file_protocol_proto_v1_proto_depIdxs,
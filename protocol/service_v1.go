@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/0xPolygon/minimal/network"
 	"github.com/0xPolygon/minimal/network/grpc"
 	"github.com/0xPolygon/minimal/protocol/proto"
 	"github.com/0xPolygon/minimal/types"
@@ -32,6 +33,7 @@ func (s *serviceV1) Notify(ctx context.Context, req *proto.NotifyReq) (*empty.Em
 
 	b := new(types.Block)
 	if err := b.UnmarshalRLP(req.Raw.Value); err != nil {
+		s.syncer.server.ReportPeer(id, network.MisbehaviorProtocolError, err.Error())
 		return nil, err
 	}
 	s.syncer.enqueueBlock(id, b)
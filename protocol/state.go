@@ -0,0 +1,119 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xPolygon/minimal/network"
+	"github.com/0xPolygon/minimal/protocol/proto"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/hashicorp/go-hclog"
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	libp2pGrpc "github.com/0xPolygon/minimal/network/grpc"
+	rawGrpc "google.golang.org/grpc"
+)
+
+const stateV1 = "/state/0.1"
+
+// maxNodeDataAmount is the max number of trie nodes and codes served in a request
+const maxNodeDataAmount = 384
+
+// NodeDataReader reads the trie nodes and the contract codes by hash
+type NodeDataReader interface {
+	GetNodeData(hash types.Hash) ([]byte, bool)
+}
+
+// StateService serves the trie nodes and the contract codes of the state to the peers
+// and requests the ones missing from the local state to them
+type StateService struct {
+	proto.UnimplementedStateServer
+
+	logger hclog.Logger
+	server *network.Server
+	reader NodeDataReader
+}
+
+// NewStateService creates the state protocol on top of the network server
+func NewStateService(logger hclog.Logger, server *network.Server, reader NodeDataReader) *StateService {
+	return &StateService{
+		logger: logger.Named("state"),
+		server: server,
+		reader: reader,
+	}
+}
+
+// Start registers the state protocol
+func (s *StateService) Start() {
+	grpc := libp2pGrpc.NewGrpcStream()
+	proto.RegisterStateServer(grpc.GrpcServer(), s)
+
+	s.server.Register(stateV1, grpc)
+}
+
+// GetNodeData implements the StateServer interface. Only the first hashes up to the
+// max are served, the others are not even decoded
+func (s *StateService) GetNodeData(ctx context.Context, req *proto.HashRequest) (*proto.Response, error) {
+	if len(req.Hash) > maxNodeDataAmount {
+		req.Hash = req.Hash[:maxNodeDataAmount]
+	}
+	hashes, err := req.DecodeHashes()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &proto.Response{
+		Objs: []*proto.Response_Component{},
+	}
+	for _, hash := range hashes {
+		data, ok := s.reader.GetNodeData(hash)
+		if !ok {
+			data = []byte{}
+		}
+		resp.Objs = append(resp.Objs, &proto.Response_Component{
+			Spec: &any.Any{
+				Value: data,
+			},
+		})
+	}
+	return resp, nil
+}
+
+// RequestNodeData requests the trie nodes and the contract codes to the peer. The entries
+// that the peer does not have are nil, and only the first ones up to the max served are requested
+func (s *StateService) RequestNodeData(ctx context.Context, id peer.ID, hashes []types.Hash) ([][]byte, error) {
+	if len(hashes) > maxNodeDataAmount {
+		hashes = hashes[:maxNodeDataAmount]
+	}
+	conn, err := s.server.NewProtoStream(stateV1, id)
+	if err != nil {
+		return nil, err
+	}
+	clt := conn.(*rawGrpc.ClientConn)
+	defer clt.Close()
+
+	return getNodeData(ctx, proto.NewStateClient(clt), hashes)
+}
+
+func getNodeData(ctx context.Context, clt proto.StateClient, hashes []types.Hash) ([][]byte, error) {
+	input := []string{}
+	for _, h := range hashes {
+		input = append(input, h.String())
+	}
+	resp, err := clt.GetNodeData(ctx, &proto.HashRequest{Hash: input})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Objs) > len(input) {
+		return nil, fmt.Errorf("not correct size")
+	}
+
+	res := make([][]byte, len(input))
+	for i, obj := range resp.Objs {
+		if obj.Spec != nil && len(obj.Spec.Value) != 0 {
+			res[i] = obj.Spec.Value
+		}
+	}
+	return res, nil
+}
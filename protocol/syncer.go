@@ -2,6 +2,7 @@ package protocol
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
@@ -185,7 +186,11 @@ func (s *Syncer) syncCurrentStatus() {
 	// watch the subscription and notify
 	for {
 		select {
-		case evnt := <-eventCh:
+		case evnt, ok := <-eventCh:
+			if !ok {
+				// the subscription is closed
+				return
+			}
 			if evnt.Type == blockchain.EventFork {
 				// we do not want to notify forks
 				continue
@@ -197,8 +202,8 @@ func (s *Syncer) syncCurrentStatus() {
 
 			status := &Status{
 				Difficulty: evnt.Difficulty,
-				Hash:       evnt.NewChain[0].Hash,
-				Number:     evnt.NewChain[0].Number,
+				Hash:       evnt.Header().Hash,
+				Number:     evnt.Header().Number,
 			}
 
 			s.statusLock.Lock()
@@ -323,6 +328,7 @@ func (s *Syncer) HandleUser(peerID peer.ID, conn *grpc.ClientConn) error {
 	}
 	status, err := statusFromProto(rawStatus)
 	if err != nil {
+		s.server.ReportPeer(peerID, network.MisbehaviorProtocolError, err.Error())
 		return err
 	}
 	peer := &syncPeer{
@@ -410,6 +416,13 @@ func (s *Syncer) WatchSyncWithPeer(p *syncPeer, handler func(b *types.Block) boo
 
 		if err := s.blockchain.WriteBlocks([]*types.Block{b}); err != nil {
 			s.logger.Error("failed to write block", "err", err)
+
+			// the peer is only reported for the invalid blocks. A block with an unknown
+			// parent was broadcasted before the node caught up with it, and the rest
+			// of the errors are local failures writing the block
+			if errors.Is(err, blockchain.ErrInvalidBlock) {
+				s.server.ReportPeer(p.peer, network.MisbehaviorBadBlock, err.Error())
+			}
 			break
 		}
 		if !handler(b) {
@@ -451,6 +464,7 @@ func (s *Syncer) BulkSyncWithPeer(p *syncPeer) error {
 			}
 
 			if err := sk.build(p.client, startBlock.Hash); err != nil {
+				s.server.ReportPeer(p.peer, network.MisbehaviorProtocolError, err.Error())
 				return fmt.Errorf("failed to build skeleton: %v", err)
 			}
 
@@ -462,6 +476,9 @@ func (s *Syncer) BulkSyncWithPeer(p *syncPeer) error {
 			// sync the data
 			for _, slot := range sk.slots {
 				if err := s.blockchain.WriteBlocks(slot.blocks); err != nil {
+					if errors.Is(err, blockchain.ErrInvalidBlock) {
+						s.server.ReportPeer(p.peer, network.MisbehaviorBadBlock, err.Error())
+					}
 					return fmt.Errorf("failed to write bulk sync blocks: %v", err)
 				}
 			}
@@ -0,0 +1,51 @@
+package state
+
+import (
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/crypto"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// deployAllowlist decides which txns can deploy contracts
+type deployAllowlist struct {
+	deployers map[types.Address]struct{}
+	registry  *types.Address
+}
+
+func newDeployAllowlist(config *chain.DeployAllowlist) *deployAllowlist {
+	a := &deployAllowlist{
+		deployers: map[types.Address]struct{}{},
+		registry:  config.Registry,
+	}
+	for _, addr := range config.Deployers {
+		a.deployers[addr] = struct{}{}
+	}
+	return a
+}
+
+// canDeploy returns true if the sender of the txn executed is allowed to deploy
+// contracts. The on-chain allowlist is read from the state of the transition
+func (t *Transition) canDeploy() bool {
+	a := t.r.deployAllowlist
+	if a == nil {
+		return true
+	}
+
+	sender := t.ctx.Origin
+	if _, ok := a.deployers[sender]; ok {
+		return true
+	}
+	if a.registry != nil {
+		return t.state.GetState(*a.registry, deployerSlot(sender)) != types.ZeroHash
+	}
+	return false
+}
+
+// deployerSlot returns the storage slot of the deployer in the
+// mapping(address => bool) at the slot 0 of the registry contract
+func deployerSlot(addr types.Address) types.Hash {
+	buf := make([]byte, 64)
+	copy(buf[12:32], addr.Bytes())
+
+	return types.BytesToHash(crypto.Keccak256(buf))
+}
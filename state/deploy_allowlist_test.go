@@ -0,0 +1,50 @@
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutor_DeployAllowlist(t *testing.T) {
+	registry := types.StringToAddress("100")
+
+	executor, root := newTestExecutor(&chain.Params{
+		Forks:   chain.AllForksEnabled,
+		ChainID: 1,
+		DeployAllowlist: &chain.DeployAllowlist{
+			Deployers: []types.Address{addr1},
+			Registry:  &registry,
+		},
+	})
+
+	transition, err := executor.BeginTxn(root, &types.Header{GasLimit: 1000000})
+	assert.NoError(t, err)
+
+	create := func(from types.Address) types.ReceiptStatus {
+		txn := &types.Transaction{
+			From:     from,
+			Nonce:    transition.Txn().GetNonce(from),
+			Value:    big.NewInt(0),
+			Gas:      100000,
+			GasPrice: big.NewInt(0),
+		}
+		assert.NoError(t, transition.Write(txn))
+
+		receipts := transition.Receipts()
+		return *receipts[len(receipts)-1].Status
+	}
+
+	assert.Equal(t, types.ReceiptSuccess, create(addr1))
+
+	// the creation fails but the txn is included
+	assert.Equal(t, types.ReceiptFailed, create(addr2))
+	assert.Equal(t, uint64(1), transition.Txn().GetNonce(addr2))
+
+	// the deployer is allowed once it is set in the registry
+	transition.Txn().SetState(registry, deployerSlot(addr2), types.BytesToHash([]byte{1}))
+	assert.Equal(t, types.ReceiptSuccess, create(addr2))
+}
@@ -1,8 +1,11 @@
 package state
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 
 	"github.com/0xPolygon/minimal/types"
 
@@ -11,14 +14,36 @@ import (
 	"github.com/0xPolygon/minimal/state/runtime"
 )
 
-const (
-	spuriousDragonMaxCodeSize = 24576
-)
-
 var (
-	errorVMOutOfGas = fmt.Errorf("out of gas")
+	// ErrIntrinsicGas is returned if the gas of the txn does not cover its intrinsic gas
+	ErrIntrinsicGas = fmt.Errorf("intrinsic gas too low")
+
+	// ErrTxTypeNotSupported is returned for the typed txns (eip-2718) before the berlin fork
+	ErrTxTypeNotSupported = fmt.Errorf("transaction type not supported")
+
+	// ErrInvalidBlock is matched with errors.Is by the errors of the blocks that fail
+	// the validation, as opposed to the local failures processing them
+	ErrInvalidBlock = errors.New("invalid block")
 )
 
+// invalidBlockError is the error of a block that failed the validation
+type invalidBlockError struct {
+	err error
+}
+
+// InvalidBlock marks the error of a block as a validation failure, which matches ErrInvalidBlock
+func InvalidBlock(err error) error {
+	return &invalidBlockError{err}
+}
+
+func (e *invalidBlockError) Error() string {
+	return e.err.Error()
+}
+
+func (e *invalidBlockError) Is(target error) bool {
+	return target == ErrInvalidBlock
+}
+
 var emptyCodeHashTwo = types.BytesToHash(crypto.Keccak256(nil))
 
 // GetHashByNumber returns the hash function of a block number
@@ -26,30 +51,65 @@ type GetHashByNumber = func(i uint64) types.Hash
 
 type GetHashByNumberHelper = func(*types.Header) GetHashByNumber
 
+// senderCacheSize is the number of recovered senders cached by the executor
+const senderCacheSize = 4096
+
 // Executor is the main entity
 type Executor struct {
 	config   *chain.Params
 	runtimes []runtime.Runtime
 	state    State
+	senders  *crypto.SenderCache
 	GetHash  GetHashByNumberHelper
 
 	PostHook func(txn *Transition)
+
+	// hooks of the txns, see AddPreTxHook and AddPostTxHook
+	preTxHooks  []PreTxHook
+	postTxHooks []PostTxHook
+
+	// deployAllowlist restricts the contract deployments, disabled if nil
+	deployAllowlist *deployAllowlist
+
+	// prefetchWorkers is the number of goroutines that prefetch the state of the blocks
+	prefetchWorkers int
+
+	metrics *Metrics
 }
 
 // NewExecutor creates a new executor
 func NewExecutor(config *chain.Params, s State) *Executor {
-	return &Executor{
+	// the size of the cache is valid
+	senders, _ := crypto.NewSenderCache(senderCacheSize)
+
+	e := &Executor{
 		config:   config,
 		runtimes: []runtime.Runtime{},
 		state:    s,
+		senders:  senders,
+		metrics:  newMetrics(),
 	}
+	if config.DeployAllowlist != nil {
+		e.deployAllowlist = newDeployAllowlist(config.DeployAllowlist)
+	}
+	return e
+}
+
+// SenderCache returns the cache of the senders recovered by the executor, which
+// can be filled ahead of the execution, i.e. with the transactions of the pool
+func (e *Executor) SenderCache() *crypto.SenderCache {
+	return e.senders
 }
 
-func (e *Executor) WriteGenesis(alloc map[types.Address]*chain.GenesisAccount) types.Hash {
+// WriteGenesis writes the alloc of the genesis and returns its state root. The init code of
+// the accounts is executed with the genesis as the block once the rest of the alloc is written,
+// in the order of the addresses, and the code returned is deployed at the account address
+func (e *Executor) WriteGenesis(genesis *chain.Genesis) (types.Hash, error) {
 	snap := e.state.NewSnapshot()
 	txn := NewTxn(e.state, snap)
 
-	for addr, account := range alloc {
+	deploys := []types.Address{}
+	for addr, account := range genesis.Alloc {
 		if account.Balance != nil {
 			txn.AddBalance(addr, account.Balance)
 		}
@@ -62,10 +122,87 @@ func (e *Executor) WriteGenesis(alloc map[types.Address]*chain.GenesisAccount) t
 		for key, value := range account.Storage {
 			txn.SetState(addr, key, value)
 		}
+		if len(account.InitCode) != 0 {
+			deploys = append(deploys, addr)
+		}
+	}
+
+	if len(deploys) != 0 {
+		sort.Slice(deploys, func(i, j int) bool {
+			return bytes.Compare(deploys[i].Bytes(), deploys[j].Bytes()) < 0
+		})
+
+		transition := e.genesisTransition(genesis, txn)
+		for _, addr := range deploys {
+			if err := transition.deployGenesis(addr, genesis.Alloc[addr]); err != nil {
+				return types.Hash{}, fmt.Errorf("failed to deploy the genesis contract %s: %v", addr, err)
+			}
+		}
 	}
 
-	_, root := txn.Commit(false)
-	return types.BytesToHash(root)
+	_, root, err := txn.Commit(false)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return types.BytesToHash(root), nil
+}
+
+// genesisTransition returns a transition on top of the txn with the genesis as the block
+func (e *Executor) genesisTransition(genesis *chain.Genesis, txn *Txn) *Transition {
+	header := genesis.GenesisHeader()
+
+	ctx := runtime.TxContext{
+		Coinbase:   header.Miner,
+		Timestamp:  int64(header.Timestamp),
+		Number:     int64(header.Number),
+		Difficulty: types.BytesToHash(new(big.Int).SetUint64(header.Difficulty).Bytes()),
+		GasLimit:   int64(header.GasLimit),
+		ChainID:    int64(e.config.ChainID),
+	}
+
+	var config chain.ForksInTime
+	if e.config.Forks != nil {
+		config = e.config.Forks.At(header.Number)
+	}
+
+	return &Transition{
+		r:     e,
+		ctx:   ctx,
+		state: txn,
+		getHash: func(uint64) types.Hash {
+			// there are no blocks before the genesis
+			return types.Hash{}
+		},
+		auxState: e.state,
+		config:   config,
+		gasPool:  header.GasLimit,
+		receipts: []*types.Receipt{},
+	}
+}
+
+// deployGenesis executes the init code of the genesis account, with the constructor arguments,
+// and sets the code returned as the code of the account. The creation is sent by the zero
+// address without value and with the gas limit of the genesis
+func (t *Transition) deployGenesis(addr types.Address, account *chain.GenesisAccount) error {
+	code := append(append([]byte{}, account.InitCode...), account.ConstructorArgs...)
+	contract := runtime.NewContractCreation(1, types.ZeroAddress, types.ZeroAddress, addr, big.NewInt(0), t.gasPool, code)
+
+	if t.config.EIP158 && t.state.GetNonce(addr) == 0 {
+		// the contracts start with nonce 1, as if they were created by a txn
+		t.state.IncrNonce(addr)
+	}
+
+	ret, _, err := t.run(contract, t)
+	if err == runtime.ErrExecutionReverted {
+		if reason, rErr := runtime.UnpackRevertReason(ret); rErr == nil {
+			return fmt.Errorf("%v: %s", err, reason)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	t.state.SetCode(addr, ret)
+	return nil
 }
 
 // SetRuntime adds a runtime to the runtime set
@@ -81,18 +218,40 @@ type BlockResult struct {
 
 // ProcessBlock already does all the handling of the whole process, TODO
 func (e *Executor) ProcessBlock(parentRoot types.Hash, block *types.Block) (*BlockResult, error) {
+	stats := e.startBlock()
+
 	txn, err := e.BeginTxn(parentRoot, block.Header)
 	if err != nil {
 		return nil, err
 	}
 
+	// recover the senders in parallel ahead of the execution. The cache is filled
+	// with the eip155 signer, so it is not used by the blocks before the fork
+	signer := crypto.NewSigner(txn.config, uint64(e.config.ChainID))
+	senders := e.senders
+	if !txn.config.EIP155 {
+		senders = nil
+	}
+	if err := crypto.RecoverSenders(signer, block.Transactions, senders); err != nil {
+		return nil, InvalidBlock(err)
+	}
+
+	// read the state of the txns ahead of their execution
+	abort := make(chan struct{})
+	defer close(abort)
+	go e.prefetch(parentRoot, block.Transactions, abort)
+
 	txn.block = block
 	for _, t := range block.Transactions {
 		if err := txn.Write(t); err != nil {
-			return nil, err
+			return nil, InvalidBlock(err)
 		}
 	}
-	_, root := txn.Commit()
+	_, root, err := txn.Commit()
+	if err != nil {
+		return nil, err
+	}
+	e.observeBlock(stats, txn, len(block.Transactions))
 
 	res := &BlockResult{
 		Root:     root,
@@ -102,6 +261,79 @@ func (e *Executor) ProcessBlock(parentRoot types.Hash, block *types.Block) (*Blo
 	return res, nil
 }
 
+// TraceTxn replays the txns of the block on top of the parent state up to the txn at the
+// index, which is executed with the tracer. It returns the return value of the txn, the
+// gas used and whether it failed
+func (e *Executor) TraceTxn(parentRoot types.Hash, block *types.Block, index int, tracer runtime.Tracer) ([]byte, uint64, bool, error) {
+	if index < 0 || index >= len(block.Transactions) {
+		return nil, 0, false, fmt.Errorf("txn %d not found in block %d", index, block.Number())
+	}
+
+	txn, err := e.BeginTxn(parentRoot, block.Header)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	signer := crypto.NewSigner(txn.config, uint64(e.config.ChainID))
+	if err := crypto.RecoverSenders(signer, block.Transactions[:index+1], nil); err != nil {
+		return nil, 0, false, err
+	}
+
+	txn.block = block
+	for _, t := range block.Transactions[:index] {
+		if err := txn.Write(t); err != nil {
+			return nil, 0, false, err
+		}
+	}
+
+	gasUsed, failed, err := txn.Trace(block.Transactions[index], tracer)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return txn.ReturnValue(), gasUsed, failed, nil
+}
+
+// TraceResult is the result of a txn traced
+type TraceResult struct {
+	ReturnValue []byte
+	GasUsed     uint64
+	Failed      bool
+}
+
+// TraceBlock replays the txns of the block on top of the state of its parent, each one
+// with its tracer. The txns are applied on the same state one on top of the other, so the
+// block is executed once
+func (e *Executor) TraceBlock(parentRoot types.Hash, block *types.Block, tracers []runtime.Tracer) ([]*TraceResult, error) {
+	if len(tracers) != len(block.Transactions) {
+		return nil, fmt.Errorf("%d tracers for %d txns", len(tracers), len(block.Transactions))
+	}
+
+	txn, err := e.BeginTxn(parentRoot, block.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	signer := crypto.NewSigner(txn.config, uint64(e.config.ChainID))
+	if err := crypto.RecoverSenders(signer, block.Transactions, nil); err != nil {
+		return nil, err
+	}
+
+	txn.block = block
+	res := make([]*TraceResult, 0, len(block.Transactions))
+	for i, t := range block.Transactions {
+		gasUsed, failed, err := txn.TraceWrite(t, tracers[i])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &TraceResult{
+			ReturnValue: append([]byte{}, txn.ReturnValue()...),
+			GasUsed:     gasUsed,
+			Failed:      failed,
+		})
+	}
+	return res, nil
+}
+
 // StateAt returns snapshot at given root
 func (e *Executor) State() State {
 	return e.state
@@ -113,14 +345,19 @@ func (e *Executor) StateAt(root types.Hash) (Snapshot, error) {
 }
 
 func (e *Executor) BeginTxn(parentRoot types.Hash, header *types.Header) (*Transition, error) {
+	return e.beginTxn(e.state, parentRoot, header)
+}
+
+// beginTxn begins the transition of the header on top of the given state
+func (e *Executor) beginTxn(s State, parentRoot types.Hash, header *types.Header) (*Transition, error) {
 	config := e.config.Forks.At(header.Number)
 
-	auxSnap2, err := e.state.NewSnapshotAt(parentRoot)
+	auxSnap2, err := s.NewSnapshotAt(parentRoot)
 	if err != nil {
 		return nil, err
 	}
 
-	newTxn := NewTxn(e.state, auxSnap2)
+	newTxn := NewTxn(s, auxSnap2)
 
 	env2 := runtime.TxContext{
 		Coinbase:   header.Miner,
@@ -136,7 +373,7 @@ func (e *Executor) BeginTxn(parentRoot types.Hash, header *types.Header) (*Trans
 		ctx:      env2,
 		state:    newTxn,
 		getHash:  e.GetHash(header),
-		auxState: e.state,
+		auxState: s,
 		config:   config,
 		gasPool:  uint64(env2.GasLimit),
 
@@ -164,14 +401,30 @@ type Transition struct {
 	receipts []*types.Receipt
 	totalGas uint64
 
+	// number of storage reads and writes of the txns
+	sloads  uint64
+	sstores uint64
+
 	// The return value for the contract execution
 	returnValue []byte
+
+	// the error of the last execution, nil if it succeeded
+	execErr error
+
+	// tracer of the executions, nil if they are not traced
+	tracer runtime.Tracer
 }
 
 func (t *Transition) ReturnValue() []byte {
 	return t.returnValue
 }
 
+// ExecutionError returns the error that made the last txn fail, nil if it succeeded. The
+// return value is the revert data if the error is runtime.ErrExecutionReverted
+func (t *Transition) ExecutionError() error {
+	return t.execErr
+}
+
 func (t *Transition) TotalGas() uint64 {
 	return t.totalGas
 }
@@ -184,51 +437,45 @@ var emptyFrom = types.Address{}
 
 // Write writes another transaction to the executor
 func (t *Transition) Write(txn *types.Transaction) error {
-	signer := crypto.NewSigner(t.config, uint64(t.r.config.ChainID))
+	_, _, err := t.write(txn)
+	return err
+}
 
+// write writes the txn and returns the gas used and whether it failed
+func (t *Transition) write(txn *types.Transaction) (uint64, bool, error) {
 	var err error
 	if txn.From == emptyFrom {
 		// Decrypt the from address
+		signer := crypto.NewSigner(t.config, uint64(t.r.config.ChainID))
 		txn.From, err = signer.Sender(txn)
 		if err != nil {
-			return err
+			return 0, false, err
 		}
 	}
 
 	// Make a local copy and apply the transaction
 	msg := txn.Copy()
 
+	snap := t.Snapshot()
 	gasUsed, failed, err := t.Apply(msg)
 	if err != nil {
-		fmt.Printf("Apply err: %v", err)
+		// the transaction cannot be included in the block
+		return 0, false, err
 	}
-	t.totalGas += gasUsed
-
-	logs := t.state.Logs()
-
-	var root []byte
 
 	receipt := &types.Receipt{
-		CumulativeGasUsed: t.totalGas,
+		CumulativeGasUsed: t.totalGas + gasUsed,
 		TxHash:            txn.Hash,
+		TxType:            txn.Type,
 		GasUsed:           gasUsed,
 	}
 
 	if t.config.Byzantium {
-		// The suicided accounts are set as deleted for the next iteration
-		t.state.CleanDeleteObjects(true)
-
 		if failed {
 			receipt.SetStatus(types.ReceiptFailed)
 		} else {
 			receipt.SetStatus(types.ReceiptSuccess)
 		}
-
-	} else {
-		ss, aux := t.state.Commit(t.config.EIP155)
-		t.state = NewTxn(t.auxState, ss)
-		root = aux
-		receipt.Root = types.BytesToHash(root)
 	}
 
 	// if the transaction created a contract, store the creation address in the receipt.
@@ -236,19 +483,81 @@ func (t *Transition) Write(txn *types.Transaction) error {
 		receipt.ContractAddress = crypto.CreateAddress(msg.From, txn.Nonce)
 	}
 
+	logs := t.state.Logs()
+	receipt.Logs = buildLogs(logs, txn.Hash, types.Hash{}, uint(len(t.receipts)))
+	if err := t.runPostTxHooks(msg, receipt); err != nil {
+		// the transaction is not included, its gas is returned to the pool
+		t.RevertToSnapshot(snap)
+		return 0, false, err
+	}
+	t.totalGas += gasUsed
+
+	// the post hooks might have emitted logs too
+	logs = append(logs, t.state.Logs()...)
+
+	if t.config.Byzantium {
+		// The suicided accounts are set as deleted for the next iteration
+		t.state.CleanDeleteObjects(true)
+	} else {
+		ss, root, err := t.state.Commit(t.config.EIP155)
+		if err != nil {
+			return 0, false, err
+		}
+		t.state = NewTxn(t.auxState, ss)
+		receipt.Root = types.BytesToHash(root)
+	}
+
 	// Set the receipt logs and create a bloom for filtering
 	receipt.Logs = buildLogs(logs, txn.Hash, types.Hash{}, uint(len(t.receipts)))
 	receipt.LogsBloom = types.CreateBloom([]*types.Receipt{receipt})
 	t.receipts = append(t.receipts, receipt)
 
-	return nil
+	return gasUsed, failed, nil
 }
 
-// Commit commits the final result
-func (t *Transition) Commit() (Snapshot, types.Hash) {
-	s2, root := t.state.Commit(t.config.EIP155)
+// TransitionSnapshot is a revert point of a transition, see Transition.Snapshot
+type TransitionSnapshot struct {
+	// state is the txn of the state at the snapshot, since the txn is
+	// replaced once each transaction is written before byzantium
+	state *Txn
+	id    int
+
+	receipts int
+	totalGas uint64
+	gasPool  uint64
+}
+
+// Snapshot returns a revert point of the transition. The block builders can drop the
+// transactions written after it, i.e. a transaction that fails once written, and keep
+// writing other transactions on top of the transition
+func (t *Transition) Snapshot() *TransitionSnapshot {
+	return &TransitionSnapshot{
+		state:    t.state,
+		id:       t.state.Snapshot(),
+		receipts: len(t.receipts),
+		totalGas: t.totalGas,
+		gasPool:  t.gasPool,
+	}
+}
 
-	return s2, types.BytesToHash(root)
+// RevertToSnapshot reverts the state changes, the receipts and the gas of the transactions
+// written after the snapshot. The snapshots taken after it cannot be used anymore
+func (t *Transition) RevertToSnapshot(snap *TransitionSnapshot) {
+	t.state = snap.state
+	t.state.RevertToSnapshot(snap.id)
+
+	t.receipts = t.receipts[:snap.receipts]
+	t.totalGas = snap.totalGas
+	t.gasPool = snap.gasPool
+}
+
+// Commit commits the final result
+func (t *Transition) Commit() (Snapshot, types.Hash, error) {
+	s2, root, err := t.state.Commit(t.config.EIP155)
+	if err != nil {
+		return nil, types.Hash{}, err
+	}
+	return s2, types.BytesToHash(root), nil
 }
 
 // Block rewards at different forks
@@ -306,7 +615,17 @@ func (t *Transition) GetTxnHash() types.Hash {
 // Apply applies a new transaction
 func (t *Transition) Apply(msg *types.Transaction) (uint64, bool, error) {
 	s := t.state.Snapshot()
-	returnValue, gas, failed, err := t.apply(msg)
+	t.execErr = nil
+
+	var (
+		returnValue []byte
+		gas         uint64
+		failed      bool
+	)
+	err := t.runPreTxHooks(msg)
+	if err == nil {
+		returnValue, gas, failed, err = t.apply(msg)
+	}
 	if err != nil {
 		t.state.RevertToSnapshot(s)
 	}
@@ -353,10 +672,20 @@ func (t *Transition) transactionGasCost(msg *types.Transaction) uint64 {
 		cost += uint64(nonZeros) * nonZeroCost
 	}
 
+	// EIP-2930: the addresses and slots in the access list are paid upfront
+	if t.config.Berlin && len(msg.AccessList) > 0 {
+		cost += uint64(len(msg.AccessList)) * 2400
+		cost += uint64(msg.AccessList.StorageKeys()) * 1900
+	}
+
 	return uint64(cost)
 }
 
 func (t *Transition) preCheck(msg *types.Transaction) (uint64, error) {
+	if msg.IsTyped() && !t.config.Berlin {
+		return 0, ErrTxTypeNotSupported
+	}
+
 	// validate nonce
 	nonce := t.state.GetNonce(msg.From)
 	if nonce < msg.Nonce {
@@ -382,6 +711,38 @@ func (t *Transition) preCheck(msg *types.Transaction) (uint64, error) {
 	return gasAvailable, nil
 }
 
+// PrecompileAddresses are the addresses of the precompiled contracts at the berlin
+// fork, which are always in the access list of the txns
+var PrecompileAddresses = []types.Address{
+	types.StringToAddress("1"),
+	types.StringToAddress("2"),
+	types.StringToAddress("3"),
+	types.StringToAddress("4"),
+	types.StringToAddress("5"),
+	types.StringToAddress("6"),
+	types.StringToAddress("7"),
+	types.StringToAddress("8"),
+	types.StringToAddress("9"),
+}
+
+// prepareAccessList adds to the access list of the txn the sender, the receiver, the
+// precompiled contracts and the addresses and slots of the access list of the msg (eip-2929)
+func (t *Transition) prepareAccessList(msg *types.Transaction) {
+	t.state.AddAddressToAccessList(msg.From)
+	if msg.To != nil {
+		t.state.AddAddressToAccessList(*msg.To)
+	}
+	for _, addr := range PrecompileAddresses {
+		t.state.AddAddressToAccessList(addr)
+	}
+	for _, tuple := range msg.AccessList {
+		t.state.AddAddressToAccessList(tuple.Address)
+		for _, slot := range tuple.StorageKeys {
+			t.state.AddSlotToAccessList(tuple.Address, slot)
+		}
+	}
+}
+
 func (t *Transition) apply(msg *types.Transaction) ([]byte, uint64, bool, error) {
 	// check if there is enough gas in the pool
 	if err := t.subGasPool(msg.Gas); err != nil {
@@ -393,10 +754,12 @@ func (t *Transition) apply(msg *types.Transaction) ([]byte, uint64, bool, error)
 
 	gas, err := t.preCheck(msg)
 	if err != nil {
+		t.addGasPool(msg.Gas)
 		return nil, 0, false, err
 	}
 	if gas > msg.Gas {
-		return nil, 0, false, errorVMOutOfGas
+		t.addGasPool(msg.Gas)
+		return nil, 0, false, ErrIntrinsicGas
 	}
 
 	gasPrice := new(big.Int).Set(msg.GasPrice)
@@ -406,12 +769,21 @@ func (t *Transition) apply(msg *types.Transaction) ([]byte, uint64, bool, error)
 	t.ctx.GasPrice = types.BytesToHash(gasPrice.Bytes())
 	t.ctx.Origin = msg.From
 
+	if t.config.Berlin {
+		t.prepareAccessList(msg)
+	}
+
 	var subErr error
 	var gasLeft uint64
 	var returnValue []byte
 
 	if msg.IsContractCreation() {
-		_, gasLeft, subErr = t.Create2(msg.From, msg.Input, value, gas)
+		var ret []byte
+		ret, gasLeft, subErr = t.Create2(msg.From, msg.Input, value, gas)
+		if subErr == runtime.ErrExecutionReverted {
+			// the return value of a creation is only the revert data
+			returnValue = ret
+		}
 	} else {
 		txn.IncrNonce(msg.From)
 		returnValue, gasLeft, subErr = t.Call2(msg.From, *msg.To, msg.Input, value, gas)
@@ -421,6 +793,7 @@ func (t *Transition) apply(msg *types.Transaction) ([]byte, uint64, bool, error)
 
 		if subErr == runtime.ErrNotEnoughFunds {
 			txn.RevertToSnapshot(s)
+			t.addGasPool(msg.Gas)
 			return nil, 0, false, subErr
 		}
 	}
@@ -445,18 +818,23 @@ func (t *Transition) apply(msg *types.Transaction) ([]byte, uint64, bool, error)
 	// return gas to the pool
 	t.addGasPool(gasLeft)
 
+	t.execErr = subErr
 	return returnValue, gasUsed, subErr != nil, nil
 }
 
 func (t *Transition) Create2(caller types.Address, code []byte, value *big.Int, gas uint64) ([]byte, uint64, error) {
 	address := crypto.CreateAddress(caller, t.state.GetNonce(caller))
 	contract := runtime.NewContractCreation(1, caller, caller, address, value, gas, code)
-	return t.applyCreate(contract, t)
+	return t.captureCall(contract, runtime.Create, func() ([]byte, uint64, error) {
+		return t.applyCreate(contract, t)
+	})
 }
 
 func (t *Transition) Call2(caller types.Address, to types.Address, input []byte, value *big.Int, gas uint64) ([]byte, uint64, error) {
 	c := runtime.NewContractCall(1, caller, caller, to, value, gas, t.state.GetCode(to), input)
-	return t.applyCall(c, runtime.Call, t)
+	return t.captureCall(c, runtime.Call, func() ([]byte, uint64, error) {
+		return t.applyCall(c, runtime.Call, t)
+	})
 }
 
 func (t *Transition) run(contract *runtime.Contract, host runtime.Host) ([]byte, uint64, error) {
@@ -498,7 +876,16 @@ func (t *Transition) applyCall(c *runtime.Contract, callType runtime.CallType, h
 		}
 	}
 
-	ret, gas, err := t.run(c, host)
+	var (
+		ret []byte
+		gas uint64
+		err error
+	)
+	if t.isNativeMinterCall(c) {
+		ret, gas, err = t.runNativeMinter(c, callType)
+	} else {
+		ret, gas, err = t.run(c, host)
+	}
 	if err != nil {
 		t.state.RevertToSnapshot(snapshot)
 	}
@@ -529,11 +916,21 @@ func (t *Transition) applyCreate(msg *runtime.Contract, host runtime.Host) ([]by
 	// Incremene the nonce of the caller
 	t.state.IncrNonce(msg.Caller)
 
+	if t.config.Berlin {
+		// the created address is warm even if the creation fails
+		t.state.AddAddressToAccessList(msg.Address)
+	}
+
 	// Check if there if there is a collision and the address already exists
 	if t.hasCodeOrNonce(msg.Address) {
 		return nil, 0, runtime.ErrContractAddressCollision
 	}
 
+	// the creations of the txns not sent by an allowed deployer fail
+	if !t.canDeploy() {
+		return nil, 0, runtime.ErrDeployNotAllowed
+	}
+
 	// Take snapshot of the current state
 	snapshot := t.state.Snapshot()
 
@@ -555,8 +952,8 @@ func (t *Transition) applyCreate(msg *runtime.Contract, host runtime.Host) ([]by
 		return code, gas, err
 	}
 
-	if t.config.EIP158 && len(code) > spuriousDragonMaxCodeSize {
-		// Contract size exceeds 'SpuriousDragon' size limit
+	if t.config.EIP158 && uint64(len(code)) > t.r.config.MaxCodeSize(uint64(t.ctx.Number)) {
+		// Contract size exceeds the size limit of the chain
 		t.state.RevertToSnapshot(snapshot)
 		return nil, 0, runtime.ErrMaxCodeSizeExceeded
 	}
@@ -579,6 +976,7 @@ func (t *Transition) applyCreate(msg *runtime.Contract, host runtime.Host) ([]by
 }
 
 func (t *Transition) SetStorage(addr types.Address, key types.Hash, value types.Hash, config *chain.ForksInTime) runtime.StorageStatus {
+	t.sstores++
 	return t.state.SetStorage(addr, key, value, config)
 }
 
@@ -611,6 +1009,7 @@ func (t *Transition) GetBalance(addr types.Address) *big.Int {
 }
 
 func (t *Transition) GetStorage(addr types.Address, key types.Hash) types.Hash {
+	t.sloads++
 	return t.state.GetState(addr, key)
 }
 
@@ -634,9 +1033,102 @@ func (t *Transition) Selfdestruct(addr types.Address, beneficiary types.Address)
 	t.state.Suicide(addr)
 }
 
-func (t *Transition) Callx(c *runtime.Contract, h runtime.Host) ([]byte, uint64, error) {
-	if c.Type == runtime.Create {
-		return t.applyCreate(c, h)
+// Trace applies the txn with the tracer. The tracers that capture the prestate
+// are called with the state before the txn once it is executed, so the txn is
+// not kept in the state of the transition
+func (t *Transition) Trace(msg *types.Transaction, tracer runtime.Tracer) (uint64, bool, error) {
+	t.tracer = tracer
+	defer func() {
+		t.tracer = nil
+	}()
+
+	s := t.state.Snapshot()
+	gas, failed, err := t.Apply(msg)
+	if err != nil {
+		return 0, false, err
 	}
-	return t.applyCall(c, c.Type, h)
+
+	if capturer, ok := tracer.(runtime.PrestateCapturer); ok {
+		t.state.RevertToSnapshot(s)
+		capturer.CapturePrestate(t)
+	}
+	return gas, failed, nil
+}
+
+// TraceWrite writes the txn with the tracer, so the next txns are applied on top of it.
+// The tracers that capture the prestate are called with the state before the txn, thus
+// the txn is traced first and then written without the tracer
+func (t *Transition) TraceWrite(txn *types.Transaction, tracer runtime.Tracer) (uint64, bool, error) {
+	if _, ok := tracer.(runtime.PrestateCapturer); ok {
+		gas, failed, err := t.Trace(txn.Copy(), tracer)
+		if err != nil {
+			return 0, false, err
+		}
+		returnValue := t.returnValue
+
+		// the gas of the traced txn is returned to the pool before it is written
+		t.addGasPool(gas)
+		if _, _, err := t.write(txn); err != nil {
+			return 0, false, err
+		}
+		t.returnValue = returnValue
+		return gas, failed, nil
+	}
+
+	t.tracer = tracer
+	defer func() {
+		t.tracer = nil
+	}()
+	return t.write(txn)
+}
+
+// captureCall notifies the tracer, if any, of the call or create of the contract
+func (t *Transition) captureCall(c *runtime.Contract, callType runtime.CallType, fn func() ([]byte, uint64, error)) ([]byte, uint64, error) {
+	if t.tracer == nil {
+		return fn()
+	}
+
+	input := c.Input
+	if callType.IsCreate() {
+		input = c.Code
+	}
+	t.tracer.CaptureEnter(callType, c.Caller, c.CodeAddress, input, c.Gas, c.Value)
+
+	ret, gas, err := fn()
+	t.tracer.CaptureExit(ret, gas, err)
+	return ret, gas, err
+}
+
+// AddressInAccessList implements the runtime.Host interface
+func (t *Transition) AddressInAccessList(addr types.Address) bool {
+	return t.state.AddressInAccessList(addr)
+}
+
+// SlotInAccessList implements the runtime.Host interface
+func (t *Transition) SlotInAccessList(addr types.Address, slot types.Hash) bool {
+	return t.state.SlotInAccessList(addr, slot)
+}
+
+// AddAddressToAccessList implements the runtime.Host interface
+func (t *Transition) AddAddressToAccessList(addr types.Address) {
+	t.state.AddAddressToAccessList(addr)
+}
+
+// AddSlotToAccessList implements the runtime.Host interface
+func (t *Transition) AddSlotToAccessList(addr types.Address, slot types.Hash) {
+	t.state.AddSlotToAccessList(addr, slot)
+}
+
+// GetTracer implements the runtime.Host interface
+func (t *Transition) GetTracer() runtime.Tracer {
+	return t.tracer
+}
+
+func (t *Transition) Callx(c *runtime.Contract, h runtime.Host) ([]byte, uint64, error) {
+	return t.captureCall(c, c.Type, func() ([]byte, uint64, error) {
+		if c.Type.IsCreate() {
+			return t.applyCreate(c, h)
+		}
+		return t.applyCall(c, c.Type, h)
+	})
 }
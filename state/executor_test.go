@@ -0,0 +1,52 @@
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransition_AccessListBeforeBerlin(t *testing.T) {
+	addr3 := types.StringToAddress("3")
+
+	forks := *chain.AllForksEnabled
+	forks.Berlin = nil
+
+	executor, root := newTestExecutor(&chain.Params{Forks: &forks, ChainID: 1})
+
+	transition, err := executor.BeginTxn(root, &types.Header{GasLimit: 1000000})
+	assert.NoError(t, err)
+
+	accessList := types.AccessList{
+		{Address: addr3, StorageKeys: []types.Hash{{0x1}}},
+	}
+	txn := &types.Transaction{
+		From:       addr1,
+		To:         &addr3,
+		Value:      big.NewInt(1),
+		Gas:        30000,
+		GasPrice:   big.NewInt(0),
+		AccessList: accessList,
+	}
+
+	// the access list is not paid before the berlin fork
+	assert.Equal(t, uint64(21000), transition.transactionGasCost(txn))
+
+	// and the typed txns are rejected
+	txn.Type = types.AccessListTx
+	assert.Equal(t, ErrTxTypeNotSupported, transition.Write(txn))
+	assert.Len(t, transition.Receipts(), 0)
+
+	// they are paid and accepted after it
+	executor, root = newTestExecutor(&chain.Params{Forks: chain.AllForksEnabled, ChainID: 1})
+
+	transition, err = executor.BeginTxn(root, &types.Header{GasLimit: 1000000})
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint64(21000+2400+1900), transition.transactionGasCost(txn))
+	assert.NoError(t, transition.Write(txn))
+	assert.Len(t, transition.Receipts(), 1)
+}
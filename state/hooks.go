@@ -0,0 +1,69 @@
+package state
+
+import (
+	"github.com/0xPolygon/minimal/types"
+)
+
+// TxHookContext is the txn executed by a transition, as seen by the txn hooks
+type TxHookContext struct {
+	// Transition is the transition executing the txn. Its state, i.e. Txn(), can be
+	// read and modified by the hooks, the changes are part of the state of the txn
+	Transition *Transition
+
+	Txn    *types.Transaction
+	Sender types.Address
+
+	// Receipt is the receipt of the txn, it is only set in the post hooks.
+	// The logs emitted by the post hooks are added to it once they run
+	Receipt *types.Receipt
+}
+
+// PreTxHook is called before a txn is executed, including the calls that are not written
+// in a block. The txn is rejected and its state reverted if the hook returns an error
+type PreTxHook interface {
+	PreTx(ctx *TxHookContext) error
+}
+
+// PostTxHook is called once a txn written in a block is executed, before its receipt is
+// added. The txn is not included in the block if the hook returns an error
+type PostTxHook interface {
+	PostTx(ctx *TxHookContext) error
+}
+
+// AddPreTxHook adds a hook called before each txn, in the order the hooks are added.
+// The hooks have to be added before the executor is used
+func (e *Executor) AddPreTxHook(hook PreTxHook) {
+	e.preTxHooks = append(e.preTxHooks, hook)
+}
+
+// AddPostTxHook adds a hook called after each txn written in a block, in the order the
+// hooks are added. The hooks have to be added before the executor is used
+func (e *Executor) AddPostTxHook(hook PostTxHook) {
+	e.postTxHooks = append(e.postTxHooks, hook)
+}
+
+func (t *Transition) runPreTxHooks(msg *types.Transaction) error {
+	if len(t.r.preTxHooks) == 0 {
+		return nil
+	}
+	ctx := &TxHookContext{Transition: t, Txn: msg, Sender: msg.From}
+	for _, hook := range t.r.preTxHooks {
+		if err := hook.PreTx(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Transition) runPostTxHooks(msg *types.Transaction, receipt *types.Receipt) error {
+	if len(t.r.postTxHooks) == 0 {
+		return nil
+	}
+	ctx := &TxHookContext{Transition: t, Txn: msg, Sender: msg.From, Receipt: receipt}
+	for _, hook := range t.r.postTxHooks {
+		if err := hook.PostTx(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
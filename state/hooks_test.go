@@ -0,0 +1,212 @@
+package state
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTxHook struct {
+	pre  func(ctx *TxHookContext) error
+	post func(ctx *TxHookContext) error
+}
+
+func (m *mockTxHook) PreTx(ctx *TxHookContext) error {
+	return m.pre(ctx)
+}
+
+func (m *mockTxHook) PostTx(ctx *TxHookContext) error {
+	return m.post(ctx)
+}
+
+// noopRuntime runs the contracts without code
+type noopRuntime struct{}
+
+func (noopRuntime) Run(c *runtime.Contract, host runtime.Host, config *chain.ForksInTime) ([]byte, uint64, error) {
+	return nil, c.Gas, nil
+}
+
+func (noopRuntime) CanRun(c *runtime.Contract, host runtime.Host, config *chain.ForksInTime) bool {
+	return true
+}
+
+func (noopRuntime) Name() string {
+	return "noop"
+}
+
+// noCodeState is a state without contract codes
+type noCodeState struct {
+	*mockState
+}
+
+func (noCodeState) GetCode(hash types.Hash) ([]byte, bool) {
+	return nil, false
+}
+
+// newTestExecutor returns an executor without code whose state at the root has funds in
+// the addresses 1 and 2
+func newTestExecutor(params *chain.Params) (*Executor, types.Hash) {
+	st, snap := newStateWithPreState(map[types.Address]*PreState{
+		addr1: {Balance: 1000000},
+		addr2: {Balance: 1000000},
+	})
+	root := types.StringToHash("root")
+	st.snapshots[root] = snap
+
+	executor := NewExecutor(params, noCodeState{st})
+	executor.SetRuntime(noopRuntime{})
+	executor.GetHash = func(*types.Header) GetHashByNumber {
+		return func(i uint64) types.Hash {
+			return types.Hash{}
+		}
+	}
+	return executor, root
+}
+
+func TestExecutor_TxHooks(t *testing.T) {
+	addr3 := types.StringToAddress("3")
+
+	executor, root := newTestExecutor(&chain.Params{Forks: chain.AllForksEnabled, ChainID: 1})
+
+	errNotAllowed := errors.New("sender not allowed")
+	hook := &mockTxHook{
+		// only addr1 is allowed to send txns
+		pre: func(ctx *TxHookContext) error {
+			if ctx.Sender != addr1 {
+				return errNotAllowed
+			}
+			return nil
+		},
+		// the fees of the successful txns are matched to addr3
+		post: func(ctx *TxHookContext) error {
+			assert.Equal(t, types.ReceiptSuccess, *ctx.Receipt.Status)
+
+			fee := new(big.Int).SetUint64(ctx.Receipt.GasUsed)
+			ctx.Transition.Txn().AddBalance(addr3, fee)
+			return nil
+		},
+	}
+	executor.AddPreTxHook(hook)
+	executor.AddPostTxHook(hook)
+
+	transition, err := executor.BeginTxn(root, &types.Header{GasLimit: 1000000})
+	assert.NoError(t, err)
+
+	txn := func(from types.Address, nonce uint64) *types.Transaction {
+		return &types.Transaction{
+			From:     from,
+			Nonce:    nonce,
+			To:       &addr3,
+			Value:    big.NewInt(1),
+			Gas:      21000,
+			GasPrice: big.NewInt(0),
+		}
+	}
+
+	assert.NoError(t, transition.Write(txn(addr1, 0)))
+	assert.Equal(t, errNotAllowed, transition.Write(txn(addr2, 0)))
+
+	assert.Len(t, transition.Receipts(), 1)
+	assert.Equal(t, uint64(21000), transition.TotalGas())
+	assert.Equal(t, big.NewInt(21001), transition.Txn().GetBalance(addr3))
+
+	// the txn is not included if a post hook fails
+	hook.post = func(ctx *TxHookContext) error {
+		return errNotAllowed
+	}
+	assert.Equal(t, errNotAllowed, transition.Write(txn(addr1, 1)))
+
+	assert.Len(t, transition.Receipts(), 1)
+	assert.Equal(t, uint64(21000), transition.TotalGas())
+	assert.Equal(t, uint64(1), transition.Txn().GetNonce(addr1))
+	assert.Equal(t, big.NewInt(21001), transition.Txn().GetBalance(addr3))
+}
+
+func TestTransition_Snapshot(t *testing.T) {
+	addr3 := types.StringToAddress("3")
+
+	executor, root := newTestExecutor(&chain.Params{Forks: chain.AllForksEnabled, ChainID: 1})
+
+	transition, err := executor.BeginTxn(root, &types.Header{GasLimit: 1000000})
+	assert.NoError(t, err)
+
+	txn := func(nonce uint64) *types.Transaction {
+		return &types.Transaction{
+			From:     addr1,
+			Nonce:    nonce,
+			To:       &addr3,
+			Value:    big.NewInt(1),
+			Gas:      21000,
+			GasPrice: big.NewInt(0),
+		}
+	}
+
+	assert.NoError(t, transition.Write(txn(0)))
+
+	snap := transition.Snapshot()
+	assert.NoError(t, transition.Write(txn(1)))
+	assert.NoError(t, transition.Write(txn(2)))
+	assert.Len(t, transition.Receipts(), 3)
+
+	// the txns written after the snapshot are dropped
+	transition.RevertToSnapshot(snap)
+	assert.Len(t, transition.Receipts(), 1)
+	assert.Equal(t, uint64(21000), transition.TotalGas())
+	assert.Equal(t, uint64(1000000-21000), transition.gasPool)
+	assert.Equal(t, big.NewInt(1), transition.Txn().GetBalance(addr3))
+	assert.Equal(t, uint64(1), transition.Txn().GetNonce(addr1))
+
+	// and the transition keeps going on top of the snapshot
+	assert.NoError(t, transition.Write(txn(1)))
+	assert.Len(t, transition.Receipts(), 2)
+	assert.Equal(t, uint64(42000), transition.Receipts()[1].CumulativeGasUsed)
+}
+
+// codeRuntime returns a code of the given size from the contract creations
+type codeRuntime struct {
+	noopRuntime
+	size int
+}
+
+func (r codeRuntime) Run(c *runtime.Contract, host runtime.Host, config *chain.ForksInTime) ([]byte, uint64, error) {
+	return make([]byte, r.size), c.Gas, nil
+}
+
+func TestExecutor_MaxCodeSize(t *testing.T) {
+	params := &chain.Params{
+		Forks:   chain.AllForksEnabled,
+		ChainID: 1,
+		MaxCodeSizes: []*chain.CodeSizeLimit{
+			{Block: 10, MaxSize: 2 * chain.DefaultMaxCodeSize},
+		},
+	}
+	executor, root := newTestExecutor(params)
+	executor.runtimes = []runtime.Runtime{codeRuntime{size: int(chain.DefaultMaxCodeSize) + 1}}
+
+	create := func(number uint64) error {
+		transition, err := executor.BeginTxn(root, &types.Header{Number: number, GasLimit: 10000000})
+		assert.NoError(t, err)
+
+		_, failed, err := transition.Apply(&types.Transaction{
+			From:     addr1,
+			Value:    big.NewInt(0),
+			Gas:      10000000,
+			GasPrice: big.NewInt(0),
+			Input:    []byte{0x1},
+		})
+		assert.NoError(t, err)
+		if failed {
+			return transition.ExecutionError()
+		}
+		return nil
+	}
+
+	// the eip-170 limit applies before the limit of the chain
+	assert.Equal(t, runtime.ErrMaxCodeSizeExceeded, create(9))
+	assert.NoError(t, create(10))
+}
@@ -0,0 +1,75 @@
+package itrie
+
+import (
+	"fmt"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// CacheConfig is the number of recent items of the state kept in memory
+type CacheConfig struct {
+	Nodes    int // Encoded trie nodes by hash
+	Code     int // Contract codes by hash
+	Accounts int // Accounts and storage slots by state root and key
+}
+
+// DefaultCacheConfig returns the default sizes of the state caches
+func DefaultCacheConfig() *CacheConfig {
+	return &CacheConfig{
+		Nodes:    65536,
+		Code:     256,
+		Accounts: 65536,
+	}
+}
+
+// SetCacheConfig sets the sizes of the caches of the state. The caches
+// are cleared, it has to be called before the state is used
+func (s *State) SetCacheConfig(config *CacheConfig) error {
+	sizes := []struct {
+		name  string
+		size  int
+		cache **lru.Cache
+	}{
+		{"nodes", config.Nodes, &s.storage.nodes},
+		{"code", config.Code, &s.codeCache},
+		{"accounts", config.Accounts, &s.accountsCache},
+	}
+	for _, c := range sizes {
+		cache, err := lru.New(c.size)
+		if err != nil {
+			return fmt.Errorf("invalid size of the %s cache: %d", c.name, c.size)
+		}
+		*c.cache = cache
+	}
+	return nil
+}
+
+// cachedStorage keeps the recent trie nodes read from the storage in memory.
+// The nodes are keyed by their hash, thus they never change once cached
+type cachedStorage struct {
+	Storage
+
+	nodes   *lru.Cache
+	metrics *Metrics
+}
+
+func (c *cachedStorage) Get(k []byte) ([]byte, bool) {
+	if len(k) != types.HashLength {
+		return c.Storage.Get(k)
+	}
+
+	key := types.BytesToHash(k)
+	if v, ok := c.nodes.Get(key); ok {
+		c.metrics.hit("nodes")
+		return v.([]byte), true
+	}
+	c.metrics.miss("nodes")
+
+	v, ok := c.Storage.Get(k)
+	if ok {
+		c.nodes.Add(key, v)
+	}
+	return v, ok
+}
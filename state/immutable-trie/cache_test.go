@@ -0,0 +1,138 @@
+package itrie
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/storage/memory"
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestState_Cache(t *testing.T) {
+	db := memory.NewBackend()
+	st := NewState(NewKVStorage(db))
+
+	assert.Error(t, st.SetCacheConfig(&CacheConfig{Nodes: 0, Code: 1, Accounts: 1}))
+	assert.NoError(t, st.SetCacheConfig(&CacheConfig{Nodes: 16, Code: 16, Accounts: 16}))
+
+	addr := types.StringToAddress("1")
+	code := []byte{0x1, 0x2}
+
+	txn := state.NewTxn(st, st.NewSnapshot())
+	txn.SetNonce(addr, 1)
+	txn.SetCode(addr, code)
+	_, root, err := txn.Commit(false)
+	assert.NoError(t, err)
+
+	// open the state without the cache of the tries
+	st.ResetCache()
+
+	read := func() {
+		snap, err := st.NewSnapshotAt(types.BytesToHash(root))
+		assert.NoError(t, err)
+
+		txn := state.NewTxn(st, snap)
+		assert.Equal(t, uint64(1), txn.GetNonce(addr))
+		assert.Equal(t, code, txn.GetCode(addr))
+	}
+
+	count := func(cache string) (float64, float64) {
+		return testutil.ToFloat64(st.metrics.hits.WithLabelValues(cache)),
+			testutil.ToFloat64(st.metrics.misses.WithLabelValues(cache))
+	}
+
+	read()
+	_, codeMisses := count("code")
+	assert.Equal(t, float64(1), codeMisses)
+	_, accountMisses := count("accounts")
+	_, nodeMisses := count("nodes")
+
+	// the second read is served by the caches
+	read()
+	for _, c := range []struct {
+		name   string
+		misses float64
+	}{
+		{"code", codeMisses},
+		{"accounts", accountMisses},
+		{"nodes", nodeMisses},
+	} {
+		hits, misses := count(c.name)
+		assert.NotZero(t, hits, c.name)
+		assert.Equal(t, c.misses, misses, c.name)
+	}
+
+	// the stats of the state add up the reads of all the caches
+	hits, misses := st.CacheStats()
+	assert.Equal(t, codeMisses+accountMisses+nodeMisses, float64(misses))
+	assert.NotZero(t, hits)
+
+	// the nodes are read from the storage once the cache is reset
+	st.ResetCache()
+	read()
+	_, nodeMisses2 := count("nodes")
+	assert.Greater(t, nodeMisses2, nodeMisses)
+}
+
+func TestState_CachedTrieConcurrent(t *testing.T) {
+	st := NewState(NewKVStorage(memory.NewBackend()))
+
+	addr := types.StringToAddress("1")
+	txn := state.NewTxn(st, st.NewSnapshot())
+	txn.SetNonce(addr, 1)
+	_, root, err := txn.Commit(false)
+	assert.NoError(t, err)
+
+	// the cached trie has its hash set at commit
+	tt, ok := st.cache.Get(types.BytesToHash(root))
+	assert.True(t, ok)
+	assert.Equal(t, types.BytesToHash(root), tt.(*Trie).hash)
+
+	// and it is shared by the snapshots opened concurrently at its root
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			snap, err := st.NewSnapshotAt(types.BytesToHash(root))
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(1), state.NewTxn(st, snap).GetNonce(addr))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestState_Prefetch(t *testing.T) {
+	st := NewState(NewKVStorage(memory.NewBackend()))
+
+	addr := types.StringToAddress("1")
+	slot := types.StringToHash("1")
+	code := []byte{0x1, 0x2}
+
+	txn := state.NewTxn(st, st.NewSnapshot())
+	txn.SetCode(addr, code)
+	txn.SetState(addr, slot, slot)
+	_, root, err := txn.Commit(false)
+	assert.NoError(t, err)
+	st.ResetCache()
+	st.codeCache.Purge()
+
+	st.Prefetch(types.BytesToHash(root), addr, []types.Hash{slot})
+
+	// the account, its code and the slot are read from the caches
+	misses := testutil.ToFloat64(st.metrics.misses.WithLabelValues("accounts"))
+
+	snap, err := st.NewSnapshotAt(types.BytesToHash(root))
+	assert.NoError(t, err)
+
+	txn = state.NewTxn(st, snap)
+	assert.Equal(t, slot, txn.GetState(addr, slot))
+	assert.Equal(t, code, txn.GetCode(addr))
+
+	assert.Equal(t, misses, testutil.ToFloat64(st.metrics.misses.WithLabelValues("accounts")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(st.metrics.misses.WithLabelValues("code")))
+}
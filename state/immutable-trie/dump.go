@@ -0,0 +1,200 @@
+package itrie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/umbracle/fastrlp"
+)
+
+var errDumpFull = errors.New("dump full")
+
+// maxDumpSlots is the max number of storage slots of each account in a dump,
+// the rest of the storage is paginated with StorageRange
+const maxDumpSlots = 1024
+
+// IterateAccounts calls fn with the accounts of the state at the root, with their code and
+// storage, in order of their hashed addresses from the start one, until fn returns an error
+func (s *State) IterateAccounts(root types.Hash, start types.Hash, fn func(account *state.DumpAccount) error) error {
+	return IterateFrom(s.storage, root, start.Bytes(), func(k, v []byte) error {
+		account, err := s.dumpAccount(k, v, 0)
+		if err != nil {
+			return err
+		}
+		return fn(account)
+	})
+}
+
+// Dump returns up to max accounts of the state at the root, from the start hashed address.
+// Up to maxDumpSlots storage slots are returned for each account
+func (s *State) Dump(root types.Hash, start types.Hash, max uint64) (*state.Dump, error) {
+	dump := &state.Dump{
+		Root:     root,
+		Accounts: []*state.DumpAccount{},
+	}
+	err := IterateFrom(s.storage, root, start.Bytes(), func(k, v []byte) error {
+		if uint64(len(dump.Accounts)) == max {
+			next := types.BytesToHash(k)
+			dump.Next = &next
+			return errDumpFull
+		}
+		account, err := s.dumpAccount(k, v, maxDumpSlots)
+		if err != nil {
+			return err
+		}
+		dump.Accounts = append(dump.Accounts, account)
+		return nil
+	})
+	if err != nil && err != errDumpFull {
+		return nil, err
+	}
+	return dump, nil
+}
+
+// dumpAccount decodes the account of the leaf of the state trie with its code and up to
+// maxSlots slots of its storage, or all of them if it is 0
+func (s *State) dumpAccount(k, v []byte, maxSlots uint64) (*state.DumpAccount, error) {
+	var account state.Account
+	if err := account.UnmarshalRlp(v); err != nil {
+		return nil, err
+	}
+	res := &state.DumpAccount{
+		Account: &account,
+		Key:     types.BytesToHash(k),
+		Storage: []*state.DumpSlot{},
+	}
+	if preimage, ok := GetPreimage(s.storage, res.Key); ok {
+		addr := types.BytesToAddress(preimage)
+		res.Address = &addr
+	}
+	if len(account.CodeHash) != 0 && !bytes.Equal(account.CodeHash, emptyCodeHash) {
+		if code, ok := s.storage.GetCode(types.BytesToHash(account.CodeHash)); ok {
+			res.Code = code
+		}
+	}
+
+	p := parserPool.Get()
+	defer parserPool.Put(p)
+
+	err := Iterate(s.storage, account.Root, func(k, v []byte) error {
+		if maxSlots != 0 && uint64(len(res.Storage)) == maxSlots {
+			next := types.BytesToHash(k)
+			res.StorageNext = &next
+			return errDumpFull
+		}
+		slot, err := s.dumpSlot(p, k, v)
+		if err != nil {
+			return err
+		}
+		res.Storage = append(res.Storage, slot)
+		return nil
+	})
+	if err != nil && err != errDumpFull {
+		return nil, err
+	}
+	return res, nil
+}
+
+// dumpSlot decodes the slot of the leaf of a storage trie
+func (s *State) dumpSlot(p *fastrlp.Parser, k, v []byte) (*state.DumpSlot, error) {
+	// the values are encoded in the storage trie
+	vv, err := p.Parse(v)
+	if err != nil {
+		return nil, err
+	}
+	value, err := vv.GetBytes(nil)
+	if err != nil {
+		return nil, err
+	}
+	slot := &state.DumpSlot{
+		Key:   types.BytesToHash(k),
+		Value: types.BytesToHash(value),
+	}
+	if preimage, ok := GetPreimage(s.storage, slot.Key); ok {
+		hash := types.BytesToHash(preimage)
+		slot.Slot = &hash
+	}
+	return slot, nil
+}
+
+// readAccount returns the account of the address in the state at the root
+func (s *State) readAccount(root types.Hash, addr types.Address) (*state.Account, error) {
+	if root == types.EmptyRootHash {
+		return nil, fmt.Errorf("account %s not found", addr)
+	}
+	t, err := s.newTrieAt(root)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := t.Get(hashit(addr.Bytes()))
+	if !ok {
+		return nil, fmt.Errorf("account %s not found", addr)
+	}
+	var account state.Account
+	if err := account.UnmarshalRlp(data); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// StorageRange returns up to max storage slots of the account in the state at
+// the root, from the start hashed slot
+func (s *State) StorageRange(root types.Hash, addr types.Address, start types.Hash, max uint64) (*state.StorageRange, error) {
+	account, err := s.readAccount(root, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parserPool.Get()
+	defer parserPool.Put(p)
+
+	res := &state.StorageRange{
+		Slots: []*state.DumpSlot{},
+	}
+	err = IterateFrom(s.storage, account.Root, start.Bytes(), func(k, v []byte) error {
+		if uint64(len(res.Slots)) == max {
+			next := types.BytesToHash(k)
+			res.Next = &next
+			return errDumpFull
+		}
+		slot, err := s.dumpSlot(p, k, v)
+		if err != nil {
+			return err
+		}
+		res.Slots = append(res.Slots, slot)
+		return nil
+	})
+	if err != nil && err != errDumpFull {
+		return nil, err
+	}
+	return res, nil
+}
+
+// AccountStats returns the size of the code and the number of storage slots of the
+// account in the state at the root. The whole storage trie of the account is walked
+func (s *State) AccountStats(root types.Hash, addr types.Address) (*state.AccountStats, error) {
+	account, err := s.readAccount(root, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &state.AccountStats{
+		Account: account,
+	}
+	if len(account.CodeHash) != 0 && !bytes.Equal(account.CodeHash, emptyCodeHash) {
+		if code, ok := s.GetCode(types.BytesToHash(account.CodeHash)); ok {
+			res.CodeSize = uint64(len(code))
+		}
+	}
+	err = Iterate(s.storage, account.Root, func(k, v []byte) error {
+		res.StorageSlots++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
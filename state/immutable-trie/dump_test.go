@@ -0,0 +1,179 @@
+package itrie
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/storage/memory"
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDump(t *testing.T) {
+	st := NewState(NewKVStorage(memory.NewBackend()))
+	st.SetPreimages(true)
+
+	code := []byte{0x60, 0x01}
+
+	txn := state.NewTxn(st, st.NewSnapshot())
+	for i := 1; i <= 25; i++ {
+		addr := types.BytesToAddress([]byte{byte(i)})
+		txn.SetBalance(addr, big.NewInt(int64(i)))
+		txn.SetState(addr, types.BytesToHash([]byte{byte(i)}), types.BytesToHash([]byte{0x1, byte(i)}))
+	}
+	txn.SetCode(types.BytesToAddress([]byte{1}), code)
+	_, root, err := txn.Commit(false)
+	assert.NoError(t, err)
+	stateRoot := types.BytesToHash(root)
+
+	// dump all the accounts in pages
+	accounts := []*state.DumpAccount{}
+	start := types.Hash{}
+	for {
+		dump, err := st.Dump(stateRoot, start, 10)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, len(dump.Accounts), 10)
+
+		accounts = append(accounts, dump.Accounts...)
+		if dump.Next == nil {
+			break
+		}
+		start = *dump.Next
+	}
+	assert.Len(t, accounts, 25)
+
+	for i, account := range accounts {
+		if i > 0 {
+			assert.Equal(t, -1, bytes.Compare(accounts[i-1].Key.Bytes(), account.Key.Bytes()))
+		}
+		assert.Equal(t, types.BytesToHash(hashit(account.Address.Bytes())), account.Key)
+
+		n := account.Address[types.AddressLength-1]
+		assert.Equal(t, big.NewInt(int64(n)), account.Balance)
+		assert.Equal(t, []*state.DumpSlot{
+			{
+				Key:   types.BytesToHash(hashit(types.BytesToHash([]byte{n}).Bytes())),
+				Slot:  hashPtr(types.BytesToHash([]byte{n})),
+				Value: types.BytesToHash([]byte{0x1, n}),
+			},
+		}, account.Storage)
+
+		if n == 1 {
+			assert.Equal(t, code, account.Code)
+		} else {
+			assert.Empty(t, account.Code)
+		}
+	}
+
+	// the iteration starts at a key between two accounts
+	start = accounts[10].Key
+	start[types.HashLength-1]--
+
+	dump, err := st.Dump(stateRoot, start, 100)
+	assert.NoError(t, err)
+	assert.Nil(t, dump.Next)
+	assert.Equal(t, accounts[10:], dump.Accounts)
+
+	// the state of a missing root cannot be dumped
+	_, err = st.Dump(types.StringToHash("1"), types.Hash{}, 10)
+	assert.Error(t, err)
+}
+
+func TestDump_MaxSlots(t *testing.T) {
+	st := NewState(NewKVStorage(memory.NewBackend()))
+
+	addr := types.StringToAddress("1")
+
+	txn := state.NewTxn(st, st.NewSnapshot())
+	for i := 0; i < maxDumpSlots+10; i++ {
+		slot := types.BytesToHash([]byte{byte(i >> 8), byte(i)})
+		txn.SetState(addr, slot, types.StringToHash("1"))
+	}
+	_, root, err := txn.Commit(false)
+	assert.NoError(t, err)
+	stateRoot := types.BytesToHash(root)
+
+	// the storage of the account is truncated
+	dump, err := st.Dump(stateRoot, types.Hash{}, 10)
+	assert.NoError(t, err)
+	assert.Len(t, dump.Accounts, 1)
+
+	account := dump.Accounts[0]
+	assert.Len(t, account.Storage, maxDumpSlots)
+	assert.NotNil(t, account.StorageNext)
+
+	// the preimages are not stored by default
+	assert.Nil(t, account.Address)
+	assert.Nil(t, account.Storage[0].Slot)
+
+	// and the rest of it is in the storage range from the next slot
+	res, err := st.StorageRange(stateRoot, addr, *account.StorageNext, 100)
+	assert.NoError(t, err)
+	assert.Len(t, res.Slots, 10)
+	assert.Nil(t, res.Next)
+}
+
+func hashPtr(h types.Hash) *types.Hash {
+	return &h
+}
+
+func TestStorageRange(t *testing.T) {
+	st := NewState(NewKVStorage(memory.NewBackend()))
+	st.SetPreimages(true)
+
+	addr := types.StringToAddress("1")
+	code := []byte{0x60, 0x01, 0x60, 0x02}
+
+	txn := state.NewTxn(st, st.NewSnapshot())
+	txn.SetCode(addr, code)
+	for i := 1; i <= 25; i++ {
+		txn.SetState(addr, types.BytesToHash([]byte{byte(i)}), types.BytesToHash([]byte{0x1, byte(i)}))
+	}
+	txn.SetBalance(types.StringToAddress("2"), big.NewInt(1))
+	_, root, err := txn.Commit(false)
+	assert.NoError(t, err)
+	stateRoot := types.BytesToHash(root)
+
+	stats, err := st.AccountStats(stateRoot, addr)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(len(code)), stats.CodeSize)
+	assert.Equal(t, uint64(25), stats.StorageSlots)
+
+	stats, err = st.AccountStats(stateRoot, types.StringToAddress("2"))
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), stats.Balance)
+	assert.Zero(t, stats.CodeSize)
+	assert.Zero(t, stats.StorageSlots)
+
+	// read the storage in pages
+	slots := []*state.DumpSlot{}
+	start := types.Hash{}
+	for {
+		res, err := st.StorageRange(stateRoot, addr, start, 10)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, len(res.Slots), 10)
+
+		slots = append(slots, res.Slots...)
+		if res.Next == nil {
+			break
+		}
+		start = *res.Next
+	}
+	assert.Len(t, slots, 25)
+
+	for i, slot := range slots {
+		if i > 0 {
+			assert.Equal(t, -1, bytes.Compare(slots[i-1].Key.Bytes(), slot.Key.Bytes()))
+		}
+		n := slot.Slot[types.HashLength-1]
+		assert.Equal(t, types.BytesToHash([]byte{0x1, n}), slot.Value)
+	}
+
+	// the account does not exist
+	_, err = st.StorageRange(stateRoot, types.StringToAddress("3"), types.Hash{}, 10)
+	assert.Error(t, err)
+	_, err = st.AccountStats(stateRoot, types.StringToAddress("3"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,54 @@
+package itrie
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/0xPolygon/minimal/crypto"
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+)
+
+var emptyCodeHash = crypto.Keccak256(nil)
+
+// ExportState calls fn with the keys and the values of the trie nodes and of the code
+// of the accounts of the state, which are enough to restore it in an empty database.
+// The nodes are immutable, so the state can be exported while the database is written
+func ExportState(s Storage, root types.Hash, fn func(k, v []byte) error) error {
+	marked := map[types.Hash]struct{}{}
+	codes := map[types.Hash]struct{}{}
+
+	err := markTrie(s, root, marked, func(value []byte) error {
+		var account state.Account
+		if err := account.UnmarshalRlp(value); err != nil {
+			return err
+		}
+		if len(account.CodeHash) != 0 && !bytes.Equal(account.CodeHash, emptyCodeHash) {
+			codes[types.BytesToHash(account.CodeHash)] = struct{}{}
+		}
+		return markTrie(s, account.Root, marked, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	for hash := range marked {
+		v, ok := s.Get(hash.Bytes())
+		if !ok {
+			return fmt.Errorf("trie node %s not found", hash)
+		}
+		if err := fn(hash.Bytes(), v); err != nil {
+			return err
+		}
+	}
+	for hash := range codes {
+		code, ok := s.GetCode(hash)
+		if !ok {
+			return fmt.Errorf("code %s not found", hash)
+		}
+		if err := fn(append(append([]byte{}, codePrefix...), hash.Bytes()...), code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package itrie
+
+import (
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/storage/memory"
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportState(t *testing.T) {
+	st := NewState(NewKVStorage(memory.NewBackend()))
+
+	addr1 := types.StringToAddress("1")
+	addr2 := types.StringToAddress("2")
+	code := []byte{0x60, 0x01}
+
+	txn := state.NewTxn(st, st.NewSnapshot())
+	for i := 0; i < 20; i++ {
+		txn.SetState(addr1, types.BytesToHash([]byte{byte(i)}), types.StringToHash("1"))
+	}
+	txn.SetCode(addr1, code)
+	txn.SetNonce(addr2, 1)
+	snap1, root1, err := txn.Commit(false)
+	assert.NoError(t, err)
+
+	// a newer state is not exported
+	txn = state.NewTxn(st, snap1)
+	txn.SetNonce(addr2, 2)
+	_, _, err = txn.Commit(false)
+	assert.NoError(t, err)
+
+	// export the state into an empty database
+	to := NewKVStorage(memory.NewBackend())
+	err = ExportState(st.storage, types.BytesToHash(root1), func(k, v []byte) error {
+		to.Put(k, v)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	restored := NewState(to)
+	snap, err := restored.NewSnapshotAt(types.BytesToHash(root1))
+	assert.NoError(t, err)
+
+	txn = state.NewTxn(restored, snap)
+	assert.Equal(t, uint64(1), txn.GetNonce(addr2))
+	assert.Equal(t, code, txn.GetCode(addr1))
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, types.StringToHash("1"), txn.GetState(addr1, types.BytesToHash([]byte{byte(i)})))
+	}
+
+	// the state of a missing root cannot be exported
+	err = ExportState(to, types.StringToHash("1"), func(k, v []byte) error {
+		return nil
+	})
+	assert.Error(t, err)
+}
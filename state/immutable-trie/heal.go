@@ -0,0 +1,227 @@
+package itrie
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/0xPolygon/minimal/crypto"
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// healVisitedSize is the max number of hashes remembered by the healer to skip the shared
+// subtries already walked. The older ones are forgotten, which only walks them again
+const healVisitedSize = 1 << 17
+
+// GetNodeData returns the encoded trie node or the contract code with the given hash
+func GetNodeData(s Storage, hash types.Hash) ([]byte, bool) {
+	if data, ok := s.Get(hash.Bytes()); ok {
+		return data, true
+	}
+	return s.GetCode(hash)
+}
+
+// healKind is the kind of an entry of the state walked by the healer
+type healKind int
+
+const (
+	healAccountNode healKind = iota
+	healStorageNode
+	healCode
+)
+
+type healEntry struct {
+	hash types.Hash
+	kind healKind
+}
+
+// HealStats is the progress of a healer
+type HealStats struct {
+	// Visited is the number of trie nodes and codes found in the storage
+	Visited uint64
+
+	// Healed is the number of trie nodes and codes delivered and written
+	Healed uint64
+
+	// Pending is the number of missing trie nodes and codes not delivered yet
+	Pending uint64
+}
+
+// Healer walks the tries of a state root and finds the trie nodes and the contract codes
+// missing from the storage, i.e. after a snapshot sync or a corruption. The entries
+// delivered are verified against their hash and written, then their children are walked.
+// It is not safe for concurrent use
+type Healer struct {
+	storage Storage
+	root    types.Hash
+
+	// queue are the entries to walk, walked depth first
+	queue   []healEntry
+	missing map[types.Hash]healKind
+	visited *lru.Cache
+
+	stats HealStats
+}
+
+// NewHealer creates a healer of the state at the given root
+func NewHealer(s Storage, root types.Hash) *Healer {
+	visited, _ := lru.New(healVisitedSize)
+	h := &Healer{
+		storage: s,
+		root:    root,
+		missing: map[types.Hash]healKind{},
+		visited: visited,
+	}
+	h.enqueue(root, healAccountNode)
+	return h
+}
+
+// Root returns the state root healed
+func (h *Healer) Root() types.Hash {
+	return h.root
+}
+
+func (h *Healer) enqueue(hash types.Hash, kind healKind) {
+	if kind == healCode && bytes.Equal(hash.Bytes(), emptyCodeHash) {
+		return
+	}
+	if kind != healCode && hash == types.EmptyRootHash {
+		return
+	}
+	if ok, _ := h.visited.ContainsOrAdd(hash, struct{}{}); ok {
+		return
+	}
+	h.queue = append(h.queue, healEntry{hash: hash, kind: kind})
+}
+
+// Missing walks the tries until max entries are missing from the storage or the walk is
+// over, and returns the hashes of up to max missing entries that were not delivered yet
+func (h *Healer) Missing(max int) ([]types.Hash, error) {
+	for len(h.queue) > 0 && len(h.missing) < max {
+		entry := h.queue[len(h.queue)-1]
+		h.queue = h.queue[:len(h.queue)-1]
+
+		if err := h.visit(entry); err != nil {
+			return nil, err
+		}
+	}
+
+	hashes := make([]types.Hash, 0, len(h.missing))
+	for hash := range h.missing {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i].Bytes(), hashes[j].Bytes()) < 0
+	})
+	if len(hashes) > max {
+		hashes = hashes[:max]
+	}
+	return hashes, nil
+}
+
+func (h *Healer) visit(entry healEntry) error {
+	if entry.kind == healCode {
+		if _, ok := h.storage.GetCode(entry.hash); !ok {
+			h.missing[entry.hash] = entry.kind
+			return nil
+		}
+		h.stats.Visited++
+		return nil
+	}
+
+	data, ok := h.storage.Get(entry.hash.Bytes())
+	if !ok {
+		h.missing[entry.hash] = entry.kind
+		return nil
+	}
+	h.stats.Visited++
+
+	node, err := decodeStoredNode(data, h.storage)
+	if err != nil {
+		return fmt.Errorf("failed to decode trie node %s: %v", entry.hash, err)
+	}
+	return h.walk(node, entry.kind)
+}
+
+// walk enqueues the nodes referenced by hash from the node and, for the leaves of the
+// account trie, the storage root and the code of the account
+func (h *Healer) walk(node Node, kind healKind) error {
+	switch n := node.(type) {
+	case nil:
+		return nil
+
+	case *ValueNode:
+		if n.hash {
+			h.enqueue(types.BytesToHash(n.buf), kind)
+			return nil
+		}
+		if kind != healAccountNode {
+			return nil
+		}
+		var account state.Account
+		if err := account.UnmarshalRlp(n.buf); err != nil {
+			return err
+		}
+		h.enqueue(account.Root, healStorageNode)
+		if len(account.CodeHash) != 0 {
+			h.enqueue(types.BytesToHash(account.CodeHash), healCode)
+		}
+		return nil
+
+	case *ShortNode:
+		return h.walk(n.child, kind)
+
+	case *FullNode:
+		for _, child := range n.children {
+			if err := h.walk(child, kind); err != nil {
+				return err
+			}
+		}
+		return h.walk(n.value, kind)
+
+	default:
+		return fmt.Errorf("unknown node type %T", n)
+	}
+}
+
+// Deliver verifies the data of a missing entry against its hash and writes it to the storage
+func (h *Healer) Deliver(hash types.Hash, data []byte) error {
+	kind, ok := h.missing[hash]
+	if !ok {
+		return fmt.Errorf("trie node %s not requested", hash)
+	}
+	if !bytes.Equal(crypto.Keccak256(data), hash.Bytes()) {
+		return fmt.Errorf("trie node %s does not match its hash", hash)
+	}
+
+	if kind == healCode {
+		h.storage.SetCode(hash, data)
+	} else {
+		node, err := decodeStoredNode(data, h.storage)
+		if err != nil {
+			return fmt.Errorf("failed to decode trie node %s: %v", hash, err)
+		}
+		if err := h.walk(node, kind); err != nil {
+			return err
+		}
+		h.storage.Put(hash.Bytes(), data)
+	}
+
+	delete(h.missing, hash)
+	h.stats.Healed++
+	return nil
+}
+
+// Done returns true once the tries are fully walked and no entry is missing
+func (h *Healer) Done() bool {
+	return len(h.queue) == 0 && len(h.missing) == 0
+}
+
+// Stats returns the progress of the healer
+func (h *Healer) Stats() HealStats {
+	stats := h.stats
+	stats.Pending = uint64(len(h.missing))
+	return stats
+}
@@ -0,0 +1,118 @@
+package itrie
+
+import (
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/storage/memory"
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealer(t *testing.T) {
+	db := memory.NewBackend()
+	st := NewState(NewKVStorage(db))
+
+	addr1 := types.StringToAddress("1")
+	addr2 := types.StringToAddress("2")
+	code := []byte{0x1, 0x2}
+
+	txn := state.NewTxn(st, st.NewSnapshot())
+	for i := 0; i < 20; i++ {
+		txn.SetState(addr1, types.BytesToHash([]byte{byte(i)}), types.StringToHash("1"))
+	}
+	txn.SetCode(addr1, code)
+	txn.SetNonce(addr2, 1)
+	_, root, err := txn.Commit(false)
+	assert.NoError(t, err)
+
+	// copy the state without some of its trie nodes and its code
+	healedDB := memory.NewBackend()
+	nodes := 0
+	assert.NoError(t, db.Iterate(nil, func(k, v []byte) bool {
+		if IsCodeKey(k) {
+			return true
+		}
+		if len(k) == types.HashLength {
+			if nodes++; nodes%2 == 1 {
+				return true
+			}
+		}
+		assert.NoError(t, healedDB.Set(k, v))
+		return true
+	}))
+
+	source := NewKVStorage(db)
+	healer := NewHealer(NewKVStorage(healedDB), types.BytesToHash(root))
+
+	missing, err := healer.Missing(4)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, missing)
+	assert.LessOrEqual(t, len(missing), 4)
+
+	// the entries that do not match their hash are rejected
+	assert.Error(t, healer.Deliver(missing[0], []byte{0x1}))
+	assert.Error(t, healer.Deliver(types.StringToHash("1"), []byte{0x1}))
+
+	healed := uint64(0)
+	for len(missing) != 0 {
+		for _, hash := range missing {
+			data, ok := GetNodeData(source, hash)
+			assert.True(t, ok)
+			assert.NoError(t, healer.Deliver(hash, data))
+			healed++
+		}
+		missing, err = healer.Missing(4)
+		assert.NoError(t, err)
+	}
+	assert.True(t, healer.Done())
+
+	stats := healer.Stats()
+	assert.Equal(t, healed, stats.Healed)
+	assert.Zero(t, stats.Pending)
+	assert.NotZero(t, stats.Visited)
+
+	// the healed state is fully readable
+	healedState := NewState(NewKVStorage(healedDB))
+	snap, err := healedState.NewSnapshotAt(types.BytesToHash(root))
+	assert.NoError(t, err)
+
+	txn = state.NewTxn(healedState, snap)
+	assert.Equal(t, uint64(1), txn.GetNonce(addr2))
+	assert.Equal(t, code, txn.GetCode(addr1))
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, types.StringToHash("1"), txn.GetState(addr1, types.BytesToHash([]byte{byte(i)})))
+	}
+
+	// nothing is missing once healed
+	healer = NewHealer(NewKVStorage(healedDB), types.BytesToHash(root))
+	missing, err = healer.Missing(4)
+	assert.NoError(t, err)
+	assert.Empty(t, missing)
+	assert.True(t, healer.Done())
+}
+
+func TestHealer_VisitedBound(t *testing.T) {
+	db := memory.NewBackend()
+	st := NewState(NewKVStorage(db))
+
+	// the accounts share the same storage trie
+	txn := state.NewTxn(st, st.NewSnapshot())
+	for i := 0; i < 10; i++ {
+		addr := types.BytesToAddress([]byte{byte(i + 1)})
+		txn.SetState(addr, types.StringToHash("1"), types.StringToHash("1"))
+	}
+	_, root, err := txn.Commit(false)
+	assert.NoError(t, err)
+
+	// the healer forgets the hashes walked, which only walks them again
+	healer := NewHealer(NewKVStorage(db), types.BytesToHash(root))
+	healer.visited, _ = lru.New(1)
+
+	missing, err := healer.Missing(4)
+	assert.NoError(t, err)
+	assert.Empty(t, missing)
+	assert.True(t, healer.Done())
+	assert.Equal(t, 1, healer.visited.Len())
+}
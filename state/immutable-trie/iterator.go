@@ -0,0 +1,105 @@
+package itrie
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// Iterate calls fn with the key and the value of each leaf of the stored trie, in key
+// order, until fn returns an error. The keys are the hashed keys inserted in the trie
+func Iterate(s Storage, root types.Hash, fn func(k, v []byte) error) error {
+	return IterateFrom(s, root, nil, fn)
+}
+
+// IterateFrom is like Iterate but it starts from the leaves whose keys are equal
+// or greater than the start key. The subtries before the start key are not read
+func IterateFrom(s Storage, root types.Hash, start []byte, fn func(k, v []byte) error) error {
+	if root == types.EmptyRootHash {
+		return nil
+	}
+	node, ok, err := GetNode(root.Bytes(), s)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("trie node %s not found", root)
+	}
+
+	var startPath []byte
+	if len(start) != 0 {
+		startPath = keybytesToHex(start)
+		startPath = startPath[:len(startPath)-1]
+	}
+	return iterateNode(s, node, []byte{}, startPath, fn)
+}
+
+// comparePrefix compares the path of a node, without terminator, with the start path
+// up to the length of the shortest of them
+func comparePrefix(path, start []byte) int {
+	if hasTerm(path) {
+		path = path[:len(path)-1]
+	}
+	n := len(path)
+	if len(start) < n {
+		n = len(start)
+	}
+	return bytes.Compare(path[:n], start[:n])
+}
+
+func iterateNode(s Storage, node Node, path, start []byte, fn func(k, v []byte) error) error {
+	if start != nil {
+		switch comparePrefix(path, start) {
+		case -1:
+			// all the keys of the node are before the start key
+			return nil
+		case 1:
+			// and here all of them are after it
+			start = nil
+		}
+	}
+
+	switch n := node.(type) {
+	case nil:
+		return nil
+
+	case *ValueNode:
+		if n.hash {
+			nc, ok, err := GetNode(n.buf, s)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("trie node %s not found", types.BytesToHash(n.buf))
+			}
+			return iterateNode(s, nc, path, start, fn)
+		}
+		if hasTerm(path) {
+			path = path[:len(path)-1]
+		}
+		if len(path)%2 != 0 {
+			return fmt.Errorf("leaf with an odd key length")
+		}
+		key := make([]byte, len(path)/2)
+		decodeNibbles(path, key)
+		return fn(key, n.buf)
+
+	case *ShortNode:
+		return iterateNode(s, n.child, concat(path, n.key), start, fn)
+
+	case *FullNode:
+		if err := iterateNode(s, n.value, concat(path, []byte{16}), start, fn); err != nil {
+			return err
+		}
+		for i, child := range n.children {
+			if err := iterateNode(s, child, concat(path, []byte{byte(i)}), start, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown node type %T", n)
+	}
+}
@@ -0,0 +1,62 @@
+package itrie
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "state"
+
+// Metrics are the prometheus metrics of the state
+type Metrics struct {
+	// number of the reads served by all the caches and not found in them, see CacheStats
+	totalHits   uint64
+	totalMisses uint64
+
+	// number of the reads served by each cache
+	hits *prometheus.CounterVec
+
+	// number of the reads not found in each cache
+	misses *prometheus.CounterVec
+}
+
+func newMetrics() *Metrics {
+	return &Metrics{
+		hits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "cache_hits_total",
+			Help:      "Number of the reads of the state served by the cache",
+		}, []string{"cache"}),
+		misses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "cache_misses_total",
+			Help:      "Number of the reads of the state not found in the cache",
+		}, []string{"cache"}),
+	}
+}
+
+func (m *Metrics) hit(cache string) {
+	atomic.AddUint64(&m.totalHits, 1)
+	m.hits.WithLabelValues(cache).Inc()
+}
+
+func (m *Metrics) miss(cache string) {
+	atomic.AddUint64(&m.totalMisses, 1)
+	m.misses.WithLabelValues(cache).Inc()
+}
+
+// CacheStats implements the state.CacheStatsState interface
+func (s *State) CacheStats() (uint64, uint64) {
+	return atomic.LoadUint64(&s.metrics.totalHits), atomic.LoadUint64(&s.metrics.totalMisses)
+}
+
+// RegisterMetrics registers the metrics of the state in the prometheus registerer
+func (s *State) RegisterMetrics(reg prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{s.metrics.hits, s.metrics.misses} {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
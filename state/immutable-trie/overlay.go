@@ -0,0 +1,52 @@
+package itrie
+
+import (
+	"github.com/0xPolygon/minimal/types"
+)
+
+// OverlayStorage keeps the writes in memory on top of a storage that is only read,
+// i.e. to execute blocks on the state of a database open in read-only mode
+type OverlayStorage struct {
+	base  Storage
+	local *memStorage
+}
+
+// NewOverlayStorage creates a trie storage that reads from the base storage and
+// keeps the writes in memory. The base storage is never written
+func NewOverlayStorage(base Storage) *OverlayStorage {
+	return &OverlayStorage{
+		base:  base,
+		local: NewMemoryStorage().(*memStorage),
+	}
+}
+
+// Discard drops the writes kept in memory
+func (o *OverlayStorage) Discard() {
+	o.local = NewMemoryStorage().(*memStorage)
+}
+
+func (o *OverlayStorage) Put(k, v []byte) {
+	o.local.Put(k, v)
+}
+
+func (o *OverlayStorage) Get(k []byte) ([]byte, bool) {
+	if v, ok := o.local.Get(k); ok {
+		return v, true
+	}
+	return o.base.Get(k)
+}
+
+func (o *OverlayStorage) Batch() Batch {
+	return o.local.Batch()
+}
+
+func (o *OverlayStorage) SetCode(hash types.Hash, code []byte) {
+	o.local.SetCode(hash, code)
+}
+
+func (o *OverlayStorage) GetCode(hash types.Hash) ([]byte, bool) {
+	if code, ok := o.local.GetCode(hash); ok {
+		return code, true
+	}
+	return o.base.GetCode(hash)
+}
@@ -0,0 +1,56 @@
+package itrie
+
+import (
+	"testing"
+
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverlayStorage(t *testing.T) {
+	base := NewMemoryStorage()
+	st := NewState(base)
+
+	addr := types.StringToAddress("1")
+
+	txn := state.NewTxn(st, st.NewSnapshot())
+	txn.SetNonce(addr, 1)
+	txn.SetCode(addr, []byte{0x1})
+	snap, root, err := txn.Commit(false)
+	assert.NoError(t, err)
+
+	// the overlay reads the states of the base
+	storage := NewOverlayStorage(base)
+	overlay := NewState(storage)
+	overlaySnap, err := overlay.NewSnapshotAt(types.BytesToHash(root))
+	assert.NoError(t, err)
+
+	txn = state.NewTxn(overlay, overlaySnap)
+	assert.Equal(t, uint64(1), txn.GetNonce(addr))
+	assert.Equal(t, []byte{0x1}, txn.GetCode(addr))
+
+	txn.SetNonce(addr, 2)
+	txn.SetCode(addr, []byte{0x2})
+	_, root2, err := txn.Commit(false)
+	assert.NoError(t, err)
+
+	// the new state is only in the overlay
+	_, err = st.NewSnapshotAt(types.BytesToHash(root2))
+	assert.Error(t, err)
+
+	overlaySnap, err = overlay.NewSnapshotAt(types.BytesToHash(root2))
+	assert.NoError(t, err)
+	txn = state.NewTxn(overlay, overlaySnap)
+	assert.Equal(t, uint64(2), txn.GetNonce(addr))
+	assert.Equal(t, []byte{0x2}, txn.GetCode(addr))
+
+	// the base keeps its state
+	txn = state.NewTxn(st, snap)
+	assert.Equal(t, uint64(1), txn.GetNonce(addr))
+
+	// the new state is gone once the writes are discarded
+	storage.Discard()
+	_, err = NewState(storage).NewSnapshotAt(types.BytesToHash(root2))
+	assert.Error(t, err)
+}
@@ -0,0 +1,186 @@
+package itrie
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/umbracle/fastrlp"
+)
+
+// Prove returns the merkle proof of the key in the stored trie of the root, which are the
+// encoded nodes in the path from the root to the key, and the value of the key. If the key
+// is not in the trie the value is nil and the proof proves its absence. The key is the
+// hashed key inserted in the trie
+func Prove(s Storage, root types.Hash, key []byte) ([][]byte, []byte, error) {
+	proof := [][]byte{}
+	if root == types.EmptyRootHash {
+		return proof, nil, nil
+	}
+
+	p := parserPool.Get()
+	defer parserPool.Put(p)
+
+	hash, path := root.Bytes(), keybytesToHex(key)
+	for {
+		data, ok := s.Get(hash)
+		if !ok {
+			return nil, nil, fmt.Errorf("trie node %s not found", types.BytesToHash(hash))
+		}
+		proof = append(proof, data)
+
+		v, err := p.Parse(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		next, value, rest, err := proofStep(v, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if next == nil {
+			return proof, copyBytes(value), nil
+		}
+		hash, path = copyBytes(next), rest
+	}
+}
+
+// VerifyProof checks the merkle proof of the key against the root and returns the value
+// of the key, which is nil if the proof proves that the key is not in the trie
+func VerifyProof(root types.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	if root == types.EmptyRootHash {
+		return nil, nil
+	}
+
+	nodes := map[types.Hash][]byte{}
+	for _, node := range proof {
+		nodes[types.BytesToHash(hashit(node))] = node
+	}
+
+	p := parserPool.Get()
+	defer parserPool.Put(p)
+
+	hash, path := root, keybytesToHex(key)
+	for {
+		data, ok := nodes[hash]
+		if !ok {
+			return nil, fmt.Errorf("proof node %s not found", hash)
+		}
+
+		v, err := p.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		next, value, rest, err := proofStep(v, path)
+		if err != nil {
+			return nil, err
+		}
+		if next == nil {
+			return copyBytes(value), nil
+		}
+		hash, path = types.BytesToHash(next), rest
+	}
+}
+
+// proofStep follows the path in the encoded node, and in the nodes embedded in it, until it
+// reaches a child node referenced by its hash, which is returned with the rest of the path.
+// Otherwise the path ends in the node and the value of the key is returned, if any
+func proofStep(v *fastrlp.Value, path []byte) ([]byte, []byte, []byte, error) {
+	for {
+		if v.Type() == fastrlp.TypeBytes {
+			switch v.Len() {
+			case 0:
+				// empty child
+				return nil, nil, nil, nil
+			case types.HashLength:
+				return v.Raw(), nil, path, nil
+			default:
+				return nil, nil, nil, fmt.Errorf("invalid child node reference")
+			}
+		}
+
+		switch v.Elems() {
+		case 17:
+			if len(path) == 0 {
+				return nil, nil, nil, fmt.Errorf("invalid key path")
+			}
+			if path[0] == 16 {
+				return nil, v.Get(16).Raw(), nil, nil
+			}
+			v, path = v.Get(int(path[0])), path[1:]
+
+		case 2:
+			key := compactToHex(v.Get(0).Raw())
+			if hasTerm(key) {
+				// leaf node
+				if bytes.Equal(key, path) {
+					return nil, v.Get(1).Raw(), nil, nil
+				}
+				return nil, nil, nil, nil
+			}
+			if len(path) < len(key) || !bytes.Equal(path[:len(key)], key) {
+				return nil, nil, nil, nil
+			}
+			v, path = v.Get(1), path[len(key):]
+
+		default:
+			return nil, nil, nil, fmt.Errorf("invalid trie node with %d items", v.Elems())
+		}
+	}
+}
+
+func copyBytes(b []byte) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+// GetProof returns the merkle proof of the account in the state of the root and
+// the proofs of the storage slots in the storage trie of the account
+func (s *State) GetProof(root types.Hash, addr types.Address, slots []types.Hash) (*state.AccountProof, error) {
+	proof, value, err := Prove(s.storage, root, hashit(addr.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	res := &state.AccountProof{
+		Proof:        proof,
+		StorageProof: []*state.StorageProof{},
+	}
+
+	storageRoot := types.EmptyRootHash
+	if value != nil {
+		var account state.Account
+		if err := account.UnmarshalRlp(value); err != nil {
+			return nil, err
+		}
+		res.Account = &account
+		storageRoot = account.Root
+	}
+
+	p := parserPool.Get()
+	defer parserPool.Put(p)
+
+	for _, slot := range slots {
+		proof, value, err := Prove(s.storage, storageRoot, hashit(slot.Bytes()))
+		if err != nil {
+			return nil, err
+		}
+		storageProof := &state.StorageProof{
+			Key:   slot,
+			Proof: proof,
+		}
+		if value != nil {
+			// the values are encoded in the storage trie
+			v, err := p.Parse(value)
+			if err != nil {
+				return nil, err
+			}
+			if storageProof.Value, err = v.GetBytes(nil); err != nil {
+				return nil, err
+			}
+		}
+		res.StorageProof = append(res.StorageProof, storageProof)
+	}
+	return res, nil
+}
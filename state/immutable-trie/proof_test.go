@@ -0,0 +1,73 @@
+package itrie
+
+import (
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/storage/memory"
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProof(t *testing.T) {
+	st := NewState(NewKVStorage(memory.NewBackend()))
+
+	addr1 := types.StringToAddress("1")
+	addr2 := types.StringToAddress("2")
+
+	txn := state.NewTxn(st, st.NewSnapshot())
+	for i := 0; i < 20; i++ {
+		txn.SetState(addr1, types.BytesToHash([]byte{byte(i)}), types.BytesToHash([]byte{byte(i + 1)}))
+	}
+	txn.SetNonce(addr1, 1)
+	for i := 3; i < 20; i++ {
+		txn.SetNonce(types.BytesToAddress([]byte{byte(i)}), 1)
+	}
+	_, root, err := txn.Commit(false)
+	assert.NoError(t, err)
+	stateRoot := types.BytesToHash(root)
+
+	proof, err := st.GetProof(stateRoot, addr1, []types.Hash{
+		types.BytesToHash([]byte{5}),
+		types.BytesToHash([]byte{50}),
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, proof.Account)
+	assert.Equal(t, uint64(1), proof.Account.Nonce)
+
+	// the account is proved against the state root
+	value, err := VerifyProof(stateRoot, hashit(addr1.Bytes()), proof.Proof)
+	assert.NoError(t, err)
+	assert.NotNil(t, value)
+
+	// and the slots against the storage root of the account
+	assert.Len(t, proof.StorageProof, 2)
+	assert.Equal(t, []byte{6}, proof.StorageProof[0].Value)
+	assert.Nil(t, proof.StorageProof[1].Value)
+
+	for _, slot := range proof.StorageProof {
+		value, err := VerifyProof(proof.Account.Root, hashit(slot.Key.Bytes()), slot.Proof)
+		assert.NoError(t, err)
+		assert.Equal(t, slot.Value == nil, value == nil)
+	}
+
+	// the proof of a missing account proves its absence
+	proof, err = st.GetProof(stateRoot, addr2, []types.Hash{types.BytesToHash([]byte{1})})
+	assert.NoError(t, err)
+	assert.Nil(t, proof.Account)
+	assert.NotEmpty(t, proof.Proof)
+	assert.Nil(t, proof.StorageProof[0].Value)
+	assert.Empty(t, proof.StorageProof[0].Proof)
+
+	value, err = VerifyProof(stateRoot, hashit(addr2.Bytes()), proof.Proof)
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	// a proof without all the nodes is not valid
+	_, err = VerifyProof(stateRoot, hashit(addr2.Bytes()), proof.Proof[1:])
+	assert.Error(t, err)
+
+	// the proof of a missing root cannot be built
+	_, err = st.GetProof(types.StringToHash("1"), addr1, nil)
+	assert.Error(t, err)
+}
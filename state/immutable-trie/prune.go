@@ -0,0 +1,241 @@
+package itrie
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// pruneBatchSize is the number of nodes deleted in each batch during a prune
+const pruneBatchSize = 1000
+
+// Prune deletes from the database all the trie nodes that are not reachable from the given
+// state roots and returns the number of nodes deleted. The states of the blocks that are not
+// in the list cannot be accessed after the prune, so it must not be used on archive nodes.
+// The database must not be written during the prune
+func Prune(db storage.Backend, roots []types.Hash) (uint64, error) {
+	return NewPruner(db).Prune(roots)
+}
+
+// Pruner deletes the old states of a trie database while it is written. The nodes written
+// through its storage since the previous prune are never deleted, since they might belong
+// to a state that is being committed and is not yet in the list of states to keep
+type Pruner struct {
+	db storage.Backend
+
+	// written are the nodes written since the current prune started,
+	// and protected the ones written between the previous prune and it
+	lock      sync.Mutex
+	written   map[types.Hash]struct{}
+	protected map[types.Hash]struct{}
+}
+
+// NewPruner creates a pruner of the trie database
+func NewPruner(db storage.Backend) *Pruner {
+	return &Pruner{
+		db:        db,
+		written:   map[types.Hash]struct{}{},
+		protected: map[types.Hash]struct{}{},
+	}
+}
+
+// Storage returns a trie storage on top of the database whose writes are tracked by the pruner
+func (p *Pruner) Storage() Storage {
+	return &prunerStorage{KVStorage: &KVStorage{p.db}, pruner: p}
+}
+
+// track records the nodes written, the lock must be held
+func (p *Pruner) track(keys [][]byte) {
+	for _, k := range keys {
+		if len(k) == types.HashLength {
+			p.written[types.BytesToHash(k)] = struct{}{}
+		}
+	}
+}
+
+// Prune deletes all the trie nodes that are neither reachable from the given state roots nor
+// written since the previous prune, and returns the number of nodes deleted. It must not run
+// concurrently with itself, but the database can be written through the storage of the pruner
+func (p *Pruner) Prune(roots []types.Hash) (uint64, error) {
+	// the nodes written from now on are protected until the next prune
+	p.lock.Lock()
+	p.protected, p.written = p.written, map[types.Hash]struct{}{}
+	protected := p.protected
+	p.lock.Unlock()
+
+	// mark the nodes of the states to keep
+	kv := NewKVStorage(p.db)
+	marked := map[types.Hash]struct{}{}
+	for _, root := range roots {
+		if err := markState(kv, root, marked); err != nil {
+			return 0, err
+		}
+	}
+
+	// sweep the rest of the nodes. The keys of the nodes are their
+	// hashes and the rest of the keys (i.e. code) have a prefix
+	var (
+		deleted  uint64
+		writeErr error
+	)
+
+	pending := []types.Hash{}
+	err := p.db.Iterate(nil, func(k, v []byte) bool {
+		if len(k) != types.HashLength {
+			return true
+		}
+		hash := types.BytesToHash(k)
+		if _, ok := marked[hash]; ok {
+			return true
+		}
+		if _, ok := protected[hash]; ok {
+			return true
+		}
+
+		if pending = append(pending, hash); len(pending) == pruneBatchSize {
+			var n uint64
+			if n, writeErr = p.delete(pending); writeErr != nil {
+				return false
+			}
+			deleted += n
+			pending = pending[:0]
+		}
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+	if writeErr != nil {
+		return 0, writeErr
+	}
+	n, err := p.delete(pending)
+	if err != nil {
+		return 0, err
+	}
+	return deleted + n, nil
+}
+
+// delete deletes the nodes that were not written since the prune started. The lock
+// is held until the deletes are written so that a node is never written in between
+func (p *Pruner) delete(nodes []types.Hash) (uint64, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	deleted := uint64(0)
+	batch := p.db.NewBatch()
+	for _, hash := range nodes {
+		if _, ok := p.written[hash]; ok {
+			continue
+		}
+		batch.Delete(hash.Bytes())
+		deleted++
+	}
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// prunerStorage is a trie storage whose writes are tracked by the pruner
+type prunerStorage struct {
+	*KVStorage
+	pruner *Pruner
+}
+
+func (s *prunerStorage) Put(k, v []byte) {
+	s.pruner.lock.Lock()
+	defer s.pruner.lock.Unlock()
+
+	s.pruner.track([][]byte{k})
+	s.KVStorage.Put(k, v)
+}
+
+func (s *prunerStorage) Batch() Batch {
+	return &prunerBatch{KVBatch: s.KVStorage.Batch().(*KVBatch), pruner: s.pruner}
+}
+
+// prunerBatch is a batch whose nodes are tracked by the pruner once it is written
+type prunerBatch struct {
+	*KVBatch
+	pruner *Pruner
+	keys   [][]byte
+}
+
+func (b *prunerBatch) Put(k, v []byte) {
+	b.keys = append(b.keys, append([]byte{}, k...))
+	b.KVBatch.Put(k, v)
+}
+
+func (b *prunerBatch) Write() error {
+	b.pruner.lock.Lock()
+	defer b.pruner.lock.Unlock()
+
+	b.pruner.track(b.keys)
+	return b.KVBatch.Write()
+}
+
+// markState marks the nodes of the account trie and of the storage tries of the accounts
+func markState(s Storage, root types.Hash, marked map[types.Hash]struct{}) error {
+	return markTrie(s, root, marked, func(value []byte) error {
+		var account state.Account
+		if err := account.UnmarshalRlp(value); err != nil {
+			return err
+		}
+		return markTrie(s, account.Root, marked, nil)
+	})
+}
+
+// markTrie marks the stored nodes of the trie and calls onLeaf with the value of each
+// leaf. The subtries that are already marked are skipped since they were already visited
+func markTrie(s Storage, root types.Hash, marked map[types.Hash]struct{}, onLeaf func(value []byte) error) error {
+	if root == types.EmptyRootHash {
+		return nil
+	}
+	if _, ok := marked[root]; ok {
+		return nil
+	}
+
+	node, ok, err := GetNode(root.Bytes(), s)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("trie node %s not found", root)
+	}
+	marked[root] = struct{}{}
+
+	return markNode(s, node, marked, onLeaf)
+}
+
+func markNode(s Storage, node Node, marked map[types.Hash]struct{}, onLeaf func(value []byte) error) error {
+	switch n := node.(type) {
+	case nil:
+		return nil
+
+	case *ValueNode:
+		if n.hash {
+			return markTrie(s, types.BytesToHash(n.buf), marked, onLeaf)
+		}
+		if onLeaf != nil {
+			return onLeaf(n.buf)
+		}
+		return nil
+
+	case *ShortNode:
+		return markNode(s, n.child, marked, onLeaf)
+
+	case *FullNode:
+		for _, child := range n.children {
+			if err := markNode(s, child, marked, onLeaf); err != nil {
+				return err
+			}
+		}
+		return markNode(s, n.value, marked, onLeaf)
+
+	default:
+		return fmt.Errorf("unknown node type %T", n)
+	}
+}
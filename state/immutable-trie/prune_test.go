@@ -0,0 +1,87 @@
+package itrie
+
+import (
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain/storage/memory"
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrune(t *testing.T) {
+	db := memory.NewBackend()
+	st := NewState(NewKVStorage(db))
+
+	addr1 := types.StringToAddress("1")
+	addr2 := types.StringToAddress("2")
+
+	txn := state.NewTxn(st, st.NewSnapshot())
+	for i := 0; i < 20; i++ {
+		txn.SetState(addr1, types.BytesToHash([]byte{byte(i)}), types.StringToHash("1"))
+	}
+	txn.SetNonce(addr2, 1)
+	snap1, root1, err := txn.Commit(false)
+	assert.NoError(t, err)
+
+	txn = state.NewTxn(st, snap1)
+	txn.SetState(addr1, types.BytesToHash([]byte{1}), types.StringToHash("2"))
+	txn.SetNonce(addr2, 2)
+	_, root2, err := txn.Commit(false)
+	assert.NoError(t, err)
+
+	deleted, err := Prune(db, []types.Hash{types.BytesToHash(root2)})
+	assert.NoError(t, err)
+	assert.NotZero(t, deleted)
+
+	// open the state without the cache of the tries
+	st = NewState(NewKVStorage(db))
+
+	_, err = st.NewSnapshotAt(types.BytesToHash(root1))
+	assert.Error(t, err)
+
+	snap2, err := st.NewSnapshotAt(types.BytesToHash(root2))
+	assert.NoError(t, err)
+
+	txn = state.NewTxn(st, snap2)
+	assert.Equal(t, uint64(2), txn.GetNonce(addr2))
+	assert.Equal(t, types.StringToHash("2"), txn.GetState(addr1, types.BytesToHash([]byte{1})))
+	for i := 2; i < 20; i++ {
+		assert.Equal(t, types.StringToHash("1"), txn.GetState(addr1, types.BytesToHash([]byte{byte(i)})))
+	}
+
+	// nothing else to prune
+	deleted, err = Prune(db, []types.Hash{types.BytesToHash(root2)})
+	assert.NoError(t, err)
+	assert.Zero(t, deleted)
+}
+
+func TestPruner_ProtectsRecentWrites(t *testing.T) {
+	db := memory.NewBackend()
+	pruner := NewPruner(db)
+	st := NewState(pruner.Storage())
+
+	addr := types.StringToAddress("1")
+
+	txn := state.NewTxn(st, st.NewSnapshot())
+	txn.SetNonce(addr, 1)
+	_, root, err := txn.Commit(false)
+	assert.NoError(t, err)
+
+	// the state written since the previous prune is not pruned even if it is not kept
+	_, err = pruner.Prune([]types.Hash{})
+	assert.NoError(t, err)
+
+	st.ResetCache()
+	_, err = st.NewSnapshotAt(types.BytesToHash(root))
+	assert.NoError(t, err)
+
+	// until the next one
+	deleted, err := pruner.Prune([]types.Hash{})
+	assert.NoError(t, err)
+	assert.NotZero(t, deleted)
+
+	st.ResetCache()
+	_, err = st.NewSnapshotAt(types.BytesToHash(root))
+	assert.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package itrie
 
 import (
+	"bytes"
 	"fmt"
 
 	lru "github.com/hashicorp/golang-lru"
@@ -10,20 +11,40 @@ import (
 )
 
 type State struct {
-	storage Storage
+	storage *cachedStorage
 	cache   *lru.Cache
+
+	codeCache     *lru.Cache
+	accountsCache *lru.Cache
+
+	// preimages enables the storage of the preimages of the hashed keys
+	preimages bool
+
+	metrics *Metrics
 }
 
 func NewState(storage Storage) *State {
 	cache, _ := lru.New(128)
 
 	s := &State{
-		storage: storage,
+		storage: &cachedStorage{Storage: storage},
 		cache:   cache,
+		metrics: newMetrics(),
+	}
+	s.storage.metrics = s.metrics
+
+	if err := s.SetCacheConfig(DefaultCacheConfig()); err != nil {
+		panic(err)
 	}
 	return s
 }
 
+// SetPreimages enables the storage of the addresses and slots whose hashes are the
+// keys of the tries. It is disabled by default, since it takes space for every key
+func (s *State) SetPreimages(enabled bool) {
+	s.preimages = enabled
+}
+
 func (s *State) NewSnapshot() state.Snapshot {
 	t := NewTrie()
 	t.state = s
@@ -36,7 +57,33 @@ func (s *State) SetCode(hash types.Hash, code []byte) {
 }
 
 func (s *State) GetCode(hash types.Hash) ([]byte, bool) {
-	return s.storage.GetCode(hash)
+	if code, ok := s.codeCache.Get(hash); ok {
+		s.metrics.hit("code")
+		return code.([]byte), true
+	}
+	s.metrics.miss("code")
+
+	code, ok := s.storage.GetCode(hash)
+	if ok {
+		s.codeCache.Add(hash, code)
+	}
+	return code, ok
+}
+
+// lookup reads the key of the trie at the root, the values are
+// cached by root since the trie at a root never changes
+func (s *State) lookup(root types.Hash, k []byte, fn func(k []byte) []byte) ([]byte, bool) {
+	key := string(root.Bytes()) + string(k)
+	if v, ok := s.accountsCache.Get(key); ok {
+		s.metrics.hit("accounts")
+		res := v.([]byte)
+		return res, res != nil
+	}
+	s.metrics.miss("accounts")
+
+	res := fn(k)
+	s.accountsCache.Add(key, res)
+	return res, res != nil
 }
 
 func (s *State) NewSnapshotAt(root types.Hash) (state.Snapshot, error) {
@@ -47,10 +94,13 @@ func (s *State) NewSnapshotAt(root types.Hash) (state.Snapshot, error) {
 
 	tt, ok := s.cache.Get(root)
 	if ok {
-		t := tt.(*Trie)
-		t.state = s
 		return tt.(*Trie), nil
 	}
+	return s.newTrieAt(root)
+}
+
+// newTrieAt reads the trie at the root from the storage
+func (s *State) newTrieAt(root types.Hash) (*Trie, error) {
 	n, ok, err := GetNode(root.Bytes(), s.storage)
 	if err != nil {
 		return nil, err
@@ -60,12 +110,58 @@ func (s *State) NewSnapshotAt(root types.Hash) (state.Snapshot, error) {
 	}
 	t := &Trie{
 		root:    n,
+		hash:    root,
 		state:   s,
 		storage: s.storage,
 	}
 	return t, nil
 }
 
+// Prefetch implements the state.PrefetchState interface. The tries are read from the
+// storage instead of from the cache of the tries, since the lookups modify their nodes
+func (s *State) Prefetch(root types.Hash, addr types.Address, slots []types.Hash) {
+	if root == types.EmptyRootHash {
+		return
+	}
+	t, err := s.newTrieAt(root)
+	if err != nil {
+		return
+	}
+	data, ok := t.Get(hashit(addr.Bytes()))
+	if !ok {
+		return
+	}
+	var account state.Account
+	if err := account.UnmarshalRlp(data); err != nil {
+		return
+	}
+
+	if len(account.CodeHash) != 0 && !bytes.Equal(account.CodeHash, emptyCodeHash) {
+		s.GetCode(types.BytesToHash(account.CodeHash))
+	}
+	if len(slots) == 0 || account.Root == types.EmptyRootHash {
+		return
+	}
+	storage, err := s.newTrieAt(account.Root)
+	if err != nil {
+		return
+	}
+	for _, slot := range slots {
+		storage.Get(hashit(slot.Bytes()))
+	}
+}
+
+// AddState caches the trie of the root. The trie is shared by the snapshots at
+// the root from then on, so its state and its hash are only set here
 func (s *State) AddState(root types.Hash, t *Trie) {
+	t.state = s
+	t.hash = root
 	s.cache.Add(root, t)
 }
+
+// ResetCache drops the cached tries and nodes, i.e. once they might have been pruned
+func (s *State) ResetCache() {
+	s.cache.Purge()
+	s.storage.nodes.Purge()
+	s.accountsCache.Purge()
+}
@@ -1,9 +1,14 @@
 package itrie
 
 import (
+	"fmt"
 	"testing"
 
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/blockchain/storage/memory"
 	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestState(t *testing.T) {
@@ -17,3 +22,30 @@ func buildPreState(pre state.PreStates) (state.State, state.Snapshot) {
 
 	return st, snap
 }
+
+// failingBackend is a backend whose batches fail to be written, i.e. with a full disk
+type failingBackend struct {
+	storage.Backend
+}
+
+func (b *failingBackend) NewBatch() storage.Batch {
+	return &failingBatch{b.Backend.NewBatch()}
+}
+
+type failingBatch struct {
+	storage.Batch
+}
+
+func (b *failingBatch) Write() error {
+	return fmt.Errorf("no space left on device")
+}
+
+func TestState_CommitError(t *testing.T) {
+	st := NewState(NewKVStorage(&failingBackend{memory.NewBackend()}))
+
+	// the commit fails instead of panicking if the trie cannot be written
+	txn := state.NewTxn(st, st.NewSnapshot())
+	txn.SetNonce(types.StringToAddress("1"), 1)
+	_, _, err := txn.Commit(false)
+	assert.Error(t, err)
+}
@@ -1,25 +1,44 @@
 package itrie
 
 import (
+	"bytes"
 	"fmt"
 
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/blockchain/storage/leveldb"
 	"github.com/0xPolygon/minimal/helper/hex"
 	"github.com/0xPolygon/minimal/types"
 	"github.com/hashicorp/go-hclog"
-	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/umbracle/fastrlp"
 )
 
 var parserPool fastrlp.ParserPool
 
 var (
-	// codePrefix is the code prefix for leveldb
+	// codePrefix is the code prefix for the storage backend
 	codePrefix = []byte("code")
+
+	// preimagePrefix is the prefix of the preimages of the hashed keys of the tries
+	preimagePrefix = []byte("preimage")
 )
 
+func preimageKey(hash []byte) []byte {
+	return append(append([]byte{}, preimagePrefix...), hash...)
+}
+
+// GetPreimage returns the address or the storage slot whose hash is a key of the tries
+func GetPreimage(s Storage, hash types.Hash) ([]byte, bool) {
+	return s.Get(preimageKey(hash.Bytes()))
+}
+
+// IsCodeKey returns true if the key of the storage backend is the key of a contract code
+func IsCodeKey(k []byte) bool {
+	return len(k) == len(codePrefix)+types.HashLength && bytes.HasPrefix(k, codePrefix)
+}
+
 type Batch interface {
 	Put(k, v []byte)
-	Write()
+	Write() error
 }
 
 // Storage stores the trie
@@ -31,23 +50,27 @@ type Storage interface {
 	GetCode(hash types.Hash) ([]byte, bool)
 }
 
-// KVStorage is a k/v storage on memory using leveldb
+// KVStorage is a k/v storage on top of a storage backend
 type KVStorage struct {
-	db *leveldb.DB
+	db storage.Backend
+}
+
+// NewKVStorage creates a trie storage on top of the backend
+func NewKVStorage(db storage.Backend) Storage {
+	return &KVStorage{db}
 }
 
-// KVBatch is a batch write for leveldb
+// KVBatch is a batch write for the storage backend
 type KVBatch struct {
-	db    *leveldb.DB
-	batch *leveldb.Batch
+	batch storage.Batch
 }
 
 func (b *KVBatch) Put(k, v []byte) {
-	b.batch.Put(k, v)
+	b.batch.Set(k, v)
 }
 
-func (b *KVBatch) Write() {
-	b.db.Write(b.batch, nil)
+func (b *KVBatch) Write() error {
+	return b.batch.Write()
 }
 
 func (kv *KVStorage) SetCode(hash types.Hash, code []byte) {
@@ -59,31 +82,27 @@ func (kv *KVStorage) GetCode(hash types.Hash) ([]byte, bool) {
 }
 
 func (kv *KVStorage) Batch() Batch {
-	return &KVBatch{db: kv.db, batch: &leveldb.Batch{}}
+	return &KVBatch{batch: kv.db.NewBatch()}
 }
 
 func (kv *KVStorage) Put(k, v []byte) {
-	kv.db.Put(k, v, nil)
+	kv.db.Set(k, v)
 }
 
 func (kv *KVStorage) Get(k []byte) ([]byte, bool) {
-	data, err := kv.db.Get(k, nil)
+	data, ok, err := kv.db.Get(k)
 	if err != nil {
-		if err.Error() == "leveldb: not found" {
-			return nil, false
-		} else {
-			panic(err)
-		}
+		panic(err)
 	}
-	return data, true
+	return data, ok
 }
 
 func NewLevelDBStorage(path string, logger hclog.Logger) (Storage, error) {
-	db, err := leveldb.OpenFile(path, nil)
+	db, err := leveldb.NewBackend(path)
 	if err != nil {
 		return nil, err
 	}
-	return &KVStorage{db}, nil
+	return NewKVStorage(db), nil
 }
 
 type memStorage struct {
@@ -133,7 +152,8 @@ func (m *memBatch) Put(p, v []byte) {
 	(*m.db)[hex.EncodeToHex(p)] = buf
 }
 
-func (m *memBatch) Write() {
+func (m *memBatch) Write() error {
+	return nil
 }
 
 // GetNode retrieves a node from storage
@@ -142,7 +162,12 @@ func GetNode(root []byte, storage Storage) (Node, bool, error) {
 	if !ok {
 		return nil, false, nil
 	}
+	n, err := decodeStoredNode(data, storage)
+	return n, err == nil, err
+}
 
+// decodeStoredNode decodes the encoding of a node stored by its hash
+func decodeStoredNode(data []byte, storage Storage) (Node, error) {
 	// NOTE. We dont need to make copies of the bytes because the nodes
 	// take the reference from data itself which is a safe copy.
 	p := parserPool.Get()
@@ -150,15 +175,13 @@ func GetNode(root []byte, storage Storage) (Node, bool, error) {
 
 	v, err := p.Parse(data)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 
 	if v.Type() != fastrlp.TypeArray {
-		return nil, false, fmt.Errorf("storage item should be an array")
+		return nil, fmt.Errorf("storage item should be an array")
 	}
-
-	n, err := decodeNode(v, storage)
-	return n, err == nil, err
+	return decodeNode(v, storage)
 }
 
 func decodeNode(v *fastrlp.Value, s Storage) (Node, error) {
@@ -101,6 +101,10 @@ type Trie struct {
 	root    Node
 	epoch   uint32
 	storage Storage
+
+	// hash is the root hash of the trie if it is read from the state,
+	// its values are cached by the state while it is set
+	hash types.Hash
 }
 
 func NewTrie() *Trie {
@@ -108,11 +112,17 @@ func NewTrie() *Trie {
 }
 
 func (t *Trie) Get(k []byte) ([]byte, bool) {
-	txn := t.Txn()
-	res := txn.Lookup(k)
+	if t.state != nil && t.hash != (types.Hash{}) {
+		return t.state.lookup(t.hash, k, t.lookup)
+	}
+	res := t.lookup(k)
 	return res, res != nil
 }
 
+func (t *Trie) lookup(k []byte) []byte {
+	return t.Txn().Lookup(k)
+}
+
 func hashit(k []byte) []byte {
 	h := sha3.NewLegacyKeccak256()
 	h.Write(k)
@@ -123,7 +133,7 @@ var accountArenaPool fastrlp.ArenaPool
 
 var stateArenaPool fastrlp.ArenaPool // TODO, Remove once we do update in fastrlp
 
-func (t *Trie) Commit(objs []*state.Object) (state.Snapshot, []byte) {
+func (t *Trie) Commit(objs []*state.Object) (state.Snapshot, []byte, error) {
 	// Create an insertion batch for all the entries
 	batch := t.storage.Batch()
 
@@ -151,7 +161,7 @@ func (t *Trie) Commit(objs []*state.Object) (state.Snapshot, []byte) {
 			if len(obj.Storage) != 0 {
 				localSnapshot, err := t.state.NewSnapshotAt(obj.Root)
 				if err != nil {
-					panic(err)
+					return nil, nil, err
 				}
 
 				localTxn := localSnapshot.(*Trie).Txn()
@@ -164,6 +174,9 @@ func (t *Trie) Commit(objs []*state.Object) (state.Snapshot, []byte) {
 					} else {
 						vv := ar1.NewBytes(bytes.TrimLeft(entry.Val, "\x00"))
 						localTxn.Insert(k, vv.MarshalTo(nil))
+						if t.state.preimages {
+							batch.Put(preimageKey(k), entry.Key)
+						}
 					}
 				}
 
@@ -183,7 +196,11 @@ func (t *Trie) Commit(objs []*state.Object) (state.Snapshot, []byte) {
 			vv := account.MarshalWith(arena)
 			data := vv.MarshalTo(nil)
 
-			tt.Insert(hashit(obj.Address.Bytes()), data)
+			key := hashit(obj.Address.Bytes())
+			tt.Insert(key, data)
+			if t.state.preimages {
+				batch.Put(preimageKey(key), obj.Address.Bytes())
+			}
 			arena.Reset()
 		}
 	}
@@ -195,10 +212,12 @@ func (t *Trie) Commit(objs []*state.Object) (state.Snapshot, []byte) {
 	nTrie.storage = t.storage
 
 	// Write all the entries to db
-	batch.Write()
+	if err := batch.Write(); err != nil {
+		return nil, nil, fmt.Errorf("failed to write the trie: %v", err)
+	}
 
 	t.state.AddState(types.BytesToHash(root), nTrie)
-	return nTrie, root
+	return nTrie, root, nil
 }
 
 // Hash returns the root hash of the trie. It does not write to the
@@ -214,6 +233,9 @@ func (t *Trie) Hash() types.Hash {
 }
 
 func (t *Trie) TryUpdate(key, value []byte) error {
+	// the root changes, the values of the old one are not cached for it anymore
+	t.hash = types.Hash{}
+
 	k := keybytesToHex(key)
 	if len(value) != 0 {
 		tt := t.Txn()
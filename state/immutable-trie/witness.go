@@ -0,0 +1,140 @@
+package itrie
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// witnessStorage records the trie nodes and the codes read from the storage, the
+// writes are kept in memory and are not recorded nor written to the storage
+type witnessStorage struct {
+	storage Storage
+
+	lock   sync.Mutex
+	writes map[string][]byte
+	nodes  map[types.Hash][]byte
+	codes  map[types.Hash][]byte
+}
+
+func newWitnessStorage(s Storage) *witnessStorage {
+	return &witnessStorage{
+		storage: s,
+		writes:  map[string][]byte{},
+		nodes:   map[types.Hash][]byte{},
+		codes:   map[types.Hash][]byte{},
+	}
+}
+
+func (w *witnessStorage) Put(k, v []byte) {
+	w.lock.Lock()
+	w.writes[string(k)] = append([]byte{}, v...)
+	w.lock.Unlock()
+}
+
+func (w *witnessStorage) Get(k []byte) ([]byte, bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if v, ok := w.writes[string(k)]; ok {
+		return v, true
+	}
+	v, ok := w.storage.Get(k)
+	if ok && len(k) == types.HashLength {
+		w.nodes[types.BytesToHash(k)] = v
+	}
+	return v, ok
+}
+
+func (w *witnessStorage) Batch() Batch {
+	return &witnessBatch{storage: w}
+}
+
+func (w *witnessStorage) SetCode(hash types.Hash, code []byte) {
+	w.Put(append(append([]byte{}, codePrefix...), hash.Bytes()...), code)
+}
+
+func (w *witnessStorage) GetCode(hash types.Hash) ([]byte, bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if v, ok := w.writes[string(codePrefix)+string(hash.Bytes())]; ok {
+		return v, true
+	}
+	code, ok := w.storage.GetCode(hash)
+	if ok {
+		w.codes[hash] = code
+	}
+	return code, ok
+}
+
+type witnessBatch struct {
+	storage *witnessStorage
+}
+
+func (b *witnessBatch) Put(k, v []byte) {
+	b.storage.Put(k, v)
+}
+
+func (b *witnessBatch) Write() error {
+	return nil
+}
+
+// witnessRecorder is a state on top of a witness storage
+type witnessRecorder struct {
+	*State
+	storage *witnessStorage
+}
+
+// NewWitnessRecorder implements the state.WitnessState interface. The recorder
+// has its own caches, so every trie node read by its snapshots is recorded
+func (s *State) NewWitnessRecorder() (state.WitnessRecorder, error) {
+	storage := newWitnessStorage(s.storage.Storage)
+	return &witnessRecorder{
+		State:   NewState(storage),
+		storage: storage,
+	}, nil
+}
+
+// Witness implements the state.WitnessRecorder interface
+func (r *witnessRecorder) Witness() *state.Witness {
+	r.storage.lock.Lock()
+	defer r.storage.lock.Unlock()
+
+	return &state.Witness{
+		Nodes: sortedValues(r.storage.nodes),
+		Codes: sortedValues(r.storage.codes),
+	}
+}
+
+func sortedValues(m map[types.Hash][]byte) [][]byte {
+	hashes := make([]types.Hash, 0, len(m))
+	for hash := range m {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i].Bytes(), hashes[j].Bytes()) < 0
+	})
+
+	res := make([][]byte, 0, len(hashes))
+	for _, hash := range hashes {
+		res = append(res, m[hash])
+	}
+	return res
+}
+
+// NewWitnessStorage returns an in memory storage with the trie nodes and the codes of the
+// witness, on top of which the block of the witness can be executed without the state
+func NewWitnessStorage(w *state.Witness) Storage {
+	s := NewMemoryStorage()
+	for _, node := range w.Nodes {
+		s.Put(hashit(node), node)
+	}
+	for _, code := range w.Codes {
+		s.SetCode(types.BytesToHash(hashit(code)), code)
+	}
+	return s
+}
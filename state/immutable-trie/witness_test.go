@@ -0,0 +1,104 @@
+package itrie
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/crypto"
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/state/runtime/evm"
+	"github.com/0xPolygon/minimal/state/runtime/precompiled"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWitness(t *testing.T) {
+	params := &chain.Params{Forks: chain.AllForksEnabled, ChainID: 100}
+	newExecutor := func(s Storage) *state.Executor {
+		executor := state.NewExecutor(params, NewState(s))
+		executor.SetRuntime(precompiled.NewPrecompiled())
+		executor.SetRuntime(evm.NewEVM())
+		executor.GetHash = func(*types.Header) state.GetHashByNumber {
+			return func(uint64) types.Hash {
+				return types.Hash{}
+			}
+		}
+		return executor
+	}
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	sender := crypto.PubKeyToAddress(&key.PublicKey)
+	signer := crypto.NewEIP155Signer(100)
+
+	// the contract deletes the slot 1, which collapses the storage trie into the slot 2
+	contract := types.StringToAddress("3")
+	genesis := &chain.Genesis{
+		GasLimit: 1000000,
+		Alloc: map[types.Address]*chain.GenesisAccount{
+			sender: {Balance: big.NewInt(1000000000)},
+			contract: {
+				Code: []byte{0x60, 0x00, 0x60, 0x01, 0x55, 0x00},
+				Storage: map[types.Hash]types.Hash{
+					types.StringToHash("1"): types.StringToHash("1"),
+					types.StringToHash("2"): types.StringToHash("2"),
+				},
+			},
+		},
+	}
+	for i := 0; i < 20; i++ {
+		genesis.Alloc[types.StringToAddress(string(rune('a'+i)))] = &chain.GenesisAccount{Balance: big.NewInt(1)}
+	}
+
+	storage := NewMemoryStorage()
+	executor := newExecutor(storage)
+	parentRoot, err := executor.WriteGenesis(genesis)
+	assert.NoError(t, err)
+
+	txns := []*types.Transaction{}
+	for i, to := range []types.Address{types.StringToAddress("2"), contract} {
+		to := to
+		txn, err := signer.SignTx(&types.Transaction{
+			Nonce:    uint64(i),
+			To:       &to,
+			Value:    big.NewInt(1),
+			Gas:      100000,
+			GasPrice: big.NewInt(1),
+		}, key)
+		assert.NoError(t, err)
+		txns = append(txns, txn)
+	}
+	block := &types.Block{
+		Header:       &types.Header{Number: 1, GasLimit: 1000000},
+		Transactions: txns,
+	}
+	result, err := executor.ProcessBlock(parentRoot, block)
+	assert.NoError(t, err)
+	block.Header.StateRoot = result.Root
+
+	// the state is not modified by the witness
+	size := len(storage.(*memStorage).db)
+	w, err := executor.Witness(parentRoot, block)
+	assert.NoError(t, err)
+	assert.Equal(t, size, len(storage.(*memStorage).db))
+
+	assert.Equal(t, parentRoot, w.Root)
+	assert.NotEmpty(t, w.Nodes)
+	assert.Len(t, w.Codes, 1)
+
+	// the block is executed on top of the witness alone
+	result, err = newExecutor(NewWitnessStorage(w)).ProcessBlock(w.Root, block)
+	assert.NoError(t, err)
+	assert.Equal(t, block.Header.StateRoot, result.Root)
+
+	// rlp encoding
+	w2 := &state.Witness{}
+	assert.NoError(t, w2.UnmarshalRLP(w.MarshalRLP()))
+	assert.Equal(t, w, w2)
+
+	// the state root of the block is checked
+	block.Header.StateRoot = types.StringToHash("1")
+	_, err = executor.Witness(parentRoot, block)
+	assert.Error(t, err)
+}
@@ -0,0 +1,140 @@
+package state
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "executor"
+
+// Metrics are the prometheus metrics of the execution of the blocks
+type Metrics struct {
+	// number of blocks and txns executed, and gas used by them
+	blocks  prometheus.Counter
+	txns    prometheus.Counter
+	gasUsed prometheus.Counter
+
+	// throughput of the execution of the last block
+	gasPerSecond  prometheus.Gauge
+	txnsPerSecond prometheus.Gauge
+
+	// number of storage reads and writes of the txns of a block
+	sloads  prometheus.Histogram
+	sstores prometheus.Histogram
+
+	// ratio of the reads of the state served by its caches while a block is executed,
+	// the reads of the state done concurrently with the execution are included
+	cacheHitRatio prometheus.Gauge
+}
+
+func newMetrics() *Metrics {
+	return &Metrics{
+		blocks: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "blocks_total",
+			Help:      "Number of blocks executed",
+		}),
+		txns: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "txns_total",
+			Help:      "Number of transactions executed in the blocks",
+		}),
+		gasUsed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "gas_used_total",
+			Help:      "Gas used by the transactions executed in the blocks",
+		}),
+		gasPerSecond: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "block_gas_per_second",
+			Help:      "Gas used per second of execution of the last block",
+		}),
+		txnsPerSecond: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "block_txns_per_second",
+			Help:      "Transactions executed per second of execution of the last block",
+		}),
+		sloads: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "block_sloads",
+			Help:      "Number of storage reads (SLOAD) of the transactions of a block",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 10),
+		}),
+		sstores: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "block_sstores",
+			Help:      "Number of storage writes (SSTORE) of the transactions of a block",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 10),
+		}),
+		cacheHitRatio: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "block_cache_hit_ratio",
+			Help:      "Ratio of the reads of the state served by its caches while the last block was executed",
+		}),
+	}
+}
+
+// RegisterMetrics registers the metrics of the executor in the prometheus registerer
+func (e *Executor) RegisterMetrics(reg prometheus.Registerer) error {
+	m := e.metrics
+	collectors := []prometheus.Collector{
+		m.blocks,
+		m.txns,
+		m.gasUsed,
+		m.gasPerSecond,
+		m.txnsPerSecond,
+		m.sloads,
+		m.sstores,
+		m.cacheHitRatio,
+	}
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cacheStats returns the reads served by the caches of the state and the ones
+// not found in them, which are 0 if the state does not count them
+func (e *Executor) cacheStats() (uint64, uint64) {
+	if st, ok := e.state.(CacheStatsState); ok {
+		return st.CacheStats()
+	}
+	return 0, 0
+}
+
+// blockStats are the stats of the execution of a block
+type blockStats struct {
+	start  time.Time
+	hits   uint64
+	misses uint64
+}
+
+func (e *Executor) startBlock() *blockStats {
+	hits, misses := e.cacheStats()
+	return &blockStats{start: time.Now(), hits: hits, misses: misses}
+}
+
+// observeBlock updates the metrics once the block of the transition is executed
+func (e *Executor) observeBlock(stats *blockStats, t *Transition, txns int) {
+	m := e.metrics
+	elapsed := time.Since(stats.start).Seconds()
+
+	m.blocks.Inc()
+	m.txns.Add(float64(txns))
+	m.gasUsed.Add(float64(t.totalGas))
+	if elapsed > 0 {
+		m.gasPerSecond.Set(float64(t.totalGas) / elapsed)
+		m.txnsPerSecond.Set(float64(txns) / elapsed)
+	}
+	m.sloads.Observe(float64(t.sloads))
+	m.sstores.Observe(float64(t.sstores))
+
+	hits, misses := e.cacheStats()
+	hits, misses = hits-stats.hits, misses-stats.misses
+	if hits+misses != 0 {
+		m.cacheHitRatio.Set(float64(hits) / float64(hits+misses))
+	}
+}
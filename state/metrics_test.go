@@ -0,0 +1,102 @@
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// storageRuntime reads and writes a storage slot of the contracts
+type storageRuntime struct {
+	noopRuntime
+}
+
+func (storageRuntime) Run(c *runtime.Contract, host runtime.Host, config *chain.ForksInTime) ([]byte, uint64, error) {
+	host.GetStorage(c.Address, hash1)
+	host.SetStorage(c.Address, hash1, hash2, config)
+	return nil, c.Gas, nil
+}
+
+// statsState counts three hits and a miss of its caches on each snapshot
+type statsState struct {
+	noCodeState
+
+	hits, misses uint64
+}
+
+func (s *statsState) NewSnapshotAt(root types.Hash) (Snapshot, error) {
+	s.hits += 3
+	s.misses++
+
+	snap, err := s.noCodeState.NewSnapshotAt(root)
+	if err != nil {
+		return nil, err
+	}
+	return &noCommitSnapshot{snap}, nil
+}
+
+// noCommitSnapshot is a snapshot whose commits are discarded
+type noCommitSnapshot struct {
+	Snapshot
+}
+
+func (s *noCommitSnapshot) Commit(objs []*Object) (Snapshot, []byte, error) {
+	return s, types.ZeroHash.Bytes(), nil
+}
+
+func (s *statsState) CacheStats() (uint64, uint64) {
+	return s.hits, s.misses
+}
+
+func TestExecutor_Metrics(t *testing.T) {
+	executor, root := newTestExecutor(&chain.Params{Forks: chain.AllForksEnabled, ChainID: 1})
+	executor.state = &statsState{noCodeState: executor.state.(noCodeState)}
+	executor.runtimes = []runtime.Runtime{storageRuntime{}}
+
+	reg := prometheus.NewRegistry()
+	assert.NoError(t, executor.RegisterMetrics(reg))
+
+	addr3 := types.StringToAddress("3")
+	txn := func(nonce uint64) *types.Transaction {
+		return &types.Transaction{
+			From:     addr1,
+			Nonce:    nonce,
+			To:       &addr3,
+			Value:    big.NewInt(1),
+			Gas:      21000,
+			GasPrice: big.NewInt(0),
+		}
+	}
+	block := &types.Block{
+		Header:       &types.Header{Number: 1, GasLimit: 100000},
+		Transactions: []*types.Transaction{txn(0), txn(1)},
+	}
+	_, err := executor.ProcessBlock(root, block)
+	assert.NoError(t, err)
+
+	m := executor.metrics
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.blocks))
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.txns))
+	assert.Equal(t, float64(42000), testutil.ToFloat64(m.gasUsed))
+	assert.NotZero(t, testutil.ToFloat64(m.gasPerSecond))
+	assert.NotZero(t, testutil.ToFloat64(m.txnsPerSecond))
+	assert.Equal(t, 0.75, testutil.ToFloat64(m.cacheHitRatio))
+
+	// each txn reads and writes a slot
+	assert.Equal(t, 1, testutil.CollectAndCount(m.sloads))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.sstores))
+
+	transition, err := executor.BeginTxn(root, block.Header)
+	assert.NoError(t, err)
+	for _, txn := range []*types.Transaction{txn(0), txn(1)} {
+		assert.NoError(t, transition.Write(txn))
+	}
+	assert.Equal(t, uint64(2), transition.sloads)
+	assert.Equal(t, uint64(2), transition.sstores)
+}
@@ -0,0 +1,72 @@
+package state
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/crypto"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// nativeMinterGas is the gas cost of a mint or a burn of the native token
+const nativeMinterGas uint64 = 20000
+
+// errBurnExceedsBalance is returned if the burn is over the balance of the account, which is
+// not runtime.ErrNotEnoughFunds since that one makes the txn of a direct call invalid
+var errBurnExceedsBalance = fmt.Errorf("burn amount exceeds balance")
+
+var (
+	// selectors of mint(address,uint256) and burn(address,uint256)
+	mintSelector = crypto.Keccak256([]byte("mint(address,uint256)"))[:4]
+	burnSelector = crypto.Keccak256([]byte("burn(address,uint256)"))[:4]
+)
+
+// isNativeMinterCall returns true if the contract calls the native minter
+// address, which is executed by runNativeMinter instead of the runtimes
+func (t *Transition) isNativeMinterCall(c *runtime.Contract) bool {
+	minter := t.r.config.NativeMinter
+	return minter != nil && c.CodeAddress == chain.NativeMinterAddress && minter.Active(uint64(t.ctx.Number))
+}
+
+// runNativeMinter mints or burns the native token of an account. Only the direct calls of the
+// minter contract are allowed, the input is the abi encoding of mint or burn(address,uint256)
+func (t *Transition) runNativeMinter(c *runtime.Contract, callType runtime.CallType) ([]byte, uint64, error) {
+	if callType != runtime.Call || c.Caller != t.r.config.NativeMinter.Minter {
+		return nil, 0, runtime.ErrMintNotAllowed
+	}
+	if c.Static {
+		// the minter is called from a static call, which cannot modify the state
+		return nil, 0, runtime.ErrWriteProtection
+	}
+	if c.Value != nil && c.Value.Sign() != 0 {
+		return nil, 0, fmt.Errorf("native minter calls cannot transfer value")
+	}
+	if c.Gas < nativeMinterGas {
+		return nil, 0, runtime.ErrGasOverflow
+	}
+	gas := c.Gas - nativeMinterGas
+
+	input := c.Input
+	if len(input) != 4+2*32 {
+		return nil, 0, fmt.Errorf("invalid native minter input of %d bytes", len(input))
+	}
+	addr := types.BytesToAddress(input[4:36])
+	amount := new(big.Int).SetBytes(input[36:68])
+
+	switch string(input[:4]) {
+	case string(mintSelector):
+		t.state.AddBalance(addr, amount)
+
+	case string(burnSelector):
+		if t.state.GetBalance(addr).Cmp(amount) < 0 {
+			return nil, 0, errBurnExceedsBalance
+		}
+		t.state.SubBalance(addr, amount)
+
+	default:
+		return nil, 0, fmt.Errorf("unknown native minter method %x", input[:4])
+	}
+	return nil, gas, nil
+}
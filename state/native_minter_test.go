@@ -0,0 +1,79 @@
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutor_NativeMinter(t *testing.T) {
+	addr3 := types.StringToAddress("3")
+
+	executor, root := newTestExecutor(&chain.Params{
+		Forks:        chain.AllForksEnabled,
+		ChainID:      1,
+		NativeMinter: &chain.NativeMinterParams{Minter: addr1, Block: chain.NewFork(1)},
+	})
+
+	begin := func(number uint64) *Transition {
+		transition, err := executor.BeginTxn(root, &types.Header{Number: number, GasLimit: 1000000})
+		assert.NoError(t, err)
+		return transition
+	}
+	call := func(transition *Transition, from types.Address, selector []byte, amount int64) error {
+		input := append([]byte{}, selector...)
+		input = append(input, types.BytesToHash(addr3.Bytes()).Bytes()...)
+		input = append(input, types.BytesToHash(big.NewInt(amount).Bytes()).Bytes()...)
+
+		_, failed, err := transition.Apply(&types.Transaction{
+			From:     from,
+			Nonce:    transition.Txn().GetNonce(from),
+			To:       &chain.NativeMinterAddress,
+			Value:    big.NewInt(0),
+			Gas:      100000,
+			GasPrice: big.NewInt(0),
+			Input:    input,
+		})
+		assert.NoError(t, err)
+		if failed {
+			return transition.ExecutionError()
+		}
+		return nil
+	}
+
+	// the minter mints and burns the native token
+	transition := begin(1)
+	assert.NoError(t, call(transition, addr1, mintSelector, 100))
+	assert.Equal(t, big.NewInt(100), transition.Txn().GetBalance(addr3))
+
+	assert.NoError(t, call(transition, addr1, burnSelector, 40))
+	assert.Equal(t, big.NewInt(60), transition.Txn().GetBalance(addr3))
+
+	// the burn fails without enough balance
+	assert.Equal(t, errBurnExceedsBalance, call(transition, addr1, burnSelector, 100))
+
+	// only the minter is allowed
+	assert.Equal(t, runtime.ErrMintNotAllowed, call(transition, addr2, mintSelector, 100))
+	assert.Equal(t, big.NewInt(60), transition.Txn().GetBalance(addr3))
+
+	// the minter cannot be called within a static call
+	input := append([]byte{}, mintSelector...)
+	input = append(input, types.BytesToHash(addr3.Bytes()).Bytes()...)
+	input = append(input, types.BytesToHash(big.NewInt(100).Bytes()).Bytes()...)
+
+	contract := runtime.NewContractCall(1, addr1, addr1, chain.NativeMinterAddress, big.NewInt(0), 100000, nil, input)
+	contract.Static = true
+
+	_, _, err := transition.runNativeMinter(contract, runtime.Call)
+	assert.Equal(t, runtime.ErrWriteProtection, err)
+	assert.Equal(t, big.NewInt(60), transition.Txn().GetBalance(addr3))
+
+	// the call is a plain call before the activation block
+	transition = begin(0)
+	assert.NoError(t, call(transition, addr1, mintSelector, 100))
+	assert.Equal(t, big.NewInt(0), transition.Txn().GetBalance(addr3))
+}
@@ -0,0 +1,52 @@
+package state
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// AccountOverride overrides the fields of an account in the state, the nil fields are
+// kept. State replaces the whole storage of the account while StateDiff only replaces
+// the given slots, so they cannot be set at the same time
+type AccountOverride struct {
+	Nonce     *uint64
+	Code      []byte
+	Balance   *big.Int
+	State     map[types.Hash]types.Hash
+	StateDiff map[types.Hash]types.Hash
+}
+
+// StateOverride are the accounts overridden in the state before the execution of a call
+type StateOverride map[types.Address]*AccountOverride
+
+// WithStateOverride applies the overrides to the state of the transition. They are
+// not meant to be committed, the transition is only used to simulate calls
+func (t *Transition) WithStateOverride(override StateOverride) error {
+	for addr, account := range override {
+		if account == nil {
+			continue
+		}
+		if account.State != nil && account.StateDiff != nil {
+			return fmt.Errorf("account %s has both state and state diff overrides", addr)
+		}
+
+		if account.Nonce != nil {
+			t.state.SetNonce(addr, *account.Nonce)
+		}
+		if account.Code != nil {
+			t.state.SetCode(addr, account.Code)
+		}
+		if account.Balance != nil {
+			t.state.SetBalance(addr, account.Balance)
+		}
+		if account.State != nil {
+			t.state.SetFullStorage(addr, account.State)
+		}
+		for key, value := range account.StateDiff {
+			t.state.SetState(addr, key, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package state
+
+import (
+	"sync"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// DefaultPrefetchWorkers is the default number of goroutines that prefetch the state of a block
+const DefaultPrefetchWorkers = 4
+
+// prefetchItem is an account and the storage slots of it read by a txn
+type prefetchItem struct {
+	addr  types.Address
+	slots []types.Hash
+}
+
+// SetPrefetchWorkers sets the number of goroutines that load the accounts, the code and the
+// storage slots referenced by the txns of a block, i.e. the senders, the receivers and the
+// access lists, into the caches of the state while the block is executed. The prefetch is
+// disabled if it is 0 or if the state does not implement PrefetchState
+func (e *Executor) SetPrefetchWorkers(workers int) {
+	e.prefetchWorkers = workers
+}
+
+// prefetch reads the state of the txns at the root with the workers of the executor,
+// until all of them are read or the abort channel is closed
+func (e *Executor) prefetch(root types.Hash, txns []*types.Transaction, abort <-chan struct{}) {
+	st, ok := e.state.(PrefetchState)
+	if !ok || e.prefetchWorkers <= 0 {
+		return
+	}
+
+	items := make(chan *prefetchItem, e.prefetchWorkers)
+	go func() {
+		defer close(items)
+
+		seen := map[types.Address]struct{}{}
+		send := func(item *prefetchItem) bool {
+			if len(item.slots) == 0 {
+				if _, ok := seen[item.addr]; ok {
+					return true
+				}
+				seen[item.addr] = struct{}{}
+			}
+			select {
+			case items <- item:
+				return true
+			case <-abort:
+				return false
+			}
+		}
+
+		for _, txn := range txns {
+			if !send(&prefetchItem{addr: txn.From}) {
+				return
+			}
+			if txn.To != nil && !send(&prefetchItem{addr: *txn.To}) {
+				return
+			}
+			for _, tuple := range txn.AccessList {
+				if !send(&prefetchItem{addr: tuple.Address, slots: tuple.StorageKeys}) {
+					return
+				}
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < e.prefetchWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for item := range items {
+				st.Prefetch(root, item.addr, item.slots)
+			}
+		}()
+	}
+	wg.Wait()
+}
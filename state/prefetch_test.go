@@ -0,0 +1,53 @@
+package state
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPrefetchState struct {
+	State
+
+	lock  sync.Mutex
+	items map[types.Address][]types.Hash
+}
+
+func (m *mockPrefetchState) Prefetch(root types.Hash, addr types.Address, slots []types.Hash) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.items[addr] = append(m.items[addr], slots...)
+}
+
+func TestExecutor_Prefetch(t *testing.T) {
+	st := &mockPrefetchState{items: map[types.Address][]types.Hash{}}
+
+	executor := NewExecutor(&chain.Params{}, st)
+	executor.SetPrefetchWorkers(2)
+
+	addr3 := types.StringToAddress("3")
+	txns := []*types.Transaction{
+		{From: addr1, To: &addr2},
+		{From: addr1, AccessList: types.AccessList{
+			{Address: addr3, StorageKeys: []types.Hash{hash1, hash2}},
+		}},
+	}
+	executor.prefetch(types.Hash{}, txns, make(chan struct{}))
+
+	assert.Len(t, st.items, 3)
+	assert.Empty(t, st.items[addr1])
+	assert.Equal(t, []types.Hash{hash1, hash2}, st.items[addr3])
+
+	// nothing is read once it is aborted
+	st.items = map[types.Address][]types.Hash{}
+
+	abort := make(chan struct{})
+	close(abort)
+	executor.SetPrefetchWorkers(1)
+	executor.prefetch(types.Hash{}, txns, abort)
+	assert.True(t, len(st.items) <= 1)
+}
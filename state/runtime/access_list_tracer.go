@@ -0,0 +1,112 @@
+package runtime
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// opcodes of the calls traced
+const (
+	opCALL         = 0xf1
+	opCALLCODE     = 0xf2
+	opDELEGATECALL = 0xf4
+	opSTATICCALL   = 0xfa
+)
+
+// AccessListTracer is a tracer that collects the addresses and the storage slots accessed
+// by the txn, to build its access list (eip-2930). The excluded addresses, i.e. the sender,
+// the receiver and the precompiled contracts, are warm anyway and they are not in the list
+type AccessListTracer struct {
+	excluded map[types.Address]struct{}
+	list     map[types.Address]map[types.Hash]struct{}
+}
+
+// NewAccessListTracer creates an access list tracer that starts with the given access list
+func NewAccessListTracer(list types.AccessList, excluded []types.Address) *AccessListTracer {
+	l := &AccessListTracer{
+		excluded: map[types.Address]struct{}{},
+		list:     map[types.Address]map[types.Hash]struct{}{},
+	}
+	for _, addr := range excluded {
+		l.excluded[addr] = struct{}{}
+	}
+	for _, tuple := range list {
+		l.addAddress(tuple.Address)
+		for _, slot := range tuple.StorageKeys {
+			l.addSlot(tuple.Address, slot)
+		}
+	}
+	return l
+}
+
+func (l *AccessListTracer) addAddress(addr types.Address) {
+	if _, ok := l.excluded[addr]; ok {
+		return
+	}
+	if _, ok := l.list[addr]; !ok {
+		l.list[addr] = map[types.Hash]struct{}{}
+	}
+}
+
+func (l *AccessListTracer) addSlot(addr types.Address, slot types.Hash) {
+	// the slots of the excluded addresses are still listed
+	if _, ok := l.list[addr]; !ok {
+		l.list[addr] = map[types.Hash]struct{}{}
+	}
+	l.list[addr][slot] = struct{}{}
+}
+
+// CaptureEnter implements the Tracer interface
+func (l *AccessListTracer) CaptureEnter(typ CallType, from, to types.Address, input []byte, gas uint64, value *big.Int) {
+}
+
+// CaptureExit implements the Tracer interface
+func (l *AccessListTracer) CaptureExit(output []byte, gasLeft uint64, err error) {
+}
+
+// CaptureState implements the Tracer interface
+func (l *AccessListTracer) CaptureState(step *TraceStep) {
+	stack := step.Stack
+
+	switch step.Op {
+	case opSLOAD, opSSTORE:
+		if len(stack) >= 1 {
+			l.addSlot(step.Address, types.BytesToHash(stack[len(stack)-1].Bytes()))
+		}
+
+	case opBALANCE, opEXTCODESIZE, opEXTCODECOPY, opEXTCODEHASH, opSELFDESTRUCT:
+		if len(stack) >= 1 {
+			l.addAddress(types.BytesToAddress(stack[len(stack)-1].Bytes()))
+		}
+
+	case opCALL, opCALLCODE, opDELEGATECALL, opSTATICCALL:
+		if len(stack) >= 2 {
+			l.addAddress(types.BytesToAddress(stack[len(stack)-2].Bytes()))
+		}
+	}
+}
+
+// AccessList returns the access list, sorted by address and slot
+func (l *AccessListTracer) AccessList() types.AccessList {
+	list := make(types.AccessList, 0, len(l.list))
+	for addr, slots := range l.list {
+		tuple := types.AccessTuple{
+			Address:     addr,
+			StorageKeys: make([]types.Hash, 0, len(slots)),
+		}
+		for slot := range slots {
+			tuple.StorageKeys = append(tuple.StorageKeys, slot)
+		}
+		sort.Slice(tuple.StorageKeys, func(i, j int) bool {
+			return bytes.Compare(tuple.StorageKeys[i].Bytes(), tuple.StorageKeys[j].Bytes()) < 0
+		})
+		list = append(list, tuple)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return bytes.Compare(list[i].Address.Bytes(), list[j].Address.Bytes()) < 0
+	})
+	return list
+}
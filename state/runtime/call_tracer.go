@@ -0,0 +1,82 @@
+package runtime
+
+import (
+	"math/big"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// CallFrame is a call or a create traced by the call tracer
+type CallFrame struct {
+	Type    CallType
+	From    types.Address
+	To      types.Address
+	Value   *big.Int
+	Gas     uint64
+	GasUsed uint64
+	Input   []byte
+	Output  []byte
+	Err     error
+
+	// Calls are the calls made by the frame
+	Calls []*CallFrame
+}
+
+// CallTracer is a tracer that keeps the tree of the calls of the txn
+type CallTracer struct {
+	onlyTopCall bool
+
+	root  *CallFrame
+	stack []*CallFrame
+}
+
+// NewCallTracer creates a call tracer, which only keeps the first call if onlyTopCall is set
+func NewCallTracer(onlyTopCall bool) *CallTracer {
+	return &CallTracer{onlyTopCall: onlyTopCall}
+}
+
+// CaptureEnter implements the Tracer interface
+func (c *CallTracer) CaptureEnter(typ CallType, from, to types.Address, input []byte, gas uint64, value *big.Int) {
+	frame := &CallFrame{
+		Type:  typ,
+		From:  from,
+		To:    to,
+		Gas:   gas,
+		Input: append([]byte{}, input...),
+	}
+	if value != nil {
+		frame.Value = new(big.Int).Set(value)
+	}
+
+	if len(c.stack) == 0 {
+		c.root = frame
+	} else if !c.onlyTopCall {
+		parent := c.stack[len(c.stack)-1]
+		parent.Calls = append(parent.Calls, frame)
+	}
+	c.stack = append(c.stack, frame)
+}
+
+// CaptureExit implements the Tracer interface
+func (c *CallTracer) CaptureExit(output []byte, gasLeft uint64, err error) {
+	if len(c.stack) == 0 {
+		return
+	}
+	frame := c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+
+	if gasLeft < frame.Gas {
+		frame.GasUsed = frame.Gas - gasLeft
+	}
+	frame.Output = append([]byte{}, output...)
+	frame.Err = err
+}
+
+// CaptureState implements the Tracer interface
+func (c *CallTracer) CaptureState(step *TraceStep) {
+}
+
+// Result returns the first call of the txn, nil if there is none
+func (c *CallTracer) Result() *CallFrame {
+	return c.root
+}
@@ -0,0 +1,89 @@
+package evm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// mockCallHost runs the calls with the evm, all the accounts have the same code
+type mockCallHost struct {
+	runtime.Host
+
+	evm    *EVM
+	code   []byte
+	config *chain.ForksInTime
+}
+
+func (m *mockCallHost) GetCode(addr types.Address) []byte {
+	return m.code
+}
+
+func (m *mockCallHost) GetBalance(addr types.Address) *big.Int {
+	return big.NewInt(0)
+}
+
+func (m *mockCallHost) AccountExists(addr types.Address) bool {
+	return true
+}
+
+func (m *mockCallHost) Empty(addr types.Address) bool {
+	return false
+}
+
+func (m *mockCallHost) GetTracer() runtime.Tracer {
+	return nil
+}
+
+func (m *mockCallHost) AddressInAccessList(addr types.Address) bool {
+	return true
+}
+
+func (m *mockCallHost) Callx(c *runtime.Contract, h runtime.Host) ([]byte, uint64, error) {
+	c.Code = m.code
+	return m.evm.Run(c, h, m.config)
+}
+
+func benchmarkEVM(b *testing.B, code, callee []byte) {
+	config := chain.AllForksEnabled.At(0)
+
+	e := NewEVM()
+	host := &mockCallHost{evm: e, code: callee, config: &config}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		c := runtime.NewContractCall(1, types.Address{}, types.Address{}, types.Address{}, big.NewInt(0), 10000000, code, nil)
+		if _, _, err := e.Run(c, host, &config); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEVM_Loop(b *testing.B) {
+	// 256 iterations of mstore(0, i) and keccak256(0, 32)
+	code := []byte{
+		0x61, 0x01, 0x00, 0x5b,
+		0x60, 0x01, 0x90, 0x03,
+		0x80, 0x60, 0x00, 0x52,
+		0x60, 0x20, 0x60, 0x00, 0x20, 0x50,
+		0x80, 0x60, 0x03, 0x57, 0x00,
+	}
+	benchmarkEVM(b, code, nil)
+}
+
+func BenchmarkEVM_Call(b *testing.B) {
+	// 256 calls to a contract that returns a word of its memory
+	code := []byte{
+		0x61, 0x01, 0x00, 0x5b,
+		0x60, 0x01, 0x90, 0x03,
+		0x60, 0x20, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0xaa, 0x5a, 0xf1, 0x50,
+		0x80, 0x60, 0x03, 0x57, 0x00,
+	}
+	callee := []byte{0x60, 0x01, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3}
+	benchmarkEVM(b, code, callee)
+}
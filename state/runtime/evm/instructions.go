@@ -122,13 +122,7 @@ func opExp(c *state) {
 	x := c.pop()
 	y := c.top()
 
-	var gas uint64
-	if c.config.EIP158 {
-		gas = 50
-	} else {
-		gas = 10
-	}
-	gasCost := uint64((y.BitLen()+7)/8) * gas
+	gasCost := uint64((y.BitLen()+7)/8) * c.rules.Gas.ExpByte
 	if !c.consumeGas(gasCost) {
 		return
 	}
@@ -321,11 +315,6 @@ func equalOrOverflowsUint256(b *big.Int) bool {
 }
 
 func opShl(c *state) {
-	if !c.config.Constantinople {
-		c.exit(errOpCodeNotFound)
-		return
-	}
-
 	shift := c.pop()
 	value := c.top()
 
@@ -338,11 +327,6 @@ func opShl(c *state) {
 }
 
 func opShr(c *state) {
-	if !c.config.Constantinople {
-		c.exit(errOpCodeNotFound)
-		return
-	}
-
 	shift := c.pop()
 	value := c.top()
 
@@ -355,11 +339,6 @@ func opShr(c *state) {
 }
 
 func opSar(c *state) {
-	if !c.config.Constantinople {
-		c.exit(errOpCodeNotFound)
-		return
-	}
-
 	shift := c.pop()
 	value := to256(c.top())
 
@@ -441,17 +420,37 @@ func opMStore8(c *state) {
 
 // --- storage ---
 
+// eip-2929 costs of the accesses to the state
+const (
+	coldAccountAccessCost = 2600
+	coldSloadCost         = 2100
+	warmStorageReadCost   = 100
+)
+
+// accessAddress adds the address to the access list and returns the cost of the access
+func (c *state) accessAddress(addr types.Address) uint64 {
+	if c.host.AddressInAccessList(addr) {
+		return warmStorageReadCost
+	}
+	c.host.AddAddressToAccessList(addr)
+	return coldAccountAccessCost
+}
+
+// accessSlot adds the slot of the contract to the access list and returns the cost of the access
+func (c *state) accessSlot(slot types.Hash) uint64 {
+	if c.host.SlotInAccessList(c.msg.Address, slot) {
+		return warmStorageReadCost
+	}
+	c.host.AddSlotToAccessList(c.msg.Address, slot)
+	return coldSloadCost
+}
+
 func opSload(c *state) {
 	loc := c.top()
 
-	var gas uint64
-	if c.config.Istanbul {
-		// eip-1884
-		gas = 800
-	} else if c.config.EIP150 {
-		gas = 200
-	} else {
-		gas = 50
+	gas := c.rules.Gas.SLoad
+	if c.rules.AccessLists {
+		gas = c.accessSlot(bigToHash(loc))
 	}
 	if !c.consumeGas(gas) {
 		return
@@ -467,7 +466,7 @@ func opSStore(c *state) {
 		return
 	}
 
-	if c.config.Istanbul && c.gas <= 2300 {
+	if c.rules.SStoreSentry && c.gas <= 2300 {
 		c.exit(errOutOfGas)
 		return
 	}
@@ -475,40 +474,24 @@ func opSStore(c *state) {
 	key := c.popHash()
 	val := c.popHash()
 
-	legacyGasMetering := !c.config.Istanbul && (c.config.Petersburg || !c.config.Constantinople)
+	cost := uint64(0)
+	if c.rules.AccessLists && !c.host.SlotInAccessList(c.msg.Address, key) {
+		// eip-2929
+		cost = coldSloadCost
+		c.host.AddSlotToAccessList(c.msg.Address, key)
+	}
 
 	status := c.host.SetStorage(c.msg.Address, key, val, c.config)
-	cost := uint64(0)
 
 	switch status {
-	case runtime.StorageUnchanged:
-		if c.config.Istanbul {
-			// eip-2200
-			cost = 800
-		} else if legacyGasMetering {
-			cost = 5000
-		} else {
-			cost = 200
-		}
-
-	case runtime.StorageModified:
-		cost = 5000
+	case runtime.StorageUnchanged, runtime.StorageModifiedAgain:
+		cost += c.rules.Gas.SStoreDirty
 
-	case runtime.StorageModifiedAgain:
-		if c.config.Istanbul {
-			// eip-2200
-			cost = 800
-		} else if legacyGasMetering {
-			cost = 5000
-		} else {
-			cost = 200
-		}
+	case runtime.StorageModified, runtime.StorageDeleted:
+		cost += c.rules.Gas.SStoreReset
 
 	case runtime.StorageAdded:
-		cost = 20000
-
-	case runtime.StorageDeleted:
-		cost = 5000
+		cost += 20000
 	}
 	if !c.consumeGas(cost) {
 		return
@@ -550,16 +533,10 @@ func opAddress(c *state) {
 func opBalance(c *state) {
 	addr, _ := c.popAddr()
 
-	var gas uint64
-	if c.config.Istanbul {
-		// eip-1884
-		gas = 700
-	} else if c.config.EIP150 {
-		gas = 400
-	} else {
-		gas = 20
+	gas := c.rules.Gas.Balance
+	if c.rules.AccessLists {
+		gas = c.accessAddress(addr)
 	}
-
 	if !c.consumeGas(gas) {
 		return
 	}
@@ -568,20 +545,10 @@ func opBalance(c *state) {
 }
 
 func opSelfBalance(c *state) {
-	if !c.config.Istanbul {
-		c.exit(errOpCodeNotFound)
-		return
-	}
-
 	c.push1().Set(c.host.GetBalance(c.msg.Address))
 }
 
 func opChainID(c *state) {
-	if !c.config.Istanbul {
-		c.exit(errOpCodeNotFound)
-		return
-	}
-
 	c.push1().SetUint64(uint64(c.host.GetTxContext().ChainID))
 }
 
@@ -629,11 +596,9 @@ func opCodeSize(c *state) {
 func opExtCodeSize(c *state) {
 	addr, _ := c.popAddr()
 
-	var gas uint64
-	if c.config.EIP150 {
-		gas = 700
-	} else {
-		gas = 20
+	gas := c.rules.Gas.ExtCodeSize
+	if c.rules.AccessLists {
+		gas = c.accessAddress(addr)
 	}
 	if !c.consumeGas(gas) {
 		return
@@ -647,26 +612,15 @@ func opGasPrice(c *state) {
 }
 
 func opReturnDataSize(c *state) {
-	if !c.config.Byzantium {
-		c.exit(errOpCodeNotFound)
-	} else {
-		c.push1().SetUint64(uint64(len(c.returnData)))
-	}
+	c.push1().SetUint64(uint64(len(c.returnData)))
 }
 
 func opExtCodeHash(c *state) {
-	if !c.config.Constantinople {
-		c.exit(errOpCodeNotFound)
-		return
-	}
-
 	address, _ := c.popAddr()
 
-	var gas uint64
-	if c.config.Istanbul {
-		gas = 700
-	} else {
-		gas = 400
+	gas := c.rules.Gas.ExtCodeHash
+	if c.rules.AccessLists {
+		gas = c.accessAddress(address)
 	}
 	if !c.consumeGas(gas) {
 		return
@@ -733,11 +687,9 @@ func opExtCodeCopy(c *state) {
 		return
 	}
 
-	var gas uint64
-	if c.config.EIP150 {
-		gas = 700
-	} else {
-		gas = 20
+	gas := c.rules.Gas.ExtCodeCopy
+	if c.rules.AccessLists {
+		gas = c.accessAddress(address)
 	}
 	if !c.consumeGas(gas) {
 		return
@@ -769,11 +721,6 @@ func opCallDataCopy(c *state) {
 }
 
 func opReturnDataCopy(c *state) {
-	if !c.config.Byzantium {
-		c.exit(errOpCodeNotFound)
-		return
-	}
-
 	memOffset := c.pop()
 	dataOffset := c.pop()
 	length := c.pop()
@@ -869,11 +816,10 @@ func opSelfDestruct(c *state) {
 	address, _ := c.popAddr()
 
 	// try to remove the gas first
-	var gas uint64
+	gas := c.rules.Gas.SelfDestruct
 
 	// EIP150 reprice fork
 	if c.config.EIP150 {
-		gas = 5000
 		if c.config.EIP158 {
 			// if empty and transfers value
 			if c.host.Empty(address) && c.host.GetBalance(c.msg.Address).Sign() != 0 {
@@ -883,6 +829,11 @@ func opSelfDestruct(c *state) {
 			gas += 25000
 		}
 	}
+	if c.rules.AccessLists && !c.host.AddressInAccessList(address) {
+		// eip-2929
+		gas += coldAccountAccessCost
+		c.host.AddAddressToAccessList(address)
+	}
 
 	if !c.consumeGas(gas) {
 		return
@@ -1004,13 +955,6 @@ func opCreate(op OpCode) instruction {
 			return
 		}
 
-		if op == CREATE2 {
-			if !c.config.Constantinople {
-				c.exit(errOpCodeNotFound)
-				return
-			}
-		}
-
 		// reset the return data
 		c.resetReturnData()
 
@@ -1019,6 +963,7 @@ func opCreate(op OpCode) instruction {
 			c.push1().Set(zero)
 			if contract != nil {
 				c.gas += contract.Gas
+				releaseContract(contract)
 			}
 			return
 		}
@@ -1027,18 +972,25 @@ func opCreate(op OpCode) instruction {
 		}
 
 		contract.Type = runtime.Create
+		if op == CREATE2 {
+			contract.Type = runtime.Create2
+		}
 
 		// Correct call
 		ret, gas, err := c.host.Callx(contract, c.host)
 
 		v := c.push1()
-		if op == CREATE && c.config.Homestead && err == runtime.ErrCodeStoreOutOfGas {
+		if op == CREATE2 && err != nil {
+			// CREATE2 is only enabled after homestead, where any error fails the creation
+			v.Set(zero)
+		} else if op == CREATE && c.config.Homestead && err == runtime.ErrCodeStoreOutOfGas {
 			v.Set(zero)
 		} else if err != nil && err != runtime.ErrCodeStoreOutOfGas {
 			v.Set(zero)
 		} else {
 			v.SetBytes(contract.Address.Bytes())
 		}
+		releaseContract(contract)
 
 		c.gas += gas
 		if err == runtime.ErrExecutionReverted {
@@ -1058,15 +1010,6 @@ func opCall(op OpCode) instruction {
 			}
 		}
 
-		if op == DELEGATECALL && !c.config.Homestead {
-			c.exit(errOpCodeNotFound)
-			return
-		}
-		if op == STATICCALL && !c.config.Byzantium {
-			c.exit(errOpCodeNotFound)
-			return
-		}
-
 		var callType runtime.CallType
 		switch op {
 		case CALL:
@@ -1090,6 +1033,7 @@ func opCall(op OpCode) instruction {
 			c.push1().Set(zero)
 			if contract != nil {
 				c.gas += contract.Gas
+				releaseContract(contract)
 			}
 			return
 		}
@@ -1100,6 +1044,7 @@ func opCall(op OpCode) instruction {
 		contract.Type = callType
 
 		ret, gas, err := c.host.Callx(contract, c.host)
+		releaseContract(contract)
 
 		v := c.push1()
 		if err != nil {
@@ -1147,11 +1092,9 @@ func (c *state) buildCallContract(op OpCode) (*runtime.Contract, uint64, uint64,
 		return nil, 0, 0, nil
 	}
 
-	var gasCost uint64
-	if c.config.EIP150 {
-		gasCost = 700
-	} else {
-		gasCost = 40
+	gasCost := c.rules.Gas.Call
+	if c.rules.AccessLists {
+		gasCost = c.accessAddress(addr)
 	}
 
 	eip158 := c.config.EIP158
@@ -1202,7 +1145,8 @@ func (c *state) buildCallContract(op OpCode) (*runtime.Contract, uint64, uint64,
 
 	parent := c
 
-	contract := runtime.NewContractCall(c.msg.Depth+1, parent.msg.Origin, parent.msg.Address, addr, value, gas, c.host.GetCode(addr), args)
+	contract := acquireContract(c.msg.Depth+1, parent.msg.Origin, parent.msg.Address, addr, value, gas, c.host.GetCode(addr))
+	contract.Input = args
 
 	if op == STATICCALL || parent.msg.Static {
 		contract.Static = true
@@ -1289,17 +1233,12 @@ func (c *state) buildCreateContract(op OpCode) (*runtime.Contract, error) {
 	} else {
 		address = crypto.CreateAddress2(c.msg.Address, bigToHash(salt), input)
 	}
-	contract := runtime.NewContractCreation(c.msg.Depth+1, c.msg.Origin, c.msg.Address, address, value, gas, input)
+	contract := acquireContract(c.msg.Depth+1, c.msg.Origin, c.msg.Address, address, value, gas, input)
 	return contract, nil
 }
 
 func opHalt(op OpCode) instruction {
 	return func(c *state) {
-		if op == REVERT && !c.config.Byzantium {
-			c.exit(errOpCodeNotFound)
-			return
-		}
-
 		offset := c.pop()
 		size := c.pop()
 
@@ -4,6 +4,9 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -90,3 +93,112 @@ func TestMStore(t *testing.T) {
 
 	assert.Len(t, s.memory, 1024+32)
 }
+
+type mockAccessListHost struct {
+	runtime.Host
+
+	addrs map[types.Address]struct{}
+	slots map[types.Hash]struct{}
+}
+
+func (m *mockAccessListHost) GetStorage(addr types.Address, key types.Hash) types.Hash {
+	return types.Hash{}
+}
+
+func (m *mockAccessListHost) GetBalance(addr types.Address) *big.Int {
+	return big.NewInt(0)
+}
+
+func (m *mockAccessListHost) AddressInAccessList(addr types.Address) bool {
+	_, ok := m.addrs[addr]
+	return ok
+}
+
+func (m *mockAccessListHost) SlotInAccessList(addr types.Address, slot types.Hash) bool {
+	_, ok := m.slots[slot]
+	return ok
+}
+
+func (m *mockAccessListHost) AddAddressToAccessList(addr types.Address) {
+	m.addrs[addr] = struct{}{}
+}
+
+func (m *mockAccessListHost) AddSlotToAccessList(addr types.Address, slot types.Hash) {
+	m.slots[slot] = struct{}{}
+}
+
+func TestAccessListGas(t *testing.T) {
+	s, close := getState()
+	defer close()
+
+	s.msg = &runtime.Contract{}
+	s.config = &chain.ForksInTime{Istanbul: true, Berlin: true}
+	s.rules = RulesFor(s.config)
+	s.host = &mockAccessListHost{
+		addrs: map[types.Address]struct{}{},
+		slots: map[types.Hash]struct{}{},
+	}
+
+	// the first access is cold and the next ones are warm
+	for _, cost := range []uint64{coldSloadCost, warmStorageReadCost} {
+		s.gas = 10000
+		s.push(big.NewInt(1))
+		opSload(s)
+		assert.Equal(t, 10000-cost, s.gas)
+	}
+	for _, cost := range []uint64{coldAccountAccessCost, warmStorageReadCost} {
+		s.gas = 10000
+		s.push(big.NewInt(1))
+		opBalance(s)
+		assert.Equal(t, 10000-cost, s.gas)
+	}
+}
+
+// mockCreateHost is a host in which every creation fails with the given error
+type mockCreateHost struct {
+	runtime.Host
+
+	err error
+}
+
+func (m *mockCreateHost) GetNonce(addr types.Address) uint64 {
+	return 0
+}
+
+func (m *mockCreateHost) Callx(c *runtime.Contract, h runtime.Host) ([]byte, uint64, error) {
+	return nil, 0, m.err
+}
+
+func TestCreateCodeStoreOutOfGas(t *testing.T) {
+	cases := []struct {
+		op        OpCode
+		homestead bool
+		success   bool
+	}{
+		// frontier only fails the creation if the code cannot be paid
+		{CREATE, false, true},
+		{CREATE, true, false},
+		{CREATE2, true, false},
+	}
+
+	for _, c := range cases {
+		s, close := getState()
+
+		s.msg = &runtime.Contract{}
+		s.config = &chain.ForksInTime{Homestead: c.homestead, EIP150: c.homestead}
+		s.host = &mockCreateHost{err: runtime.ErrCodeStoreOutOfGas}
+		s.gas = 10000
+
+		if c.op == CREATE2 {
+			s.push(big.NewInt(0)) // salt
+		}
+		s.push(big.NewInt(0)) // length
+		s.push(big.NewInt(0)) // offset
+		s.push(big.NewInt(0)) // value
+
+		opCreate(c.op)(s)
+		assert.Equal(t, c.success, s.pop().Sign() != 0)
+
+		close()
+	}
+}
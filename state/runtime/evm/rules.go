@@ -0,0 +1,192 @@
+package evm
+
+import (
+	"sync"
+
+	"github.com/0xPolygon/minimal/chain"
+)
+
+// GasTable are the gas costs of the opcodes that were repriced by the forks
+type GasTable struct {
+	// ExpByte is the cost of each byte of the exponent of EXP
+	ExpByte uint64
+
+	SLoad        uint64
+	Balance      uint64
+	ExtCodeSize  uint64
+	ExtCodeCopy  uint64
+	ExtCodeHash  uint64
+	SelfDestruct uint64
+
+	// Call is the base cost of CALL, CALLCODE, DELEGATECALL and STATICCALL
+	Call uint64
+
+	// SStoreDirty is the cost of a SSTORE that does not change the slot
+	// or that changes a slot already changed in the txn
+	SStoreDirty uint64
+
+	// SStoreReset is the cost of a SSTORE that changes or deletes a slot
+	SStoreReset uint64
+}
+
+// Rules are the opcodes available and their gas costs under a set of forks
+type Rules struct {
+	Gas GasTable
+
+	// AccessLists charges the accesses to the state whether the account or the slot
+	// were accessed before in the txn (eip-2929), instead of the costs of the gas table
+	AccessLists bool
+
+	// SStoreSentry fails the SSTOREs with less gas left than the call stipend (eip-2200)
+	SStoreSentry bool
+
+	opcodes [256]bool
+}
+
+// Enabled returns true if the opcode is available
+func (r *Rules) Enabled(op OpCode) bool {
+	return r.opcodes[op]
+}
+
+func (r *Rules) enable(ops ...OpCode) {
+	for _, op := range ops {
+		r.opcodes[op] = true
+	}
+}
+
+// forkRule is the change of the rules of the evm introduced by a fork
+type forkRule struct {
+	enabled func(f *chain.ForksInTime) bool
+	apply   func(r *Rules)
+}
+
+// forkRules are applied in order on top of the frontier rules for the forks enabled
+var forkRules = []forkRule{
+	{
+		enabled: func(f *chain.ForksInTime) bool { return f.Homestead },
+		apply: func(r *Rules) {
+			r.enable(DELEGATECALL)
+		},
+	},
+	{
+		// eip-150
+		enabled: func(f *chain.ForksInTime) bool { return f.EIP150 },
+		apply: func(r *Rules) {
+			r.Gas.SLoad = 200
+			r.Gas.Balance = 400
+			r.Gas.ExtCodeSize = 700
+			r.Gas.ExtCodeCopy = 700
+			r.Gas.SelfDestruct = 5000
+			r.Gas.Call = 700
+		},
+	},
+	{
+		// eip-160
+		enabled: func(f *chain.ForksInTime) bool { return f.EIP158 },
+		apply: func(r *Rules) {
+			r.Gas.ExpByte = 50
+		},
+	},
+	{
+		enabled: func(f *chain.ForksInTime) bool { return f.Byzantium },
+		apply: func(r *Rules) {
+			r.enable(RETURNDATASIZE, RETURNDATACOPY, STATICCALL, REVERT)
+		},
+	},
+	{
+		enabled: func(f *chain.ForksInTime) bool { return f.Constantinople },
+		apply: func(r *Rules) {
+			r.enable(SHL, SHR, SAR, EXTCODEHASH, CREATE2)
+
+			// eip-1283
+			r.Gas.SStoreDirty = 200
+		},
+	},
+	{
+		enabled: func(f *chain.ForksInTime) bool { return f.Petersburg },
+		apply: func(r *Rules) {
+			// eip-1283 is removed
+			r.Gas.SStoreDirty = 5000
+		},
+	},
+	{
+		enabled: func(f *chain.ForksInTime) bool { return f.Istanbul },
+		apply: func(r *Rules) {
+			r.enable(CHAINID, SELFBALANCE)
+
+			// eip-1884
+			r.Gas.SLoad = 800
+			r.Gas.Balance = 700
+			r.Gas.ExtCodeHash = 700
+
+			// eip-2200
+			r.Gas.SStoreDirty = 800
+			r.SStoreSentry = true
+		},
+	},
+	{
+		enabled: func(f *chain.ForksInTime) bool { return f.Berlin },
+		apply: func(r *Rules) {
+			// eip-2929, the costs of the accesses are the warm ones
+			r.AccessLists = true
+			r.Gas.SLoad = warmStorageReadCost
+			r.Gas.Balance = warmStorageReadCost
+			r.Gas.ExtCodeSize = warmStorageReadCost
+			r.Gas.ExtCodeCopy = warmStorageReadCost
+			r.Gas.ExtCodeHash = warmStorageReadCost
+			r.Gas.Call = warmStorageReadCost
+			r.Gas.SStoreDirty = warmStorageReadCost
+			r.Gas.SStoreReset = 5000 - coldSloadCost
+		},
+	},
+}
+
+// frontierRules returns the rules before any fork, without the opcodes introduced by the forks
+func frontierRules() *Rules {
+	r := &Rules{
+		Gas: GasTable{
+			ExpByte:     10,
+			SLoad:       50,
+			Balance:     20,
+			ExtCodeSize: 20,
+			ExtCodeCopy: 20,
+			ExtCodeHash: 400,
+			Call:        40,
+			SStoreDirty: 5000,
+			SStoreReset: 5000,
+		},
+	}
+	for op, h := range dispatchTable {
+		r.opcodes[op] = h.inst != nil
+	}
+
+	disabled := &Rules{}
+	for _, rule := range forkRules {
+		rule.apply(disabled)
+	}
+	for op, ok := range disabled.opcodes {
+		if ok {
+			r.opcodes[op] = false
+		}
+	}
+	return r
+}
+
+// rulesCache are the rules of each set of forks, there are a few of them in a chain
+var rulesCache sync.Map
+
+// RulesFor returns the rules of the evm under the forks enabled at a block
+func RulesFor(config *chain.ForksInTime) *Rules {
+	if r, ok := rulesCache.Load(*config); ok {
+		return r.(*Rules)
+	}
+
+	r := frontierRules()
+	for _, rule := range forkRules {
+		if rule.enabled(config) {
+			rule.apply(r)
+		}
+	}
+	rulesCache.Store(*config, r)
+	return r
+}
@@ -0,0 +1,93 @@
+package evm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules_Opcodes(t *testing.T) {
+	frontier := RulesFor(&chain.ForksInTime{})
+	for _, op := range []OpCode{DELEGATECALL, STATICCALL, REVERT, SHL, CREATE2, CHAINID, SELFBALANCE} {
+		assert.False(t, frontier.Enabled(op), op.String())
+	}
+	assert.True(t, frontier.Enabled(CALL))
+	assert.False(t, frontier.Enabled(OpCode(0xef)))
+
+	byzantium := RulesFor(&chain.ForksInTime{Homestead: true, EIP150: true, EIP158: true, Byzantium: true})
+	assert.True(t, byzantium.Enabled(STATICCALL))
+	assert.True(t, byzantium.Enabled(REVERT))
+	assert.False(t, byzantium.Enabled(SHL))
+
+	all := chain.AllForksEnabled.At(0)
+	rules := RulesFor(&all)
+	for op, h := range dispatchTable {
+		assert.Equal(t, h.inst != nil, rules.Enabled(OpCode(op)))
+	}
+
+	// the rules are cached for each set of forks
+	assert.Same(t, rules, RulesFor(&all))
+}
+
+func TestRules_Gas(t *testing.T) {
+	cases := []struct {
+		name  string
+		forks chain.ForksInTime
+		gas   GasTable
+	}{
+		{
+			"frontier",
+			chain.ForksInTime{},
+			GasTable{ExpByte: 10, SLoad: 50, Balance: 20, ExtCodeSize: 20, ExtCodeCopy: 20, ExtCodeHash: 400, Call: 40, SStoreDirty: 5000, SStoreReset: 5000},
+		},
+		{
+			"constantinople",
+			chain.ForksInTime{EIP150: true, EIP158: true, Constantinople: true},
+			GasTable{ExpByte: 50, SLoad: 200, Balance: 400, ExtCodeSize: 700, ExtCodeCopy: 700, ExtCodeHash: 400, Call: 700, SelfDestruct: 5000, SStoreDirty: 200, SStoreReset: 5000},
+		},
+		{
+			"petersburg",
+			chain.ForksInTime{EIP150: true, EIP158: true, Constantinople: true, Petersburg: true},
+			GasTable{ExpByte: 50, SLoad: 200, Balance: 400, ExtCodeSize: 700, ExtCodeCopy: 700, ExtCodeHash: 400, Call: 700, SelfDestruct: 5000, SStoreDirty: 5000, SStoreReset: 5000},
+		},
+		{
+			"istanbul",
+			chain.ForksInTime{EIP150: true, EIP158: true, Constantinople: true, Petersburg: true, Istanbul: true},
+			GasTable{ExpByte: 50, SLoad: 800, Balance: 700, ExtCodeSize: 700, ExtCodeCopy: 700, ExtCodeHash: 700, Call: 700, SelfDestruct: 5000, SStoreDirty: 800, SStoreReset: 5000},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rules := RulesFor(&c.forks)
+			assert.Equal(t, c.gas, rules.Gas)
+			assert.Equal(t, c.forks.Istanbul, rules.SStoreSentry)
+			assert.False(t, rules.AccessLists)
+		})
+	}
+
+	berlin := RulesFor(&chain.ForksInTime{EIP150: true, Istanbul: true, Berlin: true})
+	assert.True(t, berlin.AccessLists)
+	assert.Equal(t, uint64(5000-coldSloadCost), berlin.Gas.SStoreReset)
+}
+
+func TestRules_Run(t *testing.T) {
+	// shl(1, 1)
+	code := []byte{0x60, 0x01, 0x60, 0x01, 0x1b, 0x00}
+
+	e := NewEVM()
+	host := &mockCallHost{evm: e}
+
+	run := func(config *chain.ForksInTime) error {
+		c := runtime.NewContractCall(1, types.Address{}, types.Address{}, types.Address{}, big.NewInt(0), 100000, code, nil)
+		_, _, err := e.Run(c, host, config)
+		return err
+	}
+
+	// the opcode is enabled by the fork of the block
+	assert.Equal(t, errOpCodeNotFound, run(&chain.ForksInTime{Byzantium: true}))
+	assert.NoError(t, run(&chain.ForksInTime{Byzantium: true, Constantinople: true}))
+}
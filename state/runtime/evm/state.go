@@ -28,16 +28,44 @@ func releaseState(s *state) {
 	statePool.Put(s)
 }
 
+var contractPool = sync.Pool{
+	New: func() interface{} {
+		return new(runtime.Contract)
+	},
+}
+
+// acquireContract returns a contract for a call or a create from the pool. It is
+// released once the call returns, the hosts do not keep references to it
+func acquireContract(depth int, origin, from, to types.Address, value *big.Int, gas uint64, code []byte) *runtime.Contract {
+	c := contractPool.Get().(*runtime.Contract)
+	*c = runtime.Contract{
+		Caller:      from,
+		Origin:      origin,
+		CodeAddress: to,
+		Address:     to,
+		Gas:         gas,
+		Value:       value,
+		Code:        code,
+		Depth:       depth,
+	}
+	return c
+}
+
+func releaseContract(c *runtime.Contract) {
+	*c = runtime.Contract{}
+	contractPool.Put(c)
+}
+
 const stackSize = 1024
 
 var (
-	errOutOfGas       = fmt.Errorf("out of gas")
-	errStackUnderflow = fmt.Errorf("stack underflow")
-	errStackOverflow  = fmt.Errorf("stack overflow")
-	errInvalidOpcode  = fmt.Errorf("invalid opcode")
-	errReadOnly       = fmt.Errorf("read only")
-	errInvalidJump    = fmt.Errorf("invalid jump")
-	errOpCodeNotFound = fmt.Errorf("opcode not found")
+	errOutOfGas       = runtime.ErrOutOfGas
+	errStackUnderflow = runtime.ErrStackUnderflow
+	errStackOverflow  = runtime.ErrStackOverflow
+	errInvalidOpcode  = runtime.ErrOpcodeNotFound
+	errReadOnly       = runtime.ErrWriteProtection
+	errInvalidJump    = runtime.ErrJumpDestNotValid
+	errOpCodeNotFound = runtime.ErrOpcodeNotFound
 	errReturnBadSize  = fmt.Errorf("return bad size")
 	errRevert         = runtime.ErrExecutionReverted
 )
@@ -55,6 +83,12 @@ type state struct {
 	msg    *runtime.Contract // change with msg
 	config *chain.ForksInTime
 
+	// rules of the forks of the config
+	rules *Rules
+
+	// tracer of the opcodes, nil if the execution is not traced
+	tracer runtime.Tracer
+
 	// memory
 	memory      []byte
 	lastGasCost uint64
@@ -85,6 +119,7 @@ func (c *state) reset() {
 	c.lastGasCost = 0
 	c.stop = false
 	c.err = nil
+	c.tracer = nil
 
 	// reset bitmap
 	c.bitmap.reset()
@@ -141,7 +176,7 @@ func (c *state) stackAtLeast(n int) bool {
 }
 
 func (c *state) popHash() types.Hash {
-	return types.BytesToHash(c.pop().Bytes())
+	return bigToHash(c.pop())
 }
 
 func (c *state) popAddr() (types.Address, bool) {
@@ -150,7 +185,7 @@ func (c *state) popAddr() (types.Address, bool) {
 		return types.Address{}, false
 	}
 
-	return types.BytesToAddress(b.Bytes()), true
+	return bigToAddress(b), true
 }
 
 func (c *state) stackSize() int {
@@ -211,6 +246,9 @@ func (c *state) resetReturnData() {
 func (c *state) Run() ([]byte, error) {
 	var vmerr error
 
+	// step traced of the opcode being executed
+	var step *runtime.TraceStep
+
 	codeSize := len(c.code)
 	for !c.stop {
 		if c.ip >= codeSize {
@@ -223,8 +261,12 @@ func (c *state) Run() ([]byte, error) {
 		//fmt.Printf("%d OP [%d]: %s (%d)\n", c.ip, c.msg.Depth, op.String(), c.gas)
 		//fmt.Println(c.showStack())
 
+		if c.tracer != nil {
+			step = c.captureStep(op)
+		}
+
 		inst := dispatchTable[op]
-		if inst.inst == nil {
+		if !c.rules.Enabled(op) {
 			c.exit(errOpCodeNotFound)
 			break
 		}
@@ -247,8 +289,16 @@ func (c *state) Run() ([]byte, error) {
 			c.exit(errStackOverflow)
 			break
 		}
+		if step != nil {
+			c.endStep(step)
+			step = nil
+		}
 		c.ip++
 	}
+	if step != nil {
+		// the opcode that stopped the execution with an error
+		c.endStep(step)
+	}
 
 	if err := c.err; err != nil {
 		vmerr = err
@@ -256,12 +306,59 @@ func (c *state) Run() ([]byte, error) {
 	return c.ret, vmerr
 }
 
+// captureStep notifies the tracer of the state before the execution of the opcode
+func (c *state) captureStep(op OpCode) *runtime.TraceStep {
+	step := &runtime.TraceStep{
+		PC:      uint64(c.ip),
+		Op:      byte(op),
+		OpName:  op.String(),
+		Gas:     c.gas,
+		Depth:   c.msg.Depth,
+		Address: c.msg.Address,
+		Stack:   make([]*big.Int, c.sp),
+		Memory:  append([]byte{}, c.memory...),
+		Host:    c.host,
+	}
+	for i := 0; i < c.sp; i++ {
+		step.Stack[i] = new(big.Int).Set(c.stack[i])
+	}
+	c.tracer.CaptureState(step)
+	return step
+}
+
+// endStep sets the cost and the error of the opcode once it is executed
+func (c *state) endStep(step *runtime.TraceStep) {
+	if c.gas < step.Gas {
+		step.Cost = step.Gas - c.gas
+	}
+	step.Err = c.err
+}
+
 func (c *state) inStaticCall() bool {
 	return c.msg.Static
 }
 
-func bigToHash(b *big.Int) types.Hash {
-	return types.BytesToHash(b.Bytes())
+func bigToHash(b *big.Int) (h types.Hash) {
+	putBig(h[:], b)
+	return
+}
+
+func bigToAddress(b *big.Int) (addr types.Address) {
+	putBig(addr[:], b)
+	return
+}
+
+// putBig writes the low bytes of the big int in the buffer, in big endian. Unlike
+// b.Bytes(), it does not allocate, the stack values are converted often
+func putBig(buf []byte, b *big.Int) {
+	i := len(buf)
+	for _, d := range b.Bits() {
+		for j := 0; j < _S && i > 0; j++ {
+			i--
+			buf[i] = byte(d)
+			d >>= 8
+		}
+	}
 }
 
 func (c *state) Len() int {
@@ -1,8 +1,12 @@
 package evm
 
 import (
+	"math/big"
 	"testing"
 
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -23,8 +27,12 @@ func (c *codeHelper) pop() {
 	c.buf = append(c.buf, POP)
 }
 
+// getState returns a state of the pool with the frontier rules, the
+// pool can return a new state without the rules of a previous run
 func getState() (*state, func()) {
 	c := statePool.Get().(*state)
+	c.config = &chain.ForksInTime{}
+	c.rules = RulesFor(c.config)
 	return c, func() {
 		c.reset()
 		statePool.Put(c)
@@ -106,3 +114,60 @@ func TestOpcodeNotFound(t *testing.T) {
 	_, err := s.Run()
 	assert.Equal(t, errOpCodeNotFound, err)
 }
+
+type mockTracer struct {
+	steps []*runtime.TraceStep
+}
+
+func (m *mockTracer) CaptureEnter(typ runtime.CallType, from, to types.Address, input []byte, gas uint64, value *big.Int) {
+}
+
+func (m *mockTracer) CaptureExit(output []byte, gasLeft uint64, err error) {
+}
+
+func (m *mockTracer) CaptureState(step *runtime.TraceStep) {
+	m.steps = append(m.steps, step)
+}
+
+func TestRunTracer(t *testing.T) {
+	s, close := getState()
+	defer close()
+
+	tracer := &mockTracer{}
+
+	// PUSH1 1, PUSH1 1, ADD, POP, POP (underflow)
+	s.code = []byte{PUSH1, 0x1, PUSH1, 0x1, ADD, POP, POP}
+	s.gas = 10000
+	s.msg = &runtime.Contract{Depth: 1}
+	s.tracer = tracer
+
+	_, err := s.Run()
+	assert.Equal(t, errStackUnderflow, err)
+
+	assert.Len(t, tracer.steps, 5)
+
+	add := tracer.steps[2]
+	assert.Equal(t, uint64(4), add.PC)
+	assert.Equal(t, "ADD", add.OpName)
+	assert.Equal(t, uint64(10000-6), add.Gas)
+	assert.Equal(t, uint64(3), add.Cost)
+	assert.Equal(t, []*big.Int{big.NewInt(1), big.NewInt(1)}, add.Stack)
+	assert.NoError(t, add.Err)
+
+	// the step that failed
+	assert.Equal(t, "POP", tracer.steps[4].OpName)
+	assert.Equal(t, errStackUnderflow, tracer.steps[4].Err)
+}
+
+func TestBigToHash(t *testing.T) {
+	cases := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		new(big.Int).SetBytes([]byte{0x1, 0x2, 0x3, 0x4, 0x5, 0x6, 0x7, 0x8, 0x9}),
+		new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)),
+	}
+	for _, b := range cases {
+		assert.Equal(t, types.BytesToHash(b.Bytes()), bigToHash(b))
+		assert.Equal(t, types.BytesToAddress(b.Bytes()), bigToAddress(b))
+	}
+}
@@ -0,0 +1,89 @@
+package precompiled
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// Contract is a custom precompiled contract implemented in Go
+type Contract interface {
+	// Gas returns the gas required to run the contract with the input
+	Gas(input []byte, config *chain.ForksInTime) uint64
+
+	// Run runs the contract. The host gives access to the state,
+	// which must not be modified if the call is static
+	Run(c *runtime.Contract, host runtime.Host) ([]byte, error)
+}
+
+// Factory creates a custom precompiled contract with the config of its precompile params
+type Factory func(config map[string]interface{}) (Contract, error)
+
+var (
+	factoriesLock sync.Mutex
+	factories     = map[string]Factory{}
+)
+
+// Register registers a custom precompiled contract, which is enabled in the chains with
+// precompile params under the name. It panics if a contract is already registered with the name
+func Register(name string, factory Factory) {
+	factoriesLock.Lock()
+	defer factoriesLock.Unlock()
+
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("precompile '%s' already registered", name))
+	}
+	factories[name] = factory
+}
+
+// customContract is a custom precompiled contract enabled at an activation block
+type customContract struct {
+	Contract
+	params *chain.PrecompileParams
+}
+
+// EnableCustom enables the custom precompiled contracts of the chain params,
+// whose implementations must be registered
+func (p *Precompiled) EnableCustom(precompiles map[string]*chain.PrecompileParams) error {
+	if err := chain.ValidatePrecompiles(precompiles); err != nil {
+		return err
+	}
+
+	factoriesLock.Lock()
+	defer factoriesLock.Unlock()
+
+	for name, params := range precompiles {
+		factory, ok := factories[name]
+		if !ok {
+			return fmt.Errorf("precompile '%s' not registered", name)
+		}
+		contract, err := factory(params.Config)
+		if err != nil {
+			return fmt.Errorf("failed to create precompile '%s': %v", name, err)
+		}
+
+		if p.custom == nil {
+			p.custom = map[types.Address]*customContract{}
+		}
+		p.custom[params.Address] = &customContract{Contract: contract, params: params}
+	}
+	return nil
+}
+
+// runCustom runs a custom precompiled contract
+func (p *Precompiled) runCustom(contract *customContract, c *runtime.Contract, host runtime.Host, config *chain.ForksInTime) ([]byte, uint64, error) {
+	gasCost := contract.Gas(c.Input, config)
+	if c.Gas < gasCost {
+		return nil, 0, runtime.ErrGasOverflow
+	}
+	c.Gas = c.Gas - gasCost
+
+	ret, err := contract.Run(c, host)
+	if err != nil {
+		return nil, 0, err
+	}
+	return ret, c.Gas, nil
+}
@@ -0,0 +1,91 @@
+package precompiled
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockContract returns its input with a prefix set in its config
+type mockContract struct {
+	prefix []byte
+}
+
+func (m *mockContract) Gas(input []byte, config *chain.ForksInTime) uint64 {
+	return 100 + uint64(len(input))
+}
+
+func (m *mockContract) Run(c *runtime.Contract, host runtime.Host) ([]byte, error) {
+	return append(append([]byte{}, m.prefix...), c.Input...), nil
+}
+
+type mockNumberHost struct {
+	runtime.Host
+	number int64
+}
+
+func (m *mockNumberHost) GetTxContext() runtime.TxContext {
+	return runtime.TxContext{Number: m.number}
+}
+
+func init() {
+	Register("mock", func(config map[string]interface{}) (Contract, error) {
+		prefix, ok := config["prefix"].(string)
+		if !ok {
+			return nil, fmt.Errorf("prefix not found")
+		}
+		return &mockContract{prefix: []byte(prefix)}, nil
+	})
+}
+
+func TestCustomPrecompile(t *testing.T) {
+	addr := types.StringToAddress("1000")
+
+	p := NewPrecompiled()
+	err := p.EnableCustom(map[string]*chain.PrecompileParams{
+		"mock": {
+			Address: addr,
+			Block:   chain.NewFork(10),
+			Config:  map[string]interface{}{"prefix": "a"},
+		},
+	})
+	assert.NoError(t, err)
+
+	config := &chain.ForksInTime{}
+	c := runtime.NewContractCall(1, types.Address{}, types.Address{}, addr, big.NewInt(0), 1000, nil, []byte("b"))
+
+	// the contract runs once it is activated
+	assert.False(t, p.CanRun(c, &mockNumberHost{number: 9}, config))
+	assert.True(t, p.CanRun(c, &mockNumberHost{number: 10}, config))
+
+	ret, gas, err := p.Run(c, &mockNumberHost{number: 10}, config)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("ab"), ret)
+	assert.Equal(t, uint64(899), gas)
+
+	// not enough gas
+	c = runtime.NewContractCall(1, types.Address{}, types.Address{}, addr, big.NewInt(0), 100, nil, []byte("b"))
+	_, _, err = p.Run(c, &mockNumberHost{number: 10}, config)
+	assert.Equal(t, runtime.ErrGasOverflow, err)
+
+	// the contracts must be registered and created with a valid config
+	err = NewPrecompiled().EnableCustom(map[string]*chain.PrecompileParams{
+		"unknown": {Address: addr},
+	})
+	assert.Error(t, err)
+
+	err = NewPrecompiled().EnableCustom(map[string]*chain.PrecompileParams{
+		"mock": {Address: addr},
+	})
+	assert.Error(t, err)
+
+	// a contract cannot be registered twice
+	assert.Panics(t, func() {
+		Register("mock", nil)
+	})
+}
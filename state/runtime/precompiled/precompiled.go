@@ -19,6 +19,9 @@ type contract interface {
 type Precompiled struct {
 	buf       []byte
 	contracts map[types.Address]contract
+
+	// custom are the custom precompiled contracts of the chain
+	custom map[types.Address]*customContract
 }
 
 // NewPrecompiled creates a new runtime for the precompiled contracts
@@ -65,6 +68,10 @@ func (p *Precompiled) CanRun(c *runtime.Contract, host runtime.Host, config *cha
 	//fmt.Println(config)
 	//fmt.Println(config.Byzantium)
 
+	if custom, ok := p.custom[c.CodeAddress]; ok {
+		return custom.params.Active(uint64(host.GetTxContext().Number))
+	}
+
 	if _, ok := p.contracts[c.CodeAddress]; !ok {
 		return false
 	}
@@ -97,6 +104,10 @@ func (p *Precompiled) Name() string {
 
 // Run runs an execution
 func (p *Precompiled) Run(c *runtime.Contract, host runtime.Host, config *chain.ForksInTime) ([]byte, uint64, error) {
+	if custom, ok := p.custom[c.CodeAddress]; ok {
+		return p.runCustom(custom, c, host, config)
+	}
+
 	contract := p.contracts[c.CodeAddress]
 	gasCost := contract.gas(c.Input, config)
 
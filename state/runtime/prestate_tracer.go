@@ -0,0 +1,98 @@
+package runtime
+
+import (
+	"math/big"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// PrestateAccount is the state of an account before the txn
+type PrestateAccount struct {
+	Balance *big.Int
+	Nonce   uint64
+	Code    []byte
+
+	// Storage are the slots accessed by the txn
+	Storage map[types.Hash]types.Hash
+}
+
+// PrestateTracer is a tracer that keeps the state before
+// the txn of the accounts and storage slots it accessed
+type PrestateTracer struct {
+	accounts map[types.Address]map[types.Hash]struct{}
+	result   map[types.Address]*PrestateAccount
+}
+
+// NewPrestateTracer creates a prestate tracer
+func NewPrestateTracer() *PrestateTracer {
+	return &PrestateTracer{
+		accounts: map[types.Address]map[types.Hash]struct{}{},
+	}
+}
+
+func (p *PrestateTracer) touch(addr types.Address) map[types.Hash]struct{} {
+	slots, ok := p.accounts[addr]
+	if !ok {
+		slots = map[types.Hash]struct{}{}
+		p.accounts[addr] = slots
+	}
+	return slots
+}
+
+// CaptureEnter implements the Tracer interface
+func (p *PrestateTracer) CaptureEnter(typ CallType, from, to types.Address, input []byte, gas uint64, value *big.Int) {
+	p.touch(from)
+	p.touch(to)
+}
+
+// CaptureExit implements the Tracer interface
+func (p *PrestateTracer) CaptureExit(output []byte, gasLeft uint64, err error) {
+}
+
+// CaptureState implements the Tracer interface
+func (p *PrestateTracer) CaptureState(step *TraceStep) {
+	if len(step.Stack) == 0 {
+		return
+	}
+	top := step.Stack[len(step.Stack)-1]
+
+	switch step.Op {
+	case opSLOAD, opSSTORE:
+		p.touch(step.Address)[types.BytesToHash(top.Bytes())] = struct{}{}
+
+	case opBALANCE, opEXTCODESIZE, opEXTCODECOPY, opEXTCODEHASH, opSELFDESTRUCT:
+		p.touch(types.BytesToAddress(top.Bytes()))
+	}
+}
+
+// CapturePrestate implements the PrestateCapturer interface
+func (p *PrestateTracer) CapturePrestate(host Host) {
+	// the coinbase is paid the fees of the txn
+	ctx := host.GetTxContext()
+	p.touch(ctx.Coinbase)
+	p.touch(ctx.Origin)
+
+	p.result = map[types.Address]*PrestateAccount{}
+	for addr, slots := range p.accounts {
+		if !host.AccountExists(addr) {
+			continue
+		}
+		account := &PrestateAccount{
+			Balance: host.GetBalance(addr),
+			Nonce:   host.GetNonce(addr),
+			Code:    host.GetCode(addr),
+		}
+		if len(slots) != 0 {
+			account.Storage = map[types.Hash]types.Hash{}
+			for slot := range slots {
+				account.Storage[slot] = host.GetStorage(addr, slot)
+			}
+		}
+		p.result[addr] = account
+	}
+}
+
+// Result returns the state before the txn of the accounts it accessed
+func (p *PrestateTracer) Result() map[types.Address]*PrestateAccount {
+	return p.result
+}
@@ -0,0 +1,92 @@
+package runtime
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ExecutionResult is the result of the execution of a txn
+type ExecutionResult struct {
+	// ReturnValue is the revert data if the execution was reverted
+	ReturnValue []byte
+	GasUsed     uint64
+
+	// Err is the error that made the execution fail, nil if it succeeded
+	Err error
+}
+
+// Failed returns whether the execution failed
+func (r *ExecutionResult) Failed() bool {
+	return r.Err != nil
+}
+
+// Reverted returns whether the execution failed because it was reverted
+func (r *ExecutionResult) Reverted() bool {
+	return r.Err == ErrExecutionReverted
+}
+
+var (
+	// errorSelector is the selector of Error(string), the revert data of revert("reason")
+	errorSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+
+	// panicSelector is the selector of Panic(uint256), the revert data of the failed asserts
+	panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71}
+
+	errNoRevertReason = errors.New("revert data without reason")
+)
+
+// panicReasons are the reasons of the panic codes of solidity
+var panicReasons = map[uint64]string{
+	0x00: "generic panic",
+	0x01: "assert(false)",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "enum overflow",
+	0x22: "invalid encoded storage byte array accessed",
+	0x31: "out-of-bounds array access; popping on an empty array",
+	0x32: "out-of-bounds access of an array or bytesN",
+	0x41: "out of memory",
+	0x51: "uninitialized function",
+}
+
+// UnpackRevertReason decodes the reason of the revert data of an Error(string) or a Panic(uint256)
+func UnpackRevertReason(data []byte) (string, error) {
+	if len(data) < 4 {
+		return "", errNoRevertReason
+	}
+	selector, args := data[:4], data[4:]
+
+	switch {
+	case bytes.Equal(selector, errorSelector):
+		// the string is encoded as the offset of its length and its bytes
+		if len(args) < 32 {
+			return "", errNoRevertReason
+		}
+		offset := new(big.Int).SetBytes(args[:32])
+		if !offset.IsUint64() || offset.Uint64() > uint64(len(args))-32 {
+			return "", errNoRevertReason
+		}
+		start := offset.Uint64()
+
+		size := new(big.Int).SetBytes(args[start : start+32])
+		if !size.IsUint64() || size.Uint64() > uint64(len(args))-start-32 {
+			return "", errNoRevertReason
+		}
+		return string(args[start+32 : start+32+size.Uint64()]), nil
+
+	case bytes.Equal(selector, panicSelector):
+		if len(args) < 32 {
+			return "", errNoRevertReason
+		}
+		code := new(big.Int).SetBytes(args[:32])
+		if code.IsUint64() {
+			if reason, ok := panicReasons[code.Uint64()]; ok {
+				return reason, nil
+			}
+		}
+		return fmt.Sprintf("unknown panic code: %#x", code), nil
+	}
+	return "", errNoRevertReason
+}
@@ -0,0 +1,76 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/0xPolygon/minimal/helper/hex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnpackRevertReason(t *testing.T) {
+	cases := []struct {
+		name   string
+		data   string
+		reason string
+		valid  bool
+	}{
+		{
+			"error",
+			"0x08c379a0" +
+				"0000000000000000000000000000000000000000000000000000000000000020" +
+				"0000000000000000000000000000000000000000000000000000000000000006" +
+				"726561736f6e0000000000000000000000000000000000000000000000000000",
+			"reason",
+			true,
+		},
+		{
+			"panic",
+			"0x4e487b71" +
+				"0000000000000000000000000000000000000000000000000000000000000011",
+			"arithmetic underflow or overflow",
+			true,
+		},
+		{
+			"unknown panic",
+			"0x4e487b71" +
+				"00000000000000000000000000000000000000000000000000000000000000ff",
+			"unknown panic code: 0xff",
+			true,
+		},
+		{
+			"string out of bounds",
+			"0x08c379a0" +
+				"0000000000000000000000000000000000000000000000000000000000000020" +
+				"0000000000000000000000000000000000000000000000000000000000000040" +
+				"726561736f6e0000000000000000000000000000000000000000000000000000",
+			"",
+			false,
+		},
+		{
+			"custom error",
+			"0x12345678",
+			"",
+			false,
+		},
+		{
+			"empty",
+			"0x",
+			"",
+			false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			data, err := hex.DecodeHex(c.data)
+			assert.NoError(t, err)
+
+			reason, err := UnpackRevertReason(data)
+			if c.valid {
+				assert.NoError(t, err)
+				assert.Equal(t, c.reason, reason)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -70,10 +70,18 @@ type Host interface {
 	Callx(*Contract, Host) ([]byte, uint64, error)
 	Empty(addr types.Address) bool
 	GetNonce(addr types.Address) uint64
+	GetTracer() Tracer
+
+	// access list of the txn (eip-2929)
+	AddressInAccessList(addr types.Address) bool
+	SlotInAccessList(addr types.Address, slot types.Hash) bool
+	AddAddressToAccessList(addr types.Address)
+	AddSlotToAccessList(addr types.Address, slot types.Hash)
 }
 
 var (
 	ErrGasConsumed              = fmt.Errorf("gas has been consumed")
+	ErrOutOfGas                 = fmt.Errorf("out of gas")
 	ErrGasOverflow              = fmt.Errorf("gas overflow")
 	ErrStackOverflow            = fmt.Errorf("stack overflow")
 	ErrStackUnderflow           = fmt.Errorf("stack underflow")
@@ -86,6 +94,9 @@ var (
 	ErrOpcodeNotFound           = errors.New("opcode not found")
 	ErrExecutionReverted        = errors.New("execution was reverted")
 	ErrCodeStoreOutOfGas        = fmt.Errorf("code storage out of gas")
+	ErrDeployNotAllowed         = errors.New("contract deployment not allowed")
+	ErrMintNotAllowed           = errors.New("native token mint not allowed")
+	ErrWriteProtection          = errors.New("write protection")
 )
 
 type CallType int
@@ -99,6 +110,30 @@ const (
 	Create2
 )
 
+// IsCreate returns true if the call creates a contract (CREATE or CREATE2)
+func (c CallType) IsCreate() bool {
+	return c == Create || c == Create2
+}
+
+func (c CallType) String() string {
+	switch c {
+	case Call:
+		return "CALL"
+	case CallCode:
+		return "CALLCODE"
+	case DelegateCall:
+		return "DELEGATECALL"
+	case StaticCall:
+		return "STATICCALL"
+	case Create:
+		return "CREATE"
+	case Create2:
+		return "CREATE2"
+	default:
+		return "UNKNOWN"
+	}
+}
+
 // Runtime can process contracts
 type Runtime interface {
 	Run(c *Contract, host Host, config *chain.ForksInTime) ([]byte, uint64, error)
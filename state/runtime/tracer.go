@@ -0,0 +1,145 @@
+package runtime
+
+import (
+	"math/big"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// Tracer is notified of the calls and of the opcodes executed by the contracts
+type Tracer interface {
+	// CaptureEnter is called when a call or a create starts, the
+	// input of the creates is the code of the contract
+	CaptureEnter(typ CallType, from, to types.Address, input []byte, gas uint64, value *big.Int)
+
+	// CaptureExit is called when the last call or create started ends
+	CaptureExit(output []byte, gasLeft uint64, err error)
+
+	// CaptureState is called before the opcode of the step is executed.
+	// The cost and the error of the step are set once it is executed
+	CaptureState(step *TraceStep)
+}
+
+// PrestateCapturer is a tracer that reads the state before the txn once it is executed
+type PrestateCapturer interface {
+	Tracer
+
+	// CapturePrestate is called with the state before the txn
+	CapturePrestate(host Host)
+}
+
+// TraceStep is the state of a contract before the execution of an opcode
+type TraceStep struct {
+	PC      uint64
+	Op      byte
+	OpName  string
+	Gas     uint64
+	Depth   int
+	Address types.Address
+
+	// Stack and Memory are copies owned by the tracer
+	Stack  []*big.Int
+	Memory []byte
+
+	// Cost is the gas consumed by the opcode, including the gas used by the calls
+	Cost uint64
+	Err  error
+
+	Host Host
+}
+
+// StructLogConfig configures the state captured by the struct logger
+type StructLogConfig struct {
+	DisableStack   bool
+	DisableStorage bool
+	EnableMemory   bool
+}
+
+// StructLog is a step traced by the struct logger
+type StructLog struct {
+	*TraceStep
+
+	// Storage are the storage slots of the contract accessed until the step
+	Storage map[types.Hash]types.Hash
+}
+
+// StructLogger is a tracer that keeps the state of the contracts at each step
+type StructLogger struct {
+	config StructLogConfig
+	logs   []*StructLog
+
+	// storage are the slots accessed of each contract
+	storage map[types.Address]map[types.Hash]types.Hash
+}
+
+// NewStructLogger creates a struct logger, the config is optional
+func NewStructLogger(config *StructLogConfig) *StructLogger {
+	l := &StructLogger{
+		storage: map[types.Address]map[types.Hash]types.Hash{},
+	}
+	if config != nil {
+		l.config = *config
+	}
+	return l
+}
+
+// opcodes of the accesses to the state traced
+const (
+	opBALANCE      = 0x31
+	opEXTCODESIZE  = 0x3b
+	opEXTCODECOPY  = 0x3c
+	opEXTCODEHASH  = 0x3f
+	opSLOAD        = 0x54
+	opSSTORE       = 0x55
+	opSELFDESTRUCT = 0xff
+)
+
+// CaptureEnter implements the Tracer interface
+func (l *StructLogger) CaptureEnter(typ CallType, from, to types.Address, input []byte, gas uint64, value *big.Int) {
+}
+
+// CaptureExit implements the Tracer interface
+func (l *StructLogger) CaptureExit(output []byte, gasLeft uint64, err error) {
+}
+
+// CaptureState implements the Tracer interface
+func (l *StructLogger) CaptureState(step *TraceStep) {
+	log := &StructLog{TraceStep: step}
+
+	if !l.config.DisableStorage && (step.Op == opSLOAD || step.Op == opSSTORE) {
+		if len(step.Stack) >= 1 {
+			slots, ok := l.storage[step.Address]
+			if !ok {
+				slots = map[types.Hash]types.Hash{}
+				l.storage[step.Address] = slots
+			}
+
+			key := types.BytesToHash(step.Stack[len(step.Stack)-1].Bytes())
+			if step.Op == opSLOAD {
+				slots[key] = step.Host.GetStorage(step.Address, key)
+			} else if len(step.Stack) >= 2 {
+				slots[key] = types.BytesToHash(step.Stack[len(step.Stack)-2].Bytes())
+			}
+
+			log.Storage = make(map[types.Hash]types.Hash, len(slots))
+			for k, v := range slots {
+				log.Storage[k] = v
+			}
+		}
+	}
+
+	if l.config.DisableStack {
+		step.Stack = nil
+	}
+	if !l.config.EnableMemory {
+		step.Memory = nil
+	}
+	step.Host = nil
+
+	l.logs = append(l.logs, log)
+}
+
+// Logs returns the steps traced
+func (l *StructLogger) Logs() []*StructLog {
+	return l.logs
+}
@@ -0,0 +1,185 @@
+package runtime
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStructLogger(t *testing.T) {
+	addr := types.StringToAddress("1")
+
+	logger := NewStructLogger(&StructLogConfig{DisableStack: true})
+
+	// SSTORE of 2 at the slot 1
+	logger.CaptureState(&TraceStep{
+		Op:      opSSTORE,
+		Address: addr,
+		Stack:   []*big.Int{big.NewInt(2), big.NewInt(1)},
+		Memory:  []byte{0x1},
+	})
+	logger.CaptureState(&TraceStep{
+		Op:      0x01,
+		Address: addr,
+		Stack:   []*big.Int{big.NewInt(2), big.NewInt(1)},
+	})
+
+	logs := logger.Logs()
+	assert.Len(t, logs, 2)
+
+	assert.Equal(t, map[types.Hash]types.Hash{
+		types.BytesToHash([]byte{0x1}): types.BytesToHash([]byte{0x2}),
+	}, logs[0].Storage)
+	assert.Nil(t, logs[1].Storage)
+
+	// the stack and the memory are not kept
+	assert.Nil(t, logs[0].Stack)
+	assert.Nil(t, logs[0].Memory)
+}
+
+func TestCallTracer(t *testing.T) {
+	addr1 := types.StringToAddress("1")
+	addr2 := types.StringToAddress("2")
+	addr3 := types.StringToAddress("3")
+
+	tracer := NewCallTracer(false)
+
+	tracer.CaptureEnter(Call, addr1, addr2, []byte{0x1}, 1000, big.NewInt(1))
+	tracer.CaptureEnter(StaticCall, addr2, addr3, []byte{0x2}, 500, nil)
+	tracer.CaptureExit([]byte{0x3}, 400, nil)
+	tracer.CaptureEnter(Call, addr2, addr3, nil, 300, big.NewInt(0))
+	tracer.CaptureExit(nil, 0, ErrExecutionReverted)
+	tracer.CaptureExit([]byte{0x4}, 100, nil)
+
+	root := tracer.Result()
+	assert.Equal(t, Call, root.Type)
+	assert.Equal(t, addr1, root.From)
+	assert.Equal(t, addr2, root.To)
+	assert.Equal(t, uint64(900), root.GasUsed)
+	assert.Equal(t, []byte{0x4}, root.Output)
+	assert.Len(t, root.Calls, 2)
+
+	assert.Equal(t, StaticCall, root.Calls[0].Type)
+	assert.Equal(t, uint64(100), root.Calls[0].GasUsed)
+	assert.Equal(t, []byte{0x3}, root.Calls[0].Output)
+	assert.Nil(t, root.Calls[0].Value)
+
+	assert.Equal(t, ErrExecutionReverted, root.Calls[1].Err)
+	assert.Equal(t, uint64(300), root.Calls[1].GasUsed)
+
+	// only the top call
+	tracer = NewCallTracer(true)
+	tracer.CaptureEnter(Call, addr1, addr2, nil, 1000, nil)
+	tracer.CaptureEnter(Call, addr2, addr3, nil, 500, nil)
+	tracer.CaptureExit(nil, 500, nil)
+	tracer.CaptureExit(nil, 1000, nil)
+
+	assert.Empty(t, tracer.Result().Calls)
+}
+
+type mockPrestateHost struct {
+	Host
+	balances map[types.Address]*big.Int
+	storage  map[types.Hash]types.Hash
+}
+
+func (m *mockPrestateHost) GetTxContext() TxContext {
+	return TxContext{}
+}
+
+func (m *mockPrestateHost) AccountExists(addr types.Address) bool {
+	_, ok := m.balances[addr]
+	return ok
+}
+
+func (m *mockPrestateHost) GetBalance(addr types.Address) *big.Int {
+	return m.balances[addr]
+}
+
+func (m *mockPrestateHost) GetNonce(addr types.Address) uint64 {
+	return 1
+}
+
+func (m *mockPrestateHost) GetCode(addr types.Address) []byte {
+	return nil
+}
+
+func (m *mockPrestateHost) GetStorage(addr types.Address, key types.Hash) types.Hash {
+	return m.storage[key]
+}
+
+func TestPrestateTracer(t *testing.T) {
+	addr1 := types.StringToAddress("1")
+	addr2 := types.StringToAddress("2")
+	addr3 := types.StringToAddress("3")
+
+	slot := types.StringToHash("1")
+
+	tracer := NewPrestateTracer()
+	tracer.CaptureEnter(Call, addr1, addr2, nil, 1000, nil)
+	tracer.CaptureState(&TraceStep{
+		Op:      opSLOAD,
+		Address: addr2,
+		Stack:   []*big.Int{new(big.Int).SetBytes(slot.Bytes())},
+	})
+	tracer.CaptureState(&TraceStep{
+		Op:      opBALANCE,
+		Address: addr2,
+		Stack:   []*big.Int{new(big.Int).SetBytes(addr3.Bytes())},
+	})
+	tracer.CaptureExit(nil, 0, nil)
+
+	tracer.CapturePrestate(&mockPrestateHost{
+		balances: map[types.Address]*big.Int{
+			addr1: big.NewInt(1),
+			addr2: big.NewInt(2),
+		},
+		storage: map[types.Hash]types.Hash{
+			slot: types.StringToHash("2"),
+		},
+	})
+
+	// the accounts that do not exist are not in the prestate
+	res := tracer.Result()
+	assert.Len(t, res, 2)
+
+	assert.Equal(t, big.NewInt(1), res[addr1].Balance)
+	assert.Nil(t, res[addr1].Storage)
+
+	assert.Equal(t, big.NewInt(2), res[addr2].Balance)
+	assert.Equal(t, uint64(1), res[addr2].Nonce)
+	assert.Equal(t, map[types.Hash]types.Hash{slot: types.StringToHash("2")}, res[addr2].Storage)
+}
+
+func TestAccessListTracer(t *testing.T) {
+	sender := types.StringToAddress("1")
+	contract := types.StringToAddress("2")
+	other := types.StringToAddress("3")
+
+	tracer := NewAccessListTracer(nil, []types.Address{sender, contract})
+
+	// the slots of the excluded addresses are listed
+	tracer.CaptureState(&TraceStep{
+		Op:      opSLOAD,
+		Address: contract,
+		Stack:   []*big.Int{big.NewInt(1)},
+	})
+	// the calls to the excluded addresses are not
+	tracer.CaptureState(&TraceStep{
+		Op:      opCALL,
+		Address: contract,
+		Stack:   []*big.Int{big.NewInt(0), new(big.Int).SetBytes(sender.Bytes()), big.NewInt(1000)},
+	})
+	tracer.CaptureState(&TraceStep{
+		Op:      opBALANCE,
+		Address: contract,
+		Stack:   []*big.Int{new(big.Int).SetBytes(other.Bytes())},
+	})
+
+	assert.Equal(t, types.AccessList{
+		{Address: contract, StorageKeys: []types.Hash{types.StringToHash("1")}},
+		{Address: other, StorageKeys: []types.Hash{}},
+	}, tracer.AccessList())
+}
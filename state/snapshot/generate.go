@@ -0,0 +1,140 @@
+package snapshot
+
+import (
+	"errors"
+	"time"
+
+	"github.com/0xPolygon/minimal/state"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// generateBatchSize is the number of flat entries written in each batch during the generation
+const generateBatchSize = 1000
+
+var errGenerationAborted = errors.New("snapshot generation aborted")
+
+// generate writes the flat state of the disk layer from the tries of its root
+func (t *Tree) generate(disk *diskLayer, abort, done chan struct{}) {
+	defer close(done)
+
+	start := time.Now()
+	accounts, err := t.generateFlat(disk.root, abort)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.generating = false
+	if err != nil {
+		if err != errGenerationAborted {
+			t.logger.Error("failed to generate the state snapshot", "root", disk.root, "err", err)
+		}
+		return
+	}
+	disk.generated = true
+
+	t.logger.Info("Generated state snapshot", "root", disk.root, "accounts", accounts, "elapsed", time.Since(start))
+}
+
+func (t *Tree) generateFlat(root types.Hash, abort chan struct{}) (uint64, error) {
+	// the flat state is not valid until it is generated
+	marker := t.db.NewBatch()
+	marker.Delete(rootKey)
+	if err := marker.Write(); err != nil {
+		return 0, err
+	}
+	if err := t.wipe(abort); err != nil {
+		return 0, err
+	}
+
+	var (
+		accounts uint64
+		pending  int
+	)
+	batch := t.db.NewBatch()
+
+	put := func(k, v []byte) error {
+		select {
+		case <-abort:
+			return errGenerationAborted
+		default:
+		}
+
+		batch.Set(k, append([]byte{}, v...))
+		if pending++; pending == generateBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch = t.db.NewBatch()
+			pending = 0
+		}
+		return nil
+	}
+
+	trieStorage := itrie.NewKVStorage(t.db)
+	err := itrie.Iterate(trieStorage, root, func(k, v []byte) error {
+		account := types.BytesToHash(k)
+		if err := put(accountKey(account), v); err != nil {
+			return err
+		}
+		accounts++
+
+		var obj state.Account
+		if err := obj.UnmarshalRlp(v); err != nil {
+			return err
+		}
+		return itrie.Iterate(trieStorage, obj.Root, func(k, v []byte) error {
+			return put(storageKey(account, types.BytesToHash(k)), v)
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	batch.Set(rootKey, root.Bytes())
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	return accounts, nil
+}
+
+// wipe deletes the flat state on disk
+func (t *Tree) wipe(abort chan struct{}) error {
+	for _, prefix := range [][]byte{accountPrefix, storagePrefix} {
+		var (
+			keys    [][]byte
+			aborted bool
+		)
+		err := t.db.Iterate(prefix, func(k, v []byte) bool {
+			select {
+			case <-abort:
+				aborted = true
+				return false
+			default:
+			}
+			keys = append(keys, append([]byte{}, k...))
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		if aborted {
+			return errGenerationAborted
+		}
+
+		for i := 0; i < len(keys); i += generateBatchSize {
+			end := i + generateBatchSize
+			if end > len(keys) {
+				end = len(keys)
+			}
+			batch := t.db.NewBatch()
+			for _, k := range keys[i:end] {
+				batch.Delete(k)
+			}
+			if err := batch.Write(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package snapshot
+
+import (
+	"errors"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/types"
+)
+
+var (
+	// accountPrefix is the prefix of the flat accounts, keyed by their hashed address
+	accountPrefix = []byte("snap-a")
+
+	// storagePrefix is the prefix of the flat storage slots, keyed by
+	// the hashed address of the account and the hashed slot
+	storagePrefix = []byte("snap-s")
+
+	// rootKey is the state root of the flat state on disk,
+	// it is not set until the flat state is generated
+	rootKey = []byte("snap-root")
+)
+
+var (
+	errStale        = errors.New("snapshot layer is stale")
+	errNotGenerated = errors.New("snapshot is not generated")
+)
+
+func accountKey(hash types.Hash) []byte {
+	return append(append([]byte{}, accountPrefix...), hash.Bytes()...)
+}
+
+func accountStorageKey(account types.Hash) []byte {
+	return append(append([]byte{}, storagePrefix...), account.Bytes()...)
+}
+
+func storageKey(account, slot types.Hash) []byte {
+	return append(accountStorageKey(account), slot.Bytes()...)
+}
+
+// layer is the flat state at a state root. The lookups fail if the layer
+// is stale or not generated, in which case the state must be read from the trie.
+// The layers are read and modified with the lock of the tree held
+type layer interface {
+	// Root returns the state root of the layer
+	Root() types.Hash
+
+	// account returns the encoded account with the hashed address, nil if it does not exist
+	account(hash types.Hash) ([]byte, error)
+
+	// storage returns the encoded value of the hashed slot of the account, nil if it is empty
+	storage(account, slot types.Hash) ([]byte, error)
+}
+
+// diskLayer is the flat state stored in the database
+type diskLayer struct {
+	db   storage.Backend
+	root types.Hash
+
+	// generated is set once the flat state in the database is the state at the root
+	generated bool
+
+	// stale is set once the flat state in the database moved to another root
+	stale bool
+}
+
+func (d *diskLayer) Root() types.Hash {
+	return d.root
+}
+
+func (d *diskLayer) get(k []byte) ([]byte, error) {
+	if d.stale {
+		return nil, errStale
+	}
+	if !d.generated {
+		return nil, errNotGenerated
+	}
+	v, ok, err := d.db.Get(k)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+	return v, nil
+}
+
+func (d *diskLayer) account(hash types.Hash) ([]byte, error) {
+	return d.get(accountKey(hash))
+}
+
+func (d *diskLayer) storage(account, slot types.Hash) ([]byte, error) {
+	return d.get(storageKey(account, slot))
+}
+
+// diffLayer is the in memory diff of the flat state of a block on top of the parent layer
+type diffLayer struct {
+	parent layer
+	root   types.Hash
+
+	// accounts are the accounts modified, nil if they were deleted
+	accounts map[types.Hash][]byte
+
+	// destructs are the accounts whose storage was cleared before
+	// the storage slots of the layer were written
+	destructs map[types.Hash]struct{}
+
+	// slots are the storage slots modified, nil if they were cleared
+	slots map[types.Hash]map[types.Hash][]byte
+
+	// stale is set once the layer is flattened on disk or dropped
+	stale bool
+}
+
+func newDiffLayer() *diffLayer {
+	return &diffLayer{
+		accounts:  map[types.Hash][]byte{},
+		destructs: map[types.Hash]struct{}{},
+		slots:     map[types.Hash]map[types.Hash][]byte{},
+	}
+}
+
+func (d *diffLayer) Root() types.Hash {
+	return d.root
+}
+
+func (d *diffLayer) account(hash types.Hash) ([]byte, error) {
+	if d.stale {
+		return nil, errStale
+	}
+	if v, ok := d.accounts[hash]; ok {
+		return v, nil
+	}
+	return d.parent.account(hash)
+}
+
+func (d *diffLayer) storage(account, slot types.Hash) ([]byte, error) {
+	if d.stale {
+		return nil, errStale
+	}
+	if v, ok := d.slots[account][slot]; ok {
+		return v, nil
+	}
+	if _, ok := d.destructs[account]; ok {
+		return nil, nil
+	}
+	return d.parent.storage(account, slot)
+}
+
+// descends returns whether the layer is on top of the ancestor
+func (d *diffLayer) descends(ancestor layer) bool {
+	for cur := d.parent; ; {
+		if cur == ancestor {
+			return true
+		}
+		diff, ok := cur.(*diffLayer)
+		if !ok {
+			return false
+		}
+		cur = diff.parent
+	}
+}
@@ -0,0 +1,145 @@
+package snapshot
+
+import (
+	"bytes"
+
+	"github.com/0xPolygon/minimal/crypto"
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/umbracle/fastrlp"
+)
+
+// snapshot is the state at a root, whose accounts and storage slots are read from its
+// layer while it is valid and from the tries otherwise. It is committed to the tries
+// and the changes are added as a diff layer on top of its layer
+type snapshot struct {
+	tree  *Tree
+	trie  state.Snapshot
+	layer layer
+}
+
+// Get implements the state.Snapshot interface
+func (s *snapshot) Get(k []byte) ([]byte, bool) {
+	if s.layer != nil && len(k) == types.HashLength {
+		s.tree.lock.RLock()
+		v, err := s.layer.account(types.BytesToHash(k))
+		s.tree.lock.RUnlock()
+
+		if err == nil {
+			return v, v != nil
+		}
+	}
+	return s.trie.Get(k)
+}
+
+// GetStorage implements the state.StorageSnapshot interface
+func (s *snapshot) GetStorage(addrHash types.Hash, root types.Hash) (state.StorageReader, error) {
+	if s.layer == nil {
+		return s.tree.state.NewSnapshotAt(root)
+	}
+	return &storageReader{snapshot: s, account: addrHash, root: root}, nil
+}
+
+var storageArenaPool fastrlp.ArenaPool
+
+// Commit implements the state.Snapshot interface
+func (s *snapshot) Commit(objs []*state.Object) (state.Snapshot, []byte, error) {
+	var diff *diffLayer
+	if s.layer != nil {
+		// the destructs are found with the accounts before the commit
+		diff = s.newDiff(objs)
+	}
+
+	trie, root, err := s.trie.Commit(objs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	next := &snapshot{tree: s.tree, trie: trie}
+	if diff != nil {
+		for _, obj := range objs {
+			if obj.Deleted {
+				continue
+			}
+			hash := types.BytesToHash(crypto.Keccak256(obj.Address.Bytes()))
+			if v, ok := trie.Get(hash.Bytes()); ok {
+				diff.accounts[hash] = v
+			}
+		}
+		next.layer = s.tree.add(s.layer, types.BytesToHash(root), diff)
+	}
+	return next, root, nil
+}
+
+// newDiff returns the diff layer of the objects, without the encoded accounts
+func (s *snapshot) newDiff(objs []*state.Object) *diffLayer {
+	arena := storageArenaPool.Get()
+	defer storageArenaPool.Put(arena)
+
+	diff := newDiffLayer()
+	for _, obj := range objs {
+		hash := types.BytesToHash(crypto.Keccak256(obj.Address.Bytes()))
+
+		if obj.Deleted {
+			diff.accounts[hash] = nil
+			diff.destructs[hash] = struct{}{}
+			continue
+		}
+
+		// the storage of the recreated accounts is written on top of an empty one
+		if v, ok := s.Get(hash.Bytes()); ok {
+			var account state.Account
+			if err := account.UnmarshalRlp(v); err == nil && account.Root != obj.Root {
+				diff.destructs[hash] = struct{}{}
+			}
+		}
+
+		if len(obj.Storage) == 0 {
+			continue
+		}
+		slots := map[types.Hash][]byte{}
+		for _, entry := range obj.Storage {
+			slot := types.BytesToHash(crypto.Keccak256(entry.Key))
+			if entry.Deleted {
+				slots[slot] = nil
+			} else {
+				// same encoding as in the storage trie
+				slots[slot] = arena.NewBytes(bytes.TrimLeft(entry.Val, "\x00")).MarshalTo(nil)
+				arena.Reset()
+			}
+		}
+		diff.slots[hash] = slots
+	}
+	return diff
+}
+
+// storageReader reads the storage of an account from the layer of the snapshot,
+// and from the storage trie of the account once the layer is not valid
+type storageReader struct {
+	snapshot *snapshot
+	account  types.Hash
+	root     types.Hash
+
+	trie state.Snapshot
+}
+
+func (r *storageReader) Get(k []byte) ([]byte, bool) {
+	if r.trie == nil && len(k) == types.HashLength {
+		r.snapshot.tree.lock.RLock()
+		v, err := r.snapshot.layer.storage(r.account, types.BytesToHash(k))
+		r.snapshot.tree.lock.RUnlock()
+
+		if err == nil {
+			return v, v != nil
+		}
+	}
+
+	if r.trie == nil {
+		trie, err := r.snapshot.tree.state.NewSnapshotAt(r.root)
+		if err != nil {
+			return nil, false
+		}
+		r.trie = trie
+	}
+	return r.trie.Get(k)
+}
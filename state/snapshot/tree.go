@@ -0,0 +1,290 @@
+package snapshot
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+)
+
+// DefaultLayers is the default number of diff layers kept in memory on top of the disk layer
+const DefaultLayers = 128
+
+// Tree is a state whose accounts and storage slots are read from a flat key-value
+// snapshot instead of from the tries. The flat state of the oldest block kept is stored
+// on disk and the rest of the blocks are in memory diff layers on top of it, so that
+// the states of the recent blocks of any branch can be read. The states without
+// a layer, or read before the flat state is generated, are read from the tries
+type Tree struct {
+	logger hclog.Logger
+	db     storage.Backend
+	state  state.State
+
+	lock   sync.RWMutex
+	layers map[types.Hash]layer
+	disk   *diskLayer
+
+	// generation of the disk layer in the background
+	generating bool
+	genAbort   chan struct{}
+	genDone    chan struct{}
+}
+
+// NewTree creates a snapshot tree on top of the state whose tries are stored in the database
+func NewTree(logger hclog.Logger, db storage.Backend, st state.State) *Tree {
+	return &Tree{
+		logger: logger,
+		db:     db,
+		state:  st,
+		layers: map[types.Hash]layer{},
+	}
+}
+
+// Start loads the flat state on disk, which is generated again in the
+// background if it is not the state at the root of the head
+func (t *Tree) Start(root types.Hash) error {
+	v, ok, err := t.db.Get(rootKey)
+	if err != nil {
+		return err
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if ok && types.BytesToHash(v) == root {
+		t.setDisk(&diskLayer{db: t.db, root: root, generated: true})
+		return nil
+	}
+	t.regenerate(root)
+	return nil
+}
+
+// setDisk replaces all the layers with the disk layer, the lock must be held
+func (t *Tree) setDisk(disk *diskLayer) {
+	for _, l := range t.layers {
+		switch obj := l.(type) {
+		case *diskLayer:
+			obj.stale = true
+		case *diffLayer:
+			obj.stale = true
+		}
+	}
+	t.disk = disk
+	t.layers = map[types.Hash]layer{disk.root: disk}
+}
+
+// regenerate drops all the layers and generates the flat state at the root, the lock must be held
+func (t *Tree) regenerate(root types.Hash) {
+	t.logger.Info("Generating state snapshot", "root", root)
+
+	disk := &diskLayer{db: t.db, root: root}
+	t.setDisk(disk)
+
+	t.generating = true
+	t.genAbort = make(chan struct{})
+	t.genDone = make(chan struct{})
+
+	go t.generate(disk, t.genAbort, t.genDone)
+}
+
+// add adds the diff layer of a state on top of the layer of its parent state
+func (t *Tree) add(parent layer, root types.Hash, diff *diffLayer) layer {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if l, ok := t.layers[root]; ok {
+		// the state was already computed
+		return l
+	}
+	if t.layers[parent.Root()] != parent {
+		// the parent was flattened or dropped
+		return nil
+	}
+	diff.parent = parent
+	diff.root = root
+	t.layers[root] = diff
+	return diff
+}
+
+// Cap flattens on disk the diff layers below the given number of layers on top of the
+// layer of the root, and drops the layers of the branches that do not descend from
+// them. The flat state is generated again if there is no layer for the root, i.e. after
+// a reorg deeper than the layers kept. The flattening is deferred during the generation
+func (t *Tree) Cap(root types.Hash, layers int) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.disk == nil || t.generating {
+		return nil
+	}
+	l, ok := t.layers[root]
+	if !ok || !t.disk.generated {
+		// the layers of the state are lost or the generation failed
+		t.regenerate(root)
+		return nil
+	}
+
+	chain := []*diffLayer{}
+	for cur := l; ; {
+		diff, ok := cur.(*diffLayer)
+		if !ok {
+			break
+		}
+		chain = append(chain, diff)
+		cur = diff.parent
+	}
+	if len(chain) <= layers {
+		return nil
+	}
+
+	// the layers are flattened from the oldest one
+	flatten := chain[layers:]
+	bottom := flatten[0]
+	if err := t.writeFlatten(flatten); err != nil {
+		return err
+	}
+
+	disk := &diskLayer{db: t.db, root: bottom.root, generated: true}
+	t.disk.stale = true
+	for _, diff := range flatten {
+		diff.stale = true
+	}
+
+	kept := []*diffLayer{}
+	for _, l := range t.layers {
+		diff, ok := l.(*diffLayer)
+		if !ok || diff.stale {
+			continue
+		}
+		if diff.descends(bottom) {
+			kept = append(kept, diff)
+		} else {
+			diff.stale = true
+		}
+	}
+
+	t.disk = disk
+	t.layers = map[types.Hash]layer{disk.root: disk}
+	for _, diff := range kept {
+		if diff.parent == bottom {
+			diff.parent = disk
+		}
+		t.layers[diff.root] = diff
+	}
+	return nil
+}
+
+// writeFlatten writes on disk the diff layers, from the newest to the oldest one
+func (t *Tree) writeFlatten(diffs []*diffLayer) error {
+	merged := newDiffLayer()
+	for i := len(diffs) - 1; i >= 0; i-- {
+		diff := diffs[i]
+		for account := range diff.destructs {
+			merged.destructs[account] = struct{}{}
+			delete(merged.slots, account)
+		}
+		for account, v := range diff.accounts {
+			merged.accounts[account] = v
+		}
+		for account, slots := range diff.slots {
+			if merged.slots[account] == nil {
+				merged.slots[account] = map[types.Hash][]byte{}
+			}
+			for slot, v := range slots {
+				merged.slots[account][slot] = v
+			}
+		}
+	}
+
+	batch := t.db.NewBatch()
+
+	// the storage of the destructed accounts is cleared before the slots are written
+	for account := range merged.destructs {
+		err := t.db.Iterate(accountStorageKey(account), func(k, v []byte) bool {
+			batch.Delete(append([]byte{}, k...))
+			return true
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for account, v := range merged.accounts {
+		if v == nil {
+			batch.Delete(accountKey(account))
+		} else {
+			batch.Set(accountKey(account), v)
+		}
+	}
+	for account, slots := range merged.slots {
+		for slot, v := range slots {
+			if v == nil {
+				batch.Delete(storageKey(account, slot))
+			} else {
+				batch.Set(storageKey(account, slot), v)
+			}
+		}
+	}
+	batch.Set(rootKey, diffs[0].root.Bytes())
+
+	if err := batch.Write(); err != nil {
+		return fmt.Errorf("failed to flatten the snapshot layers: %v", err)
+	}
+	return nil
+}
+
+// NewSnapshot implements the state.State interface
+func (t *Tree) NewSnapshot() state.Snapshot {
+	return &snapshot{tree: t, trie: t.state.NewSnapshot()}
+}
+
+// NewSnapshotAt implements the state.State interface
+func (t *Tree) NewSnapshotAt(root types.Hash) (state.Snapshot, error) {
+	trie, err := t.state.NewSnapshotAt(root)
+	if err != nil {
+		return nil, err
+	}
+
+	t.lock.RLock()
+	l := t.layers[root]
+	t.lock.RUnlock()
+
+	return &snapshot{tree: t, trie: trie, layer: l}, nil
+}
+
+// GetCode implements the state.State interface
+func (t *Tree) GetCode(hash types.Hash) ([]byte, bool) {
+	return t.state.GetCode(hash)
+}
+
+// NewWitnessRecorder implements the state.WitnessState interface, the
+// witnesses are recorded from the tries instead of from the flat state
+func (t *Tree) NewWitnessRecorder() (state.WitnessRecorder, error) {
+	ws, ok := t.state.(state.WitnessState)
+	if !ok {
+		return nil, fmt.Errorf("the state of the snapshots does not record witnesses")
+	}
+	return ws.NewWitnessRecorder()
+}
+
+// CacheStats implements the state.CacheStatsState interface
+func (t *Tree) CacheStats() (uint64, uint64) {
+	if st, ok := t.state.(state.CacheStatsState); ok {
+		return st.CacheStats()
+	}
+	return 0, 0
+}
+
+// Close stops the generation of the flat state, if it is running
+func (t *Tree) Close() {
+	t.lock.Lock()
+	generating, abort, done := t.generating, t.genAbort, t.genDone
+	t.lock.Unlock()
+
+	if generating {
+		close(abort)
+		<-done
+	}
+}
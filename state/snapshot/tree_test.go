@@ -0,0 +1,207 @@
+package snapshot
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xPolygon/minimal/blockchain/storage"
+	"github.com/0xPolygon/minimal/blockchain/storage/memory"
+	"github.com/0xPolygon/minimal/crypto"
+	"github.com/0xPolygon/minimal/state"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	addr1 = types.StringToAddress("1")
+	addr2 = types.StringToAddress("2")
+
+	slot1 = types.StringToHash("1")
+	slot2 = types.StringToHash("2")
+)
+
+func newTestTree(t *testing.T, db storage.Backend, root types.Hash) *Tree {
+	tree := NewTree(hclog.NewNullLogger(), db, itrie.NewState(itrie.NewKVStorage(db)))
+	assert.NoError(t, tree.Start(root))
+
+	for i := 0; i < 100; i++ {
+		tree.lock.RLock()
+		generated := tree.disk.generated
+		tree.lock.RUnlock()
+
+		if generated {
+			return tree
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("snapshot not generated")
+	return nil
+}
+
+func commit(t *testing.T, st state.State, root types.Hash, fn func(txn *state.Txn)) types.Hash {
+	snap, err := st.NewSnapshotAt(root)
+	assert.NoError(t, err)
+
+	txn := state.NewTxn(st, snap)
+	fn(txn)
+	_, newRoot, err := txn.Commit(false)
+	assert.NoError(t, err)
+	return types.BytesToHash(newRoot)
+}
+
+func hashit(b []byte) types.Hash {
+	return types.BytesToHash(crypto.Keccak256(b))
+}
+
+func TestTree_State(t *testing.T) {
+	state.TestState(t, func(pre state.PreStates) (state.State, state.Snapshot) {
+		tree := newTestTree(t, memory.NewBackend(), types.EmptyRootHash)
+
+		snap, err := tree.NewSnapshotAt(types.EmptyRootHash)
+		assert.NoError(t, err)
+		return tree, snap
+	})
+}
+
+func TestTree_Layers(t *testing.T) {
+	db := memory.NewBackend()
+	tree := newTestTree(t, db, types.EmptyRootHash)
+
+	root1 := commit(t, tree, types.EmptyRootHash, func(txn *state.Txn) {
+		txn.SetBalance(addr1, big.NewInt(1))
+		txn.SetState(addr1, slot1, slot1)
+		txn.SetState(addr1, slot2, slot2)
+	})
+	root2 := commit(t, tree, root1, func(txn *state.Txn) {
+		txn.SetBalance(addr2, big.NewInt(2))
+		txn.SetState(addr1, slot1, types.Hash{})
+	})
+
+	// the states are read from the diff layers
+	l := tree.layers[root2]
+	assert.IsType(t, &diffLayer{}, l)
+
+	v, err := l.account(hashit(addr2.Bytes()))
+	assert.NoError(t, err)
+	assert.NotNil(t, v)
+
+	v, err = l.storage(hashit(addr1.Bytes()), hashit(slot1.Bytes()))
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	v, err = l.storage(hashit(addr1.Bytes()), hashit(slot2.Bytes()))
+	assert.NoError(t, err)
+	assert.NotNil(t, v)
+
+	// flatten the oldest layer on disk
+	assert.NoError(t, tree.Cap(root2, 1))
+	assert.IsType(t, &diskLayer{}, tree.layers[root1])
+	assert.Equal(t, tree.disk, tree.layers[root1])
+	assert.Equal(t, tree.disk, tree.layers[root2].(*diffLayer).parent)
+
+	// and the rest of them once the tree is closed
+	assert.NoError(t, tree.Cap(root2, 0))
+	tree.Close()
+
+	// the flat state on disk is loaded with the same root
+	tree = newTestTree(t, db, root2)
+
+	snap, err := tree.NewSnapshotAt(root2)
+	assert.NoError(t, err)
+
+	txn := state.NewTxn(tree, snap)
+	assert.Equal(t, big.NewInt(1), txn.GetBalance(addr1))
+	assert.Equal(t, big.NewInt(2), txn.GetBalance(addr2))
+	assert.Equal(t, types.Hash{}, txn.GetState(addr1, slot1))
+	assert.Equal(t, slot2, txn.GetState(addr1, slot2))
+}
+
+func TestTree_Destruct(t *testing.T) {
+	tree := newTestTree(t, memory.NewBackend(), types.EmptyRootHash)
+
+	root1 := commit(t, tree, types.EmptyRootHash, func(txn *state.Txn) {
+		txn.SetBalance(addr1, big.NewInt(1))
+		txn.SetState(addr1, slot1, slot1)
+	})
+	root2 := commit(t, tree, root1, func(txn *state.Txn) {
+		txn.Suicide(addr1)
+	})
+	root3 := commit(t, tree, root2, func(txn *state.Txn) {
+		txn.CreateAccount(addr1)
+		txn.SetState(addr1, slot2, slot2)
+	})
+	assert.NoError(t, tree.Cap(root3, 0))
+
+	account := hashit(addr1.Bytes())
+	v, err := tree.disk.storage(account, hashit(slot1.Bytes()))
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	v, err = tree.disk.storage(account, hashit(slot2.Bytes()))
+	assert.NoError(t, err)
+	assert.NotNil(t, v)
+}
+
+func TestTree_Reorg(t *testing.T) {
+	tree := newTestTree(t, memory.NewBackend(), types.EmptyRootHash)
+
+	root1 := commit(t, tree, types.EmptyRootHash, func(txn *state.Txn) {
+		txn.SetBalance(addr1, big.NewInt(1))
+	})
+	rootA := commit(t, tree, root1, func(txn *state.Txn) {
+		txn.SetBalance(addr1, big.NewInt(2))
+	})
+	rootB := commit(t, tree, root1, func(txn *state.Txn) {
+		txn.SetBalance(addr1, big.NewInt(3))
+	})
+	snapB, err := tree.NewSnapshotAt(rootB)
+	assert.NoError(t, err)
+
+	// both branches are kept until they are flattened
+	assert.NoError(t, tree.Cap(rootA, 1))
+	assert.Contains(t, tree.layers, rootA)
+	assert.Contains(t, tree.layers, rootB)
+
+	// the branch that is not flattened is dropped
+	assert.NoError(t, tree.Cap(rootA, 0))
+	assert.NotContains(t, tree.layers, rootB)
+
+	// but its state is still read from the trie
+	assert.Equal(t, big.NewInt(3), state.NewTxn(tree, snapB).GetBalance(addr1))
+
+	// the flat state is generated again after a reorg to the dropped branch
+	assert.NoError(t, tree.Cap(rootB, 0))
+	tree.Close()
+	assert.Equal(t, rootB, tree.disk.root)
+}
+
+func TestTree_Generate(t *testing.T) {
+	db := memory.NewBackend()
+	st := itrie.NewState(itrie.NewKVStorage(db))
+
+	root := commit(t, st, types.EmptyRootHash, func(txn *state.Txn) {
+		txn.SetBalance(addr1, big.NewInt(1))
+		txn.SetState(addr1, slot1, slot1)
+		txn.SetBalance(addr2, big.NewInt(2))
+	})
+
+	tree := newTestTree(t, db, root)
+
+	v, err := tree.disk.account(hashit(addr2.Bytes()))
+	assert.NoError(t, err)
+	assert.NotNil(t, v)
+
+	v, err = tree.disk.storage(hashit(addr1.Bytes()), hashit(slot1.Bytes()))
+	assert.NoError(t, err)
+	assert.NotNil(t, v)
+
+	snap, err := tree.NewSnapshotAt(root)
+	assert.NoError(t, err)
+
+	txn := state.NewTxn(tree, snap)
+	assert.Equal(t, big.NewInt(2), txn.GetBalance(addr2))
+	assert.Equal(t, slot1, txn.GetState(addr1, slot1))
+}
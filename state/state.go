@@ -20,7 +20,104 @@ type State interface {
 
 type Snapshot interface {
 	Get(k []byte) ([]byte, bool)
-	Commit(objs []*Object) (Snapshot, []byte)
+	Commit(objs []*Object) (Snapshot, []byte, error)
+}
+
+// StorageSnapshot is implemented by the snapshots that read the storage
+// of the accounts without traversing their storage tries
+type StorageSnapshot interface {
+	// GetStorage returns the storage of the account with the hashed
+	// address, whose storage root in the snapshot is the given root
+	GetStorage(addrHash types.Hash, root types.Hash) (StorageReader, error)
+}
+
+// StorageReader reads the values of the hashed slots of an account storage
+type StorageReader interface {
+	Get(k []byte) ([]byte, bool)
+}
+
+// PrefetchState is implemented by the states that can load an account, its code and
+// some of its storage slots at a root into their caches. It is safe to call it
+// concurrently with the reads of the snapshots of the state
+type PrefetchState interface {
+	Prefetch(root types.Hash, addr types.Address, slots []types.Hash)
+}
+
+// CacheStatsState is implemented by the states that count the reads served by their caches
+type CacheStatsState interface {
+	// CacheStats returns the number of reads served by the caches and the number of reads not found in them
+	CacheStats() (hits uint64, misses uint64)
+}
+
+// AccountProof is the merkle proof of an account in the state trie and the
+// proofs of some of its storage slots in the storage trie of the account
+type AccountProof struct {
+	// Account is the account proved, nil if it does not exist
+	Account *Account
+
+	// Proof are the encoded trie nodes from the root to the account
+	Proof        [][]byte
+	StorageProof []*StorageProof
+}
+
+// StorageProof is the merkle proof of a storage slot
+type StorageProof struct {
+	Key types.Hash
+
+	// Value is the value of the slot, nil if it is empty
+	Value []byte
+	Proof [][]byte
+}
+
+// Dump is a range of the accounts of a state, in order of their hashed addresses
+type Dump struct {
+	Root     types.Hash
+	Accounts []*DumpAccount
+
+	// Next is the hashed address of the first account after the range, nil if there is none
+	Next *types.Hash
+}
+
+// DumpAccount is an account of a state dump with its code and storage
+type DumpAccount struct {
+	*Account
+
+	// Key is the hashed address, the address is nil if its preimage is not stored
+	Key     types.Hash
+	Address *types.Address
+
+	Code    []byte
+	Storage []*DumpSlot
+
+	// StorageNext is the hashed slot of the first slot not in the storage
+	// if it is truncated, nil if there is none
+	StorageNext *types.Hash
+}
+
+// DumpSlot is a storage slot of a state dump
+type DumpSlot struct {
+	// Key is the hashed slot, the slot is nil if its preimage is not stored
+	Key   types.Hash
+	Slot  *types.Hash
+	Value types.Hash
+}
+
+// StorageRange is a range of the storage slots of an account, in order of their hashed slots
+type StorageRange struct {
+	Slots []*DumpSlot
+
+	// Next is the hashed slot of the first slot after the range, nil if there is none
+	Next *types.Hash
+}
+
+// AccountStats are the sizes of the code and of the storage of an account
+type AccountStats struct {
+	*Account
+
+	CodeSize uint64
+
+	// StorageSlots is the number of non empty slots of the storage
+	StorageSlots uint64
 }
 
 // account trie
@@ -78,6 +78,13 @@ func TestState(t *testing.T, buildPreState buildPreState) {
 	})
 }
 
+// commit commits the txn and returns the new snapshot
+func commit(t *testing.T, txn *Txn, deleteEmptyObjects bool) Snapshot {
+	snap, _, err := txn.Commit(deleteEmptyObjects)
+	assert.NoError(t, err)
+	return snap
+}
+
 func testDeleteCommonStateRoot(t *testing.T, buildPreState buildPreState) {
 	state, snap := buildPreState(nil)
 	txn := newTxn(state, snap)
@@ -92,13 +99,13 @@ func testDeleteCommonStateRoot(t *testing.T, buildPreState buildPreState) {
 	txn.SetState(addr2, hash1, hash1)
 	txn.SetState(addr2, hash2, hash1)
 
-	snap2, _ := txn.Commit(false)
+	snap2 := commit(t, txn, false)
 	txn2 := newTxn(state, snap2)
 
 	txn2.SetState(addr1, hash0, hash0)
 	txn2.SetState(addr1, hash1, hash0)
 
-	snap3, _ := txn2.Commit(false)
+	snap3 := commit(t, txn2, false)
 
 	txn3 := newTxn(state, snap3)
 	assert.Equal(t, hash1, txn3.GetState(addr1, hash2))
@@ -117,7 +124,7 @@ func testWriteState(t *testing.T, buildPreState buildPreState) {
 	assert.Equal(t, hash1, txn.GetState(addr1, hash1))
 	assert.Equal(t, hash2, txn.GetState(addr1, hash2))
 
-	snap, _ = txn.Commit(false)
+	snap = commit(t, txn, false)
 
 	txn = newTxn(state, snap)
 	assert.Equal(t, hash1, txn.GetState(addr1, hash1))
@@ -131,7 +138,7 @@ func testWriteEmptyState(t *testing.T, buildPreState buildPreState) {
 
 	// Without EIP150 the data is added
 	txn.SetState(addr1, hash1, hash0)
-	snap, _ = txn.Commit(false)
+	snap = commit(t, txn, false)
 
 	txn = newTxn(state, snap)
 	assert.True(t, txn.Exist(addr1))
@@ -141,7 +148,7 @@ func testWriteEmptyState(t *testing.T, buildPreState buildPreState) {
 
 	// With EIP150 the empty data is removed
 	txn.SetState(addr1, hash1, hash0)
-	snap, _ = txn.Commit(true)
+	snap = commit(t, txn, true)
 
 	txn = newTxn(state, snap)
 	assert.False(t, txn.Exist(addr1))
@@ -155,7 +162,7 @@ func testUpdateStateInPreState(t *testing.T, buildPreState buildPreState) {
 	assert.Equal(t, hash1, txn.GetState(addr1, hash1))
 
 	txn.SetState(addr1, hash1, hash2)
-	snap, _ = txn.Commit(false)
+	snap = commit(t, txn, false)
 
 	txn = newTxn(state, snap)
 	assert.Equal(t, hash2, txn.GetState(addr1, hash1))
@@ -170,7 +177,7 @@ func testUpdateStateWithEmpty(t *testing.T, buildPreState buildPreState) {
 
 	// TODO, test with false (should not be deleted)
 	// TODO, test with balance on the account and nonce
-	snap, _ = txn.Commit(true)
+	snap = commit(t, txn, true)
 
 	txn = newTxn(state, snap)
 	assert.False(t, txn.Exist(addr1))
@@ -182,7 +189,7 @@ func testSuicideAccountInPreState(t *testing.T, buildPreState buildPreState) {
 
 	txn := newTxn(state, snap)
 	txn.Suicide(addr1)
-	snap, _ = txn.Commit(true)
+	snap = commit(t, txn, true)
 
 	txn = newTxn(state, snap)
 	assert.False(t, txn.Exist(addr1))
@@ -199,7 +206,7 @@ func testSuicideAccount(t *testing.T, buildPreState buildPreState) {
 	// Note, even if has commit suicide it still exists in the current txn
 	assert.True(t, txn.Exist(addr1))
 
-	snap, _ = txn.Commit(true)
+	snap = commit(t, txn, true)
 
 	txn = newTxn(state, snap)
 	assert.False(t, txn.Exist(addr1))
@@ -218,7 +225,7 @@ func testSuicideAccountWithData(t *testing.T, buildPreState buildPreState) {
 	txn.SetState(addr1, hash1, hash1)
 
 	txn.Suicide(addr1)
-	snap, _ = txn.Commit(true)
+	snap = commit(t, txn, true)
 
 	txn = newTxn(state, snap)
 
@@ -240,7 +247,7 @@ func testSuicideCoinbase(t *testing.T, buildPreState buildPreState) {
 	txn := newTxn(state, snap)
 	txn.Suicide(addr1)
 	txn.AddSealingReward(addr1, big.NewInt(10))
-	snap, _ = txn.Commit(true)
+	snap = commit(t, txn, true)
 
 	txn = newTxn(state, snap)
 	assert.Equal(t, big.NewInt(10), txn.GetBalance(addr1))
@@ -258,7 +265,7 @@ func testSuicideWithIntermediateCommit(t *testing.T, buildPreState buildPreState
 	txn.CleanDeleteObjects(true)
 	assert.Equal(t, uint64(0), txn.GetNonce(addr1))
 
-	txn.Commit(true)
+	commit(t, txn, true)
 	assert.Equal(t, uint64(0), txn.GetNonce(addr1))
 }
 
@@ -272,7 +279,7 @@ func testRestartRefunds(t *testing.T, buildPreState buildPreState) {
 	txn.AddRefund(1000)
 	assert.Equal(t, uint64(1000), txn.GetRefund())
 
-	txn.Commit(false)
+	commit(t, txn, false)
 
 	// refund should be empty after the commit
 	assert.Equal(t, uint64(0), txn.GetRefund())
@@ -290,7 +297,7 @@ func testChangePrestateAccountBalanceToZero(t *testing.T, buildPreState buildPre
 
 	txn := newTxn(state, snap)
 	txn.SetBalance(addr1, big.NewInt(0))
-	snap, _ = txn.Commit(true)
+	snap = commit(t, txn, true)
 
 	txn = newTxn(state, snap)
 	assert.False(t, txn.Exist(addr1))
@@ -303,7 +310,7 @@ func testChangeAccountBalanceToZero(t *testing.T, buildPreState buildPreState) {
 	txn := newTxn(state, snap)
 	txn.SetBalance(addr1, big.NewInt(10))
 	txn.SetBalance(addr1, big.NewInt(0))
-	snap, _ = txn.Commit(true)
+	snap = commit(t, txn, true)
 
 	txn = newTxn(state, snap)
 	assert.False(t, txn.Exist(addr1))
@@ -0,0 +1,53 @@
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutor_TraceBlock(t *testing.T) {
+	addr3 := types.StringToAddress("3")
+
+	executor, root := newTestExecutor(&chain.Params{Forks: chain.AllForksEnabled, ChainID: 1})
+
+	txn := func(nonce uint64) *types.Transaction {
+		return &types.Transaction{
+			From:     addr1,
+			Nonce:    nonce,
+			To:       &addr3,
+			Value:    big.NewInt(1),
+			Gas:      21000,
+			GasPrice: big.NewInt(0),
+		}
+	}
+	block := &types.Block{
+		Header:       &types.Header{Number: 1, GasLimit: 42000},
+		Transactions: []*types.Transaction{txn(0), txn(1)},
+	}
+
+	callTracer := runtime.NewCallTracer(false)
+	prestateTracer := runtime.NewPrestateTracer()
+
+	_, err := executor.TraceBlock(root, block, []runtime.Tracer{callTracer})
+	assert.Error(t, err)
+
+	res, err := executor.TraceBlock(root, block, []runtime.Tracer{callTracer, prestateTracer})
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	for _, r := range res {
+		assert.Equal(t, uint64(21000), r.GasUsed)
+		assert.False(t, r.Failed)
+	}
+
+	assert.Equal(t, addr3, callTracer.Result().To)
+
+	// the second txn is traced on top of the first one
+	prestate := prestateTracer.Result()
+	assert.Equal(t, uint64(1), prestate[addr1].Nonce)
+	assert.Equal(t, big.NewInt(1), prestate[addr3].Balance)
+}
@@ -25,6 +25,9 @@ var (
 
 	// refundIndex is the index of the refund
 	refundIndex = types.BytesToHash([]byte{3}).Bytes()
+
+	// accessListIndex is the prefix of the addresses and slots of the access list in the trie
+	accessListIndex = types.BytesToHash([]byte{4}).Bytes()
 )
 
 // Txn is a reference of the state
@@ -107,7 +110,8 @@ func (txn *Txn) getStateObject(addr types.Address) (*StateObject, bool) {
 		return obj.Copy(), true
 	}
 
-	data, ok := txn.snapshot.Get(txn.hashit(addr.Bytes()))
+	addrHash := types.BytesToHash(txn.hashit(addr.Bytes()))
+	data, ok := txn.snapshot.Get(addrHash.Bytes())
 	if !ok {
 		return nil, false
 	}
@@ -121,6 +125,12 @@ func (txn *Txn) getStateObject(addr types.Address) (*StateObject, bool) {
 	// Load trie from memory if there is some state
 	if account.Root == emptyStateHash {
 		account.Trie = txn.state.NewSnapshot()
+	} else if snap, ok := txn.snapshot.(StorageSnapshot); ok {
+		// read the storage from the snapshot instead of from the trie
+		account.Trie, err = snap.GetStorage(addrHash, account.Root)
+		if err != nil {
+			return nil, false
+		}
 	} else {
 		account.Trie, err = txn.state.NewSnapshotAt(account.Root)
 		if err != nil {
@@ -298,13 +308,18 @@ func (txn *Txn) SetStorage(addr types.Address, key types.Hash, value types.Hash,
 	}
 	if original == value {
 		if original == zeroHash { // reset to original inexistent slot (2.2.2.1)
-			if config.Istanbul {
+			if config.Berlin {
+				// eip-2929
+				txn.AddRefund(19900)
+			} else if config.Istanbul {
 				txn.AddRefund(19200)
 			} else {
 				txn.AddRefund(19800)
 			}
 		} else { // reset to original existing slot (2.2.2.2)
-			if config.Istanbul {
+			if config.Berlin {
+				txn.AddRefund(2800)
+			} else if config.Istanbul {
 				txn.AddRefund(4200)
 			} else {
 				txn.AddRefund(4800)
@@ -329,6 +344,18 @@ func (txn *Txn) SetState(addr types.Address, key, value types.Hash) {
 	})
 }
 
+// SetFullStorage replaces the whole storage of the address with the given slots
+func (txn *Txn) SetFullStorage(addr types.Address, storage map[types.Hash]types.Hash) {
+	txn.upsertAccount(addr, true, func(object *StateObject) {
+		object.Account.Root = emptyStateHash
+		object.Account.Trie = txn.state.NewSnapshot()
+		object.Txn = nil
+	})
+	for key, value := range storage {
+		txn.SetState(addr, key, value)
+	}
+}
+
 // GetState returns the state of the address at a given hash
 func (txn *Txn) GetState(addr types.Address, hash types.Hash) types.Hash {
 	object, exists := txn.getStateObject(addr)
@@ -468,6 +495,38 @@ func (txn *Txn) GetRefund() uint64 {
 	return data.(uint64)
 }
 
+// accessListKey returns the key of the address, or of the slot of the address, in the access list
+func accessListKey(addr types.Address, slot *types.Hash) []byte {
+	k := append(append([]byte{}, accessListIndex...), addr.Bytes()...)
+	if slot != nil {
+		k = append(k, slot.Bytes()...)
+	}
+	return k
+}
+
+// AddressInAccessList returns true if the address is in the access list of the txn (eip-2929)
+func (txn *Txn) AddressInAccessList(addr types.Address) bool {
+	_, ok := txn.txn.Get(accessListKey(addr, nil))
+	return ok
+}
+
+// SlotInAccessList returns true if the slot of the address is in the access list of the txn
+func (txn *Txn) SlotInAccessList(addr types.Address, slot types.Hash) bool {
+	_, ok := txn.txn.Get(accessListKey(addr, &slot))
+	return ok
+}
+
+// AddAddressToAccessList adds the address to the access list, it is reverted with the snapshots
+func (txn *Txn) AddAddressToAccessList(addr types.Address) {
+	txn.txn.Insert(accessListKey(addr, nil), struct{}{})
+}
+
+// AddSlotToAccessList adds the slot and its address to the access list
+func (txn *Txn) AddSlotToAccessList(addr types.Address, slot types.Hash) {
+	txn.AddAddressToAccessList(addr)
+	txn.txn.Insert(accessListKey(addr, &slot), struct{}{})
+}
+
 // GetCommittedState returns the state of the address in the trie
 func (txn *Txn) GetCommittedState(addr types.Address, hash types.Hash) types.Hash {
 	obj, ok := txn.getStateObject(addr)
@@ -555,8 +614,9 @@ func (txn *Txn) CleanDeleteObjects(deleteEmptyObjects bool) {
 		txn.txn.Insert(k, obj2)
 	}
 
-	// delete refunds
+	// delete refunds and the access list
 	txn.txn.Delete(refundIndex)
+	txn.txn.DeletePrefix(accessListIndex)
 }
 
 func (txn *Txn) show(i *iradix.Txn) {
@@ -611,7 +671,7 @@ func show(objs []*Object) {
 	fmt.Println("##################################################################################")
 }
 
-func (txn *Txn) Commit(deleteEmptyObjects bool) (Snapshot, []byte) {
+func (txn *Txn) Commit(deleteEmptyObjects bool) (Snapshot, []byte, error) {
 	txn.CleanDeleteObjects(deleteEmptyObjects)
 
 	x := txn.txn.Commit()
@@ -656,8 +716,7 @@ func (txn *Txn) Commit(deleteEmptyObjects bool) (Snapshot, []byte) {
 	})
 	// show(objs)
 
-	t, hash := txn.snapshot.Commit(objs)
-	return t, hash
+	return txn.snapshot.Commit(objs)
 }
 
 type hashImpl interface {
@@ -43,7 +43,7 @@ func (m *mockSnapshot) Get(k []byte) ([]byte, bool) {
 	return v, ok
 }
 
-func (m *mockSnapshot) Commit(objs []*Object) (Snapshot, []byte) {
+func (m *mockSnapshot) Commit(objs []*Object) (Snapshot, []byte, error) {
 	panic("Not implemented in tests")
 }
 
@@ -134,3 +134,60 @@ func hashit(k []byte) []byte {
 	h.Write(k)
 	return h.Sum(nil)
 }
+
+func TestAccessList(t *testing.T) {
+	txn := newTestTxn(defaultPreState)
+
+	txn.AddAddressToAccessList(addr1)
+	assert.True(t, txn.AddressInAccessList(addr1))
+	assert.False(t, txn.SlotInAccessList(addr1, hash1))
+
+	// the access list is reverted with the snapshots
+	ss := txn.Snapshot()
+	txn.AddSlotToAccessList(addr2, hash1)
+	assert.True(t, txn.AddressInAccessList(addr2))
+	assert.True(t, txn.SlotInAccessList(addr2, hash1))
+
+	txn.RevertToSnapshot(ss)
+	assert.False(t, txn.AddressInAccessList(addr2))
+	assert.False(t, txn.SlotInAccessList(addr2, hash1))
+
+	// and it is cleared at the end of the txn
+	txn.CleanDeleteObjects(true)
+	assert.False(t, txn.AddressInAccessList(addr1))
+}
+
+func TestStateOverride(t *testing.T) {
+	transition := &Transition{state: newTestTxn(defaultPreState)}
+
+	nonce := uint64(5)
+	err := transition.WithStateOverride(StateOverride{
+		addr1: {
+			Nonce:   &nonce,
+			Balance: big.NewInt(100),
+			State:   map[types.Hash]types.Hash{hash2: hash2},
+		},
+		addr2: {
+			StateDiff: map[types.Hash]types.Hash{hash1: hash1},
+		},
+	})
+	assert.NoError(t, err)
+
+	txn := transition.Txn()
+	assert.Equal(t, uint64(5), txn.GetNonce(addr1))
+	assert.Equal(t, big.NewInt(100), txn.GetBalance(addr1))
+
+	// the state replaces the whole storage of the account
+	assert.Equal(t, types.Hash{}, txn.GetState(addr1, hash1))
+	assert.Equal(t, hash2, txn.GetState(addr1, hash2))
+	assert.Equal(t, hash1, txn.GetState(addr2, hash1))
+
+	// state and state diff cannot be overridden at the same time
+	err = transition.WithStateOverride(StateOverride{
+		addr1: {
+			State:     map[types.Hash]types.Hash{},
+			StateDiff: map[types.Hash]types.Hash{},
+		},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,144 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/0xPolygon/minimal/crypto"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/umbracle/fastrlp"
+)
+
+// Witness are the trie nodes and the contract codes of the state of the parent of a block
+// that are read by the execution of the block, which is enough to execute the block on top
+// of the witness alone and to check its state root (stateless verification)
+type Witness struct {
+	// Root is the state root of the parent of the block
+	Root types.Hash
+
+	// Nodes are the encoded trie nodes, in order of their hashes
+	Nodes [][]byte
+
+	// Codes are the contract codes, in order of their hashes
+	Codes [][]byte
+}
+
+var errWitnessNotSupported = fmt.Errorf("the state does not record witnesses")
+
+// WitnessRecorder is a state that records the trie nodes and the contract codes
+// read from the storage. The changes committed to it are not written to the storage
+type WitnessRecorder interface {
+	State
+
+	// Witness returns the trie nodes and the codes read so far
+	Witness() *Witness
+}
+
+// WitnessState is implemented by the states that can record the witness of the blocks
+type WitnessState interface {
+	NewWitnessRecorder() (WitnessRecorder, error)
+}
+
+// MarshalRLP returns the rlp encoding of the witness
+func (w *Witness) MarshalRLP() []byte {
+	ar := &fastrlp.Arena{}
+
+	nodes := ar.NewArray()
+	for _, node := range w.Nodes {
+		nodes.Set(ar.NewBytes(node))
+	}
+	codes := ar.NewArray()
+	for _, code := range w.Codes {
+		codes.Set(ar.NewBytes(code))
+	}
+
+	v := ar.NewArray()
+	v.Set(ar.NewBytes(w.Root.Bytes()))
+	v.Set(nodes)
+	v.Set(codes)
+	return v.MarshalTo(nil)
+}
+
+// UnmarshalRLP decodes the rlp encoding of the witness
+func (w *Witness) UnmarshalRLP(b []byte) error {
+	p := &fastrlp.Parser{}
+
+	v, err := p.Parse(b)
+	if err != nil {
+		return err
+	}
+	elems, err := v.GetElems()
+	if err != nil {
+		return err
+	}
+	if len(elems) != 3 {
+		return fmt.Errorf("expected 3 elements in the witness but found %d", len(elems))
+	}
+	if err = elems[0].GetHash(w.Root[:]); err != nil {
+		return err
+	}
+	if w.Nodes, err = getBytesList(elems[1]); err != nil {
+		return err
+	}
+	if w.Codes, err = getBytesList(elems[2]); err != nil {
+		return err
+	}
+	return nil
+}
+
+func getBytesList(v *fastrlp.Value) ([][]byte, error) {
+	elems, err := v.GetElems()
+	if err != nil {
+		return nil, err
+	}
+	res := make([][]byte, 0, len(elems))
+	for _, elem := range elems {
+		buf, err := elem.GetBytes(nil)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, buf)
+	}
+	return res, nil
+}
+
+// Witness executes the block on top of the state of its parent and returns the trie nodes
+// and the codes of the state of the parent read by the execution and by the computation of
+// the state root of the block. The state is not modified
+func (e *Executor) Witness(parentRoot types.Hash, block *types.Block) (*Witness, error) {
+	ws, ok := e.state.(WitnessState)
+	if !ok {
+		return nil, errWitnessNotSupported
+	}
+	recorder, err := ws.NewWitnessRecorder()
+	if err != nil {
+		return nil, err
+	}
+
+	txn, err := e.beginTxn(recorder, parentRoot, block.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	signer := crypto.NewSigner(txn.config, uint64(e.config.ChainID))
+	if err := crypto.RecoverSenders(signer, block.Transactions, nil); err != nil {
+		return nil, err
+	}
+
+	txn.block = block
+	for _, t := range block.Transactions {
+		if err := txn.Write(t); err != nil {
+			return nil, err
+		}
+	}
+	_, root, err := txn.Commit()
+	if err != nil {
+		return nil, err
+	}
+	if root != block.Header.StateRoot {
+		return nil, fmt.Errorf("invalid state root of block %d, expected %s but found %s", block.Number(), block.Header.StateRoot, root)
+	}
+
+	w := recorder.Witness()
+	w.Root = parentRoot
+	return w, nil
+}
@@ -71,7 +71,10 @@ func RunSpecificTest(file string, t *testing.T, c stateCase, name, fork string,
 	// mining rewards
 	txn.AddSealingReward(env.Coinbase, big.NewInt(0))
 
-	_, root := txn.Commit(forks.EIP158)
+	_, root, err := txn.Commit(forks.EIP158)
+	if err != nil {
+		t.Fatal(err)
+	}
 	if !bytes.Equal(root, p.Root.Bytes()) {
 		t.Fatalf("root mismatch (%s %s %s %d): expected %s but found %s", file, name, fork, index, p.Root.String(), hex.EncodeToHex(root))
 	}
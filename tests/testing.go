@@ -223,7 +223,10 @@ func buildState(t *testing.T, allocs map[types.Address]*chain.GenesisAccount) (s
 		}
 	}
 
-	snap, root := txn.Commit(false)
+	snap, root, err := txn.Commit(false)
+	if err != nil {
+		t.Fatal(err)
+	}
 	return s, snap, types.BytesToHash(root)
 }
 
@@ -406,6 +409,17 @@ var Forks = map[string]*chain.Forks{
 		Petersburg:     chain.NewFork(0),
 		Istanbul:       chain.NewFork(0),
 	},
+	"Berlin": {
+		Homestead:      chain.NewFork(0),
+		EIP150:         chain.NewFork(0),
+		EIP155:         chain.NewFork(0),
+		EIP158:         chain.NewFork(0),
+		Byzantium:      chain.NewFork(0),
+		Constantinople: chain.NewFork(0),
+		Petersburg:     chain.NewFork(0),
+		Istanbul:       chain.NewFork(0),
+		Berlin:         chain.NewFork(0),
+	},
 	"FrontierToHomesteadAt5": {
 		Homestead: chain.NewFork(5),
 	},
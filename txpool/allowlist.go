@@ -0,0 +1,69 @@
+package txpool
+
+import (
+	"fmt"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/helper/keccak"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// allowlist decides which transactions are accepted by the pool
+type allowlist struct {
+	senders   map[types.Address]struct{}
+	contracts map[types.Address]struct{}
+	registry  *types.Address
+}
+
+func newAllowlist(config *chain.Allowlist) *allowlist {
+	a := &allowlist{
+		senders:   map[types.Address]struct{}{},
+		contracts: map[types.Address]struct{}{},
+		registry:  config.Registry,
+	}
+	for _, addr := range config.Senders {
+		a.senders[addr] = struct{}{}
+	}
+	for _, addr := range config.Contracts {
+		a.contracts[addr] = struct{}{}
+	}
+	return a
+}
+
+// SetAllowlist enables the allowlisted-sender mode in the pool. Only the transactions
+// from an allowed sender or to an allowed contract are accepted (and gossiped)
+func (t *TxPool) SetAllowlist(config *chain.Allowlist) {
+	t.allowlist = newAllowlist(config)
+}
+
+// checkAllowlist returns an error if the allowlist mode is enabled
+// and the transaction is not allowed by it
+func (t *TxPool) checkAllowlist(txn *types.Transaction) error {
+	if t.allowlist == nil {
+		return nil
+	}
+	if _, ok := t.allowlist.senders[txn.From]; ok {
+		return nil
+	}
+	if txn.To != nil {
+		if _, ok := t.allowlist.contracts[*txn.To]; ok {
+			return nil
+		}
+	}
+	if t.allowlist.registry != nil {
+		root := t.store.Header().StateRoot
+		if len(t.store.GetStorage(root, *t.allowlist.registry, registrySlot(txn.From))) != 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("sender %s is not allowed", txn.From)
+}
+
+// registrySlot returns the storage slot of the sender in the
+// mapping(address => bool) at the slot 0 of the registry contract
+func registrySlot(addr types.Address) types.Hash {
+	buf := make([]byte, 64)
+	copy(buf[12:32], addr.Bytes())
+
+	return types.BytesToHash(keccak.Keccak256(nil, buf))
+}
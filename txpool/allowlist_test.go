@@ -0,0 +1,59 @@
+package txpool
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowlist(t *testing.T) {
+	sender := types.Address{0x1}
+	contract := types.Address{0x2}
+	registered := types.Address{0x3}
+	registry := types.Address{0x4}
+
+	store := &mockStore{
+		storage: map[types.Hash][]byte{
+			registrySlot(registered): {0x1},
+		},
+	}
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, store, nil, nil)
+	assert.NoError(t, err)
+	pool.SetDevConfig(&DevConfig{})
+
+	pool.SetAllowlist(&chain.Allowlist{
+		Senders:   []types.Address{sender},
+		Contracts: []types.Address{contract},
+		Registry:  &registry,
+	})
+
+	other := types.Address{0x5}
+	cases := []struct {
+		from    types.Address
+		to      *types.Address
+		allowed bool
+	}{
+		{sender, nil, true},
+		{other, &contract, true},
+		{registered, nil, true},
+		{other, nil, false},
+		{other, &other, false},
+	}
+	for _, c := range cases {
+		txn := &types.Transaction{
+			From:     c.from,
+			To:       c.to,
+			GasPrice: big.NewInt(1),
+		}
+		err := pool.addImpl("", txn)
+		if c.allowed {
+			assert.NoError(t, err)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
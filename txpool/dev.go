@@ -0,0 +1,51 @@
+package txpool
+
+import (
+	"fmt"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// DevConfig is the configuration of the dev mode, in which the pool accepts
+// unsigned transactions. It is only meant for the dev consensus
+type DevConfig struct {
+	// UnsignedSenders are the senders allowed to submit unsigned transactions.
+	// If empty, the unsigned transactions from any sender are accepted
+	UnsignedSenders []types.Address
+}
+
+// devMode decides which unsigned transactions are accepted by the pool
+type devMode struct {
+	senders map[types.Address]struct{}
+}
+
+func newDevMode(config *DevConfig) *devMode {
+	d := &devMode{
+		senders: map[types.Address]struct{}{},
+	}
+	for _, addr := range config.UnsignedSenders {
+		d.senders[addr] = struct{}{}
+	}
+	return d
+}
+
+// SetDevConfig enables the dev mode in the pool. The unsigned transactions
+// allowed by the config are accepted and the transactions are not gossiped
+func (t *TxPool) SetDevConfig(config *DevConfig) {
+	t.dev = newDevMode(config)
+}
+
+// checkUnsigned returns an error if the unsigned transaction
+// is not allowed by the dev mode
+func (t *TxPool) checkUnsigned(txn *types.Transaction) error {
+	if t.dev == nil {
+		return fmt.Errorf("cannot accept non-encrypted txn")
+	}
+	if len(t.dev.senders) == 0 {
+		return nil
+	}
+	if _, ok := t.dev.senders[txn.From]; ok {
+		return nil
+	}
+	return fmt.Errorf("cannot accept non-encrypted txn from %s", txn.From)
+}
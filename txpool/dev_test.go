@@ -0,0 +1,39 @@
+package txpool
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevConfig(t *testing.T) {
+	allowed := types.Address{0x1}
+	other := types.Address{0x2}
+
+	txn := func(from types.Address) *types.Transaction {
+		return &types.Transaction{
+			From:     from,
+			GasPrice: big.NewInt(1),
+		}
+	}
+
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+
+	// unsigned txns are rejected without the dev mode
+	assert.Error(t, pool.addImpl("", txn(allowed)))
+
+	// only the allowed senders can submit unsigned txns
+	pool.SetDevConfig(&DevConfig{
+		UnsignedSenders: []types.Address{allowed},
+	})
+	assert.NoError(t, pool.addImpl("", txn(allowed)))
+	assert.Error(t, pool.addImpl("", txn(other)))
+
+	// any sender is allowed if the list is empty
+	pool.SetDevConfig(&DevConfig{})
+	assert.NoError(t, pool.addImpl("", txn(other)))
+}
@@ -0,0 +1,56 @@
+package txpool
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+const (
+	defaultMaxCalldataSize = 128 * 1024       // 128KB
+	defaultMaxPoolBytes    = 64 * 1024 * 1024 // 64MB
+)
+
+// Limits are the size limits of the transactions in the pool
+type Limits struct {
+	// MaxCalldataSize is the max size of the input of a transaction. No limit if 0
+	MaxCalldataSize uint64
+
+	// MaxPoolBytes is the max size of all the transactions in the pool. No limit if 0
+	MaxPoolBytes uint64
+}
+
+// DefaultLimits returns the default size limits of the pool
+func DefaultLimits() *Limits {
+	return &Limits{
+		MaxCalldataSize: defaultMaxCalldataSize,
+		MaxPoolBytes:    defaultMaxPoolBytes,
+	}
+}
+
+// SetLimits sets the size limits of the pool
+func (t *TxPool) SetLimits(limits *Limits) {
+	t.limits = *limits
+}
+
+// Bytes returns the size of all the transactions in the pool
+func (t *TxPool) Bytes() uint64 {
+	return atomic.LoadUint64(&t.queuedBytes) + t.sorted.Bytes()
+}
+
+// checkCalldataSize returns an error if the input of the transaction is too large
+func (t *TxPool) checkCalldataSize(txn *types.Transaction) error {
+	if max := t.limits.MaxCalldataSize; max != 0 && uint64(len(txn.Input)) > max {
+		return fmt.Errorf("calldata size %d exceeds the limit of %d bytes", len(txn.Input), max)
+	}
+	return nil
+}
+
+// checkPoolBytes returns an error if the pool cannot fit the given bytes
+func (t *TxPool) checkPoolBytes(size uint64) error {
+	if max := t.limits.MaxPoolBytes; max != 0 && t.Bytes()+size > max {
+		return fmt.Errorf("txpool is full (%d bytes)", max)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package txpool
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimits_CalldataSize(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.SetDevConfig(&DevConfig{})
+	pool.SetLimits(&Limits{MaxCalldataSize: 10})
+
+	txn := func(nonce uint64, size int) *types.Transaction {
+		return &types.Transaction{
+			From:     types.Address{0x1},
+			Nonce:    nonce,
+			GasPrice: big.NewInt(1),
+			Input:    make([]byte, size),
+		}
+	}
+
+	assert.NoError(t, pool.addImpl("", txn(0, 10)))
+	assert.Error(t, pool.addImpl("", txn(1, 11)))
+}
+
+func TestLimits_PoolBytes(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.SetDevConfig(&DevConfig{})
+
+	from := types.Address{0x1}
+	txn := func(nonce uint64) *types.Transaction {
+		return &types.Transaction{
+			From:     from,
+			Nonce:    nonce,
+			GasPrice: big.NewInt(1),
+			Input:    make([]byte, 100),
+		}
+	}
+	size := txn(0).Size()
+
+	// fits two txns
+	pool.SetLimits(&Limits{MaxPoolBytes: 2 * size})
+
+	// one queued and one promoted txn
+	assert.NoError(t, pool.addImpl("", txn(2)))
+	assert.NoError(t, pool.addImpl("", txn(0)))
+	assert.Equal(t, 2*size, pool.Bytes())
+	assert.Equal(t, size, pool.sorted.Bytes())
+
+	assert.Error(t, pool.addImpl("", txn(1)))
+
+	// the pool has room again once a txn is popped
+	session := pool.NewPopSession()
+	assert.NotNil(t, session.Pop(1000))
+	assert.Equal(t, size, pool.Bytes())
+
+	// the nonce gap is filled and the queued txn promoted
+	assert.NoError(t, pool.addImpl("", txn(1)))
+	assert.Equal(t, 2*size, pool.Bytes())
+	assert.Equal(t, uint64(0), pool.queuedLength())
+	assert.Equal(t, 2*size, pool.sorted.Bytes())
+}
+
+func TestLimits_PoolBytesReplacement(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.SetDevConfig(&DevConfig{})
+
+	from := types.Address{0x1}
+	txn := func(nonce uint64, price int64, size int) *types.Transaction {
+		return &types.Transaction{
+			From:     from,
+			Nonce:    nonce,
+			GasPrice: big.NewInt(price),
+			Input:    make([]byte, size),
+		}
+	}
+
+	// fits the queued txn and half of another one
+	size := txn(1, 1, 100).Size()
+	pool.SetLimits(&Limits{MaxPoolBytes: size + 50})
+
+	assert.NoError(t, pool.addImpl("", txn(1, 1, 100)))
+	assert.Equal(t, size, pool.Bytes())
+
+	// the replacement only adds the difference of the sizes
+	assert.NoError(t, pool.addImpl("", txn(1, 2, 120)))
+	assert.Equal(t, txn(1, 2, 120).Size(), pool.Bytes())
+
+	// but a replacement larger than the room left does not fit
+	assert.Error(t, pool.addImpl("", txn(1, 3, 200)))
+}
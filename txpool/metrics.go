@@ -0,0 +1,145 @@
+package txpool
+
+import (
+	"sync"
+	"time"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "txpool"
+
+// Reasons for which a transaction fails the validation
+const (
+	reasonDecode       = "decode"
+	reasonInvalid      = "invalid"
+	reasonSender       = "invalid_sender"
+	reasonUnsigned     = "unsigned"
+	reasonNotAllowlist = "not_allowlisted"
+	reasonOversized    = "oversized"
+	reasonPoolFull     = "pool_full"
+)
+
+// Metrics are the prometheus metrics of the txpool
+type Metrics struct {
+	// number of promoted txns
+	pending prometheus.GaugeFunc
+
+	// number of txns waiting to be promoted
+	queued prometheus.GaugeFunc
+
+	// size of all the txns in the pool
+	bytes prometheus.GaugeFunc
+
+	// number of pool events by type
+	events *prometheus.CounterVec
+
+	// number of txns rejected by reason
+	validationFailures *prometheus.CounterVec
+
+	// time between a txn being added and promoted
+	promotionLatency prometheus.Histogram
+
+	// number of gossiped txns that were already in the pool
+	gossipDuplicates prometheus.Counter
+
+	// time at which the txns waiting to be promoted were added
+	lock    sync.Mutex
+	addedAt map[types.Hash]time.Time
+}
+
+func newMetrics(t *TxPool) *Metrics {
+	return &Metrics{
+		pending: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "pending_transactions",
+			Help:      "Number of transactions ready to be included in a block",
+		}, func() float64 {
+			return float64(t.Length())
+		}),
+		queued: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "queued_transactions",
+			Help:      "Number of transactions waiting for a nonce gap to be filled",
+		}, func() float64 {
+			return float64(t.queuedLength())
+		}),
+		bytes: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "size_bytes",
+			Help:      "Size of all the transactions in the pool",
+		}, func() float64 {
+			return float64(t.Bytes())
+		}),
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "events_total",
+			Help:      "Number of transaction events (added, promoted, dropped, replaced, included)",
+		}, []string{"type"}),
+		validationFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "validation_failures_total",
+			Help:      "Number of transactions rejected by the pool by reason",
+		}, []string{"reason"}),
+		promotionLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "promotion_latency_seconds",
+			Help:      "Time between a transaction being added and promoted",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+		}),
+		gossipDuplicates: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "gossip_duplicates_total",
+			Help:      "Number of gossiped transactions that were already in the pool",
+		}),
+		addedAt: map[types.Hash]time.Time{},
+	}
+}
+
+// RegisterMetrics registers the metrics of the pool in the prometheus registerer
+func (t *TxPool) RegisterMetrics(reg prometheus.Registerer) error {
+	m := t.metrics
+	collectors := []prometheus.Collector{
+		m.pending,
+		m.queued,
+		m.bytes,
+		m.events,
+		m.validationFailures,
+		m.promotionLatency,
+		m.gossipDuplicates,
+	}
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trackEvent counts the event and measures the promotion latency of the txn
+func (m *Metrics) trackEvent(typ EventType, txn *types.Transaction) {
+	m.events.WithLabelValues(typ.String()).Inc()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	switch typ {
+	case EventAdded:
+		m.addedAt[txn.Hash] = time.Now()
+
+	case EventPromoted:
+		if addedAt, ok := m.addedAt[txn.Hash]; ok {
+			m.promotionLatency.Observe(time.Since(addedAt).Seconds())
+			delete(m.addedAt, txn.Hash)
+		}
+
+	default:
+		// the txn left the pool
+		delete(m.addedAt, txn.Hash)
+	}
+}
+
+func (m *Metrics) validationFailed(reason string) {
+	m.validationFailures.WithLabelValues(reason).Inc()
+}
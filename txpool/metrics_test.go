@@ -0,0 +1,99 @@
+package txpool
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/crypto"
+	"github.com/0xPolygon/minimal/txpool/proto"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/hashicorp/go-hclog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_Events(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.SetDevConfig(&DevConfig{})
+
+	reg := prometheus.NewRegistry()
+	assert.NoError(t, pool.RegisterMetrics(reg))
+
+	from := types.Address{0x1}
+	txn := func(nonce uint64, price int64) *types.Transaction {
+		return &types.Transaction{
+			From:     from,
+			Nonce:    nonce,
+			GasPrice: big.NewInt(price),
+		}
+	}
+
+	// nonce 1 is queued and replaced by a better priced txn
+	assert.NoError(t, pool.addImpl("", txn(1, 1)))
+	assert.NoError(t, pool.addImpl("", txn(1, 2)))
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(pool.metrics.queued))
+	assert.Equal(t, float64(0), testutil.ToFloat64(pool.metrics.pending))
+
+	// nonce 0 promotes both txns
+	assert.NoError(t, pool.addImpl("", txn(0, 1)))
+
+	assert.Equal(t, float64(0), testutil.ToFloat64(pool.metrics.queued))
+	assert.Equal(t, float64(2), testutil.ToFloat64(pool.metrics.pending))
+
+	events := pool.metrics.events
+	assert.Equal(t, float64(3), testutil.ToFloat64(events.WithLabelValues("added")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(events.WithLabelValues("replaced")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(events.WithLabelValues("promoted")))
+
+	// the promotion latency of both txns was measured
+	assert.Len(t, pool.metrics.addedAt, 0)
+}
+
+func TestMetrics_ValidationFailures(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+
+	// txns with a sender are only accepted in dev mode
+	assert.Error(t, pool.addImpl("", &types.Transaction{
+		From:     types.Address{0x1},
+		GasPrice: big.NewInt(1),
+	}))
+
+	failures := pool.metrics.validationFailures
+	assert.Equal(t, float64(1), testutil.ToFloat64(failures.WithLabelValues(reasonUnsigned)))
+}
+
+func TestMetrics_GossipDuplicates(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), true, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+
+	signer := crypto.NewEIP155Signer(100)
+	pool.AddSigner(signer)
+
+	key, _ := crypto.GenerateKey()
+	txn, err := signer.SignTx(&types.Transaction{
+		Value:    big.NewInt(1),
+		GasPrice: big.NewInt(1),
+	}, key)
+	assert.NoError(t, err)
+
+	msg := &proto.Txn{
+		Raw: &any.Any{
+			Value: txn.MarshalRLP(),
+		},
+	}
+	pool.handleGossipTxn(msg)
+	pool.handleGossipTxn(msg)
+
+	assert.Equal(t, uint64(1), pool.Length())
+	assert.Equal(t, float64(1), testutil.ToFloat64(pool.metrics.gossipDuplicates))
+
+	// the duplicate does not emit any event
+	events := pool.metrics.events
+	assert.Equal(t, float64(1), testutil.ToFloat64(events.WithLabelValues("added")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(events.WithLabelValues("dropped")))
+}
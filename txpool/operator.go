@@ -2,6 +2,8 @@ package txpool
 
 import (
 	"context"
+	"fmt"
+	"io"
 
 	"github.com/0xPolygon/minimal/txpool/proto"
 	"github.com/0xPolygon/minimal/types"
@@ -11,7 +13,10 @@ import (
 // Status implements the GRPC status endpoint. Returns the number of transactions in the pool
 func (t *TxPool) Status(ctx context.Context, req *empty.Empty) (*proto.TxnPoolStatusResp, error) {
 	resp := &proto.TxnPoolStatusResp{
-		Length: t.sorted.Length(),
+		Length:   t.sorted.Length(),
+		Queued:   t.queuedLength(),
+		Accounts: t.accountsLength(),
+		Bytes:    t.Bytes(),
 	}
 
 	return resp, nil
@@ -19,6 +24,77 @@ func (t *TxPool) Status(ctx context.Context, req *empty.Empty) (*proto.TxnPoolSt
 
 // AddTxn adds a local transaction to the pool
 func (t *TxPool) AddTxn(ctx context.Context, raw *proto.AddTxnReq) (*empty.Empty, error) {
+	txn, err := decodeAddTxnReq(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.AddTx(txn); err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+// AddTxnStream adds a stream of local transactions to the pool. A transaction that
+// fails does not stop the stream, its error is returned in the result instead
+func (t *TxPool) AddTxnStream(stream proto.TxnPoolOperator_AddTxnStreamServer) error {
+	resp := &proto.AddTxnStreamResp{}
+	for {
+		raw, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(resp)
+		}
+		if err != nil {
+			return err
+		}
+
+		result := &proto.AddTxnResult{}
+		if txn, err := decodeAddTxnReq(raw); err != nil {
+			result.Error = err.Error()
+		} else {
+			if err := t.AddTx(txn); err != nil {
+				result.Error = err.Error()
+			}
+			result.Hash = txn.Hash.String()
+		}
+		resp.Results = append(resp.Results, result)
+	}
+}
+
+// GetAccount returns the pending and queued transactions of an account
+func (t *TxPool) GetAccount(ctx context.Context, req *proto.GetAccountReq) (*proto.GetAccountResp, error) {
+	addr := types.Address{}
+	if err := addr.UnmarshalText([]byte(req.Address)); err != nil {
+		return nil, err
+	}
+
+	resp := &proto.GetAccountResp{
+		Pending: []*proto.TxnInfo{},
+		Queued:  []*proto.TxnInfo{},
+	}
+	shard := t.accounts.shard(addr)
+	shard.lock.Lock()
+	if txnsQueue, ok := shard.queue[addr]; ok {
+		resp.NextNonce = txnsQueue.nextNonce
+		for _, txn := range txnsQueue.txs {
+			resp.Queued = append(resp.Queued, toTxnInfo(txn))
+		}
+	}
+	shard.lock.Unlock()
+
+	for _, txn := range t.sorted.Txns(addr) {
+		resp.Pending = append(resp.Pending, toTxnInfo(txn))
+	}
+
+	return resp, nil
+}
+
+func decodeAddTxnReq(raw *proto.AddTxnReq) (*types.Transaction, error) {
+	if raw.Raw == nil {
+		return nil, fmt.Errorf("raw transaction not found")
+	}
+
 	txn := new(types.Transaction)
 	if err := txn.UnmarshalRLP(raw.Raw.Value); err != nil {
 		return nil, err
@@ -31,16 +107,47 @@ func (t *TxPool) AddTxn(ctx context.Context, raw *proto.AddTxnReq) (*empty.Empty
 		}
 		txn.From = from
 	}
+	return txn, nil
+}
 
-	if err := t.AddTx(txn); err != nil {
-		return nil, err
+func toTxnInfo(txn *types.Transaction) *proto.TxnInfo {
+	return &proto.TxnInfo{
+		Hash:     txn.Hash.String(),
+		Nonce:    txn.Nonce,
+		GasPrice: txn.GasPrice.String(),
 	}
-
-	return &empty.Empty{}, nil
 }
 
 // Subscribe implements the operator endpoint. It subscribes to new events in the tx pool
-func (t *TxPool) Subscribe(req *empty.Empty, stream proto.TxnPoolOperator_SubscribeServer) error {
-	// TODO
-	return nil
-}
\ No newline at end of file
+func (t *TxPool) Subscribe(req *proto.SubscribeRequest, stream proto.TxnPoolOperator_SubscribeServer) error {
+	eventTypes := []EventType{}
+	for _, typ := range req.Types {
+		eventTypes = append(eventTypes, EventType(typ))
+	}
+
+	sub := t.SubscribeTxnEvents(eventTypes...)
+	defer sub.Close()
+
+	// close the subscription once the client goes away
+	go func() {
+		<-stream.Context().Done()
+		sub.Close()
+	}()
+
+	for {
+		evnt := sub.GetEvent()
+		if evnt == nil {
+			return nil
+		}
+
+		pEvent := &proto.TxPoolEvent{
+			// the proto event types have the same values as the txpool ones
+			Type:   proto.EventType(evnt.Type),
+			TxHash: evnt.Hash.String(),
+			From:   evnt.From.String(),
+		}
+		if err := stream.Send(pEvent); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package txpool
+
+import (
+	"context"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/txpool/proto"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAddTxnStream struct {
+	proto.TxnPoolOperator_AddTxnStreamServer
+
+	reqs []*proto.AddTxnReq
+	resp *proto.AddTxnStreamResp
+}
+
+func (m *mockAddTxnStream) Recv() (*proto.AddTxnReq, error) {
+	if len(m.reqs) == 0 {
+		return nil, io.EOF
+	}
+	req := m.reqs[0]
+	m.reqs = m.reqs[1:]
+	return req, nil
+}
+
+func (m *mockAddTxnStream) SendAndClose(resp *proto.AddTxnStreamResp) error {
+	m.resp = resp
+	return nil
+}
+
+func TestOperator_AddTxnStream(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.SetDevConfig(&DevConfig{})
+
+	from := types.Address{0x1}
+	addTxnReq := func(nonce uint64) *proto.AddTxnReq {
+		txn := &types.Transaction{
+			Nonce:    nonce,
+			Value:    big.NewInt(1),
+			GasPrice: big.NewInt(1),
+			V:        1,
+		}
+		return &proto.AddTxnReq{
+			Raw: &any.Any{
+				Value: txn.MarshalRLP(),
+			},
+			From: from.String(),
+		}
+	}
+
+	stream := &mockAddTxnStream{
+		reqs: []*proto.AddTxnReq{
+			addTxnReq(0),
+			{Raw: &any.Any{Value: []byte{0x1}}},
+			addTxnReq(2),
+		},
+	}
+	assert.NoError(t, pool.AddTxnStream(stream))
+
+	// the invalid txn does not stop the stream
+	assert.Len(t, stream.resp.Results, 3)
+	assert.Empty(t, stream.resp.Results[0].Error)
+	assert.NotEmpty(t, stream.resp.Results[1].Error)
+	assert.Empty(t, stream.resp.Results[2].Error)
+
+	status, err := pool.Status(context.Background(), &empty.Empty{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), status.Length)
+	assert.Equal(t, uint64(1), status.Queued)
+	assert.Equal(t, uint64(1), status.Accounts)
+
+	account, err := pool.GetAccount(context.Background(), &proto.GetAccountReq{Address: from.String()})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), account.NextNonce)
+	assert.Len(t, account.Pending, 1)
+	assert.Equal(t, uint64(0), account.Pending[0].Nonce)
+	assert.Len(t, account.Queued, 1)
+	assert.Equal(t, uint64(2), account.Queued[0].Nonce)
+	assert.Equal(t, stream.resp.Results[2].Hash, account.Queued[0].Hash)
+}
@@ -23,8 +23,12 @@ type TxnPoolOperatorClient interface {
 	Status(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*TxnPoolStatusResp, error)
 	// AddTxn adds a local transaction to the pool
 	AddTxn(ctx context.Context, in *AddTxnReq, opts ...grpc.CallOption) (*empty.Empty, error)
+	// AddTxnStream adds a stream of local transactions to the pool
+	AddTxnStream(ctx context.Context, opts ...grpc.CallOption) (TxnPoolOperator_AddTxnStreamClient, error)
+	// GetAccount returns the transactions in the pool for an account
+	GetAccount(ctx context.Context, in *GetAccountReq, opts ...grpc.CallOption) (*GetAccountResp, error)
 	// Subscribe subscribes for new events in the txpool
-	Subscribe(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (TxnPoolOperator_SubscribeClient, error)
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (TxnPoolOperator_SubscribeClient, error)
 }
 
 type txnPoolOperatorClient struct {
@@ -53,8 +57,51 @@ func (c *txnPoolOperatorClient) AddTxn(ctx context.Context, in *AddTxnReq, opts
 	return out, nil
 }
 
-func (c *txnPoolOperatorClient) Subscribe(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (TxnPoolOperator_SubscribeClient, error) {
-	stream, err := c.cc.NewStream(ctx, &TxnPoolOperator_ServiceDesc.Streams[0], "/v1.TxnPoolOperator/Subscribe", opts...)
+func (c *txnPoolOperatorClient) AddTxnStream(ctx context.Context, opts ...grpc.CallOption) (TxnPoolOperator_AddTxnStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &TxnPoolOperator_ServiceDesc.Streams[0], "/v1.TxnPoolOperator/AddTxnStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &txnPoolOperatorAddTxnStreamClient{stream}
+	return x, nil
+}
+
+type TxnPoolOperator_AddTxnStreamClient interface {
+	Send(*AddTxnReq) error
+	CloseAndRecv() (*AddTxnStreamResp, error)
+	grpc.ClientStream
+}
+
+type txnPoolOperatorAddTxnStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *txnPoolOperatorAddTxnStreamClient) Send(m *AddTxnReq) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *txnPoolOperatorAddTxnStreamClient) CloseAndRecv() (*AddTxnStreamResp, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(AddTxnStreamResp)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *txnPoolOperatorClient) GetAccount(ctx context.Context, in *GetAccountReq, opts ...grpc.CallOption) (*GetAccountResp, error) {
+	out := new(GetAccountResp)
+	err := c.cc.Invoke(ctx, "/v1.TxnPoolOperator/GetAccount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *txnPoolOperatorClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (TxnPoolOperator_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &TxnPoolOperator_ServiceDesc.Streams[1], "/v1.TxnPoolOperator/Subscribe", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -93,8 +140,12 @@ type TxnPoolOperatorServer interface {
 	Status(context.Context, *empty.Empty) (*TxnPoolStatusResp, error)
 	// AddTxn adds a local transaction to the pool
 	AddTxn(context.Context, *AddTxnReq) (*empty.Empty, error)
+	// AddTxnStream adds a stream of local transactions to the pool
+	AddTxnStream(TxnPoolOperator_AddTxnStreamServer) error
+	// GetAccount returns the transactions in the pool for an account
+	GetAccount(context.Context, *GetAccountReq) (*GetAccountResp, error)
 	// Subscribe subscribes for new events in the txpool
-	Subscribe(*empty.Empty, TxnPoolOperator_SubscribeServer) error
+	Subscribe(*SubscribeRequest, TxnPoolOperator_SubscribeServer) error
 	mustEmbedUnimplementedTxnPoolOperatorServer()
 }
 
@@ -108,7 +159,13 @@ func (UnimplementedTxnPoolOperatorServer) Status(context.Context, *empty.Empty)
 func (UnimplementedTxnPoolOperatorServer) AddTxn(context.Context, *AddTxnReq) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddTxn not implemented")
 }
-func (UnimplementedTxnPoolOperatorServer) Subscribe(*empty.Empty, TxnPoolOperator_SubscribeServer) error {
+func (UnimplementedTxnPoolOperatorServer) AddTxnStream(TxnPoolOperator_AddTxnStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method AddTxnStream not implemented")
+}
+func (UnimplementedTxnPoolOperatorServer) GetAccount(context.Context, *GetAccountReq) (*GetAccountResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccount not implemented")
+}
+func (UnimplementedTxnPoolOperatorServer) Subscribe(*SubscribeRequest, TxnPoolOperator_SubscribeServer) error {
 	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
 }
 func (UnimplementedTxnPoolOperatorServer) mustEmbedUnimplementedTxnPoolOperatorServer() {}
@@ -160,8 +217,52 @@ func _TxnPoolOperator_AddTxn_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TxnPoolOperator_AddTxnStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TxnPoolOperatorServer).AddTxnStream(&txnPoolOperatorAddTxnStreamServer{stream})
+}
+
+type TxnPoolOperator_AddTxnStreamServer interface {
+	SendAndClose(*AddTxnStreamResp) error
+	Recv() (*AddTxnReq, error)
+	grpc.ServerStream
+}
+
+type txnPoolOperatorAddTxnStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *txnPoolOperatorAddTxnStreamServer) SendAndClose(m *AddTxnStreamResp) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *txnPoolOperatorAddTxnStreamServer) Recv() (*AddTxnReq, error) {
+	m := new(AddTxnReq)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _TxnPoolOperator_GetAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAccountReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TxnPoolOperatorServer).GetAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.TxnPoolOperator/GetAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TxnPoolOperatorServer).GetAccount(ctx, req.(*GetAccountReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TxnPoolOperator_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(empty.Empty)
+	m := new(SubscribeRequest)
 	if err := stream.RecvMsg(m); err != nil {
 		return err
 	}
@@ -196,8 +297,17 @@ var TxnPoolOperator_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AddTxn",
 			Handler:    _TxnPoolOperator_AddTxn_Handler,
 		},
+		{
+			MethodName: "GetAccount",
+			Handler:    _TxnPoolOperator_GetAccount_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "AddTxnStream",
+			Handler:       _TxnPoolOperator_AddTxnStream_Handler,
+			ClientStreams: true,
+		},
 		{
 			StreamName:    "Subscribe",
 			Handler:       _TxnPoolOperator_Subscribe_Handler,
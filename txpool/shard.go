@@ -0,0 +1,71 @@
+package txpool
+
+import (
+	"sync"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// numShards is the number of shards the account queues are split into
+const numShards = 32
+
+// accountShard is a set of account queues guarded by its own lock, so that
+// the transactions of accounts in different shards are handled concurrently
+type accountShard struct {
+	lock  sync.Mutex
+	queue map[types.Address]*txQueue
+}
+
+// accountShards are the account queues of the pool split by address
+type accountShards [numShards]*accountShard
+
+func newAccountShards() *accountShards {
+	shards := &accountShards{}
+	for i := range shards {
+		shards[i] = &accountShard{
+			queue: map[types.Address]*txQueue{},
+		}
+	}
+	return shards
+}
+
+// shard returns the shard of the account. Addresses are uniformly
+// distributed so the last byte is enough to pick the shard
+func (a *accountShards) shard(addr types.Address) *accountShard {
+	return a[addr[types.AddressLength-1]%numShards]
+}
+
+// get returns the queue of the account, if any
+func (a *accountShards) get(addr types.Address) (*txQueue, bool) {
+	shard := a.shard(addr)
+
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	q, ok := shard.queue[addr]
+	return q, ok
+}
+
+// queuedLength returns the number of transactions in all the account queues
+func (a *accountShards) queuedLength() uint64 {
+	count := uint64(0)
+	for _, shard := range a {
+		shard.lock.Lock()
+		for _, txnsQueue := range shard.queue {
+			count += uint64(txnsQueue.txs.Len())
+		}
+		shard.lock.Unlock()
+	}
+	return count
+}
+
+// accountsLength returns the number of accounts with a queue
+func (a *accountShards) accountsLength() uint64 {
+	count := uint64(0)
+	for _, shard := range a {
+		shard.lock.Lock()
+		count += uint64(len(shard.queue))
+		shard.lock.Unlock()
+	}
+	return count
+}
@@ -0,0 +1,89 @@
+package txpool
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountShards(t *testing.T) {
+	shards := newAccountShards()
+
+	addr0 := types.Address{0x1}
+	addr1 := types.Address{0x2}
+	addr1[types.AddressLength-1] = numShards
+
+	// both addresses end in the same shard
+	assert.Equal(t, shards.shard(addr0), shards.shard(addr1))
+
+	_, ok := shards.get(addr0)
+	assert.False(t, ok)
+
+	shard := shards.shard(addr0)
+	shard.queue[addr0] = newTxQueue()
+	shard.queue[addr1] = newTxQueue()
+	shard.queue[addr1].Push(&types.Transaction{Nonce: 1})
+
+	_, ok = shards.get(addr0)
+	assert.True(t, ok)
+
+	assert.Equal(t, uint64(2), shards.accountsLength())
+	assert.Equal(t, uint64(1), shards.queuedLength())
+}
+
+func TestTxPool_ConcurrentAdd(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.SetDevConfig(&DevConfig{})
+
+	numAccounts, numTxns := 64, 10
+
+	var wg sync.WaitGroup
+	for i := 0; i < numAccounts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			from := types.Address{byte(i + 1)}
+			from[types.AddressLength-1] = byte(i)
+
+			// unsigned txns of different accounts need a different
+			// value so that their hashes do not collide
+			for nonce := 0; nonce < numTxns; nonce++ {
+				assert.NoError(t, pool.addImpl("", &types.Transaction{
+					From:     from,
+					Nonce:    uint64(nonce),
+					GasPrice: big.NewInt(int64(nonce + 1)),
+					Value:    big.NewInt(int64(i)),
+				}))
+			}
+		}(i)
+	}
+
+	// pop txns while they are being added
+	popped := 0
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	session := pool.NewPopSession()
+	for {
+		select {
+		case <-done:
+			session.Close()
+			assert.Equal(t, uint64(numAccounts*numTxns-popped), pool.Length())
+			assert.Equal(t, uint64(0), pool.queuedLength())
+			return
+		default:
+		}
+		if txn := session.Pop(1000); txn != nil {
+			popped++
+		}
+	}
+}
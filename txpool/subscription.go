@@ -0,0 +1,228 @@
+package txpool
+
+import (
+	"sync"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// EventType is the type of the txpool event
+type EventType int
+
+const (
+	EventAdded    EventType = iota // Transaction added to the account queue
+	EventPromoted                  // Transaction promoted to the executable set
+	EventDropped                   // Transaction removed from the pool without being included
+	EventReplaced                  // Transaction replaced by another one with the same nonce
+	EventIncluded                  // Transaction included in a canonical block
+)
+
+func (e EventType) String() string {
+	switch e {
+	case EventAdded:
+		return "added"
+	case EventPromoted:
+		return "promoted"
+	case EventDropped:
+		return "dropped"
+	case EventReplaced:
+		return "replaced"
+	case EventIncluded:
+		return "included"
+	default:
+		panic("BUG: txpool event type not found")
+	}
+}
+
+// Event is the txpool event that gets passed to the listeners
+type Event struct {
+	// Type is the type of event
+	Type EventType
+
+	// Hash is the hash of the transaction
+	Hash types.Hash
+
+	// From is the sender of the transaction
+	From types.Address
+}
+
+// Subscription is the txpool event subscription object
+type Subscription struct {
+	updateCh chan struct{} // Channel for update information
+	closeCh  chan struct{} // Channel for close signals
+	elem     *eventElem    // Reference to the txpool event wrapper
+	stream   *eventStream  // Reference to the stream of events
+
+	// filter of event types, if empty every event is returned
+	types map[EventType]struct{}
+
+	closeOnce sync.Once
+}
+
+// GetEventCh creates a new event channel, and returns it. The channel
+// is closed once the subscription is closed
+func (s *Subscription) GetEventCh() chan *Event {
+	eventCh := make(chan *Event)
+	go func() {
+		defer close(eventCh)
+
+		for {
+			evnt := s.GetEvent()
+			if evnt == nil {
+				return
+			}
+
+			select {
+			case eventCh <- evnt:
+			case <-s.closeCh:
+				return
+			}
+		}
+	}()
+
+	return eventCh
+}
+
+// GetEvent returns the next event from the subscription (BLOCKING).
+// It returns nil if the subscription is closed
+func (s *Subscription) GetEvent() *Event {
+	for {
+		if next := s.stream.next(s.elem); next != nil {
+			s.elem = next
+			if !s.match(next.event) {
+				continue
+			}
+
+			return next.event
+		}
+
+		// Wait for an update
+		select {
+		case <-s.updateCh:
+			continue
+		case <-s.closeCh:
+			return nil
+		}
+	}
+}
+
+func (s *Subscription) match(evnt *Event) bool {
+	if len(s.types) == 0 {
+		return true
+	}
+	_, ok := s.types[evnt.Type]
+	return ok
+}
+
+// Close closes the subscription
+func (s *Subscription) Close() {
+	s.closeOnce.Do(func() {
+		s.stream.unsubscribe(s.updateCh)
+		close(s.closeCh)
+	})
+}
+
+// SubscribeTxnEvents returns a subscription to the events of the pool.
+// If any event types are specified, only those are returned
+func (t *TxPool) SubscribeTxnEvents(eventTypes ...EventType) *Subscription {
+	sub := t.stream.subscribe()
+	if len(eventTypes) != 0 {
+		sub.types = map[EventType]struct{}{}
+		for _, typ := range eventTypes {
+			sub.types[typ] = struct{}{}
+		}
+	}
+	return sub
+}
+
+// emitEvent pushes a new event about the transaction to the stream
+func (t *TxPool) emitEvent(typ EventType, txn *types.Transaction) {
+	t.metrics.trackEvent(typ, txn)
+
+	t.stream.push(&Event{
+		Type: typ,
+		Hash: txn.Hash,
+		From: txn.From,
+	})
+}
+
+// eventElem contains the event, as well as the next list event
+type eventElem struct {
+	event *Event
+	next  *eventElem
+}
+
+// eventStream is the structure that contains the event list,
+// as well as the update channels which it uses to notify of updates
+type eventStream struct {
+	lock sync.Mutex
+	head *eventElem
+
+	// channel to notify updates
+	updateCh []chan struct{}
+}
+
+func newEventStream() *eventStream {
+	return &eventStream{
+		head: &eventElem{},
+	}
+}
+
+// subscribe creates a new txpool event subscription
+func (e *eventStream) subscribe() *Subscription {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	// the update channel is buffered so that a notification sent
+	// while the subscriber is busy is not lost
+	updateCh := make(chan struct{}, 1)
+	e.updateCh = append(e.updateCh, updateCh)
+
+	return &Subscription{
+		elem:     e.head,
+		updateCh: updateCh,
+		closeCh:  make(chan struct{}),
+		stream:   e,
+	}
+}
+
+// unsubscribe stops notifying the update channel of a subscription
+func (e *eventStream) unsubscribe(updateCh chan struct{}) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	for i, ch := range e.updateCh {
+		if ch == updateCh {
+			e.updateCh = append(e.updateCh[:i], e.updateCh[i+1:]...)
+			break
+		}
+	}
+}
+
+// next returns the element that follows elem in the list, if any
+func (e *eventStream) next(elem *eventElem) *eventElem {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	return elem.next
+}
+
+// push adds a new Event, and notifies listeners
+func (e *eventStream) push(event *Event) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	newHead := &eventElem{
+		event: event,
+	}
+	e.head.next = newHead
+	e.head = newHead
+
+	// Notify the listeners
+	for _, update := range e.updateCh {
+		select {
+		case update <- struct{}{}:
+		default:
+		}
+	}
+}
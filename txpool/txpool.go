@@ -6,9 +6,11 @@ import (
 	"math/big"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/0xPolygon/minimal/blockchain"
+	"github.com/0xPolygon/minimal/chain"
 	"github.com/0xPolygon/minimal/network"
 	"github.com/0xPolygon/minimal/txpool/proto"
 	"github.com/0xPolygon/minimal/types"
@@ -25,6 +27,8 @@ type store interface {
 	Header() *types.Header
 	GetNonce(root types.Hash, addr types.Address) uint64
 	GetBlockByHash(types.Hash, bool) (*types.Block, bool)
+	GetStorage(root types.Hash, addr types.Address, slot types.Hash) []byte
+	SubscribeEvents() blockchain.Subscription
 }
 
 type signer interface {
@@ -33,24 +37,53 @@ type signer interface {
 
 // TxPool is a pool of transactions
 type TxPool struct {
+	// size of the txns in the account queues, accessed atomically.
+	// It is the first field to keep it 64-bit aligned
+	queuedBytes uint64
+
 	logger hclog.Logger
 	signer signer
 
 	store      store
 	idlePeriod time.Duration
 
-	// unsorted list of transactions per account
-	queue map[types.Address]*txQueue
+	// unsorted list of transactions per account, split in shards
+	accounts *accountShards
 
 	// sorted list of current valid transactions
 	sorted *txPriceHeap
 
+	// txns handed out to the block builders, tracked until
+	// they are either included in a block or dropped
+	popped     map[types.Hash]*types.Transaction
+	poppedLock sync.Mutex
+
 	// network stack
 	network *network.Server
 	topic   *network.Topic
 
+	// stream of pool events
+	stream *eventStream
+
+	// allowed senders and contracts, nil if every txn is allowed
+	allowlist *allowlist
+
+	// subscription to the blockchain head events
+	subscription blockchain.Subscription
+
+	// prometheus metrics of the pool
+	metrics *Metrics
+
+	// dev mode settings, nil if the unsigned txns are rejected
+	dev *devMode
+
+	// size limits of the txns
+	limits Limits
+
+	// forks of the chain, nil if every txn type is accepted
+	forks *chain.Forks
+
 	sealing  bool
-	dev      bool
 	NotifyCh chan struct{}
 
 	proto.UnimplementedTxnPoolOperatorServer
@@ -62,11 +95,14 @@ func NewTxPool(logger hclog.Logger, sealing bool, store store, grpcServer *grpc.
 		logger:     logger.Named("txpool"),
 		store:      store,
 		idlePeriod: defaultIdlePeriod,
-		queue:      make(map[types.Address]*txQueue, 0),
+		accounts:   newAccountShards(),
 		network:    network,
 		sorted:     newTxPriceHeap(),
+		popped:     map[types.Hash]*types.Transaction{},
+		stream:     newEventStream(),
 		sealing:    sealing,
 	}
+	txPool.metrics = newMetrics(txPool)
 
 	if network != nil {
 		// subscribe to the gossip protocol
@@ -84,8 +120,37 @@ func NewTxPool(logger hclog.Logger, sealing bool, store store, grpcServer *grpc.
 	return txPool, nil
 }
 
+// Start starts the watcher of the blockchain head events, which prunes
+// the pool with every new canonical block
+func (t *TxPool) Start() {
+	t.subscription = t.store.SubscribeEvents()
+
+	go func() {
+		for {
+			evnt := t.subscription.GetEvent()
+			if evnt == nil {
+				// the subscription is closed
+				return
+			}
+			t.ProcessEvent(evnt)
+		}
+	}()
+}
+
+// Close stops the watcher of the blockchain head events
+func (t *TxPool) Close() {
+	if t.subscription != nil {
+		t.subscription.Close()
+	}
+}
+
 func (t *TxPool) GetNonce(addr types.Address) (uint64, bool) {
-	q, ok := t.queue[addr]
+	shard := t.accounts.shard(addr)
+
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	q, ok := shard.queue[addr]
 	if !ok {
 		return 0, false
 	}
@@ -97,6 +162,11 @@ func (t *TxPool) AddSigner(s signer) {
 	t.signer = s
 }
 
+// SetForks sets the forks of the chain, which decide the txn types accepted by the pool
+func (t *TxPool) SetForks(forks *chain.Forks) {
+	t.forks = forks
+}
+
 var topicNameV1 = "txpool/0.1"
 
 func (t *TxPool) handleGossipTxn(obj interface{}) {
@@ -107,6 +177,7 @@ func (t *TxPool) handleGossipTxn(obj interface{}) {
 	raw := obj.(*proto.Txn)
 	txn := new(types.Transaction)
 	if err := txn.UnmarshalRLP(raw.Raw.Value); err != nil {
+		t.metrics.validationFailed(reasonDecode)
 		t.logger.Error("failed to decode broadcasted txn", "err", err)
 	} else {
 		if err := t.addImpl("gossip", txn); err != nil {
@@ -115,10 +186,6 @@ func (t *TxPool) handleGossipTxn(obj interface{}) {
 	}
 }
 
-func (t *TxPool) EnableDev() {
-	t.dev = true
-}
-
 // AddTx adds a new transaction to the pool
 func (t *TxPool) AddTx(tx *types.Transaction) error {
 	if err := t.addImpl("addTxn", tx); err != nil {
@@ -127,7 +194,7 @@ func (t *TxPool) AddTx(tx *types.Transaction) error {
 
 	// broadcast the transaction only if network is enabled
 	// and we are not in dev mode
-	if t.topic != nil && !t.dev {
+	if t.topic != nil && t.dev == nil {
 		txn := &proto.Txn{
 			Raw: &any.Any{
 				Value: tx.MarshalRLP(),
@@ -158,60 +225,216 @@ func (t *TxPool) addImpl(ctx string, txns ...*types.Transaction) error {
 		// to the promoted queue and pending queue we use this point to calculate the hash
 		txn.ComputeHash()
 
+		if err := t.checkCalldataSize(txn); err != nil {
+			t.metrics.validationFailed(reasonOversized)
+			return err
+		}
+
 		err := t.validateTx(txn)
 		if err != nil {
+			t.metrics.validationFailed(reasonInvalid)
 			return err
 		}
 
 		if txn.From == types.ZeroAddress {
 			txn.From, err = t.signer.Sender(txn)
 			if err != nil {
+				t.metrics.validationFailed(reasonSender)
 				return fmt.Errorf("invalid sender")
 			}
 			from = txn.From
 		} else {
 			// only if we are in dev mode we can accept
 			// a transaction without validation
-			if !t.dev {
-				return fmt.Errorf("cannot accept non-encrypted txn")
+			if err := t.checkUnsigned(txn); err != nil {
+				t.metrics.validationFailed(reasonUnsigned)
+				return err
 			}
 		}
 
+		if err := t.checkAllowlist(txn); err != nil {
+			t.metrics.validationFailed(reasonNotAllowlist)
+			return err
+		}
+
 		t.logger.Debug("add txn", "ctx", ctx, "hash", txn.Hash, "from", from)
 	}
 
-	txnsQueue, ok := t.queue[from]
+	shard := t.accounts.shard(from)
+
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	txnsQueue, ok := shard.queue[from]
 	if !ok {
 		stateRoot := t.store.Header().StateRoot
 
 		// initialize the txn queue for the account
 		txnsQueue = newTxQueue()
 		txnsQueue.nextNonce = t.store.GetNonce(stateRoot, from)
-		t.queue[from] = txnsQueue
 	}
+
+	// the size of the pool is checked with the account locked, so that the
+	// size added by the txns does not change until they are in the queue
+	size := uint64(0)
 	for _, txn := range txns {
-		txnsQueue.Add(txn)
+		if !t.sorted.Contains(txn) {
+			size += txnsQueue.addedBytes(txn)
+		}
 	}
+	if err := t.checkPoolBytes(size); err != nil {
+		t.metrics.validationFailed(reasonPoolFull)
+		return err
+	}
+	if !ok {
+		shard.queue[from] = txnsQueue
+	}
+	defer t.trackQueuedBytes(txnsQueue)()
 
-	for _, promoted := range txnsQueue.Promote() {
-		t.sorted.Push(promoted)
+	for _, txn := range txns {
+		if t.sorted.Contains(txn) || txnsQueue.Contains(txn) {
+			// the txn is already in the pool
+			if ctx == "gossip" {
+				t.metrics.gossipDuplicates.Inc()
+			}
+			continue
+		}
+		replaced, ok := txnsQueue.Add(txn)
+		if !ok {
+			continue
+		}
+		if replaced != nil {
+			t.emitEvent(EventReplaced, replaced)
+		}
+		t.emitEvent(EventAdded, txn)
+	}
+
+	promoted, dropped := txnsQueue.Promote()
+	for _, txn := range dropped {
+		t.emitEvent(EventDropped, txn)
+	}
+	for _, txn := range promoted {
+		t.sorted.Push(txn)
+		t.emitEvent(EventPromoted, txn)
 	}
 	return nil
 }
 
+// queuedLength returns the number of transactions waiting to be promoted
+func (t *TxPool) queuedLength() uint64 {
+	return t.accounts.queuedLength()
+}
+
+// accountsLength returns the number of accounts with transactions in the pool
+func (t *TxPool) accountsLength() uint64 {
+	return t.accounts.accountsLength()
+}
+
 func (t *TxPool) Length() uint64 {
 	return t.sorted.Length()
 }
 
-func (t *TxPool) Pop() (*types.Transaction, func()) {
-	txn := t.sorted.Pop()
-	if txn == nil {
-		return nil, nil
+// GetPendingGasPrices returns the gas prices of the promoted transactions
+func (t *TxPool) GetPendingGasPrices() []*big.Int {
+	return t.sorted.Prices()
+}
+
+// PopSession hands out the transactions of the pool to a block builder. The
+// transactions set aside during the session are returned to the pool on Close
+type PopSession struct {
+	pool *TxPool
+
+	// transactions popped from the pool but not included
+	skipped []*types.Transaction
+
+	// accounts whose transactions cannot be included anymore
+	blocked map[types.Address]struct{}
+}
+
+// NewPopSession starts a new session to consume the transactions of the pool
+func (t *TxPool) NewPopSession() *PopSession {
+	return &PopSession{
+		pool:    t,
+		skipped: []*types.Transaction{},
+		blocked: map[types.Address]struct{}{},
 	}
-	ret := func() {
-		t.sorted.Push(txn.tx)
+}
+
+// Pop returns the best priced transaction whose gas fits in the remaining gas, or nil
+// if there are none left. A transaction that does not fit is set aside along with
+// any later transaction of the same account, so that its nonce order is preserved
+func (p *PopSession) Pop(remainingGas uint64) *types.Transaction {
+	for {
+		txn := p.pool.sorted.Pop()
+		if txn == nil {
+			return nil
+		}
+		if _, ok := p.blocked[txn.from]; ok {
+			p.skipped = append(p.skipped, txn.tx)
+			continue
+		}
+		if txn.tx.Gas > remainingGas {
+			p.skipped = append(p.skipped, txn.tx)
+			p.blocked[txn.from] = struct{}{}
+			continue
+		}
+		p.pool.trackPopped(txn.tx)
+		return txn.tx
 	}
-	return txn.tx, ret
+}
+
+// Demote sets aside a popped transaction that could not be included (i.e. it
+// failed to be applied) and blocks the later transactions of the same account
+func (p *PopSession) Demote(txn *types.Transaction) {
+	p.pool.untrackPopped(txn)
+	p.skipped = append(p.skipped, txn)
+	p.blocked[txn.From] = struct{}{}
+}
+
+// Close returns the transactions set aside to the pool
+func (p *PopSession) Close() {
+	for _, txn := range p.skipped {
+		if err := p.pool.sorted.Push(txn); err != nil {
+			p.pool.logger.Error("failed to return txn", "err", err)
+		}
+	}
+	p.skipped = nil
+}
+
+// trackPopped records a txn handed out to a block builder
+func (t *TxPool) trackPopped(txn *types.Transaction) {
+	t.poppedLock.Lock()
+	defer t.poppedLock.Unlock()
+
+	t.popped[txn.Hash] = txn
+}
+
+// untrackPopped stops tracking a popped txn and reports whether it was tracked
+func (t *TxPool) untrackPopped(txn *types.Transaction) bool {
+	t.poppedLock.Lock()
+	defer t.poppedLock.Unlock()
+
+	if _, ok := t.popped[txn.Hash]; !ok {
+		return false
+	}
+	delete(t.popped, txn.Hash)
+	return true
+}
+
+// untrackStalePopped stops tracking the popped txns of the account
+// with a nonce lower than the given one and returns them
+func (t *TxPool) untrackStalePopped(addr types.Address, nonce uint64) []*types.Transaction {
+	t.poppedLock.Lock()
+	defer t.poppedLock.Unlock()
+
+	stale := []*types.Transaction{}
+	for hash, txn := range t.popped {
+		if txn.From == addr && txn.Nonce < nonce {
+			stale = append(stale, txn)
+			delete(t.popped, hash)
+		}
+	}
+	return stale
 }
 
 func (t *TxPool) ResetWithHeader(h *types.Header) {
@@ -223,12 +446,17 @@ func (t *TxPool) ResetWithHeader(h *types.Header) {
 
 // ProcessEvent processes the blockchain event and resets the txpool accordingly
 func (t *TxPool) ProcessEvent(evnt *blockchain.Event) {
+	if evnt.Type == blockchain.EventFork {
+		// the canonical chain did not change
+		return
+	}
+
 	addTxns := map[types.Hash]*types.Transaction{}
 	for _, evnt := range evnt.OldChain {
 		// reinject these transactions on the pool
 		block, ok := t.store.GetBlockByHash(evnt.Hash, true)
 		if !ok {
-			t.logger.Error("block not found on txn add", "hash", block.Hash())
+			t.logger.Error("block not found on txn add", "hash", evnt.Hash)
 		} else {
 			for _, txn := range block.Transactions {
 				addTxns[txn.Hash] = txn
@@ -241,7 +469,7 @@ func (t *TxPool) ProcessEvent(evnt *blockchain.Event) {
 		// remove these transactions from the pool
 		block, ok := t.store.GetBlockByHash(evnt.Hash, true)
 		if !ok {
-			t.logger.Error("block not found on txn del", "hash", block.Hash())
+			t.logger.Error("block not found on txn del", "hash", evnt.Hash)
 		} else {
 			for _, txn := range block.Transactions {
 				delete(addTxns, txn.Hash)
@@ -257,13 +485,107 @@ func (t *TxPool) ProcessEvent(evnt *blockchain.Event) {
 		}
 	}
 
-	// remove the mined transactions from the sorted list
+	// remove the mined transactions from the sorted list or
+	// from the ones popped by the block builders
 	for _, txn := range delTxns {
-		t.sorted.Delete(txn)
+		if t.sorted.Delete(txn) || t.untrackPopped(txn) {
+			t.emitEvent(EventIncluded, txn)
+		}
+	}
+
+	if len(evnt.NewChain) == 0 {
+		return
+	}
+
+	// prune the accounts with txns in the new or old blocks, since
+	// their nonces in the state have changed
+	accounts := map[types.Address]struct{}{}
+	for _, txn := range addTxns {
+		accounts[txn.From] = struct{}{}
+	}
+	for _, txn := range delTxns {
+		accounts[txn.From] = struct{}{}
+	}
+
+	stateRoot := evnt.Header().StateRoot
+
+	for addr := range accounts {
+		t.resetAccount(addr, t.store.GetNonce(stateRoot, addr), delTxns)
+	}
+}
+
+// resetAccount removes the txns of the account with a nonce lower than the one in the
+// state and demotes the promoted txns that are not executable anymore
+func (t *TxPool) resetAccount(addr types.Address, nonce uint64, included map[types.Hash]*types.Transaction) {
+	shard := t.accounts.shard(addr)
+
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	// the popped txns with a stale nonce were not included by these
+	// blocks, so they have been replaced by other txns of the account
+	for _, txn := range t.untrackStalePopped(addr, nonce) {
+		if _, ok := included[txn.Hash]; !ok {
+			t.emitEvent(EventDropped, txn)
+		}
+	}
+
+	txnsQueue, ok := shard.queue[addr]
+	if !ok {
+		return
+	}
+	defer t.trackQueuedBytes(txnsQueue)()
+
+	// demote the promoted txns to the queue and promote them again from the state nonce.
+	// It is done with the sorted list locked so that the block builders do not see
+	// the account without its promoted txns
+	var promoted, dropped []*types.Transaction
+	wasPromoted := map[types.Hash]struct{}{}
+	t.sorted.Reset(addr, func(txns []*types.Transaction) []*types.Transaction {
+		for _, txn := range txns {
+			txnsQueue.Push(txn)
+			wasPromoted[txn.Hash] = struct{}{}
+		}
+		txnsQueue.nextNonce = nonce
+
+		promoted, dropped = txnsQueue.Promote()
+		return promoted
+	})
+	for _, txn := range dropped {
+		if _, ok := included[txn.Hash]; ok {
+			t.emitEvent(EventIncluded, txn)
+		} else {
+			t.emitEvent(EventDropped, txn)
+		}
+	}
+	for _, txn := range promoted {
+		if _, ok := wasPromoted[txn.Hash]; !ok {
+			t.emitEvent(EventPromoted, txn)
+		}
+	}
+
+	if txnsQueue.txs.Len() == 0 && len(promoted) == 0 {
+		// nothing left for the account
+		delete(shard.queue, addr)
+	}
+}
+
+// trackQueuedBytes returns a function that updates the size of the queued
+// txns in the pool with the changes to the account queue since the call
+func (t *TxPool) trackQueuedBytes(txnsQueue *txQueue) func() {
+	before := txnsQueue.bytes
+	return func() {
+		// the difference wraps around if the queue shrank
+		atomic.AddUint64(&t.queuedBytes, txnsQueue.bytes-before)
 	}
 }
 
 func (t *TxPool) validateTx(tx *types.Transaction) error {
+	// the typed txns are only valid from the berlin fork
+	if tx.IsTyped() && t.forks != nil && !t.forks.IsBerlin(t.store.Header().Number+1) {
+		return fmt.Errorf("transaction type %s not supported before the berlin fork", tx.Type)
+	}
+
 	/*
 		if tx.Size() > 32*1024 {
 			return fmt.Errorf("oversize data")
@@ -278,6 +600,9 @@ func (t *TxPool) validateTx(tx *types.Transaction) error {
 type txQueue struct {
 	txs       txHeap
 	nextNonce uint64
+
+	// size of the txns in the queue
+	bytes uint64
 }
 
 func newTxQueue() *txQueue {
@@ -297,26 +622,62 @@ func (t *txQueue) Reset(txns ...*types.Transaction) {
 	t.nextNonce = lowestNonce
 }
 
-// Add adds a new tx into the queue
-func (t *txQueue) Add(tx *types.Transaction) {
+// Add adds a new tx into the queue. If there is already a transaction with the
+// same nonce, the new one replaces it only if it pays a higher gas price. It returns
+// the replaced transaction (if any) and whether the transaction was added
+func (t *txQueue) Add(tx *types.Transaction) (*types.Transaction, bool) {
+	for i, txn := range t.txs {
+		if txn.Nonce != tx.Nonce {
+			continue
+		}
+		if txn.Hash == tx.Hash || tx.GasPrice.Cmp(txn.GasPrice) <= 0 {
+			return nil, false
+		}
+		t.txs[i] = tx
+		t.bytes += tx.Size() - txn.Size()
+		return txn, true
+	}
+
 	t.Push(tx)
+	return nil, true
+}
+
+// addedBytes returns the size the queue grows by if the transaction is added,
+// net of the size of the transaction with the same nonce it replaces
+func (t *txQueue) addedBytes(tx *types.Transaction) uint64 {
+	if tx.Nonce < t.nextNonce {
+		// the transaction is dropped once it is promoted
+		return 0
+	}
+	for _, txn := range t.txs {
+		if txn.Nonce != tx.Nonce {
+			continue
+		}
+		if txn.Hash == tx.Hash || tx.Size() <= txn.Size() {
+			return 0
+		}
+		return tx.Size() - txn.Size()
+	}
+	return tx.Size()
 }
 
-// Promote promotes all the new valid transactions
-func (t *txQueue) Promote() []*types.Transaction {
+// Promote promotes all the new valid transactions. It also returns
+// the transactions dropped because their nonce is already stale
+func (t *txQueue) Promote() ([]*types.Transaction, []*types.Transaction) {
 	// Remove elements lower than nonce
+	dropped := []*types.Transaction{}
 	for {
 		tx := t.Peek()
 		if tx == nil || tx.Nonce >= t.nextNonce {
 			break
 		}
-		t.Pop()
+		dropped = append(dropped, t.Pop())
 	}
 
 	// Promote elements
 	tx := t.Peek()
 	if tx == nil || tx.Nonce != t.nextNonce {
-		return nil
+		return nil, dropped
 	}
 
 	promote := []*types.Transaction{}
@@ -331,13 +692,23 @@ func (t *txQueue) Promote() []*types.Transaction {
 		tx = tx2
 	}
 	if len(promote) == 0 {
-		return nil
+		return nil, dropped
 	}
 
 	lastTxn := promote[len(promote)-1]
 	t.nextNonce = lastTxn.Nonce + 1
 
-	return promote
+	return promote, dropped
+}
+
+// Contains reports whether the transaction is in the queue
+func (t *txQueue) Contains(tx *types.Transaction) bool {
+	for _, txn := range t.txs {
+		if txn.Hash == tx.Hash {
+			return true
+		}
+	}
+	return false
 }
 
 func (t *txQueue) Peek() *types.Transaction {
@@ -355,6 +726,7 @@ func (t *txQueue) Push(tx *types.Transaction) {
 	}
 
 	heap.Push(&t.txs, tx)
+	t.bytes += tx.Size()
 }
 
 func (t *txQueue) Pop() *types.Transaction {
@@ -363,7 +735,9 @@ func (t *txQueue) Pop() *types.Transaction {
 		return nil
 	}
 
-	return res.(*types.Transaction)
+	tx := res.(*types.Transaction)
+	t.bytes -= tx.Size()
+	return tx
 }
 
 // Nonce ordered heap
@@ -407,46 +781,65 @@ type pricedTx struct {
 	tx    *types.Transaction
 	from  types.Address
 	price *big.Int
+	size  uint64
 	index int
 }
 
 type txPriceHeap struct {
-	lock  sync.Mutex
-	index map[types.Hash]*pricedTx
-	heap  txPriceHeapImpl
+	// number and size of the txns in the heap, accessed atomically.
+	// They are the first fields to keep them 64-bit aligned
+	length uint64
+	bytes  uint64
+
+	// index of the txns in the heap by hash. Only written with the
+	// lock held but it can be read without it
+	index sync.Map
+
+	lock sync.Mutex
+	heap txPriceHeapImpl
 }
 
 func newTxPriceHeap() *txPriceHeap {
 	return &txPriceHeap{
-		index: make(map[types.Hash]*pricedTx),
-		heap:  make(txPriceHeapImpl, 0),
+		heap: make(txPriceHeapImpl, 0),
 	}
 }
 
 func (t *txPriceHeap) Length() uint64 {
-	t.lock.Lock()
-	defer t.lock.Unlock()
+	return atomic.LoadUint64(&t.length)
+}
 
-	return uint64(len(t.index))
+// Bytes returns the size of the txns in the heap
+func (t *txPriceHeap) Bytes() uint64 {
+	return atomic.LoadUint64(&t.bytes)
 }
 
-func (t *txPriceHeap) Delete(tx *types.Transaction) {
+// Delete removes the transaction from the heap and reports whether it was found
+func (t *txPriceHeap) Delete(tx *types.Transaction) bool {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
-	if item, ok := t.index[tx.Hash]; ok {
-		heap.Remove(&t.heap, item.index)
-		delete(t.index, tx.Hash)
+	if item, ok := t.index.Load(tx.Hash); ok {
+		pTx := item.(*pricedTx)
+		heap.Remove(&t.heap, pTx.index)
+		t.removeIndex(pTx)
+		return true
 	}
+	return false
 }
 
 func (t *txPriceHeap) Push(tx *types.Transaction) error {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
+	return t.push(tx)
+}
+
+// push adds the txn to the heap, the lock must be held
+func (t *txPriceHeap) push(tx *types.Transaction) error {
 	price := new(big.Int).Set(tx.GasPrice)
 
-	if _, ok := t.index[tx.Hash]; ok {
+	if _, ok := t.index.Load(tx.Hash); ok {
 		return fmt.Errorf("tx %s already exists", tx.Hash)
 	}
 
@@ -454,8 +847,11 @@ func (t *txPriceHeap) Push(tx *types.Transaction) error {
 		tx:    tx,
 		from:  tx.From,
 		price: price,
+		size:  tx.Size(),
 	}
-	t.index[tx.Hash] = pTx
+	t.index.Store(tx.Hash, pTx)
+	atomic.AddUint64(&t.length, 1)
+	atomic.AddUint64(&t.bytes, pTx.size)
 	heap.Push(&t.heap, pTx)
 	return nil
 }
@@ -464,16 +860,82 @@ func (t *txPriceHeap) Pop() *pricedTx {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
-	if len(t.index) == 0 {
+	if len(t.heap) == 0 {
 		return nil
 	}
 	tx := heap.Pop(&t.heap).(*pricedTx)
-	delete(t.index, tx.tx.Hash)
+	t.removeIndex(tx)
 	return tx
 }
 
+// removeIndex removes the txn from the index, the lock must be held
+func (t *txPriceHeap) removeIndex(tx *pricedTx) {
+	t.index.Delete(tx.tx.Hash)
+	atomic.AddUint64(&t.length, ^uint64(0))
+	atomic.AddUint64(&t.bytes, -tx.size)
+}
+
+// Prices returns a copy of the prices of the transactions in the heap
+func (t *txPriceHeap) Prices() []*big.Int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	prices := make([]*big.Int, 0, len(t.heap))
+	for _, tx := range t.heap {
+		prices = append(prices, new(big.Int).Set(tx.price))
+	}
+	return prices
+}
+
+// Txns returns the transactions in the heap from the given account sorted by nonce
+func (t *txPriceHeap) Txns(from types.Address) []*types.Transaction {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	txns := []*types.Transaction{}
+	for _, tx := range t.heap {
+		if tx.from == from {
+			txns = append(txns, tx.tx)
+		}
+	}
+	sort.Slice(txns, func(i, j int) bool {
+		return txns[i].Nonce < txns[j].Nonce
+	})
+	return txns
+}
+
+// Reset removes the transactions of the account from the heap and pushes back the ones
+// returned by fn, which receives the removed ones sorted by nonce. The heap stays locked
+// in between, so the account is never seen without its transactions
+func (t *txPriceHeap) Reset(from types.Address, fn func([]*types.Transaction) []*types.Transaction) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	removed := []*pricedTx{}
+	for _, tx := range t.heap {
+		if tx.from == from {
+			removed = append(removed, tx)
+		}
+	}
+	txns := make([]*types.Transaction, 0, len(removed))
+	for _, tx := range removed {
+		heap.Remove(&t.heap, tx.index)
+		t.removeIndex(tx)
+		txns = append(txns, tx.tx)
+	}
+	sort.Slice(txns, func(i, j int) bool {
+		return txns[i].Nonce < txns[j].Nonce
+	})
+
+	for _, txn := range fn(txns) {
+		// the txns of the account were removed, they cannot be duplicated
+		t.push(txn)
+	}
+}
+
+// Contains reports whether the txn is in the heap without taking the lock
 func (t *txPriceHeap) Contains(tx *types.Transaction) bool {
-	_, ok := t.index[tx.Hash]
+	_, ok := t.index.Load(tx.Hash)
 	return ok
 }
 
@@ -485,7 +947,8 @@ func (t txPriceHeapImpl) Less(i, j int) bool {
 	if t[i].from == t[j].from {
 		return t[i].tx.Nonce < t[j].tx.Nonce
 	}
-	return t[i].price.Cmp((t[j].price)) < 0
+	// the most priced transactions are popped first
+	return t[i].price.Cmp((t[j].price)) > 0
 }
 
 func (t txPriceHeapImpl) Swap(i, j int) {
@@ -5,6 +5,8 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/0xPolygon/minimal/blockchain"
+	"github.com/0xPolygon/minimal/chain"
 	"github.com/0xPolygon/minimal/crypto"
 	"github.com/0xPolygon/minimal/network"
 	"github.com/0xPolygon/minimal/types"
@@ -16,7 +18,7 @@ func TestMultipleTransactions(t *testing.T) {
 	// if we add the same transaction it should only be included once
 	pool, err := NewTxPool(hclog.NewNullLogger(), false, &mockStore{}, nil, nil)
 	assert.NoError(t, err)
-	pool.EnableDev()
+	pool.SetDevConfig(&DevConfig{})
 
 	from1 := types.Address{0x1}
 
@@ -28,7 +30,8 @@ func TestMultipleTransactions(t *testing.T) {
 	assert.NoError(t, pool.addImpl("", txn0))
 	assert.NoError(t, pool.addImpl("", txn0))
 
-	assert.Len(t, pool.queue[from1].txs, 1)
+	queue1, _ := pool.accounts.get(from1)
+	assert.Len(t, queue1.txs, 1)
 	assert.Equal(t, pool.Length(), uint64(0))
 
 	from2 := types.Address{0x2}
@@ -39,7 +42,8 @@ func TestMultipleTransactions(t *testing.T) {
 	assert.NoError(t, pool.addImpl("", txn1))
 	assert.NoError(t, pool.addImpl("", txn1))
 
-	assert.Len(t, pool.queue[from2].txs, 0)
+	queue2, _ := pool.accounts.get(from2)
+	assert.Len(t, queue2.txs, 0)
 	assert.Equal(t, pool.Length(), uint64(1))
 }
 
@@ -79,25 +83,68 @@ func TestBroadcast(t *testing.T) {
 	fmt.Println(pool1.Length())
 }
 
+func TestTxPool_TypedTxnsBeforeBerlin(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.SetDevConfig(&DevConfig{})
+
+	forks := *chain.AllForksEnabled
+	forks.Berlin = chain.NewFork(10)
+	pool.SetForks(&forks)
+
+	txn := &types.Transaction{
+		Type:     types.AccessListTx,
+		From:     types.Address{0x1},
+		GasPrice: big.NewInt(1),
+	}
+	assert.Error(t, pool.addImpl("", txn))
+
+	// the legacy txns are accepted
+	txn.Type = types.LegacyTx
+	assert.NoError(t, pool.addImpl("", txn))
+
+	// and the typed ones too once the berlin fork is enabled
+	forks.Berlin = chain.NewFork(0)
+	assert.NoError(t, pool.addImpl("", &types.Transaction{
+		Type:     types.AccessListTx,
+		From:     types.Address{0x1},
+		Nonce:    1,
+		GasPrice: big.NewInt(1),
+	}))
+	assert.Equal(t, uint64(2), pool.Length())
+}
+
 type mockStore struct {
+	storage map[types.Hash][]byte
+	nonces  map[types.Address]uint64
+	blocks  map[types.Hash]*types.Block
 }
 
 func (m *mockStore) GetNonce(root types.Hash, addr types.Address) uint64 {
-	return 0
+	return m.nonces[addr]
 }
 
-func (m *mockStore) GetBlockByHash(types.Hash, bool) (*types.Block, bool) {
-	return nil, false
+func (m *mockStore) GetBlockByHash(hash types.Hash, full bool) (*types.Block, bool) {
+	block, ok := m.blocks[hash]
+	return block, ok
 }
 
 func (m *mockStore) Header() *types.Header {
 	return &types.Header{}
 }
 
+func (m *mockStore) SubscribeEvents() blockchain.Subscription {
+	return blockchain.NewMockSubscription()
+}
+
+func (m *mockStore) GetStorage(root types.Hash, addr types.Address, slot types.Hash) []byte {
+	return m.storage[slot]
+}
+
 func TestTxnQueue_Promotion(t *testing.T) {
 	pool, err := NewTxPool(hclog.NewNullLogger(), false, &mockStore{}, nil, nil)
 	assert.NoError(t, err)
-	pool.EnableDev()
+	pool.SetDevConfig(&DevConfig{})
 
 	addr1 := types.Address{0x1}
 
@@ -121,3 +168,262 @@ func TestTxnQueue_Promotion(t *testing.T) {
 	assert.Equal(t, nonce, uint64(2))
 	assert.Equal(t, pool.Length(), uint64(2))
 }
+
+func TestTxPool_SubscribeTxnEvents(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.SetDevConfig(&DevConfig{})
+
+	sub := pool.SubscribeTxnEvents()
+	defer sub.Close()
+
+	addr1 := types.Address{0x1}
+
+	txn0 := &types.Transaction{
+		From:     addr1,
+		GasPrice: big.NewInt(1),
+	}
+	txn0.ComputeHash()
+	assert.NoError(t, pool.addImpl("", txn0))
+
+	// txn2 has the same nonce as txn1 and a higher price, it replaces txn1
+	txn1 := &types.Transaction{
+		From:     addr1,
+		Nonce:    2,
+		GasPrice: big.NewInt(1),
+	}
+	txn1.ComputeHash()
+	assert.NoError(t, pool.addImpl("", txn1))
+
+	txn2 := &types.Transaction{
+		From:     addr1,
+		Nonce:    2,
+		GasPrice: big.NewInt(2),
+	}
+	txn2.ComputeHash()
+	assert.NoError(t, pool.addImpl("", txn2))
+
+	expected := []*Event{
+		{Type: EventAdded, Hash: txn0.Hash, From: addr1},
+		{Type: EventPromoted, Hash: txn0.Hash, From: addr1},
+		{Type: EventAdded, Hash: txn1.Hash, From: addr1},
+		{Type: EventReplaced, Hash: txn1.Hash, From: addr1},
+		{Type: EventAdded, Hash: txn2.Hash, From: addr1},
+	}
+	for _, evnt := range expected {
+		assert.Equal(t, evnt, sub.GetEvent())
+	}
+}
+
+func TestTxPool_SubscribeTxnEventsFilter(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.SetDevConfig(&DevConfig{})
+
+	sub := pool.SubscribeTxnEvents(EventPromoted)
+
+	txn0 := &types.Transaction{
+		From:     types.Address{0x1},
+		GasPrice: big.NewInt(1),
+	}
+	txn0.ComputeHash()
+	assert.NoError(t, pool.addImpl("", txn0))
+
+	evnt := sub.GetEvent()
+	assert.Equal(t, EventPromoted, evnt.Type)
+	assert.Equal(t, txn0.Hash, evnt.Hash)
+
+	// once closed it does not block anymore
+	sub.Close()
+	assert.Nil(t, sub.GetEvent())
+}
+
+func TestPopSession(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.SetDevConfig(&DevConfig{})
+
+	addr1, addr2, addr3 := types.Address{0x1}, types.Address{0x2}, types.Address{0x3}
+
+	addTxn := func(from types.Address, nonce uint64, gas uint64, price int64) *types.Transaction {
+		txn := &types.Transaction{
+			From:     from,
+			Nonce:    nonce,
+			Gas:      gas,
+			GasPrice: big.NewInt(price),
+		}
+		assert.NoError(t, pool.addImpl("", txn))
+		return txn
+	}
+
+	// addr1 txns do not fit in the remaining gas
+	addTxn(addr1, 0, 200, 3)
+	addTxn(addr1, 1, 10, 3)
+	// the first addr2 txn fails to be applied
+	txn2 := addTxn(addr2, 0, 10, 2)
+	addTxn(addr2, 1, 10, 2)
+	txn3 := addTxn(addr3, 0, 10, 1)
+
+	session := pool.NewPopSession()
+
+	assert.Equal(t, txn2.Hash, session.Pop(100).Hash)
+	session.Demote(txn2)
+
+	// addr1 and addr2 are blocked, only the addr3 txn is left
+	assert.Equal(t, txn3.Hash, session.Pop(100).Hash)
+	assert.Nil(t, session.Pop(100))
+	assert.Equal(t, uint64(0), pool.Length())
+
+	// the txns set aside are returned to the pool
+	session.Close()
+	assert.Equal(t, uint64(4), pool.Length())
+
+	session = pool.NewPopSession()
+	defer session.Close()
+
+	for _, nonce := range []uint64{0, 1} {
+		txn := session.Pop(1000)
+		assert.Equal(t, addr1, txn.From)
+		assert.Equal(t, nonce, txn.Nonce)
+	}
+}
+
+func TestTxPool_ProcessEvent(t *testing.T) {
+	store := &mockStore{
+		nonces: map[types.Address]uint64{},
+		blocks: map[types.Hash]*types.Block{},
+	}
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, store, nil, nil)
+	assert.NoError(t, err)
+	pool.SetDevConfig(&DevConfig{})
+
+	addr1, addr2 := types.Address{0x1}, types.Address{0x2}
+
+	newTxn := func(from types.Address, nonce uint64) *types.Transaction {
+		txn := &types.Transaction{
+			From:     from,
+			Nonce:    nonce,
+			GasPrice: big.NewInt(1),
+		}
+		txn.ComputeHash()
+		return txn
+	}
+
+	txn0, txn1, txn3 := newTxn(addr1, 0), newTxn(addr1, 1), newTxn(addr1, 3)
+	assert.NoError(t, pool.addImpl("", txn0, txn1, txn3))
+	assert.Equal(t, uint64(2), pool.Length())
+
+	sub := pool.SubscribeTxnEvents(EventIncluded, EventDropped, EventPromoted)
+	defer sub.Close()
+
+	// the new block includes txn0 from the pool and a txn with the
+	// same nonce as txn1 that was not in the pool
+	other1 := newTxn(addr1, 1)
+	other1.Value = big.NewInt(1)
+	other1.ComputeHash()
+
+	header := &types.Header{Hash: types.Hash{0x1}}
+	store.blocks[header.Hash] = &types.Block{
+		Header:       header,
+		Transactions: []*types.Transaction{txn0, other1, newTxn(addr2, 0)},
+	}
+	store.nonces[addr1] = 2
+	store.nonces[addr2] = 1
+
+	pool.ProcessEvent(&blockchain.Event{
+		NewChain: []*types.Header{header},
+	})
+
+	// txn1 is stale and txn3 is not executable yet
+	assert.Equal(t, uint64(0), pool.Length())
+	nonce, _ := pool.GetNonce(addr1)
+	assert.Equal(t, uint64(2), nonce)
+
+	evnt := sub.GetEvent()
+	assert.Equal(t, EventIncluded, evnt.Type)
+	assert.Equal(t, txn0.Hash, evnt.Hash)
+
+	evnt = sub.GetEvent()
+	assert.Equal(t, EventDropped, evnt.Type)
+	assert.Equal(t, txn1.Hash, evnt.Hash)
+
+	// once the nonce gap is filled txn3 is promoted again
+	assert.NoError(t, pool.addImpl("", newTxn(addr1, 2)))
+	assert.Equal(t, uint64(2), pool.Length())
+}
+
+func TestTxPool_ProcessEventMissingBlock(t *testing.T) {
+	store := &mockStore{
+		nonces: map[types.Address]uint64{},
+		blocks: map[types.Hash]*types.Block{},
+	}
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, store, nil, nil)
+	assert.NoError(t, err)
+
+	// the blocks of the event that are not found are skipped
+	assert.NotPanics(t, func() {
+		pool.ProcessEvent(&blockchain.Event{
+			OldChain: []*types.Header{{Hash: types.Hash{0x1}}},
+			NewChain: []*types.Header{{Hash: types.Hash{0x2}}},
+		})
+	})
+}
+
+func TestTxPool_ProcessEventPopped(t *testing.T) {
+	store := &mockStore{
+		nonces: map[types.Address]uint64{},
+		blocks: map[types.Hash]*types.Block{},
+	}
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, store, nil, nil)
+	assert.NoError(t, err)
+	pool.SetDevConfig(&DevConfig{})
+
+	addr1 := types.Address{0x1}
+
+	newTxn := func(nonce uint64, price int64) *types.Transaction {
+		txn := &types.Transaction{
+			From:     addr1,
+			Nonce:    nonce,
+			GasPrice: big.NewInt(price),
+		}
+		txn.ComputeHash()
+		return txn
+	}
+
+	txn0, txn1 := newTxn(0, 1), newTxn(1, 1)
+	assert.NoError(t, pool.addImpl("", txn0, txn1))
+
+	sub := pool.SubscribeTxnEvents(EventIncluded, EventDropped)
+	defer sub.Close()
+
+	// both txns are handed out to the block builder
+	session := pool.NewPopSession()
+	assert.Equal(t, txn0.Hash, session.Pop(100).Hash)
+	assert.Equal(t, txn1.Hash, session.Pop(100).Hash)
+	session.Close()
+	assert.Equal(t, uint64(0), pool.Length())
+
+	// the new block includes txn0 and a replacement of txn1
+	other1 := newTxn(1, 2)
+
+	header := &types.Header{Hash: types.Hash{0x1}}
+	store.blocks[header.Hash] = &types.Block{
+		Header:       header,
+		Transactions: []*types.Transaction{txn0, other1},
+	}
+	store.nonces[addr1] = 2
+
+	pool.ProcessEvent(&blockchain.Event{
+		NewChain: []*types.Header{header},
+	})
+
+	evnt := sub.GetEvent()
+	assert.Equal(t, EventIncluded, evnt.Type)
+	assert.Equal(t, txn0.Hash, evnt.Hash)
+
+	evnt = sub.GetEvent()
+	assert.Equal(t, EventDropped, evnt.Type)
+	assert.Equal(t, txn1.Hash, evnt.Hash)
+
+	assert.Len(t, pool.popped, 0)
+}
@@ -11,28 +11,18 @@ var arenaPool fastrlp.ArenaPool
 
 // CalculateReceiptsRoot calculates the root of a list of receipts
 func CalculateReceiptsRoot(receipts []*types.Receipt) types.Hash {
-	ar := arenaPool.Get()
-
-	res := calculateRootWithRlp(len(receipts), func(i int) *fastrlp.Value {
-		ar.Reset()
-		return receipts[i].MarshalRLPWith(ar)
+	// typed receipts are stored in the trie with their envelope
+	return CalculateRoot(len(receipts), func(i int) []byte {
+		return receipts[i].MarshalRLPTo(nil)
 	})
-
-	arenaPool.Put(ar)
-	return res
 }
 
 // CalculateTransactionsRoot calculates the root of a list of transactions
 func CalculateTransactionsRoot(transactions []*types.Transaction) types.Hash {
-	ar := arenaPool.Get()
-
-	res := calculateRootWithRlp(len(transactions), func(i int) *fastrlp.Value {
-		ar.Reset()
-		return transactions[i].MarshalRLPWith(ar)
+	// typed transactions are stored in the trie with their envelope
+	return CalculateRoot(len(transactions), func(i int) []byte {
+		return transactions[i].MarshalRLPTo(nil)
 	})
-
-	arenaPool.Put(ar)
-	return res
 }
 
 // CalculateUncleRoot calculates the root of a list of uncles
@@ -53,13 +43,6 @@ func CalculateUncleRoot(uncles []*types.Header) types.Hash {
 	return types.BytesToHash(root)
 }
 
-func calculateRootWithRlp(num int, h func(indx int) *fastrlp.Value) types.Hash {
-	hF := func(indx int) []byte {
-		return h(indx).MarshalTo(nil)
-	}
-	return CalculateRoot(num, hF)
-}
-
 // CalculateRoot calculates a root with a callback
 func CalculateRoot(num int, h func(indx int) []byte) types.Hash {
 	if num == 0 {
@@ -1,7 +0,0 @@
-package types
-
-import "math/big"
-
-var (
-	GasCap = big.NewInt(5000000)
-)
@@ -26,6 +26,10 @@ type Receipt struct {
 	Logs              []*Log
 	Status            *ReceiptStatus
 
+	// TxType is the type of the transaction, typed receipts are
+	// encoded as an envelope (type || payload)
+	TxType TxType
+
 	// context fields
 	GasUsed         uint64
 	ContractAddress Address
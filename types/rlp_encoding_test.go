@@ -1,6 +1,7 @@
 package types
 
 import (
+	"math/big"
 	"reflect"
 	"testing"
 
@@ -42,3 +43,121 @@ func TestRLPUnmarshal_Header_ComputeHash(t *testing.T) {
 	assert.NoError(t, h2.UnmarshalRLP(data))
 	assert.Equal(t, h.Hash, h2.Hash)
 }
+
+func TestRLPEncoding_TypedTransaction(t *testing.T) {
+	addr := Address{0x1}
+
+	cases := []*Transaction{
+		{
+			Type:     AccessListTx,
+			ChainID:  big.NewInt(100),
+			Nonce:    1,
+			GasPrice: big.NewInt(10),
+			Gas:      21000,
+			To:       &addr,
+			Value:    big.NewInt(1),
+			Input:    []byte{0x1, 0x2},
+			AccessList: AccessList{
+				{Address: addr, StorageKeys: []Hash{{0x1}, {0x2}}},
+			},
+			V: 1,
+			R: []byte{0x1},
+			S: []byte{0x2},
+		},
+		{
+			Type:      DynamicFeeTx,
+			ChainID:   big.NewInt(100),
+			Nonce:     2,
+			GasTipCap: big.NewInt(2),
+			GasFeeCap: big.NewInt(20),
+			Gas:       21000,
+			Value:     big.NewInt(1),
+			Input:     []byte{},
+			R:         []byte{0x1},
+			S:         []byte{0x2},
+		},
+	}
+	for _, txn := range cases {
+		buf := txn.MarshalRLP()
+		assert.Equal(t, byte(txn.Type), buf[0])
+
+		txn2 := new(Transaction)
+		assert.NoError(t, txn2.UnmarshalRLP(buf))
+		assert.Equal(t, txn.Type, txn2.Type)
+		assert.Equal(t, txn.AccessList, txn2.AccessList)
+		assert.Equal(t, buf, txn2.MarshalRLP())
+
+		// the hash is computed over the envelope
+		assert.Equal(t, txn.ComputeHash().Hash, txn2.ComputeHash().Hash)
+	}
+}
+
+func TestRLPEncoding_TypedTransactionInBlock(t *testing.T) {
+	b := &Block{
+		Header: &Header{},
+		Transactions: []*Transaction{
+			{
+				Nonce:    1,
+				GasPrice: big.NewInt(1),
+				Value:    big.NewInt(1),
+			},
+			{
+				Type:      DynamicFeeTx,
+				ChainID:   big.NewInt(1),
+				Nonce:     2,
+				GasTipCap: big.NewInt(1),
+				GasFeeCap: big.NewInt(2),
+				Value:     big.NewInt(1),
+			},
+		},
+	}
+
+	b2 := new(Block)
+	assert.NoError(t, b2.UnmarshalRLP(b.MarshalRLP()))
+	assert.Len(t, b2.Transactions, 2)
+	assert.Equal(t, LegacyTx, b2.Transactions[0].Type)
+	assert.Equal(t, DynamicFeeTx, b2.Transactions[1].Type)
+}
+
+func TestRLPEncoding_TypedReceipt(t *testing.T) {
+	r := &Receipt{
+		TxType:            AccessListTx,
+		CumulativeGasUsed: 100,
+	}
+	r.SetStatus(ReceiptSuccess)
+
+	buf := r.MarshalRLP()
+	assert.Equal(t, byte(AccessListTx), buf[0])
+
+	r2 := new(Receipt)
+	assert.NoError(t, r2.UnmarshalRLP(buf))
+	assert.Equal(t, AccessListTx, r2.TxType)
+	assert.Equal(t, buf, r2.MarshalRLP())
+
+	// typed receipts can be stored alongside legacy ones
+	rr := Receipts{r, {CumulativeGasUsed: 200}}
+	var rr2 Receipts
+	assert.NoError(t, rr2.UnmarshalRLP(rr.MarshalRLPTo(nil)))
+	assert.Len(t, rr2, 2)
+	assert.Equal(t, AccessListTx, rr2[0].TxType)
+	assert.Equal(t, LegacyTx, rr2[1].TxType)
+}
+
+func TestTransaction_Size(t *testing.T) {
+	txn := &Transaction{
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(1),
+		Input:    make([]byte, 100),
+	}
+	size := uint64(len(txn.MarshalRLP()))
+	assert.Equal(t, size, txn.Size())
+
+	// the size is cached once computed
+	assert.Equal(t, size, txn.size)
+
+	// but not by the copies, which might be modified
+	cpy := txn.Copy()
+	cpy.Input = make([]byte, 200)
+	assert.Equal(t, uint64(len(cpy.MarshalRLP())), cpy.Size())
+	assert.Equal(t, size, txn.Size())
+}
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"math/big"
+
 	"github.com/umbracle/fastrlp"
 )
 
@@ -102,11 +104,24 @@ func (r *Receipt) MarshalRLP() []byte {
 }
 
 func (r *Receipt) MarshalRLPTo(dst []byte) []byte {
+	if r.TxType != LegacyTx {
+		// typed receipt envelope
+		dst = append(dst, byte(r.TxType))
+		return MarshalRLPTo(r.marshalPayloadWith, dst)
+	}
 	return MarshalRLPTo(r.MarshalRLPWith, dst)
 }
 
-// MarshalRLPWith marshals a receipt with a specific fastrlp.Arena
+// MarshalRLPWith marshals a receipt with a specific fastrlp.Arena.
+// A typed receipt is marshaled as an RLP string with its envelope
 func (r *Receipt) MarshalRLPWith(a *fastrlp.Arena) *fastrlp.Value {
+	if r.TxType != LegacyTx {
+		return a.NewCopyBytes(r.MarshalRLPTo(nil))
+	}
+	return r.marshalPayloadWith(a)
+}
+
+func (r *Receipt) marshalPayloadWith(a *fastrlp.Arena) *fastrlp.Value {
 	vv := a.NewArray()
 	if r.Status != nil {
 		vv.Set(a.NewUint(uint64(*r.Status)))
@@ -149,12 +164,22 @@ func (t *Transaction) MarshalRLP() []byte {
 	return t.MarshalRLPTo(nil)
 }
 
+// MarshalRLPTo marshals the transaction to its canonical encoding. Legacy transactions
+// are encoded as an RLP list and typed transactions as an envelope (type || payload)
 func (t *Transaction) MarshalRLPTo(dst []byte) []byte {
+	if t.IsTyped() {
+		return t.marshalEnvelopeTo(dst)
+	}
 	return MarshalRLPTo(t.MarshalRLPWith, dst)
 }
 
-// MarshalRLPWith marshals the transaction to RLP with a specific fastrlp.Arena
+// MarshalRLPWith marshals the transaction to RLP with a specific fastrlp.Arena.
+// A typed transaction is marshaled as an RLP string with its envelope
 func (t *Transaction) MarshalRLPWith(arena *fastrlp.Arena) *fastrlp.Value {
+	if t.IsTyped() {
+		return arena.NewCopyBytes(t.marshalEnvelopeTo(nil))
+	}
+
 	vv := arena.NewArray()
 
 	vv.Set(arena.NewUint(t.Nonce))
@@ -178,3 +203,82 @@ func (t *Transaction) MarshalRLPWith(arena *fastrlp.Arena) *fastrlp.Value {
 
 	return vv
 }
+
+func (t *Transaction) marshalEnvelopeTo(dst []byte) []byte {
+	ar := fastrlp.DefaultArenaPool.Get()
+
+	dst = append(dst, byte(t.Type))
+	dst = t.MarshalTypedPayloadWith(ar, true).MarshalTo(dst)
+
+	fastrlp.DefaultArenaPool.Put(ar)
+	return dst
+}
+
+// MarshalTypedPayloadWith marshals the payload of a typed transaction with a specific
+// fastrlp.Arena. The signature values are only included if signed is true
+func (t *Transaction) MarshalTypedPayloadWith(arena *fastrlp.Arena, signed bool) *fastrlp.Value {
+	vv := arena.NewArray()
+
+	vv.Set(arena.NewBigInt(bigOrZero(t.ChainID)))
+	vv.Set(arena.NewUint(t.Nonce))
+
+	if t.Type == DynamicFeeTx {
+		vv.Set(arena.NewBigInt(bigOrZero(t.GasTipCap)))
+		vv.Set(arena.NewBigInt(bigOrZero(t.GasFeeCap)))
+	} else {
+		vv.Set(arena.NewBigInt(bigOrZero(t.GasPrice)))
+	}
+	vv.Set(arena.NewUint(t.Gas))
+
+	// Address may be empty
+	if t.To != nil {
+		vv.Set(arena.NewBytes((*t.To).Bytes()))
+	} else {
+		vv.Set(arena.NewNull())
+	}
+
+	vv.Set(arena.NewBigInt(bigOrZero(t.Value)))
+	vv.Set(arena.NewCopyBytes(t.Input))
+	vv.Set(t.AccessList.MarshalRLPWith(arena))
+
+	if signed {
+		// signature values, v is the y parity of the signature
+		vv.Set(arena.NewUint(uint64(t.V)))
+		vv.Set(arena.NewCopyBytes(t.R))
+		vv.Set(arena.NewCopyBytes(t.S))
+	}
+
+	return vv
+}
+
+// MarshalRLPWith marshals the access list to RLP with a specific fastrlp.Arena
+func (a AccessList) MarshalRLPWith(arena *fastrlp.Arena) *fastrlp.Value {
+	if len(a) == 0 {
+		return arena.NewNullArray()
+	}
+
+	vv := arena.NewArray()
+	for _, tuple := range a {
+		v := arena.NewArray()
+		v.Set(arena.NewCopyBytes(tuple.Address.Bytes()))
+
+		if len(tuple.StorageKeys) == 0 {
+			v.Set(arena.NewNullArray())
+		} else {
+			keys := arena.NewArray()
+			for _, key := range tuple.StorageKeys {
+				keys.Set(arena.NewCopyBytes(key.Bytes()))
+			}
+			v.Set(keys)
+		}
+		vv.Set(v)
+	}
+	return vv
+}
+
+func bigOrZero(b *big.Int) *big.Int {
+	if b == nil {
+		return big.NewInt(0)
+	}
+	return b
+}
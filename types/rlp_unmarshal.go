@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/0xPolygon/minimal/helper/keccak"
 	"github.com/umbracle/fastrlp"
 )
 
@@ -179,11 +180,35 @@ func (r *Receipts) UnmarshalRLPFrom(p *fastrlp.Parser, v *fastrlp.Value) error {
 }
 
 func (r *Receipt) UnmarshalRLP(input []byte) error {
+	if len(input) != 0 && input[0] < 0xc0 {
+		// typed receipt envelope
+		return r.unmarshalEnvelope(input)
+	}
 	return UnmarshalRlp(r.UnmarshalRLPFrom, input)
 }
 
+func (r *Receipt) unmarshalEnvelope(input []byte) error {
+	if len(input) < 2 {
+		return fmt.Errorf("typed receipt too short")
+	}
+	r.TxType = TxType(input[0])
+	return UnmarshalRlp(r.unmarshalPayloadFrom, input[1:])
+}
+
 // UnmarshalRLP unmarshals a Receipt in RLP format
 func (r *Receipt) UnmarshalRLPFrom(p *fastrlp.Parser, v *fastrlp.Value) error {
+	if v.Type() == fastrlp.TypeBytes {
+		// typed receipt envelope as an RLP string
+		buf, err := v.Bytes()
+		if err != nil {
+			return err
+		}
+		return r.unmarshalEnvelope(buf)
+	}
+	return r.unmarshalPayloadFrom(p, v)
+}
+
+func (r *Receipt) unmarshalPayloadFrom(p *fastrlp.Parser, v *fastrlp.Value) error {
 	elems, err := v.GetElems()
 	if err != nil {
 		return err
@@ -264,11 +289,24 @@ func (l *Log) UnmarshalRLPFrom(p *fastrlp.Parser, v *fastrlp.Value) error {
 }
 
 func (t *Transaction) UnmarshalRLP(input []byte) error {
+	if len(input) != 0 && input[0] < 0xc0 {
+		// typed transaction envelope
+		return t.unmarshalEnvelope(input)
+	}
 	return UnmarshalRlp(t.UnmarshalRLPFrom, input)
 }
 
 // UnmarshalRLP unmarshals a Transaction in RLP format
 func (t *Transaction) UnmarshalRLPFrom(p *fastrlp.Parser, v *fastrlp.Value) error {
+	if v.Type() == fastrlp.TypeBytes {
+		// typed transaction envelope as an RLP string
+		buf, err := v.Bytes()
+		if err != nil {
+			return err
+		}
+		return t.unmarshalEnvelope(buf)
+	}
+
 	elems, err := v.GetElems()
 	if err != nil {
 		return err
@@ -277,6 +315,7 @@ func (t *Transaction) UnmarshalRLPFrom(p *fastrlp.Parser, v *fastrlp.Value) erro
 		return fmt.Errorf("not enough elements to decode transaction, expected 9 but found %d", num)
 	}
 
+	t.Type = LegacyTx
 	p.Hash(t.Hash[:0], v)
 
 	// nonce
@@ -331,3 +370,162 @@ func (t *Transaction) UnmarshalRLPFrom(p *fastrlp.Parser, v *fastrlp.Value) erro
 	}
 	return nil
 }
+
+// unmarshalEnvelope unmarshals a typed transaction envelope (type || payload)
+func (t *Transaction) unmarshalEnvelope(input []byte) error {
+	if len(input) < 2 {
+		return fmt.Errorf("typed transaction too short")
+	}
+
+	typ := TxType(input[0])
+	switch typ {
+	case AccessListTx, DynamicFeeTx:
+	default:
+		return fmt.Errorf("transaction type %d not supported", typ)
+	}
+	t.Type = typ
+
+	err := UnmarshalRlp(t.unmarshalTypedPayloadFrom, input[1:])
+	if err != nil {
+		return err
+	}
+
+	copy(t.Hash[:], keccak.Keccak256(nil, input))
+	return nil
+}
+
+func (t *Transaction) unmarshalTypedPayloadFrom(p *fastrlp.Parser, v *fastrlp.Value) error {
+	elems, err := v.GetElems()
+	if err != nil {
+		return err
+	}
+
+	expected := 11
+	if t.Type == DynamicFeeTx {
+		expected = 12
+	}
+	if num := len(elems); num != expected {
+		return fmt.Errorf("not enough elements to decode transaction, expected %d but found %d", expected, num)
+	}
+
+	getBigInt := func() (*big.Int, error) {
+		b := new(big.Int)
+		if err := elems[0].GetBigInt(b); err != nil {
+			return nil, err
+		}
+		elems = elems[1:]
+		return b, nil
+	}
+
+	// chainID
+	if t.ChainID, err = getBigInt(); err != nil {
+		return err
+	}
+	// nonce
+	if t.Nonce, err = elems[0].GetUint64(); err != nil {
+		return err
+	}
+	elems = elems[1:]
+
+	if t.Type == DynamicFeeTx {
+		// gasTipCap
+		if t.GasTipCap, err = getBigInt(); err != nil {
+			return err
+		}
+		// gasFeeCap
+		if t.GasFeeCap, err = getBigInt(); err != nil {
+			return err
+		}
+		t.GasPrice = new(big.Int).Set(t.GasFeeCap)
+	} else {
+		// gasPrice
+		if t.GasPrice, err = getBigInt(); err != nil {
+			return err
+		}
+	}
+	// gas
+	if t.Gas, err = elems[0].GetUint64(); err != nil {
+		return err
+	}
+	// to
+	vv, err := elems[1].Bytes()
+	if err != nil {
+		return err
+	}
+	if len(vv) == 20 {
+		addr := BytesToAddress(vv)
+		t.To = &addr
+	} else {
+		t.To = nil
+	}
+	elems = elems[2:]
+
+	// value
+	if t.Value, err = getBigInt(); err != nil {
+		return err
+	}
+	// input
+	if t.Input, err = elems[0].GetBytes(t.Input[:0]); err != nil {
+		return err
+	}
+	// access list
+	if err := t.AccessList.unmarshalRLPFrom(p, elems[1]); err != nil {
+		return err
+	}
+	// v (y parity)
+	v2, err := elems[2].GetUint64()
+	if err != nil {
+		return err
+	}
+	t.V = byte(v2)
+	// R
+	if t.R, err = elems[3].GetBytes(t.R[:0]); err != nil {
+		return err
+	}
+	// S
+	if t.S, err = elems[4].GetBytes(t.S[:0]); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a *AccessList) unmarshalRLPFrom(p *fastrlp.Parser, v *fastrlp.Value) error {
+	elems, err := v.GetElems()
+	if err != nil {
+		return err
+	}
+	if len(elems) == 0 {
+		*a = nil
+		return nil
+	}
+
+	list := make(AccessList, len(elems))
+	for indx, elem := range elems {
+		tuple, err := elem.GetElems()
+		if err != nil {
+			return err
+		}
+		if len(tuple) != 2 {
+			return fmt.Errorf("expected 2 elements in access tuple but found %d", len(tuple))
+		}
+		if err := tuple[0].GetAddr(list[indx].Address[:]); err != nil {
+			return err
+		}
+
+		keys, err := tuple[1].GetElems()
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		list[indx].StorageKeys = make([]Hash, len(keys))
+		for i, key := range keys {
+			if err := key.GetHash(list[indx].StorageKeys[i][:]); err != nil {
+				return err
+			}
+		}
+	}
+	*a = list
+	return nil
+}
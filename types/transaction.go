@@ -2,10 +2,66 @@ package types
 
 import (
 	"math/big"
+	"sync/atomic"
 
 	"github.com/0xPolygon/minimal/helper/keccak"
 )
 
+// TxType is the type of the transaction envelope (EIP-2718)
+type TxType byte
+
+const (
+	LegacyTx     TxType = 0x0
+	AccessListTx TxType = 0x1
+	DynamicFeeTx TxType = 0x2
+)
+
+func (t TxType) String() string {
+	switch t {
+	case LegacyTx:
+		return "legacy"
+	case AccessListTx:
+		return "accessList"
+	case DynamicFeeTx:
+		return "dynamicFee"
+	default:
+		return "unknown"
+	}
+}
+
+// AccessTuple is an address and the storage keys the transaction accesses
+type AccessTuple struct {
+	Address     Address `json:"address"`
+	StorageKeys []Hash  `json:"storageKeys"`
+}
+
+// AccessList is the list of addresses and storage keys accessed by a
+// transaction (EIP-2930)
+type AccessList []AccessTuple
+
+// StorageKeys returns the total number of storage keys in the access list
+func (a AccessList) StorageKeys() int {
+	num := 0
+	for _, tuple := range a {
+		num += len(tuple.StorageKeys)
+	}
+	return num
+}
+
+func (a AccessList) Copy() AccessList {
+	if a == nil {
+		return nil
+	}
+	res := make(AccessList, len(a))
+	for i, tuple := range a {
+		res[i] = AccessTuple{
+			Address:     tuple.Address,
+			StorageKeys: append([]Hash{}, tuple.StorageKeys...),
+		}
+	}
+	return res
+}
+
 type Transaction struct {
 	Nonce    uint64
 	GasPrice *big.Int
@@ -18,14 +74,51 @@ type Transaction struct {
 	S        []byte
 	Hash     Hash
 	From     Address
+
+	// Type is the type of the envelope, legacy transactions have type 0
+	Type TxType
+
+	// typed transactions fields, the chain id is part of the payload
+	ChainID    *big.Int
+	AccessList AccessList
+
+	// dynamic fee transactions fields. Until there is a base fee the
+	// fee cap is used as the gas price of the transaction
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+
+	// size caches the size of the encoding, computed on the first call to Size
+	size uint64
 }
 
 func (t *Transaction) IsContractCreation() bool {
 	return t.To == nil
 }
 
+// Size returns the size of the transaction in its canonical encoding. It is only
+// computed once, so the transaction must not be modified after the first call
+func (t *Transaction) Size() uint64 {
+	if size := atomic.LoadUint64(&t.size); size != 0 {
+		return size
+	}
+	size := uint64(len(t.MarshalRLP()))
+	atomic.StoreUint64(&t.size, size)
+	return size
+}
+
+// IsTyped returns true if the transaction is a typed envelope (EIP-2718)
+func (t *Transaction) IsTyped() bool {
+	return t.Type != LegacyTx
+}
+
 // ComputeHash computes the hash of the transaction
 func (t *Transaction) ComputeHash() *Transaction {
+	if t.IsTyped() {
+		// the hash of a typed transaction is the hash of its envelope
+		copy(t.Hash[:], keccak.Keccak256(nil, t.MarshalRLP()))
+		return t
+	}
+
 	ar := marshalArenaPool.Get()
 	hash := keccak.DefaultKeccakPool.Get()
 
@@ -54,5 +147,19 @@ func (t *Transaction) Copy() *Transaction {
 
 	tt.Input = make([]byte, len(t.Input))
 	copy(tt.Input[:], t.Input[:])
+
+	if t.ChainID != nil {
+		tt.ChainID = new(big.Int).Set(t.ChainID)
+	}
+	if t.GasTipCap != nil {
+		tt.GasTipCap = new(big.Int).Set(t.GasTipCap)
+	}
+	if t.GasFeeCap != nil {
+		tt.GasFeeCap = new(big.Int).Set(t.GasFeeCap)
+	}
+	tt.AccessList = t.AccessList.Copy()
+
+	// the copy is usually modified, i.e. to simulate it with another gas
+	tt.size = 0
 	return tt
 }
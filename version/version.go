@@ -28,3 +28,9 @@ func GetVersion() string {
 
 	return version
 }
+
+// ClientVersion returns the name and the version of the client, as reported
+// to the peers and by web3_clientVersion
+func ClientVersion() string {
+	return fmt.Sprintf("polygon-sdk [%s]", GetVersion())
+}